@@ -183,6 +183,9 @@ func main() {
 		logrus.Fatalf("Failed to get config agent: %v", err)
 	}
 	go func() { logrus.Fatal(<-configErrCh) }()
+	if err := configAgent.AddIndex(registryserver.DependentsIndexName, registryserver.IndexConfigsByRegistryUsage); err != nil {
+		logrus.WithError(err).Fatal("Failed to add the registry usage index to the config agent")
+	}
 
 	registryErrCh := make(chan error)
 	registryAgent, err := agents.NewRegistryAgent(o.registryPath, registryErrCh, agents.WithRegistryMetrics(configresolverMetrics.ErrorRate), agents.WithRegistryFlat(o.flatRegistry), registryAgentOption)
@@ -205,6 +208,17 @@ func main() {
 		l("resolve"),
 		l("configGeneration"),
 		l("registryGeneration"),
+		l("v1",
+			l("config"),
+			l("configs",
+				l("batch"),
+				l("merge"),
+			),
+			l("resolve"),
+			l("generation"),
+			l("dependents"),
+			l("openapi.json"),
+		),
 	))
 
 	uisimplifier := simplifypath.NewSimplifier(l("", // shadow element mimicing the root
@@ -214,11 +228,14 @@ func main() {
 		l("reference"),
 		l("chain"),
 		l("workflow"),
+		l("dependents"),
 	))
 	handler := metrics.TraceHandler(simplifier, configresolverMetrics.HTTPRequestDuration, configresolverMetrics.HTTPResponseSize)
 	uihandler := metrics.TraceHandler(uisimplifier, configresolverMetrics.HTTPRequestDuration, configresolverMetrics.HTTPResponseSize)
 	// add handler func for incorrect paths as well; can help with identifying errors/404s caused by incorrect paths
 	http.HandleFunc("/", handler(http.HandlerFunc(http.NotFound)).ServeHTTP)
+	http.Handle(registryserver.V1Prefix+"/", handler(registryserver.NewV1Handler(configAgent, registryAgent, configAgent, registryAgent, configresolverMetrics)))
+	http.HandleFunc(registryserver.V1DependentsPath, handler(registryserver.DependentsHandler(registryAgent, configAgent, configresolverMetrics)).ServeHTTP)
 	http.HandleFunc("/config", handler(registryserver.ResolveConfig(configAgent, registryAgent, configresolverMetrics)).ServeHTTP)
 	//TODO(sgoeddel): this is deprecated, mergeConfigsWithInjectedTest should be used instead
 	http.HandleFunc("/configWithInjectedTest", handler(registryserver.ResolveConfigWithInjectedTest(configAgent, registryAgent, configresolverMetrics)).ServeHTTP)
@@ -22,6 +22,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bombsimon/logrusr/v3"
@@ -48,6 +49,7 @@ import (
 	"k8s.io/klog/v2"
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config/secret"
+	"k8s.io/test-infra/prow/github"
 	"k8s.io/test-infra/prow/logrusutil"
 	"k8s.io/test-infra/prow/pod-utils/downwardapi"
 	"k8s.io/test-infra/prow/version"
@@ -63,7 +65,6 @@ import (
 	projectapi "github.com/openshift/api/project/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	templateapi "github.com/openshift/api/template/v1"
-	buildclientset "github.com/openshift/client-go/build/clientset/versioned/typed/build/v1"
 	projectclientset "github.com/openshift/client-go/project/clientset/versioned"
 	templatescheme "github.com/openshift/client-go/template/clientset/versioned/scheme"
 	templateclientset "github.com/openshift/client-go/template/clientset/versioned/typed/template/v1"
@@ -72,15 +73,19 @@ import (
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/api/nsttl"
 	"github.com/openshift/ci-tools/pkg/defaults"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/interrupt"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/lease"
 	"github.com/openshift/ci-tools/pkg/load"
 	"github.com/openshift/ci-tools/pkg/registry"
 	"github.com/openshift/ci-tools/pkg/registry/server"
+	"github.com/openshift/ci-tools/pkg/resourceusage"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/dryrun"
+	"github.com/openshift/ci-tools/pkg/steps/kubernetesbackend"
 	"github.com/openshift/ci-tools/pkg/util"
 	"github.com/openshift/ci-tools/pkg/util/gzip"
 	"github.com/openshift/ci-tools/pkg/validation"
@@ -171,6 +176,9 @@ map.
 
 const (
 	leaseAcquireTimeout = 120 * time.Minute
+
+	buildBackendOpenShift  = "openshift"
+	buildBackendKubernetes = "kubernetes"
 )
 
 var (
@@ -180,6 +188,9 @@ var (
 	configResolverAddress = api.URLForService(api.ServiceConfig)
 )
 
+// eventsFile is the name of the file in $ARTIFACT_DIR that holds the event stream
+const eventsFile = "ci-operator-events.jsonl"
+
 // CustomProwMetadata the name of the custom prow metadata file that's expected to be found in the artifacts directory.
 const CustomProwMetadata = "custom-prow-metadata.json"
 
@@ -250,6 +261,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	opt.events = opt.newEventRecorder()
+	opt.failureLogs = results.NewFailureLogs()
 	if errs := opt.Run(); len(errs) > 0 {
 		var defaulted []error
 		for _, err := range errs {
@@ -263,9 +276,11 @@ func main() {
 		logrus.Error("Some steps failed:")
 		logrus.Error(message.String())
 		opt.Report(defaulted...)
+		opt.events.Close()
 		os.Exit(1)
 	}
 	opt.Report()
+	opt.events.Close()
 }
 
 // setupLogger sets up logrus to print all logs to a file and user-friendly logs to stdout
@@ -355,6 +370,10 @@ type options struct {
 	verbose    bool
 	help       bool
 	printGraph bool
+	checkpoint bool
+	resume     bool
+	// dryRunOutput is the directory the objects created by a dry run are written to
+	dryRunOutput string
 
 	writeParams string
 	artifactDir string
@@ -380,6 +399,11 @@ type options struct {
 	nodeName                   string
 	leaseServer                string
 	leaseServerCredentialsFile string
+	leaseBackend               string
+	leaseNamespace             string
+	leaseKubeconfigPath        string
+	leaseKubeconfig            *rest.Config
+	leaseWaitHistory           string
 	leaseAcquireTimeout        time.Duration
 	leaseClient                lease.Client
 
@@ -424,6 +448,24 @@ type options struct {
 	targetAdditionalSuffix string
 	manifestToolDockerCfg  string
 	localRegistryDNS       string
+
+	buildBackend      string
+	insecureRegistry  bool
+	registrySkipTLS   bool
+	kubernetesBackend *kubernetesbackend.Config
+
+	buildCacheNamespace string
+	buildCacheMaxAge    time.Duration
+	buildCache          *steps.BuildCache
+
+	eventSinkURL string
+	events       *events.Recorder
+	// failureLogs holds the logs of failed pods and builds to classify
+	// the failures of the execution with
+	failureLogs *results.FailureLogs
+
+	githubTokenPath string
+	githubEndpoint  string
 }
 
 func bindOptions(flag *flag.FlagSet) *options {
@@ -443,11 +485,18 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.StringVar(&opt.leaseServer, "lease-server", leaseServerAddress, "Address of the server that manages leases. Required if any test is configured to acquire a lease.")
 	flag.StringVar(&opt.leaseServerCredentialsFile, "lease-server-credentials-file", "", "The path to credentials file used to access the lease server. The content is of the form <username>:<password>.")
 	flag.DurationVar(&opt.leaseAcquireTimeout, "lease-acquire-timeout", leaseAcquireTimeout, "Maximum amount of time to wait for lease acquisition")
+	flag.StringVar(&opt.leaseBackend, "lease-backend", lease.BoskosBackend, fmt.Sprintf("The backend storing leases, one of %v. The %s backend leases LeaseResource objects in --lease-namespace of the cluster in --lease-kubeconfig instead of using --lease-server.", lease.Backends(), lease.CRDBackend))
+	flag.StringVar(&opt.leaseNamespace, "lease-namespace", "ci", "The namespace holding LeaseResource objects when using the crd lease backend.")
+	flag.StringVar(&opt.leaseKubeconfigPath, "lease-kubeconfig", "", "Path to the kubeconfig file of the cluster holding LeaseResource objects when using the crd lease backend. Runs on all build clusters must share this cluster, where the leasereaper controller frees the leases of dead runs.")
+	flag.StringVar(&opt.leaseWaitHistory, "lease-wait-history", "", "A namespace and name prefix in namespace/prefix format, in the cluster the tests run in, for ConfigMaps which keep how long lease acquisitions waited across executions, one per resource type named <prefix>-<type>. The waits are used to predict how long leases will take to acquire. When unset, only the waits of this execution are used.")
 	flag.StringVar(&opt.registryPath, "registry", "", "Path to the step registry directory")
 	flag.StringVar(&opt.configSpecPath, "config", "", "The configuration file. If not specified the CONFIG_SPEC environment variable or the configresolver will be used.")
 	flag.StringVar(&opt.unresolvedConfigPath, "unresolved-config", "", "The configuration file, before resolution. If not specified the UNRESOLVED_CONFIG environment variable will be used, if set.")
 	flag.Var(&opt.targets, "target", "One or more targets in the configuration to build. Only steps that are required for this target will be run.")
 	flag.BoolVar(&opt.printGraph, "print-graph", opt.printGraph, "Print a directed graph of the build steps and exit. Intended for use with the golang digraph utility.")
+	flag.StringVar(&opt.dryRunOutput, "dry-run-output", "", "Execute the graph against an in-memory cluster instead of the build cluster and write every object that would be created to this directory as YAML, in creation order.")
+	flag.BoolVar(&opt.checkpoint, "checkpoint", false, "Record the steps that complete in a ConfigMap in the namespace, so that a later execution can --resume from them.")
+	flag.BoolVar(&opt.resume, "resume", false, "Skip steps that completed in a previous execution in the same namespace with the same inputs, as long as the objects they created still exist. Implies --checkpoint.")
 
 	// add to the graph of things we run or create
 	flag.Var(&opt.templatePaths, "template", "A set of paths to optional templates to add as stages to this job. Each template is expected to contain at least one restart=Never pod. Parameters are filled from environment or from the automatic parameters generated by the operator.")
@@ -496,11 +545,67 @@ func bindOptions(flag *flag.FlagSet) *options {
 
 	flag.StringVar(&opt.manifestToolDockerCfg, "manifest-tool-dockercfg", "/secrets/manifest-tool/.dockerconfigjson", "The dockercfg file path to be used to push the manifest listed image after build. This is being used by the manifest-tool binary.")
 	flag.StringVar(&opt.localRegistryDNS, "local-registry-dns", "image-registry.openshift-image-registry.svc:5000", "Defines the target image registry.")
+	flag.StringVar(&opt.buildBackend, "build-backend", buildBackendOpenShift, fmt.Sprintf("The backend that executes builds and stores images: %q uses OpenShift Builds and ImageStreams, %q runs builds with kaniko in plain pods and stores images in the registry set by --local-registry-dns, for clusters like kind that do not serve the OpenShift APIs.", buildBackendOpenShift, buildBackendKubernetes))
+	flag.StringVar(&opt.eventSinkURL, "event-sink-url", "", fmt.Sprintf("An HTTP endpoint that receives the lifecycle events of the execution as newline-delimited JSON, in addition to the %s file in $ARTIFACT_DIR.", eventsFile))
+	flag.BoolVar(&opt.insecureRegistry, "insecure-registry", false, "Connect to the registry set by --local-registry-dns over plain HTTP. Only used with --build-backend=kubernetes.")
+	flag.BoolVar(&opt.registrySkipTLS, "registry-skip-tls-verify", false, "Do not verify the TLS certificate of the registry set by --local-registry-dns. Only used with --build-backend=kubernetes.")
+	flag.StringVar(&opt.buildCacheNamespace, "build-cache-namespace", "", "A namespace shared between executions where pipeline images are cached by a hash of their build inputs. Source and binary builds with inputs identical to a cached image tag it instead of building. Disabled when empty.")
+	flag.DurationVar(&opt.buildCacheMaxAge, "build-cache-max-age", 24*time.Hour, "Maximum age of images in the build cache. Older images are not used and are removed from the cache.")
 
 	opt.resultsOptions.Bind(flag)
+	flag.StringVar(&opt.githubTokenPath, "github-token-path", "", "Path to a GitHub token used to list the files changed by the pull requests under test, for steps with run_if_changed or skip_if_only_changed. Without a token, those steps always run.")
+	flag.StringVar(&opt.githubEndpoint, "github-endpoint", github.DefaultAPIEndpoint, "GitHub's API endpoint, usually a ghproxy instance.")
 	return opt
 }
 
+type pullRequestChangesGetter interface {
+	GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error)
+}
+
+// changedFilesProvider lists the files changed by all pull requests under
+// test. The GitHub client is only created and the changes are only fetched
+// when a step needs them, at most once.
+func changedFilesProvider(refs *prowapi.Refs, newClient func() (pullRequestChangesGetter, error)) api.ChangedFilesProvider {
+	var once sync.Once
+	var changes []string
+	var err error
+	return func() ([]string, error) {
+		once.Do(func() {
+			client, clientErr := newClient()
+			if clientErr != nil {
+				err = fmt.Errorf("could not create GitHub client: %w", clientErr)
+				return
+			}
+			files := sets.New[string]()
+			for _, pull := range refs.Pulls {
+				pullChanges, pullErr := client.GetPullRequestChanges(refs.Org, refs.Repo, pull.Number)
+				if pullErr != nil {
+					err = fmt.Errorf("could not get the changes of %s/%s#%d: %w", refs.Org, refs.Repo, pull.Number, pullErr)
+					return
+				}
+				for _, change := range pullChanges {
+					files.Insert(change.Filename)
+				}
+			}
+			changes = sets.List(files)
+		})
+		return changes, err
+	}
+}
+
+// newGitHubClient authenticates with the token from --github-token-path.
+// Anonymous clients are limited to 60 requests an hour, which all jobs on a
+// build farm exhaust quickly, so steps run instead of failing to be skipped.
+func (o *options) newGitHubClient() (pullRequestChangesGetter, error) {
+	if o.githubTokenPath == "" {
+		return nil, errors.New("no --github-token-path was provided")
+	}
+	if err := secret.Add(o.githubTokenPath); err != nil {
+		return nil, fmt.Errorf("could not load the GitHub token: %w", err)
+	}
+	return github.NewClient(secret.GetTokenGenerator(o.githubTokenPath), secret.Censor, github.DefaultGraphQLEndpoint, o.githubEndpoint)
+}
+
 func (o *options) Complete() error {
 	jobSpec, err := api.ResolveSpecFromEnv()
 	if err != nil {
@@ -522,12 +627,42 @@ func (o *options) Complete() error {
 		jobSpec.Refs = spec.Refs
 	}
 	jobSpec.BaseNamespace = o.baseNamespace
+	switch o.buildBackend {
+	case buildBackendOpenShift:
+	case buildBackendKubernetes:
+		o.kubernetesBackend = &kubernetesbackend.Config{Registry: o.localRegistryDNS, Insecure: o.insecureRegistry, SkipTLSVerify: o.registrySkipTLS}
+	default:
+		return fmt.Errorf("invalid --build-backend %q, must be one of %q or %q", o.buildBackend, buildBackendOpenShift, buildBackendKubernetes)
+	}
+	if !sets.New[string](lease.Backends()...).Has(o.leaseBackend) {
+		return fmt.Errorf("invalid --lease-backend %q, must be one of %v", o.leaseBackend, lease.Backends())
+	}
+	if o.leaseBackend == lease.CRDBackend && o.leaseKubeconfigPath == "" {
+		return fmt.Errorf("--lease-kubeconfig is required with --lease-backend=%s", lease.CRDBackend)
+	}
+	if o.leaseBackend != lease.CRDBackend && o.leaseKubeconfigPath != "" {
+		return fmt.Errorf("--lease-kubeconfig requires --lease-backend=%s", lease.CRDBackend)
+	}
+	if o.leaseWaitHistory != "" {
+		if parts := strings.Split(o.leaseWaitHistory, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid --lease-wait-history %q, must be in namespace/prefix format", o.leaseWaitHistory)
+		}
+	}
+	if o.buildCacheNamespace != "" {
+		if o.kubernetesBackend != nil {
+			return fmt.Errorf("--build-cache-namespace is not supported with --build-backend=%s", buildBackendKubernetes)
+		}
+		o.buildCache = steps.NewBuildCache(o.buildCacheNamespace, o.buildCacheMaxAge)
+	}
 	target := "all"
 	if len(o.targets.values) > 0 {
 		target = o.targets.values[0]
 	}
 	o.jobSpec = jobSpec
 	o.jobSpec.Target = target
+	if refs := jobSpec.Refs; refs != nil && len(refs.Pulls) > 0 {
+		o.jobSpec.SetChangedFiles(changedFilesProvider(refs, o.newGitHubClient))
+	}
 
 	info := o.getResolverInfo(jobSpec)
 	o.resolverClient = server.NewResolverClient(o.resolverAddress)
@@ -660,21 +795,23 @@ func (o *options) Complete() error {
 		o.templates = append(o.templates, template)
 	}
 
-	clusterConfig, err := util.LoadClusterConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load cluster config: %w", err)
-	}
+	if o.dryRunOutput == "" {
+		clusterConfig, err := util.LoadClusterConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load cluster config: %w", err)
+		}
 
-	if len(o.impersonateUser) > 0 {
-		clusterConfig.Impersonate = rest.ImpersonationConfig{UserName: o.impersonateUser}
-	}
+		if len(o.impersonateUser) > 0 {
+			clusterConfig.Impersonate = rest.ImpersonationConfig{UserName: o.impersonateUser}
+		}
 
-	if o.verbose {
-		clusterConfig.ContentType = "application/json"
-		clusterConfig.AcceptContentTypes = "application/json"
-	}
+		if o.verbose {
+			clusterConfig.ContentType = "application/json"
+			clusterConfig.AcceptContentTypes = "application/json"
+		}
 
-	o.clusterConfig = clusterConfig
+		o.clusterConfig = clusterConfig
+	}
 
 	if o.pullSecretPath != "" {
 		if o.pullSecret, err = getDockerConfigSecret(api.RegistryPullCredentialsSecret, o.pullSecretPath); err != nil {
@@ -702,6 +839,14 @@ func (o *options) Complete() error {
 		o.hiveKubeconfig = kubeConfig
 	}
 
+	if o.leaseKubeconfigPath != "" {
+		kubeConfig, err := util.LoadKubeConfig(o.leaseKubeconfigPath)
+		if err != nil {
+			return fmt.Errorf("could not load lease kube config from path %s: %w", o.leaseKubeconfigPath, err)
+		}
+		o.leaseKubeconfig = kubeConfig
+	}
+
 	if err := overrideMultiStageParams(o); err != nil {
 		return err
 	}
@@ -838,7 +983,7 @@ func (o *options) Report(errs ...error) {
 		o.writeFailingJUnit(errs)
 	}
 
-	reporter, loadErr := o.resultsOptions.Reporter(o.jobSpec, o.consoleHost)
+	reporter, loadErr := o.resultsOptions.Reporter(o.jobSpec, o.consoleHost, o.failureLogs)
 	if loadErr != nil {
 		logrus.WithError(loadErr).Warn("Could not load result reporting options.")
 		return
@@ -852,6 +997,12 @@ func (o *options) Report(errs ...error) {
 	if len(errorToReport) == 0 {
 		reporter.Report(nil)
 	}
+
+	if o.buildCache != nil {
+		for image, lookups := range o.buildCache.Lookups() {
+			reporter.ReportBuildCache(image, lookups.Hits, lookups.Misses)
+		}
+	}
 }
 
 func (o *options) Run() []error {
@@ -859,13 +1010,18 @@ func (o *options) Run() []error {
 	defer func() {
 		logrus.Infof("Ran for %s", time.Since(start).Truncate(time.Second))
 	}()
-	ctx, cancel := context.WithCancel(context.Background())
+	if o.dryRunOutput != "" {
+		return o.dryRun()
+	}
+	ctx, cancel := context.WithCancel(results.WithFailureLogs(events.WithRecorder(context.Background(), o.events), o.failureLogs))
+	usage := resourceusage.NewTracker(ctx)
+	ctx = resourceusage.WithTracker(ctx, usage)
 	handler := func(s os.Signal) {
 		logrus.Infof("error: Process interrupted with signal %s, cancelling execution...", s)
 		cancel()
 	}
 	var leaseClient *lease.Client
-	if o.leaseServer != "" && o.leaseServerCredentialsFile != "" {
+	if o.leaseBackend == lease.CRDBackend || (o.leaseServer != "" && o.leaseServerCredentialsFile != "") {
 		leaseClient = &o.leaseClient
 	}
 
@@ -885,7 +1041,7 @@ func (o *options) Run() []error {
 	// load the graph from the configuration
 	buildSteps, postSteps, err := defaults.FromConfig(ctx, o.configSpec, &o.graphConfig, o.jobSpec, o.templates, o.writeParams, o.promote, o.clusterConfig,
 		o.podPendingTimeout, leaseClient, o.targets.values, o.cloneAuthConfig, o.pullSecret, o.pushSecret, o.censor, o.hiveKubeconfig,
-		o.consoleHost, o.nodeName, nodeArchitectures, o.targetAdditionalSuffix, o.manifestToolDockerCfg, o.localRegistryDNS, mergedConfig)
+		o.consoleHost, o.nodeName, nodeArchitectures, o.targetAdditionalSuffix, o.manifestToolDockerCfg, o.localRegistryDNS, mergedConfig, o.kubernetesBackend, o.buildCache)
 	if err != nil {
 		return []error{results.ForReason("defaulting_config").WithError(err).Errorf("failed to generate steps from config: %v", err)}
 	}
@@ -929,6 +1085,15 @@ func (o *options) Run() []error {
 
 		_ = api.SaveArtifact(o.censor, api.CIOperatorStepGraphJSONFilename, serializedGraph)
 	}()
+	defer func() {
+		serializedUsage, err := json.Marshal(usage.Snapshot())
+		if err != nil {
+			logrus.WithError(err).Error("Failed to marshal resource usage")
+			return
+		}
+
+		_ = api.SaveArtifact(o.censor, api.ResourceUsageJSONFilename, serializedUsage)
+	}()
 	// initialize the namespace if necessary and create any resources that must
 	// exist prior to execution
 	if err := o.initializeNamespace(); err != nil {
@@ -952,8 +1117,21 @@ func (o *options) Run() []error {
 		}
 		runtimeObject := &coreapi.ObjectReference{Namespace: o.namespace}
 		eventRecorder.Event(runtimeObject, coreapi.EventTypeNormal, "CiJobStarted", eventJobDescription(o.jobSpec, o.namespace))
+		runOpts, err := o.checkpointOptions(ctx, nodes)
+		if err != nil {
+			return []error{results.ForReason("loading_checkpoint").WithError(err).Errorf("could not load checkpoint: %v", err)}
+		}
 		// execute the graph
-		suites, graphDetails, errs := steps.Run(ctx, nodes)
+		suites, graphDetails, errs := steps.Run(ctx, nodes, runOpts...)
+		if o.buildCache != nil {
+			if lookups, hitRate := o.buildCache.HitRate(); lookups > 0 {
+				logrus.Infof("Reused %.0f%% of %d pipeline images from the build cache", hitRate*100, lookups)
+			}
+		}
+		if suites != nil && len(suites.Suites) > 0 {
+			suites.Suites[0].TestCases = append(suites.Suites[0].TestCases, usage.Snapshot().TestCase())
+			suites.Suites[0].NumTests++
+		}
 		if err := o.writeJUnit(suites, "operator"); err != nil {
 			logrus.WithError(err).Warn("Unable to write JUnit result.")
 		}
@@ -986,6 +1164,78 @@ func (o *options) Run() []error {
 	})
 }
 
+// checkpointOptions configures the graph execution to record the steps that
+// complete in the namespace and, when resuming, to skip the ones that were
+// completed by a previous execution. Nothing is recorded unless checkpoints
+// were requested.
+func (o *options) checkpointOptions(ctx context.Context, graph api.StepGraph) ([]steps.RunOption, error) {
+	if !o.checkpoint && !o.resume {
+		return nil, nil
+	}
+	client, err := o.newClient()
+	if err != nil {
+		return nil, err
+	}
+	store := steps.NewCheckpointStore(client, o.namespace, o.inputHash)
+	completed := sets.New[string]()
+	if o.resume {
+		checkpoint, err := store.Load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if completed, err = steps.ResumableSteps(ctx, client, o.namespace, graph, checkpoint); err != nil {
+			return nil, err
+		}
+		if completed.Len() > 0 {
+			logrus.Infof("Resuming execution, skipping completed steps: %s", strings.Join(sets.List(completed), ", "))
+		}
+	}
+	return []steps.RunOption{steps.WithCheckpoints(store, completed)}, nil
+}
+
+// dryRun executes the graph against an in-memory cluster, in which every Pod
+// and Build succeeds immediately, and writes the objects the execution created
+// to the output directory.
+func (o *options) dryRun() []error {
+	ctx := events.WithRecorder(context.Background(), o.events)
+	// the namespace is only final once the inputs are resolved, until then it
+	// is a template that no images can live in
+	cluster := dryrun.NewCluster(func() string { return o.namespace })
+	mergedConfig := o.injectTest != "" || len(o.jobSpec.ExtraRefs) > 1
+	nodeArchitectures := []string{string(api.ReleaseArchitectureAMD64)}
+	buildSteps, postSteps, err := defaults.DryRunFromConfig(ctx, o.configSpec, &o.graphConfig, o.jobSpec, o.templates, o.writeParams, o.promote, cluster,
+		o.targets.values, o.cloneAuthConfig, o.pullSecret, o.pushSecret, o.censor, o.consoleHost, o.nodeName, nodeArchitectures, o.targetAdditionalSuffix, mergedConfig)
+	if err != nil {
+		return []error{results.ForReason("defaulting_config").WithError(err).Errorf("failed to generate steps from config: %v", err)}
+	}
+	if err := o.resolveInputs(buildSteps); err != nil {
+		return []error{results.ForReason("resolving_inputs").WithError(err).Errorf("could not resolve inputs: %v", err)}
+	}
+	nodes, err := api.BuildPartialGraph(buildSteps, o.targets.values)
+	if err != nil {
+		return []error{results.ForReason("building_graph").WithError(err).Errorf("could not build execution graph: %v", err)}
+	}
+	if err := o.createNamespace(ctx, cluster); err != nil {
+		return []error{err}
+	}
+	if err := o.setupNamespace(ctx, ctrlruntimeclient.NewNamespacedClient(cluster, o.namespace)); err != nil {
+		return []error{err}
+	}
+	_, _, errs := steps.Run(ctx, nodes)
+	if len(errs) == 0 {
+		for _, step := range postSteps {
+			if err := step.Run(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("could not run post step %s: %w", step.Name(), err))
+				break
+			}
+		}
+	}
+	if err := cluster.Write(o.dryRunOutput); err != nil {
+		errs = append(errs, fmt.Errorf("could not write dry run output: %w", err))
+	}
+	return errs
+}
+
 // runStep mostly duplicates steps.runStep. The latter uses an *api.StepNode though and we only have an api.Step for the PostSteps
 // so we can not re-use it.
 func runStep(ctx context.Context, step api.Step) (api.CIOperatorStepDetails, error) {
@@ -1098,20 +1348,94 @@ func (o *options) resolveInputs(steps []api.Step) error {
 	return nil
 }
 
-func (o *options) initializeNamespace() error {
-	// We have to keep the project client because it return a project for a projectCreationRequest, ctrlruntimeclient can not do dark magic like that
-	projectGetter, err := projectclientset.NewForConfig(o.clusterConfig)
+// newEventRecorder streams the lifecycle events of the execution to
+// $ARTIFACT_DIR and, when configured, to an HTTP endpoint.
+func (o *options) newEventRecorder() *events.Recorder {
+	var sinks []events.Sink
+	if artifactDir, set := api.Artifacts(); set {
+		sink, err := events.NewFileSink(filepath.Join(artifactDir, eventsFile))
+		if err != nil {
+			logrus.WithError(err).Warn("Could not record the event stream.")
+		} else {
+			sinks = append(sinks, sink)
+		}
+	}
+	if o.eventSinkURL != "" {
+		sinks = append(sinks, events.NewHTTPSink(o.eventSinkURL, nil))
+	}
+	return events.NewRecorder(o.censor, events.Execution{Job: o.jobSpec.Job, BuildID: o.jobSpec.BuildID}, sinks...)
+}
+
+// newClient creates a client for the build cluster that serves Builds and
+// ImageStreams from the configured build backend.
+func (o *options) newClient() (ctrlruntimeclient.WithWatch, error) {
+	client, err := ctrlruntimeclient.NewWithWatch(o.clusterConfig, ctrlruntimeclient.Options{})
 	if err != nil {
-		return fmt.Errorf("could not get project client for cluster config: %w", err)
+		return nil, fmt.Errorf("failed to construct client: %w", err)
+	}
+	if o.kubernetesBackend != nil {
+		client = kubernetesbackend.Wrap(client, *o.kubernetesBackend)
 	}
-	client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+	return client, nil
+}
+
+func (o *options) initializeNamespace() error {
+	client, err := o.newClient()
 	if err != nil {
-		return fmt.Errorf("failed to construct client: %w", err)
+		return err
 	}
-	client = ctrlruntimeclient.NewNamespacedClient(client, o.namespace)
 	ctx := context.Background()
 
 	logrus.Debugf("Creating namespace %s", o.namespace)
+	if o.kubernetesBackend != nil {
+		err = o.createNamespace(ctx, client)
+	} else {
+		err = o.createProject()
+	}
+	if err != nil {
+		return err
+	}
+	namespacedClient := ctrlruntimeclient.NewNamespacedClient(client, o.namespace)
+	return o.setupNamespace(ctx, namespacedClient)
+}
+
+// createNamespace creates the test namespace on clusters that do not serve
+// the OpenShift Project API.
+func (o *options) createNamespace(ctx context.Context, client ctrlruntimeclient.Client) error {
+	for {
+		ns := &coreapi.Namespace{
+			ObjectMeta: meta.ObjectMeta{
+				Name:        o.namespace,
+				Labels:      map[string]string{api.DPTPRequesterLabel: "ci-operator"},
+				Annotations: map[string]string{"openshift.io/description": jobDescription(o.jobSpec)},
+			},
+		}
+		if err := client.Create(ctx, ns); err != nil {
+			if !kerrors.IsAlreadyExists(err) {
+				return fmt.Errorf("could not set up namespace for test: %w", err)
+			}
+			if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: o.namespace}, ns); err != nil {
+				if kerrors.IsNotFound(err) {
+					continue
+				}
+				return fmt.Errorf("could not get namespace for test: %w", err)
+			}
+		}
+		if ns.Status.Phase == coreapi.NamespaceTerminating {
+			logrus.Info("Waiting for namespace to finish terminating before creating another")
+			time.Sleep(3 * time.Second)
+			continue
+		}
+		return nil
+	}
+}
+
+func (o *options) createProject() error {
+	// We have to keep the project client because it return a project for a projectCreationRequest, ctrlruntimeclient can not do dark magic like that
+	projectGetter, err := projectclientset.NewForConfig(o.clusterConfig)
+	if err != nil {
+		return fmt.Errorf("could not get project client for cluster config: %w", err)
+	}
 	authTimeout := 15 * time.Second
 	initBeginning := time.Now()
 	for {
@@ -1145,9 +1469,11 @@ func (o *options) initializeNamespace() error {
 			time.Sleep(3 * time.Second)
 			continue
 		}
-		break
+		return nil
 	}
+}
 
+func (o *options) setupNamespace(ctx context.Context, client ctrlruntimeclient.Client) error {
 	ssarStart := time.Now()
 	var selfSubjectAccessReviewSucceeded bool
 	for i := 0; i < 30; i++ {
@@ -1229,7 +1555,7 @@ func (o *options) initializeNamespace() error {
 
 		updateErr := client.Update(ctx, ns)
 		if kerrors.IsForbidden(updateErr) {
-			logrus.WithError(err).Warn("Could not edit namespace because you do not have permission to update the namespace.")
+			logrus.WithError(updateErr).Warn("Could not edit namespace because you do not have permission to update the namespace.")
 			return nil
 		}
 		return updateErr
@@ -1237,9 +1563,11 @@ func (o *options) initializeNamespace() error {
 		return fmt.Errorf("could not update namespace to add labels, TTLs and active annotations: %w", err)
 	}
 
+	// Image pull secrets are only minted for service accounts on OpenShift,
+	// the Kubernetes backend passes the pull secret to pods explicitly.
 	pullStart := time.Now()
-	var imagePullSecretsMinted bool
-	for i := 0; i < 119; i++ {
+	imagePullSecretsMinted := o.kubernetesBackend != nil
+	for i := 0; i < 119 && !imagePullSecretsMinted; i++ {
 		imagePullSecretsMinted = true
 		serviceAccounts := map[string]*coreapi.ServiceAccount{
 			"builder": {},
@@ -1328,12 +1656,16 @@ func (o *options) initializeNamespace() error {
 			return fmt.Errorf("failed to get pipeline imagestream: %w", err)
 		}
 	}
-	o.jobSpec.SetOwner(&meta.OwnerReference{
-		APIVersion: "image.openshift.io/v1",
-		Kind:       "ImageStream",
-		Name:       api.PipelineImageStream,
-		UID:        is.UID,
-	})
+	if o.kubernetesBackend != nil {
+		o.jobSpec.SetOwner(kubernetesbackend.OwnerReferenceFor(is))
+	} else {
+		o.jobSpec.SetOwner(&meta.OwnerReference{
+			APIVersion: "image.openshift.io/v1",
+			Kind:       "ImageStream",
+			Name:       api.PipelineImageStream,
+			UID:        is.UID,
+		})
+	}
 
 	if o.cloneAuthConfig != nil && o.cloneAuthConfig.Secret != nil {
 		o.cloneAuthConfig.Secret.Immutable = utilpointer.Bool(true)
@@ -1679,18 +2011,17 @@ func (o *options) saveNamespaceArtifacts() {
 		_ = api.SaveArtifact(o.censor, path, data)
 	}
 
-	if buildClient, err := buildclientset.NewForConfig(o.clusterConfig); err == nil {
-		builds, _ := buildClient.Builds(o.namespace).List(context.TODO(), meta.ListOptions{})
+	if client, err := o.newClient(); err == nil {
+		builds := &buildv1.BuildList{}
+		_ = client.List(context.TODO(), builds, ctrlruntimeclient.InNamespace(o.namespace))
 		data, _ := json.MarshalIndent(builds, "", "  ")
 		path := filepath.Join(namespaceDir, "builds.json")
 		_ = api.SaveArtifact(o.censor, path, data)
-	}
 
-	if client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{}); err == nil {
 		imagestreams := &imageapi.ImageStreamList{}
 		_ = client.List(context.TODO(), imagestreams, ctrlruntimeclient.InNamespace(o.namespace))
-		data, _ := json.MarshalIndent(imagestreams, "", "  ")
-		path := filepath.Join(namespaceDir, "imagestreams.json")
+		data, _ = json.MarshalIndent(imagestreams, "", "  ")
+		path = filepath.Join(namespaceDir, "imagestreams.json")
 		_ = api.SaveArtifact(o.censor, path, data)
 	}
 
@@ -1719,12 +2050,29 @@ func loadLeaseCredentials(leaseServerCredentialsFile string) (string, func() []b
 
 func (o *options) initializeLeaseClient() error {
 	var err error
-	owner := o.namespace + "-" + o.jobSpec.UniqueHash()
-	username, passwordGetter, err := loadLeaseCredentials(o.leaseServerCredentialsFile)
-	if err != nil {
-		return fmt.Errorf("failed to load lease credentials: %w", err)
+	backendOptions := lease.BackendOptions{Owner: o.namespace + "-" + o.jobSpec.UniqueHash()}
+	switch o.leaseBackend {
+	case lease.CRDBackend:
+		if backendOptions.Client, err = ctrlruntimeclient.New(o.leaseKubeconfig, ctrlruntimeclient.Options{}); err != nil {
+			return fmt.Errorf("failed to create the client for lease resources: %w", err)
+		}
+		backendOptions.Namespace = o.leaseNamespace
+	default:
+		if backendOptions.Username, backendOptions.PasswordGetter, err = loadLeaseCredentials(o.leaseServerCredentialsFile); err != nil {
+			return fmt.Errorf("failed to load lease credentials: %w", err)
+		}
+		backendOptions.URL = o.leaseServer
+	}
+	var clientOptions []lease.ClientOption
+	if o.leaseWaitHistory != "" {
+		client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+		if err != nil {
+			return fmt.Errorf("failed to create the client for the lease wait history: %w", err)
+		}
+		parts := strings.Split(o.leaseWaitHistory, "/")
+		clientOptions = append(clientOptions, lease.WithWaitHistory(lease.NewConfigMapWaitHistory(client, parts[0], parts[1])))
 	}
-	if o.leaseClient, err = lease.NewClient(owner, o.leaseServer, username, passwordGetter, 60, o.leaseAcquireTimeout); err != nil {
+	if o.leaseClient, err = lease.NewClientForBackend(o.leaseBackend, backendOptions, 60, o.leaseAcquireTimeout, clientOptions...); err != nil {
 		return fmt.Errorf("failed to create the lease client: %w", err)
 	}
 	t := time.NewTicker(30 * time.Second)
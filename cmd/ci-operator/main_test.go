@@ -9,7 +9,9 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -18,6 +20,7 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/kubernetes/scheme"
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/github"
 	"k8s.io/test-infra/prow/pod-utils/downwardapi"
 	"k8s.io/utils/diff"
 	"k8s.io/utils/pointer"
@@ -27,6 +30,7 @@ import (
 	imagev1 "github.com/openshift/api/image/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/defaults"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
@@ -994,7 +998,7 @@ func TestBuildPartialGraph(t *testing.T) {
 					loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(&imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Name: ":"}}).Build()),
 					nil,
 				),
-				steps.SourceStep(api.SourceStepConfiguration{From: api.PipelineImageStreamTagReferenceRoot, To: api.PipelineImageStreamTagReferenceSource}, api.ResourceConfiguration{}, nil, nil, &api.JobSpec{}, nil, nil),
+				steps.SourceStep(api.SourceStepConfiguration{From: api.PipelineImageStreamTagReferenceRoot, To: api.PipelineImageStreamTagReferenceSource}, api.ResourceConfiguration{}, nil, nil, &api.JobSpec{}, nil, nil, nil),
 				steps.ProjectDirectoryImageBuildStep(
 					api.ProjectDirectoryImageBuildStepConfiguration{
 						From: api.PipelineImageStreamTagReferenceSource,
@@ -1612,3 +1616,120 @@ func TestHandleTargetAdditionalSuffix(t *testing.T) {
 		})
 	}
 }
+
+type fakePullRequestChanges struct {
+	calls   int
+	changes map[int][]string
+}
+
+func (f *fakePullRequestChanges) GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error) {
+	f.calls++
+	files, ok := f.changes[number]
+	if !ok {
+		return nil, fmt.Errorf("no such pull request: %s/%s#%d", org, repo, number)
+	}
+	var ret []github.PullRequestChange
+	for _, file := range files {
+		ret = append(ret, github.PullRequestChange{Filename: file})
+	}
+	return ret, nil
+}
+
+func TestChangedFilesProvider(t *testing.T) {
+	client := &fakePullRequestChanges{changes: map[int][]string{
+		1: {"main.go", "README.md"},
+		2: {"README.md", "docs/index.md"},
+	}}
+	newClient := func() (pullRequestChangesGetter, error) { return client, nil }
+	refs := &prowapi.Refs{Org: "org", Repo: "repo", Pulls: []prowapi.Pull{{Number: 1}, {Number: 2}}}
+	provider := changedFilesProvider(refs, newClient)
+	for i := 0; i < 2; i++ {
+		changes, err := provider()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff := cmp.Diff([]string{"README.md", "docs/index.md", "main.go"}, changes); diff != "" {
+			t.Errorf("unexpected changes: %s", diff)
+		}
+	}
+	if client.calls != 2 {
+		t.Errorf("expected changes to be fetched once per pull request, got %d calls", client.calls)
+	}
+
+	missing := changedFilesProvider(&prowapi.Refs{Org: "org", Repo: "repo", Pulls: []prowapi.Pull{{Number: 3}}}, newClient)
+	if _, err := missing(); err == nil {
+		t.Error("expected an error for a missing pull request")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	if err := addSchemes(); err != nil {
+		t.Fatalf("failed to set up scheme: %v", err)
+	}
+	config := api.ReleaseBuildConfiguration{
+		Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+		InputConfiguration: api.InputConfiguration{
+			BuildRootImage: &api.BuildRootImageConfiguration{
+				ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "ci", Name: "golang", Tag: "1.21"},
+			},
+		},
+		Resources: api.ResourceConfiguration{
+			"*": {Requests: api.ResourceList{"cpu": "100m", "memory": "200Mi"}},
+		},
+		Tests: []api.TestStepConfiguration{{
+			As:                         "unit",
+			Commands:                   "make test",
+			ContainerTestConfiguration: &api.ContainerTestConfiguration{From: api.PipelineImageStreamTagReferenceSource},
+		}},
+	}
+	jobSpec := &api.JobSpec{Metadata: config.Metadata, JobSpec: downwardapi.JobSpec{
+		Type:    prowapi.PresubmitJob,
+		Job:     "pull-ci-org-repo-master-unit",
+		BuildID: "1",
+		Refs: &prowapi.Refs{
+			Org:     "org",
+			Repo:    "repo",
+			BaseRef: "master",
+			BaseSHA: "abcdef",
+			Pulls:   []prowapi.Pull{{Number: 1, Author: "author", SHA: "123456"}},
+		},
+		DecorationConfig: &prowapi.DecorationConfig{
+			Timeout:       &prowapi.Duration{Duration: time.Hour},
+			GracePeriod:   &prowapi.Duration{Duration: time.Minute},
+			UtilityImages: &prowapi.UtilityImages{Sidecar: "sidecar", Entrypoint: "entrypoint"},
+		},
+	}}
+	o := &options{
+		configSpec:   &config,
+		graphConfig:  defaults.FromConfigStatic(&config),
+		jobSpec:      jobSpec,
+		namespace:    "ci-op-dry-run",
+		targets:      stringSlice{values: []string{"unit"}},
+		censor:       &secrets.DynamicCensor{},
+		dryRunOutput: t.TempDir(),
+	}
+	if errs := o.Run(); len(errs) > 0 {
+		t.Fatalf("dry run failed: %v", utilerrors.NewAggregate(errs))
+	}
+	files, err := os.ReadDir(o.dryRunOutput)
+	if err != nil {
+		t.Fatalf("could not read output: %v", err)
+	}
+	var output string
+	for _, file := range files {
+		raw, err := os.ReadFile(filepath.Join(o.dryRunOutput, file.Name()))
+		if err != nil {
+			t.Fatalf("could not read %s: %v", file.Name(), err)
+		}
+		output += fmt.Sprintf("# %s\n%s---\n", file.Name(), raw)
+	}
+	testhelper.CompareWithFixture(t, output)
+}
+
+func TestChangedFilesProviderWithoutToken(t *testing.T) {
+	refs := &prowapi.Refs{Org: "org", Repo: "repo", Pulls: []prowapi.Pull{{Number: 1}}}
+	provider := changedFilesProvider(refs, (&options{}).newGitHubClient)
+	if _, err := provider(); err == nil || !strings.Contains(err.Error(), "--github-token-path") {
+		t.Errorf("expected an error about the missing token, got %v", err)
+	}
+}
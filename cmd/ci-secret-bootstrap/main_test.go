@@ -1940,6 +1940,27 @@ func TestGetUnusedItems(t *testing.T) {
 				},
 			},
 		},
+		{
+			id:         "previous versions kept by a rotation are not reported",
+			allowItems: sets.New[string](),
+			items: map[string]vaultclient.KVData{
+				"item-name-1": {
+					Data: map[string]string{
+						"field-name-1":          "testdata",
+						"field-name-1.previous": "previous",
+					},
+				},
+			},
+			config: secretbootstrap.Config{
+				Secrets: []secretbootstrap.SecretConfig{
+					{
+						From: map[string]secretbootstrap.ItemContext{
+							"1": {Item: "item-name-1", Field: "field-name-1"},
+						},
+					},
+				},
+			},
+		},
 		{
 			id:         "partly used, unused items expected",
 			allowItems: sets.New[string](),
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	coreclientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/test-infra/prow/flagutil"
+	"k8s.io/test-infra/prow/logrusutil"
+
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
+	"github.com/openshift/ci-tools/pkg/api/secretgenerator"
+	"github.com/openshift/ci-tools/pkg/prowconfigutils"
+	"github.com/openshift/ci-tools/pkg/secrets"
+	"github.com/openshift/ci-tools/pkg/secrets/rotation"
+)
+
+type options struct {
+	secrets secrets.CLIOptions
+
+	kubernetesOptions   flagutil.KubernetesOptions
+	configPath          string
+	bootstrapConfigPath string
+	statePath           string
+	items               flagutil.Strings
+	pollInterval        time.Duration
+	propagationTimeout  time.Duration
+	dryRun              bool
+	logLevel            string
+
+	config          secretgenerator.Config
+	bootstrapConfig secretbootstrap.Config
+}
+
+func parseOptions(censor *secrets.DynamicCensor) (options, error) {
+	o := options{kubernetesOptions: flagutil.KubernetesOptions{NOInClusterConfigDefault: true}}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.BoolVar(&o.dryRun, "dry-run", true, "Whether to only list the items to rotate and the secrets created from them.")
+	fs.StringVar(&o.configPath, "config", "", "Path to the ci-secret-generator config file.")
+	fs.StringVar(&o.bootstrapConfigPath, "bootstrap-config", "", "Path to the ci-secret-bootstrap config file.")
+	fs.StringVar(&o.statePath, "state", "", "Path to the file recording the progress of the rotation, which resumes from it when interrupted.")
+	fs.Var(&o.items, "item", "Name of an item to rotate. Can be passed multiple times. Defaults to the items with a rotation config.")
+	fs.DurationVar(&o.pollInterval, "poll-interval", time.Minute, "How often to check whether a new version is propagated to the clusters.")
+	fs.DurationVar(&o.propagationTimeout, "propagation-timeout", time.Hour, "How long to wait for ci-secret-bootstrap to propagate a new version to the clusters.")
+	fs.StringVar(&o.logLevel, "log-level", "info", fmt.Sprintf("Log level is one of %v.", logrus.AllLevels))
+	o.kubernetesOptions.AddFlags(fs)
+	o.secrets.Bind(fs, os.Getenv, censor)
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return options{}, err
+	}
+	return o, nil
+}
+
+func (o *options) validateOptions() error {
+	var errs []error
+	level, err := logrus.ParseLevel(o.logLevel)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid log level specified: %w", err))
+	}
+	logrus.SetLevel(level)
+	if o.configPath == "" {
+		errs = append(errs, errors.New("--config is required"))
+	}
+	if o.bootstrapConfigPath == "" {
+		errs = append(errs, errors.New("--bootstrap-config is required"))
+	}
+	if !o.dryRun {
+		if o.statePath == "" {
+			errs = append(errs, errors.New("--state is required"))
+		}
+		errs = append(errs, o.secrets.Validate())
+	}
+	if o.pollInterval <= 0 || o.propagationTimeout <= 0 {
+		errs = append(errs, errors.New("--poll-interval and --propagation-timeout must be positive"))
+	}
+	errs = append(errs, o.kubernetesOptions.Validate(o.dryRun))
+	return utilerrors.NewAggregate(errs)
+}
+
+func (o *options) completeOptions(censor *secrets.DynamicCensor) error {
+	if err := o.secrets.Complete(censor); err != nil {
+		return err
+	}
+	var err error
+	if o.config, err = secretgenerator.LoadConfigFromPath(o.configPath); err != nil {
+		return fmt.Errorf("couldn't load the config: %w", err)
+	}
+	if err := secretbootstrap.LoadConfigFromFile(o.bootstrapConfigPath, &o.bootstrapConfig); err != nil {
+		return fmt.Errorf("couldn't load the bootstrap config: %w", err)
+	}
+	return nil
+}
+
+// selectItems determines the items to rotate, which are the ones named or the
+// ones with a rotation config when none are
+func selectItems(config secretgenerator.Config, names []string) (secretgenerator.Config, error) {
+	var ret secretgenerator.Config
+	if len(names) == 0 {
+		for _, item := range config {
+			if item.Rotation != nil {
+				ret = append(ret, item)
+			}
+		}
+		return ret, nil
+	}
+	wanted := sets.New[string](names...)
+	for _, item := range config {
+		if wanted.Has(item.ItemName) {
+			ret = append(ret, item)
+			wanted.Delete(item.ItemName)
+		}
+	}
+	if wanted.Len() != 0 {
+		return nil, fmt.Errorf("items not generated by ci-secret-generator: %v", sets.List(wanted))
+	}
+	return ret, nil
+}
+
+// pruneDisabledClusters removes the secrets of clusters which are disabled
+// in Prow, as ci-secret-bootstrap does not propagate secrets to them
+func pruneDisabledClusters(config *secretbootstrap.Config, disabled sets.Set[string]) {
+	for i, secret := range config.Secrets {
+		var to []secretbootstrap.SecretContext
+		for _, context := range secret.To {
+			if !disabled.Has(context.Cluster) {
+				to = append(to, context)
+			}
+		}
+		config.Secrets[i].To = to
+	}
+}
+
+func clientsFor(config secretbootstrap.Config, kubeconfigs map[string]rest.Config) (map[string]coreclientset.SecretsGetter, error) {
+	clients := map[string]coreclientset.SecretsGetter{}
+	for _, secret := range config.Secrets {
+		for _, context := range secret.To {
+			if _, ok := clients[context.Cluster]; ok {
+				continue
+			}
+			kubeconfig, ok := kubeconfigs[context.Cluster]
+			if !ok {
+				return nil, fmt.Errorf("failed to find cluster context %q in the kubeconfig", context.Cluster)
+			}
+			client, err := coreclientset.NewForConfig(&kubeconfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create client for cluster %s: %w", context.Cluster, err)
+			}
+			clients[context.Cluster] = client
+		}
+	}
+	return clients, nil
+}
+
+func main() {
+	logrusutil.ComponentInit()
+	censor := secrets.NewDynamicCensor()
+	logrus.SetFormatter(logrusutil.NewFormatterWithCensor(logrus.StandardLogger().Formatter, &censor))
+	o, err := parseOptions(&censor)
+	if err != nil {
+		logrus.WithError(err).Fatalf("cannot parse args: %q", os.Args[1:])
+	}
+	if err := o.validateOptions(); err != nil {
+		logrus.WithError(err).Fatal("Invalid arguments.")
+	}
+	if err := o.completeOptions(&censor); err != nil {
+		logrus.WithError(err).Fatal("Failed to complete options.")
+	}
+	items, err := selectItems(o.config, o.items.Strings())
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to select items.")
+	}
+	prowDisabledClusters, err := prowconfigutils.ProwDisabledClusters(&o.kubernetesOptions)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to get Prow disable clusters")
+	}
+	pruneDisabledClusters(&o.bootstrapConfig, sets.New[string](prowDisabledClusters...))
+
+	if o.dryRun {
+		for _, item := range items {
+			logrus.WithFields(logrus.Fields{"item": item.ItemName, "secrets": rotation.Consumers(o.bootstrapConfig, item)}).Info("Would rotate item.")
+		}
+		return
+	}
+
+	kubeconfigs, err := o.kubernetesOptions.LoadClusterConfigs()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load cluster configs.")
+	}
+	clusters, err := clientsFor(o.bootstrapConfig, kubeconfigs)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create clients.")
+	}
+	client, err := o.secrets.NewClient(&censor)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create client.")
+	}
+	state, err := rotation.LoadState(o.statePath)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load state.")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	rotator := rotation.NewRotator(client, o.bootstrapConfig, clusters, state, o.pollInterval, o.propagationTimeout)
+	var errs []error
+	for _, item := range items {
+		if err := rotator.Rotate(ctx, item); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 0 {
+		logrus.WithError(utilerrors.NewAggregate(errs)).Error("Failed to rotate items, run again with the same --state to resume.")
+		cancel()
+		os.Exit(1)
+	}
+	if state.Done(items) {
+		if err := state.Remove(); err != nil {
+			logrus.WithError(err).Warn("Failed to remove state.")
+		}
+	}
+	logrus.Info("Rotated items.")
+}
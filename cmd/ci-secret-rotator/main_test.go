@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
+	"github.com/openshift/ci-tools/pkg/api/secretgenerator"
+)
+
+func TestSelectItems(t *testing.T) {
+	config := secretgenerator.Config{
+		{ItemName: "rotated", Rotation: &secretgenerator.Rotation{Revoke: "revoke"}},
+		{ItemName: "generated"},
+	}
+	for _, tc := range []struct {
+		name          string
+		names         []string
+		expected      []string
+		expectedError string
+	}{{
+		name:     "items with a rotation config by default",
+		expected: []string{"rotated"},
+	}, {
+		name:     "named items",
+		names:    []string{"generated", "rotated"},
+		expected: []string{"rotated", "generated"},
+	}, {
+		name:          "items which are not generated",
+		names:         []string{"generated", "manual"},
+		expectedError: "items not generated by ci-secret-generator: [manual]",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			items, err := selectItems(config, tc.names)
+			var actualError string
+			if err != nil {
+				actualError = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedError, actualError); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			var actual []string
+			for _, item := range items {
+				actual = append(actual, item.ItemName)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected items: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPruneDisabledClusters(t *testing.T) {
+	config := secretbootstrap.Config{Secrets: []secretbootstrap.SecretConfig{{
+		To: []secretbootstrap.SecretContext{{Cluster: "app.ci", Name: "a"}, {Cluster: "build01", Name: "a"}},
+	}}}
+	pruneDisabledClusters(&config, sets.New[string]("build01"))
+	expected := []secretbootstrap.SecretContext{{Cluster: "app.ci", Name: "a"}}
+	if diff := cmp.Diff(expected, config.Secrets[0].To); diff != "" {
+		t.Errorf("unexpected secrets: %s", diff)
+	}
+}
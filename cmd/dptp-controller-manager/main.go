@@ -33,6 +33,7 @@ import (
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/controller/leasereaper"
 	"github.com/openshift/ci-tools/pkg/controller/promotionreconciler"
 	serviceaccountsecretrefresher "github.com/openshift/ci-tools/pkg/controller/serviceaccount_secret_refresher"
 	testimagesdistributor "github.com/openshift/ci-tools/pkg/controller/test-images-distributor"
@@ -51,6 +52,7 @@ var allControllers = sets.New[string](
 	testimagesdistributor.ControllerName,
 	serviceaccountsecretrefresher.ControllerName,
 	testimagestreamimportcleaner.ControllerName,
+	leasereaper.ControllerName,
 )
 
 type options struct {
@@ -69,6 +71,7 @@ type options struct {
 	serviceAccountSecretRefresherOptions serviceAccountSecretRefresherOptions
 	imagePusherOptions                   imagePusherOptions
 	promotionReconcilerOptions           promotionReconcilerOptions
+	leaseReaperOptions                   leaseReaperOptions
 	*flagutil.GitHubOptions
 	releaseRepoGitSyncPath string
 }
@@ -102,6 +105,10 @@ type imagePusherOptions struct {
 	imageStreams    sets.Set[string]
 }
 
+type leaseReaperOptions struct {
+	expiry time.Duration
+}
+
 type serviceAccountSecretRefresherOptions struct {
 	enabledNamespaces     flagutil.Strings
 	removeOldSecrets      bool
@@ -134,6 +141,7 @@ func newOpts() (*options, error) {
 	fs.Var(&opts.imagePusherOptions.imageStreamsRaw, "imagePusherOptions.image-stream", "An imagestream that will be synced. It must be in namespace/name format (e.G `ci/clonerefs`). Can be passed multiple times.")
 	fs.Var(&opts.promotionReconcilerOptions.ignoreImageStreamsRaw, "promotionReconcilerOptions.ignore-image-stream", "The image stream to ignore. It is an regular expression (e.G ^openshift-priv/.+). Can be passed multiple times.")
 	fs.StringVar(&opts.promotionReconcilerOptions.sinceRaw, "promotionReconcilerOptions.since", "360h", "The image stream tags to reconcile if it is younger than a relative duration like 5s, 2m, or 3h. Defaults to 360h, i.e., 15 days")
+	fs.DurationVar(&opts.leaseReaperOptions.expiry, "leaseReaperOptions.expiry", 10*time.Minute, "How long after the last heartbeat a leased leaseresource is freed")
 	fs.BoolVar(&opts.dryRun, "dry-run", true, "Whether to run the controller-manager with dry-run")
 	fs.StringVar(&opts.releaseRepoGitSyncPath, "release-repo-git-sync-path", "", "Path to release repository dir")
 	if err := fs.Parse(os.Args[1:]); err != nil {
@@ -222,6 +230,10 @@ func newOpts() (*options, error) {
 		}
 	}
 
+	if opts.enabledControllersSet.Has(leasereaper.ControllerName) && opts.leaseReaperOptions.expiry <= 0 {
+		errs = append(errs, fmt.Errorf("--leaseReaperOptions.expiry must be positive when enabling the %s controller", leasereaper.ControllerName))
+	}
+
 	if err := opts.GitHubOptions.Validate(opts.dryRun); err != nil {
 		errs = append(errs, err)
 	}
@@ -510,6 +522,12 @@ func main() {
 		}
 	}
 
+	if opts.enabledControllersSet.Has(leasereaper.ControllerName) {
+		if err := leasereaper.AddToManager(mgr, opts.leaseReaperOptions.expiry); err != nil {
+			logrus.WithError(err).Fatal("Failed to construct the leasereaper controller")
+		}
+	}
+
 	if err := mgr.Start(ctx); err != nil {
 		logrus.WithError(err).Fatal("Manager ended with error")
 	}
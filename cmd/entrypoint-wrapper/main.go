@@ -92,6 +92,7 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.StringVar(&opt.waitPath, "wait-for-file", "", "Wait for a file to appear at this path before starting the program")
 	flag.StringVar(&opt.waitTimeoutStr, "wait-timeout", "", "Used with --wait-for-file, maximum wait time before starting the program")
 	flag.StringVar(&opt.mode, "mode", manageKubeconfigMode, fmt.Sprintf("Set how kubeconfig should be managed. Allowed values are: %s, %s or %s", manageKubeconfigMode, skipKubeconfigMode, observerMode))
+	flag.StringVar(&opt.name, "shared-dir-secret", "", "Name of the secret the shared directory is written to, defaults to $JOB_NAME_SAFE")
 	return opt
 }
 
@@ -118,8 +119,10 @@ func (o *options) complete() error {
 	if ns = os.Getenv("NAMESPACE"); ns == "" {
 		return fmt.Errorf("environment variable NAMESPACE is empty")
 	}
-	if o.name = os.Getenv("JOB_NAME_SAFE"); o.name == "" {
-		return fmt.Errorf("environment variable JOB_NAME_SAFE is empty")
+	if o.name == "" {
+		if o.name = os.Getenv("JOB_NAME_SAFE"); o.name == "" {
+			return fmt.Errorf("environment variable JOB_NAME_SAFE is empty")
+		}
 	}
 
 	if err := o.validateMode(); err != nil {
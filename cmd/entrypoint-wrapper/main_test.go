@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"os"
 	"os/exec"
 	"strings"
@@ -122,3 +123,37 @@ func TestManageHome(t *testing.T) {
 		})
 	}
 }
+
+func TestCompleteSharedDirSecret(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		expected string
+	}{{
+		name:     "defaults to the name of the job",
+		args:     []string{"--dry-run", "cmd"},
+		expected: "job",
+	}, {
+		name:     "flag overrides the name of the job",
+		args:     []string{"--dry-run", "--shared-dir-secret=job-step-shared-dir", "cmd"},
+		expected: "job-step-shared-dir",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("SHARED_DIR", t.TempDir())
+			t.Setenv("NAMESPACE", "ns")
+			t.Setenv("JOB_NAME_SAFE", "job")
+			flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+			o := bindOptions(flagSet)
+			if err := flagSet.Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			o.cmd = flagSet.Args()
+			if err := o.complete(); err != nil {
+				t.Fatal(err)
+			}
+			if o.name != tc.expected {
+				t.Errorf("expected secret %q, got %q", tc.expected, o.name)
+			}
+		})
+	}
+}
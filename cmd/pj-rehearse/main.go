@@ -11,6 +11,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/scheme"
 	prowConfig "k8s.io/test-infra/prow/config"
 	"k8s.io/test-infra/prow/config/secret"
@@ -39,15 +40,21 @@ type options struct {
 	noTemplates       bool
 	noRegistry        bool
 	noClusterProfiles bool
+	noProwConfig      bool
 
 	normalLimit int
 	moreLimit   int
 	maxLimit    int
 
+	selection      string
+	jobHistoryPath string
+
 	gcsBucket          string
 	gcsCredentialsFile string
 	gcsBrowserPrefix   string
 
+	baselineResultsRoot string
+
 	dryRun        bool
 	dryRunOptions dryRunOptions
 
@@ -74,11 +81,15 @@ func gatherOptions() (options, error) {
 	fs.BoolVar(&o.noTemplates, "no-templates", false, "If true, do not attempt to compare templates")
 	fs.BoolVar(&o.noRegistry, "no-registry", false, "If true, do not attempt to compare step registry content")
 	fs.BoolVar(&o.noClusterProfiles, "no-cluster-profiles", false, "If true, do not attempt to compare cluster profiles")
+	fs.BoolVar(&o.noProwConfig, "no-prow-config", false, "If true, do not attempt to compare Tide, branch protection and plugin configuration")
 
 	fs.IntVar(&o.normalLimit, "normal-limit", 10, "Upper limit of jobs attempted to rehearse with normal command (if more jobs are being touched, only this many will be rehearsed)")
 	fs.IntVar(&o.moreLimit, "more-limit", 20, "Upper limit of jobs attempted to rehearse with more command (if more jobs are being touched, only this many will be rehearsed)")
 	fs.IntVar(&o.maxLimit, "max-limit", 35, "Upper limit of jobs attempted to rehearse with max command (if more jobs are being touched, only this many will be rehearsed)")
 
+	fs.StringVar(&o.selection, "rehearsal-selection", rehearse.SelectionSubset, fmt.Sprintf("Strategy to select the rehearsals with when more jobs are affected than the limit, one of %v", sets.List(rehearse.Selections)))
+	fs.StringVar(&o.jobHistoryPath, "job-history-path", "", "Path to a JSON file with the number of recent runs of each job which passed and failed, read on every rehearsal. Used by the coverage selection to prefer jobs which pass reliably")
+
 	fs.Var(&o.stickyLabelAuthors, "sticky-label-author", "PR Author for which the 'rehearsals-ack' label will not be removed upon a new push. Can be passed multiple times.")
 	fs.StringVar(&o.webhookSecretFile, "hmac-secret-file", "/etc/webhook/hmac", "Path to the file containing the GitHub HMAC secret.")
 
@@ -86,6 +97,8 @@ func gatherOptions() (options, error) {
 	fs.StringVar(&o.gcsCredentialsFile, "gcs-credentials-file", "/etc/gcs/service-account.json", "GCS Credentials file to upload affected jobs list")
 	fs.StringVar(&o.gcsBrowserPrefix, "gcs-browser-prefix", "https://gcsweb-ci.apps.ci.l2s4.p1.openshiftapps.com/gcs/test-platform-results/", "Prefix for the GCS Browser for viewing the affected jobs list")
 
+	fs.StringVar(&o.baselineResultsRoot, "baseline-results-root", "", "Root of the bucket the jobs upload their results to, like gs://test-platform-results, or a local directory with the same layout. When set, the results of the rehearsals are compared to the latest runs of the rehearsed jobs in a comment on the PR")
+
 	o.github.AddFlags(fs)
 	o.githubEventServerOptions.Bind(fs)
 	o.config.AddFlags(fs)
@@ -104,6 +117,13 @@ func (o *options) validate() error {
 	}
 	logrus.SetLevel(level)
 
+	if !rehearse.Selections.Has(o.selection) {
+		errs = append(errs, fmt.Errorf("--rehearsal-selection must be one of %v", sets.List(rehearse.Selections)))
+	}
+	if o.jobHistoryPath != "" && o.selection != rehearse.SelectionCoverage {
+		errs = append(errs, fmt.Errorf("--job-history-path is only used with --rehearsal-selection=%s", rehearse.SelectionCoverage))
+	}
+
 	if o.dryRun {
 		errs = append(errs, o.dryRunOptions.validate())
 	} else {
@@ -144,10 +164,13 @@ func rehearsalConfigFromOptions(o options) rehearse.RehearsalConfig {
 		NoTemplates:        o.noTemplates,
 		NoRegistry:         o.noRegistry,
 		NoClusterProfiles:  o.noClusterProfiles,
+		NoProwConfig:       o.noProwConfig,
 		DryRun:             o.dryRun,
 		NormalLimit:        o.normalLimit,
 		MoreLimit:          o.moreLimit,
 		MaxLimit:           o.maxLimit,
+		Selection:          o.selection,
+		JobHistoryPath:     o.jobHistoryPath,
 		StickyLabelAuthors: o.stickyLabelAuthors.StringSet(),
 		GCSBucket:          o.gcsBucket,
 		GCSCredentialsFile: o.gcsCredentialsFile,
@@ -178,6 +201,14 @@ func dryRun(o options, logger *logrus.Entry) error {
 		return fmt.Errorf("error determining affected jobs: %w: %s", err, "ERROR: pj-rehearse: misconfiguration")
 	}
 
+	impact, err := rc.DetermineProwConfigImpact(candidate, candidatePath, logger)
+	if err != nil {
+		return fmt.Errorf("error determining the impact of the Prow configuration changes: %w: %s", err, "ERROR: pj-rehearse: misconfiguration")
+	}
+	if impact != nil {
+		logger.Info(impact.Render())
+	}
+
 	prConfig, prRefs, imageStreamTags, presubmitsToRehearse, err := rc.SetupJobs(candidate, candidatePath, presubmits, periodics, changedTemplates, changedClusterProfiles, dro.limit, logger)
 	if err != nil {
 		return fmt.Errorf("error setting up jobs: %w: %s", err, "ERROR: pj-rehearse: setup failure")
@@ -188,7 +219,7 @@ func dryRun(o options, logger *logrus.Entry) error {
 			return fmt.Errorf("%s: %w", "ERROR: pj-rehearse: failed to validate rehearsal jobs", err)
 		}
 
-		_, err := rc.RehearseJobs(candidate, candidatePath, prRefs, imageStreamTags, presubmitsToRehearse, changedTemplates, changedClusterProfiles, logger)
+		_, err := rc.RehearseJobs(candidate, candidatePath, prRefs, imageStreamTags, presubmitsToRehearse, changedTemplates, changedClusterProfiles, nil, logger)
 		return err
 	}
 
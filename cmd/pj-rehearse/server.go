@@ -13,6 +13,7 @@ import (
 	prowconfig "k8s.io/test-infra/prow/config"
 	"k8s.io/test-infra/prow/git/v2"
 	"k8s.io/test-infra/prow/github"
+	prowio "k8s.io/test-infra/prow/io"
 	"k8s.io/test-infra/prow/pluginhelp"
 	"k8s.io/test-infra/prow/pod-utils/gcs"
 
@@ -22,6 +23,8 @@ import (
 
 const (
 	rehearsalNotifier  = "[REHEARSALNOTIFIER]"
+	rehearsalSummary   = "[REHEARSALSUMMARY]"
+	prowConfigImpact   = "[PROWCONFIGIMPACT]"
 	pjRehearse         = "pj-rehearse"
 	needsOkToTestLabel = "needs-ok-to-test"
 	rehearseNormal     = "/pj-rehearse"
@@ -44,6 +47,7 @@ type githubClient interface {
 	GetRef(org, repo, ref string) (string, error)
 	ListIssueComments(org, repo string, number int) ([]github.IssueComment, error)
 	DeleteComment(org, repo string, id int) error
+	EditComment(org, repo string, id int, comment string) error
 }
 
 type server struct {
@@ -51,6 +55,8 @@ type server struct {
 	gc  git.ClientFactory
 
 	rehearsalConfig rehearse.RehearsalConfig
+	// baselines, when set, are the latest results of the jobs the results of their rehearsals are compared to
+	baselines rehearse.BaselineResults
 }
 
 func (s *server) helpProvider(_ []prowconfig.OrgRepo) (*pluginhelp.PluginHelp, error) {
@@ -135,10 +141,25 @@ func serverFromOptions(o options) (*server, error) {
 	rehearsalConfig.ProwjobNamespace = c.ProwJobNamespace
 	rehearsalConfig.PodNamespace = c.PodNamespace
 
+	var baselines rehearse.BaselineResults
+	if o.baselineResultsRoot != "" {
+		credentialsFile := o.gcsCredentialsFile
+		if strings.HasPrefix(o.baselineResultsRoot, "/") {
+			// the opener refuses credentials for local paths
+			credentialsFile = ""
+		}
+		opener, err := prowio.NewOpener(context.Background(), credentialsFile, "")
+		if err != nil {
+			return nil, fmt.Errorf("error creating opener for the baseline results: %w", err)
+		}
+		baselines = rehearse.NewStorageBaselines(opener, o.baselineResultsRoot)
+	}
+
 	return &server{
 		ghc:             ghc,
 		gc:              gc,
 		rehearsalConfig: rehearsalConfig,
+		baselines:       baselines,
 	}, nil
 }
 
@@ -340,6 +361,15 @@ func (s *server) handlePotentialCommands(pullRequest *github.PullRequest, commen
 					s.reportFailure("unable to determine affected jobs", err, org, repo, user, number, true, false, logger)
 					continue
 				}
+
+				if impact, err := rc.DetermineProwConfigImpact(candidate, candidatePath, logger); err != nil {
+					logger.WithError(err).Error("couldn't determine the impact of the Prow configuration changes")
+					s.reportFailure("unable to determine the impact of the Prow configuration changes", err, org, repo, user, number, true, false, logger)
+				} else if impact != nil {
+					if err := s.upsertComment(org, repo, number, prowConfigImpact, fmt.Sprintf("@%s: %s", user, impact.Render())); err != nil {
+						logger.WithError(err).Error("failed to publish the impact of the Prow configuration changes")
+					}
+				}
 				requestedOnly := command != rehearseNormal && command != rehearseMore && command != rehearseMax && command != rehearseAutoAck
 
 				if requestedOnly {
@@ -377,7 +407,21 @@ func (s *server) handlePotentialCommands(pullRequest *github.PullRequest, commen
 						continue
 					}
 
-					success, err := rc.RehearseJobs(candidate, candidatePath, prRefs, imageStreamTags, presubmitsToRehearse, changedTemplates, changedClusterProfiles, logger)
+					if lines := getSelectionLines(presubmitsToRehearse, user); len(lines) > 0 {
+						if err := s.ghc.CreateComment(org, repo, number, strings.Join(lines, "\n")); err != nil {
+							logger.WithError(err).Error("failed to create comment")
+						}
+					}
+
+					var reporter rehearse.ResultReporter
+					if s.baselines != nil {
+						sha := pullRequest.Head.SHA
+						reporter = rehearse.NewResultSummary(number, s.baselines, func(summary string) error {
+							return s.publishSummary(org, repo, number, sha, summary)
+						}, logger)
+					}
+
+					success, err := rc.RehearseJobs(candidate, candidatePath, prRefs, imageStreamTags, presubmitsToRehearse, changedTemplates, changedClusterProfiles, reporter, logger)
 					if err != nil {
 						logger.WithError(err).Error("couldn't rehearse jobs")
 						s.reportFailure("failed to create rehearsal jobs", err, org, repo, user, number, true, false, logger)
@@ -508,6 +552,27 @@ func (s *server) getJobsTableLines(presubmits config.Presubmits, periodics confi
 	return append(lines, ""), jobCount
 }
 
+// getSelectionLines returns a Markdown formatted table of the reasons the rehearsals were selected
+// for, when they were selected by a strategy which records them
+func getSelectionLines(presubmitsToRehearse []*prowconfig.Presubmit, user string) []string {
+	var jobs []string
+	for _, presubmit := range presubmitsToRehearse {
+		if reason, ok := presubmit.Annotations[rehearse.AnnotationReason]; ok {
+			jobs = append(jobs, fmt.Sprintf("%s | %s", presubmit.Name, reason))
+		}
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+	lines := []string{
+		fmt.Sprintf("@%s: more jobs are affected by this change than can be rehearsed, so the following rehearsals were selected for their coverage of the change and their recent signal:", user),
+		"",
+		"Rehearsal | Reason",
+		"--- | ---",
+	}
+	return append(lines, jobs...)
+}
+
 func getAffectedJobFormattedList(presubmits config.Presubmits, periodics config.Periodics) []string {
 	var jobs []string
 	for repoName, tests := range presubmits {
@@ -541,6 +606,28 @@ func (s *server) getUsageDetailsLines() []string {
 	}
 }
 
+// publishSummary creates the summary of the results of the rehearsals for the head of the PR,
+// or updates it when it was already created
+func (s *server) publishSummary(org, repo string, number int, sha, summary string) error {
+	return s.upsertComment(org, repo, number, fmt.Sprintf("%s %s", rehearsalSummary, sha), summary)
+}
+
+// upsertComment creates a comment on the PR starting with the marker, or updates the
+// latest comment starting with it
+func (s *server) upsertComment(org, repo string, number int, marker, body string) error {
+	comment := fmt.Sprintf("%s\n%s", marker, body)
+	comments, err := s.ghc.ListIssueComments(org, repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to list comments: %w", err)
+	}
+	for i := len(comments) - 1; i >= 0; i-- {
+		if strings.HasPrefix(comments[i].Body, marker) {
+			return s.ghc.EditComment(org, repo, comments[i].ID, comment)
+		}
+	}
+	return s.ghc.CreateComment(org, repo, number, comment)
+}
+
 func (s *server) acknowledgeRehearsals(org, repo string, number int, logger *logrus.Entry) {
 	if err := s.ghc.AddLabel(org, repo, number, rehearse.RehearsalsAckLabel); err != nil {
 		logger.WithError(err).Errorf("failed to add '%s' label", rehearse.RehearsalsAckLabel)
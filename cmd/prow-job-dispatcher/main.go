@@ -31,6 +31,7 @@ import (
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/dispatcher"
 	"github.com/openshift/ci-tools/pkg/github/prcreation"
+	"github.com/openshift/ci-tools/pkg/prowconfigutils"
 	"github.com/openshift/ci-tools/pkg/rehearse"
 	"github.com/openshift/ci-tools/pkg/util/gzip"
 )
@@ -59,6 +60,19 @@ type options struct {
 	disableClusters flagutil.Strings
 	defaultCluster  string
 
+	capacityAware      bool
+	podScalerDataDir   string
+	unhealthyClusters  flagutil.Strings
+	prowHealth         bool
+	imbalanceTolerance float64
+	maxMoves           int
+	reportPath         string
+
+	simulate             bool
+	candidateConfigPath  string
+	jobVolumesPath       string
+	recordJobVolumesPath string
+
 	bumper.GitAuthorOptions
 	dispatcher.PrometheusOptions
 	prcreation.PRCreationOptions
@@ -82,6 +96,19 @@ func gatherOptions() options {
 	fs.Var(&o.disableClusters, "disable-cluster", "Disable this cluster. Does nothing if the cluster is disabled. Can be passed multiple times and must be disjoint with all --enable-cluster values.")
 	fs.StringVar(&o.defaultCluster, "default-cluster", "", "If passed, changes the default cluster to the specified value.")
 
+	fs.BoolVar(&o.capacityAware, "capacity-aware", false, "Keep the files on their clusters and only move the ones needed to balance the demand of the jobs by the declared capacity of the clusters, instead of dispatching all files anew.")
+	fs.StringVar(&o.podScalerDataDir, "pod-scaler-data-dir", "", "Directory with the data cached by the pod-scaler, used for the resource profiles of the jobs with --capacity-aware. All jobs are assumed to use the same resources if unset.")
+	fs.Var(&o.unhealthyClusters, "unhealthy-cluster", "Move the files away from this cluster with --capacity-aware, in addition to the clusters found unhealthy by --prow-cluster-health. Can be passed multiple times.")
+	fs.BoolVar(&o.prowHealth, "prow-cluster-health", true, "Consider the clusters disabled in Prow's configuration unhealthy with --capacity-aware, moving the files away from them.")
+	fs.Float64Var(&o.imbalanceTolerance, "imbalance-tolerance", 0.1, "How far above its fair share of the demand a cluster may be before files are moved away from it with --capacity-aware.")
+	fs.IntVar(&o.maxMoves, "max-moves", 0, "Maximum number of files moved to balance the clusters with --capacity-aware, 0 means no limit. Files on unhealthy clusters are always moved.")
+	fs.StringVar(&o.reportPath, "report-path", "", "Path to write the report explaining the moves made with --capacity-aware or projected with --simulate to.")
+
+	fs.BoolVar(&o.simulate, "simulate", false, "Only report how the volume of the jobs would shift between the clusters with the candidate config, the jobs which would move and those which would run where they cannot, without changing any config.")
+	fs.StringVar(&o.candidateConfigPath, "candidate-config-path", "", "Path to the candidate config to simulate, compared to the one in --config-path. Defaults to --config-path, to simulate only the changes requested by --enable-cluster, --disable-cluster and --default-cluster.")
+	fs.StringVar(&o.jobVolumesPath, "job-volumes-path", "", "Path to the volumes of the jobs recorded with --record-job-volumes-path, replayed instead of querying Prometheus.")
+	fs.StringVar(&o.recordJobVolumesPath, "record-job-volumes-path", "", "Path to record the volumes of the jobs queried from Prometheus to, so they can be replayed offline.")
+
 	o.GitAuthorOptions.AddFlags(fs)
 	o.PrometheusOptions.AddFlags(fs)
 	o.PRCreationOptions.AddFlags(fs)
@@ -118,6 +145,25 @@ func (o *options) validate() error {
 		return fmt.Errorf("--default-cluster value cannot be also be in --disable-cluster")
 	}
 
+	if !o.capacityAware && (o.podScalerDataDir != "" || len(o.unhealthyClusters.Strings()) != 0 || o.maxMoves != 0) {
+		return fmt.Errorf("--pod-scaler-data-dir, --unhealthy-cluster and --max-moves require --capacity-aware")
+	}
+	if !o.capacityAware && !o.simulate && o.reportPath != "" {
+		return fmt.Errorf("--report-path requires --capacity-aware or --simulate")
+	}
+	if o.simulate && (o.capacityAware || o.createPR) {
+		return fmt.Errorf("--simulate cannot be used with --capacity-aware or --create-pr")
+	}
+	if !o.simulate && o.candidateConfigPath != "" {
+		return fmt.Errorf("--candidate-config-path requires --simulate")
+	}
+	if o.jobVolumesPath != "" && o.recordJobVolumesPath != "" {
+		return fmt.Errorf("--job-volumes-path and --record-job-volumes-path are mutually exclusive")
+	}
+	if o.imbalanceTolerance < 0 || o.maxMoves < 0 {
+		return fmt.Errorf("--imbalance-tolerance and --max-moves cannot be negative")
+	}
+
 	if o.createPR {
 		if o.githubLogin == "" {
 			return fmt.Errorf("--github-login cannot be empty string")
@@ -447,42 +493,150 @@ func addEnabledClusters(config *dispatcher.Config, enabled sets.Set[string], get
 	}
 }
 
-func main() {
-	o := gatherOptions()
-	if err := o.validate(); err != nil {
-		logrus.WithError(err).Fatal("Failed to complete options.")
-	}
-
-	if o.createPR {
-		if err := o.PRCreationOptions.Finalize(); err != nil {
-			logrus.WithError(err).Fatal("Failed to finalize PR creation options")
-		}
+// prometheusAPI returns the API to query the volumes of the jobs from, which
+// replays a recording when running offline
+func (o *options) prometheusAPI() (dispatcher.PrometheusAPI, error) {
+	if o.jobVolumesPath != "" {
+		return dispatcher.LoadRecording(o.jobVolumesPath)
 	}
 
 	if o.PrometheusOptions.PrometheusPasswordPath != "" {
 		if err := secret.Add(o.PrometheusOptions.PrometheusPasswordPath); err != nil {
-			logrus.WithError(err).Fatal("Failed to start secrets agent")
+			return nil, fmt.Errorf("failed to start secrets agent: %w", err)
 		}
 	}
 
 	if o.PrometheusOptions.PrometheusBearerTokenPath != "" {
 		if err := secret.Add(o.PrometheusOptions.PrometheusBearerTokenPath); err != nil {
-			logrus.WithError(err).Fatal("Failed to start secrets agent")
+			return nil, fmt.Errorf("failed to start secrets agent: %w", err)
 		}
 	}
 
 	promClient, err := o.PrometheusOptions.NewPrometheusClient(secret.GetSecret)
 	if err != nil {
-		logrus.WithError(err).Fatal("Failed to create prometheus client.")
+		return nil, err
+	}
+	var v1api dispatcher.PrometheusAPI = prometheusapi.NewAPI(promClient)
+	if o.recordJobVolumesPath != "" {
+		v1api = dispatcher.RecordTo(v1api, o.recordJobVolumesPath)
 	}
+	return v1api, nil
+}
+
+// applyClusterFlags changes the default cluster and the clusters of the build farm as requested
+func (o *options) applyClusterFlags(config *dispatcher.Config) {
+	if o.defaultCluster != "" {
+		config.Default = api.Cluster(o.defaultCluster)
+	}
+
+	enabled := o.enableClusters.StringSet()
+	disabled := o.disableClusters.StringSet()
+	if len(disabled) > 0 {
+		removeDisabledClusters(config, disabled)
+	}
+	addEnabledClusters(config, enabled, getClusterProvider)
+}
+
+// dispatch assigns the files of the jobs to the clusters of the build farm of
+// the config, returning the report of the plan when the dispatch is capacity
+// aware
+func (o *options) dispatch(config *dispatcher.Config, jobVolumes map[string]float64) (string, error) {
+	if !o.capacityAware {
+		logrus.Info("Dispatching ...")
+		return "", dispatchJobs(context.TODO(), o.prowJobConfigDir, o.maxConcurrency, config, jobVolumes)
+	}
+	profiles := dispatcher.JobProfiles{}
+	if o.podScalerDataDir != "" {
+		var err error
+		if profiles, err = loadJobProfiles(o.podScalerDataDir); err != nil {
+			return "", fmt.Errorf("failed to load the resource profiles of the jobs: %w", err)
+		}
+	}
+	jobConfigs, err := loadJobConfigs(o.prowJobConfigDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to load the Prow jobs: %w", err)
+	}
+	logrus.Info("Planning the dispatch ...")
+	plan, err := planDispatch(jobConfigs, config, jobVolumes, profiles, o.unhealthy(), dispatcher.PlanOptions{Tolerance: o.imbalanceTolerance, MaxMoves: o.maxMoves})
+	if err != nil {
+		return "", fmt.Errorf("failed to plan the dispatch: %w", err)
+	}
+	applyPlan(config, plan)
+	return plan.Report(), nil
+}
+
+// prowDisabledClusters returns the clusters disabled in Prow's configuration
+var prowDisabledClusters = func() ([]string, error) {
+	return prowconfigutils.ProwDisabledClusters(nil)
+}
 
-	v1api := prometheusapi.NewAPI(promClient)
+// unhealthy returns the clusters to move the files away from: the ones disabled in Prow
+// and the ones passed with --unhealthy-cluster. When Prow cannot be asked, only the
+// latter are known to be unhealthy.
+func (o *options) unhealthy() sets.Set[string] {
+	unhealthy := o.unhealthyClusters.StringSet()
+	if !o.prowHealth {
+		return unhealthy
+	}
+	disabled, err := prowDisabledClusters()
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to get the clusters disabled in Prow, only the clusters passed with --unhealthy-cluster are considered unhealthy")
+		return unhealthy
+	}
+	return unhealthy.Insert(disabled...)
+}
+
+// simulateDispatch dispatches the jobs with the candidate config as the dispatch
+// would, after the cluster flags are applied to it, and compares where the
+// jobs run with the current config
+func (o *options) simulateDispatch(current, candidate *dispatcher.Config, jobVolumes map[string]float64) (*dispatcher.Simulation, error) {
+	o.applyClusterFlags(candidate)
+	if _, err := o.dispatch(candidate, jobVolumes); err != nil {
+		return nil, err
+	}
+	candidate.IndexBuildFarm()
+	jobConfigs, err := loadJobConfigs(o.prowJobConfigDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the Prow jobs: %w", err)
+	}
+	jobs := map[string][]prowconfig.JobBase{}
+	for path, jc := range jobConfigs {
+		jobs[path] = jobBases(jc)
+	}
+	return dispatcher.Simulate(current, candidate, jobs, jobVolumes), nil
+}
+
+func writeReport(report, path string) {
+	if path != "" {
+		if err := os.WriteFile(path, []byte(report), 0644); err != nil {
+			logrus.WithError(err).Fatalf("Failed to write the report to %s", path)
+		}
+	}
+	fmt.Print(report)
+}
+
+func main() {
+	o := gatherOptions()
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("Failed to complete options.")
+	}
+
+	if o.createPR {
+		if err := o.PRCreationOptions.Finalize(); err != nil {
+			logrus.WithError(err).Fatal("Failed to finalize PR creation options")
+		}
+	}
+
+	prometheus, err := o.prometheusAPI()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create prometheus client.")
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	y, m, d := time.Now().Add(-time.Duration(24*o.prometheusDaysBefore) * time.Hour).Date()
 	ts := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
-	jobVolumes, err := dispatcher.GetJobVolumesFromPrometheus(ctx, v1api, ts)
+	jobVolumes, err := dispatcher.GetJobVolumesFromPrometheus(ctx, prometheus, ts)
 	logrus.Debugf("we use %s as now to query prometheus", ts.UTC())
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to get job volumes from Prometheus.")
@@ -494,21 +648,31 @@ func main() {
 		logrus.WithError(err).Fatalf("Failed to load config from %q", o.configPath)
 	}
 
-	if o.defaultCluster != "" {
-		config.Default = api.Cluster(o.defaultCluster)
-	}
-
-	enabled := o.enableClusters.StringSet()
-	disabled := o.disableClusters.StringSet()
-	if len(disabled) > 0 {
-		removeDisabledClusters(config, disabled)
+	if o.simulate {
+		current := config
+		candidatePath := o.candidateConfigPath
+		if candidatePath == "" {
+			candidatePath = o.configPath
+		}
+		if config, err = dispatcher.LoadConfig(candidatePath); err != nil {
+			logrus.WithError(err).Fatalf("Failed to load candidate config from %q", candidatePath)
+		}
+		simulation, err := o.simulateDispatch(current, config, jobVolumes)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to simulate the dispatch")
+		}
+		writeReport(simulation.Report(), o.reportPath)
+		return
 	}
-	addEnabledClusters(config, enabled, getClusterProvider)
+	o.applyClusterFlags(config)
 
-	logrus.Info("Dispatching ...")
-	if err := dispatchJobs(context.TODO(), o.prowJobConfigDir, o.maxConcurrency, config, jobVolumes); err != nil {
+	report, err := o.dispatch(config, jobVolumes)
+	if err != nil {
 		logrus.WithError(err).Fatal("Failed to dispatch")
 	}
+	if report != "" {
+		writeReport(report, o.reportPath)
+	}
 	if err := dispatcher.SaveConfig(config, o.configPath); err != nil {
 		logrus.WithError(err).Fatalf("Failed to save config file to %s", o.configPath)
 	}
@@ -78,6 +78,50 @@ func TestValidate(t *testing.T) {
 			},
 			expected: fmt.Errorf("--prometheus-days-before must be between 1 and 15"),
 		},
+		{
+			name: "capacity-aware flags require --capacity-aware",
+			given: &options{
+				prowJobConfigDir:     "prow-jobs-dir",
+				configPath:           "some-path",
+				prometheusDaysBefore: 1,
+				maxMoves:             3,
+			},
+			expected: fmt.Errorf("--pod-scaler-data-dir, --unhealthy-cluster and --max-moves require --capacity-aware"),
+		},
+		{
+			name: "simulation cannot create a PR",
+			given: &options{
+				prowJobConfigDir:     "prow-jobs-dir",
+				configPath:           "some-path",
+				prometheusDaysBefore: 1,
+				simulate:             true,
+				createPR:             true,
+			},
+			expected: fmt.Errorf("--simulate cannot be used with --capacity-aware or --create-pr"),
+		},
+		{
+			name: "simulation reports offline",
+			given: &options{
+				prowJobConfigDir:     "prow-jobs-dir",
+				configPath:           "some-path",
+				prometheusDaysBefore: 1,
+				simulate:             true,
+				candidateConfigPath:  "candidate-path",
+				jobVolumesPath:       "volumes.json",
+				reportPath:           "report.txt",
+			},
+		},
+		{
+			name: "imbalance tolerance cannot be negative",
+			given: &options{
+				prowJobConfigDir:     "prow-jobs-dir",
+				configPath:           "some-path",
+				prometheusDaysBefore: 1,
+				capacityAware:        true,
+				imbalanceTolerance:   -1,
+			},
+			expected: fmt.Errorf("--imbalance-tolerance and --max-moves cannot be negative"),
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -454,3 +498,100 @@ func TestRemoveDisabledClusters(t *testing.T) {
 		})
 	}
 }
+
+func TestSimulateDispatch(t *testing.T) {
+	current := &dispatcher.Config{
+		Default: "api.ci",
+		BuildFarm: map[api.Cloud]map[api.Cluster]*dispatcher.BuildFarmConfig{
+			api.CloudAWS: {api.ClusterBuild01: {Filenames: sets.New[string]("ci-tools-presubmits.yaml")}},
+			api.CloudGCP: {api.ClusterBuild02: {Filenames: sets.New[string]("cluster-api-provider-gcp-presubmits.yaml", "cluster-etcd-operator-master-presubmits.yaml", "wildfly-operator-presubmits.yaml")}},
+		},
+	}
+	candidate := &dispatcher.Config{
+		Default: "api.ci",
+		BuildFarm: map[api.Cloud]map[api.Cluster]*dispatcher.BuildFarmConfig{
+			api.CloudAWS: {api.ClusterBuild01: {FilenamesRaw: []string{"ci-tools-presubmits.yaml"}}},
+			api.CloudGCP: {api.ClusterBuild02: {FilenamesRaw: []string{"cluster-api-provider-gcp-presubmits.yaml", "cluster-etcd-operator-master-presubmits.yaml", "wildfly-operator-presubmits.yaml"}}},
+		},
+	}
+	candidate.IndexBuildFarm()
+	o := &options{prowJobConfigDir: filepath.Join("testdata", "TestDispatchJobs", "basic_case"), maxConcurrency: 1}
+	if err := o.disableClusters.Set(string(api.ClusterBuild02)); err != nil {
+		t.Fatalf("failed to set the disabled clusters: %v", err)
+	}
+	jobVolumes := map[string]float64{
+		"pull-ci-openshift-ci-tools-master-breaking-changes":  23,
+		"pull-ci-openshift-ci-tools-master-e2e":               12,
+		"pull-ci-openshift-cluster-etcd-operator-master-unit": 6,
+	}
+
+	simulation, err := o.simulateDispatch(current, candidate, jobVolumes)
+	if err != nil {
+		t.Fatalf("failed to simulate the dispatch: %v", err)
+	}
+	if diff := cmp.Diff(map[api.Cluster]*dispatcher.ClusterLoad{
+		api.ClusterBuild01: {Current: 35, Projected: 41, Jobs: 25},
+		api.ClusterBuild02: {Current: 6},
+	}, simulation.Load); diff != "" {
+		t.Errorf("load differs from expected:\n%s", diff)
+	}
+	for _, move := range simulation.Moves {
+		if move.From != api.ClusterBuild02 || move.To != api.ClusterBuild01 {
+			t.Errorf("expected the jobs of the disabled cluster to move to build01, got %s moving from %s to %s", move.Job, move.From, move.To)
+		}
+	}
+}
+
+func TestUnhealthy(t *testing.T) {
+	testCases := []struct {
+		name       string
+		flags      []string
+		prowHealth bool
+		disabled   []string
+		prowErr    error
+		expected   sets.Set[string]
+	}{
+		{
+			name:       "clusters disabled in Prow are unhealthy",
+			prowHealth: true,
+			disabled:   []string{"build02"},
+			expected:   sets.New[string]("build02"),
+		},
+		{
+			name:       "flags add to the clusters disabled in Prow",
+			flags:      []string{"build01"},
+			prowHealth: true,
+			disabled:   []string{"build02"},
+			expected:   sets.New[string]("build01", "build02"),
+		},
+		{
+			name:       "flags are used when Prow cannot be asked",
+			flags:      []string{"build01"},
+			prowHealth: true,
+			prowErr:    fmt.Errorf("injected error"),
+			expected:   sets.New[string]("build01"),
+		},
+		{
+			name:     "Prow is not asked when disabled",
+			flags:    []string{"build01"},
+			disabled: []string{"build02"},
+			expected: sets.New[string]("build01"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			original := prowDisabledClusters
+			t.Cleanup(func() { prowDisabledClusters = original })
+			prowDisabledClusters = func() ([]string, error) { return tc.disabled, tc.prowErr }
+			o := &options{prowHealth: tc.prowHealth}
+			for _, cluster := range tc.flags {
+				if err := o.unhealthyClusters.Set(cluster); err != nil {
+					t.Fatalf("failed to set the unhealthy clusters: %v", err)
+				}
+			}
+			if diff := cmp.Diff(tc.expected, o.unhealthy()); diff != "" {
+				t.Errorf("unexpected unhealthy clusters: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowconfig "k8s.io/test-infra/prow/config"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/dispatcher"
+	pod_scaler "github.com/openshift/ci-tools/pkg/pod-scaler"
+	"github.com/openshift/ci-tools/pkg/util/gzip"
+)
+
+const (
+	// these are the names of the files the pod-scaler caches its data in
+	podScalerCPUData    = "container_cpu_usage_seconds_total.json"
+	podScalerMemoryData = "container_memory_working_set_bytes.json"
+	// podScalerQuantile is the quantile of the usage of a job we plan for, the
+	// same the pod-scaler recommends requests at
+	podScalerQuantile = 0.8
+)
+
+// loadJobProfiles loads the resource profiles of the jobs from the data cached by the pod-scaler
+func loadJobProfiles(dir string) (dispatcher.JobProfiles, error) {
+	var queries []*pod_scaler.CachedQuery
+	for _, name := range []string{podScalerCPUData, podScalerMemoryData} {
+		path := filepath.Join(dir, name)
+		raw, err := gzip.ReadFileMaybeGZIP(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var query pod_scaler.CachedQuery
+		if err := json.Unmarshal(raw, &query); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", path, err)
+		}
+		queries = append(queries, &query)
+	}
+	return dispatcher.JobProfilesFromPodScaler(queries[0], queries[1], podScalerQuantile), nil
+}
+
+func jobBases(jc *prowconfig.JobConfig) []prowconfig.JobBase {
+	var bases []prowconfig.JobBase
+	for k := range jc.PresubmitsStatic {
+		for _, job := range jc.PresubmitsStatic[k] {
+			bases = append(bases, job.JobBase)
+		}
+	}
+	for k := range jc.PostsubmitsStatic {
+		for _, job := range jc.PostsubmitsStatic[k] {
+			bases = append(bases, job.JobBase)
+		}
+	}
+	for _, job := range jc.Periodics {
+		bases = append(bases, job.JobBase)
+	}
+	return bases
+}
+
+// planDispatch plans the dispatch of the Prow job config files to the build farm
+// by the capacity of the clusters and the demand of the jobs, which is their volume
+// multiplied by their resource profile. Files stay where the config has them unless
+// the planner moves them.
+func planDispatch(jobConfigs map[string]*prowconfig.JobConfig, config *dispatcher.Config, jobVolumes map[string]float64, profiles dispatcher.JobProfiles, unhealthy sets.Set[string], options dispatcher.PlanOptions) (*dispatcher.Plan, error) {
+	fallback := profiles.Mean()
+	demandOf := func(job string) dispatcher.Resources {
+		profile, ok := profiles[job]
+		if !ok {
+			profile = fallback
+		}
+		return profile.Scale(jobVolumes[job])
+	}
+
+	var paths []string
+	for path := range jobConfigs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var files []dispatcher.FileDemand
+	pinned := map[api.Cluster]dispatcher.Resources{}
+	var errs []error
+	for _, path := range paths {
+		jc := jobConfigs[path]
+		file := dispatcher.FileDemand{Filename: filepath.Base(path), NodePools: sets.New[string]()}
+		if clouds := getCloudProvidersForE2ETests(jc); clouds.Len() == 1 {
+			file.Cloud = api.Cloud(sets.List(clouds)[0])
+		}
+		for _, job := range jobBases(jc) {
+			cluster, mayBeRelocated, err := config.DetermineClusterForJob(job, path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to determine cluster for the job %s in path %q: %w", job.Name, path, err))
+				continue
+			}
+			if !mayBeRelocated {
+				if config.IsInBuildFarm(cluster) != "" {
+					pinned[cluster] = pinned[cluster].Add(demandOf(job.Name))
+				}
+				continue
+			}
+			file.Demand = file.Demand.Add(demandOf(job.Name))
+			if _, ok := job.Labels[api.KVMDeviceLabel]; ok {
+				file.NodePools.Insert(dispatcher.NodePoolKVM)
+			}
+		}
+		if !config.MatchingPathRegEx(path) {
+			files = append(files, file)
+		}
+	}
+	if len(errs) != 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+
+	current := map[string]api.Cluster{}
+	for _, farm := range config.BuildFarm {
+		for cluster, filenames := range farm {
+			for filename := range filenames.Filenames {
+				current[filename] = cluster
+			}
+		}
+	}
+	clusters := config.ClusterStates(unhealthy)
+	for i := range clusters {
+		clusters[i].Pinned = pinned[clusters[i].Name]
+	}
+	return dispatcher.PlanDispatch(clusters, files, current, options)
+}
+
+// applyPlan records the files the plan dispatches to each cluster in the config
+func applyPlan(config *dispatcher.Config, plan *dispatcher.Plan) {
+	for cloudProvider, farm := range config.BuildFarm {
+		for cluster := range farm {
+			config.BuildFarm[cloudProvider][cluster] = &dispatcher.BuildFarmConfig{FilenamesRaw: plan.Assignments[cluster]}
+		}
+	}
+	for _, move := range plan.Moves {
+		logrus.WithFields(logrus.Fields{"file": move.Filename, "from": move.From, "to": move.To}).Info(move.Reason)
+	}
+}
+
+// loadJobConfigs loads the Prow job config files by their path
+func loadJobConfigs(prowJobConfigDir string) (map[string]*prowconfig.JobConfig, error) {
+	jobConfigs := map[string]*prowconfig.JobConfig{}
+	var errs []error
+	if err := filepath.WalkDir(prowJobConfigDir, func(path string, info fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to walk file/directory '%s': %w", path, err)
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".yaml") {
+			return nil
+		}
+		data, err := gzip.ReadFileMaybeGZIP(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read file %q: %w", path, err))
+			return nil
+		}
+		jc := &prowconfig.JobConfig{}
+		if err := yaml.Unmarshal(data, jc); err != nil {
+			errs = append(errs, fmt.Errorf("failed to unmarshal file %q: %w", path, err))
+			return nil
+		}
+		jobConfigs[path] = jc
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to load all Prow jobs: %w", err)
+	}
+	return jobConfigs, utilerrors.NewAggregate(errs)
+}
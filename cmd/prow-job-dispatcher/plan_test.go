@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openhistogram/circonusllhist"
+	"github.com/prometheus/common/model"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/dispatcher"
+	pod_scaler "github.com/openshift/ci-tools/pkg/pod-scaler"
+)
+
+func TestPlanDispatch(t *testing.T) {
+	newConfig := func() *dispatcher.Config {
+		return &dispatcher.Config{
+			Default: "api.ci",
+			BuildFarm: map[api.Cloud]map[api.Cluster]*dispatcher.BuildFarmConfig{
+				api.CloudAWS: {api.ClusterBuild01: {Filenames: sets.New[string]("ci-tools-presubmits.yaml", "cluster-etcd-operator-master-presubmits.yaml", "wildfly-operator-presubmits.yaml")}},
+				api.CloudGCP: {api.ClusterBuild02: {Filenames: sets.New[string]("cluster-api-provider-gcp-presubmits.yaml")}},
+			},
+			BuildFarmCloud: map[api.Cloud][]string{api.CloudAWS: {"build01"}, api.CloudGCP: {"build02"}},
+		}
+	}
+	jobVolumes := map[string]float64{
+		"pull-ci-openshift-ci-tools-master-breaking-changes":  10,
+		"pull-ci-openshift-ci-tools-master-e2e":               10,
+		"pull-ci-openshift-cluster-etcd-operator-master-unit": 16,
+	}
+	testCases := []struct {
+		name              string
+		profiles          dispatcher.JobProfiles
+		unhealthy         sets.Set[string]
+		expectedMoves     []dispatcher.Move
+		expectedBuildFarm map[api.Cloud]map[api.Cluster]*dispatcher.BuildFarmConfig
+	}{
+		{
+			name: "the smaller file is moved away from the hot cluster",
+			expectedMoves: []dispatcher.Move{
+				{Filename: "cluster-etcd-operator-master-presubmits.yaml", From: "build01", To: "build02", Reason: "build01 was under 200% of the pressure of the farm, above the tolerance of 110%, and build02 under 0%"},
+			},
+			expectedBuildFarm: map[api.Cloud]map[api.Cluster]*dispatcher.BuildFarmConfig{
+				"aws": {"build01": {FilenamesRaw: []string{"ci-tools-presubmits.yaml", "wildfly-operator-presubmits.yaml"}}},
+				"gcp": {"build02": {FilenamesRaw: []string{"cluster-api-provider-gcp-presubmits.yaml", "cluster-etcd-operator-master-presubmits.yaml"}}},
+			},
+		},
+		{
+			name: "the profiles of the jobs weigh their volume",
+			profiles: dispatcher.JobProfiles{
+				"pull-ci-openshift-ci-tools-master-breaking-changes":  {CPU: 1, Memory: 1e9},
+				"pull-ci-openshift-ci-tools-master-e2e":               {CPU: 1, Memory: 1e9},
+				"pull-ci-openshift-cluster-etcd-operator-master-unit": {CPU: 8, Memory: 8e9},
+			},
+			expectedMoves: []dispatcher.Move{
+				{Filename: "ci-tools-presubmits.yaml", From: "build01", To: "build02", Reason: "build01 was under 200% of the pressure of the farm, above the tolerance of 110%, and build02 under 0%"},
+			},
+			expectedBuildFarm: map[api.Cloud]map[api.Cluster]*dispatcher.BuildFarmConfig{
+				"aws": {"build01": {FilenamesRaw: []string{"cluster-etcd-operator-master-presubmits.yaml", "wildfly-operator-presubmits.yaml"}}},
+				"gcp": {"build02": {FilenamesRaw: []string{"ci-tools-presubmits.yaml", "cluster-api-provider-gcp-presubmits.yaml"}}},
+			},
+		},
+		{
+			name:      "files are moved away from unhealthy clusters",
+			unhealthy: sets.New[string]("build02"),
+			expectedMoves: []dispatcher.Move{
+				{Filename: "cluster-api-provider-gcp-presubmits.yaml", From: "build02", To: "build01", Reason: "build02 is unhealthy, and build01 had the least pressure of the clusters which can run its jobs"},
+			},
+			expectedBuildFarm: map[api.Cloud]map[api.Cluster]*dispatcher.BuildFarmConfig{
+				"aws": {"build01": {FilenamesRaw: []string{"ci-tools-presubmits.yaml", "cluster-api-provider-gcp-presubmits.yaml", "cluster-etcd-operator-master-presubmits.yaml", "wildfly-operator-presubmits.yaml"}}},
+				"gcp": {"build02": {}},
+			},
+		},
+	}
+	jobConfigs, err := loadJobConfigs(filepath.Join("testdata", "TestDispatchJobs", "basic_case"))
+	if err != nil {
+		t.Fatalf("failed to load job configs: %v", err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := newConfig()
+			plan, err := planDispatch(jobConfigs, config, jobVolumes, tc.profiles, tc.unhealthy, dispatcher.PlanOptions{Tolerance: 0.1})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedMoves, plan.Moves); diff != "" {
+				t.Errorf("unexpected moves: %s", diff)
+			}
+			applyPlan(config, plan)
+			if diff := cmp.Diff(tc.expectedBuildFarm, config.BuildFarm, cmpopts.IgnoreUnexported(dispatcher.BuildFarmConfig{}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected build farm: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadJobProfiles(t *testing.T) {
+	dir := t.TempDir()
+	meta := pod_scaler.FullMetadata{Metadata: api.Metadata{Org: "openshift", Repo: "ci-tools", Branch: "master"}, Target: "unit", Container: "test"}
+	for name, value := range map[string]float64{podScalerCPUData: 2, podScalerMemoryData: 3e9} {
+		hist := circonusllhist.New(circonusllhist.NoLookup())
+		if err := hist.RecordValue(value); err != nil {
+			t.Fatalf("failed to record value: %v", err)
+		}
+		raw, err := json.Marshal(pod_scaler.CachedQuery{
+			Data:           map[model.Fingerprint]*circonusllhist.HistogramWithoutLookups{1: circonusllhist.NewHistogramWithoutLookups(hist)},
+			DataByMetaData: map[pod_scaler.FullMetadata][]model.Fingerprint{meta: {1}},
+		})
+		if err != nil {
+			t.Fatalf("failed to marshal data: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), raw, 0644); err != nil {
+			t.Fatalf("failed to write data: %v", err)
+		}
+	}
+	profiles, err := loadJobProfiles(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(dispatcher.Resources{CPU: 2, Memory: 3e9}, profiles["pull-ci-openshift-ci-tools-master-unit"], cmpopts.EquateApprox(0.1, 0)); diff != "" {
+		t.Errorf("unexpected profile: %s", diff)
+	}
+	if _, err := loadJobProfiles(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without data")
+	}
+}
@@ -0,0 +1,122 @@
+// registry-lint checks the commands of the references in a step registry for
+// syntax errors, undeclared variables, missing errexit and writes to files
+// that do not outlive the step
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry/lint"
+)
+
+const (
+	formatJSON = "json"
+	formatText = "text"
+)
+
+type options struct {
+	registry       string
+	format         string
+	failOnWarnings bool
+}
+
+func (o *options) Validate() error {
+	if o.registry == "" {
+		return errors.New("--registry is required")
+	}
+	if o.format != formatJSON && o.format != formatText {
+		return fmt.Errorf("--format must be %s or %s", formatJSON, formatText)
+	}
+	return nil
+}
+
+func gatherOptions() (options, error) {
+	o := options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&o.registry, "registry", "", "Path to the step registry directory.")
+	fs.StringVar(&o.format, "format", formatJSON, fmt.Sprintf("Format of the findings, %s or %s.", formatJSON, formatText))
+	fs.BoolVar(&o.failOnWarnings, "fail-on-warnings", false, "Exit with an error on warnings as well as errors.")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return options{}, fmt.Errorf("could not parse input: %w", err)
+	}
+	return o, nil
+}
+
+// commandsFiles maps the names of references to the paths of their commands
+// files, relative to the registry
+func commandsFiles(root string) (map[string]string, error) {
+	files := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, info fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		name := strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))
+		if !strings.HasSuffix(name, load.CommandsSuffix) {
+			return nil
+		}
+		relpath, err := filepath.Rel(root, path)
+		if err != nil {
+			return fmt.Errorf("failed to determine relative path for %s: %w", path, err)
+		}
+		files[strings.TrimSuffix(name, load.CommandsSuffix)] = relpath
+		return nil
+	})
+	return files, err
+}
+
+func writeReport(w io.Writer, format string, report lint.Report) error {
+	if format == formatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	for _, finding := range report.Findings {
+		if _, err := fmt.Fprintln(w, finding.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	o, err := gatherOptions()
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to gather options")
+	}
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+
+	references, _, _, _, _, _, err := load.Registry(o.registry, load.RegistryFlag(0))
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to load registry")
+	}
+	files, err := commandsFiles(o.registry)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to find commands files")
+	}
+	report := lint.Report{Findings: lint.Registry(references)}
+	for i := range report.Findings {
+		report.Findings[i].File = files[report.Findings[i].Reference]
+	}
+	if err := writeReport(os.Stdout, o.format, report); err != nil {
+		logrus.WithError(err).Fatal("failed to write findings")
+	}
+	if report.HasErrors() || (o.failOnWarnings && len(report.Findings) > 0) {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,184 @@
+// registry-run-step runs a single reference of the step registry on the local
+// machine, in a container or a subprocess, with a seeded shared directory
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/flagutil"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/registry/local"
+)
+
+type options struct {
+	registry           string
+	reference          string
+	runtime            string
+	image              string
+	workDir            string
+	sharedDir          string
+	credentials        string
+	clusterProfile     string
+	clusterProfileName string
+	testName           string
+	jobName            string
+	env                flagutil.Strings
+	dependencies       flagutil.Strings
+	pullSpecs          flagutil.Strings
+	params             flagutil.Strings
+}
+
+func (o *options) Validate() error {
+	if o.registry == "" {
+		return errors.New("--registry is required")
+	}
+	if o.reference == "" {
+		return errors.New("--reference is required")
+	}
+	switch local.Runtime(o.runtime) {
+	case local.RuntimeProcess, local.RuntimePodman, local.RuntimeDocker:
+	default:
+		return fmt.Errorf("--runtime must be one of %s, %s or %s", local.RuntimeProcess, local.RuntimePodman, local.RuntimeDocker)
+	}
+	if (o.clusterProfile == "") != (o.clusterProfileName == "") {
+		return errors.New("--cluster-profile and --cluster-profile-name must be set together")
+	}
+	for flagName, values := range map[string]flagutil.Strings{"env": o.env, "dependency": o.dependencies, "pull-spec": o.pullSpecs, "param": o.params} {
+		if _, err := keyValues(values); err != nil {
+			return fmt.Errorf("--%s: %w", flagName, err)
+		}
+	}
+	return nil
+}
+
+func gatherOptions() (options, error) {
+	o := options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&o.registry, "registry", "", "Path to the step registry directory.")
+	fs.StringVar(&o.reference, "reference", "", "Name of the reference to run.")
+	fs.StringVar(&o.runtime, "runtime", string(local.RuntimeProcess), fmt.Sprintf("How to run the commands: %s, %s or %s.", local.RuntimeProcess, local.RuntimePodman, local.RuntimeDocker))
+	fs.StringVar(&o.image, "image", "", "Image to run the step in, required when the step runs in an image built by ci-operator.")
+	fs.StringVar(&o.workDir, "work-dir", "", "Directory for the shared, artifact and home directories of the step. A temporary directory is used if unset.")
+	fs.StringVar(&o.sharedDir, "shared-dir", "", "Directory whose files seed the shared directory of the step, e.g. a kubeconfig.")
+	fs.StringVar(&o.credentials, "credentials", "", "Directory with <namespace>/<name> directories mounted in place of the credentials of the step. Missing credentials are empty.")
+	fs.StringVar(&o.clusterProfile, "cluster-profile", "", "Directory mounted as the cluster profile of the step.")
+	fs.StringVar(&o.clusterProfileName, "cluster-profile-name", "", "Name of the cluster profile, required with --cluster-profile.")
+	fs.StringVar(&o.testName, "test-name", local.DefaultTestName, "Name of the test the step runs in.")
+	fs.StringVar(&o.jobName, "job-name", "", "Name of the job the step runs in, defaults to the name of the test.")
+	fs.Var(&o.env, "env", "Value of a parameter of the step, as NAME=value. Can be specified multiple times.")
+	fs.Var(&o.dependencies, "dependency", "Image a dependency of the step refers to, as ENV=stream:tag. Can be specified multiple times.")
+	fs.Var(&o.pullSpecs, "pull-spec", "Pull spec exposed for a dependency of the step, as ENV=pull-spec. Can be specified multiple times.")
+	fs.Var(&o.params, "param", "Value of a variable ci-operator exposes to the steps of the test, e.g. RELEASE_IMAGE_LATEST=pull-spec, as NAME=value. Can be specified multiple times.")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return options{}, fmt.Errorf("could not parse input: %w", err)
+	}
+	return o, nil
+}
+
+func keyValues(values flagutil.Strings) (map[string]string, error) {
+	ret := map[string]string{}
+	for _, value := range values.Strings() {
+		key, v, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%q must be of the form KEY=value", value)
+		}
+		ret[key] = v
+	}
+	return ret, nil
+}
+
+func absolute(paths ...*string) error {
+	for _, path := range paths {
+		if *path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*path)
+		if err != nil {
+			return fmt.Errorf("failed to determine absolute path of %s: %w", *path, err)
+		}
+		*path = abs
+	}
+	return nil
+}
+
+func main() {
+	o, err := gatherOptions()
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to gather options")
+	}
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+	if o.workDir == "" {
+		if o.workDir, err = os.MkdirTemp("", "registry-run-step-"); err != nil {
+			logrus.WithError(err).Fatal("failed to create work directory")
+		}
+	}
+	if err := absolute(&o.workDir, &o.sharedDir, &o.credentials, &o.clusterProfile); err != nil {
+		logrus.WithError(err).Fatal("invalid paths")
+	}
+
+	references, chains, workflows, _, _, observers, err := load.Registry(o.registry, load.RegistryFlag(0))
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to load registry")
+	}
+	env, _ := keyValues(o.env)
+	dependencies, _ := keyValues(o.dependencies)
+	pullSpecs, _ := keyValues(o.pullSpecs)
+	params, _ := keyValues(o.params)
+	resolver := registry.NewResolver(references, chains, workflows, observers)
+	step, err := local.Resolve(resolver, o.reference, api.TestEnvironment(env), api.TestDependencies(dependencies))
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to resolve reference")
+	}
+	execution, err := local.Prepare(step, local.Options{
+		Runtime:            local.Runtime(o.runtime),
+		Image:              o.image,
+		Dependencies:       pullSpecs,
+		WorkDir:            o.workDir,
+		SharedDir:          o.sharedDir,
+		Credentials:        o.credentials,
+		ClusterProfile:     o.clusterProfile,
+		ClusterProfileName: api.ClusterProfile(o.clusterProfileName),
+		TestName:           o.testName,
+		JobName:            o.jobName,
+		Parameters:         params,
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to prepare the step")
+	}
+	if execution.Runtime == local.RuntimeProcess && len(step.Credentials) != 0 {
+		logrus.Warn("Credentials are only mounted when running in a container, the step will not find them at their mount paths.")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	cmd := execution.Command(ctx)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	logrus.Infof("Running step %s with %s, the shared directory is %s", step.As, execution.Runtime, execution.SharedDir)
+	runErr := cmd.Run()
+	cancel()
+	logrus.Infof("The shared and artifact directories of the step are in %s", o.workDir)
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) {
+			logrus.Errorf("Step %s failed with exit code %d", step.As, exitErr.ExitCode())
+			os.Exit(exitErr.ExitCode())
+		}
+		logrus.WithError(runErr).Fatalf("failed to run step %s", step.As)
+	}
+	logrus.Infof("Step %s succeeded", step.As)
+}
@@ -30,7 +30,7 @@ var (
 			Name: "ci_operator_error_rate",
 			Help: "number of errors, sorted by label/type",
 		},
-		[]string{"job_name", "type", "state", "reason", "cluster"},
+		[]string{"job_name", "type", "state", "reason", "root_cause", "cluster"},
 	)
 	podScalerHighResourceCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -39,10 +39,17 @@ var (
 		},
 		[]string{"workload_name", "workload_type", "configured_amount", "determined_amount", "resource_type"},
 	)
+	buildCacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ci_operator_build_cache_lookups",
+			Help: "number of lookups of pipeline images in the build cache, sorted by image and whether they were hits or misses",
+		},
+		[]string{"job_name", "image", "result"},
+	)
 )
 
 func init() {
-	prometheus.MustRegister(errorRate, podScalerHighResourceCounter)
+	prometheus.MustRegister(errorRate, podScalerHighResourceCounter, buildCacheLookups)
 }
 
 type options struct {
@@ -114,6 +121,19 @@ func validatePodScalerRequest(request *results.PodScalerRequest) error {
 	return nil
 }
 
+func validateBuildCacheRequest(request *results.BuildCacheRequest) error {
+	if request.JobName == "" {
+		return fmt.Errorf("job_name field in request is empty")
+	}
+	if request.Image == "" {
+		return fmt.Errorf("image field in request is empty")
+	}
+	if request.Hits < 0 || request.Misses < 0 {
+		return fmt.Errorf("hits and misses in request cannot be negative")
+	}
+	return nil
+}
+
 func handleError(w http.ResponseWriter, err error) {
 	w.WriteHeader(http.StatusBadRequest)
 	fmt.Fprint(w, err)
@@ -121,11 +141,12 @@ func handleError(w http.ResponseWriter, err error) {
 
 func withErrorRate(request *results.Request) {
 	labels := prometheus.Labels{
-		"job_name": request.JobName,
-		"type":     request.Type,
-		"state":    request.State,
-		"reason":   request.Reason,
-		"cluster":  request.Cluster,
+		"job_name":   request.JobName,
+		"type":       request.Type,
+		"state":      request.State,
+		"reason":     request.Reason,
+		"root_cause": request.RootCause,
+		"cluster":    request.Cluster,
 	}
 	errorRate.With(labels).Inc()
 }
@@ -141,6 +162,12 @@ func recordHighResource(request *results.PodScalerRequest) {
 	podScalerHighResourceCounter.With(labels).Inc()
 }
 
+func recordBuildCacheLookups(request *results.BuildCacheRequest) {
+	for result, count := range map[string]int{"hit": request.Hits, "miss": request.Misses} {
+		buildCacheLookups.With(prometheus.Labels{"job_name": request.JobName, "image": request.Image, "result": result}).Add(float64(count))
+	}
+}
+
 type validator interface {
 	Validate(username, password string) bool
 }
@@ -211,6 +238,32 @@ func handlePodScalerResult() http.HandlerFunc {
 	}
 }
 
+func handleBuildCacheResult() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		bytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleError(w, fmt.Errorf("unable to read build cache request body: %w", err))
+			return
+		}
+
+		request := &results.BuildCacheRequest{}
+		if err = json.Unmarshal(bytes, request); err != nil {
+			handleError(w, fmt.Errorf("unable to decode build cache request body: %w", err))
+			return
+		}
+
+		if err := validateBuildCacheRequest(request); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		recordBuildCacheLookups(request)
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{"request": request, "duration": time.Since(start).String()}).Info("Build cache request processed")
+	}
+}
+
 func main() {
 	o, err := gatherOptions()
 	if err != nil {
@@ -231,6 +284,7 @@ func main() {
 
 	http.Handle("/result", loginHandler(validator, handleCIOperatorResult()))
 	http.Handle("/pod-scaler", loginHandler(validator, handlePodScalerResult()))
+	http.Handle("/build-cache", loginHandler(validator, handleBuildCacheResult()))
 
 	metrics.ExposeMetrics("result-aggregator", prowConfig.PushGateway{}, flagutil.DefaultMetricsPort)
 
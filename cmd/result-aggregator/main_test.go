@@ -234,3 +234,40 @@ func TestValidatePodScalerRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateBuildCacheRequest(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		request  *results.BuildCacheRequest
+		expected error
+	}{
+		{
+			name:    "everything ok",
+			request: &results.BuildCacheRequest{JobName: "job", Image: "src", Hits: 1},
+		},
+		{
+			name:     "empty job name",
+			request:  &results.BuildCacheRequest{Image: "src", Misses: 1},
+			expected: fmt.Errorf("job_name field in request is empty"),
+		},
+		{
+			name:     "empty image",
+			request:  &results.BuildCacheRequest{JobName: "job", Hits: 1},
+			expected: fmt.Errorf("image field in request is empty"),
+		},
+		{
+			name:     "negative lookups",
+			request:  &results.BuildCacheRequest{JobName: "job", Image: "src", Misses: -1},
+			expected: fmt.Errorf("hits and misses in request cannot be negative"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := validateBuildCacheRequest(testCase.request)
+			if diff := cmp.Diff(testCase.expected, actual, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("actual error doesn't match expected error, diff: %v", diff)
+			}
+		})
+	}
+}
@@ -233,6 +233,20 @@ func ReleaseImageTagLink(name, tag string) StepLink {
 	}
 }
 
+// ImageStreamFor returns the ImageStream and tag in the test namespace that
+// a link describes. The tag is empty when the link covers the full stream.
+// Links that do not describe objects in the test namespace are not resolved.
+func ImageStreamFor(link StepLink) (stream, tag string, ok bool) {
+	switch l := link.(type) {
+	case *internalImageStreamTagLink:
+		return l.name, l.tag, true
+	case *internalImageStreamLink:
+		return l.name, "", true
+	default:
+		return "", "", false
+	}
+}
+
 func Comparer() cmp.Option {
 	return cmp.AllowUnexported(
 		internalImageStreamLink{},
@@ -568,6 +582,9 @@ func mergeSteps(into, from CIOperatorStepDetails) CIOperatorStepDetails {
 	if into.Substeps == nil {
 		into.Substeps = from.Substeps
 	}
+	if into.Leases == nil {
+		into.Leases = from.Leases
+	}
 
 	return into
 }
@@ -589,6 +606,29 @@ type CIOperatorStepDetailInfo struct {
 	Manifests    []ctrlruntimeclient.Object `json:"manifests,omitempty"`
 	LogURL       string                     `json:"log_url,omitempty"`
 	Failed       *bool                      `json:"failed,omitempty"`
+	Leases       []CIOperatorStepLease      `json:"leases,omitempty"`
+}
+
+// +k8s:deepcopy-gen=false
+// CIOperatorStepLease describes the acquisition of the leases of a step
+type CIOperatorStepLease struct {
+	ResourceType string `json:"resource_type"`
+	Count        uint   `json:"count"`
+	// Free and Leased are the numbers of resources of the type in these
+	// states before the acquisition
+	Free   int `json:"free"`
+	Leased int `json:"leased"`
+	// WaitSamples is the number of past acquisitions the wait percentiles are
+	// computed from
+	WaitSamples int           `json:"wait_samples,omitempty"`
+	WaitP50     time.Duration `json:"wait_p50,omitempty"`
+	WaitP90     time.Duration `json:"wait_p90,omitempty"`
+	WaitP99     time.Duration `json:"wait_p99,omitempty"`
+	// PredictedWait is set when there was enough information to predict the
+	// wait for the leases
+	PredictedWait *time.Duration `json:"predicted_wait,omitempty"`
+	// Wait is how long the acquisition took, when it succeeded
+	Wait *time.Duration `json:"wait,omitempty"`
 }
 
 func (c *CIOperatorStepDetailInfo) UnmarshalJSON(data []byte) error {
@@ -628,6 +668,10 @@ func (c *CIOperatorStepDetailInfo) UnmarshalJSON(data []byte) error {
 
 const CIOperatorStepGraphJSONFilename = "ci-operator-step-graph.json"
 
+// ResourceUsageJSONFilename is the artifact recording the resources requested
+// and used by the pods and builds of an execution
+const ResourceUsageJSONFilename = "resource-usage.json"
+
 // StepGraphJSONURL takes a base url like https://storage.googleapis.com/test-platform-results/pr-logs/pull/openshift_ci-tools/999/pull-ci-openshift-ci-tools-master-validate-vendor/1283812971092381696
 // and returns the full url for the step graph json document.
 func StepGraphJSONURL(baseJobURL string) string {
@@ -421,6 +421,53 @@ func TestLinkForImage(t *testing.T) {
 	}
 }
 
+func TestImageStreamFor(t *testing.T) {
+	var testCases = []struct {
+		name           string
+		link           StepLink
+		expectedStream string
+		expectedTag    string
+		expectedOK     bool
+	}{
+		{
+			name:           "pipeline image",
+			link:           InternalImageLink(PipelineImageStreamTagReferenceSource),
+			expectedStream: "pipeline",
+			expectedTag:    "src",
+			expectedOK:     true,
+		},
+		{
+			name:           "release payload",
+			link:           ReleasePayloadImageLink(LatestReleaseName),
+			expectedStream: "release",
+			expectedTag:    "latest",
+			expectedOK:     true,
+		},
+		{
+			name:           "full release stream",
+			link:           ReleaseImagesLink(InitialReleaseName),
+			expectedStream: "stable-initial",
+			expectedOK:     true,
+		},
+		{
+			name: "external image",
+			link: ExternalImageLink(ImageStreamTagReference{Namespace: "ns", Name: "name", Tag: "tag"}),
+		},
+		{
+			name: "images ready",
+			link: ImagesReadyLink(),
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			stream, tag, ok := ImageStreamFor(testCase.link)
+			if stream != testCase.expectedStream || tag != testCase.expectedTag || ok != testCase.expectedOK {
+				t.Errorf("expected (%q, %q, %t), got (%q, %q, %t)", testCase.expectedStream, testCase.expectedTag, testCase.expectedOK, stream, tag, ok)
+			}
+		})
+	}
+}
+
 func TestCIOperatorStepGraphMergeFromKeepsAllData(t *testing.T) {
 	testCases := []struct {
 		name         string
@@ -27,6 +27,9 @@ type JobSpec struct {
 	// if set, any new artifacts will be a child of this object
 	owner *meta.OwnerReference
 
+	// changedFiles lists the files changed by the pull requests under test
+	changedFiles ChangedFilesProvider
+
 	Metadata               Metadata
 	Target                 string
 	TargetAdditionalSuffix string
@@ -58,6 +61,20 @@ func (s *JobSpec) SetOwner(owner *meta.OwnerReference) {
 	s.owner = owner
 }
 
+// ChangedFilesProvider lists the files changed by the pull requests under
+// test. Implementations are expected to fetch the changes at most once.
+type ChangedFilesProvider func() ([]string, error)
+
+// ChangedFiles returns the provider of the files changed by the pull requests
+// under test, or nil if the job does not test pull requests.
+func (s *JobSpec) ChangedFiles() ChangedFilesProvider {
+	return s.changedFiles
+}
+
+func (s *JobSpec) SetChangedFiles(changedFiles ChangedFilesProvider) {
+	s.changedFiles = changedFiles
+}
+
 // Inputs returns the definition of the job as an input to
 // the execution graph.
 func (s *JobSpec) Inputs() InputDefinition {
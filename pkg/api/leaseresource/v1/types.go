@@ -0,0 +1,63 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ResourceStateFree is the state of resources that can be leased. Resources
+	// without a state are free.
+	ResourceStateFree = "free"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Owner",type=string,JSONPath=`.status.owner`
+
+// LeaseResource is a resource that can be leased by ci-operator without a
+// Boskos server, such as a slice of cloud quota. The status is updated by the
+// lessee with the resource version of the object it read, so that concurrent
+// acquisitions of the same resource cannot both succeed.
+type LeaseResource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	// +kubebuilder:validation:Required
+	Spec   LeaseResourceSpec   `json:"spec"`
+	Status LeaseResourceStatus `json:"status,omitempty"`
+}
+
+type LeaseResourceSpec struct {
+	// Type is the resource type tests request leases for
+	// +kubebuilder:validation:MinLength=1
+	Type string `json:"type"`
+}
+
+type LeaseResourceStatus struct {
+	// State is the state of the resource, "free" when it is not leased
+	State string `json:"state,omitempty"`
+	// Owner identifies the lessee
+	Owner string `json:"owner,omitempty"`
+	// LastUpdate is the last time the state changed or the lessee sent a
+	// heartbeat
+	LastUpdate metav1.Time `json:"lastUpdate,omitempty"`
+}
+
+// State returns the state of the resource, defaulting to free
+func (r *LeaseResource) State() string {
+	if r.Status.State == "" {
+		return ResourceStateFree
+	}
+	return r.Status.State
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LeaseResourceList is a list of LeaseResource resources
+type LeaseResourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []LeaseResource `json:"items"`
+}
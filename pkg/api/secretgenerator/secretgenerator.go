@@ -90,6 +90,19 @@ type SecretItem struct {
 	Fields   []FieldGenerator    `json:"fields,omitempty"`
 	Notes    string              `json:"notes,omitempty"`
 	Params   map[string][]string `json:"params,omitempty"`
+	// Rotation configures how ci-secret-rotator rotates the fields of the item
+	Rotation *Rotation `json:"rotation,omitempty"`
+}
+
+// Rotation holds the commands which complete the rotation of an item once its
+// new version has been propagated to the clusters. The commands find a file
+// per field with the new version in $CURRENT_DIR and with the previous one in
+// $PREVIOUS_DIR.
+type Rotation struct {
+	// Verify checks that the consumers of the item work with the new version.
+	Verify string `json:"verify,omitempty"`
+	// Revoke revokes the previous version of the item, e.g. deletes a key.
+	Revoke string `json:"revoke,omitempty"`
 }
 
 func (si SecretItem) generateItemsFromParams() ([]SecretItem, error) {
@@ -119,6 +132,10 @@ func (si SecretItem) generateItemsFromParams() ([]SecretItem, error) {
 					}
 				}
 				argItem.Notes = replaceParameter(paramName, param, argItem.Notes)
+				if argItem.Rotation != nil {
+					argItem.Rotation.Verify = replaceParameter(paramName, param, argItem.Rotation.Verify)
+					argItem.Rotation.Revoke = replaceParameter(paramName, param, argItem.Rotation.Revoke)
+				}
 				itemsProcessed = append(itemsProcessed, argItem)
 			}
 		}
@@ -2,7 +2,9 @@ package api
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
@@ -875,6 +877,12 @@ type RegistryChain struct {
 	Environment []StepParameter `json:"env,omitempty"`
 	// Leases lists resources that should be acquired for the test.
 	Leases []StepLease `json:"leases,omitempty"`
+	// Retry is the retry policy for steps in the chain which do not
+	// define their own.
+	Retry *RetryPolicy `json:"retry,omitempty"`
+	// Parallel defines that all steps of the chain run at the same time.
+	// See TestStep.Parallel for how their SHARED_DIR changes are merged.
+	Parallel bool `json:"parallel,omitempty"`
 }
 
 // RegistryWorkflowConfig is the struct that workflow references are unmarshalled into.
@@ -1003,6 +1011,85 @@ type LiteralTestStep struct {
 	// RunAsScript defines if this step should be executed as a script mounted
 	// in the test container instead of being executed directly via bash
 	RunAsScript *bool `json:"run_as_script,omitempty"`
+	// Retry defines if and how this step should be executed again when it
+	// fails. Every attempt runs in a separate pod, so the logs and artifacts
+	// of all attempts are preserved.
+	Retry *RetryPolicy `json:"retry,omitempty"`
+	// RunIfChanged is a regex that will result in the step only running if
+	// something that matches it was changed in the pull requests under test.
+	RunIfChanged string `json:"run_if_changed,omitempty"`
+	// SkipIfOnlyChanged is a regex that will result in the step being skipped
+	// if all files changed in the pull requests under test match that regex.
+	SkipIfOnlyChanged string `json:"skip_if_only_changed,omitempty"`
+	// ParallelGroup is the name of the group of adjacent steps this step
+	// runs concurrently with. It is set by the registry resolver from the
+	// `parallel` fields of test steps and chains and cannot be configured.
+	ParallelGroup string `json:"parallel_group,omitempty"`
+}
+
+// changeRegexps holds the compiled `run_if_changed` and `skip_if_only_changed`
+// expressions, as the same steps are evaluated in every phase and test
+var changeRegexps sync.Map
+
+func compileChangeRegexp(expr string) (*regexp.Regexp, error) {
+	if re, ok := changeRegexps.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	changeRegexps.Store(expr, re)
+	return re, nil
+}
+
+// RunsAgainstChanges determines whether the step should run for the given
+// changed files. Steps which do not define `run_if_changed` nor
+// `skip_if_only_changed` always run.
+func (s *LiteralTestStep) RunsAgainstChanges(changes []string) (bool, error) {
+	if s.RunIfChanged != "" {
+		re, err := compileChangeRegexp(s.RunIfChanged)
+		if err != nil {
+			return false, fmt.Errorf("could not compile run_if_changed regex for step %s: %w", s.As, err)
+		}
+		for _, change := range changes {
+			if re.MatchString(change) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	if s.SkipIfOnlyChanged != "" {
+		re, err := compileChangeRegexp(s.SkipIfOnlyChanged)
+		if err != nil {
+			return false, fmt.Errorf("could not compile skip_if_only_changed regex for step %s: %w", s.As, err)
+		}
+		for _, change := range changes {
+			if !re.MatchString(change) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// RetryPolicy defines when a failed step is executed again. Without any
+// exit codes or reasons, every failure is retried. When both are set, a
+// failure matching either of them is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the step is executed,
+	// including the first attempt, and at most 5.
+	MaxAttempts int `json:"max_attempts"`
+	// Backoff is how long to wait before the second attempt. The delay
+	// doubles for every subsequent attempt.
+	Backoff *prowv1.Duration `json:"backoff,omitempty"`
+	// ExitCodes restricts retries to failures where the test container
+	// exited with one of the given codes.
+	ExitCodes []int32 `json:"exit_codes,omitempty"`
+	// Reasons restricts retries to failures with one of the given reasons,
+	// e.g. `pod_pending`.
+	Reasons []string `json:"reasons,omitempty"`
 }
 
 // StepParameter is a variable set by the test, with an optional default.
@@ -1013,8 +1100,32 @@ type StepParameter struct {
 	Default *string `json:"default,omitempty"`
 	// Documentation is a textual description of the parameter.
 	Documentation string `json:"documentation,omitempty"`
+	// Type optionally restricts the values of the parameter. Values are not
+	// checked when it is not set.
+	Type StepParameterType `json:"type,omitempty"`
+	// AllowedValues are the values an `enum` parameter can be set to.
+	AllowedValues []string `json:"allowed_values,omitempty"`
+	// Pattern is the regular expression values of a `regex` parameter must
+	// match, anchored at both ends.
+	Pattern string `json:"pattern,omitempty"`
 }
 
+// StepParameterType restricts the values of a parameter
+type StepParameterType string
+
+const (
+	// StepParameterTypeBool parameters are `true` or `false`.
+	StepParameterTypeBool StepParameterType = "bool"
+	// StepParameterTypeInt parameters are decimal integers.
+	StepParameterTypeInt StepParameterType = "int"
+	// StepParameterTypeEnum parameters are one of their allowed values.
+	StepParameterTypeEnum StepParameterType = "enum"
+	// StepParameterTypeRegex parameters match their pattern.
+	StepParameterTypeRegex StepParameterType = "regex"
+	// StepParameterTypeDuration parameters are durations like `1h30m`.
+	StepParameterTypeDuration StepParameterType = "duration"
+)
+
 // CredentialReference defines a secret to mount into a step and where to mount it.
 type CredentialReference struct {
 	// Namespace is where the source secret exists.
@@ -1076,6 +1187,23 @@ type TestStep struct {
 	Reference *string `json:"ref,omitempty"`
 	// Chain is the name of a step chain reference.
 	Chain *string `json:"chain,omitempty"`
+	// RunIfChanged is a regex that will result in the step only running if
+	// something that matches it was changed. It takes precedence over the
+	// conditions of a referenced step and applies to the steps of a chain
+	// which do not declare their own.
+	RunIfChanged string `json:"run_if_changed,omitempty"`
+	// SkipIfOnlyChanged is a regex that will result in the step being skipped
+	// if all changed files match that regex. It takes precedence over the
+	// conditions of a referenced step and applies to the steps of a chain
+	// which do not declare their own.
+	SkipIfOnlyChanged string `json:"skip_if_only_changed,omitempty"`
+	// Parallel defines that the step runs at the same time as the adjacent
+	// steps of the same phase which also set it. When set on a chain, its
+	// steps also run in parallel with each other. Every step in a parallel
+	// group starts with the same SHARED_DIR; once all of them finish, their
+	// changes are merged back and it is an error for two steps to change the
+	// same file differently.
+	Parallel bool `json:"parallel,omitempty"`
 }
 
 // MultiStageTestConfiguration is a flexible configuration mode that allows tighter control over
@@ -345,3 +345,44 @@ func TestInputImageTagStepConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestRunsAgainstChanges(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		step     LiteralTestStep
+		changes  []string
+		expected bool
+	}{{
+		name:     "no conditions",
+		changes:  []string{"README.md"},
+		expected: true,
+	}, {
+		name:     "run_if_changed matches a change",
+		step:     LiteralTestStep{RunIfChanged: `^pkg/`},
+		changes:  []string{"README.md", "pkg/api/types.go"},
+		expected: true,
+	}, {
+		name:    "run_if_changed matches no change",
+		step:    LiteralTestStep{RunIfChanged: `^pkg/`},
+		changes: []string{"README.md"},
+	}, {
+		name:    "skip_if_only_changed matches all changes",
+		step:    LiteralTestStep{SkipIfOnlyChanged: `\.md$`},
+		changes: []string{"README.md", "docs/index.md"},
+	}, {
+		name:     "skip_if_only_changed does not match a change",
+		step:     LiteralTestStep{SkipIfOnlyChanged: `\.md$`},
+		changes:  []string{"README.md", "main.go"},
+		expected: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.step.RunsAgainstChanges(tc.changes)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -148,32 +148,79 @@ func GetAllConfigs(releaseRepoPath string) (*ReleaseRepoConfig, error) {
 // manipulations are propagated in the error return value. Errors occurred during the actual config loading are not
 // propagated, but the returned struct field will have a nil value in the appropriate field. The error is only logged.
 func GetAllConfigsFromSHA(releaseRepoPath, sha string) (*ReleaseRepoConfig, error) {
+	var config *ReleaseRepoConfig
+	err := atRevision(releaseRepoPath, sha, func() error {
+		var err error
+		if config, err = GetAllConfigs(releaseRepoPath); err != nil {
+			return fmt.Errorf("failed to get all configs: %w", err)
+		}
+		return nil
+	})
+	return config, err
+}
+
+// atRevision checks out the given revision of the release repo, calls load and then checks
+// out back the revision that was checked out in the working copy when this method was called.
+func atRevision(releaseRepoPath, sha string, load func() error) error {
 	currentSHA, err := revParse(releaseRepoPath, "HEAD")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get SHA of current HEAD: %w", err)
+		return fmt.Errorf("failed to get SHA of current HEAD: %w", err)
 	}
 	restoreRev, err := revParse(releaseRepoPath, "--abbrev-ref", "HEAD")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current branch: %w", err)
+		return fmt.Errorf("failed to get current branch: %w", err)
 	}
 	if restoreRev == "HEAD" {
 		restoreRev = currentSHA
 	}
 	if err := gitCheckout(releaseRepoPath, sha); err != nil {
-		return nil, fmt.Errorf("could not checkout worktree: %w", err)
+		return fmt.Errorf("could not checkout worktree: %w", err)
 	}
 
 	var errs []error
-	config, err := GetAllConfigs(releaseRepoPath)
-	if err != nil {
-		errs = append(errs, fmt.Errorf("failed to get all configs: %w", err))
+	if err := load(); err != nil {
+		errs = append(errs, err)
 	}
 
 	if err = gitCheckout(releaseRepoPath, restoreRev); err != nil {
 		errs = append(errs, fmt.Errorf("failed to check out tested revision back: %w", err))
 	}
 
-	return config, utilerrors.NewAggregate(errs)
+	return utilerrors.NewAggregate(errs)
+}
+
+// ProwConfigs is the configuration of Prow itself, including the jobs, and of its plugins from the release repo
+type ProwConfigs struct {
+	Prow    *prowconfig.Config
+	Plugins *plugins.Configuration
+}
+
+// GetProwConfigs loads the Prow and plugin configuration, including the supplemental
+// configuration files and the jobs, from the working copy of the release repo.
+func GetProwConfigs(releaseRepoPath string) (*ProwConfigs, error) {
+	prowConfigPath := filepath.Join(releaseRepoPath, ConfigInRepoPath)
+	configDir := filepath.Dir(prowConfigPath)
+	prow, err := prowconfig.Load(prowConfigPath, filepath.Join(releaseRepoPath, JobConfigInRepoPath), []string{configDir}, SupplementalProwConfigFileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Prow configuration from release repo: %w", err)
+	}
+	agent := plugins.ConfigAgent{}
+	if err := agent.Load(filepath.Join(releaseRepoPath, PluginConfigInRepoPath), []string{configDir}, SupplementalPluginConfigFileName, true, false); err != nil {
+		return nil, fmt.Errorf("failed to load plugin configuration from release repo: %w", err)
+	}
+	return &ProwConfigs{Prow: prow, Plugins: agent.Config()}, nil
+}
+
+// GetProwConfigsFromSHA loads the Prow and plugin configuration from given SHA revision of the release repo,
+// checking out back the revision that was checked out in the working copy when this method was called.
+func GetProwConfigsFromSHA(releaseRepoPath, sha string) (*ProwConfigs, error) {
+	var configs *ProwConfigs
+	err := atRevision(releaseRepoPath, sha, func() error {
+		var err error
+		configs, err = GetProwConfigs(releaseRepoPath)
+		return err
+	})
+	return configs, err
 }
 
 func GetChangedTemplates(path, baseRev string) ([]string, error) {
@@ -242,6 +289,37 @@ func GetChangedRegistrySteps(path, baseRev string, graph registry.NodeByName) ([
 	return changes, nil
 }
 
+// GetChangedProwConfigs returns the Prow and plugin configuration files that changed.
+func GetChangedProwConfigs(path, baseRev string) ([]string, error) {
+	return getRevChanges(path, filepath.Dir(ConfigInRepoPath), baseRev, false)
+}
+
+// GetChangedJobConfigs returns the Prow job configuration files that changed, including the removed ones.
+func GetChangedJobConfigs(path, baseRev string) ([]string, error) {
+	diff, err := git(path, "diff-tree", "-r", "--name-only", baseRev+":"+JobConfigInRepoPath, "HEAD:"+JobConfigInRepoPath)
+	if err != nil || diff == "" {
+		return nil, err
+	}
+	var ret []string
+	for _, l := range strings.Split(strings.TrimSpace(diff), "\n") {
+		ret = append(ret, filepath.Join(JobConfigInRepoPath, l))
+	}
+	return ret, nil
+}
+
+// FileAtRevision returns the content of the file of the release repo at the revision, and
+// whether the file exists at the revision at all.
+func FileAtRevision(releaseRepoPath, rev, path string) ([]byte, bool, error) {
+	if _, err := git(releaseRepoPath, "cat-file", "-e", rev+":"+path); err != nil {
+		return nil, false, nil
+	}
+	content, err := git(releaseRepoPath, "show", rev+":"+path)
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(content), true, nil
+}
+
 func GetChangedClusterProfiles(path, baseRev string) ([]string, error) {
 	return getRevChanges(path, ClusterProfilesPath, baseRev, false)
 }
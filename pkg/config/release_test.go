@@ -110,6 +110,37 @@ git mv renameme/file renamed/file
 	compareChanges(t, ClusterProfilesPath, files, cmd, GetChangedClusterProfiles, expected)
 }
 
+func TestGetChangedProwConfigs(t *testing.T) {
+	files := []string{
+		"_config.yaml", "_plugins.yaml", "org/repo/_prowconfig.yaml", "other/repo/_pluginconfig.yaml",
+	}
+	cmd := `
+> _config.yaml
+> org/repo/_prowconfig.yaml
+`
+	path := filepath.Dir(ConfigInRepoPath)
+	expected := []string{
+		filepath.Join(path, "_config.yaml"),
+		filepath.Join(path, "org/repo/_prowconfig.yaml"),
+	}
+	compareChanges(t, path, files, cmd, GetChangedProwConfigs, expected)
+}
+
+func TestGetChangedJobConfigs(t *testing.T) {
+	files := []string{
+		"org/repo/org-repo-master-presubmits.yaml", "org/repo/org-repo-master-postsubmits.yaml", "other/repo/other-repo-master-periodics.yaml",
+	}
+	cmd := `
+> org/repo/org-repo-master-presubmits.yaml
+git rm --quiet other/repo/other-repo-master-periodics.yaml
+`
+	expected := []string{
+		filepath.Join(JobConfigInRepoPath, "org/repo/org-repo-master-presubmits.yaml"),
+		filepath.Join(JobConfigInRepoPath, "other/repo/other-repo-master-periodics.yaml"),
+	}
+	compareChanges(t, JobConfigInRepoPath, files, cmd, GetChangedJobConfigs, expected)
+}
+
 type testNode struct {
 	string
 }
@@ -118,6 +149,7 @@ func (t testNode) Name() string {
 	return t.string
 }
 
+func (t testNode) Names() []string              { return []string{t.string} }
 func (t testNode) Type() registry.Type          { return 0 }
 func (t testNode) Ancestors() []registry.Node   { return nil }
 func (t testNode) Descendants() []registry.Node { return nil }
@@ -0,0 +1,71 @@
+package leasereaper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	leaseresourcev1 "github.com/openshift/ci-tools/pkg/api/leaseresource/v1"
+)
+
+const ControllerName = "leasereaper"
+
+func AddToManager(mgr manager.Manager, expiry time.Duration) error {
+	c, err := controller.New(ControllerName, mgr, controller.Options{
+		Reconciler:              &reconciler{client: mgr.GetClient(), expiry: expiry, now: time.Now},
+		MaxConcurrentReconciles: 10,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to construct controller: %w", err)
+	}
+	if err := c.Watch(source.Kind(mgr.GetCache(), &leaseresourcev1.LeaseResource{}), &handler.EnqueueRequestForObject{}); err != nil {
+		return fmt.Errorf("failed to watch leaseresources: %w", err)
+	}
+	return nil
+}
+
+type reconciler struct {
+	client ctrlruntimeclient.Client
+	expiry time.Duration
+	now    func() time.Time
+}
+
+func (r *reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	var resource leaseresourcev1.LeaseResource
+	if err := r.client.Get(ctx, req.NamespacedName, &resource); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get %s: %w", req, err)
+	}
+	if resource.State() == leaseresourcev1.ResourceStateFree {
+		return reconcile.Result{}, nil
+	}
+
+	if age := r.now().Sub(resource.Status.LastUpdate.Time); age < r.expiry {
+		return reconcile.Result{RequeueAfter: r.expiry - age}, nil
+	}
+
+	logrus.WithFields(logrus.Fields{"resource": req.String(), "owner": resource.Status.Owner}).Info("Freeing expired lease")
+	resource.Status = leaseresourcev1.LeaseResourceStatus{
+		State:      leaseresourcev1.ResourceStateFree,
+		LastUpdate: metav1.NewTime(r.now()),
+	}
+	// The update is guarded by the resource version, so a heartbeat sent in
+	// the meantime makes it fail and the resource is reconciled again.
+	if err := r.client.Update(ctx, &resource); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to free %s: %w", req, err)
+	}
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,87 @@
+package leasereaper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	leaseresourcev1 "github.com/openshift/ci-tools/pkg/api/leaseresource/v1"
+)
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	resource := func(status leaseresourcev1.LeaseResourceStatus) ctrlruntimeclient.Client {
+		return fakectrlruntimeclient.NewClientBuilder().WithObjects(&leaseresourcev1.LeaseResource{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "leases", Name: "name"},
+			Spec:       leaseresourcev1.LeaseResourceSpec{Type: "quota"},
+			Status:     status,
+		}).Build()
+	}
+	testCases := []struct {
+		name   string
+		client ctrlruntimeclient.Client
+
+		expectReconcileResult reconcile.Result
+		expectStatus          *leaseresourcev1.LeaseResourceStatus
+	}{
+		{
+			name:   "Not found is swallowed",
+			client: fakectrlruntimeclient.NewClientBuilder().Build(),
+		},
+		{
+			name:         "Free resource is ignored",
+			client:       resource(leaseresourcev1.LeaseResourceStatus{}),
+			expectStatus: &leaseresourcev1.LeaseResourceStatus{},
+		},
+		{
+			name:                  "RequeueAfter is returned for a recent heartbeat",
+			client:                resource(leaseresourcev1.LeaseResourceStatus{State: "leased", Owner: "owner", LastUpdate: metav1.NewTime(now.Add(-time.Minute))}),
+			expectReconcileResult: reconcile.Result{RequeueAfter: 9 * time.Minute},
+			expectStatus:          &leaseresourcev1.LeaseResourceStatus{State: "leased", Owner: "owner", LastUpdate: metav1.NewTime(now.Add(-time.Minute))},
+		},
+		{
+			name:         "Expired lease is freed",
+			client:       resource(leaseresourcev1.LeaseResourceStatus{State: "leased", Owner: "owner", LastUpdate: metav1.NewTime(now.Add(-time.Hour))}),
+			expectStatus: &leaseresourcev1.LeaseResourceStatus{State: "free", LastUpdate: metav1.NewTime(now)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &reconciler{
+				client: tc.client,
+				expiry: 10 * time.Minute,
+				now:    func() time.Time { return now },
+			}
+
+			result, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "leases", Name: "name"}})
+			if err != nil {
+				t.Fatalf("reconcile failed: %v", err)
+			}
+			if diff := cmp.Diff(result, tc.expectReconcileResult); diff != "" {
+				t.Errorf("reconcile result differs from expected: %s", diff)
+			}
+			if tc.expectStatus == nil {
+				return
+			}
+			var actual leaseresourcev1.LeaseResource
+			if err := r.client.Get(context.Background(), types.NamespacedName{Namespace: "leases", Name: "name"}, &actual); err != nil {
+				t.Fatalf("failed to get leaseresource: %v", err)
+			}
+			actual.Status.LastUpdate = metav1.NewTime(actual.Status.LastUpdate.Time.UTC())
+			if diff := cmp.Diff(tc.expectStatus, &actual.Status); diff != "" {
+				t.Errorf("status differs from expected: %s", diff)
+			}
+		})
+	}
+}
@@ -42,6 +42,8 @@ import (
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/clusterinstall"
+	"github.com/openshift/ci-tools/pkg/steps/dryrun"
+	"github.com/openshift/ci-tools/pkg/steps/kubernetesbackend"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/multi_stage"
 	releasesteps "github.com/openshift/ci-tools/pkg/steps/release"
@@ -79,18 +81,32 @@ func FromConfig(
 	manifestToolDockerCfg string,
 	localRegistryDNS string,
 	mergedConfig bool,
+	kubernetesBackend *kubernetesbackend.Config,
+	buildCache *steps.BuildCache,
 ) ([]api.Step, []api.Step, error) {
 	crclient, err := ctrlruntimeclient.NewWithWatch(clusterConfig, ctrlruntimeclient.Options{})
-	crclient = secretrecordingclient.Wrap(crclient, censor)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to construct client: %w", err)
 	}
+	if kubernetesBackend != nil {
+		crclient = kubernetesbackend.Wrap(crclient, *kubernetesBackend)
+	}
+	crclient = secretrecordingclient.Wrap(crclient, censor)
 	client := loggingclient.New(crclient)
-	buildGetter, err := buildclientset.NewForConfig(clusterConfig)
+	coreGetter, err := coreclientset.NewForConfig(clusterConfig)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not get build client for cluster config: %w", err)
+		return nil, nil, fmt.Errorf("could not get core client for cluster config: %w", err)
+	}
+	var buildClient steps.BuildClient
+	if kubernetesBackend != nil {
+		buildClient = kubernetesbackend.NewBuildClient(client, coreGetter, nodeArchitectures, manifestToolDockerCfg, localRegistryDNS)
+	} else {
+		buildGetter, err := buildclientset.NewForConfig(clusterConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not get build client for cluster config: %w", err)
+		}
+		buildClient = steps.NewBuildClient(client, buildGetter.RESTClient(), nodeArchitectures, manifestToolDockerCfg, localRegistryDNS)
 	}
-	buildClient := steps.NewBuildClient(client, buildGetter.RESTClient(), nodeArchitectures, manifestToolDockerCfg, localRegistryDNS)
 
 	templateGetter, err := templateclientset.NewForConfig(clusterConfig)
 	if err != nil {
@@ -98,11 +114,6 @@ func FromConfig(
 	}
 	templateClient := steps.NewTemplateClient(client, templateGetter.RESTClient())
 
-	coreGetter, err := coreclientset.NewForConfig(clusterConfig)
-	if err != nil {
-		return nil, nil, fmt.Errorf("could not get core client for cluster config: %w", err)
-	}
-
 	podClient := kubernetes.NewPodClient(client, clusterConfig, coreGetter.RESTClient(), podPendingTimeout)
 
 	var hiveClient ctrlruntimeclient.WithWatch
@@ -115,7 +126,36 @@ func FromConfig(
 	httpClient := retryablehttp.NewClient()
 	httpClient.Logger = nil
 
-	return fromConfig(ctx, config, graphConf, jobSpec, templates, paramFile, promote, client, buildClient, templateClient, podClient, leaseClient, hiveClient, httpClient.StandardClient(), requiredTargets, cloneAuthConfig, pullSecret, pushSecret, api.NewDeferredParameters(nil), censor, consoleHost, nodeName, targetAdditionalSuffix, nodeArchitectures, mergedConfig)
+	return fromConfig(ctx, config, graphConf, jobSpec, templates, paramFile, promote, client, buildClient, templateClient, podClient, leaseClient, hiveClient, httpClient.StandardClient(), requiredTargets, cloneAuthConfig, pullSecret, pushSecret, api.NewDeferredParameters(nil), censor, consoleHost, nodeName, targetAdditionalSuffix, nodeArchitectures, mergedConfig, buildCache)
+}
+
+// DryRunFromConfig interprets the human-friendly fields in the configuration
+// like FromConfig, but the steps interact with the in-memory cluster of a dry
+// run instead of a real one. Leases are acquired from a fake lease server.
+func DryRunFromConfig(
+	ctx context.Context,
+	config *api.ReleaseBuildConfiguration,
+	graphConf *api.GraphConfiguration,
+	jobSpec *api.JobSpec,
+	templates []*templateapi.Template,
+	paramFile string,
+	promote bool,
+	cluster *dryrun.Cluster,
+	requiredTargets []string,
+	cloneAuthConfig *steps.CloneAuthConfig,
+	pullSecret, pushSecret *coreapi.Secret,
+	censor *secrets.DynamicCensor,
+	consoleHost string,
+	nodeName string,
+	nodeArchitectures []string,
+	targetAdditionalSuffix string,
+	mergedConfig bool,
+) ([]api.Step, []api.Step, error) {
+	client := loggingclient.New(cluster)
+	leaseClient := lease.NewFakeClient("ci-operator", "", 0, nil, nil)
+	httpClient := retryablehttp.NewClient()
+	httpClient.Logger = nil
+	return fromConfig(ctx, config, graphConf, jobSpec, templates, paramFile, promote, client, dryrun.NewBuildClient(client, cluster, nodeArchitectures), dryrun.NewTemplateClient(client), dryrun.NewPodClient(client), &leaseClient, nil, httpClient.StandardClient(), requiredTargets, cloneAuthConfig, pullSecret, pushSecret, api.NewDeferredParameters(nil), censor, consoleHost, nodeName, targetAdditionalSuffix, nodeArchitectures, mergedConfig, nil)
 }
 
 func fromConfig(
@@ -143,6 +183,7 @@ func fromConfig(
 	targetAdditionalSuffix string,
 	nodeArchitectures []string,
 	mergedConfig bool,
+	buildCache *steps.BuildCache,
 ) ([]api.Step, []api.Step, error) {
 	requiredNames := sets.New[string]()
 	for _, target := range requiredTargets {
@@ -245,9 +286,9 @@ func fromConfig(
 			step = steps.InputImageTagStep(&conf, client, jobSpec)
 			inputImages[conf.InputImage] = struct{}{}
 		} else if rawStep.PipelineImageCacheStepConfiguration != nil {
-			step = steps.PipelineImageCacheStep(*rawStep.PipelineImageCacheStepConfiguration, config.Resources, buildClient, podClient, jobSpec, pullSecret)
+			step = steps.PipelineImageCacheStep(*rawStep.PipelineImageCacheStepConfiguration, config.Resources, buildClient, podClient, jobSpec, pullSecret, buildCache)
 		} else if rawStep.SourceStepConfiguration != nil {
-			step = steps.SourceStep(*rawStep.SourceStepConfiguration, config.Resources, buildClient, podClient, jobSpec, cloneAuthConfig, pullSecret)
+			step = steps.SourceStep(*rawStep.SourceStepConfiguration, config.Resources, buildClient, podClient, jobSpec, cloneAuthConfig, pullSecret, buildCache)
 		} else if rawStep.BundleSourceStepConfiguration != nil {
 			step = steps.BundleSourceStep(*rawStep.BundleSourceStepConfiguration, config, config.Resources, buildClient, podClient, jobSpec, pullSecret)
 		} else if rawStep.IndexGeneratorStepConfiguration != nil {
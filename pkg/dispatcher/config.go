@@ -8,6 +8,7 @@ import (
 	"sort"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	prowconfig "k8s.io/test-infra/prow/config"
@@ -39,6 +40,19 @@ type Config struct {
 	BuildFarm map[api.Cloud]map[api.Cluster]*BuildFarmConfig `json:"buildFarm,omitempty"`
 	// BuildFarmCloud maps sets of clusters to a cloud provider, like GCP
 	BuildFarmCloud map[api.Cloud][]string `json:"-"`
+	// Capacity declares the capacity of the clusters in the build farm, which
+	// the planner uses to balance the demand of the jobs
+	Capacity map[api.Cluster]Capacity `json:"capacity,omitempty"`
+}
+
+// Capacity is the declared capacity of a cluster in the build farm
+type Capacity struct {
+	// CPU is the number of cores available to jobs
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+	// Memory is the amount of memory available to jobs
+	Memory *resource.Quantity `json:"memory,omitempty"`
+	// NodePools lists the special node pools of the cluster, like kvm
+	NodePools []string `json:"nodePools,omitempty"`
 }
 
 type BuildFarmConfig struct {
@@ -259,6 +273,18 @@ func LoadConfig(configPath string) (*Config, error) {
 		config.Groups[cluster] = group
 	}
 
+	config.IndexBuildFarm()
+
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	return config, nil
+}
+
+// IndexBuildFarm determines the clusters of each cloud and the files of each
+// cluster of the build farm from the filenames which are serialized, which a
+// dispatch updates
+func (config *Config) IndexBuildFarm() {
 	for cloudProvider := range config.BuildFarm {
 		if config.BuildFarmCloud == nil {
 			config.BuildFarmCloud = map[api.Cloud][]string{}
@@ -274,11 +300,6 @@ func LoadConfig(configPath string) (*Config, error) {
 		}
 		config.BuildFarmCloud[cloudProvider] = sets.List(clusters)
 	}
-
-	if len(errs) > 0 {
-		return nil, utilerrors.NewAggregate(errs)
-	}
-	return config, nil
 }
 
 // Validate checks if the config is valid
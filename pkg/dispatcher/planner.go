@@ -0,0 +1,416 @@
+package dispatcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// NodePoolKVM is the node pool of the clusters which can run jobs requesting KVM devices
+const NodePoolKVM = "kvm"
+
+// ClusterState is what the planner knows about a cluster in the build farm
+type ClusterState struct {
+	Name      api.Cluster
+	Cloud     api.Cloud
+	Capacity  Resources
+	NodePools sets.Set[string]
+	Healthy   bool
+	// Pinned is the demand of the jobs which run on the cluster and cannot be relocated
+	Pinned Resources
+}
+
+// ClusterStates determines the state of the clusters in the build farm from their
+// declared capacity. Clusters which declare no capacity are assumed to have the mean
+// of the declared ones, and all clusters the same when none is declared.
+func (config *Config) ClusterStates(unhealthy sets.Set[string]) []ClusterState {
+	var clusters []ClusterState
+	var declared Resources
+	var cpus, memories int
+	for cloud, farm := range config.BuildFarm {
+		for cluster := range farm {
+			state := ClusterState{Name: cluster, Cloud: cloud, NodePools: sets.New[string](), Healthy: !unhealthy.Has(string(cluster))}
+			capacity := config.Capacity[cluster]
+			if capacity.CPU != nil {
+				state.Capacity.CPU = capacity.CPU.AsApproximateFloat64()
+				declared.CPU += state.Capacity.CPU
+				cpus++
+			}
+			if capacity.Memory != nil {
+				state.Capacity.Memory = capacity.Memory.AsApproximateFloat64()
+				declared.Memory += state.Capacity.Memory
+				memories++
+			}
+			state.NodePools.Insert(capacity.NodePools...)
+			clusters = append(clusters, state)
+		}
+	}
+	for _, cluster := range config.KVM {
+		for i := range clusters {
+			if clusters[i].Name == cluster {
+				clusters[i].NodePools.Insert(NodePoolKVM)
+			}
+		}
+	}
+	mean := Resources{CPU: 1, Memory: 1}
+	if cpus != 0 {
+		mean.CPU = declared.CPU / float64(cpus)
+	}
+	if memories != 0 {
+		mean.Memory = declared.Memory / float64(memories)
+	}
+	for i := range clusters {
+		if clusters[i].Capacity.CPU == 0 {
+			clusters[i].Capacity.CPU = mean.CPU
+		}
+		if clusters[i].Capacity.Memory == 0 {
+			clusters[i].Capacity.Memory = mean.Memory
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters
+}
+
+// FileDemand is the demand of the jobs of a Prow job config file which can be
+// relocated, which are dispatched together
+type FileDemand struct {
+	Filename string
+	// Cloud is the cloud provider of the e2e tests in the file, if they all run on one
+	Cloud api.Cloud
+	// NodePools are the node pools the jobs in the file need
+	NodePools sets.Set[string]
+	// Demand is the sum over the jobs of their volume multiplied by their profile
+	Demand Resources
+}
+
+// Move records that a file is dispatched to another cluster
+type Move struct {
+	Filename string      `json:"filename"`
+	From     api.Cluster `json:"from,omitempty"`
+	To       api.Cluster `json:"to"`
+	Reason   string      `json:"reason"`
+}
+
+// Plan is the result of planning the dispatch of the files to the build farm
+type Plan struct {
+	// Assignments holds the sorted files dispatched to each cluster
+	Assignments map[api.Cluster][]string `json:"assignments"`
+	// Moves explains every file which changes cluster
+	Moves []Move `json:"moves,omitempty"`
+	// Before and After hold the pressure of the healthy clusters
+	Before map[api.Cluster]float64 `json:"before"`
+	After  map[api.Cluster]float64 `json:"after"`
+}
+
+// PlanOptions tune the planner
+type PlanOptions struct {
+	// Tolerance is how far above its fair share the pressure of a cluster may be
+	// before files are moved away from it, e.g. 0.1 for ten percent
+	Tolerance float64
+	// MaxMoves limits the number of files moved to balance the clusters, zero
+	// means no limit. Files on clusters they cannot stay on are always moved.
+	MaxMoves int
+}
+
+// planner holds the state of the clusters while files are dispatched
+type planner struct {
+	clusters map[api.Cluster]*ClusterState
+	names    []api.Cluster
+	files    map[string]FileDemand
+	assigned map[string]api.Cluster
+	demand   map[api.Cluster]Resources
+	// farm is the demand per unit of capacity across the healthy clusters
+	farm Resources
+}
+
+// PlanDispatch dispatches the files to the clusters of the build farm with
+// minimal churn: a file stays on its current cluster unless the cluster is
+// unhealthy, gone or cannot run its jobs, or unless the cluster is under more
+// pressure than the tolerance allows. The pressure of a cluster is its demand
+// per unit of capacity relative to the one of the whole farm, so a cluster with
+// exactly its fair share of the demand has a pressure of one.
+func PlanDispatch(clusters []ClusterState, files []FileDemand, current map[string]api.Cluster, options PlanOptions) (*Plan, error) {
+	p := &planner{
+		clusters: map[api.Cluster]*ClusterState{},
+		files:    map[string]FileDemand{},
+		assigned: map[string]api.Cluster{},
+		demand:   map[api.Cluster]Resources{},
+	}
+	var capacity, demand Resources
+	for i := range clusters {
+		cluster := clusters[i]
+		p.clusters[cluster.Name] = &cluster
+		if cluster.Healthy {
+			p.names = append(p.names, cluster.Name)
+			p.demand[cluster.Name] = cluster.Pinned
+			capacity = capacity.Add(cluster.Capacity)
+			demand = demand.Add(cluster.Pinned)
+		}
+	}
+	if len(p.names) == 0 {
+		return nil, fmt.Errorf("no healthy cluster in the build farm")
+	}
+	sort.Slice(p.names, func(i, j int) bool { return p.names[i] < p.names[j] })
+	for _, file := range files {
+		p.files[file.Filename] = file
+		demand = demand.Add(file.Demand)
+	}
+	p.farm = Resources{CPU: ratio(demand.CPU, capacity.CPU), Memory: ratio(demand.Memory, capacity.Memory)}
+
+	// the pressure before counts the files on the healthy clusters where they are now
+	now := map[api.Cluster]Resources{}
+	for _, name := range p.names {
+		now[name] = p.clusters[name].Pinned
+	}
+	for _, file := range files {
+		if cluster, ok := current[file.Filename]; ok {
+			if demand, healthy := now[cluster]; healthy {
+				now[cluster] = demand.Add(file.Demand)
+			}
+		}
+	}
+	before := p.pressures(now)
+
+	moves := map[string]*Move{}
+	var unplaced []string
+	for _, file := range files {
+		from, ok := current[file.Filename]
+		if !ok {
+			moves[file.Filename] = &Move{Filename: file.Filename, Reason: "it is a new file"}
+			unplaced = append(unplaced, file.Filename)
+			continue
+		}
+		if reason := p.ineligible(file, from); reason != "" {
+			moves[file.Filename] = &Move{Filename: file.Filename, From: from, Reason: reason}
+			unplaced = append(unplaced, file.Filename)
+			continue
+		}
+		p.assign(file.Filename, from)
+	}
+
+	// the largest files are placed first, as they are the hardest to fit
+	sort.Slice(unplaced, func(i, j int) bool {
+		if p.size(unplaced[i]) != p.size(unplaced[j]) {
+			return p.size(unplaced[i]) > p.size(unplaced[j])
+		}
+		return unplaced[i] < unplaced[j]
+	})
+	var errs []string
+	for _, filename := range unplaced {
+		var to api.Cluster
+		var best float64
+		for _, name := range p.names {
+			if p.ineligible(p.files[filename], name) != "" {
+				continue
+			}
+			if pressure := p.pressure(p.demand[name].Add(p.files[filename].Demand), name); to == "" || pressure < best {
+				to, best = name, pressure
+			}
+		}
+		if to == "" {
+			errs = append(errs, fmt.Sprintf("no healthy cluster can run the jobs in %s", filename))
+			delete(moves, filename)
+			continue
+		}
+		p.assign(filename, to)
+		moves[filename].To = to
+		moves[filename].Reason = fmt.Sprintf("%s, and %s had the least pressure of the clusters which can run its jobs", moves[filename].Reason, to)
+	}
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("failed to plan the dispatch: %s", strings.Join(errs, "; "))
+	}
+
+	for balanced := 0; options.MaxMoves == 0 || balanced < options.MaxMoves; balanced++ {
+		move := p.rebalance(options.Tolerance)
+		if move == nil {
+			break
+		}
+		if existing, ok := moves[move.Filename]; ok {
+			move.From = existing.From
+			move.Reason = fmt.Sprintf("%s; then %s", existing.Reason, move.Reason)
+		}
+		moves[move.Filename] = move
+		if move.From == move.To {
+			delete(moves, move.Filename)
+		}
+		if balanced > len(files) {
+			// every move lowers the highest pressure, this is only a safeguard
+			break
+		}
+	}
+
+	plan := &Plan{Assignments: map[api.Cluster][]string{}, Before: before, After: p.pressures(p.demand)}
+	for _, name := range p.names {
+		plan.Assignments[name] = []string{}
+	}
+	for filename, cluster := range p.assigned {
+		plan.Assignments[cluster] = append(plan.Assignments[cluster], filename)
+	}
+	for cluster := range plan.Assignments {
+		sort.Strings(plan.Assignments[cluster])
+	}
+	for _, move := range moves {
+		plan.Moves = append(plan.Moves, *move)
+	}
+	sort.Slice(plan.Moves, func(i, j int) bool { return plan.Moves[i].Filename < plan.Moves[j].Filename })
+	return plan, nil
+}
+
+// ineligible explains why the jobs of a file cannot run on the cluster, if they cannot
+func (p *planner) ineligible(file FileDemand, name api.Cluster) string {
+	cluster, ok := p.clusters[name]
+	if !ok {
+		return fmt.Sprintf("%s is no longer in the build farm", name)
+	}
+	if !cluster.Healthy {
+		return fmt.Sprintf("%s is unhealthy", name)
+	}
+	if missing := file.NodePools.Difference(cluster.NodePools); missing.Len() != 0 {
+		return fmt.Sprintf("%s has no %s node pool", name, strings.Join(sets.List(missing), ", "))
+	}
+	if file.Cloud != "" && cluster.Cloud != file.Cloud && p.hasCloud(file.Cloud) {
+		return fmt.Sprintf("its e2e tests run on %s and %s is on %s", file.Cloud, name, cluster.Cloud)
+	}
+	return ""
+}
+
+func (p *planner) hasCloud(cloud api.Cloud) bool {
+	for _, name := range p.names {
+		if p.clusters[name].Cloud == cloud {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *planner) assign(filename string, cluster api.Cluster) {
+	if from, ok := p.assigned[filename]; ok {
+		p.demand[from] = p.demand[from].Add(p.files[filename].Demand.Scale(-1))
+	}
+	p.assigned[filename] = cluster
+	p.demand[cluster] = p.demand[cluster].Add(p.files[filename].Demand)
+}
+
+// pressure is the highest demand per unit of capacity of the cluster, over the
+// resources, relative to the one of the whole farm
+func (p *planner) pressure(demand Resources, name api.Cluster) float64 {
+	capacity := p.clusters[name].Capacity
+	cpu := ratio(ratio(demand.CPU, capacity.CPU), p.farm.CPU)
+	memory := ratio(ratio(demand.Memory, capacity.Memory), p.farm.Memory)
+	if cpu > memory {
+		return cpu
+	}
+	return memory
+}
+
+func (p *planner) pressures(demand map[api.Cluster]Resources) map[api.Cluster]float64 {
+	pressures := map[api.Cluster]float64{}
+	for _, name := range p.names {
+		pressures[name] = p.pressure(demand[name], name)
+	}
+	return pressures
+}
+
+// size is the share of the capacity of the farm a file needs
+func (p *planner) size(filename string) float64 {
+	demand := p.files[filename].Demand
+	return ratio(demand.CPU, p.farm.CPU) + ratio(demand.Memory, p.farm.Memory)
+}
+
+// rebalance moves one file away from the cluster under the most pressure when it
+// exceeds the tolerance, choosing the move after which the higher pressure of the
+// two clusters is the lowest, and the smallest file of those
+func (p *planner) rebalance(tolerance float64) *Move {
+	var hottest api.Cluster
+	for _, name := range p.names {
+		if hottest == "" || p.pressure(p.demand[name], name) > p.pressure(p.demand[hottest], hottest) {
+			hottest = name
+		}
+	}
+	highest := p.pressure(p.demand[hottest], hottest)
+	if highest <= 1+tolerance {
+		return nil
+	}
+	var filenames []string
+	for filename, cluster := range p.assigned {
+		if cluster == hottest {
+			filenames = append(filenames, filename)
+		}
+	}
+	sort.Strings(filenames)
+
+	var best *Move
+	var bestPressure, bestSize float64
+	for _, filename := range filenames {
+		file := p.files[filename]
+		remaining := p.pressure(p.demand[hottest].Add(file.Demand.Scale(-1)), hottest)
+		for _, name := range p.names {
+			if name == hottest || p.ineligible(file, name) != "" {
+				continue
+			}
+			pressure := p.pressure(p.demand[name].Add(file.Demand), name)
+			if remaining > pressure {
+				pressure = remaining
+			}
+			if pressure >= highest {
+				continue
+			}
+			if best == nil || pressure < bestPressure || (pressure == bestPressure && p.size(filename) < bestSize) {
+				best = &Move{
+					Filename: filename,
+					From:     hottest,
+					To:       name,
+					Reason: fmt.Sprintf("%s was under %s of the pressure of the farm, above the tolerance of %s, and %s under %s",
+						hottest, percent(highest), percent(1+tolerance), name, percent(p.pressure(p.demand[name], name))),
+				}
+				bestPressure, bestSize = pressure, p.size(filename)
+			}
+		}
+	}
+	if best != nil {
+		p.assign(best.Filename, best.To)
+	}
+	return best
+}
+
+// Report renders the plan for humans, explaining every move
+func (plan *Plan) Report() string {
+	var b strings.Builder
+	b.WriteString("Pressure of the clusters, relative to the whole build farm:\n")
+	var names []api.Cluster
+	for name := range plan.After {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %s: %s -> %s (%d files)\n", name, percent(plan.Before[name]), percent(plan.After[name]), len(plan.Assignments[name]))
+	}
+	if len(plan.Moves) == 0 {
+		b.WriteString("No file is moved.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Moved %d files:\n", len(plan.Moves))
+	for _, move := range plan.Moves {
+		from := move.From
+		if from == "" {
+			from = "(none)"
+		}
+		fmt.Fprintf(&b, "  %s: %s -> %s, as %s\n", move.Filename, from, move.To, move.Reason)
+	}
+	return b.String()
+}
+
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+func percent(value float64) string {
+	return fmt.Sprintf("%.0f%%", value*100)
+}
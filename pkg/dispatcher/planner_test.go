@@ -0,0 +1,212 @@
+package dispatcher
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestClusterStates(t *testing.T) {
+	cpu, memory := resource.MustParse("100"), resource.MustParse("400Gi")
+	smaller := resource.MustParse("50")
+	config := Config{
+		BuildFarm: map[api.Cloud]map[api.Cluster]*BuildFarmConfig{
+			api.CloudAWS: {"build01": {}},
+			api.CloudGCP: {"build02": {}, "build03": {}},
+		},
+		Capacity: map[api.Cluster]Capacity{
+			"build01": {CPU: &cpu, Memory: &memory, NodePools: []string{"arm64"}},
+			"build02": {CPU: &smaller},
+		},
+		KVM: []api.Cluster{"build03"},
+	}
+	expected := []ClusterState{
+		{Name: "build01", Cloud: api.CloudAWS, Capacity: Resources{CPU: 100, Memory: 400 << 30}, NodePools: sets.New[string]("arm64"), Healthy: true},
+		{Name: "build02", Cloud: api.CloudGCP, Capacity: Resources{CPU: 50, Memory: 400 << 30}, NodePools: sets.New[string]()},
+		{Name: "build03", Cloud: api.CloudGCP, Capacity: Resources{CPU: 75, Memory: 400 << 30}, NodePools: sets.New[string](NodePoolKVM), Healthy: true},
+	}
+	if diff := cmp.Diff(expected, config.ClusterStates(sets.New[string]("build02"))); diff != "" {
+		t.Errorf("unexpected cluster states: %s", diff)
+	}
+}
+
+func TestPlanDispatch(t *testing.T) {
+	cluster := func(name api.Cluster, cloud api.Cloud, pools ...string) ClusterState {
+		return ClusterState{Name: name, Cloud: cloud, Capacity: Resources{CPU: 10, Memory: 10}, NodePools: sets.New[string](pools...), Healthy: true}
+	}
+	file := func(name string, demand float64) FileDemand {
+		return FileDemand{Filename: name, Demand: Resources{CPU: demand, Memory: demand}}
+	}
+	unhealthy := cluster("build01", api.CloudAWS)
+	unhealthy.Healthy = false
+	pinned := cluster("build01", api.CloudAWS)
+	pinned.Pinned = Resources{CPU: 8, Memory: 8}
+	gcp := file("a", 5)
+	gcp.Cloud = api.CloudGCP
+	kvm := file("a", 5)
+	kvm.NodePools = sets.New[string](NodePoolKVM)
+
+	for _, tc := range []struct {
+		name        string
+		clusters    []ClusterState
+		files       []FileDemand
+		current     map[string]api.Cluster
+		options     PlanOptions
+		expected    *Plan
+		expectedErr string
+	}{{
+		name:     "balanced clusters keep their files",
+		clusters: []ClusterState{cluster("build01", api.CloudAWS), cluster("build02", api.CloudGCP)},
+		files:    []FileDemand{file("a", 5), file("b", 4)},
+		current:  map[string]api.Cluster{"a": "build01", "b": "build02"},
+		options:  PlanOptions{Tolerance: 0.2},
+		expected: &Plan{
+			Assignments: map[api.Cluster][]string{"build01": {"a"}, "build02": {"b"}},
+			Before:      map[api.Cluster]float64{"build01": 1.11, "build02": 0.89},
+			After:       map[api.Cluster]float64{"build01": 1.11, "build02": 0.89},
+		},
+	}, {
+		name:     "the files which balance the hot cluster best are moved",
+		clusters: []ClusterState{cluster("build01", api.CloudAWS), cluster("build02", api.CloudGCP)},
+		files:    []FileDemand{file("a", 6), file("b", 3), file("c", 1)},
+		current:  map[string]api.Cluster{"a": "build01", "b": "build01", "c": "build01"},
+		options:  PlanOptions{Tolerance: 0.1},
+		expected: &Plan{
+			Assignments: map[api.Cluster][]string{"build01": {"b", "c"}, "build02": {"a"}},
+			Moves: []Move{
+				{Filename: "a", From: "build01", To: "build02", Reason: "build01 was under 200% of the pressure of the farm, above the tolerance of 110%, and build02 under 0%"},
+			},
+			Before: map[api.Cluster]float64{"build01": 2, "build02": 0},
+			After:  map[api.Cluster]float64{"build01": 0.8, "build02": 1.2},
+		},
+	}, {
+		name:     "the number of moves to balance the clusters is limited",
+		clusters: []ClusterState{cluster("build01", api.CloudAWS), cluster("build02", api.CloudGCP), cluster("build03", api.CloudGCP)},
+		files:    []FileDemand{file("a", 2), file("b", 2), file("c", 2)},
+		current:  map[string]api.Cluster{"a": "build01", "b": "build01", "c": "build01"},
+		options:  PlanOptions{MaxMoves: 1},
+		expected: &Plan{
+			Assignments: map[api.Cluster][]string{"build01": {"b", "c"}, "build02": {"a"}, "build03": {}},
+			Moves: []Move{
+				{Filename: "a", From: "build01", To: "build02", Reason: "build01 was under 300% of the pressure of the farm, above the tolerance of 100%, and build02 under 0%"},
+			},
+			Before: map[api.Cluster]float64{"build01": 3, "build02": 0, "build03": 0},
+			After:  map[api.Cluster]float64{"build01": 2, "build02": 1, "build03": 0},
+		},
+	}, {
+		name:     "the jobs which cannot be relocated put pressure on a cluster",
+		clusters: []ClusterState{pinned, cluster("build02", api.CloudGCP)},
+		files:    []FileDemand{file("a", 2), file("b", 2)},
+		current:  map[string]api.Cluster{"a": "build01", "b": "build02"},
+		options:  PlanOptions{Tolerance: 0.1},
+		expected: &Plan{
+			Assignments: map[api.Cluster][]string{"build01": {}, "build02": {"a", "b"}},
+			Moves: []Move{
+				{Filename: "a", From: "build01", To: "build02", Reason: "build01 was under 167% of the pressure of the farm, above the tolerance of 110%, and build02 under 33%"},
+			},
+			Before: map[api.Cluster]float64{"build01": 1.67, "build02": 0.33},
+			After:  map[api.Cluster]float64{"build01": 1.33, "build02": 0.67},
+		},
+	}, {
+		name:     "files are moved away from unhealthy and removed clusters, new files are placed",
+		clusters: []ClusterState{unhealthy, cluster("build02", api.CloudGCP), cluster("build03", api.CloudGCP)},
+		files:    []FileDemand{file("a", 5), file("b", 4), file("c", 1)},
+		current:  map[string]api.Cluster{"a": "build01", "b": "build04"},
+		options:  PlanOptions{Tolerance: 0.1},
+		expected: &Plan{
+			Assignments: map[api.Cluster][]string{"build02": {"a"}, "build03": {"b", "c"}},
+			Moves: []Move{
+				{Filename: "a", From: "build01", To: "build02", Reason: "build01 is unhealthy, and build02 had the least pressure of the clusters which can run its jobs"},
+				{Filename: "b", From: "build04", To: "build03", Reason: "build04 is no longer in the build farm, and build03 had the least pressure of the clusters which can run its jobs"},
+				{Filename: "c", To: "build03", Reason: "it is a new file, and build03 had the least pressure of the clusters which can run its jobs"},
+			},
+			Before: map[api.Cluster]float64{"build02": 0, "build03": 0},
+			After:  map[api.Cluster]float64{"build02": 1, "build03": 1},
+		},
+	}, {
+		name:     "files follow their e2e tests to their cloud",
+		clusters: []ClusterState{cluster("build01", api.CloudAWS), cluster("build02", api.CloudGCP)},
+		files:    []FileDemand{gcp, file("b", 5)},
+		current:  map[string]api.Cluster{"a": "build01", "b": "build02"},
+		options:  PlanOptions{Tolerance: 0.1},
+		expected: &Plan{
+			Assignments: map[api.Cluster][]string{"build01": {"b"}, "build02": {"a"}},
+			Moves: []Move{
+				{Filename: "a", From: "build01", To: "build02", Reason: "its e2e tests run on gcp and build01 is on aws, and build02 had the least pressure of the clusters which can run its jobs"},
+				{Filename: "b", From: "build02", To: "build01", Reason: "build02 was under 200% of the pressure of the farm, above the tolerance of 110%, and build01 under 0%"},
+			},
+			Before: map[api.Cluster]float64{"build01": 1, "build02": 1},
+			After:  map[api.Cluster]float64{"build01": 1, "build02": 1},
+		},
+	}, {
+		name:     "files needing a node pool only go to clusters with it",
+		clusters: []ClusterState{cluster("build01", api.CloudAWS), cluster("build02", api.CloudGCP, NodePoolKVM)},
+		files:    []FileDemand{kvm},
+		current:  map[string]api.Cluster{"a": "build01"},
+		expected: &Plan{
+			Assignments: map[api.Cluster][]string{"build01": {}, "build02": {"a"}},
+			Moves: []Move{
+				{Filename: "a", From: "build01", To: "build02", Reason: "build01 has no kvm node pool, and build02 had the least pressure of the clusters which can run its jobs"},
+			},
+			Before: map[api.Cluster]float64{"build01": 2, "build02": 0},
+			After:  map[api.Cluster]float64{"build01": 0, "build02": 2},
+		},
+	}, {
+		name:        "no cluster can run the jobs",
+		clusters:    []ClusterState{cluster("build01", api.CloudAWS)},
+		files:       []FileDemand{kvm},
+		expectedErr: "failed to plan the dispatch: no healthy cluster can run the jobs in a",
+	}, {
+		name:        "no healthy cluster",
+		clusters:    []ClusterState{unhealthy},
+		expectedErr: "no healthy cluster in the build farm",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			plan, err := PlanDispatch(tc.clusters, tc.files, tc.current, tc.options)
+			if diff := cmp.Diff(tc.expectedErr, errString(err)); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, plan, cmpopts.EquateApprox(0, 0.01), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected plan: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPlanReport(t *testing.T) {
+	plan := Plan{
+		Assignments: map[api.Cluster][]string{"build01": {"b"}, "build02": {"a", "c"}},
+		Moves: []Move{
+			{Filename: "a", From: "build01", To: "build02", Reason: "build01 is unhealthy"},
+			{Filename: "c", To: "build02", Reason: "it is a new file"},
+		},
+		Before: map[api.Cluster]float64{"build01": 1.5, "build02": 0.5},
+		After:  map[api.Cluster]float64{"build01": 0.9, "build02": 1.1},
+	}
+	expected := `Pressure of the clusters, relative to the whole build farm:
+  build01: 150% -> 90% (1 files)
+  build02: 50% -> 110% (2 files)
+Moved 2 files:
+  a: build01 -> build02, as build01 is unhealthy
+  c: (none) -> build02, as it is a new file
+`
+	if diff := cmp.Diff(expected, plan.Report()); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+	if diff := cmp.Diff("Pressure of the clusters, relative to the whole build farm:\nNo file is moved.\n", (&Plan{}).Report()); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,109 @@
+package dispatcher
+
+import (
+	"strings"
+
+	"github.com/openhistogram/circonusllhist"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/jobconfig"
+	pod_scaler "github.com/openshift/ci-tools/pkg/pod-scaler"
+)
+
+// Resources are the resources used by a run of a job, or the demand and
+// capacity of a cluster: CPU in cores and memory in bytes
+type Resources struct {
+	CPU    float64 `json:"cpu"`
+	Memory float64 `json:"memory"`
+}
+
+// Add returns the sum of the resources
+func (r Resources) Add(other Resources) Resources {
+	return Resources{CPU: r.CPU + other.CPU, Memory: r.Memory + other.Memory}
+}
+
+// Scale returns the resources multiplied by the factor
+func (r Resources) Scale(factor float64) Resources {
+	return Resources{CPU: r.CPU * factor, Memory: r.Memory * factor}
+}
+
+// DefaultResources are used for every job when no profiles are known
+var DefaultResources = Resources{CPU: 1, Memory: 1 << 30}
+
+// JobProfiles maps the name of a job to the resources a run of it uses
+type JobProfiles map[string]Resources
+
+// Mean returns the mean profile, which we assume for jobs we have no data for
+func (p JobProfiles) Mean() Resources {
+	if len(p) == 0 {
+		return DefaultResources
+	}
+	var sum Resources
+	for _, resources := range p {
+		sum = sum.Add(resources)
+	}
+	return sum.Scale(1 / float64(len(p)))
+}
+
+// JobProfilesFromPodScaler determines the profiles of jobs from the usage data
+// the pod-scaler collects. The value at the quantile of each container is summed
+// for each Pod, and a job uses as much as the largest of its Pods, as the Pods
+// of the steps of a test run one after the other.
+func JobProfilesFromPodScaler(cpu, memory *pod_scaler.CachedQuery, quantile float64) JobProfiles {
+	// the containers of a Pod are identified by the metadata without the container
+	usage := map[pod_scaler.FullMetadata]Resources{}
+	record := func(data *pod_scaler.CachedQuery, set func(*Resources, float64)) {
+		if data == nil {
+			return
+		}
+		for meta, fingerprints := range data.DataByMetaData {
+			if meta.Target == "" {
+				// images, releases and RPM repositories are shared by the jobs of a repository
+				continue
+			}
+			overall := circonusllhist.New()
+			for _, fingerprint := range fingerprints {
+				if hist, ok := data.Data[fingerprint]; ok {
+					overall.Merge(hist.Histogram())
+				}
+			}
+			meta.Container = ""
+			resources := usage[meta]
+			set(&resources, overall.ValueAtQuantile(quantile))
+			usage[meta] = resources
+		}
+	}
+	record(cpu, func(r *Resources, value float64) { r.CPU += value })
+	record(memory, func(r *Resources, value float64) { r.Memory += value })
+
+	profiles := JobProfiles{}
+	for meta, resources := range usage {
+		for _, name := range jobNamesFor(meta) {
+			current := profiles[name]
+			if resources.CPU > current.CPU {
+				current.CPU = resources.CPU
+			}
+			if resources.Memory > current.Memory {
+				current.Memory = resources.Memory
+			}
+			profiles[name] = current
+		}
+	}
+	return profiles
+}
+
+// jobNamesFor determines the names of the jobs the usage data may belong to, as
+// the type of the job is not recorded. The target is either the name of a test,
+// the context of a presubmit or, for jobs without either, what follows the prefix
+// of the job name.
+func jobNamesFor(meta pod_scaler.FullMetadata) []string {
+	if meta.Org == "" {
+		return []string{meta.Target}
+	}
+	target := strings.TrimPrefix(meta.Target, "ci/prow/")
+	names := sets.New[string]()
+	for _, prefix := range []string{jobconfig.PresubmitPrefix, jobconfig.PostsubmitPrefix, jobconfig.PeriodicPrefix} {
+		names.Insert(meta.JobName(prefix, target), meta.JobName(prefix, "")+target)
+	}
+	return sets.List(names)
+}
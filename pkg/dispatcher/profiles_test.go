@@ -0,0 +1,74 @@
+package dispatcher
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openhistogram/circonusllhist"
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	pod_scaler "github.com/openshift/ci-tools/pkg/pod-scaler"
+)
+
+func TestJobProfilesFromPodScaler(t *testing.T) {
+	meta := api.Metadata{Org: "org", Repo: "repo", Branch: "master"}
+	query := func(values map[pod_scaler.FullMetadata]float64) *pod_scaler.CachedQuery {
+		q := &pod_scaler.CachedQuery{Data: map[model.Fingerprint]*circonusllhist.HistogramWithoutLookups{}, DataByMetaData: map[pod_scaler.FullMetadata][]model.Fingerprint{}}
+		var fingerprint model.Fingerprint
+		for m, value := range values {
+			fingerprint++
+			hist := circonusllhist.New(circonusllhist.NoLookup())
+			if err := hist.RecordValue(value); err != nil {
+				t.Fatalf("failed to record value: %v", err)
+			}
+			q.Data[fingerprint] = circonusllhist.NewHistogramWithoutLookups(hist)
+			q.DataByMetaData[m] = []model.Fingerprint{fingerprint}
+		}
+		return q
+	}
+	cpu := query(map[pod_scaler.FullMetadata]float64{
+		// the Pods of the steps of e2e run one after the other
+		{Metadata: meta, Target: "e2e", Step: "install", Pod: "e2e-install", Container: "test"}:    2,
+		{Metadata: meta, Target: "e2e", Step: "install", Pod: "e2e-install", Container: "sidecar"}: 1,
+		{Metadata: meta, Target: "e2e", Step: "test", Pod: "e2e-test", Container: "test"}:          4,
+		// a Prow job which is not run by ci-operator
+		{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"}, Target: "ci/prow/lint", Container: "test"}: 1,
+		// a periodic with no repository
+		{Target: "periodic-cleanup", Container: "test"}: 0.5,
+		// images are built for every job of a repository
+		{Metadata: meta, Step: "src", Pod: "src-build", Container: "docker-build"}: 8,
+	})
+	memory := query(map[pod_scaler.FullMetadata]float64{
+		{Metadata: meta, Target: "e2e", Step: "install", Pod: "e2e-install", Container: "test"}:    4e9,
+		{Metadata: meta, Target: "e2e", Step: "install", Pod: "e2e-install", Container: "sidecar"}: 1e9,
+		{Metadata: meta, Target: "e2e", Step: "test", Pod: "e2e-test", Container: "test"}:          1e9,
+	})
+	profiles := JobProfilesFromPodScaler(cpu, memory, 0.8)
+	expected := map[string]Resources{
+		"pull-ci-org-repo-master-e2e":     {CPU: 4, Memory: 5e9},
+		"branch-ci-org-repo-master-e2e":   {CPU: 4, Memory: 5e9},
+		"periodic-ci-org-repo-master-e2e": {CPU: 4, Memory: 5e9},
+		"pull-ci-org-repo-master-lint":    {CPU: 1},
+		"periodic-cleanup":                {CPU: 0.5},
+	}
+	for name, resources := range expected {
+		if diff := cmp.Diff(resources, profiles[name], cmpopts.EquateApprox(0.1, 0)); diff != "" {
+			t.Errorf("unexpected profile of %s: %s", name, diff)
+		}
+	}
+	if _, ok := profiles["pull-ci-org-repo-master-src"]; ok {
+		t.Error("expected no profile for the images shared by the jobs of a repository")
+	}
+}
+
+func TestJobProfilesMean(t *testing.T) {
+	if diff := cmp.Diff(DefaultResources, JobProfiles{}.Mean()); diff != "" {
+		t.Errorf("unexpected mean of no profiles: %s", diff)
+	}
+	profiles := JobProfiles{"a": {CPU: 1, Memory: 2}, "b": {CPU: 3, Memory: 4}}
+	if diff := cmp.Diff(Resources{CPU: 2, Memory: 3}, profiles.Mean()); diff != "" {
+		t.Errorf("unexpected mean: %s", diff)
+	}
+}
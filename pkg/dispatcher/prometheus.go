@@ -2,9 +2,11 @@ package dispatcher
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/prometheus/client_golang/api"
@@ -103,3 +105,64 @@ func (o *PrometheusOptions) NewPrometheusClient(secretGetter func(string) []byte
 	}
 	return api.NewClient(api.Config{Address: o.PrometheusURL, RoundTripper: roundTripper})
 }
+
+// Recording is the result of a query to Prometheus, recorded so it can be replayed offline
+type Recording struct {
+	Expression string       `json:"query"`
+	Time       time.Time    `json:"time"`
+	Result     model.Vector `json:"result"`
+}
+
+var _ PrometheusAPI = &Recording{}
+
+// Query replays the recorded result, which is only available for the recorded query
+func (r *Recording) Query(_ context.Context, query string, _ time.Time, _ ...prometheusapi.Option) (model.Value, prometheusapi.Warnings, error) {
+	if query != r.Expression {
+		return nil, nil, fmt.Errorf("query %q was not recorded, only %q was", query, r.Expression)
+	}
+	return r.Result, nil, nil
+}
+
+// LoadRecording loads a recorded query from a file
+func LoadRecording(path string) (*Recording, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the recording: %w", err)
+	}
+	recording := &Recording{}
+	if err := json.Unmarshal(raw, recording); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the recording %s: %w", path, err)
+	}
+	return recording, nil
+}
+
+type recordingPrometheusAPI struct {
+	PrometheusAPI
+	path string
+}
+
+// Query records the result of the query to the file before returning it
+func (r *recordingPrometheusAPI) Query(ctx context.Context, query string, ts time.Time, opts ...prometheusapi.Option) (model.Value, prometheusapi.Warnings, error) {
+	result, warnings, err := r.PrometheusAPI.Query(ctx, query, ts, opts...)
+	if err != nil {
+		return result, warnings, err
+	}
+	vector, ok := result.(model.Vector)
+	if !ok {
+		return result, warnings, nil
+	}
+	raw, err := json.Marshal(Recording{Expression: query, Time: ts, Result: vector})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal the recording: %w", err)
+	}
+	if err := os.WriteFile(r.path, raw, 0644); err != nil {
+		return nil, nil, fmt.Errorf("failed to write the recording: %w", err)
+	}
+	return result, warnings, nil
+}
+
+// RecordTo returns a PrometheusAPI which records the result of the queries to the file,
+// which only holds the last one
+func RecordTo(prometheusAPI PrometheusAPI, path string) PrometheusAPI {
+	return &recordingPrometheusAPI{PrometheusAPI: prometheusAPI, path: path}
+}
@@ -3,6 +3,7 @@ package dispatcher
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -90,3 +91,37 @@ func TestGetJobVolumesFromPrometheus(t *testing.T) {
 		})
 	}
 }
+
+func TestRecording(t *testing.T) {
+	ts := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	vector := model.Vector{
+		{Metric: model.Metric{"job_name": "pull-ci-openshift-ci-tools-master-unit"}, Value: 42, Timestamp: model.TimeFromUnix(ts.Unix())},
+	}
+	live := &prometheusAPIForTest{queryFunc: func(context.Context, string, time.Time) (model.Value, prometheusapi.Warnings, error) {
+		return vector, nil, nil
+	}}
+	path := filepath.Join(t.TempDir(), "volumes.json")
+	expected := map[string]float64{"pull-ci-openshift-ci-tools-master-unit": 42}
+	volumes, err := GetJobVolumesFromPrometheus(context.Background(), RecordTo(live, path), ts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, volumes); diff != "" {
+		t.Errorf("unexpected volumes: %s", diff)
+	}
+
+	recording, err := LoadRecording(path)
+	if err != nil {
+		t.Fatalf("failed to load recording: %v", err)
+	}
+	replayed, err := GetJobVolumesFromPrometheus(context.Background(), recording, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, replayed); diff != "" {
+		t.Errorf("unexpected replayed volumes: %s", diff)
+	}
+	if _, _, err := recording.Query(context.Background(), "up", ts); err == nil {
+		t.Error("expected an error for a query which was not recorded")
+	}
+}
@@ -0,0 +1,154 @@
+package dispatcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowconfig "k8s.io/test-infra/prow/config"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// ClusterLoad is the volume of the jobs which run on a cluster
+type ClusterLoad struct {
+	Current   float64 `json:"current"`
+	Projected float64 `json:"projected"`
+	// Jobs is the number of jobs which would run on the cluster
+	Jobs int `json:"jobs"`
+}
+
+// JobMove records that a job would run on another cluster
+type JobMove struct {
+	Job    string      `json:"job"`
+	Path   string      `json:"path"`
+	From   api.Cluster `json:"from,omitempty"`
+	To     api.Cluster `json:"to,omitempty"`
+	Volume float64     `json:"volume"`
+}
+
+// Violation records that a job would run where it cannot
+type Violation struct {
+	Job     string      `json:"job"`
+	Path    string      `json:"path"`
+	Cluster api.Cluster `json:"cluster,omitempty"`
+	Reason  string      `json:"reason"`
+}
+
+// Simulation is the projected effect of a candidate config on where the jobs run
+type Simulation struct {
+	Load       map[api.Cluster]*ClusterLoad `json:"load"`
+	Moves      []JobMove                    `json:"moves,omitempty"`
+	Violations []Violation                  `json:"violations,omitempty"`
+}
+
+// Simulate determines where the jobs, by the path of the file defining them, run
+// with the current and the candidate config, and how the volume of the jobs would
+// shift between the clusters. The files of the jobs must already be dispatched
+// to the build farm of the candidate config. Jobs which would run where they
+// cannot are reported as violations: KVM jobs on clusters without the kvm node
+// pool, SSH bastion jobs elsewhere than on the SSH bastion cluster and jobs the
+// candidate config fails to determine a cluster for.
+func Simulate(current, candidate *Config, jobs map[string][]prowconfig.JobBase, volumes map[string]float64) *Simulation {
+	simulation := &Simulation{Load: map[api.Cluster]*ClusterLoad{}}
+	load := func(cluster api.Cluster) *ClusterLoad {
+		if _, ok := simulation.Load[cluster]; !ok {
+			simulation.Load[cluster] = &ClusterLoad{}
+		}
+		return simulation.Load[cluster]
+	}
+	kvm := sets.New[api.Cluster](candidate.KVM...)
+	for cluster, capacity := range candidate.Capacity {
+		if sets.New[string](capacity.NodePools...).Has(NodePoolKVM) {
+			kvm.Insert(cluster)
+		}
+	}
+
+	var paths []string
+	for path := range jobs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, job := range jobs[path] {
+			volume := volumes[job.Name]
+			from, err := current.GetClusterForJob(job, path)
+			if err != nil {
+				// the current config is in use already, so we only note where the candidate puts the job
+				from = ""
+			}
+			if from != "" {
+				load(from).Current += volume
+			}
+			to, err := candidate.GetClusterForJob(job, path)
+			if err != nil {
+				simulation.Violations = append(simulation.Violations, Violation{Job: job.Name, Path: path, Reason: err.Error()})
+				continue
+			}
+			if to == "" {
+				// jobs which do not run on Kubernetes do not run on our clusters
+				continue
+			}
+			load(to).Projected += volume
+			load(to).Jobs++
+			if from != to {
+				simulation.Moves = append(simulation.Moves, JobMove{Job: job.Name, Path: path, From: from, To: to, Volume: volume})
+			}
+			if _, ok := job.Labels[api.KVMDeviceLabel]; ok && !kvm.Has(to) {
+				simulation.Violations = append(simulation.Violations, Violation{Job: job.Name, Path: path, Cluster: to, Reason: fmt.Sprintf("the job needs KVM and %s has no kvm node pool", to)})
+			}
+			if isSSHBastionJob(job) && to != candidate.SSHBastion {
+				reason := "the job needs an SSH bastion and no SSH bastion cluster is configured"
+				if candidate.SSHBastion != "" {
+					reason = fmt.Sprintf("the job needs an SSH bastion, which runs on %s", candidate.SSHBastion)
+				}
+				simulation.Violations = append(simulation.Violations, Violation{Job: job.Name, Path: path, Cluster: to, Reason: reason})
+			}
+		}
+	}
+	return simulation
+}
+
+// Report renders the simulation for humans
+func (s *Simulation) Report() string {
+	var b strings.Builder
+	var current, projected float64
+	var clusters []api.Cluster
+	for cluster, load := range s.Load {
+		clusters = append(clusters, cluster)
+		current += load.Current
+		projected += load.Projected
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i] < clusters[j] })
+	b.WriteString("Volume of the jobs by cluster, current -> projected:\n")
+	for _, cluster := range clusters {
+		load := s.Load[cluster]
+		fmt.Fprintf(&b, "  %s: %.0f (%s) -> %.0f (%s), %d jobs\n", cluster, load.Current, percent(ratio(load.Current, current)), load.Projected, percent(ratio(load.Projected, projected)), load.Jobs)
+	}
+	var moved float64
+	for _, move := range s.Moves {
+		moved += move.Volume
+	}
+	fmt.Fprintf(&b, "%d jobs with a volume of %.0f would move:\n", len(s.Moves), moved)
+	for _, move := range s.Moves {
+		from := move.From
+		if from == "" {
+			from = "(none)"
+		}
+		fmt.Fprintf(&b, "  %s (%s): %s -> %s, volume %.0f\n", move.Job, move.Path, from, move.To, move.Volume)
+	}
+	if len(s.Violations) == 0 {
+		b.WriteString("No violations.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%d violations:\n", len(s.Violations))
+	for _, violation := range s.Violations {
+		fmt.Fprintf(&b, "  %s (%s)", violation.Job, violation.Path)
+		if violation.Cluster != "" {
+			fmt.Fprintf(&b, " on %s", violation.Cluster)
+		}
+		fmt.Fprintf(&b, ": %s\n", violation.Reason)
+	}
+	return b.String()
+}
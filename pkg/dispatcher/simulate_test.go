@@ -0,0 +1,99 @@
+package dispatcher
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowconfig "k8s.io/test-infra/prow/config"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/jobconfig"
+)
+
+func TestSimulate(t *testing.T) {
+	current := &Config{
+		Default:    "build01",
+		SSHBastion: "build01",
+		KVM:        []api.Cluster{"build02"},
+		BuildFarm: map[api.Cloud]map[api.Cluster]*BuildFarmConfig{
+			api.CloudAWS: {"build01": {Filenames: sets.New[string]("a-presubmits.yaml")}},
+			api.CloudGCP: {"build02": {Filenames: sets.New[string]("b-presubmits.yaml")}},
+		},
+	}
+	candidate := &Config{
+		Default: "build01",
+		BuildFarm: map[api.Cloud]map[api.Cluster]*BuildFarmConfig{
+			api.CloudAWS: {"build01": {Filenames: sets.New[string]("b-presubmits.yaml")}},
+			api.CloudGCP: {"build02": {Filenames: sets.New[string]("a-presubmits.yaml")}},
+		},
+		Groups: JobGroups{"build03": {PathREs: []*regexp.Regexp{regexp.MustCompile("b-presubmits.yaml")}}},
+		KVM:    []api.Cluster{"build01"},
+	}
+	jobs := map[string][]prowconfig.JobBase{
+		"a-presubmits.yaml": {
+			{Name: "a-unit"},
+			{Name: "a-kvm", Labels: map[string]string{api.KVMDeviceLabel: "true"}},
+			{Name: "a-bastion", Labels: map[string]string{jobconfig.SSHBastionLabel: "true"}},
+		},
+		"b-presubmits.yaml": {
+			{Name: "b-unit"},
+			{Name: "b-tekton", Agent: "tekton-pipeline"},
+		},
+		"c-periodics.yaml": {
+			{Name: "c-pinned", Labels: map[string]string{api.ClusterLabel: "build02"}},
+		},
+	}
+	volumes := map[string]float64{"a-unit": 10, "a-kvm": 2, "a-bastion": 1, "b-unit": 5, "c-pinned": 3}
+
+	expected := &Simulation{
+		Load: map[api.Cluster]*ClusterLoad{
+			"build01": {Current: 11, Projected: 2, Jobs: 1},
+			"build02": {Current: 10, Projected: 14, Jobs: 3},
+		},
+		Moves: []JobMove{
+			{Job: "a-unit", Path: "a-presubmits.yaml", From: "build01", To: "build02", Volume: 10},
+			{Job: "a-kvm", Path: "a-presubmits.yaml", From: "build02", To: "build01", Volume: 2},
+			{Job: "a-bastion", Path: "a-presubmits.yaml", From: "build01", To: "build02", Volume: 1},
+		},
+		Violations: []Violation{
+			{Job: "a-bastion", Path: "a-presubmits.yaml", Cluster: "build02", Reason: "the job needs an SSH bastion and no SSH bastion cluster is configured"},
+			{Job: "b-unit", Path: "b-presubmits.yaml", Reason: "path b-presubmits.yaml matches more than 1 regex: [b-presubmits.yaml b-presubmits.yaml]"},
+		},
+	}
+	simulation := Simulate(current, candidate, jobs, volumes)
+	if diff := cmp.Diff(expected, simulation); diff != "" {
+		t.Fatalf("unexpected simulation: %s", diff)
+	}
+
+	expectedReport := `Volume of the jobs by cluster, current -> projected:
+  build01: 11 (52%) -> 2 (12%), 1 jobs
+  build02: 10 (48%) -> 14 (88%), 3 jobs
+3 jobs with a volume of 13 would move:
+  a-unit (a-presubmits.yaml): build01 -> build02, volume 10
+  a-kvm (a-presubmits.yaml): build02 -> build01, volume 2
+  a-bastion (a-presubmits.yaml): build01 -> build02, volume 1
+2 violations:
+  a-bastion (a-presubmits.yaml) on build02: the job needs an SSH bastion and no SSH bastion cluster is configured
+  b-unit (b-presubmits.yaml): path b-presubmits.yaml matches more than 1 regex: [b-presubmits.yaml b-presubmits.yaml]
+`
+	if diff := cmp.Diff(expectedReport, simulation.Report()); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+}
+
+func TestSimulateKVMViolation(t *testing.T) {
+	config := &Config{Default: "build01", KVM: []api.Cluster{"build02"}}
+	candidate := &Config{Default: "build01"}
+	jobs := map[string][]prowconfig.JobBase{"a-presubmits.yaml": {{Name: "a-kvm", Labels: map[string]string{api.KVMDeviceLabel: "true"}}}}
+	expected := []Violation{{Job: "a-kvm", Path: "a-presubmits.yaml", Cluster: "build01", Reason: "the job needs KVM and build01 has no kvm node pool"}}
+	if diff := cmp.Diff(expected, Simulate(config, candidate, jobs, nil).Violations); diff != "" {
+		t.Errorf("unexpected violations: %s", diff)
+	}
+	candidate.Capacity = map[api.Cluster]Capacity{"build01": {NodePools: []string{NodePoolKVM}}}
+	if violations := Simulate(config, candidate, jobs, nil).Violations; len(violations) != 0 {
+		t.Errorf("expected the kvm node pool of the capacity to count, got %v", violations)
+	}
+}
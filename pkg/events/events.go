@@ -0,0 +1,167 @@
+// Package events records a machine-readable stream of the lifecycle of a
+// ci-operator execution: steps, the pods they run, leases and artifacts.
+// Every event is serialized as a single line of JSON and written to all
+// configured sinks, so consumers can follow an execution live.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/secretutil"
+)
+
+// Type identifies what happened
+type Type string
+
+const (
+	StepQueued   Type = "step_queued"
+	StepStarted  Type = "step_started"
+	StepFinished Type = "step_finished"
+	StepSkipped  Type = "step_skipped"
+
+	PodCreated        Type = "pod_created"
+	PodPending        Type = "pod_pending"
+	PodRunning        Type = "pod_running"
+	PodSucceeded      Type = "pod_succeeded"
+	PodFailed         Type = "pod_failed"
+	ContainerFinished Type = "container_finished"
+
+	LeaseAcquired Type = "lease_acquired"
+	LeaseReleased Type = "lease_released"
+
+	ArtifactsUploaded Type = "artifacts_uploaded"
+
+	BuildCacheHit  Type = "build_cache_hit"
+	BuildCacheMiss Type = "build_cache_miss"
+)
+
+// Event is a single entry in the stream. Only the fields relevant
+// to the type of the event are set.
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+
+	// Job and BuildID identify the execution, they are set for every event
+	Job     string `json:"job,omitempty"`
+	BuildID string `json:"build_id,omitempty"`
+
+	Step string `json:"step,omitempty"`
+	// DurationSeconds is set for events that finish something
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	Error           string  `json:"error,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+	ExitCode  *int32 `json:"exit_code,omitempty"`
+
+	ResourceType string   `json:"resource_type,omitempty"`
+	Leases       []string `json:"leases,omitempty"`
+
+	Path string `json:"path,omitempty"`
+
+	// Image is the pipeline image looked up in the build cache
+	Image string `json:"image,omitempty"`
+}
+
+// Sink receives serialized events, one line of JSON at a time
+type Sink interface {
+	Write(line []byte) error
+	Close() error
+}
+
+// Execution identifies the execution that emits events
+type Execution struct {
+	Job     string
+	BuildID string
+}
+
+var now = time.Now
+
+// Recorder streams the events of one execution to its sinks. A nil
+// *Recorder is valid and drops every event, so code that emits events
+// does not need to know whether the execution is recorded.
+type Recorder struct {
+	lock      sync.Mutex
+	sinks     []Sink
+	censor    secretutil.Censorer
+	execution Execution
+}
+
+// NewRecorder streams events of an execution to the sinks. Secrets
+// known to the censor are removed from events before they are written.
+func NewRecorder(censor secretutil.Censorer, execution Execution, sinks ...Sink) *Recorder {
+	return &Recorder{sinks: sinks, censor: censor, execution: execution}
+}
+
+// Close flushes and closes all sinks, events emitted afterwards are dropped.
+func (r *Recorder) Close() {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, sink := range r.sinks {
+		if err := sink.Close(); err != nil {
+			logrus.WithError(err).Warn("Could not close event sink.")
+		}
+	}
+	r.sinks = nil
+}
+
+// Emit records an event in all sinks. It is safe to call on a nil Recorder
+// or one without sinks, in which case the event is dropped.
+func (r *Recorder) Emit(event Event) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.sinks) == 0 {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = now()
+	}
+	event.Job, event.BuildID = r.execution.Job, r.execution.BuildID
+	line, err := json.Marshal(event)
+	if err != nil {
+		logrus.WithError(err).Debug("Could not serialize event.")
+		return
+	}
+	if r.censor != nil {
+		r.censor.Censor(&line)
+	}
+	line = append(line, '\n')
+	for _, sink := range r.sinks {
+		if err := sink.Write(line); err != nil {
+			logrus.WithError(err).Debug("Could not write event.")
+		}
+	}
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context that carries the recorder
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// FromContext returns the recorder carried by the context, or nil
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+// ErrorString formats an optional error for an event
+func ErrorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,120 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/test-infra/prow/secretutil"
+)
+
+func parse(t *testing.T, data []byte) []Event {
+	var parsed []Event
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		event := Event{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("could not parse event %q: %v", scanner.Text(), err)
+		}
+		parsed = append(parsed, event)
+	}
+	return parsed
+}
+
+func TestEmit(t *testing.T) {
+	fixed := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return fixed }
+	defer func() { now = time.Now }()
+
+	var unrecorded *Recorder
+	unrecorded.Emit(Event{Type: StepStarted, Step: "dropped"})
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	file, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("could not create file sink: %v", err)
+	}
+	censor := secretutil.NewCensorer()
+	censor.Refresh("hunter2")
+	recorder := NewRecorder(censor, Execution{Job: "job", BuildID: "1"}, file)
+	FromContext(WithRecorder(context.Background(), recorder)).Emit(Event{Type: StepStarted, Step: "src"})
+	recorder.Emit(Event{Type: StepFinished, Step: "src", DurationSeconds: 2, Error: "password hunter2 rejected"})
+	recorder.Close()
+	recorder.Emit(Event{Type: StepStarted, Step: "dropped"})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read events: %v", err)
+	}
+	expected := []Event{
+		{Type: StepStarted, Time: fixed, Job: "job", BuildID: "1", Step: "src"},
+		{Type: StepFinished, Time: fixed, Job: "job", BuildID: "1", Step: "src", DurationSeconds: 2, Error: "password XXXXXXX rejected"},
+	}
+	if diff := cmp.Diff(expected, parse(t, data)); diff != "" {
+		t.Errorf("unexpected events: %s", diff)
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var lock sync.Mutex
+	var received []byte
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if fail {
+			fail = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, body...)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL, nil)
+	// the first batch is rejected by the server and dropped
+	if err := sink.Write([]byte("{\"type\":\"step_skipped\"}\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	for _, line := range []string{"{\"type\":\"step_started\"}\n", "{\"type\":\"step_finished\"}\n"} {
+		if err := sink.Write([]byte(line)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error closing sink: %v", err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	expected := []Event{{Type: StepStarted}, {Type: StepFinished}}
+	if diff := cmp.Diff(expected, parse(t, received)); diff != "" {
+		t.Errorf("unexpected events: %s", diff)
+	}
+}
+
+func TestErrorString(t *testing.T) {
+	if actual := ErrorString(nil); actual != "" {
+		t.Errorf("expected no message for no error, got %q", actual)
+	}
+	if actual := ErrorString(errors.New("oops")); actual != "oops" {
+		t.Errorf("expected the message of the error, got %q", actual)
+	}
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fileSink appends events to a newline-delimited JSON file
+type fileSink struct {
+	lock sync.Mutex
+	file *os.File
+}
+
+// NewFileSink creates a sink that writes events to the file at path
+func NewFileSink(path string) (Sink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open event stream file: %w", err)
+	}
+	return &fileSink{file: file}, nil
+}
+
+func (s *fileSink) Write(line []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, err := s.file.Write(line)
+	return err
+}
+
+func (s *fileSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.file.Close()
+}
+
+const (
+	httpSinkBuffer    = 1000
+	httpSinkBatchSize = 100
+	httpSinkTimeout   = 30 * time.Second
+)
+
+// httpSink posts events to an HTTP endpoint in the background, in batches
+// of newline-delimited JSON. Events are dropped when the endpoint cannot
+// keep up, as the stream must never slow down the execution.
+type httpSink struct {
+	url    string
+	client *http.Client
+
+	lines   chan []byte
+	done    chan struct{}
+	dropped int
+}
+
+// NewHTTPSink creates a sink that posts events to url
+func NewHTTPSink(url string, client *http.Client) Sink {
+	if client == nil {
+		client = &http.Client{Timeout: httpSinkTimeout}
+	}
+	s := &httpSink{
+		url:    url,
+		client: client,
+		lines:  make(chan []byte, httpSinkBuffer),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *httpSink) Write(line []byte) error {
+	select {
+	case s.lines <- line:
+		return nil
+	default:
+		s.dropped++
+		return fmt.Errorf("event stream sink %s is not keeping up, dropped %d events", s.url, s.dropped)
+	}
+}
+
+func (s *httpSink) run() {
+	defer close(s.done)
+	for line := range s.lines {
+		batch := bytes.NewBuffer(line)
+	collect:
+		for i := 1; i < httpSinkBatchSize; i++ {
+			select {
+			case next, ok := <-s.lines:
+				if !ok {
+					break collect
+				}
+				batch.Write(next)
+			default:
+				break collect
+			}
+		}
+		if err := s.post(batch.Bytes()); err != nil {
+			logrus.WithError(err).Debug("Could not post events.")
+		}
+	}
+}
+
+func (s *httpSink) post(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpSinkTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("event stream sink %s responded with %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+// Close waits for pending events to be posted, for at most one timeout
+func (s *httpSink) Close() error {
+	close(s.lines)
+	select {
+	case <-s.done:
+		return nil
+	case <-time.After(httpSinkTimeout):
+		return fmt.Errorf("timed out posting events to %s", s.url)
+	}
+}
@@ -2,6 +2,8 @@ package kubernetes
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -77,6 +79,21 @@ type PodClient interface {
 	WithNewLoggingClient() PodClient
 	Exec(namespace, pod string, opts *coreapi.PodExecOptions) (remotecommand.Executor, error)
 	GetLogs(namespace, name string, opts *coreapi.PodLogOptions) *rest.Request
+	// GetPodMetrics returns the current resource usage of the containers of a
+	// pod, as reported by the metrics API of the cluster.
+	GetPodMetrics(ctx context.Context, namespace, name string) (*PodMetrics, error)
+}
+
+// PodMetrics is the subset of the `metrics.k8s.io/v1beta1` PodMetrics type we
+// use, decoded directly to avoid depending on the metrics client.
+type PodMetrics struct {
+	Containers []ContainerMetrics `json:"containers"`
+}
+
+// ContainerMetrics is the resource usage of a single container
+type ContainerMetrics struct {
+	Name  string               `json:"name"`
+	Usage coreapi.ResourceList `json:"usage"`
 }
 
 func NewPodClient(ctrlclient loggingclient.LoggingClient, config *rest.Config, client rest.Interface, pendingTimeout time.Duration) PodClient {
@@ -110,6 +127,21 @@ func (c podClient) GetLogs(namespace, name string, opts *coreapi.PodLogOptions)
 	return c.client.Get().Namespace(namespace).Name(name).Resource("pods").SubResource("log").VersionedParams(opts, scheme.ParameterCodec)
 }
 
+func (c podClient) GetPodMetrics(ctx context.Context, namespace, name string) (*PodMetrics, error) {
+	if c.client == nil {
+		return nil, errors.New("no REST client configured")
+	}
+	raw, err := c.client.Get().AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", namespace, "pods", name).DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get metrics for pod %s/%s: %w", namespace, name, err)
+	}
+	var ret PodMetrics
+	if err := json.Unmarshal(raw, &ret); err != nil {
+		return nil, fmt.Errorf("could not parse metrics for pod %s/%s: %w", namespace, name, err)
+	}
+	return &ret, nil
+}
+
 func (c podClient) WithNewLoggingClient() PodClient {
 	c.LoggingClient = c.New()
 	return c
@@ -0,0 +1,84 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	boskos "sigs.k8s.io/boskos/client"
+	"sigs.k8s.io/boskos/common"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// BoskosBackend stores leases in a Boskos server
+	BoskosBackend = "boskos"
+	// CRDBackend stores leases as LeaseResource objects in a cluster
+	CRDBackend = "crd"
+)
+
+// Backend stores resources and the state of their leases. The semantics of
+// the methods are those of the Boskos client, which was the only backend
+// originally.
+type Backend interface {
+	// AcquireWithPriority moves a resource of the type from `state` to `dest`
+	// or returns ErrNotFound if there is none.
+	AcquireWithPriority(rtype, state, dest, requestID string) (*common.Resource, error)
+	// AcquireWaitWithPriority is AcquireWithPriority, retrying until `ctx` is
+	// done.
+	AcquireWaitWithPriority(ctx context.Context, rtype, state, dest, requestID string) (*common.Resource, error)
+	// UpdateOne moves a leased resource to `dest` and records a heartbeat.
+	UpdateOne(name, dest string, _ *common.UserData) error
+	// ReleaseOne ends the lease of a resource, moving it to `dest`.
+	ReleaseOne(name, dest string) error
+	// ReleaseAll ends all leases of the owner, moving the resources to `dest`.
+	ReleaseAll(dest string) error
+	// Metric counts the resources of the type by state and owner.
+	Metric(rtype string) (common.Metric, error)
+}
+
+// BackendOptions holds the configuration of all backends, each uses a subset
+type BackendOptions struct {
+	// Owner identifies the lessee
+	Owner string
+
+	// URL, Username, and PasswordGetter configure access to a Boskos server
+	URL            string
+	Username       string
+	PasswordGetter func() []byte
+
+	// Client and Namespace determine where LeaseResource objects are stored
+	Client    ctrlruntimeclient.Client
+	Namespace string
+}
+
+// BackendFactory creates a backend from the options
+type BackendFactory func(options BackendOptions) (Backend, error)
+
+var backends = map[string]BackendFactory{
+	BoskosBackend: newBoskosBackend,
+	CRDBackend:    newCRDBackend,
+}
+
+// RegisterBackend makes a backend available to NewClientForBackend under the
+// name, replacing any backend registered before with the same name
+func RegisterBackend(name string, factory BackendFactory) {
+	backends[name] = factory
+}
+
+// Backends returns the names of the registered backends
+func Backends() []string {
+	var ret []string
+	for name := range backends {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func newBoskosBackend(options BackendOptions) (Backend, error) {
+	if options.URL == "" {
+		return nil, errors.New("the URL of the Boskos server is required")
+	}
+	return boskos.NewClientWithPasswordGetter(options.Owner, options.URL, options.Username, options.PasswordGetter)
+}
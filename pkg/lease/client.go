@@ -2,8 +2,11 @@ package lease
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -11,8 +14,10 @@ import (
 	"github.com/sirupsen/logrus"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
 	boskos "sigs.k8s.io/boskos/client"
-	"sigs.k8s.io/boskos/common"
+
+	"github.com/openshift/ci-tools/pkg/events"
 )
 
 const (
@@ -20,20 +25,44 @@ const (
 	leasedState = "leased"
 )
 
-type boskosClient interface {
-	AcquireWaitWithPriority(ctx context.Context, rtype, state, dest, requestID string) (*common.Resource, error)
-	UpdateOne(name, dest string, _ *common.UserData) error
-	ReleaseOne(name, dest string) error
-	ReleaseAll(dest string) error
-	Metric(rtype string) (common.Metric, error)
-}
-
 var ErrNotFound = boskos.ErrNotFound
 
 type Metrics struct {
 	Free, Leased int
+	// Total counts the resources in all states
+	Total int
 }
 
+// Request is a number of resources of a type to lease
+type Request struct {
+	ResourceType string
+	Count        uint
+}
+
+// QueuePosition describes an acquisition that is waiting for resources
+type QueuePosition struct {
+	// Attempt is the number of times acquiring the full set failed
+	Attempt int
+	// ResourceType is the type of which no resource could be acquired in the
+	// last attempt
+	ResourceType string
+	// Position is the place of the acquisition in the queue of the type, as
+	// the number of leases of the type which must be released before it can
+	// be satisfied. The backends do not expose their queues, so this is a
+	// lower bound: at least the requested resources which are not free, and
+	// at least one, as resources which are free but were not acquired are
+	// promised to requests queued earlier.
+	Position int
+	// Metrics are the states of the resources of the type after the attempt
+	Metrics
+	// Next is how long until the next attempt
+	Next time.Duration
+}
+
+// acquireBackoff spaces out attempts to acquire a full set of resources,
+// jittered so that competing clients do not retry in lockstep
+var acquireBackoff = wait.Backoff{Duration: 3 * time.Second, Factor: 1.5, Jitter: 0.5, Steps: math.MaxInt32, Cap: time.Minute}
+
 // Client manages resource leases, acquiring, releasing, and keeping them
 // updated.
 type Client interface {
@@ -42,6 +71,15 @@ type Client interface {
 	// `ctx` can be used to abort the operation, `cancel` is called if any
 	// subsequent updates to the lease fail.
 	Acquire(rtype string, n uint, ctx context.Context, cancel context.CancelFunc) ([]string, error)
+	// AcquireAll leases all of the requested resources or none of them and
+	// returns the lease names for each request. When a resource is not
+	// available, the partial holdings are released and the full set is
+	// attempted again after a back-off, so that processes waiting for the
+	// same resources cannot deadlock holding a part of them each. `report` is
+	// called with the position of the acquisition after every failed attempt. Gives up with
+	// ErrNotFound when the acquisition timeout of the client passes. `ctx`
+	// and `cancel` are used as in Acquire.
+	AcquireAll(requests []Request, ctx context.Context, cancel context.CancelFunc, report func(QueuePosition)) ([][]string, error)
 	// Heartbeat updates all leases. It calls the cancellation function of each
 	// lease it fails to update.
 	Heartbeat() error
@@ -53,74 +91,234 @@ type Client interface {
 	// Metrics queries the states of a particular resource, for informational
 	// purposes.
 	Metrics(rtype string) (Metrics, error)
+	// Predict estimates how long each request would wait for resources from
+	// the current metrics and the waits of past acquisitions.
+	Predict(requests []Request) ([]Prediction, error)
+}
+
+// ClientOption configures optional behavior of a client
+type ClientOption func(*client)
+
+// WithWaitHistory records the wait of every acquisition in the history and
+// predicts waits from it instead of from the acquisitions of this client only
+func WithWaitHistory(history WaitHistory) ClientOption {
+	return func(c *client) {
+		c.history = history
+	}
 }
 
-// NewClient creates a client that leases resources with the specified owner.
+// NewClient creates a client that leases resources with the specified owner
+// from a Boskos server.
 func NewClient(owner, url, username string, passwordGetter func() []byte, retries int, acquireTimeout time.Duration) (Client, error) {
+	return NewClientForBackend(BoskosBackend, BackendOptions{
+		Owner:          owner,
+		URL:            url,
+		Username:       username,
+		PasswordGetter: passwordGetter,
+	}, retries, acquireTimeout)
+}
+
+// NewClientForBackend creates a client that leases resources with the owner
+// of the options from a registered backend.
+func NewClientForBackend(name string, options BackendOptions, retries int, acquireTimeout time.Duration, opts ...ClientOption) (Client, error) {
+	factory, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown lease backend %q, must be one of %v", name, Backends())
+	}
 	randId = func() string {
 		return strconv.Itoa(rand.Int())
 	}
-	c, err := boskos.NewClientWithPasswordGetter(owner, url, username, passwordGetter)
+	backend, err := factory(options)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("could not create the %s lease backend: %w", name, err)
+	}
+	c := newClient(backend, retries, acquireTimeout)
+	for _, opt := range opts {
+		opt(c.(*client))
 	}
-	return newClient(c, retries, acquireTimeout), nil
+	return c, nil
 }
 
 // for test mocking
 var randId func() string
 
-func newClient(boskos boskosClient, retries int, acquireTimeout time.Duration) Client {
+func newClient(backend Backend, retries int, acquireTimeout time.Duration) Client {
 	return &client{
-		boskos:         boskos,
+		backend:        backend,
 		retries:        retries,
 		acquireTimeout: acquireTimeout,
 		leases:         make(map[string]*lease),
+		waits:          make(map[string][]Wait),
 	}
 }
 
 type client struct {
 	sync.RWMutex
-	boskos         boskosClient
+	backend        Backend
 	retries        int
 	acquireTimeout time.Duration
 	leases         map[string]*lease
+	// waits holds the waits of the acquisitions of this client by type
+	waits   map[string][]Wait
+	history WaitHistory
 }
 
 type lease struct {
+	rtype          string
 	updateFailures int
 	// cancel holds a cancellation function for steps that depend on leases
 	// being active; we must cancel this when we encounter errors to tie the
 	// lifetime of the downstream user routines to those of the leases they
 	// require
 	cancel context.CancelFunc
+	// events records the release of the lease in the execution that
+	// acquired it
+	events *events.Recorder
 }
 
 func (c *client) Acquire(rtype string, n uint, ctx context.Context, cancel context.CancelFunc) ([]string, error) {
 	var cancelAcquire context.CancelFunc
 	ctx, cancelAcquire = context.WithTimeout(ctx, c.acquireTimeout)
 	defer cancelAcquire()
+	start := time.Now()
 	var ret []string
-	// TODO `m` processes may fight for the last `m * n` remaining leases
+	// `m` processes may fight for the last `m * n` remaining leases, AcquireAll
+	// does not hold any of them while waiting
 	for i := uint(0); i < n; i++ {
-		r, err := c.boskos.AcquireWaitWithPriority(ctx, rtype, freeState, leasedState, randId())
+		r, err := c.backend.AcquireWaitWithPriority(ctx, rtype, freeState, leasedState, randId())
 		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				c.recordWait(rtype, Wait{Duration: time.Since(start), TimedOut: true})
+			}
 			return nil, err
 		}
 		c.Lock()
-		c.leases[r.Name] = &lease{cancel: cancel}
+		c.leases[r.Name] = &lease{rtype: rtype, cancel: cancel, events: events.FromContext(ctx)}
 		c.Unlock()
 		ret = append(ret, r.Name)
 	}
+	wait := time.Since(start)
+	c.recordWait(rtype, Wait{Duration: wait})
+	events.FromContext(ctx).Emit(events.Event{Type: events.LeaseAcquired, ResourceType: rtype, Leases: ret, DurationSeconds: wait.Seconds()})
 	return ret, nil
 }
 
+func (c *client) AcquireAll(requests []Request, ctx context.Context, cancel context.CancelFunc, report func(QueuePosition)) ([][]string, error) {
+	var cancelAcquire context.CancelFunc
+	ctx, cancelAcquire = context.WithTimeout(ctx, c.acquireTimeout)
+	defer cancelAcquire()
+	start := time.Now()
+	// Acquire in a consistent order across clients.  The request IDs keep the
+	// place of each requested lease in the queue of its type across attempts;
+	// the backend forgets a request ID once a resource is acquired with it.
+	order := make([]int, len(requests))
+	requestIDs := make([][]string, len(requests))
+	for i := range requests {
+		order[i] = i
+		for n := uint(0); n < requests[i].Count; n++ {
+			requestIDs[i] = append(requestIDs[i], randId())
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return requests[order[i]].ResourceType < requests[order[j]].ResourceType
+	})
+	// A type waits until the first attempt after the last one which could
+	// not acquire it, the other types are not waited for.
+	waits := map[string]time.Duration{}
+	var short string
+	backoff := acquireBackoff
+	for attempt := 1; ; attempt++ {
+		if short != "" {
+			waits[short] = time.Since(start)
+		}
+		ret, missing, err := c.tryAcquireAll(requests, order, requestIDs)
+		if err != nil {
+			return nil, err
+		}
+		if missing == nil {
+			c.Lock()
+			for i, names := range ret {
+				for _, name := range names {
+					c.leases[name] = &lease{rtype: requests[i].ResourceType, cancel: cancel, events: events.FromContext(ctx)}
+				}
+			}
+			c.Unlock()
+			wait := time.Since(start)
+			recorded := map[string]bool{}
+			for _, i := range order {
+				if rtype := requests[i].ResourceType; !recorded[rtype] {
+					recorded[rtype] = true
+					c.recordWait(rtype, Wait{Duration: waits[rtype]})
+				}
+			}
+			for i, names := range ret {
+				events.FromContext(ctx).Emit(events.Event{Type: events.LeaseAcquired, ResourceType: requests[i].ResourceType, Leases: names, DurationSeconds: wait.Seconds()})
+			}
+			return ret, nil
+		}
+		position := QueuePosition{Attempt: attempt, ResourceType: missing.ResourceType, Position: 1, Next: backoff.Step()}
+		if metrics, err := c.Metrics(missing.ResourceType); err == nil {
+			position.Metrics = metrics
+			if unavailable := int(missing.Count) - metrics.Free; unavailable > position.Position {
+				position.Position = unavailable
+			}
+		}
+		if report != nil {
+			report(position)
+		}
+		short = missing.ResourceType
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				c.recordWait(short, Wait{Duration: time.Since(start), TimedOut: true})
+			}
+			return nil, fmt.Errorf("could not acquire %d %s lease(s) after %d attempts: %w", missing.Count, missing.ResourceType, attempt, ErrNotFound)
+		case <-time.After(position.Next):
+		}
+	}
+}
+
+// tryAcquireAll makes one attempt to acquire all requests without waiting.
+// If a resource is not available, everything acquired so far is released and
+// the request that could not be satisfied is returned.
+func (c *client) tryAcquireAll(requests []Request, order []int, requestIDs [][]string) ([][]string, *Request, error) {
+	ret := make([][]string, len(requests))
+	release := func() error {
+		var errs []error
+		for _, names := range ret {
+			for _, name := range names {
+				if err := c.backend.ReleaseOne(name, freeState); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		return utilerrors.NewAggregate(errs)
+	}
+	for _, i := range order {
+		r := requests[i]
+		for n := uint(0); n < r.Count; n++ {
+			resource, err := c.backend.AcquireWithPriority(r.ResourceType, freeState, leasedState, requestIDs[i][n])
+			if err != nil {
+				if releaseErr := release(); releaseErr != nil {
+					return nil, nil, fmt.Errorf("could not release partially acquired leases: %w", releaseErr)
+				}
+				if errors.Is(err, boskos.ErrNotFound) || errors.Is(err, boskos.ErrAlreadyInUse) {
+					return nil, &r, nil
+				}
+				return nil, nil, err
+			}
+			ret[i] = append(ret[i], resource.Name)
+		}
+	}
+	return ret, nil, nil
+}
+
 func (c *client) Heartbeat() error {
 	c.Lock()
 	defer c.Unlock()
 	var errs []error
 	for name, lease := range c.leases {
-		err := c.boskos.UpdateOne(name, leasedState, nil)
+		err := c.backend.UpdateOne(name, leasedState, nil)
 		if err == nil {
 			c.leases[name].updateFailures = 0
 			continue
@@ -140,9 +338,10 @@ func (c *client) Heartbeat() error {
 func (c *client) Release(name string) error {
 	c.Lock()
 	defer c.Unlock()
-	if err := c.boskos.ReleaseOne(name, freeState); err != nil {
+	if err := c.backend.ReleaseOne(name, freeState); err != nil {
 		return err
 	}
+	c.emitReleased(name)
 	delete(c.leases, name)
 	return nil
 }
@@ -154,22 +353,35 @@ func (c *client) ReleaseAll() ([]string, error) {
 	var errs []error
 	for l := range c.leases {
 		ret = append(ret, l)
-		if err := c.boskos.ReleaseOne(l, freeState); err != nil {
+		if err := c.backend.ReleaseOne(l, freeState); err != nil {
 			errs = append(errs, err)
 			continue
 		}
+		c.emitReleased(l)
 		delete(c.leases, l)
 	}
 	return ret, utilerrors.NewAggregate(errs)
 }
 
+func (c *client) emitReleased(name string) {
+	l, ok := c.leases[name]
+	if !ok {
+		return
+	}
+	l.events.Emit(events.Event{Type: events.LeaseReleased, ResourceType: l.rtype, Leases: []string{name}})
+}
+
 func (c *client) Metrics(rtype string) (Metrics, error) {
-	metrics, err := c.boskos.Metric(rtype)
+	metrics, err := c.backend.Metric(rtype)
 	if err != nil {
 		return Metrics{}, err
 	}
-	return Metrics{
+	ret := Metrics{
 		Free:   metrics.Current[freeState],
 		Leased: metrics.Current[leasedState],
-	}, nil
+	}
+	for _, n := range metrics.Current {
+		ret.Total += n
+	}
+	return ret, nil
 }
@@ -2,8 +2,13 @@ package lease
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
 
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -40,6 +45,115 @@ func TestAcquire(t *testing.T) {
 	}
 }
 
+func TestAcquireAll(t *testing.T) {
+	backoff := acquireBackoff
+	t.Cleanup(func() { acquireBackoff = backoff })
+	acquireBackoff.Duration, acquireBackoff.Cap = time.Millisecond, time.Millisecond
+	ctx := context.Background()
+	capacity := NewFakeCapacity(map[string]int{"a": 2, "b": 1})
+	holder := NewFakeClientWithCapacity("holder", capacity, time.Minute, nil)
+	if _, err := holder.AcquireAll([]Request{{ResourceType: "b", Count: 1}}, ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []string
+	waiter := NewFakeClientWithCapacity("waiter", capacity, time.Minute, &calls)
+	// every requested lease keeps its own place in the queue across attempts
+	var ids int
+	original := randId
+	t.Cleanup(func() { randId = original })
+	randId = func() string {
+		ids++
+		return fmt.Sprintf("request-%d", ids)
+	}
+	positions, proceed := make(chan QueuePosition), make(chan struct{})
+	type result struct {
+		names [][]string
+		err   error
+	}
+	done := make(chan result)
+	go func() {
+		names, err := waiter.AcquireAll([]Request{{ResourceType: "b", Count: 1}, {ResourceType: "a", Count: 2}}, ctx, nil, func(position QueuePosition) {
+			positions <- position
+			<-proceed
+		})
+		close(positions)
+		done <- result{names: names, err: err}
+	}()
+	position := <-positions
+	position.Next = 0
+	if diff := cmp.Diff(QueuePosition{Attempt: 1, ResourceType: "b", Position: 1, Metrics: Metrics{Leased: 1, Total: 1}}, position); diff != "" {
+		t.Errorf("unexpected position: %s", diff)
+	}
+	expected := []string{
+		"acquire waiter a free leased request-2",
+		"acquire waiter a free leased request-3",
+		"acquire waiter b free leased request-1",
+		"releaseone waiter a_0 free",
+		"releaseone waiter a_1 free",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("wrong calls to the boskos client: %v", diff.ObjectDiff(calls, expected))
+	}
+	if m, err := holder.Metrics("a"); err != nil || m.Free != 2 {
+		t.Fatalf("expected partially acquired leases to be released, got %+v (%v)", m, err)
+	}
+	if err := holder.Release("b_0"); err != nil {
+		t.Fatal(err)
+	}
+	close(proceed)
+	for range positions {
+	}
+	r := <-done
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	if diff := cmp.Diff([][]string{{"b_0"}, {"a_0", "a_1"}}, r.names); diff != "" {
+		t.Errorf("unexpected leases: %s", diff)
+	}
+	expected = append(expected,
+		"acquire waiter a free leased request-2",
+		"acquire waiter a free leased request-3",
+		"acquire waiter b free leased request-1",
+	)
+	if diff := cmp.Diff(expected, calls); diff != "" {
+		t.Errorf("unexpected calls to the boskos client: %s", diff)
+	}
+	waits := waiter.(*client).waits
+	if len(waits["a"]) != 1 || waits["a"][0] != (Wait{}) {
+		t.Errorf("expected the free resources not to be waited for, got %v", waits["a"])
+	}
+	if len(waits["b"]) != 1 || waits["b"][0].Duration == 0 {
+		t.Errorf("expected the wait for the resource to be freed to be recorded, got %v", waits["b"])
+	}
+}
+
+func TestAcquireAllTimeout(t *testing.T) {
+	backoff := acquireBackoff
+	t.Cleanup(func() { acquireBackoff = backoff })
+	acquireBackoff.Duration, acquireBackoff.Cap = time.Millisecond, time.Millisecond
+	leaseClient := NewFakeClientWithCapacity("owner", NewFakeCapacity(map[string]int{"a": 1}), 10*time.Millisecond, nil)
+	var attempts int
+	_, err := leaseClient.AcquireAll([]Request{{ResourceType: "a", Count: 2}}, context.Background(), nil, func(position QueuePosition) {
+		attempts = position.Attempt
+		if position.Position != 1 {
+			t.Errorf("expected position 1, got %d", position.Position)
+		}
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if attempts == 0 {
+		t.Error("expected the position to be reported")
+	}
+	if m, err := leaseClient.Metrics("a"); err != nil || m.Free != 1 {
+		t.Errorf("expected no leases to be held after timing out, got %+v (%v)", m, err)
+	}
+	if waits := leaseClient.(*client).waits["a"]; len(waits) != 1 || !waits[0].TimedOut {
+		t.Errorf("expected the acquisition to be recorded as timed out, got %v", waits)
+	}
+}
+
 func TestHeartbeatCancel(t *testing.T) {
 	ctx := context.Background()
 	var calls []string
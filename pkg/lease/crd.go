@@ -0,0 +1,167 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/boskos/common"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	leaseresourcev1 "github.com/openshift/ci-tools/pkg/api/leaseresource/v1"
+)
+
+// crdBackend stores leases in the status of LeaseResource objects. Every
+// change is an update guarded by the resource version of the object that was
+// read, so when two owners race for the same resource, one of them gets a
+// conflict and moves on to the next resource. Resources whose owners stop
+// sending heartbeats are freed by the lease reaper controller.
+type crdBackend struct {
+	owner     string
+	namespace string
+	client    ctrlruntimeclient.Client
+	now       func() time.Time
+}
+
+func newCRDBackend(options BackendOptions) (Backend, error) {
+	if options.Client == nil {
+		return nil, errors.New("a client for the cluster storing the leases is required")
+	}
+	if options.Namespace == "" {
+		return nil, errors.New("the namespace storing the leases is required")
+	}
+	return &crdBackend{
+		owner:     options.Owner,
+		namespace: options.Namespace,
+		client:    options.Client,
+		now:       time.Now,
+	}, nil
+}
+
+func (b *crdBackend) list(ctx context.Context, rtype string) ([]leaseresourcev1.LeaseResource, error) {
+	list := &leaseresourcev1.LeaseResourceList{}
+	if err := b.client.List(ctx, list, ctrlruntimeclient.InNamespace(b.namespace)); err != nil {
+		return nil, fmt.Errorf("could not list lease resources in %s: %w", b.namespace, err)
+	}
+	var ret []leaseresourcev1.LeaseResource
+	for _, r := range list.Items {
+		if rtype == "" || r.Spec.Type == rtype {
+			ret = append(ret, r)
+		}
+	}
+	return ret, nil
+}
+
+// AcquireWithPriority leases the least recently used resource, like Boskos.
+// Requests are not queued, so the request ID is not used.
+func (b *crdBackend) AcquireWithPriority(rtype, state, dest, _ string) (*common.Resource, error) {
+	ctx := context.Background()
+	resources, err := b.list(ctx, rtype)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if ti, tj := resources[i].Status.LastUpdate, resources[j].Status.LastUpdate; !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return resources[i].Name < resources[j].Name
+	})
+	for i := range resources {
+		r := &resources[i]
+		if r.State() != state {
+			continue
+		}
+		now := metav1.NewTime(b.now())
+		r.Status = leaseresourcev1.LeaseResourceStatus{State: dest, Owner: b.owner, LastUpdate: now}
+		if err := b.client.Update(ctx, r); err != nil {
+			if kerrors.IsConflict(err) {
+				// another owner acquired the resource first
+				continue
+			}
+			return nil, fmt.Errorf("could not update lease resource %s: %w", r.Name, err)
+		}
+		return &common.Resource{Name: r.Name, Type: rtype, State: dest, Owner: b.owner, LastUpdate: now.Time}, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (b *crdBackend) AcquireWaitWithPriority(ctx context.Context, rtype, state, dest, requestID string) (*common.Resource, error) {
+	for {
+		r, err := b.AcquireWithPriority(rtype, state, dest, requestID)
+		if err != ErrNotFound {
+			return r, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(3 * time.Second):
+		}
+	}
+}
+
+// update changes the status of a resource leased by the owner
+func (b *crdBackend) update(name string, mutate func(*leaseresourcev1.LeaseResourceStatus)) error {
+	ctx := context.Background()
+	r := &leaseresourcev1.LeaseResource{}
+	if err := b.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: b.namespace, Name: name}, r); err != nil {
+		return fmt.Errorf("could not get lease resource %s: %w", name, err)
+	}
+	if r.Status.Owner != b.owner {
+		return fmt.Errorf("lease resource %s is owned by %q", name, r.Status.Owner)
+	}
+	mutate(&r.Status)
+	r.Status.LastUpdate = metav1.NewTime(b.now())
+	if err := b.client.Update(ctx, r); err != nil {
+		return fmt.Errorf("could not update lease resource %s: %w", name, err)
+	}
+	return nil
+}
+
+func (b *crdBackend) UpdateOne(name, dest string, _ *common.UserData) error {
+	return b.update(name, func(status *leaseresourcev1.LeaseResourceStatus) {
+		status.State = dest
+	})
+}
+
+func (b *crdBackend) ReleaseOne(name, dest string) error {
+	return b.update(name, func(status *leaseresourcev1.LeaseResourceStatus) {
+		status.State, status.Owner = dest, ""
+	})
+}
+
+func (b *crdBackend) ReleaseAll(dest string) error {
+	resources, err := b.list(context.Background(), "")
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, r := range resources {
+		if r.Status.Owner != b.owner {
+			continue
+		}
+		if err := b.ReleaseOne(r.Name, dest); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (b *crdBackend) Metric(rtype string) (common.Metric, error) {
+	resources, err := b.list(context.Background(), rtype)
+	if err != nil {
+		return common.Metric{}, err
+	}
+	metric := common.NewMetric(rtype)
+	for i := range resources {
+		metric.Current[resources[i].State()]++
+		if owner := resources[i].Status.Owner; owner != "" {
+			metric.Owners[owner]++
+		}
+	}
+	return metric, nil
+}
@@ -0,0 +1,170 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	leaseresourcev1 "github.com/openshift/ci-tools/pkg/api/leaseresource/v1"
+)
+
+func leaseResource(name, rtype string, status leaseresourcev1.LeaseResourceStatus) *leaseresourcev1.LeaseResource {
+	return &leaseresourcev1.LeaseResource{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "leases", Name: name},
+		Spec:       leaseresourcev1.LeaseResourceSpec{Type: rtype},
+		Status:     status,
+	}
+}
+
+func newTestCRDBackend(t *testing.T, owner string, client ctrlruntimeclient.Client, now time.Time) *crdBackend {
+	backend, err := newCRDBackend(BackendOptions{Owner: owner, Client: client, Namespace: "leases"})
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	ret := backend.(*crdBackend)
+	ret.now = func() time.Time { return now }
+	return ret
+}
+
+func getStatus(t *testing.T, client ctrlruntimeclient.Client, name string) leaseresourcev1.LeaseResourceStatus {
+	r := &leaseresourcev1.LeaseResource{}
+	if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "leases", Name: name}, r); err != nil {
+		t.Fatalf("failed to get %s: %v", name, err)
+	}
+	r.Status.LastUpdate = metav1.NewTime(r.Status.LastUpdate.Time.UTC())
+	return r.Status
+}
+
+func TestCRDBackend(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		leaseResource("recent", "quota", leaseresourcev1.LeaseResourceStatus{LastUpdate: metav1.NewTime(now.Add(-time.Minute))}),
+		leaseResource("old", "quota", leaseresourcev1.LeaseResourceStatus{State: "free", LastUpdate: metav1.NewTime(now.Add(-time.Hour))}),
+		leaseResource("other", "ip-pool", leaseresourcev1.LeaseResourceStatus{}),
+	).Build()
+	first := newTestCRDBackend(t, "first", client, now)
+	second := newTestCRDBackend(t, "second", client, now)
+
+	r, err := first.AcquireWithPriority("quota", freeState, leasedState, "")
+	if err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	if r.Name != "old" {
+		t.Errorf("expected the least recently used resource to be acquired, got %s", r.Name)
+	}
+	if diff := cmp.Diff(leaseresourcev1.LeaseResourceStatus{State: leasedState, Owner: "first", LastUpdate: metav1.NewTime(now)}, getStatus(t, client, "old")); diff != "" {
+		t.Errorf("unexpected status after acquisition: %s", diff)
+	}
+	if r, err := second.AcquireWithPriority("quota", freeState, leasedState, ""); err != nil || r.Name != "recent" {
+		t.Fatalf("expected to acquire the other resource, got %v, %v", r, err)
+	}
+	if _, err := second.AcquireWithPriority("quota", freeState, leasedState, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound when all resources are leased, got %v", err)
+	}
+
+	metric, err := first.Metric("quota")
+	if err != nil {
+		t.Fatalf("failed to get metrics: %v", err)
+	}
+	if diff := cmp.Diff(map[string]int{leasedState: 2}, metric.Current); diff != "" {
+		t.Errorf("unexpected states: %s", diff)
+	}
+	if diff := cmp.Diff(map[string]int{"first": 1, "second": 1}, metric.Owners); diff != "" {
+		t.Errorf("unexpected owners: %s", diff)
+	}
+
+	first.now = func() time.Time { return now.Add(time.Minute) }
+	if err := first.UpdateOne("old", leasedState, nil); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if diff := cmp.Diff(leaseresourcev1.LeaseResourceStatus{State: leasedState, Owner: "first", LastUpdate: metav1.NewTime(now.Add(time.Minute))}, getStatus(t, client, "old")); diff != "" {
+		t.Errorf("unexpected status after heartbeat: %s", diff)
+	}
+	if err := first.ReleaseOne("recent", freeState); err == nil {
+		t.Error("expected releasing a resource leased by another owner to fail")
+	}
+	if err := second.ReleaseAll(freeState); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	if status := getStatus(t, client, "recent"); status.State != freeState || status.Owner != "" {
+		t.Errorf("expected the resource to be released, got %+v", status)
+	}
+	if status := getStatus(t, client, "old"); status.Owner != "first" {
+		t.Errorf("expected the resource of another owner not to be released, got %+v", status)
+	}
+}
+
+// racingClient lets another owner acquire the resource right before the first
+// update, as if it had listed the resources at the same time
+type racingClient struct {
+	ctrlruntimeclient.Client
+	raced bool
+}
+
+func (c *racingClient) Update(ctx context.Context, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.UpdateOption) error {
+	if !c.raced {
+		c.raced = true
+		winner := &leaseresourcev1.LeaseResource{}
+		if err := c.Client.Get(ctx, ctrlruntimeclient.ObjectKeyFromObject(obj), winner); err != nil {
+			return err
+		}
+		winner.Status = leaseresourcev1.LeaseResourceStatus{State: leasedState, Owner: "winner"}
+		if err := c.Client.Update(ctx, winner); err != nil {
+			return err
+		}
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func TestCRDBackendConflict(t *testing.T) {
+	client := &racingClient{Client: fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		leaseResource("a", "quota", leaseresourcev1.LeaseResourceStatus{}),
+		leaseResource("b", "quota", leaseresourcev1.LeaseResourceStatus{}),
+	).Build()}
+	backend := newTestCRDBackend(t, "loser", client, time.Now())
+	r, err := backend.AcquireWithPriority("quota", freeState, leasedState, "")
+	if err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	if r.Name != "b" {
+		t.Errorf("expected the resource acquired concurrently to be skipped, got %s", r.Name)
+	}
+	if status := getStatus(t, client, "a"); status.Owner != "winner" {
+		t.Errorf("expected the concurrent acquisition to be kept, got %+v", status)
+	}
+}
+
+func TestNewClientForBackend(t *testing.T) {
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		leaseResource("quota-0", "quota", leaseresourcev1.LeaseResourceStatus{}),
+		leaseResource("pool-0", "ip-pool", leaseresourcev1.LeaseResourceStatus{}),
+	).Build()
+	leaseClient, err := NewClientForBackend(CRDBackend, BackendOptions{Owner: "owner", Client: client, Namespace: "leases"}, 0, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	names, err := leaseClient.AcquireAll([]Request{{ResourceType: "quota", Count: 1}, {ResourceType: "ip-pool", Count: 1}}, context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	if diff := cmp.Diff([][]string{{"quota-0"}, {"pool-0"}}, names); diff != "" {
+		t.Errorf("unexpected leases: %s", diff)
+	}
+	if err := leaseClient.Heartbeat(); err != nil {
+		t.Errorf("failed to send heartbeat: %v", err)
+	}
+	if released, err := leaseClient.ReleaseAll(); err != nil || len(released) != 2 {
+		t.Errorf("expected both leases to be released, got %v, %v", released, err)
+	}
+
+	if _, err := NewClientForBackend("unknown", BackendOptions{}, 0, 0); err == nil {
+		t.Error("expected an unknown backend to be rejected")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -15,6 +16,7 @@ type fakeClient struct {
 	owner    string
 	failures sets.Set[string]
 	calls    *[]string
+	capacity *FakeCapacity
 }
 
 func NewFakeClient(owner, url string, retries int, failures sets.Set[string], calls *[]string) Client {
@@ -31,6 +33,71 @@ func NewFakeClient(owner, url string, retries int, failures sets.Set[string], ca
 	}, retries, time.Duration(0))
 }
 
+// FakeCapacity is a pool of resources shared by fake clients, so that
+// competition for a limited number of resources can be simulated
+type FakeCapacity struct {
+	sync.Mutex
+	total  map[string]int
+	leased map[string]sets.Set[string]
+}
+
+// NewFakeCapacity creates a pool with the number of resources of each type
+func NewFakeCapacity(total map[string]int) *FakeCapacity {
+	return &FakeCapacity{total: total, leased: map[string]sets.Set[string]{}}
+}
+
+func (c *FakeCapacity) acquire(rtype string) (string, bool) {
+	c.Lock()
+	defer c.Unlock()
+	leased := c.leased[rtype]
+	if leased == nil {
+		leased = sets.New[string]()
+		c.leased[rtype] = leased
+	}
+	for i := 0; i < c.total[rtype]; i++ {
+		if name := fmt.Sprintf("%s_%d", rtype, i); !leased.Has(name) {
+			leased.Insert(name)
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (c *FakeCapacity) release(name string) {
+	c.Lock()
+	defer c.Unlock()
+	for _, leased := range c.leased {
+		leased.Delete(name)
+	}
+}
+
+func (c *FakeCapacity) metric(rtype string) common.Metric {
+	c.Lock()
+	defer c.Unlock()
+	metric := common.NewMetric(rtype)
+	leased := c.leased[rtype].Len()
+	metric.Current[freeState] = c.total[rtype] - leased
+	metric.Current[leasedState] = leased
+	return metric
+}
+
+// NewFakeClientWithCapacity creates a fake client that leases resources from
+// a pool, which may be shared with other clients. Acquisitions time out after
+// acquireTimeout.
+func NewFakeClientWithCapacity(owner string, capacity *FakeCapacity, acquireTimeout time.Duration, calls *[]string) Client {
+	if calls == nil {
+		calls = &[]string{}
+	}
+	randId = func() string {
+		return "random"
+	}
+	return newClient(&fakeClient{
+		owner:    owner,
+		calls:    calls,
+		capacity: capacity,
+	}, 0, acquireTimeout)
+}
+
 func (c *fakeClient) addCall(call string, args ...string) error {
 	s := strings.Join(append([]string{call, c.owner}, args...), " ")
 	if c.calls != nil {
@@ -42,6 +109,20 @@ func (c *fakeClient) addCall(call string, args ...string) error {
 	return nil
 }
 
+func (c *fakeClient) AcquireWithPriority(rtype, state, dest, requestID string) (*common.Resource, error) {
+	if err := c.addCall("acquire", rtype, state, dest, requestID); err != nil {
+		return nil, err
+	}
+	if c.capacity == nil {
+		return &common.Resource{Name: fmt.Sprintf("%s_%d", rtype, len(*c.calls)-1)}, nil
+	}
+	name, ok := c.capacity.acquire(rtype)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &common.Resource{Name: name}, nil
+}
+
 func (c *fakeClient) AcquireWaitWithPriority(ctx context.Context, rtype, state, dest, requestID string) (*common.Resource, error) {
 	err := c.addCall("acquire", rtype, state, dest, requestID)
 	return &common.Resource{Name: fmt.Sprintf("%s_%d", rtype, len(*c.calls)-1)}, err
@@ -52,13 +133,22 @@ func (c *fakeClient) UpdateOne(name, dest string, _ *common.UserData) error {
 }
 
 func (c *fakeClient) ReleaseOne(name, dest string) error {
-	return c.addCall("releaseone", name, dest)
+	if err := c.addCall("releaseone", name, dest); err != nil {
+		return err
+	}
+	if c.capacity != nil {
+		c.capacity.release(name)
+	}
+	return nil
 }
 
 func (c *fakeClient) ReleaseAll(dest string) error {
 	return c.addCall("releaseall", dest)
 }
 
-func (*fakeClient) Metric(rtype string) (common.Metric, error) {
+func (c *fakeClient) Metric(rtype string) (common.Metric, error) {
+	if c.capacity != nil {
+		return c.capacity.metric(rtype), nil
+	}
 	return common.NewMetric(rtype), nil
 }
@@ -0,0 +1,343 @@
+package lease
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WaitHistory stores how long acquisitions waited for resources, so that the
+// waits of earlier executions can be used to predict the wait of this one
+type WaitHistory interface {
+	// Waits returns the recorded waits for the resource type
+	Waits(ctx context.Context, rtype string) ([]Wait, error)
+	// Record adds the wait of an acquisition of the resource type
+	Record(ctx context.Context, rtype string, wait Wait) error
+}
+
+// Wait is how long an acquisition waited for resources
+type Wait struct {
+	Duration time.Duration
+	// TimedOut is set when the acquisition gave up, so it would have waited
+	// longer than Duration for resources
+	TimedOut bool
+}
+
+// WaitStats summarizes how long acquisitions of a resource type waited
+type WaitStats struct {
+	// Samples is the number of acquisitions that completed, which the
+	// percentiles are computed from
+	Samples       int
+	P50, P90, P99 time.Duration
+	// TimedOutSamples is the number of acquisitions that timed out
+	TimedOutSamples int
+	// ContendedSamples is the number of acquisitions which found no free
+	// resource and had to wait for one to be released, including those that
+	// timed out waiting
+	ContendedSamples int
+	// ContendedP50 is the median wait of those acquisitions. The waits of the
+	// acquisitions that timed out are only known to be longer than recorded,
+	// so the median is estimated with the Kaplan-Meier estimator.
+	ContendedP50 time.Duration
+	// ContendedP50Exceeded is set when more than half of the contended
+	// acquisitions timed out before the median was reached, in which case the
+	// median is only known to be longer than ContendedP50
+	ContendedP50Exceeded bool
+}
+
+func newWaitStats(waits []Wait) WaitStats {
+	var completed []time.Duration
+	var contended []Wait
+	var timedOut int
+	for _, wait := range waits {
+		if wait.TimedOut {
+			timedOut++
+		} else {
+			completed = append(completed, wait.Duration)
+		}
+		// an acquisition that found free resources returns before the first
+		// retry would have happened
+		if wait.TimedOut || wait.Duration >= acquireBackoff.Duration {
+			contended = append(contended, wait)
+		}
+	}
+	sort.Slice(completed, func(i, j int) bool { return completed[i] < completed[j] })
+	stats := WaitStats{
+		Samples:          len(completed),
+		P50:              percentile(completed, 50),
+		P90:              percentile(completed, 90),
+		P99:              percentile(completed, 99),
+		TimedOutSamples:  timedOut,
+		ContendedSamples: len(contended),
+	}
+	stats.ContendedP50, stats.ContendedP50Exceeded = censoredMedian(contended)
+	return stats
+}
+
+// censoredMedian estimates the median of waits of which those that timed out
+// are censored: the survival function drops at every completed wait by the
+// share of the acquisitions still waiting at that time that completed, and the
+// median is where it reaches one half. When it never does, the longest wait is
+// returned as a lower bound.
+func censoredMedian(waits []Wait) (time.Duration, bool) {
+	if len(waits) == 0 {
+		return 0, false
+	}
+	sorted := append([]Wait{}, waits...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Duration != sorted[j].Duration {
+			return sorted[i].Duration < sorted[j].Duration
+		}
+		// acquisitions that completed at a time were still waiting for the
+		// ones which timed out then
+		return !sorted[i].TimedOut && sorted[j].TimedOut
+	})
+	survival := 1.0
+	for i := 0; i < len(sorted); {
+		duration, completed, j := sorted[i].Duration, 0, i
+		for ; j < len(sorted) && sorted[j].Duration == duration; j++ {
+			if !sorted[j].TimedOut {
+				completed++
+			}
+		}
+		if completed > 0 {
+			survival *= 1 - float64(completed)/float64(len(sorted)-i)
+			if survival <= 0.5 {
+				return duration, false
+			}
+		}
+		i = j
+	}
+	return sorted[len(sorted)-1].Duration, true
+}
+
+// percentile uses the nearest-rank method on sorted values
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// minContendedSamples is how many acquisitions must have waited for resources
+// to be released before their waits are used to predict another one
+const minContendedSamples = 5
+
+// Prediction describes how long a request is expected to wait for resources
+type Prediction struct {
+	Request
+	Metrics
+	Waits WaitStats
+	// Known is set when there is enough information to predict the wait
+	Known bool
+	// Wait is the predicted wait, when it is known
+	Wait time.Duration
+	// Insufficient is set when fewer resources exist than were requested, so
+	// the request can never be satisfied
+	Insufficient bool
+	// ExceedsTimeout is set when the request is not expected to be satisfied
+	// before the acquisition times out
+	ExceedsTimeout bool
+}
+
+// predict estimates the wait of a request. Requests for which enough
+// resources are free are expected to be satisfied immediately, otherwise the
+// median wait of past acquisitions which had to wait is used. When that median
+// is only known to be longer than some wait, the request is expected to time
+// out if that wait already reaches the timeout and the wait is unknown
+// otherwise.
+func predict(request Request, metrics Metrics, waits WaitStats, timeout time.Duration) Prediction {
+	p := Prediction{Request: request, Metrics: metrics, Waits: waits}
+	switch count := int(request.Count); {
+	case metrics.Free >= count:
+		p.Known = true
+	case metrics.Total > 0 && metrics.Total < count:
+		p.Insufficient = true
+	case waits.ContendedSamples < minContendedSamples:
+		// too few acquisitions waited to predict from their waits
+	case !waits.ContendedP50Exceeded || waits.ContendedP50 >= timeout:
+		p.Known, p.Wait = true, waits.ContendedP50
+	}
+	p.ExceedsTimeout = p.Insufficient || (p.Known && (p.Wait > timeout || (waits.ContendedP50Exceeded && p.Wait >= timeout)))
+	return p
+}
+
+// recordWait keeps the wait of an acquisition in memory and in the wait
+// history, if there is one
+func (c *client) recordWait(rtype string, wait Wait) {
+	c.Lock()
+	c.waits[rtype] = append(c.waits[rtype], wait)
+	c.Unlock()
+	if c.history == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := c.history.Record(ctx, rtype, wait); err != nil {
+		logrus.WithError(err).Warnf("Could not record the lease wait for %s.", rtype)
+	}
+}
+
+func (c *client) waitStats(rtype string) WaitStats {
+	if c.history != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		waits, err := c.history.Waits(ctx, rtype)
+		if err == nil {
+			return newWaitStats(waits)
+		}
+		logrus.WithError(err).Warnf("Could not load the lease wait history for %s, using the waits of this execution.", rtype)
+	}
+	c.RLock()
+	defer c.RUnlock()
+	return newWaitStats(c.waits[rtype])
+}
+
+func (c *client) Predict(requests []Request) ([]Prediction, error) {
+	var ret []Prediction
+	for _, r := range requests {
+		metrics, err := c.Metrics(r.ResourceType)
+		if err != nil {
+			return nil, fmt.Errorf("could not get metrics for %s: %w", r.ResourceType, err)
+		}
+		ret = append(ret, predict(r, metrics, c.waitStats(r.ResourceType), c.acquireTimeout))
+	}
+	return ret, nil
+}
+
+// maxWaitSamples is how many of the most recent waits of each resource type
+// are kept in the history
+const maxWaitSamples = 200
+
+// waitsKey is the key of the ConfigMap of a resource type holding its waits
+const waitsKey = "waits"
+
+// configMapWaitHistory keeps the waits of each resource type in a ConfigMap of
+// its own, so that executions leasing different types do not conflict when
+// they record their waits
+type configMapWaitHistory struct {
+	client    ctrlruntimeclient.Client
+	namespace string
+	prefix    string
+}
+
+// NewConfigMapWaitHistory stores the history of waits in ConfigMaps named with
+// the prefix and the resource type, which are created when the first wait of
+// the type is recorded
+func NewConfigMapWaitHistory(client ctrlruntimeclient.Client, namespace, prefix string) WaitHistory {
+	return &configMapWaitHistory{client: client, namespace: namespace, prefix: prefix}
+}
+
+// invalidNameChars are replaced in resource types to form ConfigMap names
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+func (h *configMapWaitHistory) name(rtype string) string {
+	name := fmt.Sprintf("%s-%s", h.prefix, invalidNameChars.ReplaceAllString(strings.ToLower(rtype), "-"))
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		name = name[:validation.DNS1123SubdomainMaxLength]
+	}
+	return strings.TrimRight(name, ".-")
+}
+
+func (h *configMapWaitHistory) get(ctx context.Context, name string) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	if err := h.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: h.namespace, Name: name}, cm); err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+// recordedWait is the serialized form of a Wait
+type recordedWait struct {
+	Seconds  float64 `json:"seconds"`
+	TimedOut bool    `json:"timed_out,omitempty"`
+}
+
+func parseWaits(raw string) ([]recordedWait, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var waits []recordedWait
+	if err := json.Unmarshal([]byte(raw), &waits); err != nil {
+		return nil, err
+	}
+	return waits, nil
+}
+
+func (h *configMapWaitHistory) Waits(ctx context.Context, rtype string) ([]Wait, error) {
+	name := h.name(rtype)
+	cm, err := h.get(ctx, name)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not get config map %s/%s: %w", h.namespace, name, err)
+	}
+	recorded, err := parseWaits(cm.Data[waitsKey])
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the waits for %s in config map %s/%s: %w", rtype, h.namespace, name, err)
+	}
+	var waits []Wait
+	for _, w := range recorded {
+		waits = append(waits, Wait{Duration: time.Duration(w.Seconds * float64(time.Second)), TimedOut: w.TimedOut})
+	}
+	return waits, nil
+}
+
+func (h *configMapWaitHistory) Record(ctx context.Context, rtype string, wait Wait) error {
+	name := h.name(rtype)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := h.get(ctx, name)
+		create := kerrors.IsNotFound(err)
+		if create {
+			cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: h.namespace, Name: name}}
+		} else if err != nil {
+			return fmt.Errorf("could not get config map %s/%s: %w", h.namespace, name, err)
+		}
+		// a corrupted entry is replaced rather than blocking all updates
+		waits, err := parseWaits(cm.Data[waitsKey])
+		if err != nil {
+			waits = nil
+		}
+		waits = append(waits, recordedWait{Seconds: wait.Duration.Seconds(), TimedOut: wait.TimedOut})
+		if len(waits) > maxWaitSamples {
+			waits = waits[len(waits)-maxWaitSamples:]
+		}
+		raw, err := json.Marshal(waits)
+		if err != nil {
+			return fmt.Errorf("could not serialize waits: %w", err)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[waitsKey] = string(raw)
+		if create {
+			if err := h.client.Create(ctx, cm); err != nil {
+				if kerrors.IsAlreadyExists(err) {
+					// created concurrently, retry as an update
+					return kerrors.NewConflict(corev1.Resource("configmaps"), name, err)
+				}
+				return err
+			}
+			return nil
+		}
+		return h.client.Update(ctx, cm)
+	})
+}
@@ -0,0 +1,247 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNewWaitStats(t *testing.T) {
+	var waits []Wait
+	for i := 100; i > 0; i-- {
+		waits = append(waits, Wait{Duration: time.Duration(i) * time.Second})
+	}
+	waits = append(waits, Wait{}, Wait{})
+	expected := WaitStats{
+		Samples:          102,
+		P50:              49 * time.Second,
+		P90:              90 * time.Second,
+		P99:              99 * time.Second,
+		ContendedSamples: 98,
+		ContendedP50:     51 * time.Second,
+	}
+	if diff := cmp.Diff(expected, newWaitStats(waits)); diff != "" {
+		t.Errorf("unexpected stats: %s", diff)
+	}
+	if diff := cmp.Diff(WaitStats{}, newWaitStats(nil)); diff != "" {
+		t.Errorf("unexpected stats without waits: %s", diff)
+	}
+}
+
+func TestCensoredMedian(t *testing.T) {
+	completed := func(minutes int) Wait { return Wait{Duration: time.Duration(minutes) * time.Minute} }
+	timedOut := func(minutes int) Wait { return Wait{Duration: time.Duration(minutes) * time.Minute, TimedOut: true} }
+	for _, tc := range []struct {
+		name             string
+		waits            []Wait
+		expected         time.Duration
+		expectedExceeded bool
+	}{
+		{
+			name: "no waits",
+		},
+		{
+			name:     "median of completed waits",
+			waits:    []Wait{completed(4), completed(1), completed(3), completed(2)},
+			expected: 2 * time.Minute,
+		},
+		{
+			name:     "waits that timed out are longer than the recorded duration",
+			waits:    []Wait{completed(1), completed(2), timedOut(3), timedOut(3), completed(10)},
+			expected: 10 * time.Minute,
+		},
+		{
+			name:     "waits that timed out early do not count against later completed waits",
+			waits:    []Wait{timedOut(1), completed(2), completed(3), completed(4)},
+			expected: 3 * time.Minute,
+		},
+		{
+			name:             "median is not reached when most acquisitions timed out",
+			waits:            []Wait{completed(1), timedOut(150), timedOut(150), timedOut(150)},
+			expected:         150 * time.Minute,
+			expectedExceeded: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			median, exceeded := censoredMedian(tc.waits)
+			if median != tc.expected || exceeded != tc.expectedExceeded {
+				t.Errorf("expected median %s (exceeded: %t), got %s (exceeded: %t)", tc.expected, tc.expectedExceeded, median, exceeded)
+			}
+		})
+	}
+}
+
+func TestPredict(t *testing.T) {
+	contended := WaitStats{Samples: 10, ContendedSamples: minContendedSamples, ContendedP50: 2 * time.Hour}
+	for _, tc := range []struct {
+		name     string
+		count    uint
+		metrics  Metrics
+		waits    WaitStats
+		expected Prediction
+	}{
+		{
+			name:     "enough free resources",
+			count:    2,
+			metrics:  Metrics{Free: 2, Leased: 3, Total: 5},
+			waits:    contended,
+			expected: Prediction{Known: true},
+		},
+		{
+			name:     "not enough resources exist",
+			count:    3,
+			metrics:  Metrics{Leased: 2, Total: 2},
+			expected: Prediction{Insufficient: true, ExceedsTimeout: true},
+		},
+		{
+			name:     "median of contended waits exceeds the timeout",
+			count:    1,
+			metrics:  Metrics{Leased: 2, Total: 2},
+			waits:    contended,
+			expected: Prediction{Known: true, Wait: 2 * time.Hour, ExceedsTimeout: true},
+		},
+		{
+			name:     "median of contended waits within the timeout",
+			count:    1,
+			metrics:  Metrics{Leased: 2, Total: 2},
+			waits:    WaitStats{ContendedSamples: minContendedSamples, ContendedP50: time.Minute},
+			expected: Prediction{Known: true, Wait: time.Minute},
+		},
+		{
+			name:     "most contended acquisitions timed out",
+			count:    1,
+			metrics:  Metrics{Leased: 2, Total: 2},
+			waits:    WaitStats{ContendedSamples: minContendedSamples, ContendedP50: time.Hour, ContendedP50Exceeded: true},
+			expected: Prediction{Known: true, Wait: time.Hour, ExceedsTimeout: true},
+		},
+		{
+			name:    "most contended acquisitions timed out with a shorter timeout",
+			count:   1,
+			metrics: Metrics{Leased: 2, Total: 2},
+			waits:   WaitStats{ContendedSamples: minContendedSamples, ContendedP50: time.Minute, ContendedP50Exceeded: true},
+		},
+		{
+			name:    "too few contended waits to predict",
+			count:   1,
+			metrics: Metrics{Leased: 2, Total: 2},
+			waits:   WaitStats{Samples: 100, ContendedSamples: minContendedSamples - 1, ContendedP50: 2 * time.Hour},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request := Request{ResourceType: "rtype", Count: tc.count}
+			tc.expected.Request, tc.expected.Metrics, tc.expected.Waits = request, tc.metrics, tc.waits
+			if diff := cmp.Diff(tc.expected, predict(request, tc.metrics, tc.waits, time.Hour)); diff != "" {
+				t.Errorf("unexpected prediction: %s", diff)
+			}
+		})
+	}
+}
+
+func TestConfigMapWaitHistory(t *testing.T) {
+	ctx := context.Background()
+	client := fakectrlruntimeclient.NewClientBuilder().Build()
+	history := NewConfigMapWaitHistory(client, "ci", "lease-waits")
+	if waits, err := history.Waits(ctx, "rtype"); err != nil || waits != nil {
+		t.Fatalf("expected no waits before the config map exists, got %v, %v", waits, err)
+	}
+	for i := 0; i < maxWaitSamples+1; i++ {
+		if err := history.Record(ctx, "rtype", Wait{Duration: time.Duration(i) * time.Second}); err != nil {
+			t.Fatalf("failed to record wait: %v", err)
+		}
+	}
+	if err := history.Record(ctx, "Other_Type", Wait{Duration: 1500 * time.Millisecond, TimedOut: true}); err != nil {
+		t.Fatalf("failed to record wait: %v", err)
+	}
+	waits, err := history.Waits(ctx, "rtype")
+	if err != nil {
+		t.Fatalf("failed to get waits: %v", err)
+	}
+	if len(waits) != maxWaitSamples || waits[0].Duration != time.Second || waits[len(waits)-1].Duration != maxWaitSamples*time.Second {
+		t.Errorf("expected the most recent %d waits to be kept, got %d from %s to %s", maxWaitSamples, len(waits), waits[0].Duration, waits[len(waits)-1].Duration)
+	}
+	if waits, err := history.Waits(ctx, "Other_Type"); err != nil || !cmp.Equal(waits, []Wait{{Duration: 1500 * time.Millisecond, TimedOut: true}}) {
+		t.Errorf("unexpected waits of the other type: %v, %v", waits, err)
+	}
+	for _, name := range []string{"lease-waits-rtype", "lease-waits-other-type"} {
+		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ci", Name: name}, &corev1.ConfigMap{}); err != nil {
+			t.Errorf("expected the waits of each type to be kept in a config map of its own: %v", err)
+		}
+	}
+
+	corrupted := fakectrlruntimeclient.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "lease-waits-rtype"},
+		Data:       map[string]string{waitsKey: "garbage"},
+	}).Build()
+	history = NewConfigMapWaitHistory(corrupted, "ci", "lease-waits")
+	if _, err := history.Waits(ctx, "rtype"); err == nil {
+		t.Error("expected corrupted waits to fail to load")
+	}
+	if err := history.Record(ctx, "rtype", Wait{Duration: time.Second}); err != nil {
+		t.Fatalf("failed to record wait: %v", err)
+	}
+	if waits, err := history.Waits(ctx, "rtype"); err != nil || !cmp.Equal(waits, []Wait{{Duration: time.Second}}) {
+		t.Errorf("expected corrupted waits to be replaced, got %v, %v", waits, err)
+	}
+}
+
+func TestClientPredict(t *testing.T) {
+	capacity := NewFakeCapacity(map[string]int{"rtype": 1})
+	leaseClient := NewFakeClientWithCapacity("owner", capacity, time.Minute, nil)
+	history := NewConfigMapWaitHistory(fakectrlruntimeclient.NewClientBuilder().Build(), "ci", "lease-waits")
+	for i := 0; i < minContendedSamples; i++ {
+		if err := history.Record(context.Background(), "rtype", Wait{Duration: time.Hour}); err != nil {
+			t.Fatalf("failed to record wait: %v", err)
+		}
+	}
+	WithWaitHistory(history)(leaseClient.(*client))
+
+	predictions, err := leaseClient.Predict([]Request{{ResourceType: "rtype", Count: 1}})
+	if err != nil {
+		t.Fatalf("failed to predict: %v", err)
+	}
+	if p := predictions[0]; !p.Known || p.Wait != 0 || p.Waits.Samples != minContendedSamples {
+		t.Errorf("expected free resources to be acquired immediately, got %+v", p)
+	}
+	if _, err := leaseClient.AcquireAll([]Request{{ResourceType: "rtype", Count: 1}}, context.Background(), nil, nil); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	predictions, err = leaseClient.Predict([]Request{{ResourceType: "rtype", Count: 1}})
+	if err != nil {
+		t.Fatalf("failed to predict: %v", err)
+	}
+	if p := predictions[0]; !p.ExceedsTimeout || p.Wait != time.Hour || p.Waits.Samples != minContendedSamples+1 {
+		t.Errorf("expected the wait to be predicted from the history and the acquisition to be recorded, got %+v", p)
+	}
+}
+
+func TestClientPredictTimedOut(t *testing.T) {
+	backoff := acquireBackoff
+	t.Cleanup(func() { acquireBackoff = backoff })
+	acquireBackoff.Duration, acquireBackoff.Cap = time.Millisecond, time.Millisecond
+	capacity := NewFakeCapacity(map[string]int{"rtype": 1})
+	holder := NewFakeClientWithCapacity("holder", capacity, time.Minute, nil)
+	if _, err := holder.AcquireAll([]Request{{ResourceType: "rtype", Count: 1}}, context.Background(), nil, nil); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	waiter := NewFakeClientWithCapacity("waiter", capacity, 10*time.Millisecond, nil)
+	for i := 0; i < minContendedSamples; i++ {
+		if _, err := waiter.AcquireAll([]Request{{ResourceType: "rtype", Count: 1}}, context.Background(), nil, nil); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected the acquisition to time out, got %v", err)
+		}
+	}
+	predictions, err := waiter.Predict([]Request{{ResourceType: "rtype", Count: 1}})
+	if err != nil {
+		t.Fatalf("failed to predict: %v", err)
+	}
+	if p := predictions[0]; !p.ExceedsTimeout || !p.Waits.ContendedP50Exceeded || p.Waits.TimedOutSamples != minContendedSamples {
+		t.Errorf("expected the acquisitions that timed out to predict another time out, got %+v", p)
+	}
+}
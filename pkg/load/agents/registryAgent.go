@@ -21,6 +21,9 @@ type RegistryAgent interface {
 	ResolveConfig(config api.ReleaseBuildConfiguration) (api.ReleaseBuildConfiguration, error)
 	GetRegistryComponents() (registry.ReferenceByName, registry.ChainByName, registry.WorkflowByName, map[string]string, api.RegistryMetadata)
 	GetGeneration() int
+	// GetGraph returns the graph of registry elements, which relates each of
+	// them to the elements using it
+	GetGraph() registry.NodeByName
 	registry.Resolver
 }
 
@@ -36,6 +39,7 @@ type registryAgent struct {
 	workflows     registry.WorkflowByName
 	documentation map[string]string
 	metadata      api.RegistryMetadata
+	graph         registry.NodeByName
 }
 
 var registryReloadTimeMetric = prometheus.NewHistogram(
@@ -138,6 +142,12 @@ func (a *registryAgent) GetRegistryComponents() (registry.ReferenceByName, regis
 	return a.references, a.chains, a.workflows, a.documentation, a.metadata
 }
 
+func (a *registryAgent) GetGraph() registry.NodeByName {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.graph
+}
+
 func (a *registryAgent) loadRegistry() error {
 	logrus.Debug("Reloading registry")
 	duration, err := func() (time.Duration, error) {
@@ -154,6 +164,12 @@ func (a *registryAgent) loadRegistry() error {
 		a.workflows = workflows
 		a.documentation = documentation
 		a.metadata = metadata
+		graph, err := registry.NewGraph(references, chains, workflows, observers)
+		if err != nil {
+			recordErrorForMetric(a.errorMetrics, "failed to build registry graph")
+			return time.Duration(0), fmt.Errorf("failed to build registry graph: %w", err)
+		}
+		a.graph = graph
 		a.resolver = registry.NewResolver(references, chains, workflows, observers)
 		a.generation++
 		return time.Since(startTime), nil
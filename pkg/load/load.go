@@ -92,7 +92,7 @@ func Registry(root string, flags RegistryFlag) (registry.ReferenceByName, regist
 			if err != nil {
 				return fmt.Errorf("failed to load registry file %s: %w", path, err)
 			}
-			if !flat && name != prefix {
+			if base, _ := registry.SplitVersion(name); !flat && base != prefix {
 				return fmt.Errorf("name of reference in file %s should be %s", path, prefix)
 			}
 			if strings.TrimSuffix(filepath.Base(path), RefSuffix) != name {
@@ -108,7 +108,7 @@ func Registry(root string, flags RegistryFlag) (registry.ReferenceByName, regist
 			if err != nil {
 				return fmt.Errorf("failed to load registry file %s: %w", path, err)
 			}
-			if !flat && chain.Chain.As != prefix {
+			if base, _ := registry.SplitVersion(chain.Chain.As); !flat && base != prefix {
 				return fmt.Errorf("name of chain in file %s should be %s", path, prefix)
 			}
 			if strings.TrimSuffix(filepath.Base(path), ChainSuffix) != chain.Chain.As {
@@ -124,7 +124,7 @@ func Registry(root string, flags RegistryFlag) (registry.ReferenceByName, regist
 			if err != nil {
 				return fmt.Errorf("failed to load registry file %s: %w", path, err)
 			}
-			if !flat && name != prefix {
+			if base, _ := registry.SplitVersion(name); !flat && base != prefix {
 				return fmt.Errorf("name of workflow in file %s should be %s", path, prefix)
 			}
 			if strings.TrimSuffix(filepath.Base(path), WorkflowSuffix) != name {
@@ -191,7 +191,7 @@ func Registry(root string, flags RegistryFlag) (registry.ReferenceByName, regist
 	if err != nil {
 		return nil, nil, nil, nil, nil, nil, err
 	}
-	// validate the integrity of each reference
+	// validate the integrity of each reference and chain
 	v := validation.NewValidator(nil)
 	var validationErrors []error
 	for _, r := range references {
@@ -199,6 +199,11 @@ func Registry(root string, flags RegistryFlag) (registry.ReferenceByName, regist
 			validationErrors = append(validationErrors, err...)
 		}
 	}
+	for _, c := range chains {
+		if err := v.IsValidChain(c); err != nil {
+			validationErrors = append(validationErrors, err...)
+		}
+	}
 	if len(validationErrors) > 0 {
 		return nil, nil, nil, nil, nil, nil, utilerrors.NewAggregate(validationErrors)
 	}
@@ -211,6 +216,10 @@ func loadReference(bytes []byte, baseDir, prefix string, flat bool) (string, str
 	if err != nil {
 		return "", "", api.LiteralTestStep{}, err
 	}
+	// versions of a reference are loaded side by side, each with its own commands
+	if _, version := registry.SplitVersion(step.Reference.As); version != "" {
+		prefix += registry.VersionSeparator + version
+	}
 	if !flat && step.Reference.Commands != fmt.Sprintf("%s%s%s", prefix, CommandsSuffix, filepath.Ext(step.Reference.Commands)) {
 		return "", "", api.LiteralTestStep{}, fmt.Errorf("reference %s has invalid command file path; command should be set to %s (with an optional extension like .sh)", step.Reference.As, fmt.Sprintf("%s%s", prefix, CommandsSuffix))
 	}
@@ -101,6 +101,8 @@ func TestRegistry(t *testing.T) {
 		}
 
 		deprovisionChain = `ipi-deprovision`
+		installV1Ref     = `ipi-install@v1`
+		installLatestRef = `ipi-install`
 
 		expectedWorkflows = registry.WorkflowByName{
 			"ipi": {
@@ -200,6 +202,37 @@ func TestRegistry(t *testing.T) {
 			chains:        nil,
 			workflows:     nil,
 			expectedError: true,
+		}, {
+			name:        "Read registry with versions side by side",
+			registryDir: "../../test/multistage-registry/versioned",
+			references: registry.ReferenceByName{
+				"ipi-install@v1": {
+					As:       "ipi-install@v1",
+					From:     "installer",
+					Commands: "openshift-install create cluster --v1\n",
+					Resources: api.ResourceRequirements{
+						Requests: api.ResourceList{"cpu": "1000m", "memory": "2Gi"},
+					},
+				},
+				"ipi-install@v2": {
+					As:       "ipi-install@v2",
+					From:     "installer",
+					Commands: "openshift-install create cluster --v2\n",
+					Resources: api.ResourceRequirements{
+						Requests: api.ResourceList{"cpu": "1000m", "memory": "2Gi"},
+					},
+				},
+			},
+			chains: registry.ChainByName{},
+			workflows: registry.WorkflowByName{
+				"ipi@v1": {Pre: []api.TestStep{{Reference: &installV1Ref}}},
+				"ipi@v2": {Pre: []api.TestStep{{Reference: &installLatestRef}}},
+			},
+			observers: registry.ObserverByName{},
+		}, {
+			name:          "Read registry with a ref both versioned and not",
+			registryDir:   "../../test/multistage-registry/versioned-conflict",
+			expectedError: true,
 		}}
 	)
 
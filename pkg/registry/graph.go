@@ -29,6 +29,10 @@ var nodeTypes = [4]string{
 type Node interface {
 	// Name returns the name of the registry element a Node refers to
 	Name() string
+	// Names returns all names users refer to the registry element by: its
+	// name and, for the latest version of a versioned element, its
+	// unversioned name
+	Names() []string
 	// Type returns the type of the registry element a Node refers to
 	Type() Type
 	// Ancestors returns a set of nodes containing the names of all of the node's ancestors
@@ -50,7 +54,8 @@ type NodeByName struct {
 }
 
 type nodeWithName struct {
-	name string
+	name    string
+	aliases []string
 }
 
 type nodeWithParents struct {
@@ -122,6 +127,18 @@ func (n *nodeWithName) Name() string {
 	return n.name
 }
 
+func (n *nodeWithName) Names() []string {
+	return append([]string{n.name}, n.aliases...)
+}
+
+// addVersionAliases lets the latest versions of versioned elements be referred
+// to by their unversioned names
+func addVersionAliases(latest map[string]string, nodes map[string]*nodeWithName) {
+	for base, name := range latest {
+		nodes[name].aliases = append(nodes[name].aliases, base)
+	}
+}
+
 func (*workflowNode) Type() Type {
 	return Workflow
 }
@@ -293,14 +310,22 @@ func NewGraph(stepsByName ReferenceByName, chainsByName ChainByName, workflowsBy
 	}
 	// References can only be children; load them so they can be added as children by workflows and chains
 	referenceNodes := make(referenceNodeByName, len(stepsByName))
+	referenceNames := make(map[string]*nodeWithName, len(stepsByName))
 	for name := range stepsByName {
 		node := &referenceNode{
 			nodeWithName:    newNodeWithName(name),
 			nodeWithParents: newNodeWithParents(),
 		}
 		referenceNodes[name] = node
+		referenceNames[name] = &node.nodeWithName
 		nodesByName.References[name] = node
 	}
+	latestReferences := latestVersions(stepsByName)
+	addVersionAliases(latestReferences, referenceNames)
+	// unversioned references to versioned elements refer to their latest version
+	for base, name := range latestReferences {
+		referenceNodes[base] = referenceNodes[name]
+	}
 	for name := range observersByName {
 		node := &observerNode{
 			nodeWithName: newNodeWithName(name),
@@ -311,6 +336,7 @@ func NewGraph(stepsByName ReferenceByName, chainsByName ChainByName, workflowsBy
 	// since we may load the parent chain before a child chain, we need to make the parent->child links after loading all chains
 	parentChildChain := make(map[*chainNode][]string)
 	chainNodes := make(chainNodeByName, len(chainsByName))
+	chainNames := make(map[string]*nodeWithName, len(chainsByName))
 	for name, chain := range chainsByName {
 		node := &chainNode{
 			nodeWithName:     newNodeWithName(name),
@@ -318,6 +344,7 @@ func NewGraph(stepsByName ReferenceByName, chainsByName ChainByName, workflowsBy
 			nodeWithParents:  newNodeWithParents(),
 		}
 		chainNodes[name] = node
+		chainNames[name] = &node.nodeWithName
 		nodesByName.Chains[name] = node
 		for _, step := range chain.Steps {
 			if step.Reference != nil {
@@ -331,6 +358,11 @@ func NewGraph(stepsByName ReferenceByName, chainsByName ChainByName, workflowsBy
 			}
 		}
 	}
+	latestChains := latestVersions(chainsByName)
+	addVersionAliases(latestChains, chainNames)
+	for base, name := range latestChains {
+		chainNodes[base] = chainNodes[name]
+	}
 	for parent, children := range parentChildChain {
 		for _, child := range children {
 			if _, exists := chainNodes[child]; !exists {
@@ -340,18 +372,21 @@ func NewGraph(stepsByName ReferenceByName, chainsByName ChainByName, workflowsBy
 		}
 	}
 	// verify that no cycles exist
-	for _, chain := range chainNodes {
+	for _, chain := range nodesByName.Chains {
+		chain := chain.(*chainNode)
 		if err := hasCycles(chain, sets.New[string](), []string{}); err != nil {
 			return nodesByName, err
 		}
 	}
 	workflowNodes := make(workflowNodeByName, len(workflowsByName))
+	workflowNames := make(map[string]*nodeWithName, len(workflowsByName))
 	for name, workflow := range workflowsByName {
 		node := &workflowNode{
 			nodeWithName:     newNodeWithName(name),
 			nodeWithChildren: newNodeWithChildren(),
 		}
 		workflowNodes[name] = node
+		workflowNames[name] = &node.nodeWithName
 		nodesByName.Workflows[name] = node
 		if workflow.Observers != nil {
 			for _, observer := range workflow.Observers.Enable {
@@ -385,5 +420,6 @@ func NewGraph(stepsByName ReferenceByName, chainsByName ChainByName, workflowsBy
 			}
 		}
 	}
+	addVersionAliases(latestVersions(workflowsByName), workflowNames)
 	return nodesByName, nil
 }
@@ -0,0 +1,373 @@
+// Package lint checks the commands of step registry references for problems
+// that would otherwise only surface when the step runs.
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// Severity determines whether a finding fails the lint
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Rule identifies the check a finding comes from
+type Rule string
+
+const (
+	// RuleSyntax reports commands bash cannot parse
+	RuleSyntax Rule = "syntax"
+	// RuleUndeclaredVariable reports variables which are neither declared by
+	// the step, provided by ci-operator or assigned in the commands
+	RuleUndeclaredVariable Rule = "undeclared-variable"
+	// RuleErrexit reports commands which do not exit on the first error
+	RuleErrexit Rule = "errexit"
+	// RuleWriteOutsideSharedDirs reports writes to files which are neither in
+	// SHARED_DIR nor in ARTIFACT_DIR and so are lost when the step ends
+	RuleWriteOutsideSharedDirs Rule = "write-outside-shared-dirs"
+)
+
+// Finding is a problem in the commands of a reference
+type Finding struct {
+	Reference string `json:"reference"`
+	// File is the path of the commands file, if known
+	File     string   `json:"file,omitempty"`
+	Line     uint     `json:"line,omitempty"`
+	Column   uint     `json:"column,omitempty"`
+	Rule     Rule     `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	location := f.Reference
+	if f.File != "" {
+		location = f.File
+	}
+	if f.Line != 0 {
+		location = fmt.Sprintf("%s:%d:%d", location, f.Line, f.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", location, f.Severity, f.Message, f.Rule)
+}
+
+// Report is the machine-readable output of the linter
+type Report struct {
+	Findings []Finding `json:"findings"`
+}
+
+// HasErrors determines whether any finding is an error
+func (r Report) HasErrors() bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ciOperatorEnv are the variables ci-operator sets in the container of every
+// step, in addition to those the step declares
+var ciOperatorEnv = sets.New[string](
+	"SHARED_DIR", "ARTIFACT_DIR", "CLUSTER_PROFILE_DIR", "CLUSTER_PROFILE_NAME", "CLUSTER_TYPE", api.CliEnv,
+	"KUBECONFIG", "KUBECONFIGMINIMAL", "KUBEADMIN_PASSWORD_FILE", api.DefaultLeaseEnv,
+	"NAMESPACE", "JOB_NAME", "JOB_NAME_SAFE", "JOB_NAME_HASH", "JOB_SPEC", "JOB_TYPE", "UNIQUE_HASH", "BUILD_ID", "PROW_JOB_ID",
+	"REPO_OWNER", "REPO_NAME", "PULL_BASE_REF", "PULL_BASE_SHA", "PULL_REFS", "PULL_NUMBER", "PULL_PULL_SHA", "PULL_HEAD_REF",
+	"OPENSHIFT_CI", "IMAGE_FORMAT",
+)
+
+// ciOperatorEnvPrefixes are prefixes of variables ci-operator sets depending
+// on the test, like the pull specs of the releases
+var ciOperatorEnvPrefixes = []string{"RELEASE_IMAGE_", "ORIGINAL_RELEASE_IMAGE_"}
+
+// shellEnv are the variables set by bash or the container runtime
+var shellEnv = sets.New[string](
+	"HOME", "PATH", "PWD", "OLDPWD", "HOSTNAME", "USER", "UID", "EUID", "PPID", "SHELL", "SHLVL", "TMPDIR", "TERM", "LANG", "LC_ALL",
+	"RANDOM", "SECONDS", "LINENO", "IFS", "OPTARG", "OPTIND", "REPLY", "PIPESTATUS", "FUNCNAME", "GROUPS", "BASHPID", "EPOCHSECONDS", "EPOCHREALTIME",
+	"BASH", "BASH_SOURCE", "BASH_LINENO", "BASH_REMATCH", "BASH_VERSION", "BASH_COMMAND", "PS1", "PS4", "COLUMNS",
+)
+
+// specialParameter matches positional and special parameters like $1 or $?
+var specialParameter = regexp.MustCompile(`^([0-9]+|[@*#?$!-])$`)
+
+// sharedDirs are the only directories files written by a step outlive it in
+var sharedDirs = sets.New[string]("SHARED_DIR", "ARTIFACT_DIR")
+
+// readOnlyDirs are directories provided by ci-operator steps cannot write to
+var readOnlyDirs = sets.New[string]("CLUSTER_PROFILE_DIR", api.CliEnv)
+
+// Registry lints the commands of every reference, ordered by name
+func Registry(references registry.ReferenceByName) []Finding {
+	names := make([]string, 0, len(references))
+	for name := range references {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	findings := []Finding{}
+	for _, name := range names {
+		findings = append(findings, Reference(name, references[name])...)
+	}
+	return findings
+}
+
+// Reference lints the commands of a reference
+func Reference(name string, step api.LiteralTestStep) []Finding {
+	finding := func(pos syntax.Pos, rule Rule, severity Severity, format string, args ...interface{}) Finding {
+		return Finding{Reference: name, Line: pos.Line(), Column: pos.Col(), Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)}
+	}
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(step.Commands), name)
+	if err != nil {
+		var parseErr syntax.ParseError
+		if errors.As(err, &parseErr) {
+			return []Finding{finding(parseErr.Pos, RuleSyntax, SeverityError, "%s", parseErr.Text)}
+		}
+		return []Finding{{Reference: name, Rule: RuleSyntax, Severity: SeverityError, Message: err.Error()}}
+	}
+	var findings []Finding
+	if !setsErrexit(step.Commands, file) {
+		findings = append(findings, Finding{Reference: name, Rule: RuleErrexit, Severity: SeverityWarning, Message: "commands do not exit on errors, add `set -o errexit`"})
+	}
+
+	declared := declaredVariables(step, file)
+	// variables set by sourced files or eval cannot be known
+	severity := SeverityError
+	if sourcesFiles(file) {
+		severity = SeverityWarning
+	}
+	reported := sets.New[string]()
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.ParamExp:
+			if n.Param == nil || n.Excl || handlesUnset(n) {
+				return true
+			}
+			variable := n.Param.Value
+			if specialParameter.MatchString(variable) || declared.Has(variable) || reported.Has(variable) || providedByCIOperator(variable) {
+				return true
+			}
+			reported.Insert(variable)
+			findings = append(findings, finding(n.Pos(), RuleUndeclaredVariable, severity, "variable %s is not declared in the `env`, `dependencies` or `leases` of the step, set by ci-operator or assigned in the commands", variable))
+		case *syntax.Redirect:
+			switch n.Op {
+			case syntax.RdrOut, syntax.AppOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll:
+				if msg := checkWriteTarget(n.Word); msg != "" {
+					findings = append(findings, finding(n.Word.Pos(), RuleWriteOutsideSharedDirs, SeverityWarning, "%s", msg))
+				}
+			}
+		case *syntax.CallExpr:
+			for _, target := range writtenFiles(n) {
+				if msg := checkWriteTarget(target); msg != "" {
+					findings = append(findings, finding(target.Pos(), RuleWriteOutsideSharedDirs, SeverityWarning, "%s", msg))
+				}
+			}
+		}
+		return true
+	})
+	return findings
+}
+
+func providedByCIOperator(variable string) bool {
+	if ciOperatorEnv.Has(variable) || shellEnv.Has(variable) {
+		return true
+	}
+	for _, prefix := range ciOperatorEnvPrefixes {
+		if strings.HasPrefix(variable, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// handlesUnset determines whether an expansion provides for the variable not
+// being set, like `${VAR:-default}`
+func handlesUnset(exp *syntax.ParamExp) bool {
+	if exp.Exp == nil {
+		return false
+	}
+	switch exp.Exp.Op {
+	case syntax.AlternateUnset, syntax.AlternateUnsetOrNull, syntax.DefaultUnset, syntax.DefaultUnsetOrNull,
+		syntax.ErrorUnset, syntax.ErrorUnsetOrNull, syntax.AssignUnset, syntax.AssignUnsetOrNull:
+		return true
+	}
+	return false
+}
+
+// declaredVariables returns the variables the step declares and those the
+// commands assign anywhere, as functions may use variables assigned later
+func declaredVariables(step api.LiteralTestStep, file *syntax.File) sets.Set[string] {
+	declared := sets.New[string]()
+	for _, e := range step.Environment {
+		declared.Insert(e.Name)
+	}
+	for _, d := range step.Dependencies {
+		declared.Insert(d.Env)
+	}
+	for _, l := range step.Leases {
+		declared.Insert(l.Env)
+	}
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.Assign:
+			if n.Name != nil {
+				declared.Insert(n.Name.Value)
+			}
+		case *syntax.WordIter:
+			declared.Insert(n.Name.Value)
+		case *syntax.CallExpr:
+			declared.Insert(assignedByCommand(n)...)
+		}
+		return true
+	})
+	return declared
+}
+
+// assignedByCommand returns the variables builtins like `read` assign
+func assignedByCommand(call *syntax.CallExpr) []string {
+	if len(call.Args) == 0 {
+		return nil
+	}
+	var names []string
+	switch call.Args[0].Lit() {
+	case "read", "mapfile", "readarray", "getopts":
+		for _, arg := range call.Args[1:] {
+			if lit := arg.Lit(); lit != "" && !strings.HasPrefix(lit, "-") {
+				names = append(names, lit)
+			}
+		}
+	case "printf":
+		for i := 1; i < len(call.Args)-1; i++ {
+			if call.Args[i].Lit() == "-v" {
+				names = append(names, call.Args[i+1].Lit())
+			}
+		}
+	}
+	return names
+}
+
+// sourcesFiles determines whether the commands source files or eval code,
+// either of which can set variables
+func sourcesFiles(file *syntax.File) bool {
+	var sources bool
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if call, ok := node.(*syntax.CallExpr); ok && len(call.Args) > 0 {
+			switch call.Args[0].Lit() {
+			case "source", ".", "eval":
+				sources = true
+			}
+		}
+		return !sources
+	})
+	return sources
+}
+
+// setsErrexit determines whether the commands exit on errors, either through
+// `set` or the options of the interpreter
+func setsErrexit(commands string, file *syntax.File) bool {
+	if shebang, _, _ := strings.Cut(commands, "\n"); strings.HasPrefix(shebang, "#!") {
+		for _, field := range strings.Fields(shebang)[1:] {
+			if isErrexitFlag(field) {
+				return true
+			}
+		}
+	}
+	var errexit bool
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 || call.Args[0].Lit() != "set" {
+			return !errexit
+		}
+		for i, arg := range call.Args[1:] {
+			if isErrexitFlag(arg.Lit()) || (arg.Lit() == "-o" && i+2 < len(call.Args) && call.Args[i+2].Lit() == "errexit") {
+				errexit = true
+			}
+		}
+		return !errexit
+	})
+	return errexit
+}
+
+func isErrexitFlag(flag string) bool {
+	if !strings.HasPrefix(flag, "-") || strings.HasPrefix(flag, "--") {
+		return false
+	}
+	// options taking a value, like `-o`, end the flag
+	flag, _, _ = strings.Cut(strings.TrimPrefix(flag, "-"), "o")
+	return strings.Contains(flag, "e")
+}
+
+// writtenFiles returns the files commands like `tee` or `cp` write to
+func writtenFiles(call *syntax.CallExpr) []*syntax.Word {
+	if len(call.Args) == 0 {
+		return nil
+	}
+	var operands []*syntax.Word
+	for _, arg := range call.Args[1:] {
+		if !strings.HasPrefix(arg.Lit(), "-") {
+			operands = append(operands, arg)
+		}
+	}
+	switch call.Args[0].Lit() {
+	case "tee":
+		return operands
+	case "cp", "mv":
+		if len(operands) > 1 {
+			return operands[len(operands)-1:]
+		}
+	}
+	return nil
+}
+
+// checkWriteTarget describes the problem with writing to a file, if any.
+// Files named by variables the commands set are not checked, as their value
+// cannot be known.
+func checkWriteTarget(word *syntax.Word) string {
+	if word == nil || len(word.Parts) == 0 {
+		return ""
+	}
+	first := word.Parts[0]
+	if quoted, ok := first.(*syntax.DblQuoted); ok {
+		if len(quoted.Parts) == 0 {
+			return ""
+		}
+		first = quoted.Parts[0]
+	}
+	target := printWord(word)
+	switch part := first.(type) {
+	case *syntax.Lit:
+		if strings.HasPrefix(part.Value, "/dev/") || strings.HasPrefix(part.Value, "/proc/self/fd/") {
+			return ""
+		}
+		return fmt.Sprintf("writes to %s, which is not in SHARED_DIR or ARTIFACT_DIR and is lost when the step ends", target)
+	case *syntax.SglQuoted:
+		return fmt.Sprintf("writes to %s, which is not in SHARED_DIR or ARTIFACT_DIR and is lost when the step ends", target)
+	case *syntax.ParamExp:
+		if part.Param == nil || sharedDirs.Has(part.Param.Value) {
+			return ""
+		}
+		if readOnlyDirs.Has(part.Param.Value) {
+			return fmt.Sprintf("writes to %s, which is in the read-only %s", target, part.Param.Value)
+		}
+	}
+	return ""
+}
+
+func printWord(word *syntax.Word) string {
+	var b strings.Builder
+	if err := syntax.NewPrinter().Print(&b, word); err != nil {
+		return word.Lit()
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+func TestReference(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		step     api.LiteralTestStep
+		expected []Finding
+	}{{
+		name: "clean step",
+		step: api.LiteralTestStep{
+			Commands: `#!/bin/bash
+set -euo pipefail
+echo "${TEST_PARAMETER}" > "${SHARED_DIR}/parameter"
+oc adm release info "${RELEASE_IMAGE_LATEST}" >> "${ARTIFACT_DIR}/release.txt" 2>/dev/null
+for file in "${CLUSTER_PROFILE_DIR}"/*; do
+	cp "${file}" "${SHARED_DIR}"
+done
+read -r first rest <<< "${LEASED_RESOURCE}"
+echo "${first}" "${rest}" "$1" "$?" "${OPTIONAL:-}"
+`,
+			Environment: []api.StepParameter{{Name: "TEST_PARAMETER"}},
+		},
+	}, {
+		name: "syntax error",
+		step: api.LiteralTestStep{Commands: "set -o errexit\nif true; then\n  echo\n"},
+		expected: []Finding{
+			{Reference: "step", Line: 2, Column: 1, Rule: RuleSyntax, Severity: SeverityError, Message: `if statement must end with "fi"`},
+		},
+	}, {
+		name: "missing errexit",
+		step: api.LiteralTestStep{Commands: "#!/bin/bash\nset -o nounset\necho\n"},
+		expected: []Finding{
+			{Reference: "step", Rule: RuleErrexit, Severity: SeverityWarning, Message: "commands do not exit on errors, add `set -o errexit`"},
+		},
+	}, {
+		name: "errexit in the shebang",
+		step: api.LiteralTestStep{Commands: "#!/bin/bash -ex\necho\n"},
+	}, {
+		name: "undeclared variables are reported once",
+		step: api.LiteralTestStep{
+			Commands:     "set -e\necho \"${FIPS_ENABLED}\" \"${DEP}\" \"$FIPS_ENABLED\"\n",
+			Dependencies: []api.StepDependency{{Name: "src", Env: "DEP"}},
+		},
+		expected: []Finding{
+			{Reference: "step", Line: 2, Column: 7, Rule: RuleUndeclaredVariable, Severity: SeverityError, Message: "variable FIPS_ENABLED is not declared in the `env`, `dependencies` or `leases` of the step, set by ci-operator or assigned in the commands"},
+		},
+	}, {
+		name: "undeclared variables are warnings when sourcing files",
+		step: api.LiteralTestStep{Commands: "set -e\nsource \"${SHARED_DIR}/env\"\necho \"${FROM_SHARED_DIR}\"\n"},
+		expected: []Finding{
+			{Reference: "step", Line: 3, Column: 7, Rule: RuleUndeclaredVariable, Severity: SeverityWarning, Message: "variable FROM_SHARED_DIR is not declared in the `env`, `dependencies` or `leases` of the step, set by ci-operator or assigned in the commands"},
+		},
+	}, {
+		name: "variables assigned in the commands",
+		step: api.LiteralTestStep{Commands: `set -e
+function f() { echo "${later}" "${local_var}" "${formatted}"; }
+later=1
+export EXPORTED="${later}"
+f() { local local_var; printf -v formatted '%s' "${EXPORTED}"; }
+`},
+	}, {
+		name: "writes outside of the shared directories",
+		step: api.LiteralTestStep{Commands: `set -e
+echo > /tmp/out
+echo >> 'relative'
+echo | tee "${SHARED_DIR}/ok" /etc/config
+cp -r "${SHARED_DIR}/in" "${CLUSTER_PROFILE_DIR}/out"
+tmp="$(mktemp)"
+echo > "${tmp}"
+`},
+		expected: []Finding{
+			{Reference: "step", Line: 2, Column: 8, Rule: RuleWriteOutsideSharedDirs, Severity: SeverityWarning, Message: "writes to /tmp/out, which is not in SHARED_DIR or ARTIFACT_DIR and is lost when the step ends"},
+			{Reference: "step", Line: 3, Column: 9, Rule: RuleWriteOutsideSharedDirs, Severity: SeverityWarning, Message: "writes to 'relative', which is not in SHARED_DIR or ARTIFACT_DIR and is lost when the step ends"},
+			{Reference: "step", Line: 4, Column: 31, Rule: RuleWriteOutsideSharedDirs, Severity: SeverityWarning, Message: "writes to /etc/config, which is not in SHARED_DIR or ARTIFACT_DIR and is lost when the step ends"},
+			{Reference: "step", Line: 5, Column: 26, Rule: RuleWriteOutsideSharedDirs, Severity: SeverityWarning, Message: `writes to "${CLUSTER_PROFILE_DIR}/out", which is in the read-only CLUSTER_PROFILE_DIR`},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Reference("step", tc.step)); diff != "" {
+				t.Errorf("unexpected findings: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	references := registry.ReferenceByName{
+		"b": {Commands: "echo"},
+		"a": {Commands: "set -e"},
+		"c": {Commands: "echo"},
+	}
+	var names []string
+	for _, finding := range Registry(references) {
+		names = append(names, finding.Reference)
+	}
+	if diff := cmp.Diff([]string{"b", "c"}, names); diff != "" {
+		t.Errorf("unexpected findings: %s", diff)
+	}
+	if findings := Registry(registry.ReferenceByName{}); findings == nil {
+		t.Error("expected an empty list of findings to be serialized as such")
+	}
+}
+
+func TestReportHasErrors(t *testing.T) {
+	if (Report{Findings: []Finding{{Severity: SeverityWarning}}}).HasErrors() {
+		t.Error("expected warnings not to be errors")
+	}
+	if !(Report{Findings: []Finding{{Severity: SeverityWarning}, {Severity: SeverityError}}}).HasErrors() {
+		t.Error("expected the report to have errors")
+	}
+}
@@ -0,0 +1,314 @@
+// Package local runs a single step of the step registry on the local machine,
+// with the environment and mounts ci-operator would give to its Pod, so that
+// step authors can iterate on a step without waiting for a rehearsal.
+package local
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	coreapi "k8s.io/api/core/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/steps/multi_stage"
+)
+
+// Runtime is how the commands of a step are executed
+type Runtime string
+
+const (
+	// RuntimeProcess runs the commands in a subprocess on the host
+	RuntimeProcess Runtime = "process"
+	// RuntimePodman runs the commands in a container with podman
+	RuntimePodman Runtime = "podman"
+	// RuntimeDocker runs the commands in a container with docker
+	RuntimeDocker Runtime = "docker"
+)
+
+const (
+	// Namespace is the value of NAMESPACE for steps executed locally
+	Namespace = "ci-op-local"
+	// DefaultTestName is the name of the test the step runs in, unless
+	// another one is configured
+	DefaultTestName = "local"
+	// artifactMountPath is where the artifact directory is mounted in the
+	// Pod, see steps.addArtifacts
+	artifactMountPath = "/logs/artifacts"
+	// homeMountPath is where the home volume is mounted in the Pod
+	homeMountPath = "/alabama"
+	artifactEnv   = "ARTIFACT_DIR"
+)
+
+// Options configure the execution of a step
+type Options struct {
+	// Runtime executes the commands, defaulting to a subprocess
+	Runtime Runtime
+	// Image overrides the image of the step, which is required for steps which
+	// run in an image built by ci-operator
+	Image string
+	// Dependencies maps the environment variables of the dependencies of the
+	// step to pull specs
+	Dependencies map[string]string
+	// WorkDir holds the shared, artifact, home and credential directories
+	WorkDir string
+	// SharedDir seeds the shared directory with the files in it
+	SharedDir string
+	// Credentials holds `<namespace>/<name>` directories to mount in place of
+	// the credentials of the step, which are otherwise empty
+	Credentials string
+	// ClusterProfile is mounted as the cluster profile directory
+	ClusterProfile string
+	// ClusterProfileName is the name of the cluster profile, which is
+	// required with a cluster profile directory
+	ClusterProfileName api.ClusterProfile
+	// TestName is the name of the test the step runs in, defaulting to
+	// DefaultTestName
+	TestName string
+	// JobName is the name of the job the step runs in, defaulting to the name
+	// of the test
+	JobName string
+	// Parameters holds the values of the variables ci-operator exposes to
+	// the steps of a test, like RELEASE_IMAGE_LATEST for tests with a cluster
+	// profile
+	Parameters map[string]string
+}
+
+// Mount is a directory of the host exposed to the step
+type Mount struct {
+	Source      string
+	Destination string
+	ReadOnly    bool
+}
+
+// Execution is a step ready to be executed
+type Execution struct {
+	Step     api.LiteralTestStep
+	Runtime  Runtime
+	Image    string
+	Env      []coreapi.EnvVar
+	Mounts   []Mount
+	Commands []string
+	// Dir is the working directory of the commands for the process runtime
+	Dir string
+	// SharedDir is the directory on the host which the step writes its
+	// shared files to
+	SharedDir string
+}
+
+// Resolve loads a reference through the registry, setting its parameters to
+// the values in the environment
+func Resolve(resolver registry.Resolver, name string, env api.TestEnvironment, deps api.TestDependencies) (api.LiteralTestStep, error) {
+	config := api.MultiStageTestConfiguration{
+		Test:         []api.TestStep{{Reference: &name}},
+		Environment:  env,
+		Dependencies: deps,
+	}
+	resolved, err := resolver.Resolve(name, config)
+	if err != nil {
+		return api.LiteralTestStep{}, fmt.Errorf("failed to resolve reference %s: %w", name, err)
+	}
+	if len(resolved.Test) != 1 {
+		return api.LiteralTestStep{}, fmt.Errorf("reference %s resolved to %d steps", name, len(resolved.Test))
+	}
+	return resolved.Test[0], nil
+}
+
+// Prepare creates the directories of the step in the work directory and
+// determines the environment, mounts and commands of its execution
+func Prepare(step api.LiteralTestStep, o Options) (*Execution, error) {
+	runtime := o.Runtime
+	if runtime == "" {
+		runtime = RuntimeProcess
+	}
+	e := Execution{Step: step, Runtime: runtime}
+	if runtime != RuntimeProcess {
+		image, err := imageFor(step, o.Image)
+		if err != nil {
+			return nil, err
+		}
+		e.Image = image
+	}
+
+	dirs := map[string]string{}
+	for _, dir := range []string{"shared", "artifacts", "home"} {
+		path := filepath.Join(o.WorkDir, dir)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create %s directory: %w", dir, err)
+		}
+		dirs[dir] = path
+	}
+	if o.SharedDir != "" {
+		if err := copyDir(o.SharedDir, dirs["shared"]); err != nil {
+			return nil, fmt.Errorf("failed to seed the shared directory: %w", err)
+		}
+	}
+	e.SharedDir, e.Dir = dirs["shared"], dirs["home"]
+
+	// in a container, the directories are where the Pod mounts them, while a
+	// subprocess uses the directories on the host directly
+	path := func(source, destination string, readOnly bool) string {
+		if runtime == RuntimeProcess {
+			return source
+		}
+		e.Mounts = append(e.Mounts, Mount{Source: source, Destination: destination, ReadOnly: readOnly})
+		return destination
+	}
+	sharedDir := path(dirs["shared"], multi_stage.SecretMountPath, false)
+	artifactDir := path(dirs["artifacts"], artifactMountPath, false)
+	homeDir := path(dirs["home"], homeMountPath, false)
+
+	// the environment is built like the one of the Pod, see generatePods
+	testName, jobSpec := o.TestName, &api.JobSpec{}
+	if testName == "" {
+		testName = DefaultTestName
+	}
+	if jobSpec.Job = o.JobName; jobSpec.Job == "" {
+		jobSpec.Job = testName
+	}
+	e.Env = multi_stage.JobEnv(Namespace, testName, jobSpec)
+	if o.ClusterProfile != "" {
+		if o.ClusterProfileName == "" {
+			return nil, fmt.Errorf("the name of the cluster profile in %s is required", o.ClusterProfile)
+		}
+		var missing []string
+		for _, name := range multi_stage.EnvForProfile {
+			value, ok := o.Parameters[name]
+			if !ok {
+				missing = append(missing, name)
+				continue
+			}
+			e.Env = append(e.Env, coreapi.EnvVar{Name: name, Value: value})
+		}
+		if len(missing) != 0 {
+			return nil, fmt.Errorf("no value for %s, which ci-operator exposes to steps of tests with a cluster profile", strings.Join(missing, ", "))
+		}
+	}
+	e.Env = append(e.Env, multi_stage.ParameterEnv(step.Environment, nil)...)
+	var missing []string
+	for _, dependency := range step.Dependencies {
+		ref, ok := o.Dependencies[dependency.Env]
+		if !ok {
+			ref = dependency.PullSpec
+		}
+		if ref == "" {
+			missing = append(missing, dependency.Env)
+			continue
+		}
+		e.Env = append(e.Env, coreapi.EnvVar{Name: dependency.Env, Value: ref})
+	}
+	if len(missing) != 0 {
+		return nil, fmt.Errorf("no pull spec for the dependencies %s of step %s, which are imported by ci-operator", strings.Join(missing, ", "), step.As)
+	}
+	if step.NoKubeconfig == nil || !*step.NoKubeconfig {
+		e.Env = append(e.Env, multi_stage.KubeconfigEnv(sharedDir)...)
+	}
+	if o.ClusterProfile != "" {
+		e.Env = append(e.Env, multi_stage.ClusterProfileEnv(o.ClusterProfileName, path(o.ClusterProfile, multi_stage.ClusterProfileMountPath, true))...)
+	}
+	e.Env = append(e.Env, coreapi.EnvVar{Name: multi_stage.SecretMountEnv, Value: sharedDir}, coreapi.EnvVar{Name: artifactEnv, Value: artifactDir})
+	if runtime == RuntimeProcess {
+		e.Env = append(e.Env, coreapi.EnvVar{Name: "HOME", Value: homeDir})
+	}
+	for _, credential := range step.Credentials {
+		source := filepath.Join(o.Credentials, credential.Namespace, credential.Name)
+		if _, err := os.Stat(source); o.Credentials == "" || err != nil {
+			source = filepath.Join(o.WorkDir, "credentials", credential.Namespace, credential.Name)
+			if err := os.MkdirAll(source, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create credential directory: %w", err)
+			}
+		}
+		path(source, credential.MountPath, true)
+	}
+	e.Commands = []string{"/bin/bash", "-c", multi_stage.CommandPrefix + step.Commands}
+	return &e, nil
+}
+
+// Command returns the command which executes the step
+func (e *Execution) Command(ctx context.Context) *exec.Cmd {
+	if e.Runtime == RuntimeProcess {
+		cmd := exec.CommandContext(ctx, e.Commands[0], e.Commands[1:]...)
+		cmd.Dir = e.Dir
+		// nothing but the executables of the host is inherited, so that the
+		// step cannot depend on variables it does not declare or use the
+		// credentials of the user, like their kubeconfig
+		cmd.Env = []string{fmt.Sprintf("PATH=%s", os.Getenv("PATH"))}
+		for _, env := range e.Env {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", env.Name, env.Value))
+		}
+		return cmd
+	}
+	return exec.CommandContext(ctx, string(e.Runtime), e.containerArgs()...)
+}
+
+func (e *Execution) containerArgs() []string {
+	args := []string{"run", "--rm", "--workdir", homeMountPath, "--entrypoint", e.Commands[0]}
+	for _, mount := range e.Mounts {
+		volume := fmt.Sprintf("%s:%s", mount.Source, mount.Destination)
+		if mount.ReadOnly {
+			volume += ":ro"
+		}
+		args = append(args, "--volume", volume)
+	}
+	for _, env := range e.Env {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", env.Name, env.Value))
+	}
+	return append(append(args, e.Image), e.Commands[1:]...)
+}
+
+// imageFor determines the image to run a step in, which can only be pulled
+// from outside of a job when the step runs in a literal image stream tag
+func imageFor(step api.LiteralTestStep, override string) (string, error) {
+	if override != "" {
+		return override, nil
+	}
+	if step.FromImage != nil {
+		return fmt.Sprintf("%s/%s", api.ServiceDomainAPPCIRegistry, step.FromImage.ISTagName()), nil
+	}
+	return "", fmt.Errorf("step %s runs in %s, which is built by ci-operator: an image must be provided", step.As, step.From)
+}
+
+// copyDir copies the regular files in a directory to another, flattening
+// them as the shared directory is a secret and cannot have subdirectories
+func copyDir(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if err := copyFile(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode()&fs.ModePerm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,245 @@
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	utilpointer "k8s.io/utils/pointer"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+func TestResolve(t *testing.T) {
+	references := registry.ReferenceByName{
+		"install": {
+			As:           "install",
+			Commands:     "install",
+			Environment:  []api.StepParameter{{Name: "PARAM"}, {Name: "DEFAULTED", Default: utilpointer.String("default")}},
+			Dependencies: []api.StepDependency{{Name: "installer", Env: "INSTALLER"}},
+		},
+	}
+	resolver := registry.NewResolver(references, registry.ChainByName{}, registry.WorkflowByName{}, registry.ObserverByName{})
+	step, err := Resolve(resolver, "install", api.TestEnvironment{"PARAM": "value"}, api.TestDependencies{"INSTALLER": "pipeline:installer"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := api.LiteralTestStep{
+		As:           "install",
+		Commands:     "install",
+		Environment:  []api.StepParameter{{Name: "PARAM", Default: utilpointer.String("value")}, {Name: "DEFAULTED", Default: utilpointer.String("default")}},
+		Dependencies: []api.StepDependency{{Name: "pipeline:installer", Env: "INSTALLER"}},
+	}
+	if diff := cmp.Diff(expected, step); diff != "" {
+		t.Errorf("unexpected step: %s", diff)
+	}
+	if _, err := Resolve(resolver, "install", nil, nil); err == nil {
+		t.Error("expected an error for a parameter without a value")
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	step := api.LiteralTestStep{
+		As:           "install_cluster",
+		From:         "installer",
+		Commands:     "openshift-install",
+		Environment:  []api.StepParameter{{Name: "PARAM", Default: utilpointer.String("value")}},
+		Dependencies: []api.StepDependency{{Name: "release:latest", Env: "RELEASE"}},
+		Credentials:  []api.CredentialReference{{Namespace: "ns", Name: "provided", MountPath: "/var/run/provided"}, {Namespace: "ns", Name: "fake", MountPath: "/var/run/fake"}},
+		NoKubeconfig: utilpointer.Bool(true),
+	}
+	for _, tc := range []struct {
+		name        string
+		options     Options
+		expected    Execution
+		expectedErr string
+	}{{
+		name: "container",
+		options: Options{
+			Runtime:            RuntimePodman,
+			Image:              "quay.io/installer:latest",
+			Dependencies:       map[string]string{"RELEASE": "quay.io/release:latest"},
+			Credentials:        "CREDENTIALS",
+			ClusterProfile:     "PROFILE",
+			ClusterProfileName: api.ClusterProfileAWS,
+			TestName:           "e2e_aws",
+			JobName:            "pull-ci-org-repo-master-e2e",
+			Parameters:         map[string]string{"RELEASE_IMAGE_LATEST": "quay.io/release:latest", "IMAGE_FORMAT": "quay.io/ocp:${component}", "UNUSED": "unused"},
+		},
+		expected: Execution{
+			Runtime: RuntimePodman,
+			Image:   "quay.io/installer:latest",
+			Env: []coreapi.EnvVar{
+				{Name: "NAMESPACE", Value: "ci-op-local"},
+				{Name: "JOB_NAME_SAFE", Value: "e2e-aws"},
+				{Name: "JOB_NAME_HASH", Value: "c1311"},
+				{Name: "UNIQUE_HASH", Value: "c1311"},
+				{Name: "RELEASE_IMAGE_LATEST", Value: "quay.io/release:latest"},
+				{Name: "IMAGE_FORMAT", Value: "quay.io/ocp:${component}"},
+				{Name: "PARAM", Value: "value"},
+				{Name: "RELEASE", Value: "quay.io/release:latest"},
+				{Name: "CLUSTER_PROFILE_NAME", Value: "aws"},
+				{Name: "CLUSTER_TYPE", Value: "aws"},
+				{Name: "CLUSTER_PROFILE_DIR", Value: "/var/run/secrets/ci.openshift.io/cluster-profile"},
+				{Name: "SHARED_DIR", Value: "/var/run/secrets/ci.openshift.io/multi-stage"},
+				{Name: "ARTIFACT_DIR", Value: "/logs/artifacts"},
+			},
+			Mounts: []Mount{
+				{Source: "WORK/shared", Destination: "/var/run/secrets/ci.openshift.io/multi-stage"},
+				{Source: "WORK/artifacts", Destination: "/logs/artifacts"},
+				{Source: "WORK/home", Destination: "/alabama"},
+				{Source: "PROFILE", Destination: "/var/run/secrets/ci.openshift.io/cluster-profile", ReadOnly: true},
+				{Source: "CREDENTIALS/ns/provided", Destination: "/var/run/provided", ReadOnly: true},
+				{Source: "WORK/credentials/ns/fake", Destination: "/var/run/fake", ReadOnly: true},
+			},
+			Commands:  []string{"/bin/bash", "-c", "#!/bin/bash\nset -eu\nopenshift-install"},
+			Dir:       "WORK/home",
+			SharedDir: "WORK/shared",
+		},
+	}, {
+		name:    "process",
+		options: Options{Dependencies: map[string]string{"RELEASE": "quay.io/release:latest"}},
+		expected: Execution{
+			Runtime: RuntimeProcess,
+			Env: []coreapi.EnvVar{
+				{Name: "NAMESPACE", Value: "ci-op-local"},
+				{Name: "JOB_NAME_SAFE", Value: "local"},
+				{Name: "JOB_NAME_HASH", Value: "25bf8"},
+				{Name: "UNIQUE_HASH", Value: "25bf8"},
+				{Name: "PARAM", Value: "value"},
+				{Name: "RELEASE", Value: "quay.io/release:latest"},
+				{Name: "SHARED_DIR", Value: "WORK/shared"},
+				{Name: "ARTIFACT_DIR", Value: "WORK/artifacts"},
+				{Name: "HOME", Value: "WORK/home"},
+			},
+			Commands:  []string{"/bin/bash", "-c", "#!/bin/bash\nset -eu\nopenshift-install"},
+			Dir:       "WORK/home",
+			SharedDir: "WORK/shared",
+		},
+	}, {
+		name:        "image built by ci-operator",
+		options:     Options{Runtime: RuntimeDocker},
+		expectedErr: "step install_cluster runs in installer, which is built by ci-operator: an image must be provided",
+	}, {
+		name:        "cluster profile without a name",
+		options:     Options{Dependencies: map[string]string{"RELEASE": "quay.io/release:latest"}, ClusterProfile: "PROFILE"},
+		expectedErr: "the name of the cluster profile in PROFILE is required",
+	}, {
+		name:        "cluster profile without the parameters of its tests",
+		options:     Options{Dependencies: map[string]string{"RELEASE": "quay.io/release:latest"}, ClusterProfile: "PROFILE", ClusterProfileName: api.ClusterProfileAWS},
+		expectedErr: "no value for RELEASE_IMAGE_LATEST, IMAGE_FORMAT, which ci-operator exposes to steps of tests with a cluster profile",
+	}, {
+		name:        "dependency without a pull spec",
+		options:     Options{},
+		expectedErr: "no pull spec for the dependencies RELEASE of step install_cluster, which are imported by ci-operator",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			work, credentials := filepath.Join(tmp, "work"), filepath.Join(tmp, "credentials")
+			if err := os.MkdirAll(filepath.Join(credentials, "ns", "provided"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			tc.options.WorkDir = work
+			if tc.options.Credentials != "" {
+				tc.options.Credentials = credentials
+			}
+			execution, err := Prepare(step, tc.options)
+			if diff := cmp.Diff(tc.expectedErr, errString(err)); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			tc.expected.Step = step
+			replace := func(s string) string {
+				for placeholder, value := range map[string]string{"WORK": work, "CREDENTIALS": credentials} {
+					if len(s) >= len(placeholder) && s[:len(placeholder)] == placeholder {
+						return value + s[len(placeholder):]
+					}
+				}
+				return s
+			}
+			for i := range tc.expected.Env {
+				tc.expected.Env[i].Value = replace(tc.expected.Env[i].Value)
+			}
+			for i := range tc.expected.Mounts {
+				tc.expected.Mounts[i].Source = replace(tc.expected.Mounts[i].Source)
+			}
+			tc.expected.Dir, tc.expected.SharedDir = replace(tc.expected.Dir), replace(tc.expected.SharedDir)
+			if diff := cmp.Diff(&tc.expected, execution); diff != "" {
+				t.Errorf("unexpected execution: %s", diff)
+			}
+		})
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestContainerArgs(t *testing.T) {
+	e := Execution{
+		Runtime:  RuntimeDocker,
+		Image:    "image",
+		Env:      []coreapi.EnvVar{{Name: "SHARED_DIR", Value: "/shared"}},
+		Mounts:   []Mount{{Source: "/work/shared", Destination: "/shared"}, {Source: "/profile", Destination: "/profile", ReadOnly: true}},
+		Commands: []string{"/bin/bash", "-c", "echo"},
+	}
+	expected := []string{
+		"docker", "run", "--rm", "--workdir", "/alabama", "--entrypoint", "/bin/bash",
+		"--volume", "/work/shared:/shared", "--volume", "/profile:/profile:ro",
+		"--env", "SHARED_DIR=/shared",
+		"image", "-c", "echo",
+	}
+	if diff := cmp.Diff(expected, e.Command(context.Background()).Args); diff != "" {
+		t.Errorf("unexpected arguments: %s", diff)
+	}
+}
+
+func TestRunProcess(t *testing.T) {
+	tmp := t.TempDir()
+	seed := filepath.Join(tmp, "seed")
+	if err := os.MkdirAll(seed, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(seed, "input"), []byte("seeded"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the environment of the user is not exposed to the step
+	t.Setenv("KUBECONFIG", filepath.Join(tmp, "kubeconfig"))
+	t.Setenv("UNDECLARED", "value")
+	step := api.LiteralTestStep{
+		As:           "step",
+		Commands:     `cat "${SHARED_DIR}/input" > "${SHARED_DIR}/output"; echo "${PARAM}" > "${ARTIFACT_DIR}/param"; echo "${KUBECONFIG:-}${UNDECLARED:-}" > "${ARTIFACT_DIR}/host"`,
+		Environment:  []api.StepParameter{{Name: "PARAM", Default: utilpointer.String("value")}},
+		NoKubeconfig: utilpointer.Bool(true),
+	}
+	execution, err := Prepare(step, Options{WorkDir: filepath.Join(tmp, "work"), SharedDir: seed})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out, err := execution.Command(context.Background()).CombinedOutput(); err != nil {
+		t.Fatalf("failed to run the step: %v: %s", err, out)
+	}
+	for path, expected := range map[string]string{
+		filepath.Join(execution.SharedDir, "output"):     "seeded",
+		filepath.Join(tmp, "work", "artifacts", "param"): "value\n",
+		filepath.Join(tmp, "work", "artifacts", "host"):  "\n",
+	} {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read output: %v", err)
+		}
+		if diff := cmp.Diff(expected, string(raw)); diff != "" {
+			t.Errorf("unexpected content of %s: %s", path, diff)
+		}
+	}
+}
@@ -25,8 +25,11 @@ type ObserverByName map[string]api.Observer
 // A superset of this validation is performed later when actual test
 // configurations are resolved.
 func Validate(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName) error {
-	reg := registry{stepsByName, chainsByName, workflowsByName, observersByName}
+	reg := newRegistry(stepsByName, chainsByName, workflowsByName, observersByName)
 	var ret []error
+	ret = append(ret, validateVersions("reference", stepsByName)...)
+	ret = append(ret, validateVersions("chain", chainsByName)...)
+	ret = append(ret, validateVersions("workflow", workflowsByName)...)
 	for k := range chainsByName {
 		if _, err := reg.process([]api.TestStep{{Chain: &k}}, sets.New[string](), stackForChain()); err != nil {
 			ret = append(ret, err...)
@@ -39,7 +42,7 @@ func Validate(stepsByName ReferenceByName, chainsByName ChainByName, workflowsBy
 				ret = append(ret, err...)
 			}
 		}
-		ret = append(ret, stack.checkUnused(&stack.records[0], nil, &reg)...)
+		ret = append(ret, stack.checkUnused(&stack.records[0], nil, reg)...)
 	}
 	for _, v := range observersByName {
 		ret = append(ret, validation.Observer(v)...)
@@ -55,17 +58,48 @@ type registry struct {
 	chainsByName    ChainByName
 	workflowsByName WorkflowByName
 	observersByName ObserverByName
+	// latest* map the unversioned names of versioned elements to the name of
+	// their latest version
+	latestSteps     map[string]string
+	latestChains    map[string]string
+	latestWorkflows map[string]string
 }
 
 func NewResolver(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName) Resolver {
+	return newRegistry(stepsByName, chainsByName, workflowsByName, observersByName)
+}
+
+func newRegistry(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName) *registry {
 	return &registry{
 		stepsByName:     stepsByName,
 		chainsByName:    chainsByName,
 		workflowsByName: workflowsByName,
 		observersByName: observersByName,
+		latestSteps:     latestVersions(stepsByName),
+		latestChains:    latestVersions(chainsByName),
+		latestWorkflows: latestVersions(workflowsByName),
 	}
 }
 
+// step returns the reference with the name, which refers to the latest
+// version of a versioned reference when it is not pinned
+func (r *registry) step(name string) (api.LiteralTestStep, bool) {
+	step, ok := r.stepsByName[resolveVersion(r.latestSteps, name)]
+	return step, ok
+}
+
+// chain returns the chain with the name, see step
+func (r *registry) chain(name string) (api.RegistryChain, bool) {
+	chain, ok := r.chainsByName[resolveVersion(r.latestChains, name)]
+	return chain, ok
+}
+
+// workflow returns the workflow with the name, see step
+func (r *registry) workflow(name string) (api.MultiStageTestConfiguration, bool) {
+	workflow, ok := r.workflowsByName[resolveVersion(r.latestWorkflows, name)]
+	return workflow, ok
+}
+
 func (r *registry) Resolve(name string, config api.MultiStageTestConfiguration) (api.MultiStageTestConfigurationLiteral, error) {
 	var overridden [][]api.TestStep
 	if config.Workflow != nil {
@@ -80,7 +114,7 @@ func (r *registry) Resolve(name string, config api.MultiStageTestConfiguration)
 
 func (r *registry) mergeWorkflow(config *api.MultiStageTestConfiguration) ([][]api.TestStep, []error) {
 	var overridden [][]api.TestStep
-	workflow, ok := r.workflowsByName[*config.Workflow]
+	workflow, ok := r.workflow(*config.Workflow)
 	if !ok {
 		return nil, []error{fmt.Errorf("no workflow named %s", *config.Workflow)}
 	}
@@ -138,14 +172,17 @@ func (r *registry) resolveTest(
 		stack.push(stackRecordForTest("workflow/"+*config.Workflow, nil, nil, nil))
 	}
 	pre, errs := r.process(config.Pre, sets.New[string](), stack)
+	uniqueParallelGroups(pre)
 	expandedFlow.Pre = append(expandedFlow.Pre, pre...)
 	resolveErrors = append(resolveErrors, errs...)
 
 	test, errs := r.process(config.Test, sets.New[string](), stack)
+	uniqueParallelGroups(test)
 	expandedFlow.Test = append(expandedFlow.Test, test...)
 	resolveErrors = append(resolveErrors, errs...)
 
 	post, errs := r.process(config.Post, sets.New[string](), stack)
+	uniqueParallelGroups(post)
 	expandedFlow.Post = append(expandedFlow.Post, post...)
 	resolveErrors = append(resolveErrors, errs...)
 
@@ -170,7 +207,7 @@ func (r *registry) resolveTest(
 }
 
 func (r *registry) ResolveWorkflow(name string) (api.MultiStageTestConfigurationLiteral, error) {
-	workflow, ok := r.workflowsByName[name]
+	workflow, ok := r.workflow(name)
 	if !ok {
 		return api.MultiStageTestConfigurationLiteral{}, fmt.Errorf("no workflow named %s", name)
 	}
@@ -262,10 +299,21 @@ func mergeLeases(dst, src []api.StepLease) ([]api.StepLease, error) {
 }
 
 func (r *registry) process(steps []api.TestStep, seen sets.Set[string], stack stack) (ret []api.LiteralTestStep, errs []error) {
+	// group is the parallel group of the current run of adjacent steps which
+	// set `parallel`, named after its first element
+	var group string
 	for _, step := range steps {
+		first := len(ret)
 		if step.Chain != nil {
 			steps, err := r.processChain(*step.Chain, seen, stack)
 			errs = append(errs, err...)
+			if step.RunIfChanged != "" || step.SkipIfOnlyChanged != "" {
+				for i := range steps {
+					if steps[i].RunIfChanged == "" && steps[i].SkipIfOnlyChanged == "" {
+						steps[i].RunIfChanged, steps[i].SkipIfOnlyChanged = step.RunIfChanged, step.SkipIfOnlyChanged
+					}
+				}
+			}
 			ret = append(ret, steps...)
 		} else {
 			step, err := r.processStep(&step, seen, stack)
@@ -274,30 +322,86 @@ func (r *registry) process(steps []api.TestStep, seen sets.Set[string], stack st
 				ret = append(ret, step)
 			}
 		}
+		if !step.Parallel {
+			group = ""
+			continue
+		}
+		if group == "" {
+			group = testStepName(step)
+		}
+		for i := first; i < len(ret); i++ {
+			ret[i].ParallelGroup = group
+		}
 	}
 	return
 }
 
+// uniqueParallelGroups renames the groups of a phase which are named like an
+// earlier one, so that every run of adjacent parallel steps is a group of its
+// own even when a chain or a step name starts more than one run.
+func uniqueParallelGroups(steps []api.LiteralTestStep) {
+	seen := map[string]int{}
+	for i := 0; i < len(steps); {
+		group, end := steps[i].ParallelGroup, i+1
+		for group != "" && end < len(steps) && steps[end].ParallelGroup == group {
+			end++
+		}
+		if group != "" {
+			seen[group]++
+			if n := seen[group]; n > 1 {
+				for j := i; j < end; j++ {
+					steps[j].ParallelGroup = fmt.Sprintf("%s-%d", group, n)
+				}
+			}
+		}
+		i = end
+	}
+}
+
+// testStepName returns the name of the step or chain referenced by a test step
+func testStepName(step api.TestStep) string {
+	switch {
+	case step.Chain != nil:
+		name, _ := SplitVersion(*step.Chain)
+		return name
+	case step.Reference != nil:
+		name, _ := SplitVersion(*step.Reference)
+		return name
+	case step.LiteralTestStep != nil:
+		return step.As
+	}
+	return ""
+}
+
 func (r *registry) processChain(name string, seen sets.Set[string], stack stack) ([]api.LiteralTestStep, []error) {
-	chain, ok := r.chainsByName[name]
+	chain, ok := r.chain(name)
 	if !ok {
 		return nil, []error{stack.errorf("unknown step chain: %s", name)}
 	}
 	rec := stackRecordForStep("chain/"+name, chain.Environment, nil, nil)
+	rec.retry = chain.Retry
 	stack.push(rec)
 	defer stack.pop()
 	ret, err := r.process(chain.Steps, seen, stack)
 	err = append(err, stack.checkUnused(&rec, nil, r)...)
+	if chain.Parallel {
+		group, _ := SplitVersion(name)
+		for i := range ret {
+			ret[i].ParallelGroup = group
+		}
+	}
 	return ret, err
 }
 
 func (r *registry) processStep(step *api.TestStep, seen sets.Set[string], stack stack) (ret api.LiteralTestStep, err []error) {
 	if ref := step.Reference; ref != nil {
 		var ok bool
-		ret, ok = r.stepsByName[*ref]
+		ret, ok = r.step(*ref)
 		if !ok {
 			return api.LiteralTestStep{}, []error{stack.errorf("invalid step reference: %s", *ref)}
 		}
+		// the version is not part of the name of the step, which names its pod
+		ret.As, _ = SplitVersion(ret.As)
 	} else if step.LiteralTestStep != nil {
 		ret = *step.LiteralTestStep
 	} else {
@@ -306,6 +410,9 @@ func (r *registry) processStep(step *api.TestStep, seen sets.Set[string], stack
 	if seen.Has(ret.As) {
 		return api.LiteralTestStep{}, []error{stack.errorf("duplicate name: %s", ret.As)}
 	}
+	if step.RunIfChanged != "" || step.SkipIfOnlyChanged != "" {
+		ret.RunIfChanged, ret.SkipIfOnlyChanged = step.RunIfChanged, step.SkipIfOnlyChanged
+	}
 	seen.Insert(ret.As)
 	var errs []error
 	if ret.Leases != nil {
@@ -319,6 +426,11 @@ func (r *registry) processStep(step *api.TestStep, seen sets.Set[string], stack
 			} else if e.Default == nil && !stack.partial {
 				errs = append(errs, stack.errorf("step/%s: unresolved parameter: %s", ret.As, e.Name))
 			}
+			if e.Default != nil {
+				if err := validation.StepParameterValue(e, *e.Default); err != nil {
+					errs = append(errs, stack.errorf("step/%s: invalid parameter %s: %v", ret.As, e.Name, err))
+				}
+			}
 			env = append(env, e)
 		}
 		ret.Environment = env
@@ -337,6 +449,11 @@ func (r *registry) processStep(step *api.TestStep, seen sets.Set[string], stack
 	if ret.DNSConfig != nil {
 		ret.DNSConfig = stack.resolveDNS(ret.DNSConfig)
 	}
+	if ret.Retry != nil {
+		ret.Retry = ret.Retry.DeepCopy()
+	} else {
+		ret.Retry = stack.resolveRetry()
+	}
 	return ret, errs
 }
 
@@ -354,6 +471,11 @@ func (r *registry) processObservers(observerNames sets.Set[string], stack stack)
 				} else if e.Default == nil && !stack.partial {
 					errs = append(errs, stack.errorf("observer/%s: unresolved parameter: %s", observer.Name, e.Name))
 				}
+				if e.Default != nil {
+					if err := validation.StepParameterValue(e, *e.Default); err != nil {
+						errs = append(errs, stack.errorf("observer/%s: invalid parameter %s: %v", observer.Name, e.Name, err))
+					}
+				}
 				env = append(env, e)
 			}
 			observer.Environment = env
@@ -367,7 +489,7 @@ func (r *registry) processObservers(observerNames sets.Set[string], stack stack)
 func (r *registry) iterateSteps(s api.TestStep, f func(*api.LiteralTestStep)) error {
 	switch {
 	case s.Chain != nil:
-		c, ok := r.chainsByName[*s.Chain]
+		c, ok := r.chain(*s.Chain)
 		if !ok {
 			return fmt.Errorf("invalid reference: %s", *s.Reference)
 		}
@@ -377,7 +499,7 @@ func (r *registry) iterateSteps(s api.TestStep, f func(*api.LiteralTestStep)) er
 			}
 		}
 	case s.Reference != nil:
-		r, ok := r.stepsByName[*s.Reference]
+		r, ok := r.step(*s.Reference)
 		if !ok {
 			return fmt.Errorf("invalid reference: %s", *s.Reference)
 		}
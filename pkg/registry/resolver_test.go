@@ -1130,6 +1130,18 @@ func TestResolveParameters(t *testing.T) {
 			}},
 		},
 		err: errors.New("test/test: step/step: unresolved parameter: UNRESOLVED"),
+	}, {
+		name: "typed parameter set to an invalid value",
+		test: api.MultiStageTestConfiguration{
+			Test: []api.TestStep{{
+				LiteralTestStep: &api.LiteralTestStep{
+					As:          "step",
+					Environment: []api.StepParameter{{Name: "FIPS_ENABLED", Type: api.StepParameterTypeBool, Default: &defaultEmpty}},
+				},
+			}},
+			Environment: api.TestEnvironment{"FIPS_ENABLED": "ture"},
+		},
+		err: errors.New("test/test: step/step: invalid parameter FIPS_ENABLED: \"ture\" is not a bool, must be `true` or `false`"),
 	}, {
 		name: "unresolved workflow override is not an error",
 		test: api.MultiStageTestConfiguration{
@@ -1275,3 +1287,173 @@ func TestResolveLeasesCopy(t *testing.T) {
 	expected := []api.StepLease{{Count: 42}, {Count: 0}}
 	testhelper.Diff(t, "leases", leases, expected)
 }
+
+func TestResolveRetry(t *testing.T) {
+	own, inherited, inner, outer := "own", "inherited", "inner", "outer"
+	refs := ReferenceByName{
+		own:       {As: own, Retry: &api.RetryPolicy{MaxAttempts: 2}},
+		inherited: {As: inherited},
+	}
+	chains := ChainByName{
+		inner: {
+			Steps: []api.TestStep{{Reference: &own}, {Reference: &inherited}},
+			Retry: &api.RetryPolicy{MaxAttempts: 3, Reasons: []string{"pod_pending"}},
+		},
+		outer: {
+			Steps: []api.TestStep{
+				{Chain: &inner},
+				{LiteralTestStep: &api.LiteralTestStep{As: "literal"}},
+			},
+			Retry: &api.RetryPolicy{MaxAttempts: 4},
+		},
+	}
+	ret, err := NewResolver(refs, chains, nil, nil).Resolve("test", api.MultiStageTestConfiguration{
+		Test: []api.TestStep{
+			{Chain: &outer},
+			{LiteralTestStep: &api.LiteralTestStep{As: "no-retry"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var retries []*api.RetryPolicy
+	for _, step := range ret.Test {
+		retries = append(retries, step.Retry)
+	}
+	expected := []*api.RetryPolicy{
+		{MaxAttempts: 2},
+		{MaxAttempts: 3, Reasons: []string{"pod_pending"}},
+		{MaxAttempts: 4},
+		nil,
+	}
+	testhelper.Diff(t, "retry policies", retries, expected)
+	ret.Test[1].Retry.Reasons[0] = "changed"
+	if reason := chains[inner].Retry.Reasons[0]; reason != "pod_pending" {
+		t.Errorf("resolved policy shares memory with the chain, which now has reason %q", reason)
+	}
+}
+
+func TestResolveChangeConditions(t *testing.T) {
+	own, plain, chain := "own", "plain", "chain"
+	refs := ReferenceByName{
+		own:   {As: own, RunIfChanged: "^pkg/"},
+		plain: {As: plain},
+	}
+	chains := ChainByName{
+		chain: {Steps: []api.TestStep{{Reference: &own}, {Reference: &plain}}},
+	}
+	for _, tc := range []struct {
+		name     string
+		steps    []api.TestStep
+		expected [][2]string
+	}{{
+		name:     "conditions of the referenced step",
+		steps:    []api.TestStep{{Reference: &own}},
+		expected: [][2]string{{"^pkg/", ""}},
+	}, {
+		name:     "reference overrides the conditions of the step",
+		steps:    []api.TestStep{{Reference: &own, SkipIfOnlyChanged: `\.md$`}},
+		expected: [][2]string{{"", `\.md$`}},
+	}, {
+		name: "literal step",
+		steps: []api.TestStep{{
+			LiteralTestStep:   &api.LiteralTestStep{As: "literal"},
+			SkipIfOnlyChanged: `\.md$`,
+		}},
+		expected: [][2]string{{"", `\.md$`}},
+	}, {
+		name:     "chain applies to steps without conditions",
+		steps:    []api.TestStep{{Chain: &chain, RunIfChanged: "^docs/"}},
+		expected: [][2]string{{"^pkg/", ""}, {"^docs/", ""}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ret, err := NewResolver(refs, chains, nil, nil).Resolve("test", api.MultiStageTestConfiguration{Test: tc.steps})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var conditions [][2]string
+			for _, step := range ret.Test {
+				conditions = append(conditions, [2]string{step.RunIfChanged, step.SkipIfOnlyChanged})
+			}
+			testhelper.Diff(t, "conditions", conditions, tc.expected)
+		})
+	}
+}
+
+func TestResolveParallelGroups(t *testing.T) {
+	gather, audit, metrics, deprovision := "gather", "audit", "metrics", "deprovision"
+	serial, parallel := "serial", "parallel"
+	// a step named like the parallel chain
+	parallelStep := parallel
+	refs := ReferenceByName{
+		parallel:    {As: parallel},
+		gather:      {As: gather},
+		audit:       {As: audit},
+		metrics:     {As: metrics},
+		deprovision: {As: deprovision},
+	}
+	chains := ChainByName{
+		serial:   {Steps: []api.TestStep{{Reference: &gather}, {Reference: &audit}}},
+		parallel: {Steps: []api.TestStep{{Reference: &gather}, {Reference: &audit}}, Parallel: true},
+	}
+	for _, tc := range []struct {
+		name     string
+		steps    []api.TestStep
+		expected [][2]string
+	}{{
+		name:     "no grouping",
+		steps:    []api.TestStep{{Reference: &gather}, {Reference: &audit}},
+		expected: [][2]string{{gather, ""}, {audit, ""}},
+	}, {
+		name: "adjacent parallel steps form a group named after the first one",
+		steps: []api.TestStep{
+			{Reference: &gather, Parallel: true},
+			{LiteralTestStep: &api.LiteralTestStep{As: "literal"}, Parallel: true},
+			{Reference: &audit, Parallel: true},
+			{Reference: &deprovision},
+		},
+		expected: [][2]string{{gather, gather}, {"literal", gather}, {audit, gather}, {deprovision, ""}},
+	}, {
+		name: "a sequential step splits groups",
+		steps: []api.TestStep{
+			{Reference: &gather, Parallel: true},
+			{Reference: &audit},
+			{Reference: &metrics, Parallel: true},
+			{Reference: &deprovision, Parallel: true},
+		},
+		expected: [][2]string{{gather, gather}, {audit, ""}, {metrics, metrics}, {deprovision, metrics}},
+	}, {
+		name:     "parallel chain",
+		steps:    []api.TestStep{{Chain: &parallel}, {Reference: &deprovision}},
+		expected: [][2]string{{gather, parallel}, {audit, parallel}, {deprovision, ""}},
+	}, {
+		name:     "chain in a parallel group",
+		steps:    []api.TestStep{{Chain: &serial, Parallel: true}, {Reference: &metrics, Parallel: true}},
+		expected: [][2]string{{gather, serial}, {audit, serial}, {metrics, serial}},
+	}, {
+		name: "runs which start with the same name are separate groups",
+		steps: []api.TestStep{
+			{Chain: &parallel},
+			{Reference: &deprovision},
+			{Reference: &parallelStep, Parallel: true},
+			{Reference: &metrics, Parallel: true},
+		},
+		expected: [][2]string{{gather, parallel}, {audit, parallel}, {deprovision, ""}, {parallel, parallel + "-2"}, {metrics, parallel + "-2"}},
+	}, {
+		name:     "outer group overrides the group of a chain",
+		steps:    []api.TestStep{{Reference: &metrics, Parallel: true}, {Chain: &parallel, Parallel: true}},
+		expected: [][2]string{{metrics, metrics}, {gather, metrics}, {audit, metrics}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ret, err := NewResolver(refs, chains, nil, nil).Resolve("test", api.MultiStageTestConfiguration{Post: tc.steps})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var groups [][2]string
+			for _, step := range ret.Post {
+				groups = append(groups, [2]string{step.As, step.ParallelGroup})
+			}
+			testhelper.Diff(t, "groups", groups, tc.expected)
+		})
+	}
+}
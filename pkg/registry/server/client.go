@@ -7,19 +7,24 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/sirupsen/logrus"
 
-	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/yaml"
 
 	"github.com/openshift/ci-tools/pkg/api"
 )
 
+// ResolverClient loads resolved configurations from the versioned API of the
+// configresolver
 type ResolverClient interface {
 	Config(*api.Metadata) (*api.ReleaseBuildConfiguration, error)
 	ConfigWithTest(base *api.Metadata, testSource *api.MetadataWithTest) (*api.ReleaseBuildConfiguration, error)
+	// ConfigBatch resolves many configurations in one request, a failure to
+	// resolve any of them is reported in its result
+	ConfigBatch([]api.Metadata) ([]BatchResult, error)
 	Resolve([]byte) (*api.ReleaseBuildConfiguration, error)
 }
 
@@ -29,11 +34,36 @@ func NewResolverClient(address string) ResolverClient {
 
 type resolverClient struct {
 	Address string
+
+	// cache holds the last configuration served for each metadata, which is
+	// reused as long as the server responds that it is not modified
+	lock  sync.Mutex
+	cache map[string]cachedConfig
+}
+
+type cachedConfig struct {
+	etag string
+	raw  []byte
+}
+
+func (r *resolverClient) cached(key string) cachedConfig {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.cache[key]
+}
+
+func (r *resolverClient) store(key string, entry cachedConfig) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.cache == nil {
+		r.cache = map[string]cachedConfig{}
+	}
+	r.cache[key] = entry
 }
 
 func (r *resolverClient) Config(info *api.Metadata) (*api.ReleaseBuildConfiguration, error) {
 	logrus.Infof("Loading configuration from %s for %s", r.Address, info.AsString())
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/config", r.Address), nil)
+	req, err := http.NewRequest(http.MethodGet, r.Address+V1ConfigPath, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request for configresolver: %w", err)
 	}
@@ -45,37 +75,55 @@ func (r *resolverClient) Config(info *api.Metadata) (*api.ReleaseBuildConfigurat
 		query.Add(VariantQuery, info.Variant)
 	}
 	req.URL.RawQuery = query.Encode()
-	return configFromResolverRequest(req)
+	key := info.AsString()
+	cached := r.cached(key)
+	if cached.etag != "" {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+	resp, err := doResolverRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	raw := resp.body
+	if resp.notModified {
+		raw = cached.raw
+	} else if etag := resp.header.Get("ETag"); etag != "" {
+		r.store(key, cachedConfig{etag: etag, raw: raw})
+	}
+	return unmarshalConfig(raw)
 }
 
 func (r *resolverClient) ConfigWithTest(base *api.Metadata, testSource *api.MetadataWithTest) (*api.ReleaseBuildConfiguration, error) {
 	logrus.Infof("Loading configuration from %s for %s", r.Address, base.AsString())
-	endpoint := fmt.Sprintf("%s/mergeConfigsWithInjectedTest", r.Address)
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := newJSONRequest(r.Address+V1MergePath, MergeRequest{Configs: []api.Metadata{*base}, InjectTest: *testSource})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request for configresolver: %w", err)
+		return nil, err
 	}
-	query := req.URL.Query()
-	optional := sets.New[string](VariantQuery, InjectFromVariantQuery)
-	for k, v := range map[string]string{
-		InjectTestQuery:        testSource.Test,
-		InjectFromOrgQuery:     testSource.Org,
-		InjectFromRepoQuery:    testSource.Repo,
-		InjectFromBranchQuery:  testSource.Branch,
-		InjectFromVariantQuery: testSource.Variant,
-		OrgQuery:               base.Org,
-		RepoQuery:              base.Repo,
-		BranchQuery:            base.Branch,
-		VariantQuery:           base.Variant,
-	} {
-		if len(v) == 0 && !optional.Has(k) {
-			return nil, fmt.Errorf("param cannot be empty: %s", k)
-		}
-		query.Add(k, v)
+	resp, err := doResolverRequest(req)
+	if err != nil {
+		return nil, err
 	}
+	return unmarshalConfig(resp.body)
+}
 
-	req.URL.RawQuery = query.Encode()
-	return configFromResolverRequest(req)
+func (r *resolverClient) ConfigBatch(metadata []api.Metadata) ([]BatchResult, error) {
+	logrus.Infof("Loading %d configurations from %s", len(metadata), r.Address)
+	req, err := newJSONRequest(r.Address+V1BatchPath, BatchRequest{Configs: metadata})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := doResolverRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	var batch BatchResponse
+	if err := json.Unmarshal(resp.body, &batch); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal batch response from configresolver: %w", err)
+	}
+	if len(batch.Results) != len(metadata) {
+		return nil, fmt.Errorf("configresolver returned %d results for %d configurations", len(batch.Results), len(metadata))
+	}
+	return batch.Results, nil
 }
 
 func (r *resolverClient) Resolve(raw []byte) (*api.ReleaseBuildConfiguration, error) {
@@ -84,16 +132,28 @@ func (r *resolverClient) Resolve(raw []byte) (*api.ReleaseBuildConfiguration, er
 	if err := yaml.UnmarshalStrict(raw, unresolvedConfig); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal unresolved config: invalid configuration: %w, raw: %v", err, string(raw))
 	}
-	encoded, err := json.Marshal(unresolvedConfig)
+	req, err := newJSONRequest(r.Address+V1ResolvePath, unresolvedConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal unresolved config: invalid configuration: %w", err)
 	}
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/resolve", r.Address), bytes.NewReader(encoded))
+	resp, err := doResolverRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalConfig(resp.body)
+}
+
+func newJSONRequest(endpoint string, body interface{}) (*http.Request, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request for configresolver: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(encoded))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request for configresolver: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	return configFromResolverRequest(req)
+	return req, nil
 }
 
 type adapter struct{}
@@ -126,7 +186,13 @@ func (a adapter) Warn(s string, i ...interface{}) {
 
 var _ retryablehttp.LeveledLogger = adapter{}
 
-func configFromResolverRequest(req *http.Request) (*api.ReleaseBuildConfiguration, error) {
+type resolverResponse struct {
+	header      http.Header
+	body        []byte
+	notModified bool
+}
+
+func doResolverRequest(req *http.Request) (*resolverResponse, error) {
 	retryClient := retryablehttp.NewClient()
 	retryClient.RetryMax = 5
 	retryClient.Logger = adapter{}
@@ -137,22 +203,30 @@ func configFromResolverRequest(req *http.Request) (*api.ReleaseBuildConfiguratio
 		return nil, fmt.Errorf("failed to make request to configresolver: %w", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return &resolverResponse{header: resp.Header, notModified: true}, nil
+	}
+	data, err := io.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		var responseBody string
-		if data, err := io.ReadAll(resp.Body); err != nil {
+		if err != nil {
 			logrus.WithError(err).Warn("Failed to read response body from configresolver.")
-		} else {
-			responseBody = string(data)
 		}
-		return nil, fmt.Errorf("got unexpected http %d status code from configresolver: %s", resp.StatusCode, responseBody)
+		// older servers and proxies respond with plain text
+		apiErr := &APIError{}
+		if json.Unmarshal(data, apiErr) != nil || apiErr.Message == "" {
+			apiErr = &APIError{Message: string(data)}
+		}
+		return nil, fmt.Errorf("got unexpected http %d status code from configresolver: %w", resp.StatusCode, apiErr)
 	}
-	data, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read configresolver response body: %w", err)
 	}
+	return &resolverResponse{header: resp.Header, body: data}, nil
+}
+
+func unmarshalConfig(data []byte) (*api.ReleaseBuildConfiguration, error) {
 	configSpecHTTP := &api.ReleaseBuildConfiguration{}
-	err = json.Unmarshal(data, configSpecHTTP)
-	if err != nil {
+	if err := json.Unmarshal(data, configSpecHTTP); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config from configresolver: invalid configuration: %w\nvalue:\n%s", err, string(data))
 	}
 	return configSpecHTTP, nil
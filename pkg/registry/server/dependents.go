@@ -0,0 +1,152 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/metrics"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+const (
+	// V1DependentsPath serves the tests using a registry element, GET with
+	// the type and name of the element in the query
+	V1DependentsPath = V1Prefix + "/dependents"
+
+	TypeQuery = "type"
+	NameQuery = "name"
+)
+
+// DependentsIndexName is the name of the index of configurations by the
+// registry elements their tests refer to, see IndexConfigsByRegistryUsage
+const DependentsIndexName = "registry-usage"
+
+// IndexConfigsByRegistryUsage indexes configurations under the registry
+// elements their multi-stage tests refer to directly
+func IndexConfigsByRegistryUsage(config api.ReleaseBuildConfiguration) []string {
+	var keys []string
+	for _, test := range config.Tests {
+		keys = append(keys, registry.DirectUsages(test.MultiStageTestConfiguration)...)
+	}
+	return keys
+}
+
+// ConfigIndex looks up configurations in an index added to the config agent
+type ConfigIndex interface {
+	GetFromIndex(indexName string, indexKey string) ([]*api.ReleaseBuildConfiguration, error)
+}
+
+// Dependent is a test which runs a registry element
+type Dependent struct {
+	api.MetadataWithTest `json:",inline"`
+	// Via is the element the test refers to, which is either the element
+	// itself or one of its ancestors
+	Via RegistryElement `json:"via"`
+}
+
+// RegistryElement identifies a workflow, chain, reference or observer
+type RegistryElement struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// DependentsResponse lists all tests which run a registry element
+type DependentsResponse struct {
+	RegistryElement `json:",inline"`
+	Dependents      []Dependent `json:"dependents"`
+}
+
+// Dependents finds every test which runs the registry element: the tests that
+// refer to the element or to any of its ancestors in the graph. Tests are
+// only listed once, via the element itself if they refer to it directly.
+func Dependents(node registry.Node, configs ConfigIndex) ([]Dependent, error) {
+	ancestors := node.Ancestors()
+	sort.Slice(ancestors, func(i, j int) bool {
+		if ancestors[i].Type() != ancestors[j].Type() {
+			return ancestors[i].Type() < ancestors[j].Type()
+		}
+		return ancestors[i].Name() < ancestors[j].Name()
+	})
+	seen := map[api.MetadataWithTest]bool{}
+	var dependents []Dependent
+	for _, current := range append([]registry.Node{node}, ancestors...) {
+		var matching []*api.ReleaseBuildConfiguration
+		for _, name := range current.Names() {
+			configs, err := configs.GetFromIndex(DependentsIndexName, registry.UsageKey(current.Type(), name))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get configs using %s %s: %w", current.Type(), name, err)
+			}
+			matching = append(matching, configs...)
+		}
+		for _, config := range matching {
+			for _, test := range config.Tests {
+				if !registry.UsesNode(test.MultiStageTestConfiguration, current) {
+					continue
+				}
+				key := api.MetadataWithTest{Metadata: config.Metadata, Test: test.As}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				dependents = append(dependents, Dependent{
+					MetadataWithTest: key,
+					Via:              RegistryElement{Type: current.Type().String(), Name: current.Name()},
+				})
+			}
+		}
+	}
+	sort.Slice(dependents, func(i, j int) bool {
+		if a, b := dependents[i].Metadata.AsString(), dependents[j].Metadata.AsString(); a != b {
+			return a < b
+		}
+		return dependents[i].Test < dependents[j].Test
+	})
+	return dependents, nil
+}
+
+// GraphGetter is implemented by the registry agent
+type GraphGetter interface {
+	GetGraph() registry.NodeByName
+}
+
+// DependentsHandler serves the tests which run a registry element
+func DependentsHandler(graph GraphGetter, configs ConfigIndex, resolverMetrics *metrics.Metrics) http.HandlerFunc {
+	fail := func(w http.ResponseWriter, status int, apiErr *APIError) {
+		metrics.RecordError(apiErr.Code, resolverMetrics.ErrorRate)
+		logrus.WithField("code", apiErr.Code).Warning(apiErr.Message)
+		respond(w, status, apiErr)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			fail(w, http.StatusMethodNotAllowed, &APIError{Code: ErrorCodeMethodNotAllowed, Message: fmt.Sprintf("expected GET, got %s", r.Method)})
+			return
+		}
+		element := RegistryElement{Type: r.URL.Query().Get(TypeQuery), Name: r.URL.Query().Get(NameQuery)}
+		nodeType, err := registry.ParseType(element.Type)
+		if err != nil {
+			fail(w, http.StatusBadRequest, &APIError{Code: ErrorCodeInvalidRequest, Message: err.Error()})
+			return
+		}
+		if element.Name == "" {
+			fail(w, http.StatusBadRequest, &APIError{Code: ErrorCodeInvalidRequest, Message: fmt.Sprintf("%s is required", NameQuery)})
+			return
+		}
+		node, ok := graph.GetGraph().Lookup(nodeType, element.Name)
+		if !ok {
+			fail(w, http.StatusNotFound, &APIError{Code: ErrorCodeNotFound, Message: fmt.Sprintf("%s %s not found", element.Type, element.Name)})
+			return
+		}
+		dependents, err := Dependents(node, configs)
+		if err != nil {
+			fail(w, http.StatusInternalServerError, &APIError{Code: ErrorCodeInternal, Message: err.Error()})
+			return
+		}
+		respond(w, http.StatusOK, DependentsResponse{RegistryElement: element, Dependents: dependents})
+	}
+}
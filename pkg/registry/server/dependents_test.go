@@ -0,0 +1,198 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/test-infra/prow/metrics"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+type fakeConfigIndex map[string][]*api.ReleaseBuildConfiguration
+
+func newFakeConfigIndex(configs ...api.ReleaseBuildConfiguration) fakeConfigIndex {
+	index := fakeConfigIndex{}
+	for i := range configs {
+		for _, key := range IndexConfigsByRegistryUsage(configs[i]) {
+			index[key] = append(index[key], &configs[i])
+		}
+	}
+	return index
+}
+
+func (f fakeConfigIndex) GetFromIndex(indexName string, indexKey string) ([]*api.ReleaseBuildConfiguration, error) {
+	if indexName != DependentsIndexName {
+		return nil, fmt.Errorf("no index %s configured", indexName)
+	}
+	return f[indexKey], nil
+}
+
+type fakeGraphGetter registry.NodeByName
+
+func (f fakeGraphGetter) GetGraph() registry.NodeByName {
+	return registry.NodeByName(f)
+}
+
+func newTestGraph(t *testing.T) registry.NodeByName {
+	install, deprovision, setup, ipi := "install", "deprovision", "setup", "ipi"
+	graph, err := registry.NewGraph(
+		registry.ReferenceByName{install: {}, deprovision: {}, "unused": {}},
+		registry.ChainByName{setup: {Steps: []api.TestStep{{Reference: &install}}}},
+		registry.WorkflowByName{ipi: {
+			Observers: &api.Observers{Enable: []string{"observer"}},
+			Pre:       []api.TestStep{{Chain: &setup}},
+			Post:      []api.TestStep{{Reference: &deprovision}},
+		}},
+		registry.ObserverByName{"observer": {}},
+	)
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	return graph
+}
+
+func newTestConfigIndex() fakeConfigIndex {
+	ipi, setup, install := "ipi", "setup", "install"
+	return newFakeConfigIndex(
+		api.ReleaseBuildConfiguration{
+			Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"},
+			Tests: []api.TestStepConfiguration{
+				{As: "e2e", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: &ipi}},
+				{As: "e2e-install", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+					Workflow: &ipi,
+					Test:     []api.TestStep{{Reference: &install}},
+				}},
+				{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+			},
+		},
+		api.ReleaseBuildConfiguration{
+			Metadata: api.Metadata{Org: "org", Repo: "other", Branch: "main", Variant: "variant"},
+			Tests: []api.TestStepConfiguration{
+				{As: "setup", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Pre: []api.TestStep{{Chain: &setup}}}},
+			},
+		},
+	)
+}
+
+func TestDependents(t *testing.T) {
+	graph := newTestGraph(t)
+	configs := newTestConfigIndex()
+	e2e := api.MetadataWithTest{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"}, Test: "e2e"}
+	e2eInstall := api.MetadataWithTest{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"}, Test: "e2e-install"}
+	setup := api.MetadataWithTest{Metadata: api.Metadata{Org: "org", Repo: "other", Branch: "main", Variant: "variant"}, Test: "setup"}
+	for _, tc := range []struct {
+		name     string
+		nodeType registry.Type
+		node     string
+		expected []Dependent
+	}{
+		{
+			name:     "reference used directly and through a chain and a workflow",
+			nodeType: registry.Reference,
+			node:     "install",
+			expected: []Dependent{
+				{MetadataWithTest: setup, Via: RegistryElement{Type: "chain", Name: "setup"}},
+				{MetadataWithTest: e2e, Via: RegistryElement{Type: "workflow", Name: "ipi"}},
+				{MetadataWithTest: e2eInstall, Via: RegistryElement{Type: "reference", Name: "install"}},
+			},
+		},
+		{
+			name:     "observer enabled by a workflow",
+			nodeType: registry.Observer,
+			node:     "observer",
+			expected: []Dependent{
+				{MetadataWithTest: e2e, Via: RegistryElement{Type: "workflow", Name: "ipi"}},
+				{MetadataWithTest: e2eInstall, Via: RegistryElement{Type: "workflow", Name: "ipi"}},
+			},
+		},
+		{
+			name:     "unused reference",
+			nodeType: registry.Reference,
+			node:     "unused",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			node, ok := graph.Lookup(tc.nodeType, tc.node)
+			if !ok {
+				t.Fatalf("%s %s not found", tc.nodeType, tc.node)
+			}
+			dependents, err := Dependents(node, configs)
+			if err != nil {
+				t.Fatalf("failed to get dependents: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, dependents); diff != "" {
+				t.Errorf("unexpected dependents: %s", diff)
+			}
+		})
+	}
+}
+
+func TestDependentsHandler(t *testing.T) {
+	resolverMetrics := &metrics.Metrics{ErrorRate: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "errors"}, []string{"error"})}
+	server := httptest.NewServer(DependentsHandler(fakeGraphGetter(newTestGraph(t)), newTestConfigIndex(), resolverMetrics))
+	defer server.Close()
+	for _, tc := range []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expected       interface{}
+	}{
+		{
+			name:           "dependents of a chain",
+			query:          "?type=chain&name=setup",
+			expectedStatus: http.StatusOK,
+			expected: &DependentsResponse{
+				RegistryElement: RegistryElement{Type: "chain", Name: "setup"},
+				Dependents: []Dependent{
+					{MetadataWithTest: api.MetadataWithTest{Metadata: api.Metadata{Org: "org", Repo: "other", Branch: "main", Variant: "variant"}, Test: "setup"}, Via: RegistryElement{Type: "chain", Name: "setup"}},
+					{MetadataWithTest: api.MetadataWithTest{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"}, Test: "e2e"}, Via: RegistryElement{Type: "workflow", Name: "ipi"}},
+					{MetadataWithTest: api.MetadataWithTest{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"}, Test: "e2e-install"}, Via: RegistryElement{Type: "workflow", Name: "ipi"}},
+				},
+			},
+		},
+		{
+			name:           "unknown type",
+			query:          "?type=step&name=setup",
+			expectedStatus: http.StatusBadRequest,
+			expected:       &APIError{Code: ErrorCodeInvalidRequest, Message: `unknown registry element type "step"`},
+		},
+		{
+			name:           "unknown element",
+			query:          "?type=workflow&name=setup",
+			expectedStatus: http.StatusNotFound,
+			expected:       &APIError{Code: ErrorCodeNotFound, Message: "workflow setup not found"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + tc.query)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, resp.StatusCode)
+			}
+			var actual interface{}
+			switch tc.expected.(type) {
+			case *APIError:
+				actual = &APIError{}
+			default:
+				actual = &DependentsResponse{}
+			}
+			if err := json.NewDecoder(resp.Body).Decode(actual); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected response: %s", diff)
+			}
+		})
+	}
+}
@@ -261,163 +261,173 @@ func ResolveAndMergeConfigsAndInjectTest(configs Getter, resolver Resolver, reso
 		}
 		logger := logrus.WithField("merged", "true")
 
-		mergedConfig := api.ReleaseBuildConfiguration{
-			InputConfiguration: api.InputConfiguration{
-				BuildRootImages: make(map[string]api.BuildRootImageConfiguration, len(metadataList)),
-				BaseImages:      make(map[string]api.ImageStreamTagReference),
-				BaseRPMImages:   make(map[string]api.ImageStreamTagReference),
-			},
-			Resources: make(api.ResourceConfiguration),
-		}
-		for _, metadata := range metadataList {
-			configLogger := logger.WithFields(api.LogFieldsFor(metadata))
-			configLogger.Info("requested metadata to be merged")
-			config, err := configs.GetMatchingConfig(metadata)
-			if err != nil {
-				metrics.RecordError("config not found", resolverMetrics.ErrorRate)
-				w.WriteHeader(http.StatusNotFound)
-				fmt.Fprintf(w, "failed to get config: %v", err)
-				configLogger.WithError(err).Warning("failed to get config")
-				return
-			}
-			ref := fmt.Sprintf("%s.%s", metadata.Org, metadata.Repo)
+		mergedConfig, err := mergeConfigs(configs, metadataList, logger)
+		if err != nil {
+			metrics.RecordError("config not found", resolverMetrics.ErrorRate)
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "failed to get config: %v", err)
+			logger.WithError(err).Warning("failed to get config")
+			return
+		}
+		//TODO: If this is to be used for a general purpose outside of payload testing, we will need to merge tests and other elements
 
-			mergedConfig.BuildRootImages[ref] = *config.BuildRootImage
+		if configWithInjectedTest := injectTest(mergedConfig, configs, resolverMetrics, w, r, logger); configWithInjectedTest != nil {
+			resolveAndRespond(resolver, *configWithInjectedTest, w, logger, resolverMetrics)
+		}
+	}
+}
 
-			for key, image := range config.BaseImages {
-				imageRef := fmt.Sprintf("%s-%s", key, ref)
-				mergedConfig.BaseImages[imageRef] = image
-			}
-			if config.BinaryBuildCommands != "" {
-				mergedConfig.BinaryBuildCommandsList = append(mergedConfig.BinaryBuildCommandsList, api.RefCommands{
-					Ref:      ref,
-					Commands: config.BinaryBuildCommands,
-				})
-			}
-			if config.TestBinaryBuildCommands != "" {
-				mergedConfig.TestBinaryBuildCommandsList = append(mergedConfig.TestBinaryBuildCommandsList, api.RefCommands{
-					Ref:      ref,
-					Commands: config.TestBinaryBuildCommands,
-				})
-			}
-			if config.RpmBuildCommands != "" {
-				mergedConfig.RpmBuildCommandsList = append(mergedConfig.RpmBuildCommandsList, api.RefCommands{
-					Ref:      ref,
-					Commands: config.RpmBuildCommands,
-				})
-			}
-			if config.RpmBuildLocation != "" {
-				mergedConfig.RpmBuildLocationList = append(mergedConfig.RpmBuildLocationList, api.RefLocation{
-					Ref:      ref,
-					Location: config.RpmBuildLocation,
-				})
-			}
-			for key, image := range config.BaseRPMImages {
-				imageRef := fmt.Sprintf("%s-%s", key, ref)
-				mergedConfig.BaseRPMImages[imageRef] = image
-			}
-			if config.Operator != nil {
-				if mergedConfig.Operator == nil {
-					mergedConfig.Operator = config.Operator
-				} else {
-					//TODO: when merging multiple configs with 'operator' defined we could have conflicts, we could handle these better, but it is unlikely to come up
-					mergedConfig.Operator.Bundles = append(mergedConfig.Operator.Bundles, config.Operator.Bundles...)
-					mergedConfig.Operator.Substitutions = append(mergedConfig.Operator.Substitutions, config.Operator.Substitutions...)
-				}
-			}
-			if config.CanonicalGoRepository != nil {
-				mergedConfig.CanonicalGoRepositoryList = append(mergedConfig.CanonicalGoRepositoryList, api.RefRepository{
-					Ref:        ref,
-					Repository: *config.CanonicalGoRepository,
-				})
+// mergeConfigs merges the configurations of several repositories into one
+// that builds all of them, namespacing images and steps by repository
+func mergeConfigs(configs Getter, metadataList []api.Metadata, logger *logrus.Entry) (api.ReleaseBuildConfiguration, error) {
+	mergedConfig := api.ReleaseBuildConfiguration{
+		InputConfiguration: api.InputConfiguration{
+			BuildRootImages: make(map[string]api.BuildRootImageConfiguration, len(metadataList)),
+			BaseImages:      make(map[string]api.ImageStreamTagReference),
+			BaseRPMImages:   make(map[string]api.ImageStreamTagReference),
+		},
+		Resources: make(api.ResourceConfiguration),
+	}
+	for _, metadata := range metadataList {
+		configLogger := logger.WithFields(api.LogFieldsFor(metadata))
+		configLogger.Info("requested metadata to be merged")
+		config, err := configs.GetMatchingConfig(metadata)
+		if err != nil {
+			return api.ReleaseBuildConfiguration{}, fmt.Errorf("%s: %w", metadata.AsString(), err)
+		}
+		ref := fmt.Sprintf("%s.%s", metadata.Org, metadata.Repo)
+
+		mergedConfig.BuildRootImages[ref] = *config.BuildRootImage
+
+		for key, image := range config.BaseImages {
+			imageRef := fmt.Sprintf("%s-%s", key, ref)
+			mergedConfig.BaseImages[imageRef] = image
+		}
+		if config.BinaryBuildCommands != "" {
+			mergedConfig.BinaryBuildCommandsList = append(mergedConfig.BinaryBuildCommandsList, api.RefCommands{
+				Ref:      ref,
+				Commands: config.BinaryBuildCommands,
+			})
+		}
+		if config.TestBinaryBuildCommands != "" {
+			mergedConfig.TestBinaryBuildCommandsList = append(mergedConfig.TestBinaryBuildCommandsList, api.RefCommands{
+				Ref:      ref,
+				Commands: config.TestBinaryBuildCommands,
+			})
+		}
+		if config.RpmBuildCommands != "" {
+			mergedConfig.RpmBuildCommandsList = append(mergedConfig.RpmBuildCommandsList, api.RefCommands{
+				Ref:      ref,
+				Commands: config.RpmBuildCommands,
+			})
+		}
+		if config.RpmBuildLocation != "" {
+			mergedConfig.RpmBuildLocationList = append(mergedConfig.RpmBuildLocationList, api.RefLocation{
+				Ref:      ref,
+				Location: config.RpmBuildLocation,
+			})
+		}
+		for key, image := range config.BaseRPMImages {
+			imageRef := fmt.Sprintf("%s-%s", key, ref)
+			mergedConfig.BaseRPMImages[imageRef] = image
+		}
+		if config.Operator != nil {
+			if mergedConfig.Operator == nil {
+				mergedConfig.Operator = config.Operator
+			} else {
+				//TODO: when merging multiple configs with 'operator' defined we could have conflicts, we could handle these better, but it is unlikely to come up
+				mergedConfig.Operator.Bundles = append(mergedConfig.Operator.Bundles, config.Operator.Bundles...)
+				mergedConfig.Operator.Substitutions = append(mergedConfig.Operator.Substitutions, config.Operator.Substitutions...)
 			}
-			for step, resources := range config.Resources {
-				if step == "*" { // * is special, and the ref should not be appended, it will be merged to use the greatest value instead
-					if existing, ok := mergedConfig.Resources["*"]; ok {
-						replaceIfGreater := func(resourceType string) {
-							existingValue, err := resource.ParseQuantity(existing.Requests[resourceType])
-							if err != nil {
-								logger.WithError(err).Warnf("couldn't parse existing '%s' resource quantity", resourceType)
-								return
-							}
-							value, err := resource.ParseQuantity(resources.Requests[resourceType])
-							if err != nil {
-								logger.WithError(err).Warnf("couldn't parse '%s' resource quantity", resourceType)
-								return
-							}
-							if existingValue.Cmp(value) < 0 { // This value is higher than existing
-								mergedConfig.Resources["*"].Requests[resourceType] = resources.Requests[resourceType]
-							}
+		}
+		if config.CanonicalGoRepository != nil {
+			mergedConfig.CanonicalGoRepositoryList = append(mergedConfig.CanonicalGoRepositoryList, api.RefRepository{
+				Ref:        ref,
+				Repository: *config.CanonicalGoRepository,
+			})
+		}
+		for step, resources := range config.Resources {
+			if step == "*" { // * is special, and the ref should not be appended, it will be merged to use the greatest value instead
+				if existing, ok := mergedConfig.Resources["*"]; ok {
+					replaceIfGreater := func(resourceType string) {
+						existingValue, err := resource.ParseQuantity(existing.Requests[resourceType])
+						if err != nil {
+							logger.WithError(err).Warnf("couldn't parse existing '%s' resource quantity", resourceType)
+							return
+						}
+						value, err := resource.ParseQuantity(resources.Requests[resourceType])
+						if err != nil {
+							logger.WithError(err).Warnf("couldn't parse '%s' resource quantity", resourceType)
+							return
 						}
-						replaceIfGreater("memory")
-						replaceIfGreater("cpu")
-					} else {
-						mergedConfig.Resources["*"] = api.ResourceRequirements{
-							Requests: resources.Requests,
-							// We cannot set Limits for * because other configs may not be able to fall under them
+						if existingValue.Cmp(value) < 0 { // This value is higher than existing
+							mergedConfig.Resources["*"].Requests[resourceType] = resources.Requests[resourceType]
 						}
 					}
+					replaceIfGreater("memory")
+					replaceIfGreater("cpu")
 				} else {
-					stepWithRef := fmt.Sprintf("%s-%s", step, ref)
-					mergedConfig.Resources[stepWithRef] = resources
+					mergedConfig.Resources["*"] = api.ResourceRequirements{
+						Requests: resources.Requests,
+						// We cannot set Limits for * because other configs may not be able to fall under them
+					}
 				}
+			} else {
+				stepWithRef := fmt.Sprintf("%s-%s", step, ref)
+				mergedConfig.Resources[stepWithRef] = resources
 			}
-			if len(config.Releases) > 0 && len(mergedConfig.Releases) == 0 {
-				// Since the release configs "should" be identical, we can just use the first one we come across
-				mergedConfig.Releases = config.Releases
-			}
+		}
+		if len(config.Releases) > 0 && len(mergedConfig.Releases) == 0 {
+			// Since the release configs "should" be identical, we can just use the first one we come across
+			mergedConfig.Releases = config.Releases
+		}
 
-			for i := range config.Images {
-				image := config.Images[i]
-				if image.From != "" {
-					image.From = api.PipelineImageStreamTagReference(fmt.Sprintf("%s-%s", image.From, ref))
-				}
-				inputs := make(map[string]api.ImageBuildInputs)
-				for name, input := range image.Inputs {
-					inputs[fmt.Sprintf("%s-%s", name, ref)] = input
-				}
-				image.Inputs = inputs
-				image.To = api.PipelineImageStreamTagReference(fmt.Sprintf("%s-%s", image.To, ref))
-				image.Ref = ref
-				mergedConfig.Images = append(mergedConfig.Images, image)
+		for i := range config.Images {
+			image := config.Images[i]
+			if image.From != "" {
+				image.From = api.PipelineImageStreamTagReference(fmt.Sprintf("%s-%s", image.From, ref))
 			}
-
-			// Attempt to handle a few simple raw_step types on a best-effort basis
-			for i := range config.RawSteps {
-				rawStep := config.RawSteps[i]
-				modifiedStep := rawStep.DeepCopy()
-				if rawStep.RPMImageInjectionStepConfiguration != nil {
-					to := fmt.Sprintf("%s-%s", rawStep.RPMImageInjectionStepConfiguration.To, ref)
-					modifiedStep.RPMImageInjectionStepConfiguration.To = api.PipelineImageStreamTagReference(to)
-					from := fmt.Sprintf("%s-%s", rawStep.RPMImageInjectionStepConfiguration.From, ref)
-					modifiedStep.RPMImageInjectionStepConfiguration.From = api.PipelineImageStreamTagReference(from)
-				} else if rawStep.ProjectDirectoryImageBuildStepConfiguration != nil {
-					to := fmt.Sprintf("%s-%s", rawStep.ProjectDirectoryImageBuildStepConfiguration.To, ref)
-					modifiedStep.ProjectDirectoryImageBuildStepConfiguration.To = api.PipelineImageStreamTagReference(to)
-					from := fmt.Sprintf("%s-%s", rawStep.ProjectDirectoryImageBuildStepConfiguration.From, ref)
-					modifiedStep.ProjectDirectoryImageBuildStepConfiguration.From = api.PipelineImageStreamTagReference(from)
-					modifiedStep.ProjectDirectoryImageBuildStepConfiguration.Ref = ref
-				} else if rawStep.PipelineImageCacheStepConfiguration != nil {
-					to := fmt.Sprintf("%s-%s", rawStep.PipelineImageCacheStepConfiguration.To, ref)
-					modifiedStep.PipelineImageCacheStepConfiguration.To = api.PipelineImageStreamTagReference(to)
-					from := fmt.Sprintf("%s-%s", rawStep.PipelineImageCacheStepConfiguration.From, ref)
-					modifiedStep.PipelineImageCacheStepConfiguration.From = api.PipelineImageStreamTagReference(from)
-				} else if rawStep.OutputImageTagStepConfiguration != nil {
-					from := fmt.Sprintf("%s-%s", rawStep.OutputImageTagStepConfiguration.From, ref)
-					modifiedStep.OutputImageTagStepConfiguration.From = api.PipelineImageStreamTagReference(from)
-					//We don't want to change the 'to' here as it will likely land in stable and shouldn't be modified
-				} else {
-					configLogger.Warnf("raw_steps[%d] in config is of an unsupported type for multi-pr payload testing, this is not handled and may result in errors", i)
-				}
-				mergedConfig.RawSteps = append(mergedConfig.RawSteps, *modifiedStep)
+			inputs := make(map[string]api.ImageBuildInputs)
+			for name, input := range image.Inputs {
+				inputs[fmt.Sprintf("%s-%s", name, ref)] = input
 			}
+			image.Inputs = inputs
+			image.To = api.PipelineImageStreamTagReference(fmt.Sprintf("%s-%s", image.To, ref))
+			image.Ref = ref
+			mergedConfig.Images = append(mergedConfig.Images, image)
 		}
-		//TODO: If this is to be used for a general purpose outside of payload testing, we will need to merge tests and other elements
 
-		if configWithInjectedTest := injectTest(mergedConfig, configs, resolverMetrics, w, r, logger); configWithInjectedTest != nil {
-			resolveAndRespond(resolver, *configWithInjectedTest, w, logger, resolverMetrics)
+		// Attempt to handle a few simple raw_step types on a best-effort basis
+		for i := range config.RawSteps {
+			rawStep := config.RawSteps[i]
+			modifiedStep := rawStep.DeepCopy()
+			if rawStep.RPMImageInjectionStepConfiguration != nil {
+				to := fmt.Sprintf("%s-%s", rawStep.RPMImageInjectionStepConfiguration.To, ref)
+				modifiedStep.RPMImageInjectionStepConfiguration.To = api.PipelineImageStreamTagReference(to)
+				from := fmt.Sprintf("%s-%s", rawStep.RPMImageInjectionStepConfiguration.From, ref)
+				modifiedStep.RPMImageInjectionStepConfiguration.From = api.PipelineImageStreamTagReference(from)
+			} else if rawStep.ProjectDirectoryImageBuildStepConfiguration != nil {
+				to := fmt.Sprintf("%s-%s", rawStep.ProjectDirectoryImageBuildStepConfiguration.To, ref)
+				modifiedStep.ProjectDirectoryImageBuildStepConfiguration.To = api.PipelineImageStreamTagReference(to)
+				from := fmt.Sprintf("%s-%s", rawStep.ProjectDirectoryImageBuildStepConfiguration.From, ref)
+				modifiedStep.ProjectDirectoryImageBuildStepConfiguration.From = api.PipelineImageStreamTagReference(from)
+				modifiedStep.ProjectDirectoryImageBuildStepConfiguration.Ref = ref
+			} else if rawStep.PipelineImageCacheStepConfiguration != nil {
+				to := fmt.Sprintf("%s-%s", rawStep.PipelineImageCacheStepConfiguration.To, ref)
+				modifiedStep.PipelineImageCacheStepConfiguration.To = api.PipelineImageStreamTagReference(to)
+				from := fmt.Sprintf("%s-%s", rawStep.PipelineImageCacheStepConfiguration.From, ref)
+				modifiedStep.PipelineImageCacheStepConfiguration.From = api.PipelineImageStreamTagReference(from)
+			} else if rawStep.OutputImageTagStepConfiguration != nil {
+				from := fmt.Sprintf("%s-%s", rawStep.OutputImageTagStepConfiguration.From, ref)
+				modifiedStep.OutputImageTagStepConfiguration.From = api.PipelineImageStreamTagReference(from)
+				//We don't want to change the 'to' here as it will likely land in stable and shouldn't be modified
+			} else {
+				configLogger.Warnf("raw_steps[%d] in config is of an unsupported type for multi-pr payload testing, this is not handled and may result in errors", i)
+			}
+			mergedConfig.RawSteps = append(mergedConfig.RawSteps, *modifiedStep)
 		}
 	}
+	return mergedConfig, nil
 }
 
 func MetadataEntriesFromQuery(w http.ResponseWriter, r *http.Request) ([]api.Metadata, error) {
@@ -0,0 +1,326 @@
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/metrics"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// V1Prefix is the path under which the versioned API is served
+const V1Prefix = "/v1"
+
+const (
+	// V1ConfigPath serves a resolved configuration, GET with the metadata in the query
+	V1ConfigPath = V1Prefix + "/config"
+	// V1BatchPath serves many resolved configurations, POST with a BatchRequest
+	V1BatchPath = V1Prefix + "/configs/batch"
+	// V1MergePath serves configurations merged together with a test injected
+	// into them, POST with a MergeRequest
+	V1MergePath = V1Prefix + "/configs/merge"
+	// V1ResolvePath resolves a literal configuration, POST with the configuration
+	V1ResolvePath = V1Prefix + "/resolve"
+	// V1GenerationPath serves the generations of the configurations and the registry
+	V1GenerationPath = V1Prefix + "/generation"
+	// V1OpenAPIPath serves the OpenAPI document describing the API
+	V1OpenAPIPath = V1Prefix + "/openapi.json"
+)
+
+// maxBatchSize limits how many configurations are resolved in one request
+const maxBatchSize = 500
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// Error codes identify the kind of failure in an APIError
+const (
+	ErrorCodeInvalidRequest   = "invalid_request"
+	ErrorCodeMethodNotAllowed = "method_not_allowed"
+	ErrorCodeNotFound         = "not_found"
+	ErrorCodeInjectionFailed  = "injection_failed"
+	ErrorCodeResolutionFailed = "resolution_failed"
+	ErrorCodeInternal         = "internal"
+)
+
+// APIError is the body of every failed response of the versioned API, and of
+// every failed entry of a batch
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// BatchRequest lists the configurations to resolve in one call
+type BatchRequest struct {
+	Configs []api.Metadata `json:"configs"`
+}
+
+// BatchResult holds either the resolved configuration or the reason it could
+// not be resolved
+type BatchResult struct {
+	Metadata api.Metadata                   `json:"metadata"`
+	Config   *api.ReleaseBuildConfiguration `json:"config,omitempty"`
+	Error    *APIError                      `json:"error,omitempty"`
+}
+
+// BatchResponse holds one result per requested configuration, in the order of
+// the request
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// MergeRequest asks for configurations to be merged into one, into which a
+// test from another configuration is injected
+type MergeRequest struct {
+	Configs    []api.Metadata       `json:"configs"`
+	InjectTest api.MetadataWithTest `json:"inject_test"`
+}
+
+// Generations are incremented every time the configurations or the registry
+// are reloaded
+type Generations struct {
+	Config   int `json:"config"`
+	Registry int `json:"registry"`
+}
+
+// ETag identifies the content served for the generations, as anything served
+// can only change when either of them does
+func (g Generations) ETag() string {
+	return fmt.Sprintf(`"%d-%d"`, g.Config, g.Registry)
+}
+
+// Generation is implemented by the agents loading configurations and the
+// registry
+type Generation interface {
+	GetGeneration() int
+}
+
+type v1Server struct {
+	configs            Getter
+	resolver           Resolver
+	configGeneration   Generation
+	registryGeneration Generation
+	metrics            *metrics.Metrics
+}
+
+// NewV1Handler serves the versioned API under V1Prefix
+func NewV1Handler(configs Getter, resolver Resolver, configGeneration, registryGeneration Generation, resolverMetrics *metrics.Metrics) http.Handler {
+	s := &v1Server{
+		configs:            configs,
+		resolver:           resolver,
+		configGeneration:   configGeneration,
+		registryGeneration: registryGeneration,
+		metrics:            resolverMetrics,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(V1ConfigPath, s.method(http.MethodGet, s.config))
+	mux.HandleFunc(V1BatchPath, s.method(http.MethodPost, s.batch))
+	mux.HandleFunc(V1MergePath, s.method(http.MethodPost, s.merge))
+	mux.HandleFunc(V1ResolvePath, s.method(http.MethodPost, s.resolve))
+	mux.HandleFunc(V1GenerationPath, s.method(http.MethodGet, s.generation))
+	mux.HandleFunc(V1OpenAPIPath, s.method(http.MethodGet, s.openAPI))
+	mux.HandleFunc(V1Prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		s.fail(w, http.StatusNotFound, &APIError{Code: ErrorCodeNotFound, Message: fmt.Sprintf("no such endpoint: %s", r.URL.Path)})
+	})
+	return mux
+}
+
+func (s *v1Server) method(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			s.fail(w, http.StatusMethodNotAllowed, &APIError{Code: ErrorCodeMethodNotAllowed, Message: fmt.Sprintf("expected %s, got %s", method, r.Method)})
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *v1Server) generations() Generations {
+	return Generations{Config: s.configGeneration.GetGeneration(), Registry: s.registryGeneration.GetGeneration()}
+}
+
+func (s *v1Server) fail(w http.ResponseWriter, status int, apiErr *APIError) {
+	metrics.RecordError(apiErr.Code, s.metrics.ErrorRate)
+	logrus.WithField("code", apiErr.Code).Warning(apiErr.Message)
+	respond(w, status, apiErr)
+}
+
+func respond(w http.ResponseWriter, status int, body interface{}) {
+	raw, err := json.MarshalIndent(body, "", "  ")
+	if err != nil {
+		status = http.StatusInternalServerError
+		raw, _ = json.Marshal(&APIError{Code: ErrorCodeInternal, Message: fmt.Sprintf("failed to marshal response: %v", err)})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(raw); err != nil {
+		logrus.WithError(err).Error("Failed to write response")
+	}
+}
+
+func validateMetadata(metadata api.Metadata) *APIError {
+	for _, field := range []struct{ name, value string }{
+		{name: OrgQuery, value: metadata.Org},
+		{name: RepoQuery, value: metadata.Repo},
+		{name: BranchQuery, value: metadata.Branch},
+	} {
+		if field.value == "" {
+			return &APIError{Code: ErrorCodeInvalidRequest, Message: fmt.Sprintf("%s is required", field.name)}
+		}
+	}
+	return nil
+}
+
+// resolveMetadata loads and resolves one configuration, returning the status
+// code matching the failure if it cannot be
+func (s *v1Server) resolveMetadata(metadata api.Metadata) (*api.ReleaseBuildConfiguration, int, *APIError) {
+	if apiErr := validateMetadata(metadata); apiErr != nil {
+		return nil, http.StatusBadRequest, apiErr
+	}
+	config, err := s.configs.GetMatchingConfig(metadata)
+	if err != nil {
+		return nil, http.StatusNotFound, &APIError{Code: ErrorCodeNotFound, Message: fmt.Sprintf("failed to get config for %s: %v", metadata.AsString(), err)}
+	}
+	return s.resolveConfig(config)
+}
+
+func (s *v1Server) resolveConfig(config api.ReleaseBuildConfiguration) (*api.ReleaseBuildConfiguration, int, *APIError) {
+	resolved, err := s.resolver.ResolveConfig(config)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, &APIError{Code: ErrorCodeResolutionFailed, Message: fmt.Sprintf("failed to resolve config with registry: %v", err)}
+	}
+	return &resolved, http.StatusOK, nil
+}
+
+func (s *v1Server) decode(w http.ResponseWriter, r *http.Request, into interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(into); err != nil {
+		s.fail(w, http.StatusBadRequest, &APIError{Code: ErrorCodeInvalidRequest, Message: fmt.Sprintf("could not parse request body: %v", err)})
+		return false
+	}
+	return true
+}
+
+func (s *v1Server) config(w http.ResponseWriter, r *http.Request) {
+	etag := s.generations().ETag()
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	query := r.URL.Query()
+	metadata := api.Metadata{
+		Org:     query.Get(OrgQuery),
+		Repo:    query.Get(RepoQuery),
+		Branch:  query.Get(BranchQuery),
+		Variant: query.Get(VariantQuery),
+	}
+	config, status, apiErr := s.resolveMetadata(metadata)
+	if apiErr != nil {
+		s.fail(w, status, apiErr)
+		return
+	}
+	respond(w, http.StatusOK, config)
+}
+
+func (s *v1Server) batch(w http.ResponseWriter, r *http.Request) {
+	var request BatchRequest
+	if !s.decode(w, r, &request) {
+		return
+	}
+	if len(request.Configs) > maxBatchSize {
+		s.fail(w, http.StatusBadRequest, &APIError{Code: ErrorCodeInvalidRequest, Message: fmt.Sprintf("at most %d configs can be requested at once, got %d", maxBatchSize, len(request.Configs))})
+		return
+	}
+	w.Header().Set("ETag", s.generations().ETag())
+	response := BatchResponse{Results: make([]BatchResult, 0, len(request.Configs))}
+	for _, metadata := range request.Configs {
+		config, _, apiErr := s.resolveMetadata(metadata)
+		if apiErr != nil {
+			metrics.RecordError(apiErr.Code, s.metrics.ErrorRate)
+		}
+		response.Results = append(response.Results, BatchResult{Metadata: metadata, Config: config, Error: apiErr})
+	}
+	respond(w, http.StatusOK, response)
+}
+
+func (s *v1Server) merge(w http.ResponseWriter, r *http.Request) {
+	var request MergeRequest
+	if !s.decode(w, r, &request) {
+		return
+	}
+	if len(request.Configs) == 0 {
+		s.fail(w, http.StatusBadRequest, &APIError{Code: ErrorCodeInvalidRequest, Message: "at least one config is required"})
+		return
+	}
+	for _, metadata := range append([]api.Metadata{request.InjectTest.Metadata}, request.Configs...) {
+		if apiErr := validateMetadata(metadata); apiErr != nil {
+			s.fail(w, http.StatusBadRequest, apiErr)
+			return
+		}
+	}
+	if request.InjectTest.Test == "" {
+		s.fail(w, http.StatusBadRequest, &APIError{Code: ErrorCodeInvalidRequest, Message: "the test to inject is required"})
+		return
+	}
+	w.Header().Set("ETag", s.generations().ETag())
+	logger := logrus.WithField("merged", "true")
+	merged, err := mergeConfigs(s.configs, request.Configs, logger)
+	if err != nil {
+		s.fail(w, http.StatusNotFound, &APIError{Code: ErrorCodeNotFound, Message: fmt.Sprintf("failed to get config: %v", err)})
+		return
+	}
+	injectFrom, err := s.configs.GetMatchingConfig(request.InjectTest.Metadata)
+	if err != nil {
+		s.fail(w, http.StatusNotFound, &APIError{Code: ErrorCodeNotFound, Message: fmt.Sprintf("failed to get config to inject from: %v", err)})
+		return
+	}
+	injected, err := merged.WithPresubmitFrom(&injectFrom, request.InjectTest.Test)
+	if err != nil {
+		s.fail(w, http.StatusUnprocessableEntity, &APIError{Code: ErrorCodeInjectionFailed, Message: fmt.Sprintf("failed to inject test into config: %v", err)})
+		return
+	}
+	config, status, apiErr := s.resolveConfig(*injected)
+	if apiErr != nil {
+		s.fail(w, status, apiErr)
+		return
+	}
+	respond(w, http.StatusOK, config)
+}
+
+func (s *v1Server) resolve(w http.ResponseWriter, r *http.Request) {
+	var unresolved api.ReleaseBuildConfiguration
+	if !s.decode(w, r, &unresolved) {
+		return
+	}
+	w.Header().Set("ETag", s.generations().ETag())
+	config, status, apiErr := s.resolveConfig(unresolved)
+	if apiErr != nil {
+		s.fail(w, status, apiErr)
+		return
+	}
+	respond(w, http.StatusOK, config)
+}
+
+func (s *v1Server) generation(w http.ResponseWriter, _ *http.Request) {
+	generations := s.generations()
+	w.Header().Set("ETag", generations.ETag())
+	respond(w, http.StatusOK, generations)
+}
+
+func (s *v1Server) openAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(openAPISpec); err != nil {
+		logrus.WithError(err).Error("Failed to write response")
+	}
+}
@@ -0,0 +1,313 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/test-infra/prow/metrics"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+type fakeGetter map[api.Metadata]api.ReleaseBuildConfiguration
+
+func (g fakeGetter) GetMatchingConfig(metadata api.Metadata) (api.ReleaseBuildConfiguration, error) {
+	config, ok := g[metadata]
+	if !ok {
+		return api.ReleaseBuildConfiguration{}, errors.New("no config")
+	}
+	return config, nil
+}
+
+// fakeResolver marks configurations as resolved by setting their metadata
+// variant, and fails to resolve configurations without any build root
+type fakeResolver struct{}
+
+func (fakeResolver) ResolveConfig(config api.ReleaseBuildConfiguration) (api.ReleaseBuildConfiguration, error) {
+	if config.BuildRootImage == nil && len(config.BuildRootImages) == 0 {
+		return api.ReleaseBuildConfiguration{}, errors.New("no build root")
+	}
+	config.Metadata.Variant = "resolved"
+	return config, nil
+}
+
+type fakeGeneration int
+
+func (g *fakeGeneration) GetGeneration() int {
+	return int(*g)
+}
+
+func testConfig(metadata api.Metadata) api.ReleaseBuildConfiguration {
+	return api.ReleaseBuildConfiguration{
+		Metadata: metadata,
+		InputConfiguration: api.InputConfiguration{
+			BuildRootImage: &api.BuildRootImageConfiguration{ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "ci", Name: "root", Tag: metadata.Repo}},
+		},
+		Tests: []api.TestStepConfiguration{{As: "unit", Commands: "make test", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}}},
+	}
+}
+
+func resolved(config api.ReleaseBuildConfiguration) *api.ReleaseBuildConfiguration {
+	config.Metadata.Variant = "resolved"
+	return &config
+}
+
+func newTestV1Server(t *testing.T) (*httptest.Server, *fakeGeneration, map[string]api.Metadata) {
+	metadata := map[string]api.Metadata{
+		"good":   {Org: "org", Repo: "good", Branch: "main"},
+		"broken": {Org: "org", Repo: "broken", Branch: "main"},
+		"other":  {Org: "org", Repo: "other", Branch: "main"},
+	}
+	broken := testConfig(metadata["broken"])
+	broken.BuildRootImage = nil
+	configs := fakeGetter{
+		metadata["good"]:   testConfig(metadata["good"]),
+		metadata["broken"]: broken,
+		metadata["other"]:  testConfig(metadata["other"]),
+	}
+	configGeneration, registryGeneration := fakeGeneration(1), fakeGeneration(2)
+	resolverMetrics := &metrics.Metrics{ErrorRate: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "errors"}, []string{"error"})}
+	server := httptest.NewServer(NewV1Handler(configs, fakeResolver{}, &configGeneration, &registryGeneration, resolverMetrics))
+	t.Cleanup(server.Close)
+	return server, &configGeneration, metadata
+}
+
+func TestV1Handler(t *testing.T) {
+	server, _, metadata := newTestV1Server(t)
+	for _, tc := range []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		header         map[string]string
+		expectedStatus int
+		expectedError  *APIError
+		expectedConfig *api.ReleaseBuildConfiguration
+	}{
+		{
+			name:           "config is resolved",
+			method:         http.MethodGet,
+			path:           V1ConfigPath + "?org=org&repo=good&branch=main",
+			expectedStatus: http.StatusOK,
+			expectedConfig: resolved(testConfig(metadata["good"])),
+		},
+		{
+			name:           "config is not modified",
+			method:         http.MethodGet,
+			path:           V1ConfigPath + "?org=org&repo=good&branch=main",
+			header:         map[string]string{"If-None-Match": `"1-2"`},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "config of a previous generation is served again",
+			method:         http.MethodGet,
+			path:           V1ConfigPath + "?org=org&repo=good&branch=main",
+			header:         map[string]string{"If-None-Match": `"1-1"`},
+			expectedStatus: http.StatusOK,
+			expectedConfig: resolved(testConfig(metadata["good"])),
+		},
+		{
+			name:           "missing query",
+			method:         http.MethodGet,
+			path:           V1ConfigPath + "?org=org&branch=main",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  &APIError{Code: ErrorCodeInvalidRequest, Message: "repo is required"},
+		},
+		{
+			name:           "missing config",
+			method:         http.MethodGet,
+			path:           V1ConfigPath + "?org=org&repo=missing&branch=main",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  &APIError{Code: ErrorCodeNotFound, Message: "failed to get config for org/missing@main: no config"},
+		},
+		{
+			name:           "config cannot be resolved",
+			method:         http.MethodGet,
+			path:           V1ConfigPath + "?org=org&repo=broken&branch=main",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  &APIError{Code: ErrorCodeResolutionFailed, Message: "failed to resolve config with registry: no build root"},
+		},
+		{
+			name:           "wrong method",
+			method:         http.MethodPost,
+			path:           V1ConfigPath,
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedError:  &APIError{Code: ErrorCodeMethodNotAllowed, Message: "expected GET, got POST"},
+		},
+		{
+			name:           "unknown endpoint",
+			method:         http.MethodGet,
+			path:           V1Prefix + "/unknown",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  &APIError{Code: ErrorCodeNotFound, Message: "no such endpoint: /v1/unknown"},
+		},
+		{
+			name:           "literal config is resolved",
+			method:         http.MethodPost,
+			path:           V1ResolvePath,
+			body:           `{"build_root":{"image_stream_tag":{"namespace":"ci","name":"root","tag":"literal"}}}`,
+			expectedStatus: http.StatusOK,
+			expectedConfig: &api.ReleaseBuildConfiguration{
+				Metadata: api.Metadata{Variant: "resolved"},
+				InputConfiguration: api.InputConfiguration{
+					BuildRootImage: &api.BuildRootImageConfiguration{ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "ci", Name: "root", Tag: "literal"}},
+				},
+			},
+		},
+		{
+			name:           "literal config is malformed",
+			method:         http.MethodPost,
+			path:           V1ResolvePath,
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  &APIError{Code: ErrorCodeInvalidRequest, Message: "could not parse request body: unexpected EOF"},
+		},
+		{
+			name:           "test to inject is missing",
+			method:         http.MethodPost,
+			path:           V1MergePath,
+			body:           `{"configs":[{"org":"org","repo":"good","branch":"main"}],"inject_test":{"org":"org","repo":"other","branch":"main"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  &APIError{Code: ErrorCodeInvalidRequest, Message: "the test to inject is required"},
+		},
+		{
+			name:           "test cannot be injected",
+			method:         http.MethodPost,
+			path:           V1MergePath,
+			body:           `{"configs":[{"org":"org","repo":"good","branch":"main"}],"inject_test":{"org":"org","repo":"other","branch":"main","test":"e2e"}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  &APIError{Code: ErrorCodeInjectionFailed, Message: "failed to inject test into config: test 'e2e' not found in source configuration"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, server.URL+tc.path, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, resp.StatusCode)
+			}
+			if tc.expectedError != nil {
+				var apiErr *APIError
+				if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+					t.Fatalf("failed to decode error: %v", err)
+				}
+				if diff := cmp.Diff(tc.expectedError, apiErr); diff != "" {
+					t.Errorf("unexpected error: %s", diff)
+				}
+				return
+			}
+			if etag := resp.Header.Get("ETag"); etag != `"1-2"` {
+				t.Errorf("expected the ETag to identify the generations, got %q", etag)
+			}
+			if tc.expectedConfig != nil {
+				var config *api.ReleaseBuildConfiguration
+				if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+					t.Fatalf("failed to decode config: %v", err)
+				}
+				if diff := cmp.Diff(tc.expectedConfig, config); diff != "" {
+					t.Errorf("unexpected config: %s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestV1OpenAPI(t *testing.T) {
+	server, _, _ := newTestV1Server(t)
+	resp, err := http.Get(server.URL + V1OpenAPIPath)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var spec struct {
+		Paths map[string]interface{} `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("failed to decode the OpenAPI document: %v", err)
+	}
+	for _, path := range []string{V1ConfigPath, V1BatchPath, V1MergePath, V1ResolvePath, V1GenerationPath, V1DependentsPath, V1OpenAPIPath} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("endpoint %s is not documented", path)
+		}
+	}
+}
+
+func TestV1Client(t *testing.T) {
+	server, configGeneration, metadata := newTestV1Server(t)
+	client := NewResolverClient(server.URL)
+
+	config, err := client.Config(&api.Metadata{Org: "org", Repo: "good", Branch: "main"})
+	if err != nil {
+		t.Fatalf("failed to get config: %v", err)
+	}
+	if diff := cmp.Diff(resolved(testConfig(metadata["good"])), config); diff != "" {
+		t.Errorf("unexpected config: %s", diff)
+	}
+	if etag := client.(*resolverClient).cached("org/good@main").etag; etag != `"1-2"` {
+		t.Errorf("expected the config to be cached with its ETag, got %q", etag)
+	}
+	// served from the cache, as the generations did not change
+	if config, err := client.Config(&api.Metadata{Org: "org", Repo: "good", Branch: "main"}); err != nil || !cmp.Equal(resolved(testConfig(metadata["good"])), config) {
+		t.Errorf("expected the cached config, got %v, %v", config, err)
+	}
+	*configGeneration++
+	if config, err := client.Config(&api.Metadata{Org: "org", Repo: "good", Branch: "main"}); err != nil || !cmp.Equal(resolved(testConfig(metadata["good"])), config) {
+		t.Errorf("expected the config of the new generation, got %v, %v", config, err)
+	}
+
+	_, err = client.Config(&api.Metadata{Org: "org", Repo: "missing", Branch: "main"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != ErrorCodeNotFound {
+		t.Errorf("expected a structured not found error, got %v", err)
+	}
+	if diff := cmp.Diff(errors.New("got unexpected http 404 status code from configresolver: failed to get config for org/missing@main: no config"), err, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+
+	results, err := client.ConfigBatch([]api.Metadata{metadata["good"], metadata["broken"], {Org: "org", Repo: "missing", Branch: "main"}})
+	if err != nil {
+		t.Fatalf("failed to get configs: %v", err)
+	}
+	expected := []BatchResult{
+		{Metadata: metadata["good"], Config: resolved(testConfig(metadata["good"]))},
+		{Metadata: metadata["broken"], Error: &APIError{Code: ErrorCodeResolutionFailed, Message: "failed to resolve config with registry: no build root"}},
+		{Metadata: api.Metadata{Org: "org", Repo: "missing", Branch: "main"}, Error: &APIError{Code: ErrorCodeNotFound, Message: "failed to get config for org/missing@main: no config"}},
+	}
+	if diff := cmp.Diff(expected, results); diff != "" {
+		t.Errorf("unexpected batch results: %s", diff)
+	}
+
+	base := metadata["good"]
+	config, err = client.ConfigWithTest(&base, &api.MetadataWithTest{Metadata: metadata["other"], Test: "unit"})
+	if err != nil {
+		t.Fatalf("failed to get config with injected test: %v", err)
+	}
+	if len(config.Tests) != 1 || config.Tests[0].As != "unit" || config.BuildRootImages["org.good"].ImageStreamTagReference.Tag != "good" {
+		t.Errorf("expected the merged config with the injected test, got %+v", config)
+	}
+
+	config, err = client.Resolve([]byte("build_root:\n  image_stream_tag:\n    namespace: ci\n    name: root\n    tag: literal\n"))
+	if err != nil {
+		t.Fatalf("failed to resolve config: %v", err)
+	}
+	if config.Metadata.Variant != "resolved" {
+		t.Errorf("expected the config to be resolved, got %+v", config)
+	}
+}
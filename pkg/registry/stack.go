@@ -86,6 +86,18 @@ func (s *stack) resolveDNS(dns *api.StepDNSConfig) *api.StepDNSConfig {
 	return dns
 }
 
+// resolveRetry returns the retry policy of the innermost record which has
+// one, so a chain's policy applies to all of its steps which do not define
+// their own.
+func (s *stack) resolveRetry() *api.RetryPolicy {
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if r := s.records[i].retry; r != nil {
+			return r.DeepCopy()
+		}
+	}
+	return nil
+}
+
 // checkUnused emits errors for each unused parameter/dependency in the record.
 // `overridden` is an alternative list of steps used to exclude unused errors
 // for parameters that exist only in overridden steps.  This can happen if a
@@ -152,6 +164,7 @@ type stackRecord struct {
 	deps       []api.StepDependency
 	unusedDeps sets.Set[string]
 	dnsConfig  *api.StepDNSConfig
+	retry      *api.RetryPolicy
 }
 
 func stackRecordForStep(name string, env []api.StepParameter, deps []api.StepDependency, dns *api.StepDNSConfig) stackRecord {
@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+func (t Type) String() string {
+	return nodeTypes[t]
+}
+
+// ParseType returns the type of registry element with the name
+func ParseType(name string) (Type, error) {
+	for t, n := range nodeTypes {
+		if n == name {
+			return Type(t), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown registry element type %q", name)
+}
+
+// Lookup returns the node of the registry element with the type and name. The
+// unversioned name of a versioned element refers to its latest version.
+func (n NodeByName) Lookup(t Type, name string) (Node, bool) {
+	var nodes map[string]Node
+	switch t {
+	case Workflow:
+		nodes = n.Workflows
+	case Chain:
+		nodes = n.Chains
+	case Reference:
+		nodes = n.References
+	case Observer:
+		nodes = n.Observers
+	}
+	if node, ok := nodes[name]; ok {
+		return node, true
+	}
+	for _, node := range nodes {
+		if util.Contains(node.Names(), name) {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// UsageKey identifies a registry element among those a test refers to
+func UsageKey(t Type, name string) string {
+	return t.String() + "/" + name
+}
+
+// DirectUsages returns the keys of the registry elements a test refers to
+// itself: its workflow, the observers it enables and the references and chains
+// among its steps. Elements those refer to in turn are not included.
+func DirectUsages(test *api.MultiStageTestConfiguration) []string {
+	if test == nil {
+		return nil
+	}
+	var keys []string
+	if test.Workflow != nil {
+		keys = append(keys, UsageKey(Workflow, *test.Workflow))
+	}
+	if test.Observers != nil {
+		for _, o := range test.Observers.Enable {
+			keys = append(keys, UsageKey(Observer, o))
+		}
+	}
+	for _, steps := range [][]api.TestStep{test.Pre, test.Test, test.Post} {
+		for _, step := range steps {
+			if step.Reference != nil {
+				keys = append(keys, UsageKey(Reference, *step.Reference))
+			}
+			if step.Chain != nil {
+				keys = append(keys, UsageKey(Chain, *step.Chain))
+			}
+		}
+	}
+	return keys
+}
+
+// UsesNode determines whether a test refers to the registry element itself,
+// by any of its names. A test uses an element indirectly when it refers to any
+// of its ancestors. Tests pinned to another version of the element do not use
+// it.
+func UsesNode(test *api.MultiStageTestConfiguration, node Node) bool {
+	usages := DirectUsages(test)
+	for _, name := range node.Names() {
+		if util.Contains(usages, UsageKey(node.Type(), name)) {
+			return true
+		}
+	}
+	return false
+}
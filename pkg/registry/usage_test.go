@@ -0,0 +1,63 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestDirectUsages(t *testing.T) {
+	test := &api.MultiStageTestConfiguration{
+		Workflow:  &ipi,
+		Observers: &api.Observers{Enable: []string{simpleObserver}},
+		Pre:       []api.TestStep{{Chain: &ipiConfAWS}},
+		Test:      []api.TestStep{{Reference: &ipiInstallInstall}, {LiteralTestStep: &api.LiteralTestStep{As: "literal"}}},
+		Post:      []api.TestStep{{Chain: &ipiDeprovision}},
+	}
+	expected := []string{"workflow/ipi", "observer/simple-observer", "chain/ipi-conf-aws", "reference/ipi-install-install", "chain/ipi-deprovision"}
+	if diff := cmp.Diff(expected, DirectUsages(test)); diff != "" {
+		t.Errorf("unexpected usages: %s", diff)
+	}
+	if usages := DirectUsages(nil); usages != nil {
+		t.Errorf("expected no usages without a multi-stage test, got %v", usages)
+	}
+
+	graph, err := NewGraph(referenceMap, chainMap, workflowMap, observerMap)
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	for _, tc := range []struct {
+		nodeType Type
+		name     string
+		expected bool
+	}{
+		{nodeType: Workflow, name: ipi, expected: true},
+		{nodeType: Observer, name: simpleObserver, expected: true},
+		{nodeType: Chain, name: ipiConfAWS, expected: true},
+		{nodeType: Reference, name: ipiInstallInstall, expected: true},
+		// only used through the ipi-install chain
+		{nodeType: Reference, name: ipiInstallRBAC},
+		{nodeType: Chain, name: ipiInstall},
+	} {
+		node, ok := graph.Lookup(tc.nodeType, tc.name)
+		if !ok {
+			t.Fatalf("%s %s not found", tc.nodeType, tc.name)
+		}
+		if actual := UsesNode(test, node); actual != tc.expected {
+			t.Errorf("expected %s %s to be used: %t, got %t", tc.nodeType, tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestParseType(t *testing.T) {
+	for _, nodeType := range []Type{Workflow, Chain, Reference, Observer} {
+		if parsed, err := ParseType(nodeType.String()); err != nil || parsed != nodeType {
+			t.Errorf("expected %s to be parsed, got %v, %v", nodeType, parsed, err)
+		}
+	}
+	if _, err := ParseType("step"); err == nil {
+		t.Error("expected an unknown type to be rejected")
+	}
+}
@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VersionSeparator separates the name of a registry element from its major
+// version, as in `ipi-install@v3`. Versions of an element are loaded side by
+// side, and the unversioned name refers to the latest version. Changes which
+// are not compatible with the users of an element are made in a new version,
+// so that users pinned to an older version are not affected by them.
+const VersionSeparator = "@"
+
+// SplitVersion returns the unversioned name of a registry element and its
+// version, which is empty for unversioned elements
+func SplitVersion(name string) (string, string) {
+	if i := strings.LastIndex(name, VersionSeparator); i != -1 {
+		return name[:i], name[i+len(VersionSeparator):]
+	}
+	return name, ""
+}
+
+// parseVersion returns the number of a major version like `v3`
+func parseVersion(version string) (int, error) {
+	if !strings.HasPrefix(version, "v") {
+		return 0, fmt.Errorf("version %q must be a major version like v1", version)
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil || number < 1 || strconv.Itoa(number) != strings.TrimPrefix(version, "v") {
+		return 0, fmt.Errorf("version %q must be a major version like v1", version)
+	}
+	return number, nil
+}
+
+// Versions returns the names of all versions of the element with the
+// unversioned name, from the oldest to the latest. Elements which are not
+// versioned have no versions.
+func Versions[M ~map[string]T, T any](elements M, name string) []string {
+	type version struct {
+		name   string
+		number int
+	}
+	var versions []version
+	for candidate := range elements {
+		base, v := SplitVersion(candidate)
+		if base != name || v == "" {
+			continue
+		}
+		number, err := parseVersion(v)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, version{name: candidate, number: number})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].number < versions[j].number })
+	var ret []string
+	for _, v := range versions {
+		ret = append(ret, v.name)
+	}
+	return ret
+}
+
+// latestVersions maps the unversioned names of versioned elements to the name
+// of their latest version
+func latestVersions[M ~map[string]T, T any](elements M) map[string]string {
+	latest := map[string]string{}
+	numbers := map[string]int{}
+	for name := range elements {
+		base, v := SplitVersion(name)
+		if v == "" {
+			continue
+		}
+		number, err := parseVersion(v)
+		if err != nil {
+			continue
+		}
+		if number > numbers[base] {
+			latest[base], numbers[base] = name, number
+		}
+	}
+	return latest
+}
+
+// resolveVersion returns the name of the element to use for a name, which is
+// the latest version when an unversioned name refers to a versioned element
+func resolveVersion(latest map[string]string, name string) string {
+	if versioned, ok := latest[name]; ok {
+		return versioned
+	}
+	return name
+}
+
+// validateVersions verifies that versions are well-formed and that elements are
+// either versioned or not
+func validateVersions[M ~map[string]T, T any](kind string, elements M) []error {
+	var errs []error
+	for name := range elements {
+		base, v := SplitVersion(name)
+		if v == "" {
+			continue
+		}
+		if _, err := parseVersion(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", kind, name, err))
+		}
+		if _, exists := elements[base]; exists {
+			errs = append(errs, fmt.Errorf("%s %s: %s %s must be versioned as well, as the unversioned name refers to the latest version", kind, name, kind, base))
+		}
+	}
+	return errs
+}
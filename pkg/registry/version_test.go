@@ -0,0 +1,161 @@
+package registry
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestVersions(t *testing.T) {
+	elements := ReferenceByName{
+		"install@v10":  {},
+		"install@v2":   {},
+		"install@v1":   {},
+		"install-rbac": {},
+		"other@v3":     {},
+	}
+	if diff := cmp.Diff([]string{"install@v1", "install@v2", "install@v10"}, Versions(elements, "install")); diff != "" {
+		t.Errorf("unexpected versions: %s", diff)
+	}
+	if versions := Versions(elements, "install-rbac"); versions != nil {
+		t.Errorf("expected no versions for an unversioned element, got %v", versions)
+	}
+	if diff := cmp.Diff(map[string]string{"install": "install@v10", "other": "other@v3"}, latestVersions(elements)); diff != "" {
+		t.Errorf("unexpected latest versions: %s", diff)
+	}
+	for name, expected := range map[string][2]string{
+		"install@v3":   {"install", "v3"},
+		"install":      {"install", ""},
+		"install-rbac": {"install-rbac", ""},
+	} {
+		if base, version := SplitVersion(name); base != expected[0] || version != expected[1] {
+			t.Errorf("%s: expected %v, got %s, %s", name, expected, base, version)
+		}
+	}
+}
+
+func TestValidateVersions(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		elements ReferenceByName
+		expected []string
+	}{{
+		name:     "versioned elements",
+		elements: ReferenceByName{"install@v1": {}, "install@v2": {}, "rbac": {}},
+	}, {
+		name:     "invalid versions",
+		elements: ReferenceByName{"install@3": {}, "rbac@v0": {}},
+		expected: []string{
+			`reference install@3: version "3" must be a major version like v1`,
+			`reference rbac@v0: version "v0" must be a major version like v1`,
+		},
+	}, {
+		name:     "element both versioned and not",
+		elements: ReferenceByName{"install@v1": {}, "install": {}},
+		expected: []string{
+			"reference install@v1: reference install must be versioned as well, as the unversioned name refers to the latest version",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual []string
+			for _, err := range validateVersions("reference", tc.elements) {
+				actual = append(actual, err.Error())
+			}
+			sort.Strings(actual)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected errors: %s", diff)
+			}
+		})
+	}
+}
+
+func TestResolveVersions(t *testing.T) {
+	installV1, installV2, install, setup, e2e := "install@v1", "install@v2", "install", "setup", "e2e"
+	references := ReferenceByName{
+		installV1: {As: installV1, Commands: "v1"},
+		installV2: {As: installV2, Commands: "v2"},
+	}
+	chains := ChainByName{"setup@v1": {As: "setup@v1", Steps: []api.TestStep{{Reference: &installV1}}, Parallel: true}}
+	workflows := WorkflowByName{"e2e@v1": {Pre: []api.TestStep{{Reference: &install}}}}
+	if err := Validate(references, chains, workflows, ObserverByName{}); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	resolver := NewResolver(references, chains, workflows, ObserverByName{})
+	for _, tc := range []struct {
+		name     string
+		config   api.MultiStageTestConfiguration
+		expected api.MultiStageTestConfigurationLiteral
+	}{{
+		name:     "pinned reference",
+		config:   api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: &installV1}}},
+		expected: api.MultiStageTestConfigurationLiteral{Test: []api.LiteralTestStep{{As: install, Commands: "v1"}}},
+	}, {
+		name:     "unversioned reference refers to the latest version",
+		config:   api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: &install}}},
+		expected: api.MultiStageTestConfigurationLiteral{Test: []api.LiteralTestStep{{As: install, Commands: "v2"}}},
+	}, {
+		name:     "unversioned chain refers to the latest version",
+		config:   api.MultiStageTestConfiguration{Test: []api.TestStep{{Chain: &setup}}},
+		expected: api.MultiStageTestConfigurationLiteral{Test: []api.LiteralTestStep{{As: install, Commands: "v1", ParallelGroup: setup}}},
+	}, {
+		name:     "unversioned workflow refers to the latest version",
+		config:   api.MultiStageTestConfiguration{Workflow: &e2e},
+		expected: api.MultiStageTestConfigurationLiteral{Pre: []api.LiteralTestStep{{As: install, Commands: "v2"}}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := resolver.Resolve("test", tc.config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected resolved test: %s", diff)
+			}
+		})
+	}
+	if _, err := resolver.Resolve("test", api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: &installV1}, {Reference: &installV2}}}); err == nil {
+		t.Error("expected an error when running two versions of a reference in a test")
+	}
+}
+
+func TestVersionedGraph(t *testing.T) {
+	installV1, install := "install@v1", "install"
+	references := ReferenceByName{"install@v1": {}, "install@v2": {}}
+	workflows := WorkflowByName{
+		"pinned": {Pre: []api.TestStep{{Reference: &installV1}}},
+		"latest": {Pre: []api.TestStep{{Reference: &install}}},
+	}
+	graph, err := NewGraph(references, ChainByName{}, workflows, ObserverByName{})
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	for name, expected := range map[string][]string{
+		"install@v1": {"install@v1"},
+		"install@v2": {"install@v2", "install"},
+	} {
+		if diff := cmp.Diff(expected, graph.References[name].Names()); diff != "" {
+			t.Errorf("%s: unexpected names: %s", name, diff)
+		}
+		var parents []string
+		for _, parent := range graph.References[name].Parents() {
+			parents = append(parents, parent.Name())
+		}
+		if expected := map[string]string{"install@v1": "pinned", "install@v2": "latest"}[name]; len(parents) != 1 || parents[0] != expected {
+			t.Errorf("%s: expected parent %s, got %v", name, expected, parents)
+		}
+	}
+	node, ok := graph.Lookup(Reference, install)
+	if !ok || node.Name() != "install@v2" {
+		t.Fatalf("expected %s to refer to the latest version, got %v", install, node)
+	}
+	pinned := &api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: &installV1}}}
+	latest := &api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: &install}}}
+	if UsesNode(pinned, node) || !UsesNode(latest, node) {
+		t.Error("expected only the test using the unversioned name to use the latest version")
+	}
+	if v1 := graph.References[installV1]; !UsesNode(pinned, v1) || UsesNode(latest, v1) {
+		t.Error("expected only the pinned test to use the first version")
+	}
+}
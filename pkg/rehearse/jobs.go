@@ -35,7 +35,6 @@ import (
 	"github.com/openshift/ci-tools/pkg/diffs"
 	"github.com/openshift/ci-tools/pkg/jobconfig"
 	"github.com/openshift/ci-tools/pkg/registry"
-	"github.com/openshift/ci-tools/pkg/util"
 	"github.com/openshift/ci-tools/pkg/util/gzip"
 )
 
@@ -385,6 +384,8 @@ type JobConfigurer struct {
 	prNumber              int
 	refs                  *pjapi.Refs
 	logger                *logrus.Entry
+	// tests are the tests of the ci-operator configs the configured jobs run, by the name of the job
+	tests map[string]api.TestStepConfiguration
 }
 
 // NewJobConfigurer filters the jobs and returns a new JobConfigurer.
@@ -398,6 +399,7 @@ func NewJobConfigurer(ciopConfigs config.DataByFilename, prowConfig *prowconfig.
 		prNumber:              prNumber,
 		refs:                  refs,
 		logger:                logger,
+		tests:                 map[string]api.TestStepConfiguration{},
 	}
 }
 
@@ -426,7 +428,7 @@ func (jc *JobConfigurer) ConfigurePeriodicRehearsals(periodics config.Periodics)
 		}
 		jc.configureDecorationConfig(&job.JobBase, metadata)
 		testname := metadata.TestNameFromJobName(job.Name, jobconfig.PeriodicPrefix)
-		imageStreamTags, err := jc.configureJobSpec(job.Spec, metadata, testname, jc.logger.WithField("name", job.Name))
+		imageStreamTags, err := jc.configureJobSpec(job.Name, job.Spec, metadata, testname, jc.logger.WithField("name", job.Name))
 		if err != nil {
 			jobLogger.WithError(err).Warn("Failed to inline ci-operator-config into rehearsal periodic job")
 			return nil, nil, err
@@ -473,7 +475,7 @@ func (jc *JobConfigurer) ConfigurePresubmitRehearsals(presubmits config.Presubmi
 			}
 			testname := metadata.TestNameFromJobName(job.Name, jobconfig.PresubmitPrefix)
 
-			imageStreamTags, err := jc.configureJobSpec(rehearsal.Spec, metadata, testname, jc.logger.WithField("name", job.Name))
+			imageStreamTags, err := jc.configureJobSpec(job.Name, rehearsal.Spec, metadata, testname, jc.logger.WithField("name", job.Name))
 			if err != nil {
 				jobLogger.WithError(err).Warn("Failed to inline ci-operator-config into rehearsal presubmit job")
 				return nil, nil, err
@@ -499,7 +501,7 @@ func (jc *JobConfigurer) configureDecorationConfig(job *prowconfig.JobBase, meta
 	job.DecorationConfig.GCSConfiguration.JobURLPrefix = determineJobURLPrefix(jc.prowConfig.Plank, metadata.Org, metadata.Repo)
 }
 
-func (jc *JobConfigurer) configureJobSpec(spec *v1.PodSpec, metadata api.Metadata, testName string, logger *logrus.Entry) (apihelper.ImageStreamTagMap, error) {
+func (jc *JobConfigurer) configureJobSpec(jobName string, spec *v1.PodSpec, metadata api.Metadata, testName string, logger *logrus.Entry) (apihelper.ImageStreamTagMap, error) {
 	// Remove configresolver flags from ci-operator jobs
 	var metadataFromFlags api.Metadata
 	if len(spec.Containers[0].Command) > 0 && spec.Containers[0].Command[0] == "ci-operator" {
@@ -511,6 +513,7 @@ func (jc *JobConfigurer) configureJobSpec(spec *v1.PodSpec, metadata api.Metadat
 	if metadata.IsComplete() != nil && metadataFromFlags.IsComplete() == nil {
 		metadata = metadataFromFlags
 	}
+	jc.recordTest(jobName, metadata, testName)
 
 	imageStreamTags, err := inlineCiOpConfig(&spec.Containers[0], jc.ciopConfigs, jc.registryResolver, metadata, testName, jc.logger)
 	if err != nil {
@@ -523,6 +526,23 @@ func (jc *JobConfigurer) configureJobSpec(spec *v1.PodSpec, metadata api.Metadat
 	return imageStreamTags, nil
 }
 
+// recordTest remembers the test of the ci-operator config the job runs, so we can tell what its rehearsal covers
+func (jc *JobConfigurer) recordTest(jobName string, metadata api.Metadata, testName string) {
+	if metadata.IsComplete() != nil {
+		return
+	}
+	ciopConfig, ok := jc.ciopConfigs[metadata.Basename()]
+	if !ok {
+		return
+	}
+	for _, test := range ciopConfig.Configuration.Tests {
+		if test.As == testName {
+			jc.tests[jobName] = test
+			return
+		}
+	}
+}
+
 // ConvertPeriodicsToPresubmits converts periodic jobs to presubmits by using the same JobBase and filling up
 // the rest of the presubmit's required fields.
 func (jc *JobConfigurer) ConvertPeriodicsToPresubmits(periodics []prowconfig.Periodic) ([]*prowconfig.Presubmit, error) {
@@ -629,25 +649,8 @@ func selectJobsForRegistryStep(node registry.Node, configs []*config.DataWithInf
 			// Workflows can have overridden logFields and thus may have overridden the field that made the workflow an ancestor.
 			// This should be handled to reduce the number of rehearsals being done, but requires much more information than
 			// the graph alone provides.
-			if node.Type() == registry.Workflow {
-				if test.MultiStageTestConfiguration.Workflow != nil && node.Name() == *test.MultiStageTestConfiguration.Workflow {
-					selectJob()
-				}
-				continue
-			}
-			if node.Type() == registry.Observer {
-				if testUsesObserver(test, node.Name()) {
-					selectJob()
-				}
-				continue
-			}
-			testSteps := append(test.MultiStageTestConfiguration.Pre, append(test.MultiStageTestConfiguration.Test, test.MultiStageTestConfiguration.Post...)...)
-			for _, testStep := range testSteps {
-				hasRef := testStep.Reference != nil && node.Type() == registry.Reference && node.Name() == *testStep.Reference
-				hasChain := testStep.Chain != nil && node.Type() == registry.Chain && node.Name() == *testStep.Chain
-				if hasRef || hasChain {
-					selectJob()
-				}
+			if registry.UsesNode(test.MultiStageTestConfiguration, node) {
+				selectJob()
 			}
 		}
 	}
@@ -658,13 +661,6 @@ func selectJobsForRegistryStep(node registry.Node, configs []*config.DataWithInf
 	return selectedPresubmits, selectedPeriodics
 }
 
-func testUsesObserver(test api.TestStepConfiguration, o string) bool {
-	t := test.MultiStageTestConfiguration
-	return t != nil &&
-		t.Observers != nil &&
-		util.Contains(t.Observers.Enable, o)
-}
-
 // getAffectedNodes returns a sorted list of all nodes affected by a seed list
 // of changed nodes. Affected node is either a directly changed node or any of
 // its ancestors. Each node is present at most once.
@@ -864,6 +860,8 @@ type Executor struct {
 	logger     *logrus.Entry
 	pjclient   ctrlruntimeclient.Client
 	namespace  string
+	// reporter, when set, is told about the rehearsals as they are submitted and as they finish
+	reporter ResultReporter
 	// Allow faking this in tests
 	pollFunc func(interval, timeout time.Duration, condition wait.ConditionFunc) error
 }
@@ -923,6 +921,13 @@ func (e *Executor) ExecuteJobs() (bool, error) {
 	for _, job := range pjs {
 		names.Insert(job.Name)
 	}
+	if e.reporter != nil && len(pjs) != 0 {
+		var submitted []pjapi.ProwJob
+		for _, job := range pjs {
+			submitted = append(submitted, *job)
+		}
+		e.reporter.Report(submitted)
+	}
 	waitSuccess, err := e.waitForJobs(names, selector)
 	if !submitSuccess {
 		return waitSuccess, fmt.Errorf("failed to submit all rehearsal jobs")
@@ -949,6 +954,12 @@ func (e *Executor) waitForJobs(jobs sets.Set[string], selector ctrlruntimeclient
 		// Reset the errors after a successful list
 		listErrors = nil
 
+		var finished []pjapi.ProwJob
+		defer func() {
+			if e.reporter != nil && len(finished) != 0 {
+				e.reporter.Report(finished)
+			}
+		}()
 		for _, pj := range result.Items {
 			fields := pjutil.ProwJobFields(&pj)
 			fields["state"] = pj.Status.State
@@ -967,6 +978,7 @@ func (e *Executor) waitForJobs(jobs sets.Set[string], selector ctrlruntimeclient
 				continue
 			}
 			jobs.Delete(pj.Name)
+			finished = append(finished, pj)
 			if jobs.Len() == 0 {
 				return true, nil
 			}
@@ -947,6 +947,44 @@ func TestWaitForJobsLog(t *testing.T) {
 	check(hook, "failure", logrus.InfoLevel, &failureState)
 }
 
+type recordingReporter struct {
+	reported [][]string
+}
+
+func (r *recordingReporter) Report(jobs []pjapi.ProwJob) {
+	var names []string
+	for _, job := range jobs {
+		names = append(names, job.Name)
+	}
+	sort.Strings(names)
+	r.reported = append(r.reported, names)
+}
+
+func TestWaitForJobsReports(t *testing.T) {
+	client := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(
+		&pjapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "success"},
+			Status:     pjapi.ProwJobStatus{State: pjapi.SuccessState}},
+		&pjapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "failure"},
+			Status:     pjapi.ProwJobStatus{State: pjapi.FailureState}},
+		&pjapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "pending"},
+			Status:     pjapi.ProwJobStatus{State: pjapi.PendingState}},
+	).Build()
+
+	reporter := &recordingReporter{}
+	executor := NewExecutor(nil, 0, "", &pjapi.Refs{}, true, logrus.NewEntry(logrus.New()), client, "")
+	executor.reporter = reporter
+	executor.pollFunc = threetimesTryingPoller
+	if _, err := executor.waitForJobs(sets.New[string]("success", "failure", "pending"), &ctrlruntimeclient.ListOptions{}); err == nil {
+		t.Fatal("expected the pending job to time out")
+	}
+	if diff := cmp.Diff([][]string{{"failure", "success"}}, reporter.reported); diff != "" {
+		t.Errorf("unexpected reports: %s", diff)
+	}
+}
+
 func TestFilterPresubmits(t *testing.T) {
 	canBeRehearsed := map[string]string{"pj-rehearse.openshift.io/can-be-rehearsed": "true"}
 
@@ -0,0 +1,412 @@
+package rehearse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowconfig "k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/plugins"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/config"
+)
+
+// otherBranches stands for the branches of a repo which have no branch protection policy of their own
+const otherBranches = "*"
+
+// BranchPolicy is how branch protection treats a branch
+type BranchPolicy struct {
+	Protected        bool
+	RequiredContexts sets.Set[string]
+}
+
+// OrgRepoPolicy is how Prow treats the pull requests of an org or of a repo
+type OrgRepoPolicy struct {
+	// MergeRequirements describe the Tide queries the pull requests can match to merge
+	MergeRequirements sets.Set[string]
+	MergeMethod       string
+	// Branches are the branch protection policies by branch, otherBranches for the branches without their own
+	Branches map[string]BranchPolicy
+	Plugins  sets.Set[string]
+}
+
+// OrgRepoImpact is how a change to the Prow configuration affects an org, or a repo
+type OrgRepoImpact struct {
+	// OrgRepo is "org" for the repos of the org without their own configuration, or "org/repo"
+	OrgRepo string
+	Changes []string
+	// LostProtection describes the required contexts the branches would no longer require
+	LostProtection []string
+}
+
+// ProwConfigImpact is how a change to the Prow configuration affects the orgs and repos
+type ProwConfigImpact struct {
+	OrgRepos []OrgRepoImpact
+}
+
+// DetermineProwConfigImpact compares the Prow, job and plugin configuration of the candidate to the
+// base revision. It returns nil when the change touches neither the Prow configuration nor the
+// presubmits branch protection derives required contexts from, or when it affects no org or repo.
+func (r RehearsalConfig) DetermineProwConfigImpact(candidate RehearsalCandidate, candidatePath string, logger *logrus.Entry) (*ProwConfigImpact, error) {
+	if r.NoProwConfig {
+		return nil, nil
+	}
+	changed, err := config.GetChangedProwConfigs(candidatePath, candidate.base.sha)
+	if err != nil {
+		return nil, fmt.Errorf("could not determine changed Prow configuration: %w", err)
+	}
+	if len(changed) == 0 {
+		changedJobs, err := config.GetChangedJobConfigs(candidatePath, candidate.base.sha)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine changed job configuration: %w", err)
+		}
+		if changed, err = changedPresubmitRequirements(candidatePath, candidate.base.sha, changedJobs); err != nil {
+			return nil, err
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	logger.WithField("files", changed).Info("Prow configuration changed")
+
+	candidateConfig, err := config.GetProwConfigs(candidatePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not load Prow configuration from candidate revision of release repo: %w", err)
+	}
+	baseConfig, err := config.GetProwConfigsFromSHA(candidatePath, candidate.base.sha)
+	if err != nil {
+		return nil, fmt.Errorf("could not load Prow configuration from base revision of release repo: %w", err)
+	}
+	impact, err := diffProwConfigs(baseConfig, candidateConfig)
+	if err != nil || len(impact.OrgRepos) == 0 {
+		return nil, err
+	}
+	return impact, nil
+}
+
+// changedPresubmitRequirements returns the job files among the changed ones in which the presubmits
+// change the way branch protection considers them, so that only those need the whole configuration
+// loaded to determine the impact
+func changedPresubmitRequirements(candidatePath, baseSHA string, changedJobs []string) ([]string, error) {
+	var changed []string
+	for _, file := range changedJobs {
+		before, _, err := config.FileAtRevision(candidatePath, baseSHA, file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s in the base revision: %w", file, err)
+		}
+		after, err := os.ReadFile(filepath.Join(candidatePath, file))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("could not read %s: %w", file, err)
+		}
+		was, err := presubmitRequirements(before)
+		if err != nil {
+			return nil, fmt.Errorf("could not load %s in the base revision: %w", file, err)
+		}
+		is, err := presubmitRequirements(after)
+		if err != nil {
+			return nil, fmt.Errorf("could not load %s: %w", file, err)
+		}
+		if !was.Equal(is) {
+			changed = append(changed, file)
+		}
+	}
+	return changed, nil
+}
+
+// presubmitRequirements describes what branch protection considers of the presubmits in a job file:
+// the contexts they report, for which branches, whether they are required and how they are triggered
+func presubmitRequirements(raw []byte) (sets.Set[string], error) {
+	requirements := sets.New[string]()
+	var jobs prowconfig.JobConfig
+	if err := yaml.Unmarshal(raw, &jobs); err != nil {
+		return nil, err
+	}
+	for orgRepo, presubmits := range jobs.PresubmitsStatic {
+		for _, presubmit := range presubmits {
+			context := presubmit.Context
+			if context == "" {
+				context = presubmit.Name
+			}
+			requirements.Insert(fmt.Sprintf("%s %s branches=%v skip_branches=%v optional=%t skip_report=%t always_run=%t run_if_changed=%q skip_if_only_changed=%q",
+				orgRepo, context, presubmit.Branches, presubmit.SkipBranches, presubmit.Optional, presubmit.SkipReport,
+				presubmit.AlwaysRun, presubmit.RunIfChanged, presubmit.SkipIfOnlyChanged))
+		}
+	}
+	return requirements, nil
+}
+
+// diffProwConfigs determines the effective difference between the configurations for every org
+// and repo either of them configures
+func diffProwConfigs(base, candidate *config.ProwConfigs) (*ProwConfigImpact, error) {
+	impact := &ProwConfigImpact{}
+	for _, orgRepo := range sets.List(configuredOrgRepos(base).Union(configuredOrgRepos(candidate))) {
+		org, repo, _ := strings.Cut(orgRepo, "/")
+		branches := sets.New[string](otherBranches).Union(protectedBranches(base.Prow, org, repo)).Union(protectedBranches(candidate.Prow, org, repo))
+		branches = branches.Union(presubmitBranches(base.Prow, org, repo)).Union(presubmitBranches(candidate.Prow, org, repo))
+		before, err := policyFor(base, org, repo, branches)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine the policy for %s in the base revision: %w", orgRepo, err)
+		}
+		after, err := policyFor(candidate, org, repo, branches)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine the policy for %s in the candidate revision: %w", orgRepo, err)
+		}
+		if orgRepoImpact := diffPolicies(orgRepo, before, after); len(orgRepoImpact.Changes) > 0 {
+			impact.OrgRepos = append(impact.OrgRepos, orgRepoImpact)
+		}
+	}
+	return impact, nil
+}
+
+// configuredOrgRepos returns the orgs and repos the Tide, branch protection and plugin configuration mention,
+// and the repos of the orgs with branch protection which have presubmits
+func configuredOrgRepos(cfg *config.ProwConfigs) sets.Set[string] {
+	orgRepos := sets.New[string]()
+	for _, query := range cfg.Prow.Tide.Queries {
+		orgRepos.Insert(query.Orgs...)
+		orgRepos.Insert(query.Repos...)
+		orgRepos.Insert(query.ExcludedRepos...)
+	}
+	for key, orgMergeType := range cfg.Prow.Tide.MergeType {
+		key, _, _ = strings.Cut(key, "@")
+		orgRepos.Insert(key)
+		for repo := range orgMergeType.Repos {
+			if repo != "*" {
+				orgRepos.Insert(key + "/" + repo)
+			}
+		}
+	}
+	for org, orgPolicy := range cfg.Prow.BranchProtection.Orgs {
+		orgRepos.Insert(org)
+		for repo := range orgPolicy.Repos {
+			orgRepos.Insert(org + "/" + repo)
+		}
+	}
+	for orgRepo := range cfg.Prow.PresubmitsStatic {
+		org, _, _ := strings.Cut(orgRepo, "/")
+		if _, ok := cfg.Prow.BranchProtection.Orgs[org]; ok {
+			orgRepos.Insert(orgRepo)
+		}
+	}
+	for key, orgPlugins := range cfg.Plugins.Plugins {
+		orgRepos.Insert(key)
+		for _, repo := range orgPlugins.ExcludedRepos {
+			orgRepos.Insert(key + "/" + repo)
+		}
+	}
+	return orgRepos
+}
+
+// protectedBranches returns the branches of the repo with their own branch protection policy
+func protectedBranches(cfg *prowconfig.Config, org, repo string) sets.Set[string] {
+	branches := sets.New[string]()
+	if repo == "" {
+		return branches
+	}
+	for branch := range cfg.BranchProtection.Orgs[org].Repos[repo].Branches {
+		branches.Insert(branch)
+	}
+	return branches
+}
+
+// presubmitBranches returns the branches the presubmits of the repo name literally, like ^release-4\.14$
+func presubmitBranches(cfg *prowconfig.Config, org, repo string) sets.Set[string] {
+	branches := sets.New[string]()
+	if repo == "" {
+		return branches
+	}
+	for _, presubmit := range cfg.PresubmitsStatic[org+"/"+repo] {
+		for _, branch := range presubmit.Branches {
+			literal := strings.TrimSuffix(strings.TrimPrefix(branch, "^"), "$")
+			name := strings.ReplaceAll(literal, `\`, "")
+			// the dots in branch names are not always escaped
+			if quoted := regexp.QuoteMeta(name); quoted == literal || strings.ReplaceAll(quoted, `\.`, ".") == literal {
+				branches.Insert(name)
+			}
+		}
+	}
+	return branches
+}
+
+// policyFor determines how the configuration treats the pull requests of the repo, or of the repos
+// of the org without their own configuration when the repo is empty. Like the branch protector, it
+// requires the contexts of the presubmits of the repo which must pass for its branches.
+func policyFor(cfg *config.ProwConfigs, org, repo string, branches sets.Set[string]) (OrgRepoPolicy, error) {
+	orgRepo := prowconfig.OrgRepo{Org: org, Repo: repo}
+	policy := OrgRepoPolicy{
+		MergeRequirements: sets.New[string](),
+		MergeMethod:       string(cfg.Prow.Tide.MergeMethod(orgRepo)),
+		Branches:          map[string]BranchPolicy{},
+		Plugins:           enabledPlugins(cfg.Plugins, org, repo),
+	}
+	for _, query := range cfg.Prow.Tide.Queries {
+		if query.ForRepo(orgRepo) {
+			policy.MergeRequirements.Insert(describeQuery(query))
+		}
+	}
+	var presubmits []prowconfig.Presubmit
+	if repo != "" {
+		presubmits = cfg.Prow.PresubmitsStatic[org+"/"+repo]
+	}
+	for _, branch := range sets.List(branches) {
+		branchPolicy, err := cfg.Prow.GetBranchProtection(org, repo, branch, presubmits)
+		if err != nil {
+			return OrgRepoPolicy{}, fmt.Errorf("could not determine the branch protection of %s: %w", branch, err)
+		}
+		effective := BranchPolicy{RequiredContexts: sets.New[string]()}
+		if branchPolicy != nil && (branchPolicy.Unmanaged == nil || !*branchPolicy.Unmanaged) && branchPolicy.Protect != nil && *branchPolicy.Protect {
+			effective.Protected = true
+			if branchPolicy.RequiredStatusChecks != nil {
+				effective.RequiredContexts.Insert(branchPolicy.RequiredStatusChecks.Contexts...)
+			}
+		}
+		policy.Branches[branch] = effective
+	}
+	return policy, nil
+}
+
+// enabledPlugins returns the plugins enabled for the repo the same way the hook does,
+// or the plugins enabled for the org when the repo is empty
+func enabledPlugins(cfg *plugins.Configuration, org, repo string) sets.Set[string] {
+	enabled := sets.New[string]()
+	if !sets.New[string](cfg.Plugins[org].ExcludedRepos...).Has(repo) {
+		enabled.Insert(cfg.Plugins[org].Plugins...)
+	}
+	if repo != "" {
+		enabled.Insert(cfg.Plugins[org+"/"+repo].Plugins...)
+	}
+	return enabled
+}
+
+// describeQuery summarizes what a pull request needs to match the Tide query
+func describeQuery(query prowconfig.TideQuery) string {
+	var parts []string
+	list := func(kind string, items []string) {
+		if len(items) > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %s", kind, strings.Join(sets.List(sets.New[string](items...)), ", ")))
+		}
+	}
+	list("labels", query.Labels)
+	list("missing labels", query.MissingLabels)
+	if query.Author != "" {
+		parts = append(parts, "author: "+query.Author)
+	}
+	if query.Milestone != "" {
+		parts = append(parts, "milestone: "+query.Milestone)
+	}
+	if query.ReviewApprovedRequired {
+		parts = append(parts, "approved review")
+	}
+	list("branches", query.IncludedBranches)
+	list("excluded branches", query.ExcludedBranches)
+	if len(parts) == 0 {
+		return "any pull request"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func diffPolicies(orgRepo string, before, after OrgRepoPolicy) OrgRepoImpact {
+	impact := OrgRepoImpact{OrgRepo: orgRepo}
+	change := func(format string, args ...interface{}) {
+		impact.Changes = append(impact.Changes, fmt.Sprintf(format, args...))
+	}
+
+	if before.MergeRequirements.Len() > 0 && after.MergeRequirements.Len() == 0 {
+		change("pull requests would no longer be merged by Tide")
+	}
+	for _, query := range sets.List(before.MergeRequirements.Difference(after.MergeRequirements)) {
+		change("Tide query would no longer apply: %s", query)
+	}
+	for _, query := range sets.List(after.MergeRequirements.Difference(before.MergeRequirements)) {
+		change("Tide query would apply: %s", query)
+	}
+	if before.MergeMethod != after.MergeMethod {
+		change("merge method would change from %s to %s", before.MergeMethod, after.MergeMethod)
+	}
+
+	var branches []string
+	for branch := range before.Branches {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+	for _, branch := range branches {
+		was, is := before.Branches[branch], after.Branches[branch]
+		name := describeBranch(branch)
+		switch {
+		case was.Protected && !is.Protected:
+			change("%s would no longer be protected", name)
+			impact.LostProtection = append(impact.LostProtection, fmt.Sprintf("%s would no longer be protected", name))
+		case !was.Protected && is.Protected:
+			change("%s would be protected", name)
+		}
+		if lost := was.RequiredContexts.Difference(is.RequiredContexts); lost.Len() > 0 {
+			change("%s would no longer require %s", name, formatList(sets.List(lost)))
+			if is.Protected {
+				impact.LostProtection = append(impact.LostProtection, fmt.Sprintf("%s would no longer require %s", name, formatList(sets.List(lost))))
+			}
+		}
+		if added := is.RequiredContexts.Difference(was.RequiredContexts); added.Len() > 0 {
+			change("%s would require %s", name, formatList(sets.List(added)))
+		}
+	}
+
+	if disabled := before.Plugins.Difference(after.Plugins); disabled.Len() > 0 {
+		change("plugins would be disabled: %s", formatList(sets.List(disabled)))
+	}
+	if enabled := after.Plugins.Difference(before.Plugins); enabled.Len() > 0 {
+		change("plugins would be enabled: %s", formatList(sets.List(enabled)))
+	}
+	return impact
+}
+
+func describeBranch(branch string) string {
+	if branch == otherBranches {
+		return "branches without their own policy"
+	}
+	return fmt.Sprintf("branch `%s`", branch)
+}
+
+func formatList(items []string) string {
+	quoted := make([]string, 0, len(items))
+	for _, item := range items {
+		quoted = append(quoted, fmt.Sprintf("`%s`", item))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// Render formats the impact as Markdown, flagging the orgs and repos which would lose
+// required-context protection first
+func (i ProwConfigImpact) Render() string {
+	if len(i.OrgRepos) == 0 {
+		return "Changes to the Prow configuration do not affect the merge requirements, required contexts or plugins of any org or repo."
+	}
+	lines := []string{fmt.Sprintf("Changes to the Prow configuration affect %d orgs and repos.", len(i.OrgRepos))}
+	var flagged []string
+	for _, orgRepo := range i.OrgRepos {
+		for _, lost := range orgRepo.LostProtection {
+			flagged = append(flagged, fmt.Sprintf("- **%s**: %s", orgRepo.OrgRepo, lost))
+		}
+	}
+	if len(flagged) > 0 {
+		lines = append(lines, "", ":warning: The following would lose required-context protection:")
+		lines = append(lines, flagged...)
+	}
+	for _, orgRepo := range i.OrgRepos {
+		heading := orgRepo.OrgRepo
+		if !strings.Contains(heading, "/") {
+			heading += " (repos without their own configuration)"
+		}
+		lines = append(lines, "", "#### "+heading)
+		for _, change := range orgRepo.Changes {
+			lines = append(lines, "- "+change)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,302 @@
+package rehearse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowconfig "k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/plugins"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/config"
+)
+
+func prowConfigsFrom(t *testing.T, prowYAML, jobYAML, pluginsYAML string) *config.ProwConfigs {
+	t.Helper()
+	configs := &config.ProwConfigs{Prow: &prowconfig.Config{}, Plugins: &plugins.Configuration{}}
+	if err := yaml.Unmarshal([]byte(prowYAML), &configs.Prow.ProwConfig); err != nil {
+		t.Fatalf("failed to unmarshal Prow config: %v", err)
+	}
+	if err := yaml.Unmarshal([]byte(jobYAML), &configs.Prow.JobConfig); err != nil {
+		t.Fatalf("failed to unmarshal job config: %v", err)
+	}
+	if err := configs.Prow.SetPresubmits(configs.Prow.PresubmitsStatic); err != nil {
+		t.Fatalf("failed to set presubmits: %v", err)
+	}
+	if err := yaml.Unmarshal([]byte(pluginsYAML), configs.Plugins); err != nil {
+		t.Fatalf("failed to unmarshal plugin config: %v", err)
+	}
+	return configs
+}
+
+const baseProwConfig = `tide:
+  queries:
+  - repos: [org/repo]
+    labels: [lgtm, approved]
+    missingLabels: [do-not-merge/hold]
+  - orgs: [other]
+    labels: [lgtm]
+branch-protection:
+  protect-tested-repos: true
+  orgs:
+    org:
+      repos:
+        repo:
+          protect: true
+          required_status_checks:
+            contexts: [ci/prow/unit]
+          branches:
+            release:
+              protect: true
+              required_status_checks:
+                contexts: [ci/prow/e2e]
+        lint:
+          protect: true
+          required_status_checks:
+            contexts: [ci/prow/lint]
+`
+
+const baseJobConfig = `presubmits:
+  org/tested:
+  - name: pull-ci-org-tested-release-4.14-images
+    context: ci/prow/images
+    always_run: true
+    branches: [^release-4\.14$]
+  - name: pull-ci-org-tested-release-4.14-lint
+    context: ci/prow/lint
+    always_run: true
+    optional: true
+    branches: [^release-4\.14$]
+`
+
+const basePluginConfig = `plugins:
+  org:
+    plugins: [lgtm]
+`
+
+func TestDiffProwConfigs(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		prowConfig       string
+		jobConfig        string
+		pluginConfig     string
+		expected         *ProwConfigImpact
+		expectedRendered string
+	}{{
+		name:             "no changes",
+		prowConfig:       baseProwConfig,
+		jobConfig:        baseJobConfig,
+		pluginConfig:     basePluginConfig,
+		expected:         &ProwConfigImpact{},
+		expectedRendered: "Changes to the Prow configuration do not affect the merge requirements, required contexts or plugins of any org or repo.",
+	}, {
+		name: "changes to merge requirements, required contexts and plugins",
+		prowConfig: `tide:
+  merge_method:
+    org/repo: squash
+  queries:
+  - repos: [org/repo]
+    labels: [lgtm]
+    missingLabels: [do-not-merge/hold]
+branch-protection:
+  protect-tested-repos: true
+  orgs:
+    org:
+      repos:
+        repo:
+          protect: true
+          required_status_checks:
+            contexts: [ci/prow/unit]
+          branches:
+            release:
+              protect: true
+        lint:
+          protect: false
+`,
+		jobConfig: baseJobConfig,
+		pluginConfig: `plugins:
+  org:
+    plugins: [lgtm]
+  org/repo:
+    plugins: [approve]
+`,
+		expected: &ProwConfigImpact{OrgRepos: []OrgRepoImpact{{
+			OrgRepo: "org/lint",
+			Changes: []string{
+				"branches without their own policy would no longer be protected",
+				"branches without their own policy would no longer require `ci/prow/lint`",
+			},
+			LostProtection: []string{"branches without their own policy would no longer be protected"},
+		}, {
+			OrgRepo: "org/repo",
+			Changes: []string{
+				"Tide query would no longer apply: labels: approved, lgtm; missing labels: do-not-merge/hold",
+				"Tide query would apply: labels: lgtm; missing labels: do-not-merge/hold",
+				"merge method would change from merge to squash",
+				"branch `release` would no longer require `ci/prow/e2e`",
+				"plugins would be enabled: `approve`",
+			},
+			LostProtection: []string{"branch `release` would no longer require `ci/prow/e2e`"},
+		}, {
+			OrgRepo: "other",
+			Changes: []string{
+				"pull requests would no longer be merged by Tide",
+				"Tide query would no longer apply: labels: lgtm",
+			},
+		}}},
+		expectedRendered: "Changes to the Prow configuration affect 3 orgs and repos." + `
+
+:warning: The following would lose required-context protection:
+- **org/lint**: branches without their own policy would no longer be protected
+- **org/repo**: branch ` + "`release`" + ` would no longer require ` + "`ci/prow/e2e`" + `
+
+#### org/lint
+- branches without their own policy would no longer be protected
+- branches without their own policy would no longer require ` + "`ci/prow/lint`" + `
+
+#### org/repo
+- Tide query would no longer apply: labels: approved, lgtm; missing labels: do-not-merge/hold
+- Tide query would apply: labels: lgtm; missing labels: do-not-merge/hold
+- merge method would change from merge to squash
+- branch ` + "`release`" + ` would no longer require ` + "`ci/prow/e2e`" + `
+- plugins would be enabled: ` + "`approve`" + `
+
+#### other (repos without their own configuration)
+- pull requests would no longer be merged by Tide
+- Tide query would no longer apply: labels: lgtm`,
+	}, {
+		name:       "required presubmit becomes optional",
+		prowConfig: baseProwConfig,
+		jobConfig: `presubmits:
+  org/tested:
+  - name: pull-ci-org-tested-release-4.14-images
+    context: ci/prow/images
+    always_run: true
+    optional: true
+    branches: [^release-4\.14$]
+  - name: pull-ci-org-tested-release-4.14-lint
+    context: ci/prow/lint
+    always_run: true
+    optional: true
+    branches: [^release-4\.14$]
+`,
+		pluginConfig: basePluginConfig,
+		expected: &ProwConfigImpact{OrgRepos: []OrgRepoImpact{{
+			OrgRepo: "org/tested",
+			Changes: []string{
+				"branch `release-4.14` would no longer be protected",
+				"branch `release-4.14` would no longer require `ci/prow/images`",
+			},
+			LostProtection: []string{"branch `release-4.14` would no longer be protected"},
+		}}},
+		expectedRendered: "Changes to the Prow configuration affect 1 orgs and repos." + `
+
+:warning: The following would lose required-context protection:
+- **org/tested**: branch ` + "`release-4.14`" + ` would no longer be protected
+
+#### org/tested
+- branch ` + "`release-4.14`" + ` would no longer be protected
+- branch ` + "`release-4.14`" + ` would no longer require ` + "`ci/prow/images`",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			base := prowConfigsFrom(t, baseProwConfig, baseJobConfig, basePluginConfig)
+			candidate := prowConfigsFrom(t, tc.prowConfig, tc.jobConfig, tc.pluginConfig)
+			impact, err := diffProwConfigs(base, candidate)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, impact); diff != "" {
+				t.Errorf("unexpected impact: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedRendered, impact.Render()); diff != "" {
+				t.Errorf("unexpected rendered impact: %s", diff)
+			}
+		})
+	}
+}
+
+func TestDescribeQuery(t *testing.T) {
+	for _, tc := range []struct {
+		query    prowconfig.TideQuery
+		expected string
+	}{
+		{expected: "any pull request"},
+		{
+			query: prowconfig.TideQuery{
+				Labels:                 []string{"lgtm", "approved"},
+				Author:                 "bot",
+				Milestone:              "v1",
+				ReviewApprovedRequired: true,
+				ExcludedBranches:       []string{"release"},
+			},
+			expected: "labels: approved, lgtm; author: bot; milestone: v1; approved review; excluded branches: release",
+		},
+	} {
+		if actual := describeQuery(tc.query); actual != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, actual)
+		}
+	}
+}
+
+func TestPresubmitRequirements(t *testing.T) {
+	const base = `presubmits:
+  org/repo:
+  - name: pull-ci-org-repo-master-unit
+    context: ci/prow/unit
+    always_run: true
+    branches: [^master$]
+    labels:
+      ci.openshift.io/generator: prowgen
+`
+	for _, tc := range []struct {
+		name     string
+		jobs     string
+		expected bool
+	}{{
+		name: "labels change",
+		jobs: `presubmits:
+  org/repo:
+  - name: pull-ci-org-repo-master-unit
+    context: ci/prow/unit
+    always_run: true
+    branches: [^master$]
+`,
+		expected: true,
+	}, {
+		name: "required presubmit becomes optional",
+		jobs: `presubmits:
+  org/repo:
+  - name: pull-ci-org-repo-master-unit
+    context: ci/prow/unit
+    always_run: true
+    optional: true
+    branches: [^master$]
+`,
+	}, {
+		name: "presubmit runs conditionally",
+		jobs: `presubmits:
+  org/repo:
+  - name: pull-ci-org-repo-master-unit
+    context: ci/prow/unit
+    run_if_changed: ^pkg/
+    branches: [^master$]
+`,
+	}, {
+		name: "file removed",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			before, err := presubmitRequirements([]byte(base))
+			if err != nil {
+				t.Fatalf("failed to load the base jobs: %v", err)
+			}
+			after, err := presubmitRequirements([]byte(tc.jobs))
+			if err != nil {
+				t.Fatalf("failed to load the jobs: %v", err)
+			}
+			if actual := before.Equal(after); actual != tc.expected {
+				t.Errorf("expected the requirements to be equal: %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
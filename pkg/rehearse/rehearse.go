@@ -56,11 +56,17 @@ type RehearsalConfig struct {
 	NoTemplates       bool
 	NoRegistry        bool
 	NoClusterProfiles bool
+	NoProwConfig      bool
 
 	NormalLimit int
 	MoreLimit   int
 	MaxLimit    int
 
+	// Selection is the strategy to select the rehearsals with when more jobs are affected than the limit
+	Selection string
+	// JobHistoryPath is the path to the recent signal of the jobs, used by the coverage selection
+	JobHistoryPath string
+
 	StickyLabelAuthors sets.Set[string]
 
 	GCSBucket          string
@@ -193,7 +199,7 @@ func (r RehearsalConfig) DetermineAffectedJobs(candidate RehearsalCandidate, can
 }
 
 func (r RehearsalConfig) SetupJobs(candidate RehearsalCandidate, candidatePath string, presubmits config.Presubmits, periodics config.Periodics, rehearsalTemplates, rehearsalClusterProfiles *ConfigMaps, limit int, logger *logrus.Entry) (*config.ReleaseRepoConfig, *pjapi.Refs, apihelper.ImageStreamTagMap, []*prowconfig.Presubmit, error) {
-	resolver, err := r.createResolver(candidatePath)
+	resolver, graph, err := r.createResolver(candidatePath)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
@@ -233,7 +239,7 @@ func (r RehearsalConfig) SetupJobs(candidate RehearsalCandidate, candidatePath s
 			"rehearsal-jobs":      rehearsals,
 		}
 		logger.WithFields(jobCountFields).Info("Would rehearse too many jobs, selecting a subset")
-		presubmitsToRehearse = determineSubsetToRehearse(presubmitsToRehearse, limit)
+		presubmitsToRehearse = r.selectRehearsals(presubmitsToRehearse, prNumber, jobConfigurer, graph, limit, logger)
 	}
 
 	if prConfig.Prow.JobConfig.PresubmitsStatic == nil {
@@ -246,7 +252,7 @@ func (r RehearsalConfig) SetupJobs(candidate RehearsalCandidate, candidatePath s
 	return prConfig, prRefs, imageStreamTags, presubmitsToRehearse, nil
 }
 
-func (r RehearsalConfig) createResolver(candidatePath string) (registry.Resolver, error) {
+func (r RehearsalConfig) createResolver(candidatePath string) (registry.Resolver, registry.NodeByName, error) {
 	var registryRefs registry.ReferenceByName
 	var chains registry.ChainByName
 	var workflows registry.WorkflowByName
@@ -255,11 +261,40 @@ func (r RehearsalConfig) createResolver(candidatePath string) (registry.Resolver
 		var err error
 		registryRefs, chains, workflows, _, _, observers, err = load.Registry(filepath.Join(candidatePath, config.RegistryPath), load.RegistryFlag(0))
 		if err != nil {
-			return nil, fmt.Errorf("could not load step registry: %w", err)
+			return nil, registry.NodeByName{}, fmt.Errorf("could not load step registry: %w", err)
 		}
 	}
 	resolver := registry.NewResolver(registryRefs, chains, workflows, observers)
-	return resolver, nil
+	var graph registry.NodeByName
+	if r.Selection == SelectionCoverage {
+		var err error
+		graph, err = registry.NewGraph(registryRefs, chains, workflows, observers)
+		if err != nil {
+			return nil, registry.NodeByName{}, fmt.Errorf("could not create step registry graph: %w", err)
+		}
+	}
+	return resolver, graph, nil
+}
+
+// selectRehearsals selects up to limit rehearsals with the configured strategy
+func (r RehearsalConfig) selectRehearsals(presubmitsToRehearse []*prowconfig.Presubmit, prNumber int, jobConfigurer *JobConfigurer, graph registry.NodeByName, limit int, logger *logrus.Entry) []*prowconfig.Presubmit {
+	switch r.Selection {
+	case SelectionCoverage:
+		history := JobHistory{}
+		if r.JobHistoryPath != "" {
+			var err error
+			if history, err = LoadJobHistory(r.JobHistoryPath); err != nil {
+				logger.WithError(err).Warn("Could not load the job history, selecting the rehearsals by their coverage only")
+			}
+		}
+		selected := selectByCoverage(presubmitsToRehearse, prNumber, jobConfigurer.coverage(graph), history, limit)
+		for _, job := range selected {
+			logger.WithFields(logrus.Fields{logRehearsalJob: job.Name, diffs.LogReasons: job.Annotations[AnnotationReason]}).Info("Selected the job for rehearsal")
+		}
+		return selected
+	default:
+		return determineSubsetToRehearse(presubmitsToRehearse, limit)
+	}
 }
 
 func (r RehearsalConfig) AbortAllRehearsalJobs(org, repo string, number int, logger *logrus.Entry) {
@@ -307,8 +342,9 @@ func labelSelectorForRehearsalJobs(org, repo string, prNumber int) ctrlruntimecl
 	}
 }
 
-// RehearseJobs returns true if the jobs were triggered and succeed
-func (r RehearsalConfig) RehearseJobs(candidate RehearsalCandidate, candidatePath string, prRefs *pjapi.Refs, imageStreamTags apihelper.ImageStreamTagMap, presubmitsToRehearse []*prowconfig.Presubmit, rehearsalTemplates, rehearsalClusterProfiles *ConfigMaps, logger *logrus.Entry) (bool, error) {
+// RehearseJobs returns true if the jobs were triggered and succeed. The reporter, when
+// set, is told about the rehearsals as they are submitted and as they finish.
+func (r RehearsalConfig) RehearseJobs(candidate RehearsalCandidate, candidatePath string, prRefs *pjapi.Refs, imageStreamTags apihelper.ImageStreamTagMap, presubmitsToRehearse []*prowconfig.Presubmit, rehearsalTemplates, rehearsalClusterProfiles *ConfigMaps, reporter ResultReporter, logger *logrus.Entry) (bool, error) {
 	buildClusterConfigs, prowJobConfig := r.getBuildClusterAndProwJobConfigs(logger)
 	pjclient, err := NewProwJobClient(prowJobConfig, r.DryRun)
 	if err != nil {
@@ -344,6 +380,7 @@ func (r RehearsalConfig) RehearseJobs(candidate RehearsalCandidate, candidatePat
 	}
 
 	executor := NewExecutor(presubmitsToRehearse, candidate.prNumber, candidatePath, prRefs, r.DryRun, logger, pjclient, r.ProwjobNamespace)
+	executor.reporter = reporter
 	success, err := executor.ExecuteJobs()
 	if err != nil {
 		logger.WithError(err).Error("Failed to rehearse jobs")
@@ -0,0 +1,259 @@
+package rehearse
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowconfig "k8s.io/test-infra/prow/config"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+const (
+	// SelectionSubset selects the rehearsals evenly across the reasons the jobs were affected
+	SelectionSubset = "subset"
+	// SelectionCoverage selects the rehearsals which cover the most of the affected content,
+	// preferring jobs which pass reliably
+	SelectionCoverage = "coverage"
+
+	// AnnotationReason explains why a rehearsal was selected
+	AnnotationReason = "ci.openshift.io/rehearse.reason"
+)
+
+// Selections are the strategies to select a subset of the rehearsals with
+var Selections = sets.New[string](SelectionSubset, SelectionCoverage)
+
+// JobCoverage is the content of the release repository a rehearsal exercises
+type JobCoverage struct {
+	ClusterProfile string
+	Workflow       string
+	// StepPaths are the paths through the step registry to the steps
+	// the job runs, like "workflow/chain/reference"
+	StepPaths sets.Set[string]
+}
+
+type coverageItem struct {
+	kind, name string
+}
+
+func (c JobCoverage) items() []coverageItem {
+	var items []coverageItem
+	if c.ClusterProfile != "" {
+		items = append(items, coverageItem{kind: "cluster profile", name: c.ClusterProfile})
+	}
+	if c.Workflow != "" {
+		items = append(items, coverageItem{kind: "workflow", name: c.Workflow})
+	}
+	for _, path := range sets.List(c.StepPaths) {
+		items = append(items, coverageItem{kind: "step path", name: path})
+	}
+	return items
+}
+
+// coverageForTest determines what running the test exercises, using the step registry
+// graph to find the paths to the steps of its workflow and of its chains. Literal tests
+// no longer know the workflow and chains their steps came from, so the paths to their
+// steps are the names of the references they run.
+func coverageForTest(test api.TestStepConfiguration, graph registry.NodeByName) JobCoverage {
+	coverage := JobCoverage{StepPaths: sets.New[string]()}
+	var walk func(node registry.Node, prefix string)
+	walk = func(node registry.Node, prefix string) {
+		path := node.Name()
+		if prefix != "" {
+			path = prefix + "/" + path
+		}
+		children := node.Children()
+		if len(children) == 0 {
+			coverage.StepPaths.Insert(path)
+		}
+		for _, child := range children {
+			walk(child, path)
+		}
+	}
+	walkSteps := func(steps []api.TestStep) {
+		for _, step := range steps {
+			switch {
+			case step.Reference != nil:
+				if node, ok := graph.Lookup(registry.Reference, *step.Reference); ok {
+					walk(node, "")
+				}
+			case step.Chain != nil:
+				if node, ok := graph.Lookup(registry.Chain, *step.Chain); ok {
+					walk(node, "")
+				}
+			}
+		}
+	}
+
+	switch {
+	case test.MultiStageTestConfiguration != nil:
+		ms := test.MultiStageTestConfiguration
+		coverage.ClusterProfile = string(ms.ClusterProfile)
+		if ms.Workflow != nil {
+			coverage.Workflow = *ms.Workflow
+			if node, ok := graph.Lookup(registry.Workflow, *ms.Workflow); ok {
+				coverage.Workflow = node.Name()
+				walk(node, "")
+			}
+		}
+		walkSteps(ms.Pre)
+		walkSteps(ms.Test)
+		walkSteps(ms.Post)
+	case test.MultiStageTestConfigurationLiteral != nil:
+		ms := test.MultiStageTestConfigurationLiteral
+		coverage.ClusterProfile = string(ms.ClusterProfile)
+		for _, steps := range [][]api.LiteralTestStep{ms.Pre, ms.Test, ms.Post} {
+			for _, step := range steps {
+				if node, ok := graph.Lookup(registry.Reference, step.As); ok {
+					walk(node, "")
+				}
+			}
+		}
+	}
+	return coverage
+}
+
+// coverage determines what the rehearsals of the configured jobs cover, by the name of the job
+func (jc *JobConfigurer) coverage(graph registry.NodeByName) map[string]JobCoverage {
+	coverage := map[string]JobCoverage{}
+	for name, test := range jc.tests {
+		coverage[name] = coverageForTest(test, graph)
+	}
+	return coverage
+}
+
+// JobSignal is the outcome of the recent runs of a job
+type JobSignal struct {
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+}
+
+// passRate estimates how likely the job is to pass, smoothed so that
+// jobs with few or no recent runs are neither trusted nor dismissed
+func (s JobSignal) passRate() float64 {
+	return float64(s.Passed+1) / float64(s.Passed+s.Failed+2)
+}
+
+func (s JobSignal) String() string {
+	if s.Passed+s.Failed == 0 {
+		return "no recent runs"
+	}
+	return fmt.Sprintf("passed %d of %d recent runs", s.Passed, s.Passed+s.Failed)
+}
+
+// JobHistory is the recent signal of the jobs, by their name
+type JobHistory map[string]JobSignal
+
+// LoadJobHistory loads the history of the jobs from a JSON file mapping the
+// names of the jobs to the number of their recent runs which passed and failed
+func LoadJobHistory(path string) (JobHistory, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the job history: %w", err)
+	}
+	var history JobHistory
+	if err := json.Unmarshal(raw, &history); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the job history: %w", err)
+	}
+	return history, nil
+}
+
+// selectByCoverage selects up to limit rehearsals, greedily picking the one which covers the most
+// cluster profiles, workflows and step paths no picked rehearsal covers yet, weighed by how
+// reliably the job has passed recently so that the failure of a rehearsal is meaningful. Once
+// no rehearsal adds any coverage, the most reliable ones fill up the limit. The reason each
+// rehearsal was picked for is recorded in its annotations. The coverage and the history
+// are by the names of the jobs the rehearsals were created from.
+func selectByCoverage(presubmitsToRehearse []*prowconfig.Presubmit, prNumber int, coverage map[string]JobCoverage, history JobHistory, limit int) []*prowconfig.Presubmit {
+	if len(presubmitsToRehearse) <= limit {
+		return presubmitsToRehearse
+	}
+	type candidate struct {
+		job      *prowconfig.Presubmit
+		items    []coverageItem
+		signal   JobSignal
+		passRate float64
+	}
+	var candidates []*candidate
+	for _, job := range presubmitsToRehearse {
+		source := strings.TrimPrefix(job.Name, fmt.Sprintf("rehearse-%d-", prNumber))
+		signal := history[source]
+		candidates = append(candidates, &candidate{job: job, items: coverage[source].items(), signal: signal, passRate: signal.passRate()})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].job.Name < candidates[j].job.Name })
+
+	covered := map[coverageItem]bool{}
+	uncovered := func(c *candidate) []coverageItem {
+		var items []coverageItem
+		for _, item := range c.items {
+			if !covered[item] {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	var selected []*prowconfig.Presubmit
+	pick := func(i int, reason string) {
+		c := candidates[i]
+		if c.job.Annotations == nil {
+			c.job.Annotations = map[string]string{}
+		}
+		c.job.Annotations[AnnotationReason] = fmt.Sprintf("%s; %s", reason, c.signal)
+		selected = append(selected, c.job)
+		candidates = append(candidates[:i], candidates[i+1:]...)
+	}
+
+	for len(selected) < limit {
+		best, bestScore := -1, 0.0
+		var bestItems []coverageItem
+		for i, c := range candidates {
+			items := uncovered(c)
+			if score := float64(len(items)) * c.passRate; score > bestScore {
+				best, bestScore, bestItems = i, score, items
+			}
+		}
+		if best == -1 {
+			break
+		}
+		for _, item := range bestItems {
+			covered[item] = true
+		}
+		pick(best, "first to cover "+describeCoverage(bestItems))
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].passRate > candidates[j].passRate })
+	for len(selected) < limit {
+		pick(0, "adds no coverage, selected for its recent signal")
+	}
+	return selected
+}
+
+// describeCoverage summarizes the coverage items, naming the cluster profiles and
+// workflows and counting the step paths
+func describeCoverage(items []coverageItem) string {
+	var parts []string
+	var steps int
+	for _, item := range items {
+		if item.kind == "step path" {
+			steps++
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", item.kind, item.name))
+	}
+	switch steps {
+	case 0:
+	case 1:
+		parts = append(parts, "1 step path")
+	default:
+		parts = append(parts, fmt.Sprintf("%d step paths", steps))
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
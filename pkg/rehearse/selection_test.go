@@ -0,0 +1,155 @@
+package rehearse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowconfig "k8s.io/test-infra/prow/config"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+func TestCoverageForTest(t *testing.T) {
+	install, deprovision, conf, gather := "ipi-install", "ipi-deprovision", "ipi-conf", "gather"
+	ipi, pre := "ipi", "ipi-pre"
+	upgrade, upgradeV1, upgradeV2, upgradeChain, upgradeChainV2 := "upgrade", "upgrade@v1", "upgrade@v2", "upgrade-steps", "upgrade-steps@v2"
+	graph, err := registry.NewGraph(
+		registry.ReferenceByName{install: {}, deprovision: {}, conf: {}, gather: {}, upgradeV1: {}, upgradeV2: {}},
+		registry.ChainByName{pre: {Steps: []api.TestStep{{Reference: &conf}, {Reference: &install}}}, upgradeChainV2: {Steps: []api.TestStep{{Reference: &upgrade}}}},
+		registry.WorkflowByName{ipi: {Pre: []api.TestStep{{Chain: &pre}}, Post: []api.TestStep{{Reference: &deprovision}}}, "upgrade@v1": {Test: []api.TestStep{{Chain: &upgradeChain}}}},
+		registry.ObserverByName{},
+	)
+	if err != nil {
+		t.Fatalf("failed to create the graph: %v", err)
+	}
+	for _, tc := range []struct {
+		name     string
+		test     api.TestStepConfiguration
+		expected JobCoverage
+	}{{
+		name: "workflow and additional steps",
+		test: api.TestStepConfiguration{MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+			ClusterProfile: api.ClusterProfileAWS,
+			Workflow:       &ipi,
+			Test:           []api.TestStep{{Reference: &gather}, {LiteralTestStep: &api.LiteralTestStep{As: "literal"}}},
+		}},
+		expected: JobCoverage{
+			ClusterProfile: "aws",
+			Workflow:       "ipi",
+			StepPaths:      sets.New[string]("gather", "ipi/ipi-deprovision", "ipi/ipi-pre/ipi-conf", "ipi/ipi-pre/ipi-install"),
+		},
+	}, {
+		name: "unversioned names of versioned elements",
+		test: api.TestStepConfiguration{MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+			Workflow: &upgrade,
+			Post:     []api.TestStep{{Reference: &upgrade}, {Reference: &upgradeV1}, {Chain: &upgradeChain}},
+		}},
+		expected: JobCoverage{
+			Workflow:  "upgrade@v1",
+			StepPaths: sets.New[string]("upgrade@v1/upgrade-steps@v2/upgrade@v2", "upgrade@v2", "upgrade@v1", "upgrade-steps@v2/upgrade@v2"),
+		},
+	}, {
+		name: "literal test",
+		test: api.TestStepConfiguration{MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+			ClusterProfile: api.ClusterProfileGCP,
+			Pre:            []api.LiteralTestStep{{As: conf}, {As: install}},
+			Test:           []api.LiteralTestStep{{As: "literal"}, {As: upgrade}},
+			Post:           []api.LiteralTestStep{{As: deprovision}},
+		}},
+		expected: JobCoverage{ClusterProfile: "gcp", StepPaths: sets.New[string]("ipi-conf", "ipi-install", "upgrade@v2", "ipi-deprovision")},
+	}, {
+		name:     "container test",
+		test:     api.TestStepConfiguration{ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+		expected: JobCoverage{StepPaths: sets.New[string]()},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, coverageForTest(tc.test, graph)); diff != "" {
+				t.Errorf("unexpected coverage: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSelectByCoverage(t *testing.T) {
+	job := func(name string) *prowconfig.Presubmit {
+		return &prowconfig.Presubmit{JobBase: prowconfig.JobBase{Name: "rehearse-123-" + name}}
+	}
+	coverage := map[string]JobCoverage{
+		"aws-a":    {ClusterProfile: "aws", Workflow: "ipi-aws", StepPaths: sets.New[string]("ipi-aws/install", "ipi-aws/aws-conf")},
+		"aws-b":    {ClusterProfile: "aws", Workflow: "ipi-aws", StepPaths: sets.New[string]("ipi-aws/install", "ipi-aws/aws-conf")},
+		"gcp":      {ClusterProfile: "gcp", Workflow: "ipi-gcp", StepPaths: sets.New[string]("ipi-gcp/install")},
+		"flaky":    {ClusterProfile: "azure4", Workflow: "ipi-azure", StepPaths: sets.New[string]("ipi-azure/install")},
+		"no-steps": {},
+	}
+	history := JobHistory{
+		"aws-a": {Passed: 2, Failed: 8},
+		"aws-b": {Passed: 9, Failed: 1},
+		"gcp":   {Passed: 10},
+		"flaky": {Failed: 20},
+	}
+	for _, tc := range []struct {
+		name     string
+		limit    int
+		expected map[string]string
+	}{{
+		name:  "the reliable jobs with the most new coverage are selected",
+		limit: 2,
+		expected: map[string]string{
+			"rehearse-123-aws-b": "first to cover cluster profile aws, workflow ipi-aws and 2 step paths; passed 9 of 10 recent runs",
+			"rehearse-123-gcp":   "first to cover cluster profile gcp, workflow ipi-gcp and 1 step path; passed 10 of 10 recent runs",
+		},
+	}, {
+		name:  "unreliable jobs are selected for their coverage, then the most reliable ones fill the limit",
+		limit: 4,
+		expected: map[string]string{
+			"rehearse-123-aws-b":    "first to cover cluster profile aws, workflow ipi-aws and 2 step paths; passed 9 of 10 recent runs",
+			"rehearse-123-gcp":      "first to cover cluster profile gcp, workflow ipi-gcp and 1 step path; passed 10 of 10 recent runs",
+			"rehearse-123-flaky":    "first to cover cluster profile azure4, workflow ipi-azure and 1 step path; passed 0 of 20 recent runs",
+			"rehearse-123-no-steps": "adds no coverage, selected for its recent signal; no recent runs",
+		},
+	}, {
+		name:  "all jobs fit in the limit",
+		limit: 5,
+		expected: map[string]string{
+			"rehearse-123-aws-a":    "",
+			"rehearse-123-aws-b":    "",
+			"rehearse-123-gcp":      "",
+			"rehearse-123-flaky":    "",
+			"rehearse-123-no-steps": "",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			jobs := []*prowconfig.Presubmit{job("aws-a"), job("aws-b"), job("gcp"), job("flaky"), job("no-steps")}
+			selected := selectByCoverage(jobs, 123, coverage, history, tc.limit)
+			reasons := map[string]string{}
+			for _, job := range selected {
+				reasons[job.Name] = job.Annotations[AnnotationReason]
+			}
+			if diff := cmp.Diff(tc.expected, reasons); diff != "" {
+				t.Errorf("unexpected selection: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadJobHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	if err := os.WriteFile(path, []byte(`{"job-a": {"passed": 3, "failed": 1}}`), 0644); err != nil {
+		t.Fatalf("failed to write the history: %v", err)
+	}
+	history, err := LoadJobHistory(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(JobHistory{"job-a": {Passed: 3, Failed: 1}}, history); diff != "" {
+		t.Errorf("unexpected history: %s", diff)
+	}
+	if _, err := LoadJobHistory(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,360 @@
+package rehearse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	pjapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	prowio "k8s.io/test-infra/prow/io"
+	"k8s.io/test-infra/prow/pod-utils/gcs"
+
+	"github.com/openshift/ci-tools/pkg/jobconfig"
+)
+
+// Classification is the result of a rehearsal compared to the latest run of the rehearsed job
+// on the base branch
+type Classification string
+
+const (
+	// ClassificationPass means the rehearsal passed, and so did the job or it has not run yet
+	ClassificationPass Classification = "pass"
+	// ClassificationFixed means the rehearsal passed where the job failed
+	ClassificationFixed Classification = "fixed"
+	// ClassificationNewFailure means the rehearsal failed, but the job passed on the base branch
+	// or has not run there yet
+	ClassificationNewFailure Classification = "new-failure"
+	// ClassificationPreExistingFailure means the rehearsal failed, and so did the job
+	ClassificationPreExistingFailure Classification = "pre-existing-failure"
+	// ClassificationUnknownBaseline means the rehearsal failed, and whether the job passes
+	// could not be determined
+	ClassificationUnknownBaseline Classification = "unknown-baseline"
+	// ClassificationAborted means the rehearsal was aborted, like when a push superseded it
+	ClassificationAborted Classification = "aborted"
+	// ClassificationPending means the rehearsal has not finished yet
+	ClassificationPending Classification = "pending"
+)
+
+// ErrNoBaseBranchRuns means a job only runs on PRs, the same test does not run on the
+// base branch and the job has not run on any PR yet, so no result tells whether the test
+// passes without the changes of a PR
+var ErrNoBaseBranchRuns = errors.New("the test has not run on the base branch or on any PR")
+
+// BaselineSource is where the result a rehearsal is compared to comes from
+type BaselineSource string
+
+const (
+	// BaselineSourceBaseBranch is a run of the job, or of the postsubmit or periodic of the
+	// same test, on the base branch
+	BaselineSourceBaseBranch BaselineSource = "base branch"
+	// BaselineSourcePullRequest is a run of a presubmit on some PR, which includes the
+	// changes of that PR
+	BaselineSourcePullRequest BaselineSource = "latest PR"
+)
+
+// Baseline is the result of the latest run of a job
+type Baseline struct {
+	BuildID string
+	Passed  bool
+	// Job is the job that ran, which is not the rehearsed one when another job runs the
+	// same test on the base branch
+	Job    string
+	Source BaselineSource
+}
+
+// BaselineResults looks up the latest results of the jobs
+type BaselineResults interface {
+	// Latest returns the result of the latest finished run of the job on the base branch,
+	// or of a presubmit on any PR when the test does not run on the base branch, or nil
+	// when the latest run has not finished
+	Latest(ctx context.Context, job string) (*Baseline, error)
+}
+
+// reader is the part of a prowio.Opener we need to read the results of the jobs
+type reader interface {
+	Reader(ctx context.Context, path string) (io.ReadCloser, error)
+}
+
+// storageBaselines reads the results of the jobs from where Prow uploads them
+type storageBaselines struct {
+	opener reader
+	// root is the bucket, like gs://test-platform-results, or a local directory with the same layout
+	root string
+}
+
+// NewStorageBaselines returns the latest results of the jobs from the root of a bucket the jobs upload
+// their results to, like gs://test-platform-results. A local directory with the same layout can stand in
+// for the bucket.
+func NewStorageBaselines(opener prowio.Opener, root string) BaselineResults {
+	return &storageBaselines{opener: opener, root: strings.TrimSuffix(root, "/")}
+}
+
+// finished is the part of the finished.json of a job run we care about
+type finished struct {
+	Passed *bool  `json:"passed,omitempty"`
+	Result string `json:"result,omitempty"`
+}
+
+func (s *storageBaselines) read(ctx context.Context, relative string) (string, error) {
+	reader, err := s.opener.Reader(ctx, s.root+"/"+relative)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", relative, err)
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
+
+// baseBranchJobs returns the names of the jobs running the same test as the job on the
+// base branch. The runs of presubmits include the changes of the PRs they ran for, so the
+// postsubmit and the periodic of the same test are the better baseline.
+func baseBranchJobs(job string) ([]string, bool) {
+	test := strings.TrimPrefix(job, jobconfig.PresubmitPrefix+"-")
+	if test == job {
+		return []string{job}, false
+	}
+	return []string{jobconfig.PostsubmitPrefix + "-" + test, jobconfig.PeriodicPrefix + "-" + test}, true
+}
+
+// Latest finds the latest run of the job on the base branch by the latest-build.txt Prow
+// uploads next to the results of the postsubmits and the periodics. A presubmit has no runs
+// there; the latest run of the postsubmit or the periodic of the same test stands in for it.
+// When neither exists, the latest run of the presubmit itself on any PR is used, found by
+// the latest-build.txt and the links to the builds Prow uploads to pr-logs/directory.
+func (s *storageBaselines) Latest(ctx context.Context, job string) (*Baseline, error) {
+	jobs, presubmit := baseBranchJobs(job)
+	for _, name := range jobs {
+		root := path.Join(gcs.NonPRLogs, name)
+		baseline, found, err := s.latest(ctx, name, root, func(buildID string) (string, error) {
+			return path.Join(root, buildID), nil
+		})
+		if !found {
+			continue
+		}
+		if baseline != nil {
+			baseline.Source = BaselineSourceBaseBranch
+		}
+		return baseline, err
+	}
+	if !presubmit {
+		return nil, nil
+	}
+	root := path.Join(gcs.PRLogs, "directory", job)
+	baseline, found, err := s.latest(ctx, job, root, func(buildID string) (string, error) {
+		// the builds of presubmits live under the PR they ran for, which the link names
+		// as gs://<bucket>/<path>; the path is the same relative to the root
+		link, err := s.read(ctx, path.Join(root, buildID+".txt"))
+		if err != nil {
+			return "", err
+		}
+		_, relative, _ := strings.Cut(strings.TrimPrefix(link, "gs://"), "/")
+		return relative, nil
+	})
+	if !found {
+		return nil, fmt.Errorf("%s: %w", job, ErrNoBaseBranchRuns)
+	}
+	if baseline != nil {
+		baseline.Source = BaselineSourcePullRequest
+	}
+	return baseline, err
+}
+
+// latest reads the result of the latest build of a job by the latest-build.txt in the root,
+// and returns whether the job has any builds there. The build directory is determined from
+// the build ID by the function. The baseline is nil when the latest build has not finished.
+func (s *storageBaselines) latest(ctx context.Context, job, root string, buildDir func(buildID string) (string, error)) (*Baseline, bool, error) {
+	buildID, err := s.read(ctx, path.Join(root, "latest-build.txt"))
+	if prowio.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, true, fmt.Errorf("failed to determine the latest build of %s: %w", job, err)
+	}
+	dir, err := buildDir(buildID)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to determine where the latest build of %s is: %w", job, err)
+	}
+	raw, err := s.read(ctx, path.Join(dir, "finished.json"))
+	if prowio.IsNotExist(err) {
+		// the latest build did not finish yet; we do not look further back
+		return nil, true, nil
+	} else if err != nil {
+		return nil, true, fmt.Errorf("failed to read the result of the latest build of %s: %w", job, err)
+	}
+	var result finished
+	if err := json.Unmarshal([]byte(raw), &result); err != nil {
+		return nil, true, fmt.Errorf("failed to unmarshal the result of the latest build of %s: %w", job, err)
+	}
+	passed := result.Result == "SUCCESS"
+	if result.Passed != nil {
+		passed = *result.Passed
+	}
+	return &Baseline{BuildID: buildID, Passed: passed, Job: job}, true, nil
+}
+
+// ResultReporter is told about the rehearsals as they are submitted and as they finish
+type ResultReporter interface {
+	Report(jobs []pjapi.ProwJob)
+}
+
+// SummaryRow is the result of a rehearsal and of the latest run of the rehearsed job
+type SummaryRow struct {
+	Rehearsal      string
+	URL            string
+	State          pjapi.ProwJobState
+	Baseline       *Baseline
+	Classification Classification
+}
+
+// ResultSummary classifies the results of the rehearsals against the latest runs of the rehearsed
+// jobs, and publishes the summary every time a rehearsal finishes
+type ResultSummary struct {
+	prNumber  int
+	baselines BaselineResults
+	publish   func(summary string) error
+	logger    *logrus.Entry
+
+	lock sync.Mutex
+	rows map[string]*SummaryRow
+}
+
+// NewResultSummary creates a summary of the rehearsals for a PR, published with the function
+func NewResultSummary(prNumber int, baselines BaselineResults, publish func(summary string) error, logger *logrus.Entry) *ResultSummary {
+	return &ResultSummary{
+		prNumber:  prNumber,
+		baselines: baselines,
+		publish:   publish,
+		logger:    logger,
+		rows:      map[string]*SummaryRow{},
+	}
+}
+
+// Report records the state of the rehearsals and publishes the summary
+func (s *ResultSummary) Report(jobs []pjapi.ProwJob) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, job := range jobs {
+		row, ok := s.rows[job.Spec.Job]
+		if !ok {
+			row = &SummaryRow{Rehearsal: job.Spec.Job}
+			s.rows[job.Spec.Job] = row
+		}
+		row.URL = job.Status.URL
+		row.State = job.Status.State
+		if !job.Complete() {
+			row.Classification = ClassificationPending
+			continue
+		}
+		if job.Status.State == pjapi.AbortedState {
+			row.Baseline = nil
+			row.Classification = ClassificationAborted
+			continue
+		}
+		source := strings.TrimPrefix(job.Spec.Job, fmt.Sprintf("rehearse-%d-", s.prNumber))
+		baseline, err := s.baselines.Latest(context.Background(), source)
+		if err != nil && !errors.Is(err, ErrNoBaseBranchRuns) {
+			s.logger.WithError(err).WithField("job", source).Warn("Could not determine the latest result of the job")
+		}
+		row.Baseline = baseline
+		row.Classification = classify(job.Status.State == pjapi.SuccessState, baseline, err == nil)
+	}
+	if err := s.publish(s.render()); err != nil {
+		s.logger.WithError(err).Error("Failed to publish the summary of the rehearsals")
+	}
+}
+
+// classify compares the result of a finished rehearsal to the baseline, which is unknown
+// when it could not be looked up
+func classify(passed bool, baseline *Baseline, known bool) Classification {
+	baselineFailed := baseline != nil && !baseline.Passed
+	switch {
+	case passed && baselineFailed:
+		return ClassificationFixed
+	case passed:
+		return ClassificationPass
+	case baselineFailed:
+		return ClassificationPreExistingFailure
+	case !known:
+		return ClassificationUnknownBaseline
+	default:
+		return ClassificationNewFailure
+	}
+}
+
+// sortedRows returns the rows of the summary, by the name of the rehearsals
+func (s *ResultSummary) sortedRows() []SummaryRow {
+	var rows []SummaryRow
+	for _, row := range s.rows {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Rehearsal < rows[j].Rehearsal })
+	return rows
+}
+
+// render formats the summary as a Markdown table, the new failures first
+func (s *ResultSummary) render() string {
+	order := []Classification{ClassificationNewFailure, ClassificationUnknownBaseline, ClassificationPreExistingFailure, ClassificationFixed, ClassificationPass, ClassificationAborted, ClassificationPending}
+	counts := map[Classification]int{}
+	byClassification := map[Classification][]SummaryRow{}
+	for _, row := range s.sortedRows() {
+		counts[row.Classification]++
+		byClassification[row.Classification] = append(byClassification[row.Classification], row)
+	}
+	var totals []string
+	for _, classification := range order {
+		if counts[classification] != 0 {
+			totals = append(totals, fmt.Sprintf("%d %s", counts[classification], classification))
+		}
+	}
+	lines := []string{
+		fmt.Sprintf("Results of the rehearsals compared to the latest runs of the rehearsed jobs: %s", strings.Join(totals, ", ")),
+		"",
+		"Rehearsal | Result | Latest run of the job | Classification",
+		"--- | --- | --- | ---",
+	}
+	for _, classification := range order {
+		for _, row := range byClassification[classification] {
+			rehearsal := row.Rehearsal
+			if row.URL != "" {
+				rehearsal = fmt.Sprintf("[%s](%s)", row.Rehearsal, row.URL)
+			}
+			baseline := "unknown"
+			if row.Baseline != nil {
+				baseline = describeBaseline(*row.Baseline)
+			} else if row.Classification == ClassificationPending || row.Classification == ClassificationAborted {
+				baseline = "-"
+			}
+			lines = append(lines, fmt.Sprintf("%s | %s | %s | %s", rehearsal, row.State, baseline, row.Classification))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// describeBaseline names the result, the build and where the build comes from
+func describeBaseline(baseline Baseline) string {
+	build := "build " + baseline.BuildID
+	if baseline.Job != "" {
+		build += " of " + baseline.Job
+	}
+	if baseline.Source != "" {
+		build += ", " + string(baseline.Source)
+	}
+	return fmt.Sprintf("%s (%s)", passedOrFailed(baseline.Passed), build)
+}
+
+func passedOrFailed(passed bool) string {
+	if passed {
+		return "passed"
+	}
+	return "failed"
+}
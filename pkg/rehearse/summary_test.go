@@ -0,0 +1,179 @@
+package rehearse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	pjapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+)
+
+type localReader struct{}
+
+func (localReader) Reader(_ context.Context, path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+func TestStorageBaselines(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"logs/periodic-passing/latest-build.txt":                 "2",
+		"logs/periodic-passing/2/finished.json":                  `{"passed": true, "result": "SUCCESS"}`,
+		"logs/periodic-failing/latest-build.txt":                 "5",
+		"logs/periodic-failing/5/finished.json":                  `{"result": "FAILURE"}`,
+		"logs/periodic-running/latest-build.txt":                 "7",
+		"logs/branch-ci-org-repo-master-images/latest-build.txt": "8",
+		"logs/branch-ci-org-repo-master-images/8/finished.json":  `{"passed": true, "result": "SUCCESS"}`,
+		"logs/periodic-ci-org-repo-master-e2e/latest-build.txt":  "10",
+		"logs/periodic-ci-org-repo-master-e2e/10/finished.json":  `{"passed": false, "result": "FAILURE"}`,
+		// the latest run of a presubmit on any PR is the baseline when the test does not run on the base branch
+		"pr-logs/directory/pull-ci-org-repo-master-unit/latest-build.txt":       "9",
+		"pr-logs/directory/pull-ci-org-repo-master-unit/9.txt":                  "gs://test-platform-results/pr-logs/pull/org_repo/12/pull-ci-org-repo-master-unit/9",
+		"pr-logs/pull/org_repo/12/pull-ci-org-repo-master-unit/9/finished.json": `{"passed": false, "result": "FAILURE"}`,
+		"pr-logs/directory/pull-ci-org-repo-master-lint/latest-build.txt":       "11",
+		"pr-logs/directory/pull-ci-org-repo-master-lint/11.txt":                 "gs://test-platform-results/pr-logs/pull/org_repo/13/pull-ci-org-repo-master-lint/11",
+		// the baseline of the base branch is preferred over the latest run of the presubmit
+		"pr-logs/directory/pull-ci-org-repo-master-e2e/latest-build.txt": "12",
+		"pr-logs/directory/pull-ci-org-repo-master-e2e/12.txt":           "gs://test-platform-results/pr-logs/pull/org_repo/13/pull-ci-org-repo-master-e2e/12",
+	} {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	baselines := &storageBaselines{opener: localReader{}, root: dir}
+	for _, tc := range []struct {
+		job         string
+		expected    *Baseline
+		expectedErr error
+	}{
+		{job: "periodic-passing", expected: &Baseline{BuildID: "2", Passed: true, Job: "periodic-passing", Source: BaselineSourceBaseBranch}},
+		{job: "periodic-failing", expected: &Baseline{BuildID: "5", Job: "periodic-failing", Source: BaselineSourceBaseBranch}},
+		{job: "periodic-running"},
+		{job: "pull-ci-org-repo-master-images", expected: &Baseline{BuildID: "8", Passed: true, Job: "branch-ci-org-repo-master-images", Source: BaselineSourceBaseBranch}},
+		{job: "pull-ci-org-repo-master-e2e", expected: &Baseline{BuildID: "10", Job: "periodic-ci-org-repo-master-e2e", Source: BaselineSourceBaseBranch}},
+		{job: "pull-ci-org-repo-master-unit", expected: &Baseline{BuildID: "9", Job: "pull-ci-org-repo-master-unit", Source: BaselineSourcePullRequest}},
+		{job: "pull-ci-org-repo-master-lint"},
+		{job: "pull-ci-org-repo-master-never-ran", expectedErr: ErrNoBaseBranchRuns},
+		{job: "never-ran"},
+	} {
+		t.Run(tc.job, func(t *testing.T) {
+			baseline, err := baselines.Latest(context.Background(), tc.job)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, baseline); diff != "" {
+				t.Errorf("unexpected baseline: %s", diff)
+			}
+		})
+	}
+}
+
+type fakeBaselines map[string]*Baseline
+
+func (f fakeBaselines) Latest(_ context.Context, job string) (*Baseline, error) {
+	if job == "unknown" {
+		return nil, errors.New("injected error")
+	}
+	return f[job], nil
+}
+
+func TestResultSummary(t *testing.T) {
+	job := func(name string, state pjapi.ProwJobState) pjapi.ProwJob {
+		pj := pjapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       pjapi.ProwJobSpec{Job: "rehearse-123-" + name},
+			Status:     pjapi.ProwJobStatus{State: state, URL: "https://prow/" + name},
+		}
+		if state != pjapi.PendingState {
+			pj.Status.CompletionTime = &metav1.Time{}
+		}
+		return pj
+	}
+	baselines := fakeBaselines{
+		"broken":   {BuildID: "1", Job: "pull-ci-broken", Source: BaselineSourcePullRequest},
+		"repaired": {BuildID: "2", Job: "periodic-repaired", Source: BaselineSourceBaseBranch},
+		"healthy":  {BuildID: "3", Passed: true, Job: "periodic-healthy", Source: BaselineSourceBaseBranch},
+	}
+	var published []string
+	summary := NewResultSummary(123, baselines, func(summary string) error {
+		published = append(published, summary)
+		return nil
+	}, logrus.NewEntry(logrus.New()))
+
+	summary.Report([]pjapi.ProwJob{job("broken", pjapi.PendingState), job("repaired", pjapi.PendingState), job("healthy", pjapi.PendingState), job("new", pjapi.PendingState)})
+	summary.Report([]pjapi.ProwJob{job("broken", pjapi.FailureState), job("repaired", pjapi.SuccessState)})
+	summary.Report([]pjapi.ProwJob{job("healthy", pjapi.FailureState)})
+	summary.Report([]pjapi.ProwJob{job("new", pjapi.AbortedState), job("unknown", pjapi.FailureState)})
+
+	expected := []string{
+		`Results of the rehearsals compared to the latest runs of the rehearsed jobs: 4 pending
+
+Rehearsal | Result | Latest run of the job | Classification
+--- | --- | --- | ---
+[rehearse-123-broken](https://prow/broken) | pending | - | pending
+[rehearse-123-healthy](https://prow/healthy) | pending | - | pending
+[rehearse-123-new](https://prow/new) | pending | - | pending
+[rehearse-123-repaired](https://prow/repaired) | pending | - | pending`,
+		`Results of the rehearsals compared to the latest runs of the rehearsed jobs: 1 pre-existing-failure, 1 fixed, 2 pending
+
+Rehearsal | Result | Latest run of the job | Classification
+--- | --- | --- | ---
+[rehearse-123-broken](https://prow/broken) | failure | failed (build 1 of pull-ci-broken, latest PR) | pre-existing-failure
+[rehearse-123-repaired](https://prow/repaired) | success | failed (build 2 of periodic-repaired, base branch) | fixed
+[rehearse-123-healthy](https://prow/healthy) | pending | - | pending
+[rehearse-123-new](https://prow/new) | pending | - | pending`,
+		`Results of the rehearsals compared to the latest runs of the rehearsed jobs: 1 new-failure, 1 pre-existing-failure, 1 fixed, 1 pending
+
+Rehearsal | Result | Latest run of the job | Classification
+--- | --- | --- | ---
+[rehearse-123-healthy](https://prow/healthy) | failure | passed (build 3 of periodic-healthy, base branch) | new-failure
+[rehearse-123-broken](https://prow/broken) | failure | failed (build 1 of pull-ci-broken, latest PR) | pre-existing-failure
+[rehearse-123-repaired](https://prow/repaired) | success | failed (build 2 of periodic-repaired, base branch) | fixed
+[rehearse-123-new](https://prow/new) | pending | - | pending`,
+		`Results of the rehearsals compared to the latest runs of the rehearsed jobs: 1 new-failure, 1 unknown-baseline, 1 pre-existing-failure, 1 fixed, 1 aborted
+
+Rehearsal | Result | Latest run of the job | Classification
+--- | --- | --- | ---
+[rehearse-123-healthy](https://prow/healthy) | failure | passed (build 3 of periodic-healthy, base branch) | new-failure
+[rehearse-123-unknown](https://prow/unknown) | failure | unknown | unknown-baseline
+[rehearse-123-broken](https://prow/broken) | failure | failed (build 1 of pull-ci-broken, latest PR) | pre-existing-failure
+[rehearse-123-repaired](https://prow/repaired) | success | failed (build 2 of periodic-repaired, base branch) | fixed
+[rehearse-123-new](https://prow/new) | aborted | - | aborted`,
+	}
+	if diff := cmp.Diff(expected, published); diff != "" {
+		t.Errorf("unexpected summaries: %s", diff)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		passed   bool
+		baseline *Baseline
+		unknown  bool
+		expected Classification
+	}{
+		{passed: true, expected: ClassificationPass},
+		{passed: true, unknown: true, expected: ClassificationPass},
+		{passed: true, baseline: &Baseline{Passed: true}, expected: ClassificationPass},
+		{passed: true, baseline: &Baseline{}, expected: ClassificationFixed},
+		{expected: ClassificationNewFailure},
+		{unknown: true, expected: ClassificationUnknownBaseline},
+		{baseline: &Baseline{Passed: true}, expected: ClassificationNewFailure},
+		{baseline: &Baseline{}, expected: ClassificationPreExistingFailure},
+	} {
+		if actual := classify(tc.passed, tc.baseline, !tc.unknown); actual != tc.expected {
+			t.Errorf("passed: %t, baseline: %v, unknown: %t: expected %s, got %s", tc.passed, tc.baseline, tc.unknown, tc.expected, actual)
+		}
+	}
+}
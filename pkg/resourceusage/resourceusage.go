@@ -0,0 +1,179 @@
+// Package resourceusage accounts for the resources requested and used by the
+// pods and builds a ci-operator execution creates.  Every workload is
+// recorded once it finishes; the report of the execution is written as an
+// artifact so the cost of jobs can be aggregated.
+package resourceusage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+// Kind is the type of workload that was accounted for
+type Kind string
+
+const (
+	Pod   Kind = "pod"
+	Build Kind = "build"
+)
+
+// Resources is an amount of CPU and memory
+type Resources struct {
+	CPUMillicores int64 `json:"cpu_millicores"`
+	MemoryBytes   int64 `json:"memory_bytes"`
+}
+
+// Record is the resource usage of a single workload
+type Record struct {
+	Kind      Kind   `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Step is the ci-operator step which created the workload, when known
+	Step      string    `json:"step,omitempty"`
+	Requested Resources `json:"requested"`
+	// Observed is the usage reported by the metrics API of the cluster while
+	// the workload ran: the average CPU and the peak memory.  It is not set
+	// when no samples could be collected.
+	Observed        *Resources `json:"observed,omitempty"`
+	Samples         int        `json:"samples,omitempty"`
+	WallTimeSeconds float64    `json:"wall_time_seconds"`
+}
+
+// Totals sums up the usage of all workloads, weighted by their wall time
+type Totals struct {
+	WallTimeSeconds           float64 `json:"wall_time_seconds"`
+	RequestedCPUCoreSeconds   float64 `json:"requested_cpu_core_seconds"`
+	RequestedMemoryGiBSeconds float64 `json:"requested_memory_gib_seconds"`
+	// The observed totals only include workloads with samples
+	ObservedCPUCoreSeconds   float64 `json:"observed_cpu_core_seconds"`
+	ObservedMemoryGiBSeconds float64 `json:"observed_memory_gib_seconds"`
+}
+
+// Report is the resource usage of an execution
+type Report struct {
+	Records []Record `json:"records"`
+	Totals  Totals   `json:"totals"`
+}
+
+// Tracker accounts for the workloads of one execution. A single goroutine
+// samples the usage of all workloads which are running. A nil *Tracker is
+// valid and accounts for nothing.
+type Tracker struct {
+	lock     sync.Mutex
+	records  []Record
+	sampling map[*Sampler]struct{}
+}
+
+// NewTracker starts sampling the running workloads until ctx is done
+func NewTracker(ctx context.Context) *Tracker {
+	t := &Tracker{sampling: map[*Sampler]struct{}{}}
+	go t.sample(ctx)
+	return t
+}
+
+type trackerKey struct{}
+
+// WithTracker returns a context that carries the tracker
+func WithTracker(ctx context.Context, t *Tracker) context.Context {
+	return context.WithValue(ctx, trackerKey{}, t)
+}
+
+// FromContext returns the tracker carried by the context, or nil
+func FromContext(ctx context.Context) *Tracker {
+	t, _ := ctx.Value(trackerKey{}).(*Tracker)
+	return t
+}
+
+// Add records the usage of a workload which finished
+func (t *Tracker) Add(r Record) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.records = append(t.records, r)
+}
+
+// Snapshot returns the report of all workloads recorded so far, ordered by
+// kind, namespace and name.
+func (t *Tracker) Snapshot() Report {
+	var ret Report
+	if t != nil {
+		t.lock.Lock()
+		ret.Records = append([]Record(nil), t.records...)
+		t.lock.Unlock()
+	}
+	sort.SliceStable(ret.Records, func(i, j int) bool {
+		a, b := ret.Records[i], ret.Records[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	const gib = 1 << 30
+	for _, r := range ret.Records {
+		t := &ret.Totals
+		t.WallTimeSeconds += r.WallTimeSeconds
+		t.RequestedCPUCoreSeconds += float64(r.Requested.CPUMillicores) / 1000 * r.WallTimeSeconds
+		t.RequestedMemoryGiBSeconds += float64(r.Requested.MemoryBytes) / gib * r.WallTimeSeconds
+		if r.Observed != nil {
+			t.ObservedCPUCoreSeconds += float64(r.Observed.CPUMillicores) / 1000 * r.WallTimeSeconds
+			t.ObservedMemoryGiBSeconds += float64(r.Observed.MemoryBytes) / gib * r.WallTimeSeconds
+		}
+	}
+	return ret
+}
+
+// TestCase summarizes the report as a passing test case, so the usage of the
+// execution is visible along with its results.
+func (r Report) TestCase() *junit.TestCase {
+	var out strings.Builder
+	fmt.Fprintf(&out, "Pods and builds: %d\n", len(r.Records))
+	fmt.Fprintf(&out, "Wall time: %s\n", time.Duration(r.Totals.WallTimeSeconds*float64(time.Second)).Truncate(time.Second))
+	fmt.Fprintf(&out, "Requested: %.2f CPU core-hours, %.2f memory GiB-hours\n", r.Totals.RequestedCPUCoreSeconds/3600, r.Totals.RequestedMemoryGiBSeconds/3600)
+	fmt.Fprintf(&out, "Observed: %.2f CPU core-hours, %.2f memory GiB-hours\n", r.Totals.ObservedCPUCoreSeconds/3600, r.Totals.ObservedMemoryGiBSeconds/3600)
+	return &junit.TestCase{
+		Name:      "Account for the resources used by the job",
+		SystemOut: out.String(),
+	}
+}
+
+// ResourcesFor converts the CPU and memory of a list of resources
+func ResourcesFor(list corev1.ResourceList) Resources {
+	return Resources{
+		CPUMillicores: list.Cpu().MilliValue(),
+		MemoryBytes:   list.Memory().Value(),
+	}
+}
+
+// PodRequests returns the effective requests of a pod: the sum of its
+// containers, or the largest init container if that is larger.
+func PodRequests(spec *corev1.PodSpec) Resources {
+	var ret Resources
+	for _, c := range spec.Containers {
+		r := ResourcesFor(c.Resources.Requests)
+		ret.CPUMillicores += r.CPUMillicores
+		ret.MemoryBytes += r.MemoryBytes
+	}
+	for _, c := range spec.InitContainers {
+		r := ResourcesFor(c.Resources.Requests)
+		if r.CPUMillicores > ret.CPUMillicores {
+			ret.CPUMillicores = r.CPUMillicores
+		}
+		if r.MemoryBytes > ret.MemoryBytes {
+			ret.MemoryBytes = r.MemoryBytes
+		}
+	}
+	return ret
+}
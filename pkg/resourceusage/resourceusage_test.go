@@ -0,0 +1,132 @@
+package resourceusage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+)
+
+func TestSnapshot(t *testing.T) {
+	tracker := &Tracker{}
+	tracker.Add(Record{Kind: Pod, Namespace: "ns", Name: "b", Requested: Resources{CPUMillicores: 500, MemoryBytes: 1 << 30}, WallTimeSeconds: 60})
+	tracker.Add(Record{Kind: Build, Namespace: "ns", Name: "src", Requested: Resources{CPUMillicores: 2000, MemoryBytes: 2 << 30}, Observed: &Resources{CPUMillicores: 1000, MemoryBytes: 1 << 30}, Samples: 2, WallTimeSeconds: 30})
+	tracker.Add(Record{Kind: Pod, Namespace: "ns", Name: "a", Step: "e2e", Requested: Resources{CPUMillicores: 1000}, Observed: &Resources{CPUMillicores: 250, MemoryBytes: 1 << 29}, Samples: 1, WallTimeSeconds: 10})
+
+	expected := Report{
+		Records: []Record{
+			{Kind: Build, Namespace: "ns", Name: "src", Requested: Resources{CPUMillicores: 2000, MemoryBytes: 2 << 30}, Observed: &Resources{CPUMillicores: 1000, MemoryBytes: 1 << 30}, Samples: 2, WallTimeSeconds: 30},
+			{Kind: Pod, Namespace: "ns", Name: "a", Step: "e2e", Requested: Resources{CPUMillicores: 1000}, Observed: &Resources{CPUMillicores: 250, MemoryBytes: 1 << 29}, Samples: 1, WallTimeSeconds: 10},
+			{Kind: Pod, Namespace: "ns", Name: "b", Requested: Resources{CPUMillicores: 500, MemoryBytes: 1 << 30}, WallTimeSeconds: 60},
+		},
+		Totals: Totals{
+			WallTimeSeconds:           100,
+			RequestedCPUCoreSeconds:   60 + 10 + 30,
+			RequestedMemoryGiBSeconds: 60 + 60,
+			ObservedCPUCoreSeconds:    30 + 2.5,
+			ObservedMemoryGiBSeconds:  30 + 5,
+		},
+	}
+	report := tracker.Snapshot()
+	if diff := cmp.Diff(expected, report); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+	expectedOut := `Pods and builds: 3
+Wall time: 1m40s
+Requested: 0.03 CPU core-hours, 0.03 memory GiB-hours
+Observed: 0.01 CPU core-hours, 0.01 memory GiB-hours
+`
+	if diff := cmp.Diff(expectedOut, report.TestCase().SystemOut); diff != "" {
+		t.Errorf("unexpected summary: %s", diff)
+	}
+}
+
+func TestPodRequests(t *testing.T) {
+	requests := func(cpu, mem string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(mem),
+		}}
+	}
+	for _, tc := range []struct {
+		name     string
+		spec     corev1.PodSpec
+		expected Resources
+	}{
+		{
+			name:     "no requests",
+			spec:     corev1.PodSpec{Containers: []corev1.Container{{Name: "test"}}},
+			expected: Resources{},
+		},
+		{
+			name: "containers are summed",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "test", Resources: requests("100m", "200Mi")},
+				{Name: "sidecar", Resources: requests("10m", "20Mi")},
+			}},
+			expected: Resources{CPUMillicores: 110, MemoryBytes: 220 << 20},
+		},
+		{
+			name: "larger init container wins",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Resources: requests("1", "100Mi")}},
+				Containers:     []corev1.Container{{Name: "test", Resources: requests("100m", "200Mi")}},
+			},
+			expected: Resources{CPUMillicores: 1000, MemoryBytes: 200 << 20},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, PodRequests(&tc.spec)); diff != "" {
+				t.Errorf("unexpected requests: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSampler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracker := NewTracker(ctx)
+	s := tracker.StartSampling(nil, "ns", func() string { return "" })
+	usage := func(cpu, mem string) *kubernetes.PodMetrics {
+		return &kubernetes.PodMetrics{Containers: []kubernetes.ContainerMetrics{{
+			Name: "test",
+			Usage: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(mem),
+			},
+		}}}
+	}
+	s.add(usage("100m", "300Mi"))
+	s.add(usage("300m", "100Mi"))
+	observed, samples := s.Stop()
+	if diff := cmp.Diff(&Resources{CPUMillicores: 200, MemoryBytes: 300 << 20}, observed); diff != "" {
+		t.Errorf("unexpected usage: %s", diff)
+	}
+	if samples != 2 {
+		t.Errorf("expected 2 samples, got %d", samples)
+	}
+
+	if len(tracker.sampling) != 0 {
+		t.Errorf("expected stopped samplers to be forgotten, got %d", len(tracker.sampling))
+	}
+
+	observed, samples = tracker.StartSampling(nil, "ns", func() string { return "" }).Stop()
+	if observed != nil || samples != 0 {
+		t.Errorf("expected no usage without samples, got %v from %d samples", observed, samples)
+	}
+
+	var untracked *Tracker
+	untracked.Add(Record{Kind: Pod, Name: "dropped"})
+	if observed, samples := untracked.StartSampling(nil, "ns", func() string { return "pod" }).Stop(); observed != nil || samples != 0 {
+		t.Errorf("expected no usage without a tracker, got %v from %d samples", observed, samples)
+	}
+	if records := untracked.Snapshot().Records; len(records) != 0 {
+		t.Errorf("expected no records without a tracker, got %v", records)
+	}
+}
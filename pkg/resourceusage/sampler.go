@@ -0,0 +1,113 @@
+package resourceusage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+)
+
+// SampleInterval is how often the usage of running workloads is sampled,
+// which is close to the resolution of the metrics API
+var SampleInterval = 30 * time.Second
+
+// MetricsGetter retrieves the current usage of a pod
+type MetricsGetter interface {
+	GetPodMetrics(ctx context.Context, namespace, name string) (*kubernetes.PodMetrics, error)
+}
+
+// Sampler collects the samples of the usage of one pod
+type Sampler struct {
+	tracker   *Tracker
+	client    MetricsGetter
+	namespace string
+	name      func() string
+
+	lock     sync.Mutex
+	cpuTotal int64
+	memPeak  int64
+	samples  int
+}
+
+// StartSampling samples the usage of a pod until the sampler is stopped. The
+// name of the pod is resolved before each sample, since it may not be known
+// when a workload is created; samples are skipped while it is empty.
+func (t *Tracker) StartSampling(client MetricsGetter, namespace string, name func() string) *Sampler {
+	if t == nil {
+		return nil
+	}
+	s := &Sampler{tracker: t, client: client, namespace: namespace, name: name}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.sampling[s] = struct{}{}
+	return s
+}
+
+// sample collects a sample of every running workload at each interval
+func (t *Tracker) sample(ctx context.Context) {
+	ticker := time.NewTicker(SampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		t.lock.Lock()
+		samplers := make([]*Sampler, 0, len(t.sampling))
+		for s := range t.sampling {
+			samplers = append(samplers, s)
+		}
+		t.lock.Unlock()
+		for _, s := range samplers {
+			s.sample(ctx)
+		}
+	}
+}
+
+func (s *Sampler) sample(ctx context.Context) {
+	pod := s.name()
+	if pod == "" {
+		return
+	}
+	metrics, err := s.client.GetPodMetrics(ctx, s.namespace, pod)
+	if err != nil {
+		logrus.WithError(err).Tracef("Could not sample the resource usage of pod %s.", pod)
+		return
+	}
+	s.add(metrics)
+}
+
+func (s *Sampler) add(metrics *kubernetes.PodMetrics) {
+	var cpu, mem int64
+	for _, c := range metrics.Containers {
+		cpu += c.Usage.Cpu().MilliValue()
+		mem += c.Usage.Memory().Value()
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.cpuTotal += cpu
+	if mem > s.memPeak {
+		s.memPeak = mem
+	}
+	s.samples++
+}
+
+// Stop stops sampling and returns the observed usage, if any was sampled
+func (s *Sampler) Stop() (*Resources, int) {
+	if s == nil {
+		return nil, 0
+	}
+	s.tracker.lock.Lock()
+	delete(s.tracker.sampling, s)
+	s.tracker.lock.Unlock()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.samples == 0 {
+		return nil, 0
+	}
+	return &Resources{CPUMillicores: s.cpuTotal / int64(s.samples), MemoryBytes: s.memPeak}, s.samples
+}
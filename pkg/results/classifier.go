@@ -0,0 +1,254 @@
+package results
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Rule maps failures to a root cause. While a Reason identifies the phase of
+// the execution that failed, the root cause identifies why it failed.
+type Rule struct {
+	// RootCause is reported for failures that match the rule
+	RootCause string `json:"root_cause"`
+	// Pattern is a regular expression matched against the error message and
+	// the logs of the pods and builds the error refers to
+	Pattern string `json:"pattern"`
+	// Reasons restricts the rule to failures with one of these reasons in
+	// their chain, when set
+	Reasons []Reason `json:"reasons,omitempty"`
+}
+
+// ClassifierConfig is the format of the file holding classification rules.
+// The rules are evaluated in order and the first match determines the root
+// cause.
+type ClassifierConfig struct {
+	Rules []Rule `json:"rules"`
+}
+
+type rule struct {
+	Rule
+	pattern *regexp.Regexp
+}
+
+// Classifier determines the root causes of failures
+type Classifier struct {
+	rules []rule
+}
+
+// NewClassifier compiles the rules of the configuration
+func NewClassifier(config ClassifierConfig) (*Classifier, error) {
+	c := &Classifier{}
+	for i, r := range config.Rules {
+		if r.RootCause == "" {
+			return nil, fmt.Errorf("rule %d: root_cause must be set", i)
+		}
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): invalid pattern: %w", i, r.RootCause, err)
+		}
+		c.rules = append(c.rules, rule{Rule: r, pattern: pattern})
+	}
+	return c, nil
+}
+
+// LoadClassifier reads the classification rules from a file
+func LoadClassifier(path string) (*Classifier, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read classification rules: %w", err)
+	}
+	var config ClassifierConfig
+	if err := yaml.UnmarshalStrict(raw, &config); err != nil {
+		return nil, fmt.Errorf("could not parse classification rules: %w", err)
+	}
+	return NewClassifier(config)
+}
+
+// Classify determines the root cause of the innermost Error of every chain in
+// the errors. The failure logs recorded for the pods and builds an error
+// message refers to are considered along with the message itself.
+func (c *Classifier) Classify(logs *FailureLogs, errs ...error) {
+	c.classify(logs, nil, errs...)
+}
+
+// classify returns whether an Error was found in the errors
+func (c *Classifier) classify(logs *FailureLogs, reasons []Reason, errs ...error) bool {
+	var found bool
+	for _, err := range errs {
+		switch err := err.(type) {
+		case *Error:
+			found = true
+			chain := append(reasons[:len(reasons):len(reasons)], err.reason)
+			if !c.classify(logs, chain, err.Unwrap()) {
+				err.rootCause = c.match(chain, err.text(), logs.For(err.text()))
+			}
+		case interface{ Errors() []error }:
+			if c.classify(logs, reasons, err.Errors()...) {
+				found = true
+			}
+		case interface{ Unwrap() error }:
+			if c.classify(logs, reasons, err.Unwrap()) {
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+func (c *Classifier) match(reasons []Reason, text string, logs []string) string {
+	for _, r := range c.rules {
+		if len(r.Reasons) > 0 && !containsAny(reasons, r.Reasons) {
+			continue
+		}
+		if r.pattern.MatchString(text) {
+			return r.RootCause
+		}
+		for _, log := range logs {
+			if r.pattern.MatchString(log) {
+				return r.RootCause
+			}
+		}
+	}
+	return ""
+}
+
+func containsAny(reasons, candidates []Reason) bool {
+	for _, reason := range reasons {
+		for _, candidate := range candidates {
+			if reason == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// text is the message of the error, including the message of the error it
+// wraps when the latter was not formatted into it
+func (e *Error) text() string {
+	if e.wrapped == nil {
+		return e.message
+	}
+	wrapped := e.wrapped.Error()
+	if strings.Contains(e.message, wrapped) {
+		return e.message
+	}
+	return e.message + "\n" + wrapped
+}
+
+// maxFailureLogBytes is how much of the end of a failure log is kept, which is
+// where the cause of a failure is usually found
+const maxFailureLogBytes = 64 * 1024
+
+// objectName matches the names of pods and builds, which may contain dashes
+// and dots, so a name must not be a part of a longer one to match
+var objectName = regexp.MustCompile(`[\w.-]+`)
+
+// FailureLogTail keeps the end of a failure log that is written to it, up to
+// the size kept by FailureLogs, without holding the whole log in memory
+type FailureLogTail struct {
+	buf  []byte
+	next int
+	full bool
+}
+
+func (t *FailureLogTail) Write(p []byte) (int, error) {
+	n := len(p)
+	if t.buf == nil {
+		t.buf = make([]byte, maxFailureLogBytes)
+	}
+	if len(p) > len(t.buf) {
+		p = p[len(p)-len(t.buf):]
+	}
+	for len(p) > 0 {
+		copied := copy(t.buf[t.next:], p)
+		p = p[copied:]
+		t.next += copied
+		if t.next == len(t.buf) {
+			t.next, t.full = 0, true
+		}
+	}
+	return n, nil
+}
+
+// String returns the end of the log in order
+func (t *FailureLogTail) String() string {
+	if !t.full {
+		return string(t.buf[:t.next])
+	}
+	return string(t.buf[t.next:]) + string(t.buf[:t.next])
+}
+
+// FailureLogs holds the logs of the failed pod containers and builds of one
+// execution, so that failures which refer to an object by name can be
+// classified by its log. A nil *FailureLogs is valid and records nothing.
+type FailureLogs struct {
+	lock sync.Mutex
+	logs map[string]string
+}
+
+// NewFailureLogs creates an empty store of failure logs
+func NewFailureLogs() *FailureLogs {
+	return &FailureLogs{logs: map[string]string{}}
+}
+
+// Record keeps the log of a failed pod container or build. It is safe to
+// call on a nil FailureLogs.
+func (l *FailureLogs) Record(name, log string) {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if existing, ok := l.logs[name]; ok {
+		log = existing + "\n" + log
+	}
+	if len(log) > maxFailureLogBytes {
+		log = log[len(log)-maxFailureLogBytes:]
+	}
+	l.logs[name] = log
+}
+
+// For returns the logs recorded for objects named in the text, in a stable
+// order
+func (l *FailureLogs) For(text string) []string {
+	if l == nil {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	seen := map[string]bool{}
+	var names []string
+	for _, name := range objectName.FindAllString(text, -1) {
+		if _, recorded := l.logs[name]; recorded && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var ret []string
+	for _, name := range names {
+		ret = append(ret, l.logs[name])
+	}
+	return ret
+}
+
+type failureLogsKey struct{}
+
+// WithFailureLogs returns a context that carries the failure logs
+func WithFailureLogs(ctx context.Context, l *FailureLogs) context.Context {
+	return context.WithValue(ctx, failureLogsKey{}, l)
+}
+
+// FailureLogsFromContext returns the failure logs carried by the context, or nil
+func FailureLogsFromContext(ctx context.Context) *FailureLogs {
+	l, _ := ctx.Value(failureLogsKey{}).(*FailureLogs)
+	return l
+}
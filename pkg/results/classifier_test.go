@@ -0,0 +1,161 @@
+package results
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+func TestClassify(t *testing.T) {
+	classifier, err := NewClassifier(ClassifierConfig{Rules: []Rule{
+		{RootCause: "image_pull", Pattern: `ErrImagePull|ImagePullBackOff`},
+		{RootCause: "quota", Pattern: `(?i)quota exceeded`, Reasons: []Reason{"installing_cluster"}},
+		{RootCause: "go_compile", Pattern: `^# [\w/.-]+$|cannot use .* as .* value`},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create classifier: %v", err)
+	}
+	logs := NewFailureLogs()
+	logs.Record("unit", "ok  \tpkg/a\n./b.go:3:9: cannot use x (variable of type int) as string value in return statement")
+	logs.Record("unit-other", "ErrImagePull")
+
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected []chain
+	}{
+		{
+			name:     "message matches",
+			err:      ForReason("running_pod").ForError(errors.New("container is waiting: ImagePullBackOff")),
+			expected: []chain{{reason: "running_pod", rootCause: "image_pull"}},
+		},
+		{
+			name:     "nothing matches",
+			err:      ForReason("running_pod").ForError(errors.New("exited with code 1")),
+			expected: []chain{{reason: "running_pod"}},
+		},
+		{
+			name: "rule restricted to reasons matches anywhere in the chain",
+			err: ForReason("installing_cluster").ForError(
+				ForReason("running_pod").ForError(errors.New("Quota exceeded for resource vCPUs")),
+			),
+			expected: []chain{{reason: "installing_cluster:running_pod", rootCause: "quota"}},
+		},
+		{
+			name:     "rule restricted to other reasons does not match",
+			err:      ForReason("running_pod").ForError(errors.New("Quota exceeded for resource vCPUs")),
+			expected: []chain{{reason: "running_pod"}},
+		},
+		{
+			name:     "log of the pod named by the error matches",
+			err:      ForReason("running_pod").ForError(errors.New("the pod ci-op-1/unit failed after 1m0s")),
+			expected: []chain{{reason: "running_pod", rootCause: "go_compile"}},
+		},
+		{
+			name: "every chain of an aggregate is classified",
+			err: ForReason("executing_graph").ForError(utilerrors.NewAggregate([]error{
+				ForReason("running_pod").ForError(errors.New("the pod ci-op-1/unit-other failed")),
+				ForReason("building_image").WithError(errors.New("ImagePullBackOff")).Errorf("could not build"),
+				fmt.Errorf("wrapped: %w", ForReason("running_pod").ForError(errors.New("exited"))),
+			})),
+			expected: []chain{
+				{reason: "executing_graph:running_pod", rootCause: "image_pull"},
+				{reason: "executing_graph:building_image", rootCause: "image_pull"},
+				{reason: "executing_graph:running_pod"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			classifier.Classify(logs, tc.err)
+			if diff := cmp.Diff(tc.expected, chains(tc.err), cmp.AllowUnexported(chain{})); diff != "" {
+				t.Errorf("unexpected classification: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadClassifier(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name        string
+		content     string
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			content: `rules:
+- root_cause: image_pull
+  pattern: ErrImagePull
+  reasons:
+  - running_pod
+`,
+		},
+		{
+			name:        "unknown field",
+			content:     "rules:\n- cause: image_pull\n",
+			expectedErr: `could not parse classification rules: error unmarshaling JSON: while decoding JSON: json: unknown field "cause"`,
+		},
+		{
+			name:        "invalid pattern",
+			content:     "rules:\n- root_cause: broken\n  pattern: '('\n",
+			expectedErr: "rule 0 (broken): invalid pattern: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name:        "missing root cause",
+			content:     "rules:\n- pattern: ErrImagePull\n",
+			expectedErr: "rule 0: root_cause must be set",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name+".yaml")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadClassifier(path)
+			var actual string
+			if err != nil {
+				actual = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedErr, actual); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestFailureLogTail(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		writes   []int
+		expected int
+	}{
+		{name: "short log is kept", writes: []int{10, 20}, expected: 30},
+		{name: "log of the maximum size is kept", writes: []int{maxFailureLogBytes}, expected: maxFailureLogBytes},
+		{name: "end of a long log is kept across writes", writes: []int{maxFailureLogBytes - 5, 100, 7}, expected: maxFailureLogBytes},
+		{name: "end of a single long write is kept", writes: []int{3*maxFailureLogBytes + 11}, expected: maxFailureLogBytes},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var written []byte
+			tail := &FailureLogTail{}
+			for _, size := range tc.writes {
+				chunk := make([]byte, size)
+				for i := range chunk {
+					chunk[i] = byte('a' + (len(written)+i)%26)
+				}
+				written = append(written, chunk...)
+				if n, err := tail.Write(chunk); err != nil || n != size {
+					t.Fatalf("expected to write %d bytes, wrote %d: %v", size, n, err)
+				}
+			}
+			if diff := cmp.Diff(string(written[len(written)-tc.expected:]), tail.String()); diff != "" {
+				t.Errorf("unexpected tail of the log: %s", diff)
+			}
+		})
+	}
+}
@@ -15,6 +15,8 @@ type Error struct {
 	reason  Reason
 	message string
 	wrapped error
+	// rootCause is set by a Classifier when the failure matches a known cause
+	rootCause string
 }
 
 // Error makes an Error an error
@@ -33,27 +35,47 @@ func (e *Error) Is(target error) bool {
 	return is
 }
 
+// RootCause is the cause a Classifier determined for the failure, or an empty
+// string if the failure was not classified
+func (e *Error) RootCause() string {
+	return e.rootCause
+}
+
 // Reasons provides the chains of error reasons.
 // Each item in the return value is a single chain divided by colons.  Aggregate
 // errors — those whose type provides an `Errors` method returning a list of
 // errors — are recursively expanded, generating a separate chain for each
 // child.
 func Reasons(errs ...error) (ret []string) {
+	for _, c := range chains(errs...) {
+		ret = append(ret, c.reason)
+	}
+	return
+}
+
+// chain is a single chain of error reasons, along with the root cause of the
+// innermost error
+type chain struct {
+	reason    string
+	rootCause string
+}
+
+func chains(errs ...error) (ret []chain) {
 	for _, err := range errs {
 		switch err := err.(type) {
 		case *Error:
-			children := Reasons(err.Unwrap())
+			children := chains(err.Unwrap())
 			if len(children) == 0 {
-				ret = append(ret, string(err.reason))
+				ret = append(ret, chain{reason: string(err.reason), rootCause: err.rootCause})
 				break
 			}
-			for _, r := range children {
-				ret = append(ret, fmt.Sprintf("%s:%s", err.reason, r))
+			for _, c := range children {
+				ret = append(ret, chain{reason: fmt.Sprintf("%s:%s", err.reason, c.reason), rootCause: c.rootCause})
 			}
 		case interface{ Errors() []error }:
-			ret = append(ret, Reasons(err.Errors()...)...)
+			ret = append(ret, chains(err.Errors()...)...)
 		case interface{ Unwrap() error }:
-			ret = append(ret, Reasons(err.Unwrap())...)
+			ret = append(ret, chains(err.Unwrap())...)
 		}
 	}
 	return
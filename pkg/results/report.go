@@ -24,14 +24,16 @@ const (
 
 // Options holds the configuration options for connecting to the remote aggregation server
 type Options struct {
-	address     string
-	credentials string
+	address             string
+	credentials         string
+	classificationRules string
 }
 
 // Bind adds flags for the options
 func (o *Options) Bind(flag *flag.FlagSet) {
 	flag.StringVar(&o.address, "report-address", reportAddress, "Address of the aggregate reporting server.")
 	flag.StringVar(&o.credentials, "report-credentials-file", "", "File holding the <username>:<password> for the aggregate reporting server.")
+	flag.StringVar(&o.classificationRules, "failure-classification-rules", "", "File holding the rules that determine the root cause of failures, which is reported along with the reason.")
 }
 
 // Validate checks if the Options elements are empty
@@ -58,7 +60,7 @@ func getUsernameAndPassword(credentials string) (string, string, error) {
 }
 
 // Client returns an HTTP or HTTPs client, based on the options
-func (o *Options) Reporter(spec *api.JobSpec, consoleHost string) (Reporter, error) {
+func (o *Options) Reporter(spec *api.JobSpec, consoleHost string, logs *FailureLogs) (Reporter, error) {
 	if o.address == "" || o.credentials == "" {
 		return &noopReporter{}, nil
 	}
@@ -72,6 +74,13 @@ func (o *Options) Reporter(spec *api.JobSpec, consoleHost string) (Reporter, err
 		return nil, fmt.Errorf("failed to get username and password: %w", err)
 	}
 
+	var classifier *Classifier
+	if o.classificationRules != "" {
+		if classifier, err = LoadClassifier(o.classificationRules); err != nil {
+			return nil, fmt.Errorf("failed to load failure classification rules: %w", err)
+		}
+	}
+
 	return &reporter{
 		spec:        spec,
 		address:     o.address,
@@ -79,6 +88,8 @@ func (o *Options) Reporter(spec *api.JobSpec, consoleHost string) (Reporter, err
 		client:      &http.Client{},
 		username:    username,
 		password:    password,
+		classifier:  classifier,
+		failureLogs: logs,
 	}, nil
 }
 
@@ -94,6 +105,8 @@ type Request struct {
 	State string `json:"state"`
 	// Reason is a colon-delimited list of reasons for failure
 	Reason string `json:"reason"`
+	// RootCause is why the execution failed, when the failure was classified
+	RootCause string `json:"root_cause,omitempty"`
 }
 
 // PodScalerRequest holds the data from pod-scaler used to report a result to an aggregation server
@@ -105,6 +118,19 @@ type PodScalerRequest struct {
 	ResourceType     string
 }
 
+// BuildCacheRequest holds the lookups of one pipeline image in the build cache
+// during an execution
+type BuildCacheRequest struct {
+	// JobName is the name of the job which looked up the image
+	JobName string `json:"job_name"`
+	// Image is the name of the pipeline image
+	Image string `json:"image"`
+	// Hits is how often the image was tagged from the cache
+	Hits int `json:"hits"`
+	// Misses is how often the image had to be built
+	Misses int `json:"misses"`
+}
+
 const (
 	StateSucceeded string = "succeeded"
 	StateFailed    string = "failed"
@@ -115,12 +141,17 @@ type Reporter interface {
 	// This action is best-effort and errors are logged but not exposed.
 	// Err may be nil in which case a success is reported.
 	Report(err error)
+	// ReportBuildCache sends the lookups of a pipeline image in the build
+	// cache to an aggregation server. This action is best-effort as well.
+	ReportBuildCache(image string, hits, misses int)
 }
 
 type noopReporter struct{}
 
 func (r *noopReporter) Report(err error) {}
 
+func (r *noopReporter) ReportBuildCache(image string, hits, misses int) {}
+
 type reporter struct {
 	client             *http.Client
 	username, password string
@@ -128,24 +159,30 @@ type reporter struct {
 
 	spec        *api.JobSpec
 	consoleHost string
+	classifier  *Classifier
+	failureLogs *FailureLogs
 }
 
 func (r *reporter) Report(err error) {
 	state := StateSucceeded
 	if err != nil {
 		state = StateFailed
+		if r.classifier != nil {
+			r.classifier.Classify(r.failureLogs, err)
+		}
 	}
-	reasons := Reasons(err)
+	reasons := chains(err)
 	if len(reasons) == 0 {
-		reasons = []string{string(ReasonUnknown)}
+		reasons = []chain{{reason: string(ReasonUnknown)}}
 	}
 	for _, reason := range reasons {
 		r.report(Request{
-			JobName: r.spec.Job,
-			Type:    string(r.spec.Type),
-			Cluster: r.consoleHost,
-			State:   state,
-			Reason:  reason,
+			JobName:   r.spec.Job,
+			Type:      string(r.spec.Type),
+			Cluster:   r.consoleHost,
+			State:     state,
+			Reason:    reason.reason,
+			RootCause: reason.rootCause,
 		})
 	}
 }
@@ -160,6 +197,9 @@ func (r *reporter) report(request Request) {
 	reportMsg := fmt.Sprintf("Reporting job state '%s'", request.State)
 	if request.State != StateSucceeded {
 		reportMsg = fmt.Sprintf("Reporting job state '%s' with reason '%s'", request.State, request.Reason)
+		if request.RootCause != "" {
+			reportMsg += fmt.Sprintf(" and root cause '%s'", request.RootCause)
+		}
 	}
 
 	logrus.Infof(reportMsg)
@@ -171,6 +211,20 @@ func (r *reporter) report(request Request) {
 	sendRequest(req, r.client, r.username, r.password)
 }
 
+func (r *reporter) ReportBuildCache(image string, hits, misses int) {
+	data, err := json.Marshal(BuildCacheRequest{JobName: r.spec.Job, Image: image, Hits: hits, Misses: misses})
+	if err != nil {
+		logrus.Tracef("could not marshal build cache request: %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/build-cache", r.address), bytes.NewReader(data))
+	if err != nil {
+		logrus.Tracef("could not create build cache request: %v", err)
+		return
+	}
+	sendRequest(req, r.client, r.username, r.password)
+}
+
 type PodScalerReporter interface {
 	ReportResourceConfigurationWarning(workloadName, workloadType, configuredAmount, determinedAmount, resourceType string)
 }
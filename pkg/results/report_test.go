@@ -58,6 +58,13 @@ func TestReporter_Report(t *testing.T) {
 			err:         ForReason("because").WithError(ForReason("something").ForError(errors.New("oops"))).Errorf("argh"),
 			expected:    `{"job_name":"runme","type":"presubmit","cluster":"foo.com","state":"failed","reason":"because:something"}`,
 		},
+		{
+			name:        "classified err reports failure with root cause",
+			spec:        &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "runme", Type: v1.PresubmitJob}},
+			consoleHost: "foo.com",
+			err:         &Error{reason: "because", message: "oops", rootCause: "image_pull"},
+			expected:    `{"job_name":"runme","type":"presubmit","cluster":"foo.com","state":"failed","reason":"because","root_cause":"image_pull"}`,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -107,7 +114,7 @@ func TestReporter_Report(t *testing.T) {
 func TestOptions_Reporter(t *testing.T) {
 	// this simulates the flow for ci-operator while we migrate to using the tool
 	options := Options{} // no flags set
-	reporter, err := options.Reporter(&api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "runme", Type: v1.PresubmitJob}}, "http.com")
+	reporter, err := options.Reporter(&api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "runme", Type: v1.PresubmitJob}}, "http.com", nil)
 	if err != nil {
 		t.Errorf("should not get an error creating a reporter, but got: %v", err)
 	}
@@ -7,6 +7,11 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
+// PreviousVersionSuffix is appended to the name of a field to keep the version
+// it had before a rotation, so that the rotation can revoke it once the new
+// version is in use. Such fields are not expected to be used by any secret.
+const PreviousVersionSuffix = ".previous"
+
 type ReadOnlyClient interface {
 	GetFieldOnItem(itemName, fieldName string) ([]byte, error)
 	GetInUseInformationForAllItems(optionalPrefix string) (map[string]SecretUsageComparer, error)
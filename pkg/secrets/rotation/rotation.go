@@ -0,0 +1,457 @@
+// Package rotation rotates the items generated by ci-secret-generator: it
+// generates a new version of an item, waits for ci-secret-bootstrap to
+// propagate it to the clusters, verifies that its consumers work with it and
+// revokes the previous version. The progress of every item is recorded in a
+// state file, so that an interrupted rotation resumes where it stopped.
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreclientset "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
+	"github.com/openshift/ci-tools/pkg/api/secretgenerator"
+	"github.com/openshift/ci-tools/pkg/secrets"
+)
+
+// Phase is the last step of its rotation an item completed
+type Phase string
+
+const (
+	// PhasePending items have not started their rotation
+	PhasePending Phase = ""
+	// PhaseBackedUp items have their current version kept in the fields with
+	// the secrets.PreviousVersionSuffix
+	PhaseBackedUp Phase = "BackedUp"
+	// PhaseGenerated items have their new version in Vault
+	PhaseGenerated Phase = "Generated"
+	// PhasePropagated items have their new version in all the secrets which
+	// ci-secret-bootstrap creates from them
+	PhasePropagated Phase = "Propagated"
+	// PhaseVerified items have consumers which work with the new version
+	PhaseVerified Phase = "Verified"
+	// PhaseRevoked items have their previous version revoked and are rotated
+	PhaseRevoked Phase = "Revoked"
+)
+
+const (
+	currentDirEnv  = "CURRENT_DIR"
+	previousDirEnv = "PREVIOUS_DIR"
+)
+
+// ItemState is the progress of the rotation of an item
+type ItemState struct {
+	Phase   Phase     `json:"phase"`
+	Updated time.Time `json:"updated"`
+	// HasPrevious is set when the item existed before the rotation, so that
+	// there is a previous version to revoke
+	HasPrevious bool `json:"has_previous,omitempty"`
+	// Error is why the last attempt to complete the next phase failed
+	Error string `json:"error,omitempty"`
+}
+
+// State is the progress of a rotation, persisted to a file after every phase
+type State struct {
+	Items map[string]*ItemState `json:"items"`
+
+	path string
+}
+
+// LoadState reads the state of a rotation, which is empty if the file does
+// not exist yet
+func LoadState(path string) (*State, error) {
+	state := &State{Items: map[string]*ItemState{}, path: path}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	if err := json.Unmarshal(raw, state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+	if state.Items == nil {
+		state.Items = map[string]*ItemState{}
+	}
+	return state, nil
+}
+
+// save writes the state atomically, so that an interruption never leaves a
+// partial state behind
+func (s *State) save() error {
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+func (s *State) item(name string) *ItemState {
+	if _, ok := s.Items[name]; !ok {
+		s.Items[name] = &ItemState{}
+	}
+	return s.Items[name]
+}
+
+// Done determines whether all the items are rotated
+func (s *State) Done(items secretgenerator.Config) bool {
+	for _, item := range items {
+		if state, ok := s.Items[item.ItemName]; !ok || state.Phase != PhaseRevoked {
+			return false
+		}
+	}
+	return true
+}
+
+// Remove deletes the state file once the rotation is complete, so that the
+// next rotation starts over
+func (s *State) Remove() error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove state: %w", err)
+	}
+	return nil
+}
+
+// Rotator rotates items, recording its progress in a state
+type Rotator struct {
+	client   secrets.Client
+	config   secretbootstrap.Config
+	clusters map[string]coreclientset.SecretsGetter
+	state    *State
+
+	interval time.Duration
+	timeout  time.Duration
+
+	execute func(ctx context.Context, command string, env []string) ([]byte, error)
+	now     func() time.Time
+}
+
+// NewRotator creates a rotator for items stored with the client. The secrets
+// in the bootstrap config are read with the clients of their clusters to
+// determine when a new version is propagated, waiting for up to the timeout.
+func NewRotator(client secrets.Client, config secretbootstrap.Config, clusters map[string]coreclientset.SecretsGetter, state *State, interval, timeout time.Duration) *Rotator {
+	return &Rotator{
+		client:   client,
+		config:   config,
+		clusters: clusters,
+		state:    state,
+		interval: interval,
+		timeout:  timeout,
+		execute:  executeCommand,
+		now:      time.Now,
+	}
+}
+
+// Rotate completes the rotation of an item, resuming from the last phase it
+// completed
+func (r *Rotator) Rotate(ctx context.Context, item secretgenerator.SecretItem) error {
+	logger := logrus.WithField("item", item.ItemName)
+	state := r.state.item(item.ItemName)
+	for state.Phase != PhaseRevoked {
+		logger.WithField("phase", state.Phase).Info("Rotating item.")
+		next, err := r.next(ctx, item, state)
+		state.Updated = r.now()
+		if err != nil {
+			state.Error = err.Error()
+			if err := r.state.save(); err != nil {
+				logger.WithError(err).Error("Failed to save state.")
+			}
+			return fmt.Errorf("failed to rotate item %s: %w", item.ItemName, err)
+		}
+		state.Phase, state.Error = next, ""
+		if err := r.state.save(); err != nil {
+			return err
+		}
+	}
+	logger.Info("Item is rotated.")
+	return nil
+}
+
+// next completes the phase after the one the item is in
+func (r *Rotator) next(ctx context.Context, item secretgenerator.SecretItem, state *ItemState) (Phase, error) {
+	switch state.Phase {
+	case PhasePending:
+		return PhaseBackedUp, r.backUp(item, state)
+	case PhaseBackedUp:
+		return PhaseGenerated, r.generate(ctx, item)
+	case PhaseGenerated:
+		return PhasePropagated, r.waitForPropagation(ctx, item)
+	case PhasePropagated:
+		return PhaseVerified, r.verify(ctx, item)
+	case PhaseVerified:
+		return PhaseRevoked, r.revoke(ctx, item, state)
+	default:
+		return "", fmt.Errorf("unknown phase %q", state.Phase)
+	}
+}
+
+// backUp keeps the current version of the fields of an item, which are then
+// overwritten with the new version. Items which do not exist yet have no
+// previous version.
+func (r *Rotator) backUp(item secretgenerator.SecretItem, state *ItemState) error {
+	exists, err := r.client.HasItem(item.ItemName)
+	if err != nil {
+		return fmt.Errorf("failed to determine whether the item exists: %w", err)
+	}
+	if !exists {
+		state.HasPrevious = false
+		return nil
+	}
+	for _, field := range item.Fields {
+		value, err := r.client.GetFieldOnItem(item.ItemName, field.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get field %s: %w", field.Name, err)
+		}
+		if err := r.client.SetFieldOnItem(item.ItemName, field.Name+secrets.PreviousVersionSuffix, value); err != nil {
+			return fmt.Errorf("failed to back up field %s: %w", field.Name, err)
+		}
+	}
+	state.HasPrevious = true
+	return nil
+}
+
+func (r *Rotator) generate(ctx context.Context, item secretgenerator.SecretItem) error {
+	for _, field := range item.Fields {
+		value, err := r.execute(ctx, field.Cmd, nil)
+		if err != nil {
+			return fmt.Errorf("failed to generate field %s: %w", field.Name, err)
+		}
+		if len(bytes.TrimSpace(value)) == 0 || string(bytes.TrimSpace(value)) == "null" {
+			return fmt.Errorf("failed to generate field %s: command %q returned no output", field.Name, field.Cmd)
+		}
+		if err := r.client.SetFieldOnItem(item.ItemName, field.Name, value); err != nil {
+			return fmt.Errorf("failed to upload field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// consumer is a key of a secret created by ci-secret-bootstrap from a field
+type consumer struct {
+	secretbootstrap.SecretContext
+	key          string
+	field        string
+	base64Decode bool
+	// registry is set when the field is the auth of a registry in a
+	// dockerconfigjson key
+	registry string
+}
+
+func (c consumer) String() string {
+	return fmt.Sprintf("%s/%s[%s] in cluster %s", c.Namespace, c.Name, c.key, c.Cluster)
+}
+
+// consumersOf determines the keys of the secrets which ci-secret-bootstrap
+// creates from the fields of an item
+func consumersOf(config secretbootstrap.Config, item secretgenerator.SecretItem) []consumer {
+	prefix := ""
+	if config.VaultDPTPPrefix != "" {
+		prefix = config.VaultDPTPPrefix + "/"
+	}
+	fields := sets.New[string]()
+	for _, field := range item.Fields {
+		fields.Insert(field.Name)
+	}
+	matches := func(name, field string) bool {
+		return strings.TrimPrefix(name, prefix) == item.ItemName && fields.Has(field)
+	}
+	var ret []consumer
+	for _, secret := range config.Secrets {
+		var keys []consumer
+		for key, from := range secret.From {
+			if matches(from.Item, from.Field) {
+				keys = append(keys, consumer{key: key, field: from.Field, base64Decode: from.Base64Decode})
+			}
+			for _, data := range from.DockerConfigJSONData {
+				if matches(data.Item, data.AuthField) {
+					keys = append(keys, consumer{key: key, field: data.AuthField, registry: data.RegistryURL})
+				}
+			}
+		}
+		for _, to := range secret.To {
+			for _, key := range keys {
+				key.SecretContext = to
+				ret = append(ret, key)
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].String() < ret[j].String() })
+	return ret
+}
+
+// Consumers lists the keys of the secrets which ci-secret-bootstrap creates
+// from the fields of an item
+func Consumers(config secretbootstrap.Config, item secretgenerator.SecretItem) []string {
+	var ret []string
+	for _, c := range consumersOf(config, item) {
+		ret = append(ret, c.String())
+	}
+	return ret
+}
+
+// waitForPropagation waits until every secret created from the item has the
+// new version, which ci-secret-bootstrap copies from Vault periodically
+func (r *Rotator) waitForPropagation(ctx context.Context, item secretgenerator.SecretItem) error {
+	consumers := consumersOf(r.config, item)
+	if len(consumers) == 0 {
+		logrus.WithField("item", item.ItemName).Warn("No secret is created from the item, nothing to wait for.")
+		return nil
+	}
+	var pending []string
+	err := wait.PollUntilContextTimeout(ctx, r.interval, r.timeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		pending, err = r.pending(ctx, item, consumers)
+		return len(pending) == 0, err
+	})
+	if err != nil && len(pending) != 0 {
+		return fmt.Errorf("the new version was not propagated to %s: %w", strings.Join(pending, ", "), err)
+	}
+	return err
+}
+
+// pending lists the consumers which do not have the new version yet
+func (r *Rotator) pending(ctx context.Context, item secretgenerator.SecretItem, consumers []consumer) ([]string, error) {
+	values := map[string][]byte{}
+	var pending []string
+	for _, c := range consumers {
+		client, ok := r.clusters[c.Cluster]
+		if !ok {
+			return nil, fmt.Errorf("no client for cluster %s", c.Cluster)
+		}
+		if _, ok := values[c.field]; !ok {
+			value, err := r.client.GetFieldOnItem(item.ItemName, c.field)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get field %s: %w", c.field, err)
+			}
+			values[c.field] = value
+		}
+		secret, err := client.Secrets(c.Namespace).Get(ctx, c.Name, metav1.GetOptions{})
+		if err != nil {
+			if !kerrors.IsNotFound(err) {
+				logrus.WithError(err).WithField("secret", c.String()).Warn("Failed to get secret.")
+			}
+			pending = append(pending, c.String())
+			continue
+		}
+		propagated, err := c.has(secret.Data[c.key], values[c.field])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c, err)
+		}
+		if !propagated {
+			pending = append(pending, c.String())
+		}
+	}
+	return pending, nil
+}
+
+// has determines whether the data of a key holds the value of the field
+func (c consumer) has(data, value []byte) (bool, error) {
+	if c.registry != "" {
+		var config secretbootstrap.DockerConfigJSON
+		if err := json.Unmarshal(data, &config); err != nil {
+			return false, nil
+		}
+		return config.Auths[c.registry].Auth == string(bytes.TrimSpace(value)), nil
+	}
+	if c.base64Decode {
+		decoded, err := base64.StdEncoding.DecodeString(string(value))
+		if err != nil {
+			return false, fmt.Errorf("failed to decode field %s: %w", c.field, err)
+		}
+		value = decoded
+	}
+	return bytes.Equal(data, value), nil
+}
+
+func (r *Rotator) verify(ctx context.Context, item secretgenerator.SecretItem) error {
+	if item.Rotation == nil || item.Rotation.Verify == "" {
+		return nil
+	}
+	current, err := r.writeFields(item, "")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(current)
+	if _, err := r.execute(ctx, item.Rotation.Verify, []string{currentDirEnv + "=" + current}); err != nil {
+		return fmt.Errorf("failed to verify the new version: %w", err)
+	}
+	return nil
+}
+
+func (r *Rotator) revoke(ctx context.Context, item secretgenerator.SecretItem, state *ItemState) error {
+	if item.Rotation == nil || item.Rotation.Revoke == "" || !state.HasPrevious {
+		return nil
+	}
+	current, err := r.writeFields(item, "")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(current)
+	previous, err := r.writeFields(item, secrets.PreviousVersionSuffix)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(previous)
+	if _, err := r.execute(ctx, item.Rotation.Revoke, []string{currentDirEnv + "=" + current, previousDirEnv + "=" + previous}); err != nil {
+		return fmt.Errorf("failed to revoke the previous version: %w", err)
+	}
+	return nil
+}
+
+// writeFields writes a version of the fields of an item to a file per field
+// in a temporary directory, for the commands of the rotation to use
+func (r *Rotator) writeFields(item secretgenerator.SecretItem, suffix string) (string, error) {
+	dir, err := os.MkdirTemp("", "ci-secret-rotator-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	for _, field := range item.Fields {
+		value, err := r.client.GetFieldOnItem(item.ItemName, field.Name+suffix)
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("failed to get field %s: %w", field.Name+suffix, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, field.Name), value, 0600); err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("failed to write field %s: %w", field.Name, err)
+		}
+	}
+	return dir, nil
+}
+
+func executeCommand(ctx context.Context, command string, env []string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "bash", "-o", "errexit", "-o", "nounset", "-o", "pipefail", "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run command %q: %w\nerror output:\n%s", command, err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
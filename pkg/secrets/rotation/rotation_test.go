@@ -0,0 +1,219 @@
+package rotation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	coreclientset "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
+	"github.com/openshift/ci-tools/pkg/api/secretgenerator"
+	"github.com/openshift/ci-tools/pkg/secrets"
+)
+
+type fakeClient struct {
+	items map[string]map[string][]byte
+}
+
+func (c *fakeClient) GetFieldOnItem(itemName, fieldName string) ([]byte, error) {
+	value, ok := c.items[itemName][fieldName]
+	if !ok {
+		return nil, fmt.Errorf("item %s has no field %s", itemName, fieldName)
+	}
+	return value, nil
+}
+
+func (c *fakeClient) GetInUseInformationForAllItems(string) (map[string]secrets.SecretUsageComparer, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) GetUserSecrets() (map[types.NamespacedName]map[string]string, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) HasItem(itemName string) (bool, error) {
+	_, ok := c.items[itemName]
+	return ok, nil
+}
+
+func (c *fakeClient) SetFieldOnItem(itemName, fieldName string, fieldValue []byte) error {
+	if c.items[itemName] == nil {
+		c.items[itemName] = map[string][]byte{}
+	}
+	c.items[itemName][fieldName] = fieldValue
+	return nil
+}
+
+func (c *fakeClient) UpdateNotesOnItem(string, string) error {
+	return nil
+}
+
+func TestConsumersOf(t *testing.T) {
+	config := secretbootstrap.Config{
+		VaultDPTPPrefix: "dptp",
+		Secrets: []secretbootstrap.SecretConfig{{
+			From: map[string]secretbootstrap.ItemContext{
+				"token":     {Item: "dptp/item", Field: "token"},
+				"encoded":   {Item: "dptp/item", Field: "token", Base64Decode: true},
+				"other":     {Item: "dptp/other", Field: "token"},
+				"unrotated": {Item: "dptp/item", Field: "unrotated"},
+			},
+			To: []secretbootstrap.SecretContext{{Cluster: "app.ci", Namespace: "ci", Name: "secret"}, {Cluster: "build01", Namespace: "ci", Name: "secret"}},
+		}, {
+			From: map[string]secretbootstrap.ItemContext{
+				coreapi.DockerConfigJsonKey: {DockerConfigJSONData: []secretbootstrap.DockerConfigJSONData{
+					{Item: "dptp/item", AuthField: "token", RegistryURL: "quay.io"},
+					{Item: "dptp/other", AuthField: "token", RegistryURL: "registry.ci.openshift.org"},
+				}},
+			},
+			To: []secretbootstrap.SecretContext{{Cluster: "app.ci", Namespace: "ci", Name: "pull-secret", Type: coreapi.SecretTypeDockerConfigJson}},
+		}},
+	}
+	item := secretgenerator.SecretItem{ItemName: "item", Fields: []secretgenerator.FieldGenerator{{Name: "token"}}}
+	var actual []string
+	for _, c := range consumersOf(config, item) {
+		actual = append(actual, fmt.Sprintf("%s base64:%t registry:%s", c, c.base64Decode, c.registry))
+	}
+	expected := []string{
+		"ci/pull-secret[.dockerconfigjson] in cluster app.ci base64:false registry:quay.io",
+		"ci/secret[encoded] in cluster app.ci base64:true registry:",
+		"ci/secret[encoded] in cluster build01 base64:true registry:",
+		"ci/secret[token] in cluster app.ci base64:false registry:",
+		"ci/secret[token] in cluster build01 base64:false registry:",
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected consumers: %s", diff)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	config := secretbootstrap.Config{
+		Secrets: []secretbootstrap.SecretConfig{{
+			From: map[string]secretbootstrap.ItemContext{"token": {Item: "item", Field: "token"}},
+			To:   []secretbootstrap.SecretContext{{Cluster: "build01", Namespace: "ci", Name: "secret"}},
+		}},
+	}
+	item := secretgenerator.SecretItem{
+		ItemName: "item",
+		Fields:   []secretgenerator.FieldGenerator{{Name: "token", Cmd: "generate"}},
+		Rotation: &secretgenerator.Rotation{Verify: "verify", Revoke: "revoke"},
+	}
+	client := &fakeClient{items: map[string]map[string][]byte{"item": {"token": []byte("old")}}}
+	cluster := fake.NewSimpleClientset(&coreapi.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "secret"},
+		Data:       map[string][]byte{"token": []byte("old")},
+	})
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	state, err := LoadState(statePath)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+
+	var executed []string
+	execute := func(_ context.Context, command string, env []string) ([]byte, error) {
+		line := command
+		for _, variable := range env {
+			name, dir, _ := strings.Cut(variable, "=")
+			raw, err := os.ReadFile(filepath.Join(dir, "token"))
+			if err != nil {
+				return nil, err
+			}
+			line += fmt.Sprintf(" %s:%s", name, raw)
+		}
+		executed = append(executed, line)
+		return []byte("new"), nil
+	}
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	newRotator := func(state *State) *Rotator {
+		r := NewRotator(client, config, map[string]coreclientset.SecretsGetter{"build01": cluster.CoreV1()}, state, time.Millisecond, 10*time.Millisecond)
+		r.execute, r.now = execute, func() time.Time { return now }
+		return r
+	}
+
+	// ci-secret-bootstrap has not propagated the new version yet
+	err = newRotator(state).Rotate(context.Background(), item)
+	if err == nil || !strings.Contains(err.Error(), "the new version was not propagated to ci/secret[token] in cluster build01") {
+		t.Fatalf("expected the rotation to time out waiting for the propagation, got %v", err)
+	}
+	state, err = LoadState(statePath)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if phase := state.Items["item"].Phase; phase != PhaseGenerated || state.Items["item"].Error == "" {
+		t.Fatalf("expected the item to be generated with an error, got %#v", state.Items["item"])
+	}
+	if diff := cmp.Diff(map[string][]byte{"token": []byte("new"), "token.previous": []byte("old")}, client.items["item"]); diff != "" {
+		t.Errorf("unexpected item: %s", diff)
+	}
+
+	// the rotation resumes once the new version is propagated
+	if _, err := cluster.CoreV1().Secrets("ci").Update(context.Background(), &coreapi.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "secret"},
+		Data:       map[string][]byte{"token": []byte("new")},
+	}, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+	if err := newRotator(state).Rotate(context.Background(), item); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"generate", "verify CURRENT_DIR:new", "revoke CURRENT_DIR:new PREVIOUS_DIR:old"}, executed); diff != "" {
+		t.Errorf("unexpected commands: %s", diff)
+	}
+	state, err = LoadState(statePath)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if diff := cmp.Diff(map[string]*ItemState{"item": {Phase: PhaseRevoked, Updated: now, HasPrevious: true}}, state.Items); diff != "" {
+		t.Errorf("unexpected state: %s", diff)
+	}
+	if !state.Done(secretgenerator.Config{item}) {
+		t.Error("expected the rotation to be done")
+	}
+
+	// a rotated item is not rotated again until the state is removed
+	if err := newRotator(state).Rotate(context.Background(), item); err != nil || len(executed) != 3 {
+		t.Errorf("expected the rotated item to be skipped, got %v and commands %v", err, executed)
+	}
+	if err := state.Remove(); err != nil {
+		t.Fatalf("failed to remove state: %v", err)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("expected the state to be removed, got %v", err)
+	}
+}
+
+func TestRotateNewItem(t *testing.T) {
+	item := secretgenerator.SecretItem{
+		ItemName: "item",
+		Fields:   []secretgenerator.FieldGenerator{{Name: "token", Cmd: "generate"}},
+		Rotation: &secretgenerator.Rotation{Revoke: "revoke"},
+	}
+	client := &fakeClient{items: map[string]map[string][]byte{}}
+	state, err := LoadState(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	r := NewRotator(client, secretbootstrap.Config{}, nil, state, time.Millisecond, time.Millisecond)
+	var executed []string
+	r.execute = func(_ context.Context, command string, _ []string) ([]byte, error) {
+		executed = append(executed, command)
+		return []byte("new"), nil
+	}
+	if err := r.Rotate(context.Background(), item); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"generate"}, executed); diff != "" {
+		t.Errorf("expected nothing to be revoked for a new item: %s", diff)
+	}
+}
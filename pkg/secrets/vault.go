@@ -158,6 +158,9 @@ func (c *vaultClient) GetInUseInformationForAllItems(optionalSubPath string) (ma
 			}
 			comparer := vaultSecretUsageComparer{item: *kvData, allFields: sets.Set[string]{}, inUseFields: sets.Set[string]{}}
 			for key := range kvData.Data {
+				if strings.HasSuffix(key, PreviousVersionSuffix) {
+					continue
+				}
 				comparer.allFields.Insert(key)
 			}
 			result[strings.TrimPrefix(key, c.prefix+"/")] = &comparer
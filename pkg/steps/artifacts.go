@@ -30,6 +30,7 @@ import (
 	buildapi "github.com/openshift/api/build/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/kubernetes"
 	"github.com/openshift/ci-tools/pkg/util"
@@ -378,6 +379,7 @@ type ArtifactWorker struct {
 	dir       string
 	podClient kubernetes.PodClient
 	namespace string
+	events    *events.Recorder
 
 	// Processing this requires the lock, so it must not be held
 	// when writing into it.
@@ -389,12 +391,13 @@ type ArtifactWorker struct {
 	hasArtifacts sets.Set[string]
 }
 
-func NewArtifactWorker(podClient kubernetes.PodClient, artifactDir, namespace string) *ArtifactWorker {
+func NewArtifactWorker(podClient kubernetes.PodClient, artifactDir, namespace string, recorder *events.Recorder) *ArtifactWorker {
 	// stream artifacts in the background
 	w := &ArtifactWorker{
 		podClient: podClient,
 		namespace: namespace,
 		dir:       artifactDir,
+		events:    recorder,
 
 		remaining:    make(podWaitRecord),
 		required:     make(podContainersMap),
@@ -410,8 +413,11 @@ func (w *ArtifactWorker) run() {
 	for podName := range w.podsToDownload {
 		logger := logrus.WithField("pod", podName)
 		logger.Trace("Processing Pod to download artifacts.")
-		if err := w.downloadArtifacts(podName, w.hasArtifacts.Has(podName)); err != nil {
+		hasArtifacts := w.hasArtifacts.Has(podName)
+		if err := w.downloadArtifacts(podName, hasArtifacts); err != nil {
 			logger.WithError(err).Trace("Error downloading artifacts.")
+		} else if hasArtifacts {
+			w.events.Emit(events.Event{Type: events.ArtifactsUploaded, Namespace: w.namespace, Pod: podName, Path: w.dir})
 		}
 		// indicate we are done with this pod by removing the map entry
 		w.lock.Lock()
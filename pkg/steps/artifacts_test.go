@@ -521,7 +521,7 @@ func TestArtifactWorker(t *testing.T) {
 		Namespace: "namespace",
 		Name:      pod,
 	}
-	w := NewArtifactWorker(podClient, tmp, "namespace")
+	w := NewArtifactWorker(podClient, tmp, "namespace", nil)
 	w.CollectFromPod(pod, []string{"container"}, nil)
 	w.Complete(pod)
 	select {
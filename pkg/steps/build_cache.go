@@ -0,0 +1,372 @@
+package steps
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildapi "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+// BuildCache shares pipeline images between executions. Once an image is
+// built, it is tagged into a common namespace under a hash of the inputs of
+// its build; executions in other namespaces that would run a build with the
+// same inputs tag the cached image into their pipeline instead.
+//
+// The cached images are stored in one image stream per pipeline image, e.g.
+// <namespace>/src:<hash>. Tags older than the maximum age are not used and are
+// removed whenever a new image is stored.
+type BuildCache struct {
+	namespace string
+	maxAge    time.Duration
+
+	lock    sync.Mutex
+	lookups map[string]BuildCacheLookups
+	now     func() time.Time
+}
+
+// BuildCacheLookups counts how often a pipeline image was found in the cache
+type BuildCacheLookups struct {
+	Hits   int
+	Misses int
+}
+
+// NewBuildCache returns a cache storing images in the namespace for maxAge
+func NewBuildCache(namespace string, maxAge time.Duration) *BuildCache {
+	return &BuildCache{namespace: namespace, maxAge: maxAge, lookups: map[string]BuildCacheLookups{}, now: time.Now}
+}
+
+// HitRate returns the number of lookups in the cache and the ratio of them
+// which were served from the cache
+func (c *BuildCache) HitRate() (int, float64) {
+	var hits, lookups int
+	for _, l := range c.Lookups() {
+		hits += l.Hits
+		lookups += l.Hits + l.Misses
+	}
+	if lookups == 0 {
+		return 0, 0
+	}
+	return lookups, float64(hits) / float64(lookups)
+}
+
+// Lookups returns the lookups in the cache by pipeline image
+func (c *BuildCache) Lookups() map[string]BuildCacheLookups {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ret := make(map[string]BuildCacheLookups, len(c.lookups))
+	for image, l := range c.lookups {
+		ret[image] = l
+	}
+	return ret
+}
+
+func (c *BuildCache) recordLookup(image string, hit bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	l := c.lookups[image]
+	if hit {
+		l.Hits++
+	} else {
+		l.Misses++
+	}
+	c.lookups[image] = l
+}
+
+// buildInputs are the parts of a build which determine its output. Images
+// and secrets are identified by their content rather than by their names,
+// which are local to the namespace and can point to new content over time.
+type buildInputs struct {
+	FromDigest     string        `json:"from_digest"`
+	Dockerfile     string        `json:"dockerfile,omitempty"`
+	DockerfilePath string        `json:"dockerfile_path,omitempty"`
+	ContextDir     string        `json:"context_dir,omitempty"`
+	Images         []imageInput  `json:"images,omitempty"`
+	Secrets        []secretInput `json:"secrets,omitempty"`
+	// Env includes the refs that are cloned into the source image
+	Env           []corev1.EnvVar `json:"env,omitempty"`
+	BuildArgs     []corev1.EnvVar `json:"build_args,omitempty"`
+	Architectures []string        `json:"architectures,omitempty"`
+}
+
+// imageInput is an image that paths are copied from, like clonerefs
+type imageInput struct {
+	Digest string                     `json:"digest"`
+	As     []string                   `json:"as,omitempty"`
+	Paths  []buildapi.ImageSourcePath `json:"paths,omitempty"`
+}
+
+// secretInput is a secret that is mounted into the build
+type secretInput struct {
+	DataHash       string `json:"data_hash"`
+	DestinationDir string `json:"destination_dir,omitempty"`
+}
+
+// resolveInputs identifies the images and secrets of the build by content
+func resolveInputs(ctx context.Context, client ctrlruntimeclient.Client, build *buildapi.Build) ([]imageInput, []secretInput, error) {
+	var images []imageInput
+	for _, image := range build.Spec.Source.Images {
+		digest, err := imageDigest(ctx, client, build.Namespace, image.From)
+		if err != nil {
+			return nil, nil, err
+		}
+		images = append(images, imageInput{Digest: digest, As: image.As, Paths: image.Paths})
+	}
+	var secrets []secretInput
+	for _, source := range build.Spec.Source.Secrets {
+		secret := &corev1.Secret{}
+		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: build.Namespace, Name: source.Secret.Name}, secret); err != nil {
+			return nil, nil, fmt.Errorf("could not get secret %s: %w", source.Secret.Name, err)
+		}
+		secrets = append(secrets, secretInput{DataHash: secretDataHash(secret), DestinationDir: source.DestinationDir})
+	}
+	return images, secrets, nil
+}
+
+// imageDigest resolves the digest of an image source of a build
+func imageDigest(ctx context.Context, client ctrlruntimeclient.Client, namespace string, ref corev1.ObjectReference) (string, error) {
+	switch ref.Kind {
+	case "ImageStreamTag":
+		if ref.Namespace != "" {
+			namespace = ref.Namespace
+		}
+		ist := &imagev1.ImageStreamTag{}
+		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: ref.Name}, ist); err != nil {
+			return "", fmt.Errorf("could not get image stream tag %s/%s: %w", namespace, ref.Name, err)
+		}
+		return ist.Image.Name, nil
+	case "DockerImage":
+		if _, digest, ok := strings.Cut(ref.Name, "@"); ok {
+			return digest, nil
+		}
+		return "", fmt.Errorf("image %s is not referenced by digest", ref.Name)
+	default:
+		return "", fmt.Errorf("unsupported image source %s %s", ref.Kind, ref.Name)
+	}
+}
+
+// secretDataHash hashes the keys and values of a secret
+func secretDataHash(secret *corev1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(secret.Data[key])
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// inputHash identifies the output of a build by its inputs
+func inputHash(build *buildapi.Build, fromDigest string, architectures []string, images []imageInput, secrets []secretInput) (string, error) {
+	inputs := buildInputs{
+		FromDigest:     fromDigest,
+		DockerfilePath: build.Spec.Strategy.DockerStrategy.DockerfilePath,
+		ContextDir:     build.Spec.Source.ContextDir,
+		Images:         images,
+		Secrets:        secrets,
+		Env:            build.Spec.Strategy.DockerStrategy.Env,
+		BuildArgs:      build.Spec.Strategy.DockerStrategy.BuildArgs,
+		Architectures:  architectures,
+	}
+	if build.Spec.Source.Dockerfile != nil {
+		inputs.Dockerfile = *build.Spec.Source.Dockerfile
+	}
+	raw, err := json.Marshal(inputs)
+	if err != nil {
+		return "", fmt.Errorf("could not serialize build inputs: %w", err)
+	}
+	hash := sha256.Sum256(raw)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Build produces the output of the build, either by tagging an image built
+// from the same inputs by another execution or by running the build and
+// storing the result. Failing to use the cache never fails the build.
+func (c *BuildCache) Build(ctx context.Context, client BuildClient, build *buildapi.Build, fromDigest string, run func() error) error {
+	if c == nil {
+		return run()
+	}
+	to, namespace := build.Name, build.Namespace
+	logger := logrus.WithFields(logrus.Fields{"build": to, "cache": c.namespace})
+	images, secrets, err := resolveInputs(ctx, client, build)
+	if err != nil {
+		logger.WithError(err).Warn("Could not resolve build inputs, not using the build cache.")
+		return run()
+	}
+	hash, err := inputHash(build, fromDigest, client.NodeArchitectures(), images, secrets)
+	if err != nil {
+		logger.WithError(err).Warn("Could not hash build inputs, not using the build cache.")
+		return run()
+	}
+	logger = logger.WithField("hash", hash)
+
+	digest, err := c.lookup(ctx, client, to, hash)
+	if err != nil {
+		logger.WithError(err).Warn("Could not look up the build cache.")
+	}
+	if digest != "" {
+		if err := c.tagFromCache(ctx, client, namespace, to, digest); err != nil {
+			logger.WithError(err).Warn("Could not tag the cached image, building instead.")
+		} else {
+			c.recordLookup(to, true)
+			events.FromContext(ctx).Emit(events.Event{Type: events.BuildCacheHit, Namespace: c.namespace, Image: to})
+			logrus.Infof("Reused %s from the build cache", to)
+			return nil
+		}
+	}
+	c.recordLookup(to, false)
+	events.FromContext(ctx).Emit(events.Event{Type: events.BuildCacheMiss, Namespace: c.namespace, Image: to})
+
+	if err := run(); err != nil {
+		return err
+	}
+	if err := c.store(ctx, client, namespace, to, hash); err != nil {
+		logger.WithError(err).Warn("Could not store the image in the build cache.")
+	}
+	if err := c.evict(ctx, client, to); err != nil {
+		logger.WithError(err).Warn("Could not evict expired images from the build cache.")
+	}
+	return nil
+}
+
+// lookup returns the digest of the image cached for the hash, if there is one
+// that has not expired
+func (c *BuildCache) lookup(ctx context.Context, client ctrlruntimeclient.Client, stream, hash string) (string, error) {
+	is := &imagev1.ImageStream{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: c.namespace, Name: stream}, is); err != nil {
+		if kerrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("could not get image stream %s/%s: %w", c.namespace, stream, err)
+	}
+	for _, tag := range is.Status.Tags {
+		if tag.Tag != hash || len(tag.Items) == 0 {
+			continue
+		}
+		if c.expired(tag.Items[0].Created) {
+			return "", nil
+		}
+		return tag.Items[0].Image, nil
+	}
+	return "", nil
+}
+
+func (c *BuildCache) expired(created metav1.Time) bool {
+	return c.now().Sub(created.Time) > c.maxAge
+}
+
+// tagFromCache tags the cached image into the pipeline and waits for the tag
+// to be imported
+func (c *BuildCache) tagFromCache(ctx context.Context, client ctrlruntimeclient.Client, namespace, to, digest string) error {
+	ist := &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s:%s", api.PipelineImageStream, to),
+			Namespace: namespace,
+		},
+		Tag: &imagev1.TagReference{
+			ReferencePolicy: imagev1.TagReferencePolicy{
+				Type: imagev1.LocalTagReferencePolicy,
+			},
+			From: &corev1.ObjectReference{
+				Kind:      "ImageStreamImage",
+				Name:      fmt.Sprintf("%s@%s", to, digest),
+				Namespace: c.namespace,
+			},
+			ImportPolicy: imagev1.TagImportPolicy{
+				ImportMode: imagev1.ImportModePreserveOriginal,
+			},
+		},
+	}
+	if err := client.Create(ctx, ist); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create image stream tag %s: %w", ist.Name, err)
+	}
+	importCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	return wait.PollImmediateUntil(10*time.Second, func() (bool, error) {
+		pipeline := &imagev1.ImageStream{}
+		if err := client.Get(importCtx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: api.PipelineImageStream}, pipeline); err != nil {
+			return false, err
+		}
+		_, exists := util.ResolvePullSpec(pipeline, to, true)
+		return exists, nil
+	}, importCtx.Done())
+}
+
+// store tags the image that was built into the cache under the hash
+func (c *BuildCache) store(ctx context.Context, client ctrlruntimeclient.Client, namespace, to, hash string) error {
+	built := &imagev1.ImageStreamTag{}
+	name := fmt.Sprintf("%s:%s", api.PipelineImageStream, to)
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, built); err != nil {
+		return fmt.Errorf("could not get image stream tag %s: %w", name, err)
+	}
+	ist := &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s:%s", to, hash),
+			Namespace: c.namespace,
+		},
+		Tag: &imagev1.TagReference{
+			ReferencePolicy: imagev1.TagReferencePolicy{
+				Type: imagev1.LocalTagReferencePolicy,
+			},
+			From: &corev1.ObjectReference{
+				Kind:      "ImageStreamImage",
+				Name:      fmt.Sprintf("%s@%s", api.PipelineImageStream, built.Image.Name),
+				Namespace: namespace,
+			},
+			ImportPolicy: imagev1.TagImportPolicy{
+				ImportMode: imagev1.ImportModePreserveOriginal,
+			},
+		},
+	}
+	if err := client.Create(ctx, ist); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create image stream tag %s/%s: %w", ist.Namespace, ist.Name, err)
+	}
+	return nil
+}
+
+// evict removes the tags of the cached image stream which have expired
+func (c *BuildCache) evict(ctx context.Context, client ctrlruntimeclient.Client, stream string) error {
+	is := &imagev1.ImageStream{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: c.namespace, Name: stream}, is); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("could not get image stream %s/%s: %w", c.namespace, stream, err)
+	}
+	for _, tag := range is.Status.Tags {
+		if len(tag.Items) == 0 || !c.expired(tag.Items[0].Created) {
+			continue
+		}
+		ist := &imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: c.namespace, Name: fmt.Sprintf("%s:%s", stream, tag.Tag)}}
+		if err := client.Delete(ctx, ist); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("could not delete image stream tag %s/%s: %w", ist.Namespace, ist.Name, err)
+		}
+		logrus.Debugf("Evicted %s/%s from the build cache", ist.Namespace, ist.Name)
+	}
+	return nil
+}
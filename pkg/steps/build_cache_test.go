@@ -0,0 +1,293 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildapi "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+)
+
+func TestInputHash(t *testing.T) {
+	dockerfile := "FROM pipeline:root"
+	build := &buildapi.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci-op-1", Name: "src", Labels: map[string]string{"build-id": "1"}},
+		Spec: buildapi.BuildSpec{CommonSpec: buildapi.CommonSpec{
+			Source: buildapi.BuildSource{Dockerfile: &dockerfile},
+			Strategy: buildapi.BuildStrategy{DockerStrategy: &buildapi.DockerBuildStrategy{
+				Env: []corev1.EnvVar{{Name: "CLONEREFS_OPTIONS", Value: `{"refs":[{"base_sha":"abc"}]}`}},
+			}},
+		}},
+	}
+	images := []imageInput{{Digest: "sha256:clonerefs", Paths: []buildapi.ImageSourcePath{{SourcePath: "/clonerefs", DestinationDir: "."}}}}
+	hash := func(build *buildapi.Build, digest string, architectures ...string) string {
+		ret, err := inputHash(build, digest, architectures, images, nil)
+		if err != nil {
+			t.Fatalf("failed to hash inputs: %v", err)
+		}
+		return ret
+	}
+	original := hash(build, "sha256:root")
+
+	other := build.DeepCopy()
+	other.Namespace, other.Labels = "ci-op-2", map[string]string{"build-id": "2"}
+	if hash(other, "sha256:root") != original {
+		t.Error("expected the namespace and metadata of the build not to change the hash")
+	}
+	if hash(build, "sha256:other") == original {
+		t.Error("expected the digest of the base image to change the hash")
+	}
+	if hash(build, "sha256:root", "arm64") == original {
+		t.Error("expected the architectures to change the hash")
+	}
+	images[0].Digest = "sha256:other"
+	if hash(build, "sha256:root") == original {
+		t.Error("expected the digest of an image source to change the hash")
+	}
+	images[0].Digest = "sha256:clonerefs"
+	other = build.DeepCopy()
+	other.Spec.Strategy.DockerStrategy.Env[0].Value = `{"refs":[{"base_sha":"def"}]}`
+	if hash(other, "sha256:root") == original {
+		t.Error("expected the refs to change the hash")
+	}
+	changed := "FROM pipeline:src"
+	other.Spec.Source.Dockerfile = &changed
+	if hash(other, "sha256:root") == original {
+		t.Error("expected the Dockerfile to change the hash")
+	}
+}
+
+func TestBuildCache(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	build := &buildapi.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci-op-1", Name: "src"},
+		Spec: buildapi.BuildSpec{CommonSpec: buildapi.CommonSpec{
+			Strategy: buildapi.BuildStrategy{DockerStrategy: &buildapi.DockerBuildStrategy{}},
+		}},
+	}
+	hash, err := inputHash(build, "sha256:root", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to hash inputs: %v", err)
+	}
+	cached := func(created time.Time) *imagev1.ImageStream {
+		return &imagev1.ImageStream{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "cache", Name: "src"},
+			Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{
+				{Tag: hash, Items: []imagev1.TagEvent{{Image: "sha256:cached", Created: metav1.NewTime(created)}}},
+			}},
+		}
+	}
+	cachedTag := &imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: "cache", Name: "src:" + hash}}
+	// the pipeline image stream as it is after the image was built or tagged
+	pipeline := &imagev1.ImageStream{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci-op-1", Name: api.PipelineImageStream},
+		Status: imagev1.ImageStreamStatus{
+			PublicDockerImageRepository: "registry/ci-op-1/pipeline",
+			Tags:                        []imagev1.NamedTagEventList{{Tag: "src", Items: []imagev1.TagEvent{{Image: "sha256:built"}}}},
+		},
+	}
+	built := &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci-op-1", Name: "pipeline:src"},
+		Image:      imagev1.Image{ObjectMeta: metav1.ObjectMeta{Name: "sha256:built"}},
+	}
+	tagFrom := func(namespace, name string) *corev1.ObjectReference {
+		return &corev1.ObjectReference{Kind: "ImageStreamImage", Namespace: namespace, Name: name}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		objects  []ctrlruntimeclient.Object
+		buildErr error
+
+		expectedBuilt   bool
+		expectedErr     bool
+		expectedTags    map[ctrlruntimeclient.ObjectKey]*corev1.ObjectReference
+		expectedDeleted []ctrlruntimeclient.ObjectKey
+		expectedHitRate float64
+	}{
+		{
+			name:    "cached image is tagged into the pipeline",
+			objects: []ctrlruntimeclient.Object{cached(now.Add(-time.Hour)), pipeline},
+			expectedTags: map[ctrlruntimeclient.ObjectKey]*corev1.ObjectReference{
+				{Namespace: "ci-op-1", Name: "pipeline:src"}: tagFrom("cache", "src@sha256:cached"),
+			},
+			expectedHitRate: 1,
+		},
+		{
+			name:          "nothing is cached, image is built and stored",
+			objects:       []ctrlruntimeclient.Object{pipeline, built},
+			expectedBuilt: true,
+			expectedTags: map[ctrlruntimeclient.ObjectKey]*corev1.ObjectReference{
+				{Namespace: "cache", Name: "src:" + hash}: tagFrom("ci-op-1", "pipeline@sha256:built"),
+			},
+		},
+		{
+			name:            "expired image is not used and is evicted",
+			objects:         []ctrlruntimeclient.Object{cached(now.Add(-48 * time.Hour)), cachedTag, pipeline, built},
+			expectedBuilt:   true,
+			expectedDeleted: []ctrlruntimeclient.ObjectKey{{Namespace: "cache", Name: "src:" + hash}},
+		},
+		{
+			name:          "failed build is not stored",
+			objects:       []ctrlruntimeclient.Object{pipeline},
+			buildErr:      errors.New("oops"),
+			expectedBuilt: true,
+			expectedErr:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.objects...).Build())
+			cache := NewBuildCache("cache", 24*time.Hour)
+			cache.now = func() time.Time { return now }
+			var ran bool
+			err := cache.Build(context.Background(), NewFakeBuildClient(client, ""), build, "sha256:root", func() error {
+				ran = true
+				return tc.buildErr
+			})
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if ran != tc.expectedBuilt {
+				t.Errorf("expected build to run: %t, ran: %t", tc.expectedBuilt, ran)
+			}
+			for key, expected := range tc.expectedTags {
+				ist := &imagev1.ImageStreamTag{}
+				if err := client.Get(context.Background(), key, ist); err != nil {
+					t.Fatalf("failed to get image stream tag %s: %v", key, err)
+				}
+				if diff := cmp.Diff(expected, ist.Tag.From); diff != "" {
+					t.Errorf("unexpected tag of %s: %s", key, diff)
+				}
+			}
+			for _, key := range tc.expectedDeleted {
+				if err := client.Get(context.Background(), key, &imagev1.ImageStreamTag{}); !kerrors.IsNotFound(err) {
+					t.Errorf("expected %s to be deleted, got %v", key, err)
+				}
+			}
+			lookups, hitRate := cache.HitRate()
+			if lookups != 1 || hitRate != tc.expectedHitRate {
+				t.Errorf("expected a hit rate of %v over 1 lookup, got %v over %d", tc.expectedHitRate, hitRate, lookups)
+			}
+		})
+	}
+}
+
+func TestResolveInputs(t *testing.T) {
+	build := func(images []buildapi.ImageSource, secrets []buildapi.SecretBuildSource) *buildapi.Build {
+		return &buildapi.Build{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ci-op-1", Name: "src"},
+			Spec: buildapi.BuildSpec{CommonSpec: buildapi.CommonSpec{
+				Source: buildapi.BuildSource{Images: images, Secrets: secrets},
+			}},
+		}
+	}
+	ist := func(namespace, name, digest string) *imagev1.ImageStreamTag {
+		return &imagev1.ImageStreamTag{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Image:      imagev1.Image{ObjectMeta: metav1.ObjectMeta{Name: digest}},
+		}
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci-op-1", Name: "pull-secret"},
+		Data:       map[string][]byte{"token": []byte("secret")},
+	}
+	paths := []buildapi.ImageSourcePath{{SourcePath: "/bin", DestinationDir: "."}}
+
+	for _, tc := range []struct {
+		name    string
+		build   *buildapi.Build
+		objects []ctrlruntimeclient.Object
+
+		expectedImages  []imageInput
+		expectedSecrets []secretInput
+		expectedErr     bool
+	}{
+		{
+			name: "pipeline image is resolved in the namespace of the build",
+			build: build([]buildapi.ImageSource{
+				{From: corev1.ObjectReference{Kind: "ImageStreamTag", Name: "pipeline:bin"}, Paths: paths},
+			}, nil),
+			objects:        []ctrlruntimeclient.Object{ist("ci-op-1", "pipeline:bin", "sha256:bin")},
+			expectedImages: []imageInput{{Digest: "sha256:bin", Paths: paths}},
+		},
+		{
+			name: "image in another namespace is resolved there",
+			build: build([]buildapi.ImageSource{
+				{From: corev1.ObjectReference{Kind: "ImageStreamTag", Namespace: "ocp", Name: "4.14:cli"}, Paths: paths},
+			}, nil),
+			objects:        []ctrlruntimeclient.Object{ist("ocp", "4.14:cli", "sha256:cli")},
+			expectedImages: []imageInput{{Digest: "sha256:cli", Paths: paths}},
+		},
+		{
+			name: "clonerefs image is referenced by digest",
+			build: build([]buildapi.ImageSource{
+				{From: corev1.ObjectReference{Kind: "DockerImage", Name: "registry/ci/clonerefs@sha256:clonerefs"}, Paths: paths},
+			}, nil),
+			expectedImages: []imageInput{{Digest: "sha256:clonerefs", Paths: paths}},
+		},
+		{
+			name: "image referenced by tag cannot be resolved",
+			build: build([]buildapi.ImageSource{
+				{From: corev1.ObjectReference{Kind: "DockerImage", Name: "registry/ci/clonerefs:latest"}, Paths: paths},
+			}, nil),
+			expectedErr: true,
+		},
+		{
+			name: "missing image cannot be resolved",
+			build: build([]buildapi.ImageSource{
+				{From: corev1.ObjectReference{Kind: "ImageStreamTag", Name: "pipeline:bin"}, Paths: paths},
+			}, nil),
+			expectedErr: true,
+		},
+		{
+			name:            "secret is identified by its data",
+			build:           build(nil, []buildapi.SecretBuildSource{{Secret: corev1.LocalObjectReference{Name: "pull-secret"}, DestinationDir: "/secret"}}),
+			objects:         []ctrlruntimeclient.Object{secret},
+			expectedSecrets: []secretInput{{DataHash: secretDataHash(secret), DestinationDir: "/secret"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.objects...).Build()
+			images, secrets, err := resolveInputs(context.Background(), client, tc.build)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expectedImages, images); diff != "" {
+				t.Errorf("unexpected images: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedSecrets, secrets); diff != "" {
+				t.Errorf("unexpected secrets: %s", diff)
+			}
+		})
+	}
+
+	changed := secret.DeepCopy()
+	changed.Data["token"] = []byte("rotated")
+	if secretDataHash(changed) == secretDataHash(secret) {
+		t.Error("expected the data of the secret to change its hash")
+	}
+}
+
+func TestNilBuildCache(t *testing.T) {
+	var cache *BuildCache
+	var ran bool
+	if err := cache.Build(context.Background(), nil, nil, "", func() error { ran = true; return nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ran {
+		t.Error("expected the build to run without a cache")
+	}
+}
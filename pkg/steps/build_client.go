@@ -4,11 +4,14 @@ import (
 	"context"
 	"io"
 
+	"github.com/sirupsen/logrus"
+
 	"k8s.io/client-go/rest"
 
 	buildapi "github.com/openshift/api/build/v1"
 	"github.com/openshift/client-go/build/clientset/versioned/scheme"
 
+	"github.com/openshift/ci-tools/pkg/manifestpusher"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 )
 
@@ -20,6 +23,12 @@ type BuildClient interface {
 	LocalRegistryDNS() string
 }
 
+// ManifestPusherProvider is implemented by build clients that push the
+// manifest lists of multi-architecture builds differently than manifest-tool
+type ManifestPusherProvider interface {
+	ManifestPusher(logger *logrus.Entry) manifestpusher.ManifestPusher
+}
+
 type buildClient struct {
 	loggingclient.LoggingClient
 	client                rest.Interface
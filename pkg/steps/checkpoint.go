@@ -0,0 +1,226 @@
+package steps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// CheckpointConfigMapName is the name of the ConfigMap in the test
+	// namespace that holds the completion state of the step graph.
+	CheckpointConfigMapName = "ci-operator-checkpoint"
+	checkpointKey           = "checkpoint.json"
+)
+
+// Checkpoint holds the completion state of the nodes of a step graph that
+// was executed in a namespace, so an interrupted execution can be resumed.
+type Checkpoint struct {
+	// InputHash is the hash of the inputs of the execution that
+	// recorded the checkpoint.
+	InputHash string `json:"input_hash"`
+	// Steps maps the names of steps that completed to their outputs.
+	Steps map[string]CompletedStep `json:"steps,omitempty"`
+}
+
+// CompletedStep records a step that finished successfully.
+type CompletedStep struct {
+	FinishedAt time.Time `json:"finished_at"`
+	// Creates holds the objects that the step produced.
+	Creates []CheckpointLink `json:"creates"`
+}
+
+// CheckpointLink is the serialized form of a link created by a step,
+// pointing to an ImageStream or ImageStreamTag in the test namespace.
+type CheckpointLink struct {
+	ImageStream string `json:"image_stream"`
+	Tag         string `json:"tag,omitempty"`
+}
+
+// checkpointLinksFor determines the objects a step creates. Only steps that
+// create objects we can verify later can be checkpointed.
+func checkpointLinksFor(step api.Step) ([]CheckpointLink, bool) {
+	creates := step.Creates()
+	if len(creates) == 0 {
+		return nil, false
+	}
+	links := make([]CheckpointLink, 0, len(creates))
+	for _, link := range creates {
+		stream, tag, ok := api.ImageStreamFor(link)
+		if !ok {
+			return nil, false
+		}
+		links = append(links, CheckpointLink{ImageStream: stream, Tag: tag})
+	}
+	return links, true
+}
+
+// CheckpointStore persists the completion state of a step graph.
+type CheckpointStore interface {
+	// Load returns the stored checkpoint or nil if none was recorded
+	// for the current inputs.
+	Load(ctx context.Context) (*Checkpoint, error)
+	// Record marks a step as completed.
+	Record(ctx context.Context, name string, step CompletedStep) error
+}
+
+type configMapCheckpointStore struct {
+	client    ctrlruntimeclient.Client
+	namespace string
+	inputHash string
+}
+
+// NewCheckpointStore returns a CheckpointStore that persists
+// the completion state in a ConfigMap in the test namespace.
+func NewCheckpointStore(client ctrlruntimeclient.Client, namespace, inputHash string) CheckpointStore {
+	return &configMapCheckpointStore{client: client, namespace: namespace, inputHash: inputHash}
+}
+
+func (s *configMapCheckpointStore) get(ctx context.Context) (*coreapi.ConfigMap, *Checkpoint, error) {
+	cm := &coreapi.ConfigMap{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.namespace, Name: CheckpointConfigMapName}, cm); err != nil {
+		return nil, nil, err
+	}
+	checkpoint := &Checkpoint{}
+	if err := json.Unmarshal([]byte(cm.Data[checkpointKey]), checkpoint); err != nil {
+		return nil, nil, fmt.Errorf("could not unmarshal checkpoint: %w", err)
+	}
+	return cm, checkpoint, nil
+}
+
+func (s *configMapCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	_, checkpoint, err := s.get(ctx)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not load checkpoint: %w", err)
+	}
+	if checkpoint.InputHash != s.inputHash {
+		logrus.Debugf("Ignoring checkpoint recorded for input hash %s, current input hash is %s.", checkpoint.InputHash, s.inputHash)
+		return nil, nil
+	}
+	return checkpoint, nil
+}
+
+func (s *configMapCheckpointStore) Record(ctx context.Context, name string, step CompletedStep) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, checkpoint, err := s.get(ctx)
+		create := kerrors.IsNotFound(err)
+		if err != nil && !create {
+			return err
+		}
+		if create {
+			cm = &coreapi.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: s.namespace, Name: CheckpointConfigMapName}}
+		}
+		if checkpoint == nil || checkpoint.InputHash != s.inputHash {
+			checkpoint = &Checkpoint{InputHash: s.inputHash}
+		}
+		if checkpoint.Steps == nil {
+			checkpoint.Steps = map[string]CompletedStep{}
+		}
+		checkpoint.Steps[name] = step
+		raw, err := json.Marshal(checkpoint)
+		if err != nil {
+			return fmt.Errorf("could not marshal checkpoint: %w", err)
+		}
+		cm.Data = map[string]string{checkpointKey: string(raw)}
+		if create {
+			return s.client.Create(ctx, cm)
+		}
+		return s.client.Update(ctx, cm)
+	})
+}
+
+// ResumableSteps determines which nodes of the graph were completed by the
+// execution that recorded the checkpoint and whose outputs still exist in
+// the namespace, so they do not need to run again.
+func ResumableSteps(ctx context.Context, client ctrlruntimeclient.Client, namespace string, graph api.StepGraph, checkpoint *Checkpoint) (sets.Set[string], error) {
+	resumable := sets.New[string]()
+	if checkpoint == nil {
+		return resumable, nil
+	}
+	var errs []error
+	graph.IterateAllEdges(func(node *api.StepNode) {
+		name := node.Step.Name()
+		completed, recorded := checkpoint.Steps[name]
+		if !recorded {
+			return
+		}
+		links, ok := checkpointLinksFor(node.Step)
+		if !ok || !reflect.DeepEqual(links, completed.Creates) {
+			logrus.Debugf("Step %s changed its outputs since it was checkpointed, it will run again.", name)
+			return
+		}
+		for _, link := range links {
+			exists, err := checkpointLinkExists(ctx, client, namespace, link)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("could not verify outputs of step %s: %w", name, err))
+				return
+			}
+			if !exists {
+				logrus.Infof("Output of step %s no longer exists, it will run again.", name)
+				return
+			}
+		}
+		resumable.Insert(name)
+	})
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return nil, err
+	}
+	// a step that runs again produces new outputs, so whatever consumes
+	// them needs to run again as well, even if it completed before
+	invalidated := sets.New[string]()
+	var invalidate func(nodes []*api.StepNode)
+	invalidate = func(nodes []*api.StepNode) {
+		for _, node := range nodes {
+			if name := node.Step.Name(); !invalidated.Has(name) {
+				invalidated.Insert(name)
+				invalidate(node.Children)
+			}
+		}
+	}
+	graph.IterateAllEdges(func(node *api.StepNode) {
+		if !resumable.Has(node.Step.Name()) {
+			invalidate(node.Children)
+		}
+	})
+	if invalidated.Intersection(resumable).Len() > 0 {
+		logrus.Infof("Steps that depend on steps that will run again will also run again: %v", sets.List(invalidated.Intersection(resumable)))
+	}
+	return resumable.Difference(invalidated), nil
+}
+
+func checkpointLinkExists(ctx context.Context, client ctrlruntimeclient.Client, namespace string, link CheckpointLink) (bool, error) {
+	var obj ctrlruntimeclient.Object
+	name := link.ImageStream
+	if link.Tag == "" {
+		obj = &imagev1.ImageStream{}
+	} else {
+		obj = &imagev1.ImageStreamTag{}
+		name = fmt.Sprintf("%s:%s", link.ImageStream, link.Tag)
+	}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
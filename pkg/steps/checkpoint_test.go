@@ -0,0 +1,206 @@
+package steps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	client := fakectrlruntimeclient.NewClientBuilder().Build()
+	store := NewCheckpointStore(client, "ns", "hash")
+
+	checkpoint, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error loading missing checkpoint: %v", err)
+	}
+	if checkpoint != nil {
+		t.Fatalf("expected no checkpoint, got %v", checkpoint)
+	}
+
+	finished := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	src := CompletedStep{FinishedAt: finished, Creates: []CheckpointLink{{ImageStream: "pipeline", Tag: "src"}}}
+	bin := CompletedStep{FinishedAt: finished, Creates: []CheckpointLink{{ImageStream: "pipeline", Tag: "bin"}}}
+	if err := store.Record(ctx, "src", src); err != nil {
+		t.Fatalf("unexpected error recording step: %v", err)
+	}
+	if err := store.Record(ctx, "bin", bin); err != nil {
+		t.Fatalf("unexpected error recording step: %v", err)
+	}
+
+	checkpoint, err = store.Load(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error loading checkpoint: %v", err)
+	}
+	expected := &Checkpoint{InputHash: "hash", Steps: map[string]CompletedStep{"src": src, "bin": bin}}
+	if diff := cmp.Diff(expected, checkpoint); diff != "" {
+		t.Errorf("unexpected checkpoint: %s", diff)
+	}
+
+	checkpoint, err = NewCheckpointStore(client, "ns", "other-hash").Load(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error loading checkpoint: %v", err)
+	}
+	if checkpoint != nil {
+		t.Errorf("expected checkpoint for different inputs to be ignored, got %v", checkpoint)
+	}
+}
+
+func TestResumableSteps(t *testing.T) {
+	src := &fakeStep{
+		name:     "src",
+		requires: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceRoot)},
+		creates:  []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)},
+	}
+	bin := &fakeStep{
+		name:     "bin",
+		requires: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)},
+		creates:  []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceBinaries)},
+	}
+	release := &fakeStep{
+		name:    "release",
+		creates: []api.StepLink{api.ReleaseImagesLink(api.LatestReleaseName)},
+	}
+	test := &fakeStep{
+		name:     "unit",
+		requires: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)},
+	}
+	graph := api.BuildGraph([]api.Step{src, bin, release, test})
+
+	checkpoint := &Checkpoint{
+		InputHash: "hash",
+		Steps: map[string]CompletedStep{
+			"src":     {Creates: []CheckpointLink{{ImageStream: "pipeline", Tag: "src"}}},
+			"bin":     {Creates: []CheckpointLink{{ImageStream: "pipeline", Tag: "bin"}}},
+			"release": {Creates: []CheckpointLink{{ImageStream: "stable"}}},
+			"unit":    {},
+		},
+	}
+
+	var testCases = []struct {
+		name       string
+		checkpoint *Checkpoint
+		objects    []ctrlruntimeclient.Object
+		expected   sets.Set[string]
+	}{
+		{
+			name:     "no checkpoint",
+			expected: sets.New[string](),
+		},
+		{
+			name:       "all outputs exist",
+			checkpoint: checkpoint,
+			objects: []ctrlruntimeclient.Object{
+				&imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline:src"}},
+				&imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline:bin"}},
+				&imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "stable"}},
+			},
+			expected: sets.New[string]("src", "bin", "release"),
+		},
+		{
+			name:       "missing outputs are not resumed",
+			checkpoint: checkpoint,
+			objects: []ctrlruntimeclient.Object{
+				&imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline:src"}},
+				&imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "pipeline:bin"}},
+			},
+			expected: sets.New[string]("src"),
+		},
+		{
+			name:       "steps depending on steps that run again are not resumed",
+			checkpoint: checkpoint,
+			objects: []ctrlruntimeclient.Object{
+				&imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline:bin"}},
+				&imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "stable"}},
+			},
+			expected: sets.New[string]("release"),
+		},
+		{
+			name: "changed outputs are not resumed",
+			checkpoint: &Checkpoint{
+				InputHash: "hash",
+				Steps: map[string]CompletedStep{
+					"src": {Creates: []CheckpointLink{{ImageStream: "pipeline", Tag: "root"}}},
+				},
+			},
+			objects: []ctrlruntimeclient.Object{
+				&imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline:src"}},
+				&imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline:root"}},
+			},
+			expected: sets.New[string](),
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(testCase.objects...).Build()
+			actual, err := ResumableSteps(context.Background(), client, "ns", graph, testCase.checkpoint)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(testCase.expected, actual); diff != "" {
+				t.Errorf("unexpected resumable steps: %s", diff)
+			}
+		})
+	}
+}
+
+type fakeCheckpointStore struct {
+	recorded sets.Set[string]
+}
+
+func (f *fakeCheckpointStore) Load(context.Context) (*Checkpoint, error) { return nil, nil }
+
+func (f *fakeCheckpointStore) Record(_ context.Context, name string, _ CompletedStep) error {
+	f.recorded.Insert(name)
+	return nil
+}
+
+func TestRunWithCheckpoints(t *testing.T) {
+	root := &fakeStep{
+		name:    "root",
+		creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceRoot)},
+	}
+	src := &fakeStep{
+		name:     "src",
+		requires: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceRoot)},
+		creates:  []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)},
+	}
+	bin := &fakeStep{
+		name:     "bin",
+		requires: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)},
+		creates:  []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceBinaries)},
+	}
+	test := &fakeStep{
+		name:     "unit",
+		requires: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceBinaries)},
+	}
+	store := &fakeCheckpointStore{recorded: sets.New[string]()}
+	suites, _, errs := Run(context.Background(), api.BuildGraph([]api.Step{root, src, bin, test}), WithCheckpoints(store, sets.New[string]("root", "src")))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	for step, expected := range map[*fakeStep]int{root: 0, src: 0, bin: 1, test: 1} {
+		if step.numRuns != expected {
+			t.Errorf("expected step %s to run %d times, ran %d times", step.name, expected, step.numRuns)
+		}
+	}
+	if diff := cmp.Diff(sets.New[string]("bin"), store.recorded); diff != "" {
+		t.Errorf("unexpected recorded steps: %s", diff)
+	}
+	if suite := suites.Suites[0]; suite.NumTests != 4 || suite.NumSkipped != 2 || suite.NumFailed != 0 {
+		t.Errorf("unexpected junit output: %#v", suite)
+	}
+}
@@ -0,0 +1,122 @@
+package dryrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+
+	buildapi "github.com/openshift/api/build/v1"
+	templateapi "github.com/openshift/api/template/v1"
+
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/manifestpusher"
+	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+)
+
+// pendingTimeout is never reached, since workloads complete when created
+const pendingTimeout = time.Hour
+
+var errNoCluster = errors.New("not available in a dry run")
+
+type podClient struct {
+	loggingclient.LoggingClient
+}
+
+// NewPodClient returns a pod client for a dry run. Pods produce no logs and
+// cannot be executed into.
+func NewPodClient(client loggingclient.LoggingClient) kubernetes.PodClient {
+	return &podClient{LoggingClient: client}
+}
+
+func (*podClient) GetPendingTimeout() time.Duration {
+	return pendingTimeout
+}
+
+func (c *podClient) WithNewLoggingClient() kubernetes.PodClient {
+	return &podClient{LoggingClient: c.New()}
+}
+
+func (*podClient) Exec(string, string, *coreapi.PodExecOptions) (remotecommand.Executor, error) {
+	return nil, errNoCluster
+}
+
+func (*podClient) GetLogs(string, string, *coreapi.PodLogOptions) *rest.Request {
+	return rest.NewRequestWithClient(nil, "", rest.ClientContentConfig{}, nil)
+}
+
+func (*podClient) GetPodMetrics(context.Context, string, string) (*kubernetes.PodMetrics, error) {
+	return nil, errNoCluster
+}
+
+type buildClient struct {
+	loggingclient.LoggingClient
+	cluster           *Cluster
+	nodeArchitectures []string
+}
+
+// NewBuildClient returns a build client for a dry run. Builds produce no logs
+// and manifest lists are tagged into the cluster directly.
+func NewBuildClient(client loggingclient.LoggingClient, cluster *Cluster, nodeArchitectures []string) steps.BuildClient {
+	return &buildClient{LoggingClient: client, cluster: cluster, nodeArchitectures: nodeArchitectures}
+}
+
+func (c *buildClient) ManifestPusher(*logrus.Entry) manifestpusher.ManifestPusher {
+	return &manifestPusher{cluster: c.cluster}
+}
+
+type manifestPusher struct {
+	cluster *Cluster
+}
+
+// PushImageWithManifest tags the target, a <namespace>/<stream>:<tag> reference
+func (p *manifestPusher) PushImageWithManifest(_ []buildapi.Build, targetImageRef string) error {
+	namespace, name, found := strings.Cut(targetImageRef, "/")
+	if !found {
+		return fmt.Errorf("invalid target image %q", targetImageRef)
+	}
+	stream, tag, err := splitImageStreamTagName(name)
+	if err != nil {
+		return err
+	}
+	return p.cluster.tagInto(context.TODO(), namespace, stream, tag)
+}
+
+func (*buildClient) Logs(string, string, *buildapi.BuildLogOptions) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func (c *buildClient) NodeArchitectures() []string {
+	return c.nodeArchitectures
+}
+
+func (*buildClient) ManifestToolDockerCfg() string {
+	return ""
+}
+
+func (*buildClient) LocalRegistryDNS() string {
+	return ""
+}
+
+type templateClient struct {
+	loggingclient.LoggingClient
+}
+
+// NewTemplateClient returns a template client for a dry run, which returns
+// templates without processing them
+func NewTemplateClient(client loggingclient.LoggingClient) steps.TemplateClient {
+	return &templateClient{LoggingClient: client}
+}
+
+func (*templateClient) Process(_ string, template *templateapi.Template) (*templateapi.Template, error) {
+	return template.DeepCopy(), nil
+}
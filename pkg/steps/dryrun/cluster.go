@@ -0,0 +1,372 @@
+// Package dryrun allows ci-operator to execute without a cluster. Objects are
+// stored in memory by a fake client and the parts of the platform ci-operator
+// depends on are emulated: workloads succeed as soon as they are created and
+// images are tagged into ImageStreams immediately. Every object ci-operator
+// creates is recorded, so that the execution can be reviewed as a whole.
+package dryrun
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	authapi "k8s.io/api/authorization/v1"
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	buildapi "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+	templateapi "github.com/openshift/api/template/v1"
+)
+
+// Registry is the host the images of the emulated ImageStreams are served from
+const Registry = "registry.dry-run.local"
+
+// Cluster is an in-memory cluster that records the objects created in it
+type Cluster struct {
+	ctrlruntimeclient.WithWatch
+	// namespace resolves the namespace of the job, which is only known once
+	// the inputs of the execution have been resolved. Images in any other
+	// namespace are expected to exist.
+	namespace func() string
+
+	lock    sync.Mutex
+	created []ctrlruntimeclient.Object
+}
+
+// NewCluster creates an empty cluster for the job executing in namespace
+func NewCluster(namespace func() string) *Cluster {
+	return &Cluster{
+		WithWatch: fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(restMapper(scheme.Scheme)).Build(),
+		namespace: namespace,
+	}
+}
+
+// clusterScoped are the kinds ci-operator interacts with which are not namespaced
+var clusterScoped = sets.New[string]("Namespace", "Node", "ClusterRole", "ClusterRoleBinding", "SelfSubjectAccessReview", "Project", "ProjectRequest")
+
+// restMapper maps every kind known to the scheme, so that the scope of objects
+// can be determined
+func restMapper(s *runtime.Scheme) meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(s.PrioritizedVersionsAllGroups())
+	for gvk := range s.AllKnownTypes() {
+		scope := meta.RESTScopeNamespace
+		if clusterScoped.Has(gvk.Kind) {
+			scope = meta.RESTScopeRoot
+		}
+		mapper.Add(gvk, scope)
+	}
+	return mapper
+}
+
+// Created returns every object created so far, in creation order
+func (c *Cluster) Created() []ctrlruntimeclient.Object {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]ctrlruntimeclient.Object(nil), c.created...)
+}
+
+func (c *Cluster) record(obj ctrlruntimeclient.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return fmt.Errorf("could not determine the kind of %T: %w", obj, err)
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.created = append(c.created, obj)
+	return nil
+}
+
+func (c *Cluster) Create(ctx context.Context, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.CreateOption) error {
+	if o, ok := obj.(*authapi.SelfSubjectAccessReview); ok {
+		// reviews are not persisted and everything is allowed
+		o.Status.Allowed = true
+		return nil
+	}
+	requested := obj.DeepCopyObject().(ctrlruntimeclient.Object)
+	var err error
+	switch o := obj.(type) {
+	case *coreapi.Namespace:
+		err = c.createServiceAccounts(ctx, o.Name)
+	case *coreapi.Pod:
+		completePod(o)
+	case *buildapi.Build:
+		err = c.completeBuild(ctx, o)
+	case *imagev1.ImageStreamTag:
+		err = c.importImageStreamTag(ctx, o)
+	case *templateapi.TemplateInstance:
+		o.Status.Conditions = append(o.Status.Conditions, templateapi.TemplateInstanceCondition{
+			Type:   templateapi.TemplateInstanceReady,
+			Status: coreapi.ConditionTrue,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.WithWatch.Create(ctx, obj, opts...); err != nil {
+		return err
+	}
+	return c.record(requested)
+}
+
+func (c *Cluster) Get(ctx context.Context, key ctrlruntimeclient.ObjectKey, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.GetOption) error {
+	err := c.WithWatch.Get(ctx, key, obj, opts...)
+	if !kerrors.IsNotFound(err) || key.Namespace == "" || key.Namespace == c.namespace() {
+		return err
+	}
+	switch o := obj.(type) {
+	case *imagev1.ImageStream:
+		*o = *imageStreamFor(key.Namespace, key.Name)
+	case *imagev1.ImageStreamTag:
+		stream, tag, splitErr := splitImageStreamTagName(key.Name)
+		if splitErr != nil {
+			return err
+		}
+		*o = imagev1.ImageStreamTag{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+			Image:      imageFor(key.Namespace, stream, tag),
+		}
+	default:
+		return err
+	}
+	return nil
+}
+
+func (c *Cluster) List(ctx context.Context, list ctrlruntimeclient.ObjectList, opts ...ctrlruntimeclient.ListOption) error {
+	name, opts := nameSelector(opts)
+	if err := c.WithWatch.List(ctx, list, opts...); err != nil || name == "" {
+		return err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	var filtered []runtime.Object
+	for _, item := range items {
+		if o, ok := item.(metav1.Object); ok && o.GetName() == name {
+			filtered = append(filtered, item)
+		}
+	}
+	return meta.SetList(list, filtered)
+}
+
+func (c *Cluster) Watch(ctx context.Context, list ctrlruntimeclient.ObjectList, opts ...ctrlruntimeclient.ListOption) (watch.Interface, error) {
+	name, opts := nameSelector(opts)
+	w, err := c.WithWatch.Watch(ctx, list, opts...)
+	if err != nil || name == "" {
+		return w, err
+	}
+	return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+		o, ok := event.Object.(metav1.Object)
+		return event, ok && o.GetName() == name
+	}), nil
+}
+
+// nameSelector extracts a field selector on the name of objects from the
+// options, which the fake client only supports with indices.
+func nameSelector(opts []ctrlruntimeclient.ListOption) (string, []ctrlruntimeclient.ListOption) {
+	listOpts := &ctrlruntimeclient.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	var name string
+	if listOpts.FieldSelector != nil {
+		if value, ok := listOpts.FieldSelector.RequiresExactMatch("metadata.name"); ok {
+			name, listOpts.FieldSelector = value, nil
+		}
+	}
+	if listOpts.Raw != nil && listOpts.Raw.FieldSelector != "" {
+		if selector, err := fields.ParseSelector(listOpts.Raw.FieldSelector); err == nil {
+			if value, ok := selector.RequiresExactMatch("metadata.name"); ok {
+				name = value
+				raw := *listOpts.Raw
+				raw.FieldSelector = ""
+				listOpts.Raw = &raw
+			}
+		}
+	}
+	return name, []ctrlruntimeclient.ListOption{listOpts}
+}
+
+// createServiceAccounts emulates the service accounts minted for every new
+// namespace, along with their image pull secrets
+func (c *Cluster) createServiceAccounts(ctx context.Context, namespace string) error {
+	for _, name := range []string{"builder", "default"} {
+		sa := &coreapi.ServiceAccount{
+			ObjectMeta:       metav1.ObjectMeta{Namespace: namespace, Name: name},
+			ImagePullSecrets: []coreapi.LocalObjectReference{{Name: name + "-dockercfg"}},
+		}
+		if err := c.WithWatch.Create(ctx, sa); err != nil && !kerrors.IsAlreadyExists(err) {
+			return fmt.Errorf("could not create service account %s/%s: %w", namespace, name, err)
+		}
+	}
+	return nil
+}
+
+func completePod(pod *coreapi.Pod) {
+	now := metav1.Now()
+	pod.Status.Phase = coreapi.PodSucceeded
+	pod.Status.StartTime = &now
+	terminated := func(containers []coreapi.Container) []coreapi.ContainerStatus {
+		var ret []coreapi.ContainerStatus
+		for _, container := range containers {
+			ret = append(ret, coreapi.ContainerStatus{
+				Name: container.Name,
+				State: coreapi.ContainerState{Terminated: &coreapi.ContainerStateTerminated{
+					Reason:     "Completed",
+					StartedAt:  now,
+					FinishedAt: now,
+				}},
+			})
+		}
+		return ret
+	}
+	pod.Status.InitContainerStatuses = terminated(pod.Spec.InitContainers)
+	pod.Status.ContainerStatuses = terminated(pod.Spec.Containers)
+}
+
+func (c *Cluster) completeBuild(ctx context.Context, build *buildapi.Build) error {
+	now := metav1.Now()
+	build.Status.Phase = buildapi.BuildPhaseComplete
+	build.Status.StartTimestamp = &now
+	build.Status.CompletionTimestamp = &now
+	to := build.Spec.Output.To
+	if to == nil || to.Kind != "ImageStreamTag" {
+		return nil
+	}
+	namespace := to.Namespace
+	if namespace == "" {
+		namespace = build.Namespace
+	}
+	stream, tag, err := splitImageStreamTagName(to.Name)
+	if err != nil {
+		return err
+	}
+	return c.tagInto(ctx, namespace, stream, tag)
+}
+
+// importImageStreamTag resolves the image of a tag that was created and
+// records it in the status of the ImageStream
+func (c *Cluster) importImageStreamTag(ctx context.Context, ist *imagev1.ImageStreamTag) error {
+	stream, tag, err := splitImageStreamTagName(ist.Name)
+	if err != nil {
+		return err
+	}
+	ist.Image = imageFor(ist.Namespace, stream, tag)
+	return c.tagInto(ctx, ist.Namespace, stream, tag)
+}
+
+// tagInto records an image for the tag in the status of the ImageStream,
+// creating the ImageStream if necessary
+func (c *Cluster) tagInto(ctx context.Context, namespace, stream, tag string) error {
+	is := &imagev1.ImageStream{}
+	create := false
+	if err := c.WithWatch.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: stream}, is); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return fmt.Errorf("could not get image stream %s/%s: %w", namespace, stream, err)
+		}
+		is, create = imageStreamFor(namespace, stream), true
+	}
+	if is.Status.PublicDockerImageRepository == "" {
+		is.Status = imageStreamFor(namespace, stream).Status
+	}
+	image := imageFor(namespace, stream, tag)
+	event := imagev1.TagEvent{DockerImageReference: image.DockerImageReference, Image: image.Name}
+	found := false
+	for i := range is.Status.Tags {
+		if is.Status.Tags[i].Tag == tag {
+			is.Status.Tags[i].Items = []imagev1.TagEvent{event}
+			found = true
+		}
+	}
+	if !found {
+		is.Status.Tags = append(is.Status.Tags, imagev1.NamedTagEventList{Tag: tag, Items: []imagev1.TagEvent{event}})
+	}
+	if create {
+		return c.WithWatch.Create(ctx, is)
+	}
+	return c.WithWatch.Update(ctx, is)
+}
+
+func imageStreamFor(namespace, name string) *imagev1.ImageStream {
+	repository := fmt.Sprintf("%s/%s/%s", Registry, namespace, name)
+	return &imagev1.ImageStream{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Status: imagev1.ImageStreamStatus{
+			DockerImageRepository:       repository,
+			PublicDockerImageRepository: repository,
+		},
+	}
+}
+
+// imageFor returns a stable image for a tag, so the output is reproducible
+func imageFor(namespace, stream, tag string) imagev1.Image {
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(fmt.Sprintf("%s/%s:%s", namespace, stream, tag))))
+	return imagev1.Image{
+		ObjectMeta:           metav1.ObjectMeta{Name: digest},
+		DockerImageReference: fmt.Sprintf("%s/%s/%s@%s", Registry, namespace, stream, digest),
+	}
+}
+
+// redact replaces the values of a Secret with their length, so the output
+// shows which keys were set without leaking credentials like pull secrets.
+// Redacted values are written as `stringData` to keep them readable.
+func redact(obj ctrlruntimeclient.Object) ctrlruntimeclient.Object {
+	secret, ok := obj.(*coreapi.Secret)
+	if !ok || (len(secret.Data) == 0 && len(secret.StringData) == 0) {
+		return obj
+	}
+	ret := secret.DeepCopy()
+	ret.Data = nil
+	ret.StringData = map[string]string{}
+	for key, value := range secret.Data {
+		ret.StringData[key] = fmt.Sprintf("<redacted: %d bytes>", len(value))
+	}
+	for key, value := range secret.StringData {
+		ret.StringData[key] = fmt.Sprintf("<redacted: %d bytes>", len(value))
+	}
+	return ret
+}
+
+func splitImageStreamTagName(name string) (string, string, error) {
+	parts := strings.Split(name, ":")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid image stream tag name %q", name)
+	}
+	return parts[0], parts[1], nil
+}
+
+// Write writes every object created so far to a YAML file in dir, named after
+// its position in the creation order, its kind and name. The values of
+// Secrets are redacted.
+func (c *Cluster) Write(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not create output directory: %w", err)
+	}
+	for i, obj := range c.Created() {
+		raw, err := yaml.Marshal(redact(obj))
+		if err != nil {
+			return fmt.Errorf("could not marshal %s %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), err)
+		}
+		name := strings.NewReplacer(":", "-", "/", "-").Replace(obj.GetName())
+		path := filepath.Join(dir, fmt.Sprintf("%03d-%s-%s.yaml", i, strings.ToLower(obj.GetObjectKind().GroupVersionKind().Kind), name))
+		if err := os.WriteFile(path, raw, 0644); err != nil {
+			return fmt.Errorf("could not write %s: %w", path, err)
+		}
+	}
+	return nil
+}
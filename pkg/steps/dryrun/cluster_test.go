@@ -0,0 +1,163 @@
+package dryrun
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildapi "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+func init() {
+	if err := imagev1.AddToScheme(scheme.Scheme); err != nil {
+		panic(fmt.Sprintf("failed to add imagev1 to scheme: %v", err))
+	}
+	if err := buildapi.AddToScheme(scheme.Scheme); err != nil {
+		panic(fmt.Sprintf("failed to add buildv1 to scheme: %v", err))
+	}
+}
+
+func TestCluster(t *testing.T) {
+	ctx := context.Background()
+	cluster := NewCluster(func() string { return "ns" })
+
+	external := &imagev1.ImageStreamTag{}
+	if err := cluster.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ci", Name: "golang:1.21"}, external); err != nil {
+		t.Fatalf("expected images outside of the job namespace to exist: %v", err)
+	}
+	if diff := cmp.Diff(imageFor("ci", "golang", "1.21"), external.Image); diff != "" {
+		t.Errorf("unexpected external image: %s", diff)
+	}
+	if err := cluster.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "pipeline:src"}, &imagev1.ImageStreamTag{}); err == nil {
+		t.Error("expected images in the job namespace not to exist")
+	}
+
+	build := &buildapi.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "src"},
+		Spec: buildapi.BuildSpec{CommonSpec: buildapi.CommonSpec{Output: buildapi.BuildOutput{
+			To: &coreapi.ObjectReference{Kind: "ImageStreamTag", Name: "pipeline:src"},
+		}}},
+	}
+	pod := &coreapi.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "test"},
+		Spec:       coreapi.PodSpec{Containers: []coreapi.Container{{Name: "test"}}},
+	}
+	other := &coreapi.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "other"}}
+	secret := &coreapi.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pull-secret"},
+		Data:       map[string][]byte{coreapi.DockerConfigJsonKey: []byte(`{"auths":{"registry":{"auth":"hunter2"}}}`)},
+		StringData: map[string]string{"token": "correct-horse"},
+	}
+	for _, obj := range []ctrlruntimeclient.Object{build, pod, other, secret} {
+		if err := cluster.Create(ctx, obj); err != nil {
+			t.Fatalf("failed to create %s: %v", obj.GetName(), err)
+		}
+	}
+	if err := cluster.Create(ctx, &coreapi.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "test"}}); err == nil {
+		t.Error("expected an error creating a pod that exists")
+	}
+
+	if build.Status.Phase != buildapi.BuildPhaseComplete {
+		t.Errorf("expected the build to complete, got phase %q", build.Status.Phase)
+	}
+	pipeline := &imagev1.ImageStream{}
+	if err := cluster.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "pipeline"}, pipeline); err != nil {
+		t.Fatalf("failed to get the pipeline image stream: %v", err)
+	}
+	if _, ok := util.ResolvePullSpec(pipeline, "src", true); !ok {
+		t.Error("expected the output of the build to be tagged")
+	}
+
+	pods := &coreapi.PodList{}
+	if err := cluster.List(ctx, pods, &ctrlruntimeclient.ListOptions{Namespace: "ns", FieldSelector: fields.OneTermEqualSelector("metadata.name", "test")}); err != nil {
+		t.Fatalf("failed to list pods: %v", err)
+	}
+	if len(pods.Items) != 1 || pods.Items[0].Name != "test" {
+		t.Fatalf("expected to list only the selected pod, got %v", pods.Items)
+	}
+	if phase := pods.Items[0].Status.Phase; phase != coreapi.PodSucceeded {
+		t.Errorf("expected the pod to succeed, got phase %q", phase)
+	}
+
+	dir := t.TempDir()
+	if err := cluster.Write(dir); err != nil {
+		t.Fatalf("failed to write output: %v", err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	if diff := cmp.Diff([]string{"000-build-src.yaml", "001-pod-test.yaml", "002-pod-other.yaml", "003-secret-pull-secret.yaml"}, names); diff != "" {
+		t.Errorf("unexpected output: %s", diff)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "001-pod-test.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read pod: %v", err)
+	}
+	expected := `apiVersion: v1
+kind: Pod
+metadata:
+  creationTimestamp: null
+  name: test
+  namespace: ns
+spec:
+  containers:
+  - name: test
+    resources: {}
+status: {}
+`
+	if diff := cmp.Diff(expected, string(raw)); diff != "" {
+		t.Errorf("expected the pod to be recorded as it was created: %s", diff)
+	}
+
+	for _, file := range names {
+		raw, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		for _, value := range []string{"hunter2", "correct-horse", base64.StdEncoding.EncodeToString(secret.Data[coreapi.DockerConfigJsonKey])} {
+			if strings.Contains(string(raw), value) {
+				t.Errorf("expected the value of the secret to be redacted in %s, got:\n%s", file, raw)
+			}
+		}
+	}
+	raw, err = os.ReadFile(filepath.Join(dir, "003-secret-pull-secret.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read secret: %v", err)
+	}
+	expected = `apiVersion: v1
+kind: Secret
+metadata:
+  creationTimestamp: null
+  name: pull-secret
+  namespace: ns
+stringData:
+  .dockerconfigjson: '<redacted: 41 bytes>'
+  token: '<redacted: 13 bytes>'
+`
+	if diff := cmp.Diff(expected, string(raw)); diff != "" {
+		t.Errorf("expected the secret to be recorded with redacted values: %s", diff)
+	}
+	if len(secret.Data) != 1 || len(secret.StringData) != 1 {
+		t.Error("expected the secret in the cluster not to be redacted")
+	}
+}
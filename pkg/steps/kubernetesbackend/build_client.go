@@ -0,0 +1,61 @@
+package kubernetesbackend
+
+import (
+	"context"
+	"io"
+
+	coreapi "k8s.io/api/core/v1"
+	coreclientset "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	buildapi "github.com/openshift/api/build/v1"
+
+	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+)
+
+type buildClient struct {
+	loggingclient.LoggingClient
+	pods                  coreclientset.PodsGetter
+	nodeArchitectures     []string
+	manifestToolDockerCfg string
+	localRegistryDNS      string
+}
+
+// NewBuildClient returns a build client that reads the logs of builds from
+// the Pods that execute them. The client passed in is expected to have been
+// wrapped with Wrap so that Builds are served from Pods.
+func NewBuildClient(client loggingclient.LoggingClient, pods coreclientset.PodsGetter, nodeArchitectures []string, manifestToolDockerCfg, localRegistryDNS string) steps.BuildClient {
+	return &buildClient{
+		LoggingClient:         client,
+		pods:                  pods,
+		nodeArchitectures:     nodeArchitectures,
+		manifestToolDockerCfg: manifestToolDockerCfg,
+		localRegistryDNS:      localRegistryDNS,
+	}
+}
+
+func (c *buildClient) Logs(namespace, name string, options *buildapi.BuildLogOptions) (io.ReadCloser, error) {
+	podOptions := &coreapi.PodLogOptions{Container: BuildContainerName}
+	if options != nil {
+		podOptions.Follow = options.Follow
+		podOptions.Previous = options.Previous
+		podOptions.SinceSeconds = options.SinceSeconds
+		podOptions.SinceTime = options.SinceTime
+		podOptions.Timestamps = options.Timestamps
+		podOptions.TailLines = options.TailLines
+		podOptions.LimitBytes = options.LimitBytes
+	}
+	return c.pods.Pods(namespace).GetLogs(PodNameFor(name), podOptions).Stream(context.TODO())
+}
+
+func (c *buildClient) NodeArchitectures() []string {
+	return c.nodeArchitectures
+}
+
+func (c *buildClient) ManifestToolDockerCfg() string {
+	return c.manifestToolDockerCfg
+}
+
+func (c *buildClient) LocalRegistryDNS() string {
+	return c.localRegistryDNS
+}
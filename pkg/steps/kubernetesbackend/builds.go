@@ -0,0 +1,564 @@
+package kubernetesbackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildapi "github.com/openshift/api/build/v1"
+)
+
+const (
+	// BuildLabel marks the Pods that execute Builds and holds the name of the Build
+	BuildLabel = "ci.openshift.io/kubernetes-backend-build"
+	// buildKey holds the serialized Build in the ConfigMap next to the Pod that executes it
+	buildKey = "build.json"
+
+	// BuildContainerName is the name of the container running kaniko
+	BuildContainerName = "docker-build"
+	prepareContainer   = "prepare-context"
+	workspaceVolume    = "workspace"
+	workspaceDir       = "/workspace"
+	pullSecretDir      = "/kaniko/.docker"
+)
+
+// PodNameFor determines the name of the Pod that executes a Build,
+// following the naming OpenShift uses for build pods.
+func PodNameFor(build string) string {
+	return fmt.Sprintf("%s-build", build)
+}
+
+// buildConfigMapNameFor determines the name of the ConfigMap that holds a Build.
+// Builds can be larger than what fits into the annotations of the Pod.
+func buildConfigMapNameFor(build string) string {
+	return fmt.Sprintf("%s-build-spec", build)
+}
+
+// copyScript copies paths out of an image into the build context. The paths are
+// passed as pairs of arguments, the source and the destination directory, so they
+// are never interpreted by the shell.
+const copyScript = `set -o errexit
+while [ "$#" -gt 0 ]; do
+	mkdir -p "$2"
+	cp -a "$1" "$2/"
+	shift 2
+done
+`
+
+// prepareScript writes an inline Dockerfile, copies secrets into the context and
+// rewrites the FROM instructions to point to resolved images. The last stage gets
+// ARG instructions for all variables passed to the build, so they are exposed in
+// the environment of RUN instructions as OpenShift does for the strategy env.
+const prepareScript = `set -o errexit
+set -o nounset
+set -o pipefail
+if [ -n "${GIT_URI:-}" ]; then
+	git clone "${GIT_URI}" /workspace
+	if [ -n "${GIT_REF:-}" ]; then
+		git -C /workspace fetch origin "${GIT_REF}"
+		git -C /workspace checkout FETCH_HEAD
+	fi
+fi
+mkdir -p "$(dirname "${DOCKERFILE_PATH}")"
+if [ -n "${DOCKERFILE:-}" ]; then
+	printf '%s' "${DOCKERFILE}" > "${DOCKERFILE_PATH}"
+fi
+for secret in ${SECRETS:-}; do
+	mkdir -p "${secret#*=}"
+	cp -a "/var/run/secrets/build/${secret%%=*}/." "${secret#*=}/"
+done
+awk -v from="${FROM_IMAGE:-}" -v replacements="${REPLACEMENTS:-}" -v args="${BUILD_ARGS:-}" '
+BEGIN { n = split(replacements, r, " "); for (i = 1; i <= n; i++) { split(r[i], kv, "="); images[kv[1]] = kv[2] } }
+{ lines[NR] = $0 }
+toupper($1) == "FROM" { last = NR }
+END {
+	for (i = 1; i <= NR; i++) {
+		line = lines[i]
+		m = split(line, f, /[ \t]+/)
+		if (toupper(f[1]) == "FROM") {
+			image = f[2]
+			if (i == last && from != "") { image = from } else if (f[2] in images) { image = images[f[2]] }
+			line = "FROM " image
+			for (j = 3; j <= m; j++) { line = line " " f[j] }
+		}
+		print line
+		if (i == last) { k = split(args, a, " "); for (j = 1; j <= k; j++) { print "ARG " a[j] } }
+	}
+}' "${DOCKERFILE_PATH}" > "${DOCKERFILE_PATH}.rewritten"
+mv "${DOCKERFILE_PATH}.rewritten" "${DOCKERFILE_PATH}"
+`
+
+// resolveImage determines the pull spec of an image referenced by a Build
+func (c *client) resolveImage(ctx context.Context, namespace string, ref coreapi.ObjectReference) (string, error) {
+	if ref.Kind == "DockerImage" {
+		return ref.Name, nil
+	}
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+	switch ref.Kind {
+	case "ImageStreamTag":
+		stream, tag, err := splitImageStreamTagName(ref.Name)
+		if err != nil {
+			return "", err
+		}
+		image, err := c.registry.resolve(ctx, c.repositoryFor(namespace, stream), tag)
+		if err != nil {
+			return "", fmt.Errorf("could not resolve %s/%s: %w", namespace, ref.Name, err)
+		}
+		return fmt.Sprintf("%s@%s", c.pullSpecFor(namespace, stream), image.Digest), nil
+	case "ImageStreamImage":
+		stream, digest, ok := strings.Cut(ref.Name, "@")
+		if !ok {
+			return "", fmt.Errorf("invalid ImageStreamImage name %q, expected <stream>@<digest>", ref.Name)
+		}
+		return fmt.Sprintf("%s@%s", c.pullSpecFor(namespace, stream), digest), nil
+	default:
+		return "", fmt.Errorf("unsupported image reference kind %q", ref.Kind)
+	}
+}
+
+// destinationFor determines where kaniko pushes the output of a Build
+func (c *client) destinationFor(build *buildapi.Build) (string, error) {
+	to := build.Spec.Output.To
+	if to == nil {
+		return "", fmt.Errorf("build %s has no output", build.Name)
+	}
+	namespace := to.Namespace
+	if namespace == "" {
+		namespace = build.Namespace
+	}
+	switch to.Kind {
+	case "ImageStreamTag":
+		stream, tag, err := splitImageStreamTagName(to.Name)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s:%s", c.pullSpecFor(namespace, stream), tag), nil
+	case "DockerImage":
+		return to.Name, nil
+	default:
+		return "", fmt.Errorf("unsupported build output kind %q", to.Kind)
+	}
+}
+
+// podFor translates a Build into the Pod that executes it with kaniko
+func (c *client) podFor(ctx context.Context, build *buildapi.Build) (*coreapi.Pod, error) {
+	strategy := build.Spec.Strategy.DockerStrategy
+	if build.Spec.Strategy.Type != buildapi.DockerBuildStrategyType || strategy == nil {
+		return nil, fmt.Errorf("build %s: only the Docker strategy is supported by the Kubernetes backend", build.Name)
+	}
+	source := build.Spec.Source
+	if source.Git != nil && len(source.Images) > 0 {
+		return nil, fmt.Errorf("build %s: combining git and image sources is not supported by the Kubernetes backend", build.Name)
+	}
+	destination, err := c.destinationFor(build)
+	if err != nil {
+		return nil, err
+	}
+
+	contextDir := path.Join(workspaceDir, source.ContextDir)
+	dockerfilePath := strategy.DockerfilePath
+	if dockerfilePath == "" {
+		dockerfilePath = "Dockerfile"
+	}
+	env := []coreapi.EnvVar{{Name: "DOCKERFILE_PATH", Value: path.Join(contextDir, dockerfilePath)}}
+	if source.Dockerfile != nil {
+		env = append(env, coreapi.EnvVar{Name: "DOCKERFILE", Value: *source.Dockerfile})
+	}
+	if source.Git != nil {
+		env = append(env, coreapi.EnvVar{Name: "GIT_URI", Value: source.Git.URI}, coreapi.EnvVar{Name: "GIT_REF", Value: source.Git.Ref})
+	}
+	if strategy.From != nil {
+		from, err := c.resolveImage(ctx, build.Namespace, *strategy.From)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, coreapi.EnvVar{Name: "FROM_IMAGE", Value: from})
+	}
+
+	volumes := []coreapi.Volume{{Name: workspaceVolume, VolumeSource: coreapi.VolumeSource{EmptyDir: &coreapi.EmptyDirVolumeSource{}}}}
+	workspace := coreapi.VolumeMount{Name: workspaceVolume, MountPath: workspaceDir}
+	prepareMounts := []coreapi.VolumeMount{workspace}
+	var secrets []string
+	for i, secret := range source.Secrets {
+		name := fmt.Sprintf("build-secret-%d", i)
+		volumes = append(volumes, coreapi.Volume{Name: name, VolumeSource: coreapi.VolumeSource{Secret: &coreapi.SecretVolumeSource{SecretName: secret.Secret.Name}}})
+		prepareMounts = append(prepareMounts, coreapi.VolumeMount{Name: name, MountPath: fmt.Sprintf("/var/run/secrets/build/%d", i), ReadOnly: true})
+		secrets = append(secrets, fmt.Sprintf("%d=%s", i, path.Join(contextDir, secret.DestinationDir)))
+	}
+	if len(secrets) > 0 {
+		env = append(env, coreapi.EnvVar{Name: "SECRETS", Value: strings.Join(secrets, " ")})
+	}
+
+	var initContainers []coreapi.Container
+	var replacements []string
+	for i, image := range source.Images {
+		pullSpec, err := c.resolveImage(ctx, build.Namespace, image.From)
+		if err != nil {
+			return nil, err
+		}
+		for _, as := range image.As {
+			replacements = append(replacements, fmt.Sprintf("%s=%s", as, pullSpec))
+		}
+		if len(image.Paths) == 0 {
+			continue
+		}
+		name := fmt.Sprintf("copy-image-source-%d", i)
+		command := []string{"/bin/sh", "-c", copyScript, name}
+		for _, p := range image.Paths {
+			command = append(command, p.SourcePath, path.Join(workspaceDir, p.DestinationDir))
+		}
+		initContainers = append(initContainers, coreapi.Container{
+			Name:         name,
+			Image:        pullSpec,
+			Command:      command,
+			VolumeMounts: []coreapi.VolumeMount{workspace},
+		})
+	}
+	if len(replacements) > 0 {
+		env = append(env, coreapi.EnvVar{Name: "REPLACEMENTS", Value: strings.Join(replacements, " ")})
+	}
+
+	args := []string{
+		fmt.Sprintf("--context=dir://%s", contextDir),
+		fmt.Sprintf("--dockerfile=%s", path.Join(contextDir, dockerfilePath)),
+		fmt.Sprintf("--destination=%s", destination),
+	}
+	if strategy.NoCache {
+		args = append(args, "--cache=false")
+	}
+	if policy := strategy.ImageOptimizationPolicy; policy != nil && *policy != buildapi.ImageOptimizationNone {
+		args = append(args, "--single-snapshot")
+	}
+	if c.config.Insecure {
+		args = append(args, "--insecure", "--insecure-pull")
+	}
+	if c.config.SkipTLSVerify {
+		args = append(args, "--skip-tls-verify", "--skip-tls-verify-pull")
+	}
+	var argNames []string
+	for _, variable := range append(append([]coreapi.EnvVar{}, strategy.Env...), strategy.BuildArgs...) {
+		argNames = append(argNames, variable.Name)
+		args = append(args, fmt.Sprintf("--build-arg=%s=%s", variable.Name, variable.Value))
+	}
+	if len(argNames) > 0 {
+		env = append(env, coreapi.EnvVar{Name: "BUILD_ARGS", Value: strings.Join(argNames, " ")})
+	}
+	labels := append([]buildapi.ImageLabel{}, build.Spec.Output.ImageLabels...)
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	for _, label := range labels {
+		args = append(args, fmt.Sprintf("--label=%s=%s", label.Name, label.Value))
+	}
+
+	buildMounts := []coreapi.VolumeMount{workspace}
+	var pullSecrets []coreapi.LocalObjectReference
+	if secret := strategy.PullSecret; secret != nil {
+		pullSecrets = append(pullSecrets, *secret)
+		volumes = append(volumes, coreapi.Volume{Name: "pull-secret", VolumeSource: coreapi.VolumeSource{Secret: &coreapi.SecretVolumeSource{
+			SecretName: secret.Name,
+			Items:      []coreapi.KeyToPath{{Key: coreapi.DockerConfigJsonKey, Path: "config.json"}},
+		}}})
+		buildMounts = append(buildMounts, coreapi.VolumeMount{Name: "pull-secret", MountPath: pullSecretDir, ReadOnly: true})
+	}
+
+	initContainers = append(initContainers, coreapi.Container{
+		Name:         prepareContainer,
+		Image:        c.config.HelperImage,
+		Command:      []string{"/bin/sh", "-c", prepareScript},
+		Env:          env,
+		VolumeMounts: prepareMounts,
+	})
+
+	return &coreapi.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       build.Namespace,
+			Name:            PodNameFor(build.Name),
+			Labels:          buildLabelsFor(build),
+			OwnerReferences: build.OwnerReferences,
+		},
+		Spec: coreapi.PodSpec{
+			RestartPolicy:    coreapi.RestartPolicyNever,
+			NodeSelector:     build.Spec.NodeSelector,
+			ImagePullSecrets: pullSecrets,
+			InitContainers:   initContainers,
+			Containers: []coreapi.Container{{
+				Name:                     BuildContainerName,
+				Image:                    c.config.BuilderImage,
+				Args:                     args,
+				Resources:                build.Spec.Resources,
+				VolumeMounts:             buildMounts,
+				TerminationMessagePolicy: coreapi.TerminationMessageFallbackToLogsOnError,
+			}},
+			Volumes: volumes,
+		},
+	}, nil
+}
+
+func buildLabelsFor(build *buildapi.Build) map[string]string {
+	labels := map[string]string{BuildLabel: build.Name}
+	for key, value := range build.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// configMapForBuild stores a Build next to the Pod that executes it
+func configMapForBuild(build *buildapi.Build) (*coreapi.ConfigMap, error) {
+	serialized, err := json.Marshal(build)
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize build %s: %w", build.Name, err)
+	}
+	return &coreapi.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       build.Namespace,
+			Name:            buildConfigMapNameFor(build.Name),
+			Labels:          buildLabelsFor(build),
+			OwnerReferences: build.OwnerReferences,
+		},
+		Data: map[string]string{buildKey: string(serialized)},
+	}, nil
+}
+
+// buildFor reconstructs the Build a Pod executes from the ConfigMap that holds it,
+// with a status derived from the Pod
+func (c *client) buildFor(ctx context.Context, pod *coreapi.Pod) (*buildapi.Build, error) {
+	cm := &coreapi.ConfigMap{}
+	if err := c.WithWatch.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: pod.Namespace, Name: buildConfigMapNameFor(pod.Labels[BuildLabel])}, cm); err != nil {
+		return nil, fmt.Errorf("could not get the build executed by pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	return buildFromConfigMap(pod, cm)
+}
+
+func buildFromConfigMap(pod *coreapi.Pod, cm *coreapi.ConfigMap) (*buildapi.Build, error) {
+	build := &buildapi.Build{}
+	if err := json.Unmarshal([]byte(cm.Data[buildKey]), build); err != nil {
+		return nil, fmt.Errorf("could not deserialize build from ConfigMap %s/%s: %w", cm.Namespace, cm.Name, err)
+	}
+	build.Namespace = pod.Namespace
+	build.UID = pod.UID
+	build.ResourceVersion = pod.ResourceVersion
+	build.CreationTimestamp = pod.CreationTimestamp
+	build.DeletionTimestamp = pod.DeletionTimestamp
+	if build.Annotations == nil {
+		build.Annotations = map[string]string{}
+	}
+	build.Annotations[buildapi.BuildPodNameAnnotation] = pod.Name
+	build.Status = buildStatusFor(pod)
+	return build, nil
+}
+
+func buildStatusFor(pod *coreapi.Pod) buildapi.BuildStatus {
+	status := buildapi.BuildStatus{StartTimestamp: pod.Status.StartTime}
+	switch pod.Status.Phase {
+	case coreapi.PodPending, "":
+		status.Phase = buildapi.BuildPhasePending
+		if pod.Spec.NodeName == "" {
+			status.Phase = buildapi.BuildPhaseNew
+		}
+	case coreapi.PodRunning:
+		status.Phase = buildapi.BuildPhaseRunning
+	case coreapi.PodSucceeded:
+		status.Phase = buildapi.BuildPhaseComplete
+	default:
+		status.Phase = buildapi.BuildPhaseFailed
+		status.Reason = buildapi.StatusReasonGenericBuildFailed
+		status.Message = pod.Status.Message
+		if pod.Status.Reason == "Evicted" {
+			status.Reason = buildapi.StatusReasonBuildPodEvicted
+		}
+	}
+	for _, container := range append(append([]coreapi.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+		terminated := container.State.Terminated
+		if terminated == nil {
+			continue
+		}
+		if status.CompletionTimestamp == nil || status.CompletionTimestamp.Before(&terminated.FinishedAt) {
+			finished := terminated.FinishedAt
+			status.CompletionTimestamp = &finished
+		}
+		if status.Phase != buildapi.BuildPhaseFailed || terminated.ExitCode == 0 || status.LogSnippet != "" {
+			continue
+		}
+		switch {
+		case terminated.Reason == "OOMKilled":
+			status.Reason = buildapi.StatusReasonOutOfMemoryKilled
+		case container.Name == BuildContainerName:
+			status.Reason = buildapi.StatusReasonDockerBuildFailed
+		case container.Name == prepareContainer:
+			status.Reason = buildapi.StatusReasonManageDockerfileFailed
+		default:
+			status.Reason = buildapi.StatusReasonFetchImageContentFailed
+		}
+		status.Message = fmt.Sprintf("container %s exited with code %d", container.Name, terminated.ExitCode)
+		status.LogSnippet = terminated.Message
+	}
+	if status.StartTimestamp != nil && status.CompletionTimestamp != nil {
+		status.Duration = status.CompletionTimestamp.Sub(status.StartTimestamp.Time)
+	}
+	return status
+}
+
+func notFoundBuild(err error, name string) error {
+	if kerrors.IsNotFound(err) {
+		return kerrors.NewNotFound(buildapi.Resource("builds"), name)
+	}
+	return err
+}
+
+func (c *client) getBuild(ctx context.Context, key ctrlruntimeclient.ObjectKey, into *buildapi.Build) error {
+	pod := &coreapi.Pod{}
+	if err := c.WithWatch.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: key.Namespace, Name: PodNameFor(key.Name)}, pod); err != nil {
+		return notFoundBuild(err, key.Name)
+	}
+	build, err := c.buildFor(ctx, pod)
+	if err != nil {
+		return err
+	}
+	build.DeepCopyInto(into)
+	return nil
+}
+
+func (c *client) createBuild(ctx context.Context, build *buildapi.Build, opts ...ctrlruntimeclient.CreateOption) error {
+	pod, err := c.podFor(ctx, build)
+	if err != nil {
+		return err
+	}
+	cm, err := configMapForBuild(build)
+	if err != nil {
+		return err
+	}
+	// the Build is stored before its Pod exists, so whoever sees the Pod can find it
+	if err := c.WithWatch.Create(ctx, cm, opts...); err != nil {
+		if kerrors.IsAlreadyExists(err) {
+			return kerrors.NewAlreadyExists(buildapi.Resource("builds"), build.Name)
+		}
+		return err
+	}
+	if err := c.WithWatch.Create(ctx, pod, opts...); err != nil {
+		if deleteErr := c.WithWatch.Delete(ctx, cm); deleteErr != nil && !kerrors.IsNotFound(deleteErr) {
+			return fmt.Errorf("could not create pod for build %s: %w, and could not clean up its ConfigMap: %v", build.Name, err, deleteErr)
+		}
+		if kerrors.IsAlreadyExists(err) {
+			return kerrors.NewAlreadyExists(buildapi.Resource("builds"), build.Name)
+		}
+		return err
+	}
+	created, err := buildFromConfigMap(pod, cm)
+	if err != nil {
+		return err
+	}
+	created.DeepCopyInto(build)
+	return nil
+}
+
+func (c *client) deleteBuild(ctx context.Context, build *buildapi.Build, opts ...ctrlruntimeclient.DeleteOption) error {
+	pod := &coreapi.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: PodNameFor(build.Name)}}
+	if err := c.WithWatch.Delete(ctx, pod, opts...); err != nil {
+		return notFoundBuild(err, build.Name)
+	}
+	cm := &coreapi.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: buildConfigMapNameFor(build.Name)}}
+	if err := c.WithWatch.Delete(ctx, cm, opts...); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("could not delete the ConfigMap holding build %s: %w", build.Name, err)
+	}
+	return nil
+}
+
+// podListOptions translates options for listing Builds into options for
+// listing their Pods, mapping field selectors on the name of a Build.
+func podListOptions(opts ...ctrlruntimeclient.ListOption) (*ctrlruntimeclient.ListOptions, error) {
+	listOpts := &ctrlruntimeclient.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.FieldSelector != nil {
+		name, ok := listOpts.FieldSelector.RequiresExactMatch("metadata.name")
+		if !ok || len(listOpts.FieldSelector.Requirements()) != 1 {
+			return nil, fmt.Errorf("unsupported field selector for builds: %s", listOpts.FieldSelector)
+		}
+		listOpts.FieldSelector = fields.OneTermEqualSelector("metadata.name", PodNameFor(name))
+	}
+	if listOpts.Raw != nil {
+		raw := listOpts.Raw.DeepCopy()
+		if raw.FieldSelector != "" {
+			selector, err := fields.ParseSelector(raw.FieldSelector)
+			if err != nil {
+				return nil, err
+			}
+			name, ok := selector.RequiresExactMatch("metadata.name")
+			if !ok || len(selector.Requirements()) != 1 {
+				return nil, fmt.Errorf("unsupported field selector for builds: %s", raw.FieldSelector)
+			}
+			raw.FieldSelector = fields.OneTermEqualSelector("metadata.name", PodNameFor(name)).String()
+		}
+		listOpts.Raw = raw
+	}
+	if listOpts.LabelSelector == nil {
+		ctrlruntimeclient.HasLabels{BuildLabel}.ApplyToList(listOpts)
+	}
+	return listOpts, nil
+}
+
+func (c *client) listBuilds(ctx context.Context, list *buildapi.BuildList, opts ...ctrlruntimeclient.ListOption) error {
+	listOpts, err := podListOptions(opts...)
+	if err != nil {
+		return err
+	}
+	pods := &coreapi.PodList{}
+	if err := c.WithWatch.List(ctx, pods, listOpts); err != nil {
+		return err
+	}
+	list.ResourceVersion = pods.ResourceVersion
+	list.Items = nil
+	for i := range pods.Items {
+		if _, ok := pods.Items[i].Labels[BuildLabel]; !ok {
+			continue
+		}
+		build, err := c.buildFor(ctx, &pods.Items[i])
+		if err != nil {
+			return err
+		}
+		list.Items = append(list.Items, *build)
+	}
+	return nil
+}
+
+func (c *client) watchBuilds(ctx context.Context, opts ...ctrlruntimeclient.ListOption) (watch.Interface, error) {
+	listOpts, err := podListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	w, err := c.WithWatch.Watch(ctx, &coreapi.PodList{}, listOpts)
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+		pod, ok := in.Object.(*coreapi.Pod)
+		if !ok {
+			return in, true
+		}
+		if _, ok := pod.Labels[BuildLabel]; !ok {
+			return in, false
+		}
+		build, err := c.buildFor(ctx, pod)
+		if kerrors.IsNotFound(err) && in.Type == watch.Deleted {
+			// the ConfigMap holding a deleted Build can be gone before its Pod
+			build, err = &buildapi.Build{ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Labels[BuildLabel]}}, nil
+			build.Status = buildStatusFor(pod)
+		}
+		if err != nil {
+			return watch.Event{Type: watch.Error, Object: &metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}}, true
+		}
+		in.Object = runtime.Object(build)
+		return in, true
+	}), nil
+}
@@ -0,0 +1,295 @@
+package kubernetesbackend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildapi "github.com/openshift/api/build/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func testBuild() *buildapi.Build {
+	dockerfile := "FROM root\nCOPY . .\nFROM base AS final\nRUN make\n"
+	layers := buildapi.ImageOptimizationSkipLayers
+	return &buildapi.Build{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "bin",
+			Labels:    map[string]string{"created-by-ci": "true"},
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "imagestream-pipeline", UID: "uid"},
+			},
+		},
+		Spec: buildapi.BuildSpec{
+			CommonSpec: buildapi.CommonSpec{
+				Source: buildapi.BuildSource{
+					Dockerfile: &dockerfile,
+					Images: []buildapi.ImageSource{{
+						From:  coreapi.ObjectReference{Kind: "ImageStreamTag", Name: "pipeline:src"},
+						As:    []string{"root"},
+						Paths: []buildapi.ImageSourcePath{{SourcePath: "/go/src/github.com/org/repo/.", DestinationDir: "."}},
+					}},
+					Secrets: []buildapi.SecretBuildSource{{Secret: coreapi.LocalObjectReference{Name: "secret"}, DestinationDir: "secrets"}},
+				},
+				Strategy: buildapi.BuildStrategy{
+					Type: buildapi.DockerBuildStrategyType,
+					DockerStrategy: &buildapi.DockerBuildStrategy{
+						From:                    &coreapi.ObjectReference{Kind: "ImageStreamTag", Name: "pipeline:root"},
+						PullSecret:              &coreapi.LocalObjectReference{Name: "registry-pull-credentials"},
+						NoCache:                 true,
+						Env:                     []coreapi.EnvVar{{Name: "BUILD_LOGLEVEL", Value: "0"}},
+						BuildArgs:               []coreapi.EnvVar{{Name: "TAGS", Value: "release"}},
+						ImageOptimizationPolicy: &layers,
+					},
+				},
+				Output: buildapi.BuildOutput{
+					To:          &coreapi.ObjectReference{Kind: "ImageStreamTag", Name: "pipeline:bin"},
+					ImageLabels: []buildapi.ImageLabel{{Name: "vcs-ref", Value: "abc"}, {Name: "io.openshift.build.name", Value: "bin"}},
+				},
+				Resources: coreapi.ResourceRequirements{
+					Requests: coreapi.ResourceList{coreapi.ResourceCPU: resource.MustParse("100m")},
+				},
+				NodeSelector: map[string]string{"kubernetes.io/arch": "amd64"},
+			},
+		},
+	}
+}
+
+func TestPodFor(t *testing.T) {
+	fake, host := newTestRegistry(t)
+	fake.push(t, "ns/pipeline", "src", "/go/src")
+	fake.push(t, "ns/pipeline", "root", "/")
+	c := Wrap(fakectrlruntimeclient.NewClientBuilder().Build(), Config{Registry: "registry.local:5000", Insecure: true, SkipTLSVerify: true}).(*client)
+	c.registry.host = host
+
+	pod, err := c.podFor(context.Background(), testBuild())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testhelper.CompareWithFixture(t, pod)
+
+	quoted := testBuild()
+	quoted.Spec.Source.Images[0].Paths = []buildapi.ImageSourcePath{{SourcePath: "/it's/here", DestinationDir: "'; rm -rf /"}}
+	pod, err = c.podFor(context.Background(), quoted)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"/it's/here", "/workspace/'; rm -rf "}, pod.Spec.InitContainers[0].Command[4:]); diff != "" {
+		t.Errorf("expected the paths to be passed as arguments: %s", diff)
+	}
+
+	unsupported := testBuild()
+	unsupported.Spec.Strategy = buildapi.BuildStrategy{Type: buildapi.SourceBuildStrategyType}
+	if _, err := c.podFor(context.Background(), unsupported); err == nil {
+		t.Errorf("expected an error for a source build")
+	}
+}
+
+func TestBuildStatusFor(t *testing.T) {
+	start := metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	finish := metav1.NewTime(start.Add(time.Minute))
+	terminated := func(name string, code int32, reason string) coreapi.ContainerStatus {
+		return coreapi.ContainerStatus{Name: name, State: coreapi.ContainerState{Terminated: &coreapi.ContainerStateTerminated{
+			ExitCode: code, Reason: reason, Message: "output", FinishedAt: finish,
+		}}}
+	}
+	var testCases = []struct {
+		name     string
+		pod      coreapi.Pod
+		expected buildapi.BuildStatus
+	}{
+		{
+			name:     "unscheduled pod is a new build",
+			pod:      coreapi.Pod{Status: coreapi.PodStatus{Phase: coreapi.PodPending}},
+			expected: buildapi.BuildStatus{Phase: buildapi.BuildPhaseNew},
+		},
+		{
+			name:     "scheduled pod is a pending build",
+			pod:      coreapi.Pod{Spec: coreapi.PodSpec{NodeName: "node"}, Status: coreapi.PodStatus{Phase: coreapi.PodPending}},
+			expected: buildapi.BuildStatus{Phase: buildapi.BuildPhasePending},
+		},
+		{
+			name:     "running pod",
+			pod:      coreapi.Pod{Status: coreapi.PodStatus{Phase: coreapi.PodRunning, StartTime: &start}},
+			expected: buildapi.BuildStatus{Phase: buildapi.BuildPhaseRunning, StartTimestamp: &start},
+		},
+		{
+			name: "succeeded pod",
+			pod: coreapi.Pod{Status: coreapi.PodStatus{
+				Phase:             coreapi.PodSucceeded,
+				StartTime:         &start,
+				ContainerStatuses: []coreapi.ContainerStatus{terminated(BuildContainerName, 0, "Completed")},
+			}},
+			expected: buildapi.BuildStatus{Phase: buildapi.BuildPhaseComplete, StartTimestamp: &start, CompletionTimestamp: &finish, Duration: time.Minute},
+		},
+		{
+			name: "failed build",
+			pod: coreapi.Pod{Status: coreapi.PodStatus{
+				Phase:             coreapi.PodFailed,
+				StartTime:         &start,
+				ContainerStatuses: []coreapi.ContainerStatus{terminated(BuildContainerName, 1, "Error")},
+			}},
+			expected: buildapi.BuildStatus{
+				Phase:               buildapi.BuildPhaseFailed,
+				Reason:              buildapi.StatusReasonDockerBuildFailed,
+				Message:             "container docker-build exited with code 1",
+				LogSnippet:          "output",
+				StartTimestamp:      &start,
+				CompletionTimestamp: &finish,
+				Duration:            time.Minute,
+			},
+		},
+		{
+			name: "failure to fetch image content",
+			pod: coreapi.Pod{Status: coreapi.PodStatus{
+				Phase:                 coreapi.PodFailed,
+				InitContainerStatuses: []coreapi.ContainerStatus{terminated("copy-image-source-0", 1, "Error")},
+			}},
+			expected: buildapi.BuildStatus{
+				Phase:               buildapi.BuildPhaseFailed,
+				Reason:              buildapi.StatusReasonFetchImageContentFailed,
+				Message:             "container copy-image-source-0 exited with code 1",
+				LogSnippet:          "output",
+				CompletionTimestamp: &finish,
+			},
+		},
+		{
+			name: "out of memory",
+			pod: coreapi.Pod{Status: coreapi.PodStatus{
+				Phase:             coreapi.PodFailed,
+				ContainerStatuses: []coreapi.ContainerStatus{terminated(BuildContainerName, 137, "OOMKilled")},
+			}},
+			expected: buildapi.BuildStatus{
+				Phase:               buildapi.BuildPhaseFailed,
+				Reason:              buildapi.StatusReasonOutOfMemoryKilled,
+				Message:             "container docker-build exited with code 137",
+				LogSnippet:          "output",
+				CompletionTimestamp: &finish,
+			},
+		},
+		{
+			name: "evicted",
+			pod:  coreapi.Pod{Status: coreapi.PodStatus{Phase: coreapi.PodFailed, Reason: "Evicted", Message: "node under pressure"}},
+			expected: buildapi.BuildStatus{
+				Phase:   buildapi.BuildPhaseFailed,
+				Reason:  buildapi.StatusReasonBuildPodEvicted,
+				Message: "node under pressure",
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if diff := cmp.Diff(testCase.expected, buildStatusFor(&testCase.pod)); diff != "" {
+				t.Errorf("unexpected build status: %s", diff)
+			}
+		})
+	}
+}
+
+func TestBuilds(t *testing.T) {
+	ctx := context.Background()
+	fake, host := newTestRegistry(t)
+	fake.push(t, "ns/pipeline", "src", "/go/src")
+	fake.push(t, "ns/pipeline", "root", "/")
+	upstream := fakectrlruntimeclient.NewClientBuilder().WithIndex(&coreapi.Pod{}, "metadata.name", func(o ctrlruntimeclient.Object) []string {
+		return []string{o.GetName()}
+	}).WithObjects(&coreapi.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "unrelated"}}).Build()
+	client := Wrap(upstream, Config{Registry: host, Insecure: true})
+
+	build := testBuild()
+	if err := client.Create(ctx, build); err != nil {
+		t.Fatalf("unexpected error creating build: %v", err)
+	}
+	if err := client.Create(ctx, testBuild()); !kerrors.IsAlreadyExists(err) {
+		t.Errorf("expected creating an existing build to fail with AlreadyExists, got %v", err)
+	}
+
+	pod := &coreapi.Pod{}
+	if err := upstream.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "bin-build"}, pod); err != nil {
+		t.Fatalf("expected build pod to be created: %v", err)
+	}
+	if err := upstream.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "bin-build-spec"}, &coreapi.ConfigMap{}); err != nil {
+		t.Fatalf("expected the build to be stored in a ConfigMap: %v", err)
+	}
+	pod.Status.Phase = coreapi.PodSucceeded
+	if err := upstream.Update(ctx, pod); err != nil {
+		t.Fatalf("could not update pod: %v", err)
+	}
+
+	actual := &buildapi.Build{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "bin"}, actual); err != nil {
+		t.Fatalf("unexpected error getting build: %v", err)
+	}
+	if actual.Status.Phase != buildapi.BuildPhaseComplete {
+		t.Errorf("expected build to be complete, got %s", actual.Status.Phase)
+	}
+	if actual.Annotations[buildapi.BuildPodNameAnnotation] != "bin-build" {
+		t.Errorf("expected build to reference its pod, got annotations %v", actual.Annotations)
+	}
+	if diff := cmp.Diff(testBuild().Spec, actual.Spec); diff != "" {
+		t.Errorf("unexpected build spec: %s", diff)
+	}
+
+	builds := &buildapi.BuildList{}
+	if err := client.List(ctx, builds, ctrlruntimeclient.InNamespace("ns"), ctrlruntimeclient.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector("metadata.name", "bin")}); err != nil {
+		t.Fatalf("unexpected error listing builds: %v", err)
+	}
+	if len(builds.Items) != 1 || builds.Items[0].Name != "bin" {
+		t.Errorf("expected to list the build, got %v", builds.Items)
+	}
+
+	w, err := client.Watch(ctx, &buildapi.BuildList{}, ctrlruntimeclient.InNamespace("ns"))
+	if err != nil {
+		t.Fatalf("unexpected error watching builds: %v", err)
+	}
+	defer w.Stop()
+	if err := client.Delete(ctx, build); err != nil {
+		t.Fatalf("unexpected error deleting build: %v", err)
+	}
+	select {
+	case event := <-w.ResultChan():
+		deleted, ok := event.Object.(*buildapi.Build)
+		if event.Type != watch.Deleted || !ok || deleted.Name != "bin" {
+			t.Errorf("expected a deletion event for the build, got %s for %T", event.Type, event.Object)
+		}
+	case <-time.After(10 * time.Second):
+		t.Errorf("timed out waiting for a watch event")
+	}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "bin"}, &buildapi.Build{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected deleted build to not be found, got %v", err)
+	}
+	if err := upstream.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "bin-build-spec"}, &coreapi.ConfigMap{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected the ConfigMap of the deleted build to be removed, got %v", err)
+	}
+}
+
+func TestPodListOptions(t *testing.T) {
+	opts, err := podListOptions(ctrlruntimeclient.InNamespace("ns"), &ctrlruntimeclient.ListOptions{
+		Raw: &metav1.ListOptions{FieldSelector: "metadata.name=bin", ResourceVersion: "1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Raw.FieldSelector != "metadata.name=bin-build" || opts.Raw.ResourceVersion != "1" {
+		t.Errorf("unexpected raw options: %#v", opts.Raw)
+	}
+	if opts.LabelSelector == nil || opts.LabelSelector.String() != BuildLabel {
+		t.Errorf("expected pods to be selected by the build label, got %v", opts.LabelSelector)
+	}
+	if _, err := podListOptions(ctrlruntimeclient.MatchingFields{"status.phase": "Running"}); err == nil {
+		t.Errorf("expected an error for an unsupported field selector")
+	}
+}
@@ -0,0 +1,155 @@
+// Package kubernetesbackend allows ci-operator to execute on clusters that do
+// not serve the OpenShift Build and ImageStream APIs. Builds are stored in
+// ConfigMaps and executed by kaniko in plain Pods, and ImageStreams are backed
+// by repositories in an OCI registry, laid out as
+// <registry>/<namespace>/<imagestream>:<tag>.
+package kubernetesbackend
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/watch"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildapi "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+)
+
+const (
+	// DefaultBuilderImage is the kaniko executor used to run builds
+	DefaultBuilderImage = "gcr.io/kaniko-project/executor:v1.15.0"
+	// DefaultHelperImage prepares the build context, it needs to provide sh, awk and git
+	DefaultHelperImage = "docker.io/alpine/git:2.40.1"
+)
+
+// Config configures the Kubernetes backend.
+type Config struct {
+	// Registry is the host of the OCI registry that holds the contents of ImageStreams.
+	Registry string
+	// Insecure allows connections to the registry over plain HTTP.
+	Insecure bool
+	// SkipTLSVerify disables the verification of the TLS certificate of the registry.
+	SkipTLSVerify bool
+	// BuilderImage is the kaniko executor image that runs builds.
+	BuilderImage string
+	// HelperImage is the image that prepares the build context.
+	HelperImage string
+}
+
+// Wrap returns a client that serves Builds, ImageStreams and ImageStreamTags
+// from Pods, ConfigMaps and the registry. All other objects are passed through
+// to the upstream client unchanged.
+func Wrap(upstream ctrlruntimeclient.WithWatch, config Config) ctrlruntimeclient.WithWatch {
+	if config.BuilderImage == "" {
+		config.BuilderImage = DefaultBuilderImage
+	}
+	if config.HelperImage == "" {
+		config.HelperImage = DefaultHelperImage
+	}
+	httpClient := &http.Client{Timeout: 5 * time.Minute}
+	if config.SkipTLSVerify {
+		httpClient.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	return &client{
+		WithWatch: upstream,
+		config:    config,
+		registry:  &registryClient{host: config.Registry, insecure: config.Insecure, client: httpClient},
+	}
+}
+
+type client struct {
+	ctrlruntimeclient.WithWatch
+	config   Config
+	registry *registryClient
+}
+
+func (c *client) Get(ctx context.Context, key ctrlruntimeclient.ObjectKey, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.GetOption) error {
+	switch o := obj.(type) {
+	case *buildapi.Build:
+		return c.getBuild(ctx, key, o)
+	case *imagev1.ImageStream:
+		return c.getImageStream(ctx, key, o)
+	case *imagev1.ImageStreamTag:
+		return c.getImageStreamTag(ctx, key, o)
+	default:
+		return c.WithWatch.Get(ctx, key, obj, opts...)
+	}
+}
+
+func (c *client) List(ctx context.Context, list ctrlruntimeclient.ObjectList, opts ...ctrlruntimeclient.ListOption) error {
+	switch l := list.(type) {
+	case *buildapi.BuildList:
+		return c.listBuilds(ctx, l, opts...)
+	case *imagev1.ImageStreamList:
+		return c.listImageStreams(ctx, l, opts...)
+	default:
+		return c.WithWatch.List(ctx, list, opts...)
+	}
+}
+
+func (c *client) Watch(ctx context.Context, list ctrlruntimeclient.ObjectList, opts ...ctrlruntimeclient.ListOption) (watch.Interface, error) {
+	switch list.(type) {
+	case *buildapi.BuildList:
+		return c.watchBuilds(ctx, opts...)
+	case *imagev1.ImageStreamList, *imagev1.ImageStreamTagList:
+		return nil, unsupported("watch", list)
+	default:
+		return c.WithWatch.Watch(ctx, list, opts...)
+	}
+}
+
+func (c *client) Create(ctx context.Context, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.CreateOption) error {
+	switch o := obj.(type) {
+	case *buildapi.Build:
+		return c.createBuild(ctx, o, opts...)
+	case *imagev1.ImageStream:
+		return c.createImageStream(ctx, o, opts...)
+	case *imagev1.ImageStreamTag:
+		return c.createImageStreamTag(ctx, o)
+	default:
+		return c.WithWatch.Create(ctx, obj, opts...)
+	}
+}
+
+func (c *client) Update(ctx context.Context, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.UpdateOption) error {
+	switch o := obj.(type) {
+	case *buildapi.Build:
+		return unsupported("update", obj)
+	case *imagev1.ImageStream:
+		return c.updateImageStream(ctx, o, opts...)
+	case *imagev1.ImageStreamTag:
+		return c.createImageStreamTag(ctx, o)
+	default:
+		return c.WithWatch.Update(ctx, obj, opts...)
+	}
+}
+
+func (c *client) Patch(ctx context.Context, obj ctrlruntimeclient.Object, patch ctrlruntimeclient.Patch, opts ...ctrlruntimeclient.PatchOption) error {
+	switch obj.(type) {
+	case *buildapi.Build, *imagev1.ImageStream, *imagev1.ImageStreamTag:
+		return unsupported("patch", obj)
+	default:
+		return c.WithWatch.Patch(ctx, obj, patch, opts...)
+	}
+}
+
+func (c *client) Delete(ctx context.Context, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.DeleteOption) error {
+	switch o := obj.(type) {
+	case *buildapi.Build:
+		return c.deleteBuild(ctx, o, opts...)
+	case *imagev1.ImageStream:
+		return c.deleteImageStream(ctx, o, opts...)
+	case *imagev1.ImageStreamTag:
+		return c.deleteImageStreamTag(ctx, o)
+	default:
+		return c.WithWatch.Delete(ctx, obj, opts...)
+	}
+}
+
+func unsupported(verb string, obj interface{}) error {
+	return fmt.Errorf("the Kubernetes backend does not support the %s operation on %T", verb, obj)
+}
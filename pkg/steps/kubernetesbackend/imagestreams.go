@@ -0,0 +1,338 @@
+package kubernetesbackend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+)
+
+const (
+	// ImageStreamLabel marks the ConfigMaps that hold ImageStreams, with a hash of the name
+	// of the ImageStream as the value as names can be longer than label values
+	ImageStreamLabel = "ci.openshift.io/kubernetes-backend-imagestream"
+	imageStreamKey   = "imagestream.json"
+)
+
+// configMapNameFor determines the ConfigMap that holds an ImageStream
+func configMapNameFor(imageStream string) string {
+	return fmt.Sprintf("imagestream-%s", imageStream)
+}
+
+// imageStreamLabelValueFor determines the value of ImageStreamLabel for an ImageStream
+func imageStreamLabelValueFor(imageStream string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(imageStream)))[:32]
+}
+
+// OwnerReferenceFor returns a reference to the object that backs an
+// ImageStream, to be used for objects that should be garbage collected
+// along with the ImageStream.
+func OwnerReferenceFor(is *imagev1.ImageStream) *metav1.OwnerReference {
+	return &metav1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       configMapNameFor(is.Name),
+		UID:        is.UID,
+	}
+}
+
+func (c *client) repositoryFor(namespace, imageStream string) string {
+	return fmt.Sprintf("%s/%s", namespace, imageStream)
+}
+
+func (c *client) pullSpecFor(namespace, imageStream string) string {
+	return fmt.Sprintf("%s/%s", c.config.Registry, c.repositoryFor(namespace, imageStream))
+}
+
+func (c *client) getImageStream(ctx context.Context, key ctrlruntimeclient.ObjectKey, into *imagev1.ImageStream) error {
+	cm := &coreapi.ConfigMap{}
+	err := c.WithWatch.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: key.Namespace, Name: configMapNameFor(key.Name)}, cm)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	stored := err == nil
+	is := &imagev1.ImageStream{}
+	if stored {
+		if is, err = imageStreamFromConfigMap(cm); err != nil {
+			return err
+		}
+	} else {
+		is.ObjectMeta = metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}
+	}
+	tags, err := c.registry.tags(ctx, c.repositoryFor(key.Namespace, key.Name))
+	if err != nil {
+		return err
+	}
+	if !stored && len(tags) == 0 {
+		return kerrors.NewNotFound(imagev1.Resource("imagestreams"), key.Name)
+	}
+	is.Status = imagev1.ImageStreamStatus{
+		DockerImageRepository:       c.pullSpecFor(key.Namespace, key.Name),
+		PublicDockerImageRepository: c.pullSpecFor(key.Namespace, key.Name),
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		_, _, digest, err := c.registry.manifest(ctx, c.repositoryFor(key.Namespace, key.Name), tag)
+		if errors.Is(err, errManifestNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		is.Status.Tags = append(is.Status.Tags, imagev1.NamedTagEventList{
+			Tag: tag,
+			Items: []imagev1.TagEvent{{
+				DockerImageReference: fmt.Sprintf("%s@%s", c.pullSpecFor(key.Namespace, key.Name), digest),
+				Image:                digest,
+			}},
+		})
+	}
+	is.DeepCopyInto(into)
+	return nil
+}
+
+func imageStreamFromConfigMap(cm *coreapi.ConfigMap) (*imagev1.ImageStream, error) {
+	is := &imagev1.ImageStream{}
+	if err := json.Unmarshal([]byte(cm.Data[imageStreamKey]), is); err != nil {
+		return nil, fmt.Errorf("could not unmarshal ImageStream from ConfigMap %s/%s: %w", cm.Namespace, cm.Name, err)
+	}
+	is.Namespace = cm.Namespace
+	is.UID = cm.UID
+	is.ResourceVersion = cm.ResourceVersion
+	is.CreationTimestamp = cm.CreationTimestamp
+	is.Generation = cm.Generation
+	return is, nil
+}
+
+func configMapFor(is *imagev1.ImageStream) (*coreapi.ConfigMap, error) {
+	stored := &imagev1.ImageStream{
+		TypeMeta: metav1.TypeMeta{APIVersion: "image.openshift.io/v1", Kind: "ImageStream"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        is.Name,
+			Labels:      is.Labels,
+			Annotations: is.Annotations,
+		},
+		Spec: is.Spec,
+	}
+	raw, err := json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal ImageStream %s: %w", is.Name, err)
+	}
+	labels := map[string]string{ImageStreamLabel: imageStreamLabelValueFor(is.Name)}
+	for key, value := range is.Labels {
+		labels[key] = value
+	}
+	return &coreapi.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       is.Namespace,
+			Name:            configMapNameFor(is.Name),
+			Labels:          labels,
+			OwnerReferences: is.OwnerReferences,
+			ResourceVersion: is.ResourceVersion,
+		},
+		Data: map[string]string{imageStreamKey: string(raw)},
+	}, nil
+}
+
+func (c *client) createImageStream(ctx context.Context, is *imagev1.ImageStream, opts ...ctrlruntimeclient.CreateOption) error {
+	cm, err := configMapFor(is)
+	if err != nil {
+		return err
+	}
+	cm.ResourceVersion = ""
+	if err := c.WithWatch.Create(ctx, cm, opts...); err != nil {
+		if kerrors.IsAlreadyExists(err) {
+			return kerrors.NewAlreadyExists(imagev1.Resource("imagestreams"), is.Name)
+		}
+		return err
+	}
+	if err := c.importSpecTags(ctx, is); err != nil {
+		return err
+	}
+	return c.getImageStream(ctx, ctrlruntimeclient.ObjectKeyFromObject(is), is)
+}
+
+func (c *client) updateImageStream(ctx context.Context, is *imagev1.ImageStream, opts ...ctrlruntimeclient.UpdateOption) error {
+	cm, err := configMapFor(is)
+	if err != nil {
+		return err
+	}
+	if err := c.WithWatch.Update(ctx, cm, opts...); err != nil {
+		if kerrors.IsNotFound(err) {
+			return kerrors.NewNotFound(imagev1.Resource("imagestreams"), is.Name)
+		}
+		return err
+	}
+	if err := c.importSpecTags(ctx, is); err != nil {
+		return err
+	}
+	return c.getImageStream(ctx, ctrlruntimeclient.ObjectKeyFromObject(is), is)
+}
+
+func (c *client) deleteImageStream(ctx context.Context, is *imagev1.ImageStream, opts ...ctrlruntimeclient.DeleteOption) error {
+	cm := &coreapi.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: is.Namespace, Name: configMapNameFor(is.Name)}}
+	if err := c.WithWatch.Delete(ctx, cm, opts...); err != nil {
+		if kerrors.IsNotFound(err) {
+			return kerrors.NewNotFound(imagev1.Resource("imagestreams"), is.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *client) listImageStreams(ctx context.Context, list *imagev1.ImageStreamList, opts ...ctrlruntimeclient.ListOption) error {
+	listOpts := &ctrlruntimeclient.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	cms := &coreapi.ConfigMapList{}
+	if err := c.WithWatch.List(ctx, cms, ctrlruntimeclient.InNamespace(listOpts.Namespace), ctrlruntimeclient.HasLabels{ImageStreamLabel}); err != nil {
+		return err
+	}
+	list.Items = nil
+	for i := range cms.Items {
+		stored, err := imageStreamFromConfigMap(&cms.Items[i])
+		if err != nil {
+			return err
+		}
+		is := imagev1.ImageStream{}
+		if err := c.getImageStream(ctx, ctrlruntimeclient.ObjectKey{Namespace: stored.Namespace, Name: stored.Name}, &is); err != nil {
+			return err
+		}
+		list.Items = append(list.Items, is)
+	}
+	return nil
+}
+
+// importSpecTags copies the images referenced by the spec tags into
+// the repository of the stream, as an import would on OpenShift
+func (c *client) importSpecTags(ctx context.Context, is *imagev1.ImageStream) error {
+	for _, tag := range is.Spec.Tags {
+		if tag.From == nil {
+			continue
+		}
+		if err := c.tagInto(ctx, is.Namespace, is.Name, tag.Name, *tag.From); err != nil {
+			return fmt.Errorf("could not import tag %s into ImageStream %s: %w", tag.Name, is.Name, err)
+		}
+	}
+	return nil
+}
+
+// splitImageStreamTagName splits the name of an ImageStreamTag into the stream and the tag
+func splitImageStreamTagName(name string) (string, string, error) {
+	stream, tag, ok := strings.Cut(name, ":")
+	if !ok || stream == "" || tag == "" {
+		return "", "", fmt.Errorf("invalid ImageStreamTag name %q, expected <stream>:<tag>", name)
+	}
+	return stream, tag, nil
+}
+
+func (c *client) getImageStreamTag(ctx context.Context, key ctrlruntimeclient.ObjectKey, into *imagev1.ImageStreamTag) error {
+	stream, tag, err := splitImageStreamTagName(key.Name)
+	if err != nil {
+		return kerrors.NewBadRequest(err.Error())
+	}
+	image, err := c.registry.resolve(ctx, c.repositoryFor(key.Namespace, stream), tag)
+	if errors.Is(err, errManifestNotFound) {
+		return kerrors.NewNotFound(imagev1.Resource("imagestreamtags"), key.Name)
+	}
+	if err != nil {
+		return err
+	}
+	ist := &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+		Image: imagev1.Image{
+			ObjectMeta:           metav1.ObjectMeta{Name: image.Digest},
+			DockerImageReference: fmt.Sprintf("%s@%s", c.pullSpecFor(key.Namespace, stream), image.Digest),
+		},
+	}
+	if image.Metadata != nil {
+		raw, err := json.Marshal(image.Metadata)
+		if err != nil {
+			return fmt.Errorf("could not marshal image metadata: %w", err)
+		}
+		ist.Image.DockerImageMetadata = runtime.RawExtension{Raw: raw}
+	}
+	ist.DeepCopyInto(into)
+	return nil
+}
+
+func (c *client) createImageStreamTag(ctx context.Context, ist *imagev1.ImageStreamTag) error {
+	if ist.Tag == nil || ist.Tag.From == nil {
+		return kerrors.NewBadRequest(fmt.Sprintf("ImageStreamTag %s must reference an image", ist.Name))
+	}
+	stream, tag, err := splitImageStreamTagName(ist.Name)
+	if err != nil {
+		return kerrors.NewBadRequest(err.Error())
+	}
+	if err := c.tagInto(ctx, ist.Namespace, stream, tag, *ist.Tag.From); err != nil {
+		return err
+	}
+	return c.getImageStreamTag(ctx, ctrlruntimeclient.ObjectKeyFromObject(ist), ist)
+}
+
+func (c *client) deleteImageStreamTag(ctx context.Context, ist *imagev1.ImageStreamTag) error {
+	stream, tag, err := splitImageStreamTagName(ist.Name)
+	if err != nil {
+		return kerrors.NewBadRequest(err.Error())
+	}
+	err = c.registry.deleteTag(ctx, c.repositoryFor(ist.Namespace, stream), tag)
+	if errors.Is(err, errManifestNotFound) {
+		return kerrors.NewNotFound(imagev1.Resource("imagestreamtags"), ist.Name)
+	}
+	return err
+}
+
+// tagInto points a tag in an ImageStream to the image a reference resolves to.
+// The referenced image must exist in the registry backing the ImageStreams.
+func (c *client) tagInto(ctx context.Context, namespace, stream, tag string, from coreapi.ObjectReference) error {
+	fromNamespace := from.Namespace
+	if fromNamespace == "" {
+		fromNamespace = namespace
+	}
+	var repository, reference string
+	switch from.Kind {
+	case "ImageStreamTag":
+		fromStream, fromTag, err := splitImageStreamTagName(from.Name)
+		if err != nil {
+			return err
+		}
+		repository, reference = c.repositoryFor(fromNamespace, fromStream), fromTag
+	case "ImageStreamImage":
+		fromStream, digest, ok := strings.Cut(from.Name, "@")
+		if !ok {
+			return fmt.Errorf("invalid ImageStreamImage name %q, expected <stream>@<digest>", from.Name)
+		}
+		repository, reference = c.repositoryFor(fromNamespace, fromStream), digest
+	case "DockerImage":
+		name, ok := strings.CutPrefix(from.Name, c.config.Registry+"/")
+		if !ok {
+			return fmt.Errorf("image %s is not in registry %s, mirror it there to use it with the Kubernetes backend", from.Name, c.config.Registry)
+		}
+		if repo, digest, ok := strings.Cut(name, "@"); ok {
+			repository, reference = repo, digest
+		} else if i := strings.LastIndex(name, ":"); i > 0 {
+			repository, reference = name[:i], name[i+1:]
+		} else {
+			repository, reference = name, "latest"
+		}
+	default:
+		return fmt.Errorf("unsupported image reference kind %q", from.Kind)
+	}
+	if err := c.registry.copy(ctx, repository, reference, c.repositoryFor(namespace, stream), tag); err != nil {
+		if errors.Is(err, errManifestNotFound) {
+			return kerrors.NewNotFound(imagev1.Resource("imagestreamtags"), fmt.Sprintf("%s:%s", repository, reference))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package kubernetesbackend
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/api/image/docker10"
+	imagev1 "github.com/openshift/api/image/v1"
+)
+
+func TestImageStreams(t *testing.T) {
+	ctx := context.Background()
+	fake, host := newTestRegistry(t)
+	srcDigest := fake.push(t, "ns/pipeline", "src", "/go/src/github.com/org/repo")
+	binDigest := fake.push(t, "ns/pipeline", "bin", "/go/src/github.com/org/repo/bin")
+	client := Wrap(fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(&coreapi.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}).Build(), Config{Registry: host, Insecure: true})
+
+	pipeline := &imagev1.ImageStream{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline"},
+		Spec:       imagev1.ImageStreamSpec{LookupPolicy: imagev1.ImageLookupPolicy{Local: true}},
+	}
+	if err := client.Create(ctx, pipeline); err != nil {
+		t.Fatalf("unexpected error creating imagestream: %v", err)
+	}
+	if err := client.Create(ctx, pipeline.DeepCopy()); !kerrors.IsAlreadyExists(err) {
+		t.Errorf("expected creating an existing imagestream to fail with AlreadyExists, got %v", err)
+	}
+
+	is := &imagev1.ImageStream{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "pipeline"}, is); err != nil {
+		t.Fatalf("unexpected error getting imagestream: %v", err)
+	}
+	if !is.Spec.LookupPolicy.Local {
+		t.Errorf("expected the spec of the imagestream to be stored")
+	}
+	repository := host + "/ns/pipeline"
+	expectedStatus := imagev1.ImageStreamStatus{
+		DockerImageRepository:       repository,
+		PublicDockerImageRepository: repository,
+		Tags: []imagev1.NamedTagEventList{
+			{Tag: "bin", Items: []imagev1.TagEvent{{DockerImageReference: repository + "@" + binDigest, Image: binDigest}}},
+			{Tag: "src", Items: []imagev1.TagEvent{{DockerImageReference: repository + "@" + srcDigest, Image: srcDigest}}},
+		},
+	}
+	if diff := cmp.Diff(expectedStatus, is.Status); diff != "" {
+		t.Errorf("unexpected imagestream status: %s", diff)
+	}
+
+	ist := &imagev1.ImageStreamTag{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "pipeline:src"}, ist); err != nil {
+		t.Fatalf("unexpected error getting imagestreamtag: %v", err)
+	}
+	if ist.Image.Name != srcDigest || ist.Image.DockerImageReference != repository+"@"+srcDigest {
+		t.Errorf("unexpected image for imagestreamtag: %#v", ist.Image)
+	}
+	metadata := &docker10.DockerImage{}
+	if err := json.Unmarshal(ist.Image.DockerImageMetadata.Raw, metadata); err != nil {
+		t.Fatalf("could not parse image metadata: %v", err)
+	}
+	if metadata.Config == nil || metadata.Config.WorkingDir != "/go/src/github.com/org/repo" {
+		t.Errorf("unexpected image metadata: %#v", metadata)
+	}
+
+	tag := &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "stable:src"},
+		Tag:        &imagev1.TagReference{From: &coreapi.ObjectReference{Kind: "ImageStreamTag", Name: "pipeline:src"}},
+	}
+	if err := client.Create(ctx, tag); err != nil {
+		t.Fatalf("unexpected error tagging image: %v", err)
+	}
+	if tag.Image.Name != srcDigest {
+		t.Errorf("expected tagged image to be %s, got %s", srcDigest, tag.Image.Name)
+	}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "stable"}, &imagev1.ImageStream{}); err != nil {
+		t.Errorf("expected imagestream with tags to exist, got %v", err)
+	}
+
+	external := &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "stable:external"},
+		Tag:        &imagev1.TagReference{From: &coreapi.ObjectReference{Kind: "DockerImage", Name: "quay.io/org/image:latest"}},
+	}
+	if err := client.Create(ctx, external); err == nil {
+		t.Errorf("expected tagging an image from another registry to fail")
+	}
+
+	root := &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "stable:root"},
+		Tag:        &imagev1.TagReference{From: &coreapi.ObjectReference{Kind: "ImageStreamTag", Name: "stable:src"}},
+	}
+	if err := client.Create(ctx, root); err != nil {
+		t.Fatalf("unexpected error tagging image: %v", err)
+	}
+	if err := client.Delete(ctx, tag); err != nil {
+		t.Fatalf("unexpected error deleting imagestreamtag: %v", err)
+	}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "stable:src"}, &imagev1.ImageStreamTag{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected deleted imagestreamtag to not be found, got %v", err)
+	}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "stable:root"}, &imagev1.ImageStreamTag{}); err != nil {
+		t.Errorf("expected imagestreamtag of the same image to remain, got %v", err)
+	}
+	if err := client.Delete(ctx, tag); !kerrors.IsNotFound(err) {
+		t.Errorf("expected deleting a missing imagestreamtag to not find it, got %v", err)
+	}
+	if err := client.Delete(ctx, root); err != nil {
+		t.Fatalf("unexpected error deleting imagestreamtag: %v", err)
+	}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "missing"}, &imagev1.ImageStream{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected missing imagestream to not be found, got %v", err)
+	}
+
+	list := &imagev1.ImageStreamList{}
+	if err := client.List(ctx, list, ctrlruntimeclient.InNamespace("ns")); err != nil {
+		t.Fatalf("unexpected error listing imagestreams: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "pipeline" {
+		t.Errorf("expected to list the pipeline imagestream, got %v", list.Items)
+	}
+}
+
+func TestConfigMapForLongName(t *testing.T) {
+	name := strings.Repeat("long-imagestream-name", 5)
+	cm, err := configMapFor(&imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if errs := validation.IsValidLabelValue(cm.Labels[ImageStreamLabel]); len(errs) > 0 {
+		t.Errorf("invalid label value: %v", errs)
+	}
+	stored, err := imageStreamFromConfigMap(cm)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored.Name != name {
+		t.Errorf("expected the stored name to be %s, got %s", name, stored.Name)
+	}
+}
+
+func TestOwnerReferenceFor(t *testing.T) {
+	is := &imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline", UID: "uid"}}
+	expected := &metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "imagestream-pipeline", UID: "uid"}
+	if diff := cmp.Diff(expected, OwnerReferenceFor(is)); diff != "" {
+		t.Errorf("unexpected owner reference: %s", diff)
+	}
+}
@@ -0,0 +1,341 @@
+package kubernetesbackend
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/api/image/docker10"
+)
+
+const (
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+var acceptedManifestTypes = strings.Join([]string{mediaTypeOCIIndex, mediaTypeOCIManifest, mediaTypeDockerManifestList, mediaTypeDockerManifest}, ", ")
+
+// errManifestNotFound is returned when a tag or digest does not exist in a repository
+var errManifestNotFound = errors.New("manifest not found")
+
+type descriptor struct {
+	MediaType string    `json:"mediaType,omitempty"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size,omitempty"`
+	Platform  *platform `json:"platform,omitempty"`
+}
+
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+// manifest holds the fields shared by image manifests and manifest lists
+type manifest struct {
+	MediaType string       `json:"mediaType,omitempty"`
+	Config    *descriptor  `json:"config,omitempty"`
+	Layers    []descriptor `json:"layers,omitempty"`
+	Manifests []descriptor `json:"manifests,omitempty"`
+}
+
+func (m *manifest) isList(mediaType string) bool {
+	return mediaType == mediaTypeDockerManifestList || mediaType == mediaTypeOCIIndex || len(m.Manifests) > 0
+}
+
+// imageConfig is the subset of the OCI image configuration we expose
+type imageConfig struct {
+	Architecture string                 `json:"architecture"`
+	Created      metav1.Time            `json:"created,omitempty"`
+	Config       *docker10.DockerConfig `json:"config,omitempty"`
+}
+
+// resolvedImage is an image found in the registry
+type resolvedImage struct {
+	Digest   string
+	Metadata *docker10.DockerImage
+}
+
+// registryClient talks to the OCI distribution API of a plain registry.
+// Repositories in the registry are laid out as <namespace>/<imagestream>,
+// so every ImageStream has a backing repository.
+type registryClient struct {
+	host     string
+	insecure bool
+	client   *http.Client
+}
+
+func (r *registryClient) url(path string) string {
+	scheme := "https"
+	if r.insecure {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s", scheme, r.host, path)
+}
+
+func (r *registryClient) do(ctx context.Context, method, target string, body []byte, header http.Header) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	return r.client.Do(req)
+}
+
+func unexpectedResponse(resp *http.Response, action string) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("could not %s: registry responded with %s: %s", action, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// manifest fetches the raw manifest for a tag or digest in a repository
+func (r *registryClient) manifest(ctx context.Context, repository, reference string) ([]byte, string, string, error) {
+	resp, err := r.do(ctx, http.MethodGet, r.url(fmt.Sprintf("%s/manifests/%s", repository, reference)), nil, http.Header{"Accept": []string{acceptedManifestTypes}})
+	if err != nil {
+		return nil, "", "", fmt.Errorf("could not fetch manifest %s:%s: %w", repository, reference, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", "", errManifestNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", "", unexpectedResponse(resp, fmt.Sprintf("fetch manifest %s:%s", repository, reference))
+	}
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("could not read manifest %s:%s: %w", repository, reference, err)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = fmt.Sprintf("sha256:%x", sha256.Sum256(raw))
+	}
+	mediaType := resp.Header.Get("Content-Type")
+	if mediaType == "" {
+		m := manifest{}
+		if err := json.Unmarshal(raw, &m); err == nil {
+			mediaType = m.MediaType
+		}
+	}
+	return raw, mediaType, digest, nil
+}
+
+// resolve determines the digest and the metadata of the image a tag or digest points to
+func (r *registryClient) resolve(ctx context.Context, repository, reference string) (*resolvedImage, error) {
+	raw, mediaType, digest, err := r.manifest(ctx, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+	image := &resolvedImage{Digest: digest}
+	m := manifest{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("could not parse manifest %s:%s: %w", repository, reference, err)
+	}
+	if m.isList(mediaType) {
+		if len(m.Manifests) == 0 {
+			return nil, fmt.Errorf("manifest list %s:%s is empty", repository, reference)
+		}
+		// all images in a list share the relevant metadata, so we can use the first one
+		first := m.Manifests[0].Digest
+		if raw, _, _, err = r.manifest(ctx, repository, first); err != nil {
+			return nil, err
+		}
+		m = manifest{}
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return nil, fmt.Errorf("could not parse manifest %s@%s: %w", repository, first, err)
+		}
+	}
+	if m.Config == nil {
+		return image, nil
+	}
+	config, err := r.blob(ctx, repository, m.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	parsed := imageConfig{}
+	if err := json.Unmarshal(config, &parsed); err != nil {
+		return nil, fmt.Errorf("could not parse image configuration of %s:%s: %w", repository, reference, err)
+	}
+	image.Metadata = &docker10.DockerImage{
+		ID:           m.Config.Digest,
+		Created:      parsed.Created,
+		Config:       parsed.Config,
+		Architecture: parsed.Architecture,
+	}
+	return image, nil
+}
+
+func (r *registryClient) blob(ctx context.Context, repository, digest string) ([]byte, error) {
+	resp, err := r.do(ctx, http.MethodGet, r.url(fmt.Sprintf("%s/blobs/%s", repository, digest)), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch blob %s@%s: %w", repository, digest, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, unexpectedResponse(resp, fmt.Sprintf("fetch blob %s@%s", repository, digest))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// tags lists the tags in a repository, which is empty when the repository does not exist
+func (r *registryClient) tags(ctx context.Context, repository string) ([]string, error) {
+	resp, err := r.do(ctx, http.MethodGet, r.url(fmt.Sprintf("%s/tags/list", repository)), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not list tags for %s: %w", repository, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, unexpectedResponse(resp, fmt.Sprintf("list tags for %s", repository))
+	}
+	list := struct {
+		Tags []string `json:"tags"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("could not parse tags for %s: %w", repository, err)
+	}
+	return list.Tags, nil
+}
+
+// copy tags an image that exists in one repository of the registry into another
+// one, mounting the blobs across repositories so no layer data is transferred
+func (r *registryClient) copy(ctx context.Context, fromRepository, fromReference, toRepository, toTag string) error {
+	raw, mediaType, _, err := r.manifest(ctx, fromRepository, fromReference)
+	if err != nil {
+		return err
+	}
+	m := manifest{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return fmt.Errorf("could not parse manifest %s:%s: %w", fromRepository, fromReference, err)
+	}
+	if m.isList(mediaType) {
+		for _, child := range m.Manifests {
+			childRaw, childType, _, err := r.manifest(ctx, fromRepository, child.Digest)
+			if err != nil {
+				return err
+			}
+			childManifest := manifest{}
+			if err := json.Unmarshal(childRaw, &childManifest); err != nil {
+				return fmt.Errorf("could not parse manifest %s@%s: %w", fromRepository, child.Digest, err)
+			}
+			if err := r.mountBlobs(ctx, fromRepository, toRepository, childManifest); err != nil {
+				return err
+			}
+			if err := r.putManifest(ctx, toRepository, child.Digest, childRaw, childType); err != nil {
+				return err
+			}
+		}
+	} else if err := r.mountBlobs(ctx, fromRepository, toRepository, m); err != nil {
+		return err
+	}
+	return r.putManifest(ctx, toRepository, toTag, raw, mediaType)
+}
+
+func (r *registryClient) mountBlobs(ctx context.Context, fromRepository, toRepository string, m manifest) error {
+	blobs := m.Layers
+	if m.Config != nil {
+		blobs = append([]descriptor{*m.Config}, blobs...)
+	}
+	for _, blob := range blobs {
+		if err := r.mountBlob(ctx, fromRepository, toRepository, blob.Digest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *registryClient) mountBlob(ctx context.Context, fromRepository, toRepository, digest string) error {
+	query := url.Values{"mount": []string{digest}, "from": []string{fromRepository}}
+	resp, err := r.do(ctx, http.MethodPost, r.url(fmt.Sprintf("%s/blobs/uploads/?%s", toRepository, query.Encode())), nil, nil)
+	if err != nil {
+		return fmt.Errorf("could not mount blob %s into %s: %w", digest, toRepository, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return nil
+	case http.StatusAccepted:
+		// the registry declined the mount and started a regular upload instead
+		content, err := r.blob(ctx, fromRepository, digest)
+		if err != nil {
+			return err
+		}
+		location, err := r.uploadLocation(resp, digest)
+		if err != nil {
+			return err
+		}
+		upload, err := r.do(ctx, http.MethodPut, location, content, http.Header{"Content-Type": []string{"application/octet-stream"}})
+		if err != nil {
+			return fmt.Errorf("could not upload blob %s into %s: %w", digest, toRepository, err)
+		}
+		defer upload.Body.Close()
+		if upload.StatusCode != http.StatusCreated {
+			return unexpectedResponse(upload, fmt.Sprintf("upload blob %s into %s", digest, toRepository))
+		}
+		return nil
+	default:
+		return unexpectedResponse(resp, fmt.Sprintf("mount blob %s into %s", digest, toRepository))
+	}
+}
+
+func (r *registryClient) uploadLocation(resp *http.Response, digest string) (string, error) {
+	location, err := resp.Location()
+	if err != nil {
+		return "", fmt.Errorf("registry did not return an upload location: %w", err)
+	}
+	query := location.Query()
+	query.Set("digest", digest)
+	location.RawQuery = query.Encode()
+	return location.String(), nil
+}
+
+func (r *registryClient) putManifest(ctx context.Context, repository, reference string, raw []byte, mediaType string) error {
+	resp, err := r.do(ctx, http.MethodPut, r.url(fmt.Sprintf("%s/manifests/%s", repository, reference)), raw, http.Header{"Content-Type": []string{mediaType}})
+	if err != nil {
+		return fmt.Errorf("could not push manifest %s:%s: %w", repository, reference, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return unexpectedResponse(resp, fmt.Sprintf("push manifest %s:%s", repository, reference))
+	}
+	return nil
+}
+
+// deleteTag removes a tag from a repository, leaving the manifest it points to
+// and any other tags of that manifest in place. Deleting a manifest by digest
+// would untag it everywhere, so registries must support deleting tags.
+func (r *registryClient) deleteTag(ctx context.Context, repository, tag string) error {
+	resp, err := r.do(ctx, http.MethodDelete, r.url(fmt.Sprintf("%s/manifests/%s", repository, tag)), nil, nil)
+	if err != nil {
+		return fmt.Errorf("could not delete tag %s:%s: %w", repository, tag, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return errManifestNotFound
+	case http.StatusBadRequest, http.StatusMethodNotAllowed:
+		return fmt.Errorf("registry does not support deleting tag %s:%s: %w", repository, tag, unexpectedResponse(resp, "delete tag"))
+	default:
+		return unexpectedResponse(resp, fmt.Sprintf("delete tag %s:%s", repository, tag))
+	}
+}
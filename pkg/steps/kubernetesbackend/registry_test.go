@@ -0,0 +1,185 @@
+package kubernetesbackend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/openshift/api/image/docker10"
+)
+
+// fakeRegistry implements the subset of the OCI distribution API used by the registry client
+type fakeRegistry struct {
+	sync.Mutex
+	blobs      map[string][]byte
+	mediaTypes map[string]string
+	// repositories maps repositories to tags and digests to manifest digests
+	repositories map[string]map[string]string
+	manifests    map[string][]byte
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		blobs:        map[string][]byte{},
+		mediaTypes:   map[string]string{},
+		repositories: map[string]map[string]string{},
+		manifests:    map[string][]byte{},
+	}
+}
+
+func digestOf(raw []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(raw))
+}
+
+// push adds an image with the given working directory to a repository
+func (f *fakeRegistry) push(t *testing.T, repository, tag, workingDir string) string {
+	config, err := json.Marshal(imageConfig{Architecture: "amd64", Config: &docker10.DockerConfig{WorkingDir: workingDir}})
+	if err != nil {
+		t.Fatalf("could not marshal config: %v", err)
+	}
+	layer := []byte("layer-" + workingDir)
+	m, err := json.Marshal(manifest{
+		MediaType: mediaTypeOCIManifest,
+		Config:    &descriptor{MediaType: "application/vnd.oci.image.config.v1+json", Digest: digestOf(config)},
+		Layers:    []descriptor{{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: digestOf(layer)}},
+	})
+	if err != nil {
+		t.Fatalf("could not marshal manifest: %v", err)
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.blobs[digestOf(config)] = config
+	f.blobs[digestOf(layer)] = layer
+	f.store(repository, tag, m, mediaTypeOCIManifest)
+	return digestOf(m)
+}
+
+func (f *fakeRegistry) store(repository, reference string, raw []byte, mediaType string) {
+	digest := digestOf(raw)
+	f.manifests[digest] = raw
+	f.mediaTypes[digest] = mediaType
+	if f.repositories[repository] == nil {
+		f.repositories[repository] = map[string]string{}
+	}
+	f.repositories[repository][reference] = digest
+	f.repositories[repository][digest] = digest
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	switch {
+	case strings.HasSuffix(path, "/tags/list"):
+		repository := strings.TrimSuffix(path, "/tags/list")
+		references, ok := f.repositories[repository]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var tags []string
+		for reference := range references {
+			if !strings.HasPrefix(reference, "sha256:") {
+				tags = append(tags, reference)
+			}
+		}
+		sort.Strings(tags)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": repository, "tags": tags})
+	case strings.Contains(path, "/manifests/"):
+		repository, reference, _ := strings.Cut(path, "/manifests/")
+		switch r.Method {
+		case http.MethodGet:
+			digest, ok := f.repositories[repository][reference]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", f.mediaTypes[digest])
+			w.Header().Set("Docker-Content-Digest", digest)
+			_, _ = w.Write(f.manifests[digest])
+		case http.MethodPut:
+			raw, _ := io.ReadAll(r.Body)
+			f.store(repository, reference, raw, r.Header.Get("Content-Type"))
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			digest, ok := f.repositories[repository][reference]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			if reference != digest {
+				// deleting a tag only removes the tag
+				delete(f.repositories[repository], reference)
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			for ref, d := range f.repositories[repository] {
+				if d == digest {
+					delete(f.repositories[repository], ref)
+				}
+			}
+			w.WriteHeader(http.StatusAccepted)
+		}
+	case strings.HasSuffix(path, "/blobs/uploads/"):
+		digest := r.URL.Query().Get("mount")
+		if _, ok := f.blobs[digest]; !ok {
+			http.Error(w, "unknown blob", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	case strings.Contains(path, "/blobs/"):
+		_, digest, _ := strings.Cut(path, "/blobs/")
+		blob, ok := f.blobs[digest]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(blob)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newTestRegistry serves a fake registry and returns its host
+func newTestRegistry(t *testing.T) (*fakeRegistry, string) {
+	registry := newFakeRegistry()
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+	return registry, strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestRegistryCopy(t *testing.T) {
+	fake, host := newTestRegistry(t)
+	digest := fake.push(t, "ns/pipeline", "src", "/go/src")
+	r := &registryClient{host: host, insecure: true, client: http.DefaultClient}
+
+	if err := r.copy(context.Background(), "ns/pipeline", "src", "other/stable", "latest"); err != nil {
+		t.Fatalf("unexpected error copying image: %v", err)
+	}
+	image, err := r.resolve(context.Background(), "other/stable", "latest")
+	if err != nil {
+		t.Fatalf("unexpected error resolving image: %v", err)
+	}
+	if image.Digest != digest {
+		t.Errorf("expected copied image to have digest %s, got %s", digest, image.Digest)
+	}
+	if image.Metadata == nil || image.Metadata.Config == nil || image.Metadata.Config.WorkingDir != "/go/src" {
+		t.Errorf("unexpected metadata for copied image: %#v", image.Metadata)
+	}
+
+	if _, err := r.resolve(context.Background(), "other/stable", "missing"); err != errManifestNotFound {
+		t.Errorf("expected a missing tag to not be found, got %v", err)
+	}
+	tags, err := r.tags(context.Background(), "missing/repository")
+	if err != nil || tags != nil {
+		t.Errorf("expected no tags for a missing repository, got %v, %v", tags, err)
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -23,6 +23,7 @@ var NoLeaseClientErr = errors.New("step needs a lease but no lease client provid
 type stepLease struct {
 	api.StepLease
 	resources []string
+	details   api.CIOperatorStepLease
 }
 
 // leaseStep wraps another step and acquires/releases one or more leases.
@@ -94,6 +95,16 @@ func (s *leaseStep) Provides() api.ParameterMap {
 	return parameters
 }
 
+func (s *leaseStep) Leases() []api.CIOperatorStepLease {
+	var ret []api.CIOperatorStepLease
+	for _, l := range s.leases {
+		if l.details.ResourceType != "" {
+			ret = append(ret, l.details)
+		}
+	}
+	return ret
+}
+
 func (s *leaseStep) SubTests() []*junit.TestCase {
 	if subTests, ok := s.wrapped.(SubtestReporter); ok {
 		return subTests.SubTests()
@@ -140,35 +151,82 @@ func acquireLeases(
 	cancel context.CancelFunc,
 	leases []stepLease,
 ) error {
-	// Sort by resource type to avoid a(n unlikely and temporary) deadlock.
-	var sorted []int
-	for i := range leases {
-		sorted = append(sorted, i)
+	requests := make([]lease.Request, 0, len(leases))
+	for _, l := range leases {
+		logrus.Debugf("Acquiring %d lease(s) for %s", l.Count, l.ResourceType)
+		requests = append(requests, lease.Request{ResourceType: l.ResourceType, Count: l.Count})
+	}
+	predictions, err := client.Predict(requests)
+	if err != nil {
+		logrus.WithError(err).Warn("Could not predict the wait for leases.")
+	}
+	var exceeding []string
+	for i, p := range predictions {
+		logPrediction(p)
+		leases[i].details = api.CIOperatorStepLease{
+			ResourceType: p.ResourceType,
+			Count:        p.Count,
+			Free:         p.Free,
+			Leased:       p.Leased,
+			WaitSamples:  p.Waits.Samples,
+			WaitP50:      p.Waits.P50,
+			WaitP90:      p.Waits.P90,
+			WaitP99:      p.Waits.P99,
+		}
+		if p.Known {
+			predicted := p.Wait
+			leases[i].details.PredictedWait = &predicted
+		}
+		switch {
+		case p.Insufficient:
+			exceeding = append(exceeding, fmt.Sprintf("%d %s lease(s) were requested but only %d exist", p.Count, p.ResourceType, p.Total))
+		case p.ExceedsTimeout && p.Waits.ContendedP50Exceeded:
+			exceeding = append(exceeding, fmt.Sprintf("acquiring %d %s lease(s) is predicted to take longer than %s, as more than half of %d past acquisitions which found no free resources timed out", p.Count, p.ResourceType, p.Wait.Round(time.Second), p.Waits.ContendedSamples))
+		case p.ExceedsTimeout:
+			exceeding = append(exceeding, fmt.Sprintf("acquiring %d %s lease(s) is predicted to take %s, the median wait of %d past acquisitions which found no free resources", p.Count, p.ResourceType, p.Wait.Round(time.Second), p.Waits.ContendedSamples))
+		}
 	}
-	sort.Slice(sorted, func(i, j int) bool {
-		return leases[i].ResourceType < leases[j].ResourceType
+	if len(exceeding) > 0 {
+		return results.ForReason("lease_wait_exceeds_timeout").ForError(fmt.Errorf("not waiting for leases that are not expected to be acquired before the acquisition timeout: %s", strings.Join(exceeding, "; ")))
+	}
+	// All leases are acquired at once, so that steps competing for the same
+	// resources do not hold some of them while waiting for the others.
+	start := time.Now()
+	names, err := client.AcquireAll(requests, ctx, cancel, func(position lease.QueuePosition) {
+		logrus.Infof("Waiting for %s lease(s) at position %d in the queue, current capacity: %d free, %d leased (attempt %d, retrying in %s)", position.ResourceType, position.Position, position.Free, position.Leased, position.Attempt, position.Next.Round(time.Second))
 	})
-	var errs []error
-	for _, i := range sorted {
-		l := &leases[i]
-		logrus.Debugf("Acquiring %d lease(s) for %s", l.Count, l.ResourceType)
-		names, err := client.Acquire(l.ResourceType, l.Count, ctx, cancel)
-		if err != nil {
-			if err == lease.ErrNotFound {
+	if err != nil {
+		if errors.Is(err, lease.ErrNotFound) {
+			for _, l := range leases {
 				printResourceMetrics(client, l.ResourceType)
 			}
-			errs = append(errs, results.ForReason(results.Reason("acquiring_lease")).WithError(err).Errorf("failed to acquire lease for %q: %v", l.ResourceType, err))
-			break
 		}
-		logrus.Infof("Acquired %d lease(s) for %s: %v", l.Count, l.ResourceType, names)
-		l.resources = names
+		return results.ForReason(results.Reason("acquiring_lease")).WithError(err).Errorf("failed to acquire leases: %v", err)
 	}
-	if errs != nil {
-		if err := releaseLeases(client, leases); err != nil {
-			errs = append(errs, fmt.Errorf("failed to release leases after acquisition failure: %w", err))
+	wait := time.Since(start)
+	for i := range leases {
+		logrus.Infof("Acquired %d lease(s) for %s: %v", leases[i].Count, leases[i].ResourceType, names[i])
+		leases[i].resources = names[i]
+		if leases[i].details.ResourceType != "" {
+			leases[i].details.Wait = &wait
 		}
 	}
-	return utilerrors.NewAggregate(errs)
+	return nil
+}
+
+func logPrediction(p lease.Prediction) {
+	history := "no past acquisitions completed"
+	if p.Waits.Samples > 0 {
+		history = fmt.Sprintf("past waits over %d acquisitions: p50 %s, p90 %s, p99 %s", p.Waits.Samples, p.Waits.P50.Round(time.Second), p.Waits.P90.Round(time.Second), p.Waits.P99.Round(time.Second))
+	}
+	if p.Waits.TimedOutSamples > 0 {
+		history = fmt.Sprintf("%s; %d past acquisitions timed out", history, p.Waits.TimedOutSamples)
+	}
+	predicted := "unknown"
+	if p.Known {
+		predicted = p.Wait.Round(time.Second).String()
+	}
+	logrus.Infof("Leases for %s: %d free, %d leased; %s; predicted wait: %s", p.ResourceType, p.Free, p.Leased, history, predicted)
 }
 
 func releaseLeases(client lease.Client, leases []stepLease) error {
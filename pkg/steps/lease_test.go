@@ -5,6 +5,7 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -248,3 +249,49 @@ func TestAcquireRelease(t *testing.T) {
 		t.Fatalf("wrong calls to the lease client: %s", diff.ObjectDiff(calls, expected))
 	}
 }
+
+func TestLeasePrediction(t *testing.T) {
+	var immediately time.Duration
+	for _, tc := range []struct {
+		name            string
+		count           uint
+		expectedReasons []string
+		expectedCalls   []string
+		expectedLeases  []api.CIOperatorStepLease
+	}{{
+		name:          "free leases are acquired and reported",
+		count:         1,
+		expectedCalls: []string{"acquire owner rtype free leased random", "releaseone owner rtype_0 free"},
+		expectedLeases: []api.CIOperatorStepLease{
+			{ResourceType: "rtype", Count: 1, Free: 2, PredictedWait: &immediately},
+		},
+	}, {
+		name:            "more leases than exist fail without waiting",
+		count:           3,
+		expectedReasons: []string{"utilizing_lease:lease_wait_exceeds_timeout"},
+		expectedLeases: []api.CIOperatorStepLease{
+			{ResourceType: "rtype", Count: 3, Free: 2},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			client := lease.NewFakeClientWithCapacity("owner", lease.NewFakeCapacity(map[string]int{"rtype": 2}), time.Hour, &calls)
+			step := stepNeedsLease{}
+			withLease := LeaseStep(&client, []api.StepLease{{ResourceType: "rtype", Count: tc.count}}, &step, func() string { return "" })
+			err := withLease.Run(context.Background())
+			testhelper.Diff(t, "reasons", results.Reasons(err), tc.expectedReasons)
+			if step.ran != (err == nil) {
+				t.Errorf("expected the step to run only when the leases are acquired, ran: %t", step.ran)
+			}
+			testhelper.Diff(t, "calls", calls, tc.expectedCalls)
+			leases := withLease.(LeaseReporter).Leases()
+			for i := range leases {
+				if (leases[i].Wait != nil) != (err == nil) {
+					t.Errorf("expected the wait to be reported only when the leases are acquired, got %v", leases[i].Wait)
+				}
+				leases[i].Wait = nil
+			}
+			testhelper.Diff(t, "leases", leases, tc.expectedLeases)
+		})
+	}
+}
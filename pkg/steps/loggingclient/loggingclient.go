@@ -7,12 +7,15 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/openshift/ci-tools/pkg/events"
 )
 
 type LoggingClient interface {
@@ -57,6 +60,9 @@ func (lc *loggingClient) Create(ctx context.Context, obj ctrlruntimeclient.Objec
 		return err
 	}
 	lc.logObject(obj)
+	if _, ok := obj.(*corev1.Pod); ok {
+		events.FromContext(ctx).Emit(events.Event{Type: events.PodCreated, Namespace: obj.GetNamespace(), Pod: obj.GetName()})
+	}
 	return nil
 }
 
@@ -51,6 +51,9 @@ func (s *multiStageTestStep) generateObservers(
 
 type generatePodOptions struct {
 	IsObserver bool
+	// Attempt is set when generating the pod for a retry of a step, so the
+	// pod and its artifacts do not replace the ones of previous attempts.
+	Attempt int
 }
 
 func defaultGeneratePodOptions() *generatePodOptions {
@@ -81,6 +84,11 @@ func (s *multiStageTestStep) generatePods(
 	}
 	for _, step := range steps {
 		name := fmt.Sprintf("%s-%s", s.name, step.As)
+		artifactDir := fmt.Sprintf("%s/%s", s.name, step.As)
+		if genPodOpts.Attempt > 1 {
+			name = fmt.Sprintf("%s-attempt-%d", name, genPodOpts.Attempt)
+			artifactDir = fmt.Sprintf("%s-attempt-%d", artifactDir, genPodOpts.Attempt)
+		}
 		if o := step.OptionalOnSuccess; o != nil && *o && s.flags&allowSkipOnSuccess != 0 && s.flags&hasPrevErrs == 0 {
 			logrus.Infof(fmt.Sprintf("Skipping optional step %s", name))
 			continue
@@ -112,7 +120,6 @@ func (s *multiStageTestStep) generatePods(
 		p := func(i int64) *int64 {
 			return &i
 		}
-		artifactDir := fmt.Sprintf("%s/%s", s.name, step.As)
 		timeout := entrypoint.DefaultTimeout
 		if step.Timeout != nil {
 			timeout = step.Timeout.Duration
@@ -144,6 +151,11 @@ func (s *multiStageTestStep) generatePods(
 		delete(pod.Labels, base_steps.ProwJobIdLabel)
 		pod.Annotations[base_steps.AnnotationSaveContainerLogs] = "true"
 		pod.Labels[MultiStageTestLabel] = s.name
+		var sharedDirSecret string
+		if step.ParallelGroup != "" && !genPodOpts.IsObserver {
+			pod.Annotations[ParallelGroupAnnotation] = step.ParallelGroup
+			sharedDirSecret = parallelSharedDirSecret(s.name, step.As)
+		}
 		needsKubeConfig := isKubeconfigNeeded(&step, genPodOpts)
 		if needsKubeConfig {
 			pod.Spec.ServiceAccountName = s.name
@@ -175,19 +187,14 @@ func (s *multiStageTestStep) generatePods(
 			}
 		}
 
-		addSecretWrapper(pod, s.vpnConf, !needsKubeConfig, genPodOpts)
+		addSecretWrapper(pod, s.vpnConf, !needsKubeConfig, sharedDirSecret, genPodOpts)
 		if s.vpnConf != nil {
 			s.addVPNClient(pod)
 		}
 		container := &pod.Spec.Containers[0]
-		container.Env = append(container.Env, []coreapi.EnvVar{
-			{Name: "NAMESPACE", Value: s.jobSpec.Namespace()},
-			{Name: "JOB_NAME_SAFE", Value: strings.Replace(s.name, "_", "-", -1)},
-			{Name: "JOB_NAME_HASH", Value: s.jobSpec.JobNameHash()},
-			{Name: "UNIQUE_HASH", Value: s.jobSpec.UniqueHash()},
-		}...)
+		container.Env = append(container.Env, JobEnv(s.jobSpec.Namespace(), s.name, s.jobSpec)...)
 		container.Env = append(container.Env, env...)
-		container.Env = append(container.Env, s.generateParams(step.Environment)...)
+		container.Env = append(container.Env, ParameterEnv(step.Environment, s.env)...)
 		depEnv, depErrs := s.envForDependencies(step)
 		if len(depErrs) != 0 {
 			errs = append(errs, depErrs...)
@@ -212,11 +219,7 @@ func (s *multiStageTestStep) generatePods(
 				container.VolumeMounts = append(container.VolumeMounts, clusterClaimMount...)
 			}
 		} else if needsKubeConfig {
-			container.Env = append(container.Env, []coreapi.EnvVar{
-				{Name: "KUBECONFIG", Value: filepath.Join(SecretMountPath, "kubeconfig")},
-				{Name: "KUBECONFIGMINIMAL", Value: filepath.Join(SecretMountPath, "kubeconfig-minimal")},
-				{Name: "KUBEADMIN_PASSWORD_FILE", Value: filepath.Join(SecretMountPath, "kubeadmin-password")},
-			}...)
+			container.Env = append(container.Env, KubeconfigEnv(SecretMountPath)...)
 		}
 		shmSize := allResources.Requests.Name(api.ShmResource, resource.BinarySI)
 		if !shmSize.IsZero() {
@@ -230,7 +233,11 @@ func (s *multiStageTestStep) generatePods(
 			imagestream, _, _ := s.config.DependencyParts(dependency, claimRelease)
 			addCliInjector(imagestream, pod)
 		}
-		addSharedDirSecret(s.name, pod)
+		if sharedDirSecret == "" {
+			addSharedDirSecret(s.name, s.name, pod)
+		} else {
+			addSharedDirSecret(s.name, sharedDirSecret, pod)
+		}
 		addCredentials(step.Credentials, pod)
 		if step.RunAsScript != nil && *step.RunAsScript {
 			addCommandScript(commandConfigMapForTest(s.name), pod)
@@ -262,7 +269,10 @@ func isKubeconfigNeeded(step *api.LiteralTestStep, opts *generatePodOptions) boo
 	return needsKubeconfig || opts.IsObserver
 }
 
-func addSecretWrapper(pod *coreapi.Pod, vpnConf *vpnConf, skipKubeconfig bool, genPodOpts *generatePodOptions) {
+// addSecretWrapper wraps the command of the pod so the shared directory is
+// written back to a secret when it finishes.  The secret is the one named after
+// the test unless `sharedDirSecret` is set.
+func addSecretWrapper(pod *coreapi.Pod, vpnConf *vpnConf, skipKubeconfig bool, sharedDirSecret string, genPodOpts *generatePodOptions) {
 	volume := "entrypoint-wrapper"
 	dir := "/tmp/entrypoint-wrapper"
 	bin := filepath.Join(dir, "entrypoint-wrapper")
@@ -295,6 +305,9 @@ func addSecretWrapper(pod *coreapi.Pod, vpnConf *vpnConf, skipKubeconfig bool, g
 	if genPodOpts.IsObserver {
 		container.Args = append(container.Args, "--mode=observer")
 	}
+	if sharedDirSecret != "" {
+		container.Args = append(container.Args, "--shared-dir-secret="+sharedDirSecret)
+	}
 	container.Args = append(container.Args, container.Command...)
 	container.Args = append(container.Args, args...)
 	container.Command = []string{bin}
@@ -370,14 +383,26 @@ func setSecurityContexts(
 	f(pod.Spec.Containers)
 }
 
-func (s *multiStageTestStep) generateParams(env []api.StepParameter) []coreapi.EnvVar {
+// JobEnv identifies the job and the test a step runs in
+func JobEnv(namespace, testName string, jobSpec *api.JobSpec) []coreapi.EnvVar {
+	return []coreapi.EnvVar{
+		{Name: "NAMESPACE", Value: namespace},
+		{Name: "JOB_NAME_SAFE", Value: strings.Replace(testName, "_", "-", -1)},
+		{Name: "JOB_NAME_HASH", Value: jobSpec.JobNameHash()},
+		{Name: "UNIQUE_HASH", Value: jobSpec.UniqueHash()},
+	}
+}
+
+// ParameterEnv sets the parameters of a step to the values in the test
+// environment or to their defaults
+func ParameterEnv(params []api.StepParameter, testEnv api.TestEnvironment) []coreapi.EnvVar {
 	var ret []coreapi.EnvVar
-	for _, env := range env {
+	for _, env := range params {
 		value := ""
 		if env.Default != nil {
 			value = *env.Default
 		}
-		if v, ok := s.env[env.Name]; ok {
+		if v, ok := testEnv[env.Name]; ok {
 			value = v
 		}
 		ret = append(ret, coreapi.EnvVar{Name: env.Name, Value: value})
@@ -385,6 +410,25 @@ func (s *multiStageTestStep) generateParams(env []api.StepParameter) []coreapi.E
 	return ret
 }
 
+// KubeconfigEnv points a step to the kubeconfig of the cluster under test in
+// the shared directory
+func KubeconfigEnv(sharedDir string) []coreapi.EnvVar {
+	return []coreapi.EnvVar{
+		{Name: "KUBECONFIG", Value: filepath.Join(sharedDir, "kubeconfig")},
+		{Name: "KUBECONFIGMINIMAL", Value: filepath.Join(sharedDir, "kubeconfig-minimal")},
+		{Name: "KUBEADMIN_PASSWORD_FILE", Value: filepath.Join(sharedDir, "kubeadmin-password")},
+	}
+}
+
+// ClusterProfileEnv describes the cluster profile of a test to its steps
+func ClusterProfileEnv(profile api.ClusterProfile, dir string) []coreapi.EnvVar {
+	return []coreapi.EnvVar{
+		{Name: "CLUSTER_PROFILE_NAME", Value: profile.Name()},
+		{Name: "CLUSTER_TYPE", Value: profile.ClusterType()},
+		{Name: ClusterProfileMountEnv, Value: dir},
+	}
+}
+
 func (s *multiStageTestStep) envForDependencies(step api.LiteralTestStep) ([]coreapi.EnvVar, []error) {
 	var env []coreapi.EnvVar
 	var errs []error
@@ -476,16 +520,7 @@ func addProfile(name string, profile api.ClusterProfile, pod *coreapi.Pod) {
 		Name:      profileVolumeName,
 		MountPath: ClusterProfileMountPath,
 	})
-	container.Env = append(container.Env, []coreapi.EnvVar{{
-		Name:  "CLUSTER_PROFILE_NAME",
-		Value: profile.Name(),
-	}, {
-		Name:  "CLUSTER_TYPE",
-		Value: profile.ClusterType(),
-	}, {
-		Name:  ClusterProfileMountEnv,
-		Value: ClusterProfileMountPath,
-	}}...)
+	container.Env = append(container.Env, ClusterProfileEnv(profile, ClusterProfileMountPath)...)
 }
 
 func addCliInjector(imagestream string, pod *coreapi.Pod) {
@@ -517,15 +552,15 @@ func addCliInjector(imagestream string, pod *coreapi.Pod) {
 	})
 }
 
-func addSharedDirSecret(secret string, pod *coreapi.Pod) {
+func addSharedDirSecret(volume, secret string, pod *coreapi.Pod) {
 	pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{
-		Name: secret,
+		Name: volume,
 		VolumeSource: coreapi.VolumeSource{
 			Secret: &coreapi.SecretVolumeSource{SecretName: secret},
 		},
 	})
 	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, coreapi.VolumeMount{
-		Name:      secret,
+		Name:      volume,
 		MountPath: SecretMountPath,
 	})
 	pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, coreapi.EnvVar{
@@ -313,6 +313,62 @@ func TestGeneratePodBestEffort(t *testing.T) {
 	}
 }
 
+func TestGeneratePodParallelGroup(t *testing.T) {
+	config := api.ReleaseBuildConfiguration{
+		Tests: []api.TestStepConfiguration{{
+			As: "test",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				Post: []api.LiteralTestStep{
+					{As: "step0", From: "src", Commands: "command0", ParallelGroup: "step0"},
+					{As: "step1", From: "src", Commands: "command1"},
+				},
+			},
+		}},
+	}
+	jobSpec := api.JobSpec{
+		JobSpec: prowdapi.JobSpec{
+			Job:       "job",
+			BuildID:   "build id",
+			ProwJobID: "prow job id",
+			Type:      prowapi.PeriodicJob,
+			DecorationConfig: &prowapi.DecorationConfig{
+				Timeout:     &prowapi.Duration{Duration: time.Minute},
+				GracePeriod: &prowapi.Duration{Duration: time.Second},
+				UtilityImages: &prowapi.UtilityImages{
+					Sidecar:    "sidecar",
+					Entrypoint: "entrypoint",
+				},
+			},
+		},
+	}
+	jobSpec.SetNamespace("namespace")
+	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil)
+	pods, _, err := step.generatePods(config.Tests[0].MultiStageTestConfigurationLiteral.Post, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []struct {
+		group, secret string
+	}{
+		{group: "step0", secret: "test-step0-shared-dir"},
+		{secret: "test"},
+	} {
+		pod := pods[i]
+		if group := pod.Annotations[ParallelGroupAnnotation]; group != expected.group {
+			t.Errorf("expected pod %s to be in group %q, got %q", pod.Name, expected.group, group)
+		}
+		var secret string
+		for _, v := range pod.Spec.Volumes {
+			if v.Name == "test" && v.Secret != nil {
+				secret = v.Secret.SecretName
+			}
+		}
+		if secret != expected.secret {
+			t.Errorf("expected pod %s to mount shared directory %q, got %q", pod.Name, expected.secret, secret)
+		}
+	}
+}
+
 func TestAddCredentials(t *testing.T) {
 	var testCases = []struct {
 		name        string
@@ -58,6 +58,9 @@ const (
 const (
 	// MultiStageTestLabel is the label we use to mark a pod as part of a multi-stage test
 	MultiStageTestLabel = "ci.openshift.io/multi-stage-test"
+	// ParallelGroupAnnotation marks the pods of steps which run concurrently
+	// with the adjacent steps of the same group
+	ParallelGroupAnnotation = "ci.openshift.io/multi-stage-parallel-group"
 	// ClusterProfileMountPath is where we mount the cluster profile in a pod
 	ClusterProfileMountPath = "/var/run/secrets/ci.openshift.io/cluster-profile"
 	// SecretMountPath is where we mount the shared dir secret
@@ -77,7 +80,9 @@ const (
 	vpnConfPath = "vpn.yaml"
 )
 
-var envForProfile = []string{
+// EnvForProfile are the parameters exposed to the steps of tests with a
+// cluster profile
+var EnvForProfile = []string{
 	utils.ReleaseImageEnv(api.LatestReleaseName),
 	utils.ImageFormatEnv,
 }
@@ -291,7 +296,7 @@ func (s *multiStageTestStep) Requires() (ret []api.StepLink) {
 	}
 	if s.profile != "" {
 		needsReleasePayload = true
-		for _, env := range envForProfile {
+		for _, env := range EnvForProfile {
 			if link, ok := utils.LinkForEnv(env); ok {
 				ret = append(ret, link)
 			}
@@ -369,7 +374,7 @@ func (s *multiStageTestStep) environment() ([]coreapi.EnvVar, error) {
 	}
 
 	if s.profile != "" {
-		for _, e := range envForProfile {
+		for _, e := range EnvForProfile {
 			val, err := s.params.Get(e)
 			if err != nil {
 				return nil, err
@@ -0,0 +1,189 @@
+package multi_stage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
+)
+
+// parallelSharedDirSecret is the name of the copy of the shared directory a
+// step in a parallel group works on.
+func parallelSharedDirSecret(test, step string) string {
+	return fmt.Sprintf("%s-%s-shared-dir", test, step)
+}
+
+// parallelSharedDirSecrets lists the copies of the shared directory used by
+// the steps of all parallel groups in the test.
+func (s *multiStageTestStep) parallelSharedDirSecrets() []string {
+	var ret []string
+	for _, phase := range [][]api.LiteralTestStep{s.pre, s.test, s.post} {
+		for _, step := range phase {
+			if step.ParallelGroup != "" {
+				ret = append(ret, parallelSharedDirSecret(s.name, step.As))
+			}
+		}
+	}
+	return ret
+}
+
+// runParallelGroup runs the pods of a parallel group concurrently.  Each step
+// starts with its own copy of the shared directory; once all of them finish,
+// the changes are merged back into the shared directory of the test.  Errors
+// are returned in the order in which the steps are defined.
+func (s *multiStageTestStep) runParallelGroup(ctx context.Context, group string, pods []coreapi.Pod, bestEffortSteps sets.Set[string], retries map[string]*stepRetry) []error {
+	logrus.Infof("Running steps of parallel group %s.", group)
+	ns := s.jobSpec.Namespace()
+	base := &coreapi.Secret{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: ns, Name: s.name}, base); err != nil {
+		return []error{fmt.Errorf("failed to get shared directory %q: %w", s.name, err)}
+	}
+	steps := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		step := pod.Labels[base_steps.LabelMetadataStep]
+		steps = append(steps, step)
+		if err := s.createParallelSharedDir(ctx, parallelSharedDirSecret(s.name, step), base.Data); err != nil {
+			return []error{err}
+		}
+	}
+	stepErrs := make([]error, len(pods))
+	var wg sync.WaitGroup
+	wg.Add(len(pods))
+	for i := range pods {
+		go func(i int) {
+			defer wg.Done()
+			stepErrs[i] = s.runStep(ctx, pods[i], bestEffortSteps, retries)
+		}(i)
+	}
+	wg.Wait()
+	var errs []error
+	for _, err := range stepErrs {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := s.mergeParallelSharedDirs(group, base, steps); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func (s *multiStageTestStep) createParallelSharedDir(ctx context.Context, name string, data map[string][]byte) error {
+	secret := &coreapi.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: s.jobSpec.Namespace(),
+			Name:      name,
+			Labels:    map[string]string{api.SkipCensoringLabel: "true", MultiStageTestLabel: s.name},
+		},
+		Data: make(map[string][]byte, len(data)),
+	}
+	for k, v := range data {
+		secret.Data[k] = v
+	}
+	if err := s.client.Delete(ctx, secret); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("cannot delete shared directory %q: %w", name, err)
+	}
+	if err := s.client.Create(ctx, secret); err != nil {
+		return fmt.Errorf("cannot create shared directory %q: %w", name, err)
+	}
+	return nil
+}
+
+// mergeParallelSharedDirs writes the changes made by the steps of a parallel
+// group back to the shared directory of the test and removes their copies.
+func (s *multiStageTestStep) mergeParallelSharedDirs(group string, base *coreapi.Secret, steps []string) error {
+	ctx := base_steps.CleanupCtx
+	ns := s.jobSpec.Namespace()
+	dirs := make([]map[string][]byte, 0, len(steps))
+	var errs []error
+	for _, step := range steps {
+		secret := &coreapi.Secret{}
+		name := parallelSharedDirSecret(s.name, step)
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: ns, Name: name}, secret); err != nil {
+			errs = append(errs, fmt.Errorf("failed to get shared directory %q: %w", name, err))
+			dirs = append(dirs, base.Data)
+			continue
+		}
+		dirs = append(dirs, secret.Data)
+		if err := s.client.Delete(ctx, secret); err != nil && !kerrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("cannot delete shared directory %q: %w", name, err))
+		}
+	}
+	merged, err := mergeSharedDirs(base.Data, steps, dirs)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("parallel group %s: %w", group, err))
+	}
+	base.Data = merged
+	if err := s.client.Update(ctx, base); err != nil {
+		errs = append(errs, fmt.Errorf("failed to update shared directory %q: %w", s.name, err))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// mergeSharedDirs applies the changes each step made to its copy of the base
+// shared directory.  A file is changed by a step if it was created, modified
+// or removed.  Steps which change a file the same way do not conflict; when
+// they disagree, the change of the step defined first is kept and an error
+// listing every conflicting file is returned.
+func mergeSharedDirs(base map[string][]byte, steps []string, dirs []map[string][]byte) (map[string][]byte, error) {
+	files := sets.New[string]()
+	for k := range base {
+		files.Insert(k)
+	}
+	for _, dir := range dirs {
+		for k := range dir {
+			files.Insert(k)
+		}
+	}
+	ret := make(map[string][]byte, len(base))
+	for k, v := range base {
+		ret[k] = v
+	}
+	var conflicts []string
+	for _, file := range sets.List(files) {
+		baseValue, inBase := base[file]
+		var changedBy []string
+		var value []byte
+		var present, conflict bool
+		for i, dir := range dirs {
+			v, ok := dir[file]
+			if ok == inBase && bytes.Equal(v, baseValue) {
+				continue
+			}
+			if changedBy == nil {
+				value, present = v, ok
+			} else if ok != present || !bytes.Equal(v, value) {
+				conflict = true
+			}
+			changedBy = append(changedBy, steps[i])
+		}
+		if changedBy == nil {
+			continue
+		}
+		if present {
+			ret[file] = value
+		} else {
+			delete(ret, file)
+		}
+		if conflict {
+			conflicts = append(conflicts, fmt.Sprintf("%s (changed by %s)", file, strings.Join(changedBy, ", ")))
+		}
+	}
+	if conflicts != nil {
+		return ret, fmt.Errorf("conflicting changes to the shared directory: %s", strings.Join(conflicts, "; "))
+	}
+	return ret, nil
+}
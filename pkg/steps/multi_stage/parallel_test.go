@@ -0,0 +1,72 @@
+package multi_stage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestMergeSharedDirs(t *testing.T) {
+	base := map[string][]byte{
+		"kubeconfig": []byte("kubeconfig"),
+		"proxy":      []byte("proxy"),
+	}
+	for _, tc := range []struct {
+		name        string
+		dirs        []map[string][]byte
+		expected    map[string][]byte
+		expectedErr error
+	}{{
+		name:     "no changes",
+		dirs:     []map[string][]byte{base, base},
+		expected: base,
+	}, {
+		name: "changes to different files are merged",
+		dirs: []map[string][]byte{
+			{"kubeconfig": []byte("kubeconfig"), "proxy": []byte("proxy"), "audit.log": []byte("audit")},
+			{"kubeconfig": []byte("kubeconfig"), "proxy": []byte("new proxy")},
+			{"proxy": []byte("proxy")},
+		},
+		expected: map[string][]byte{
+			"proxy":     []byte("new proxy"),
+			"audit.log": []byte("audit"),
+		},
+	}, {
+		name: "identical changes do not conflict",
+		dirs: []map[string][]byte{
+			{"kubeconfig": []byte("kubeconfig"), "proxy": []byte("proxy"), "metrics": []byte("metrics")},
+			{"kubeconfig": []byte("kubeconfig"), "proxy": []byte("proxy"), "metrics": []byte("metrics")},
+			{"kubeconfig": []byte("kubeconfig")},
+		},
+		expected: map[string][]byte{
+			"kubeconfig": []byte("kubeconfig"),
+			"metrics":    []byte("metrics"),
+		},
+	}, {
+		name: "conflicts keep the change of the first step and are all reported",
+		dirs: []map[string][]byte{
+			{"kubeconfig": []byte("a"), "proxy": []byte("proxy"), "z": []byte("a")},
+			{"kubeconfig": []byte("b"), "proxy": []byte("proxy"), "z": []byte("b")},
+			{"kubeconfig": []byte("a"), "proxy": []byte("proxy")},
+		},
+		expected: map[string][]byte{
+			"kubeconfig": []byte("a"),
+			"proxy":      []byte("proxy"),
+			"z":          []byte("a"),
+		},
+		expectedErr: errors.New("conflicting changes to the shared directory: kubeconfig (changed by a, b, c); z (changed by a, b)"),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := mergeSharedDirs(base, []string{"a", "b", "c"}[:len(tc.dirs)], tc.dirs)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected shared directory: %s", diff)
+			}
+		})
+	}
+}
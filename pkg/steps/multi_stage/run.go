@@ -18,7 +18,9 @@ import (
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/results"
 	base_steps "github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/util"
 )
@@ -33,6 +35,7 @@ func (s *multiStageTestStep) runSteps(
 ) error {
 	start := time.Now()
 	logrus.Infof("Running multi-stage phase %s", phase)
+	steps = s.skipUnchangedSteps(ctx, steps)
 	pods, bestEffortSteps, err := s.generatePods(steps, env, secretVolumes, secretVolumeMounts, nil)
 	if err != nil {
 		s.flags |= hasPrevErrs
@@ -44,7 +47,27 @@ func (s *multiStageTestStep) runSteps(
 			s.flags |= hasPrevErrs
 		}
 	}()
-	if err := s.runPods(ctx, pods, bestEffortSteps); err != nil {
+	retries := map[string]*stepRetry{}
+	for _, step := range steps {
+		if step.Retry == nil {
+			continue
+		}
+		step := step
+		retries[fmt.Sprintf("%s-%s", s.name, step.As)] = &stepRetry{
+			policy: step.Retry,
+			generate: func(attempt int) (*coreapi.Pod, error) {
+				pods, _, err := s.generatePods([]api.LiteralTestStep{step}, env, secretVolumes, secretVolumeMounts, &generatePodOptions{Attempt: attempt})
+				if err != nil {
+					return nil, err
+				}
+				if len(pods) != 1 {
+					return nil, fmt.Errorf("expected one pod for step %s, got %d", step.As, len(pods))
+				}
+				return &pods[0], nil
+			},
+		}
+	}
+	if err := s.runPods(ctx, pods, bestEffortSteps, retries); err != nil {
 		errs = append(errs, err)
 	}
 	select {
@@ -79,25 +102,150 @@ func (s *multiStageTestStep) runSteps(
 	return err
 }
 
-func (s *multiStageTestStep) runPods(ctx context.Context, pods []coreapi.Pod, bestEffortSteps sets.Set[string]) error {
-	var errs []error
-	for _, pod := range pods {
-		err := s.runPod(ctx, &pod, base_steps.NewTestCaseNotifier(util.NopNotifier), util.WaitForPodFlag(0))
-		if err == nil {
+// skipUnchangedSteps filters out the steps which do not need to run for the
+// files changed by the pull requests under test and records them as skipped.
+// Steps run when the changed files cannot be determined.
+func (s *multiStageTestStep) skipUnchangedSteps(ctx context.Context, steps []api.LiteralTestStep) []api.LiteralTestStep {
+	changedFiles := s.jobSpec.ChangedFiles()
+	if changedFiles == nil {
+		return steps
+	}
+	var ret []api.LiteralTestStep
+	for _, step := range steps {
+		if step.RunIfChanged == "" && step.SkipIfOnlyChanged == "" {
+			ret = append(ret, step)
 			continue
 		}
-		if bestEffortSteps != nil && bestEffortSteps.Has(pod.Name) {
-			logrus.Infof("Pod %s is running in best-effort mode, ignoring the failure...", pod.Name)
+		name := fmt.Sprintf("%s-%s", s.name, step.As)
+		changes, err := changedFiles()
+		if err != nil {
+			logrus.WithError(err).Warnf("Could not determine the changed files, running step %s.", name)
+			ret = append(ret, step)
 			continue
 		}
-		errs = append(errs, err)
-		if s.flags&shortCircuit != 0 {
+		run, err := step.RunsAgainstChanges(changes)
+		if err != nil {
+			logrus.WithError(err).Warnf("Could not evaluate the changed files, running step %s.", name)
+			ret = append(ret, step)
+			continue
+		}
+		if run {
+			ret = append(ret, step)
+			continue
+		}
+		logrus.Infof("Skipping step %s, no relevant files were changed.", name)
+		events.FromContext(ctx).Emit(events.Event{Type: events.StepSkipped, Step: name})
+		s.subLock.Lock()
+		s.subTests = append(s.subTests, &junit.TestCase{
+			Name:        fmt.Sprintf("%s - %s container test", s.Description(), name),
+			SkipMessage: &junit.SkipMessage{Message: "No files relevant to the step were changed."},
+		})
+		s.subLock.Unlock()
+	}
+	return ret
+}
+
+// stepRetry holds the retry policy of a step and a way to generate the pod
+// for another attempt of it.
+type stepRetry struct {
+	policy   *api.RetryPolicy
+	generate func(attempt int) (*coreapi.Pod, error)
+}
+
+// runPods runs the pods of a phase in order.  Adjacent pods of the same
+// parallel group run at the same time.
+func (s *multiStageTestStep) runPods(ctx context.Context, pods []coreapi.Pod, bestEffortSteps sets.Set[string], retries map[string]*stepRetry) error {
+	var errs []error
+	for i := 0; i < len(pods); {
+		group, end := pods[i].Annotations[ParallelGroupAnnotation], i+1
+		for group != "" && end < len(pods) && pods[end].Annotations[ParallelGroupAnnotation] == group {
+			end++
+		}
+		var stepErrs []error
+		if group == "" {
+			if err := s.runStep(ctx, pods[i], bestEffortSteps, retries); err != nil {
+				stepErrs = append(stepErrs, err)
+			}
+		} else {
+			stepErrs = s.runParallelGroup(ctx, group, pods[i:end], bestEffortSteps, retries)
+		}
+		errs = append(errs, stepErrs...)
+		if len(stepErrs) != 0 && s.flags&shortCircuit != 0 {
 			break
 		}
+		i = end
 	}
 	return utilerrors.NewAggregate(errs)
 }
 
+// runStep runs the pod of a step, ignoring the failure of best-effort steps.
+func (s *multiStageTestStep) runStep(ctx context.Context, pod coreapi.Pod, bestEffortSteps sets.Set[string], retries map[string]*stepRetry) error {
+	name := pod.Name
+	err := s.runPodWithRetries(ctx, pod, retries[name])
+	if err != nil && bestEffortSteps != nil && bestEffortSteps.Has(name) {
+		logrus.Infof("Pod %s is running in best-effort mode, ignoring the failure...", name)
+		return nil
+	}
+	return err
+}
+
+// runPodWithRetries runs the pod of a step and, if it fails in a way the
+// retry policy of the step covers, runs new pods for further attempts. Each
+// attempt is recorded as a separate sub-step with its own artifacts.
+func (s *multiStageTestStep) runPodWithRetries(ctx context.Context, pod coreapi.Pod, retry *stepRetry) error {
+	err := s.runPod(ctx, &pod, base_steps.NewTestCaseNotifier(util.NopNotifier), util.WaitForPodFlag(0))
+	if retry == nil {
+		return err
+	}
+	var backoff time.Duration
+	if retry.policy.Backoff != nil {
+		backoff = retry.policy.Backoff.Duration
+	}
+	for attempt := 2; err != nil && attempt <= retry.policy.MaxAttempts && ctx.Err() == nil && shouldRetry(retry.policy, &pod, err); attempt++ {
+		logrus.Infof("Step %s failed, starting attempt %d/%d in %s.", pod.Name, attempt, retry.policy.MaxAttempts, backoff)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		next, genErr := retry.generate(attempt)
+		if genErr != nil {
+			return utilerrors.NewAggregate([]error{err, fmt.Errorf("failed to generate pod for attempt %d: %w", attempt, genErr)})
+		}
+		pod = *next
+		err = s.runPod(ctx, &pod, base_steps.NewTestCaseNotifier(util.NopNotifier), util.WaitForPodFlag(0))
+	}
+	return err
+}
+
+// shouldRetry determines whether the failure of a step's pod is covered by
+// its retry policy.
+func shouldRetry(policy *api.RetryPolicy, pod *coreapi.Pod, err error) bool {
+	if len(policy.ExitCodes) == 0 && len(policy.Reasons) == 0 {
+		return true
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName || status.State.Terminated == nil {
+			continue
+		}
+		for _, code := range policy.ExitCodes {
+			if status.State.Terminated.ExitCode == code {
+				return true
+			}
+		}
+	}
+	reasons := sets.New[string](policy.Reasons...)
+	for _, chain := range results.Reasons(err) {
+		for _, reason := range strings.Split(chain, ":") {
+			if reasons.Has(reason) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s *multiStageTestStep) runObservers(ctx, textCtx context.Context, pods []coreapi.Pod, done chan<- struct{}) {
 	wg := sync.WaitGroup{}
 	wg.Add(len(pods))
@@ -132,6 +280,8 @@ func (s *multiStageTestStep) runObservers(ctx, textCtx context.Context, pods []c
 	done <- struct{}{}
 }
 
+// runPod creates the pod and waits for it to complete. The pod is updated
+// with the last state observed in the cluster.
 func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notifier *base_steps.TestCaseNotifier, flags util.WaitForPodFlag) error {
 	start := time.Now()
 	logrus.Infof("Running step %s.", pod.Name)
@@ -141,7 +291,7 @@ func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notif
 	}
 	newPod, err := util.WaitForPodCompletion(ctx, client, pod.Namespace, pod.Name, notifier, flags)
 	if newPod != nil {
-		pod = newPod
+		*pod = *newPod
 	}
 	finished := time.Now()
 	duration := finished.Sub(start)
@@ -164,7 +314,11 @@ func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notif
 	s.subLock.Unlock()
 	if err != nil {
 		linksText := strings.Builder{}
-		linksText.WriteString(fmt.Sprintf("Link to step on registry info site: https://steps.ci.openshift.org/reference/%s", strings.TrimPrefix(pod.Name, s.name+"-")))
+		step, ok := pod.Labels[base_steps.LabelMetadataStep]
+		if !ok {
+			step = strings.TrimPrefix(pod.Name, s.name+"-")
+		}
+		linksText.WriteString(fmt.Sprintf("Link to step on registry info site: https://steps.ci.openshift.org/reference/%s", step))
 		linksText.WriteString(fmt.Sprintf("\nLink to job on registry info site: https://steps.ci.openshift.org/job?org=%s&repo=%s&branch=%s&test=%s", s.config.Metadata.Org, s.config.Metadata.Repo, s.config.Metadata.Branch, s.name))
 		if s.config.Metadata.Variant != "" {
 			linksText.WriteString(fmt.Sprintf("&variant=%s", s.config.Metadata.Variant))
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -277,6 +278,255 @@ func TestJUnit(t *testing.T) {
 	}
 }
 
+// runFixture returns a pod client in which pods with the given names fail
+// and the periodic job that runs the multi-stage test
+func runFixture(failures sets.Set[string]) (*testhelper_kube.FakePodExecutor, *api.JobSpec) {
+	sa := &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-namespace", Labels: map[string]string{"ci.openshift.io/multi-stage-test": "test"}}}
+	crclient := &testhelper_kube.FakePodExecutor{
+		LoggingClient: loggingclient.New(
+			fakectrlruntimeclient.NewClientBuilder().
+				WithIndex(&v1.Pod{}, "metadata.name", fakePodNameIndexer).
+				WithObjects(sa).
+				Build()),
+		Failures: failures,
+	}
+	jobSpec := &api.JobSpec{
+		JobSpec: prowdapi.JobSpec{
+			Job:       "job",
+			BuildID:   "build_id",
+			ProwJobID: "prow_job_id",
+			Type:      prowapi.PeriodicJob,
+			DecorationConfig: &prowapi.DecorationConfig{
+				Timeout:     &prowapi.Duration{Duration: time.Minute},
+				GracePeriod: &prowapi.Duration{Duration: time.Second},
+				UtilityImages: &prowapi.UtilityImages{
+					Sidecar:    "sidecar",
+					Entrypoint: "entrypoint",
+				},
+			},
+		},
+	}
+	jobSpec.SetNamespace("test-namespace")
+	return crclient, jobSpec
+}
+
+func TestRunRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		retry         *api.RetryPolicy
+		failures      sets.Set[string]
+		expectedErr   bool
+		expectedPods  []string
+		expectedTests []string
+	}{{
+		name:         "no retry policy, failure is final",
+		failures:     sets.New[string]("test-test0"),
+		expectedErr:  true,
+		expectedPods: []string{"test-test0"},
+	}, {
+		name:         "succeeds on the second attempt",
+		retry:        &api.RetryPolicy{MaxAttempts: 3},
+		failures:     sets.New[string]("test-test0"),
+		expectedPods: []string{"test-test0", "test-test0-attempt-2"},
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test - test-test0-attempt-2 container test",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}, {
+		name:         "fails on every attempt",
+		retry:        &api.RetryPolicy{MaxAttempts: 2},
+		failures:     sets.New[string]("test-test0", "test-test0-attempt-2"),
+		expectedErr:  true,
+		expectedPods: []string{"test-test0", "test-test0-attempt-2"},
+	}, {
+		name:         "matching exit code is retried",
+		retry:        &api.RetryPolicy{MaxAttempts: 2, ExitCodes: []int32{1}},
+		failures:     sets.New[string]("test-test0"),
+		expectedPods: []string{"test-test0", "test-test0-attempt-2"},
+	}, {
+		name:         "other exit codes are not retried",
+		retry:        &api.RetryPolicy{MaxAttempts: 2, ExitCodes: []int32{137}},
+		failures:     sets.New[string]("test-test0"),
+		expectedErr:  true,
+		expectedPods: []string{"test-test0"},
+	}, {
+		name:         "other reasons are not retried",
+		retry:        &api.RetryPolicy{MaxAttempts: 2, Reasons: []string{api.ReasonPending}},
+		failures:     sets.New[string]("test-test0"),
+		expectedErr:  true,
+		expectedPods: []string{"test-test0"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			crclient, jobSpec := runFixture(tc.failures)
+			step := MultiStageTestStep(api.TestStepConfiguration{
+				As: "test",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test: []api.LiteralTestStep{{As: "test0", Retry: tc.retry}},
+				},
+			}, &api.ReleaseBuildConfiguration{}, nil, &testhelper_kube.FakePodClient{FakePodExecutor: crclient}, jobSpec, nil, "node-name", "", nil)
+			if err := step.Run(context.Background()); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got error: %v", tc.expectedErr, err)
+			}
+			var pods []string
+			for _, pod := range crclient.CreatedPods {
+				pods = append(pods, pod.Name)
+			}
+			if diff := cmp.Diff(tc.expectedPods, pods); diff != "" {
+				t.Errorf("unexpected pods: %s", diff)
+			}
+			if tc.expectedTests == nil {
+				return
+			}
+			var tests []string
+			for _, t := range step.(steps.SubtestReporter).SubTests() {
+				tests = append(tests, t.Name)
+			}
+			if diff := cmp.Diff(tc.expectedTests, tests); diff != "" {
+				t.Errorf("unexpected tests: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRunSkipUnchanged(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		changedFiles  api.ChangedFilesProvider
+		expectedPods  []string
+		expectedTests []string
+		expectedSkips []string
+	}{{
+		name:         "job does not test pull requests, all steps run",
+		expectedPods: []string{"test-docs", "test-code", "test-always"},
+	}, {
+		name:         "only documentation changed",
+		changedFiles: func() ([]string, error) { return []string{"README.md"}, nil },
+		expectedPods: []string{"test-docs", "test-always"},
+		expectedSkips: []string{
+			"Run multi-stage test test - test-code container test",
+		},
+	}, {
+		name:         "only code changed",
+		changedFiles: func() ([]string, error) { return []string{"main.go"}, nil },
+		expectedPods: []string{"test-code", "test-always"},
+		expectedSkips: []string{
+			"Run multi-stage test test - test-docs container test",
+		},
+	}, {
+		name:         "changes cannot be determined, all steps run",
+		changedFiles: func() ([]string, error) { return nil, fmt.Errorf("injected failure") },
+		expectedPods: []string{"test-docs", "test-code", "test-always"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			crclient, jobSpec := runFixture(nil)
+			jobSpec.SetChangedFiles(tc.changedFiles)
+			step := MultiStageTestStep(api.TestStepConfiguration{
+				As: "test",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test: []api.LiteralTestStep{
+						{As: "docs", RunIfChanged: `\.md$`},
+						{As: "code", SkipIfOnlyChanged: `\.md$`},
+						{As: "always"},
+					},
+				},
+			}, &api.ReleaseBuildConfiguration{}, nil, &testhelper_kube.FakePodClient{FakePodExecutor: crclient}, jobSpec, nil, "node-name", "", nil)
+			if err := step.Run(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var pods []string
+			for _, pod := range crclient.CreatedPods {
+				pods = append(pods, pod.Name)
+			}
+			if diff := cmp.Diff(tc.expectedPods, pods); diff != "" {
+				t.Errorf("unexpected pods: %s", diff)
+			}
+			var skips []string
+			for _, t := range step.(steps.SubtestReporter).SubTests() {
+				if t.SkipMessage != nil {
+					skips = append(skips, t.Name)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedSkips, skips); diff != "" {
+				t.Errorf("unexpected skipped tests: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		failures     sets.Set[string]
+		expectedErr  bool
+		expectedPods []string
+	}{{
+		name:         "all steps succeed",
+		expectedPods: []string{"test-pre0", "test-test0", "test-test1", "test-test2", "test-post0", "test-post1"},
+	}, {
+		name:         "failure in a group does not stop the rest of the group, but the phase",
+		failures:     sets.New[string]("test-test0"),
+		expectedErr:  true,
+		expectedPods: []string{"test-pre0", "test-test0", "test-test1", "test-post0", "test-post1"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			crclient, jobSpec := runFixture(tc.failures)
+			step := MultiStageTestStep(api.TestStepConfiguration{
+				As: "test",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Pre: []api.LiteralTestStep{{As: "pre0"}},
+					Test: []api.LiteralTestStep{
+						{As: "test0", ParallelGroup: "test0"},
+						{As: "test1", ParallelGroup: "test0"},
+						{As: "test2"},
+					},
+					Post: []api.LiteralTestStep{
+						{As: "post0", ParallelGroup: "post0"},
+						{As: "post1", ParallelGroup: "post0"},
+					},
+				},
+			}, &api.ReleaseBuildConfiguration{}, nil, &testhelper_kube.FakePodClient{FakePodExecutor: crclient}, jobSpec, nil, "node-name", "", nil)
+			if err := step.Run(context.Background()); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got error: %v", tc.expectedErr, err)
+			}
+			pods := map[string]*v1.Pod{}
+			var names []string
+			for _, pod := range crclient.CreatedPods {
+				pods[pod.Name] = pod
+				names = append(names, pod.Name)
+			}
+			// steps of a group can start in any order
+			for i := 0; i < len(names); {
+				group, end := pods[names[i]].Annotations[ParallelGroupAnnotation], i+1
+				for group != "" && end < len(names) && pods[names[end]].Annotations[ParallelGroupAnnotation] == group {
+					end++
+				}
+				sort.Strings(names[i:end])
+				i = end
+			}
+			if diff := cmp.Diff(tc.expectedPods, names); diff != "" {
+				t.Errorf("unexpected pods: %s", diff)
+			}
+			for name, pod := range pods {
+				_, grouped := pod.Annotations[ParallelGroupAnnotation]
+				arg := "--shared-dir-secret=" + name + "-shared-dir"
+				if has := sets.New[string](pod.Spec.Containers[0].Args...).Has(arg); has != grouped {
+					t.Errorf("expected pod %s to have argument %s: %t, args: %v", name, arg, grouped, pod.Spec.Containers[0].Args)
+				}
+			}
+			secrets := &v1.SecretList{}
+			if err := crclient.List(context.TODO(), secrets, ctrlruntimeclient.InNamespace(jobSpec.Namespace())); err != nil {
+				t.Fatal(err)
+			}
+			if l := secrets.Items; len(l) != 1 || l[0].Name != "test" {
+				t.Errorf("expected only the shared directory of the test to remain, got %v", l)
+			}
+		})
+	}
+}
+
 func fakePodNameIndexer(object ctrlruntimeclient.Object) []string {
 	p, ok := object.(*v1.Pod)
 	if !ok {
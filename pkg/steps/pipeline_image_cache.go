@@ -28,6 +28,7 @@ type pipelineImageCacheStep struct {
 	podClient  kubernetes.PodClient
 	jobSpec    *api.JobSpec
 	pullSecret *coreapi.Secret
+	cache      *BuildCache
 }
 
 func (s *pipelineImageCacheStep) Inputs() (api.InputDefinition, error) {
@@ -46,7 +47,7 @@ func (s *pipelineImageCacheStep) run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	return handleBuilds(ctx, s.client, s.podClient, *buildFromSource(
+	build := buildFromSource(
 		s.jobSpec, s.config.From, s.config.To,
 		buildapi.BuildSource{
 			Type:       buildapi.BuildSourceDockerfile,
@@ -58,7 +59,10 @@ func (s *pipelineImageCacheStep) run(ctx context.Context) error {
 		s.pullSecret,
 		nil,
 		s.config.Ref,
-	))
+	)
+	return s.cache.Build(ctx, s.client, build, fromDigest, func() error {
+		return handleBuilds(ctx, s.client, s.podClient, *build)
+	})
 }
 
 func (s *pipelineImageCacheStep) Requires() []api.StepLink {
@@ -95,6 +99,7 @@ func PipelineImageCacheStep(
 	podClient kubernetes.PodClient,
 	jobSpec *api.JobSpec,
 	pullSecret *coreapi.Secret,
+	cache *BuildCache,
 ) api.Step {
 	return &pipelineImageCacheStep{
 		config:     config,
@@ -103,5 +108,6 @@ func PipelineImageCacheStep(
 		podClient:  podClient,
 		jobSpec:    jobSpec,
 		pullSecret: pullSecret,
+		cache:      cache,
 	}
 }
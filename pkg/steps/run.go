@@ -6,7 +6,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/results"
 )
@@ -15,11 +20,44 @@ type message struct {
 	node            *api.StepNode
 	duration        time.Duration
 	err             error
+	skipped         bool
 	additionalTests []*junit.TestCase
 	stepDetails     api.CIOperatorStepDetails
 }
 
-func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIOperatorStepDetails, []error) {
+// RunOption customizes the execution of a step graph.
+type RunOption func(*runOptions)
+
+type runOptions struct {
+	checkpoints CheckpointStore
+	completed   sets.Set[string]
+}
+
+// WithCheckpoints records every step that completes successfully in the
+// store. Steps in completed were already executed with the same inputs and
+// are skipped, while their children are scheduled as if they had just run.
+func WithCheckpoints(store CheckpointStore, completed sets.Set[string]) RunOption {
+	return func(o *runOptions) {
+		o.checkpoints = store
+		o.completed = completed
+	}
+}
+
+func Run(ctx context.Context, graph api.StepGraph, opts ...RunOption) (*junit.TestSuites, []api.CIOperatorStepDetails, []error) {
+	o := runOptions{completed: sets.New[string]()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	recorder := events.FromContext(ctx)
+	launch := func(node *api.StepNode, out chan<- message) {
+		if o.completed.Has(node.Step.Name()) {
+			go skipStep(ctx, node, out)
+		} else {
+			// the dependencies of the step are satisfied, it starts as soon as it is scheduled
+			recorder.Emit(events.Event{Type: events.StepQueued, Step: node.Step.Name()})
+			go runStep(ctx, node, out)
+		}
+	}
 	var seen []api.StepLink
 	executionResults := make(chan message)
 	done := make(chan bool)
@@ -34,7 +72,7 @@ func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIO
 
 	start := time.Now()
 	for _, root := range graph {
-		go runStep(ctx, root, executionResults)
+		launch(root, executionResults)
 	}
 
 	suites := &junit.TestSuites{
@@ -56,11 +94,17 @@ func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIO
 		case out := <-executionResults:
 			testCase := &junit.TestCase{Name: out.node.Step.Description(), Duration: out.duration.Seconds()}
 			stepDetails = append(stepDetails, out.stepDetails)
+			if out.skipped {
+				testCase.SkipMessage = &junit.SkipMessage{Message: "Step completed in a previous execution."}
+			}
 			if out.err != nil {
 				testCase.FailureOutput = &junit.FailureOutput{Output: out.err.Error()}
 				executionErrors = append(executionErrors, results.ForReason("step_failed").WithError(out.err).Errorf("step %s failed: %v", out.node.Step.Name(), out.err))
 			} else {
 				seen = append(seen, out.node.Step.Creates()...)
+				if o.checkpoints != nil && !out.skipped {
+					recordCheckpoint(ctx, o.checkpoints, out)
+				}
 				if !interrupted {
 					for _, child := range out.node.Children {
 						// we can trigger a child if all of it's pre-requisites
@@ -70,7 +114,7 @@ func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIO
 						// when the last of its parents finishes.
 						if api.HasAllLinks(child.Step.Requires(), seen) {
 							wg.Add(1)
-							go runStep(ctx, child, executionResults)
+							launch(child, executionResults)
 						}
 					}
 				}
@@ -116,8 +160,52 @@ type SubStepReporter interface {
 	SubSteps() []api.CIOperatorStepDetailInfo
 }
 
+// LeaseReporter allows steps to report the leases they acquired.
+type LeaseReporter interface {
+	Leases() []api.CIOperatorStepLease
+}
+
+func recordCheckpoint(ctx context.Context, store CheckpointStore, out message) {
+	links, ok := checkpointLinksFor(out.node.Step)
+	if !ok {
+		return
+	}
+	step := CompletedStep{Creates: links}
+	if out.stepDetails.FinishedAt != nil {
+		step.FinishedAt = *out.stepDetails.FinishedAt
+	}
+	if err := store.Record(ctx, out.node.Step.Name(), step); err != nil {
+		logrus.WithError(err).Warnf("Could not record checkpoint for step %s.", out.node.Step.Name())
+	}
+}
+
+// skipStep reports a step that does not need to run as it was
+// completed by a previous execution.
+func skipStep(ctx context.Context, node *api.StepNode, out chan<- message) {
+	now := time.Now()
+	var duration time.Duration
+	failed := false
+	events.FromContext(ctx).Emit(events.Event{Type: events.StepSkipped, Step: node.Step.Name()})
+	out <- message{
+		node:    node,
+		skipped: true,
+		stepDetails: api.CIOperatorStepDetails{
+			CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{
+				StepName:    node.Step.Name(),
+				Description: node.Step.Description(),
+				StartedAt:   &now,
+				FinishedAt:  &now,
+				Duration:    &duration,
+				Failed:      &failed,
+			},
+		},
+	}
+}
+
 func runStep(ctx context.Context, node *api.StepNode, out chan<- message) {
 	start := time.Now()
+	recorder := events.FromContext(ctx)
+	recorder.Emit(events.Event{Type: events.StepStarted, Step: node.Step.Name()})
 	err := node.Step.Run(ctx)
 	var additionalTests []*junit.TestCase
 	if reporter, ok := node.Step.(SubtestReporter); ok {
@@ -126,11 +214,16 @@ func runStep(ctx context.Context, node *api.StepNode, out chan<- message) {
 	duration := time.Since(start)
 	failed := err != nil
 	finishedAt := start.Add(duration)
+	recorder.Emit(events.Event{Type: events.StepFinished, Step: node.Step.Name(), DurationSeconds: duration.Seconds(), Error: events.ErrorString(err)})
 
 	var subSteps []api.CIOperatorStepDetailInfo
 	if x, ok := node.Step.(SubStepReporter); ok {
 		subSteps = x.SubSteps()
 	}
+	var leases []api.CIOperatorStepLease
+	if x, ok := node.Step.(LeaseReporter); ok {
+		leases = x.Leases()
+	}
 
 	out <- message{
 		node:            node,
@@ -146,6 +239,7 @@ func runStep(ctx context.Context, node *api.StepNode, out chan<- message) {
 				Duration:    &duration,
 				Manifests:   node.Step.Objects(),
 				Failed:      &failed,
+				Leases:      leases,
 			},
 			Substeps: subSteps,
 		},
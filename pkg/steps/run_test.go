@@ -2,6 +2,7 @@ package steps
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -14,6 +15,7 @@ import (
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/results"
 )
 
@@ -337,3 +339,49 @@ func TestStepsRun(t *testing.T) {
 		})
 	}
 }
+
+type recordingSink struct {
+	lock  sync.Mutex
+	lines []string
+}
+
+func (s *recordingSink) Write(line []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.lines = append(s.lines, string(line))
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func TestStepsRunEmitsEvents(t *testing.T) {
+	root := &fakeStep{name: "root", creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceRoot)}}
+	src := &fakeStep{
+		name:     "src",
+		runErr:   errors.New("oops"),
+		requires: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceRoot)},
+	}
+	sink := &recordingSink{}
+	ctx := events.WithRecorder(context.Background(), events.NewRecorder(nil, events.Execution{}, sink))
+	Run(ctx, api.BuildGraph([]api.Step{root, src}))
+
+	var actual []string
+	for _, line := range sink.lines {
+		event := events.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("could not parse event: %v", err)
+		}
+		actual = append(actual, fmt.Sprintf("%s %s %s", event.Type, event.Step, event.Error))
+	}
+	expected := []string{
+		"step_queued root ",
+		"step_started root ",
+		"step_finished root ",
+		"step_queued src ",
+		"step_started src ",
+		"step_finished src oops",
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected events: %s", diff)
+	}
+}
@@ -33,6 +33,7 @@ import (
 	apiutils "github.com/openshift/ci-tools/pkg/api/utils"
 	"github.com/openshift/ci-tools/pkg/kubernetes"
 	"github.com/openshift/ci-tools/pkg/manifestpusher"
+	"github.com/openshift/ci-tools/pkg/resourceusage"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
@@ -159,6 +160,7 @@ type sourceStep struct {
 	jobSpec         *api.JobSpec
 	cloneAuthConfig *CloneAuthConfig
 	pullSecret      *corev1.Secret
+	cache           *BuildCache
 }
 
 func (s *sourceStep) Inputs() (api.InputDefinition, error) {
@@ -181,7 +183,10 @@ func (s *sourceStep) run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	return handleBuilds(ctx, s.client, s.podClient, *createBuild(s.config, s.jobSpec, clonerefsRef, s.resources, s.cloneAuthConfig, s.pullSecret, fromDigest))
+	build := createBuild(s.config, s.jobSpec, clonerefsRef, s.resources, s.cloneAuthConfig, s.pullSecret, fromDigest)
+	return s.cache.Build(ctx, s.client, build, fromDigest, func() error {
+		return handleBuilds(ctx, s.client, s.podClient, *build)
+	})
 }
 
 func createBuild(config api.SourceStepConfiguration, jobSpec *api.JobSpec, clonerefsRef corev1.ObjectReference, resources api.ResourceConfiguration, cloneAuthConfig *CloneAuthConfig, pullSecret *corev1.Secret, fromDigest string) *buildapi.Build {
@@ -465,7 +470,11 @@ func handleBuilds(ctx context.Context, buildClient BuildClient, podClient kubern
 	}
 
 	if len(errs) == 0 {
-		manifestPusher := manifestpusher.NewManifestPusher(logrus.WithField("for-build", build.Name), buildClient.LocalRegistryDNS(), buildClient.ManifestToolDockerCfg())
+		logger := logrus.WithField("for-build", build.Name)
+		manifestPusher := manifestpusher.NewManifestPusher(logger, buildClient.LocalRegistryDNS(), buildClient.ManifestToolDockerCfg())
+		if provider, ok := buildClient.(ManifestPusherProvider); ok {
+			manifestPusher = provider.ManifestPusher(logger)
+		}
 		if err := manifestPusher.PushImageWithManifest(builds, fmt.Sprintf("%s/%s", build.Spec.Output.To.Namespace, build.Spec.Output.To.Name)); err != nil {
 			errs = append(errs, err)
 		}
@@ -627,6 +636,28 @@ func waitForBuild(
 	// It is always valid in the `pendingCheck` thread since it is only started
 	// after the first version is seen.
 	var ret atomic.Pointer[buildapi.Build]
+	// the build pod is only known once the build has been scheduled
+	usage := resourceusage.FromContext(ctx)
+	sampler := usage.StartSampling(podClient, namespace, func() string {
+		if build := ret.Load(); build != nil {
+			return build.Annotations[buildapi.BuildPodNameAnnotation]
+		}
+		return ""
+	})
+	defer func() {
+		observed, samples := sampler.Stop()
+		if build := ret.Load(); build != nil {
+			usage.Add(resourceusage.Record{
+				Kind:            resourceusage.Build,
+				Namespace:       namespace,
+				Name:            name,
+				Requested:       resourceusage.ResourcesFor(build.Spec.Resources.Requests),
+				Observed:        observed,
+				Samples:         samples,
+				WallTimeSeconds: buildDuration(build).Seconds(),
+			})
+		}
+	}()
 	var eg *errgroup.Group
 	eg, ctx = errgroup.WithContext(ctx)
 	pendingCtx, cancel := context.WithCancel(ctx)
@@ -664,7 +695,7 @@ func waitForBuild(
 				return true, nil
 			case buildapi.BuildPhaseFailed, buildapi.BuildPhaseCancelled, buildapi.BuildPhaseError:
 				logrus.Infof("Build %s failed, printing logs:", build.Name)
-				printBuildLogs(buildClient, build.Namespace, build.Name)
+				printBuildLogs(ctx, buildClient, build.Namespace, build.Name)
 				return true, util.AppendLogToError(fmt.Errorf("the build %s failed after %s with reason %s: %s", build.Name, buildDuration(build).Truncate(time.Second), build.Status.Reason, build.Status.Message), build.Status.LogSnippet)
 			}
 			return false, nil
@@ -702,14 +733,16 @@ func buildDuration(build *buildapi.Build) time.Duration {
 	return duration
 }
 
-func printBuildLogs(buildClient BuildClient, namespace, name string) {
+func printBuildLogs(ctx context.Context, buildClient BuildClient, namespace, name string) {
 	if s, err := buildClient.Logs(namespace, name, &buildapi.BuildLogOptions{
 		NoWait: true,
 	}); err == nil {
 		defer s.Close()
-		if _, err := io.Copy(os.Stdout, s); err != nil {
+		logs := &results.FailureLogTail{}
+		if _, err := io.Copy(io.MultiWriter(os.Stdout, logs), s); err != nil {
 			logrus.WithError(err).Warn("Unable to copy log output from failed build.")
 		}
+		results.FailureLogsFromContext(ctx).Record(name, logs.String())
 	} else {
 		logrus.WithError(err).Warn("Unable to retrieve logs from failed build")
 	}
@@ -772,6 +805,7 @@ func SourceStep(
 	jobSpec *api.JobSpec,
 	cloneAuthConfig *CloneAuthConfig,
 	pullSecret *corev1.Secret,
+	cache *BuildCache,
 ) api.Step {
 	return &sourceStep{
 		config:          config,
@@ -781,6 +815,7 @@ func SourceStep(
 		jobSpec:         jobSpec,
 		cloneAuthConfig: cloneAuthConfig,
 		pullSecret:      pullSecret,
+		cache:           cache,
 	}
 }
 
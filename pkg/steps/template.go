@@ -23,6 +23,7 @@ import (
 	templateapi "github.com/openshift/api/template/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/kubernetes"
 	"github.com/openshift/ci-tools/pkg/results"
@@ -146,7 +147,7 @@ func (s *templateExecutionStep) run(ctx context.Context) error {
 	// now that the pods have been resolved by the template, add them to the artifact map
 	var notifier util.ContainerNotifier = util.NopNotifier
 	if artifactDir, artifactsRequested := api.Artifacts(); artifactsRequested {
-		artifacts := NewArtifactWorker(s.podClient, filepath.Join(artifactDir, s.template.Name), s.jobSpec.Namespace())
+		artifacts := NewArtifactWorker(s.podClient, filepath.Join(artifactDir, s.template.Name), s.jobSpec.Namespace(), events.FromContext(ctx))
 		for _, ref := range instance.Status.Objects {
 			switch {
 			case ref.Ref.Kind == "Pod" && ref.Ref.APIVersion == "v1":
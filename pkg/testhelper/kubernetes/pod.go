@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 	"time"
 
 	coreapi "k8s.io/api/core/v1"
@@ -25,6 +26,7 @@ type FakePodExecutor struct {
 	loggingclient.LoggingClient
 	Failures    sets.Set[string]
 	CreatedPods []*coreapi.Pod
+	lock        sync.Mutex
 }
 
 func (f *FakePodExecutor) Create(ctx context.Context, o ctrlruntimeclient.Object, opts ...ctrlruntimeclient.CreateOption) error {
@@ -32,7 +34,9 @@ func (f *FakePodExecutor) Create(ctx context.Context, o ctrlruntimeclient.Object
 		if pod.Namespace == "" {
 			return errors.New("pod had no namespace set")
 		}
+		f.lock.Lock()
 		f.CreatedPods = append(f.CreatedPods, pod.DeepCopy())
+		f.lock.Unlock()
 		pod.Status.Phase = coreapi.PodPending
 	}
 	return f.LoggingClient.Create(ctx, o, opts...)
@@ -96,6 +100,8 @@ type FakePodClient struct {
 	*FakePodExecutor
 	Namespace, Name string
 	PendingTimeout  time.Duration
+	// Metrics are returned by GetPodMetrics, keyed by pod name
+	Metrics map[string]*kubernetes.PodMetrics
 }
 
 func (f FakePodClient) GetPendingTimeout() time.Duration {
@@ -116,6 +122,13 @@ func (*FakePodClient) GetLogs(string, string, *coreapi.PodLogOptions) *rest.Requ
 	return rest.NewRequestWithClient(nil, "", rest.ClientContentConfig{}, nil)
 }
 
+func (f *FakePodClient) GetPodMetrics(_ context.Context, namespace, name string) (*kubernetes.PodMetrics, error) {
+	if m, ok := f.Metrics[name]; ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("no metrics for pod %s/%s", namespace, name)
+}
+
 func (f *FakePodClient) WithNewLoggingClient() kubernetes.PodClient {
 	return f
 }
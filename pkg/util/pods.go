@@ -24,7 +24,9 @@ import (
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/resourceusage"
 	"github.com/openshift/ci-tools/pkg/results"
 )
 
@@ -117,16 +119,42 @@ func WaitForPodDeletion(ctx context.Context, podClient ctrlruntimeclient.Client,
 	})
 }
 
+// stepLabel mirrors steps.LabelMetadataStep, which cannot be imported here
+const stepLabel = "ci.openshift.io/metadata.step"
+
+// WaitForPodCompletion waits for a pod to complete and records the resources
+// it requested and used.
 func WaitForPodCompletion(ctx context.Context, podClient kubernetes.PodClient, namespace, name string, notifier ContainerNotifier, flags WaitForPodFlag) (*corev1.Pod, error) {
+	usage := resourceusage.FromContext(ctx)
+	sampler := usage.StartSampling(podClient, namespace, func() string { return name })
+	pod, err := waitForPodCompletion(ctx, podClient, namespace, name, notifier, flags)
+	observed, samples := sampler.Stop()
+	if pod != nil {
+		usage.Add(resourceusage.Record{
+			Kind:            resourceusage.Pod,
+			Namespace:       namespace,
+			Name:            name,
+			Step:            pod.Labels[stepLabel],
+			Requested:       resourceusage.PodRequests(&pod.Spec),
+			Observed:        observed,
+			Samples:         samples,
+			WallTimeSeconds: podDuration(pod).Seconds(),
+		})
+	}
+	return pod, err
+}
+
+func waitForPodCompletion(ctx context.Context, podClient kubernetes.PodClient, namespace, name string, notifier ContainerNotifier, flags WaitForPodFlag) (*corev1.Pod, error) {
 	if notifier == nil {
 		notifier = NopNotifier
 	}
 	ctxDone := ctx.Done()
 	notifierDone := notifier.Done(name)
 	completed := make(map[string]time.Time)
+	var phase corev1.PodPhase
 	var pod *corev1.Pod
 	for {
-		newPod, err := waitForPodCompletionOrTimeout(ctx, podClient, namespace, name, completed, notifier, flags)
+		newPod, err := waitForPodCompletionOrTimeout(ctx, podClient, namespace, name, completed, &phase, notifier, flags)
 		if newPod != nil {
 			pod = newPod
 		}
@@ -153,7 +181,7 @@ func WaitForPodCompletion(ctx context.Context, podClient kubernetes.PodClient, n
 	return pod, nil
 }
 
-func waitForPodCompletionOrTimeout(ctx context.Context, podClient kubernetes.PodClient, namespace, name string, completed map[string]time.Time, notifier ContainerNotifier, flags WaitForPodFlag) (*corev1.Pod, error) {
+func waitForPodCompletionOrTimeout(ctx context.Context, podClient kubernetes.PodClient, namespace, name string, completed map[string]time.Time, phase *corev1.PodPhase, notifier ContainerNotifier, flags WaitForPodFlag) (*corev1.Pod, error) {
 	var ret atomic.Pointer[corev1.Pod]
 	var eg *errgroup.Group
 	eg, ctx = errgroup.WithContext(ctx)
@@ -177,6 +205,7 @@ func waitForPodCompletionOrTimeout(ctx context.Context, podClient kubernetes.Pod
 			if ret.Swap(pod) == nil {
 				eg.Go(pendingCheck)
 			}
+			emitPodPhase(events.FromContext(ctx), pod, phase)
 			return processPodEvent(ctx, podClient, completed, notifier, flags, pod)
 		}, 0); err != nil {
 			if errors.Is(err, wait.ErrWaitTimeout) {
@@ -194,6 +223,24 @@ func waitForPodCompletionOrTimeout(ctx context.Context, podClient kubernetes.Pod
 	return ret.Load(), err
 }
 
+var podPhaseEvents = map[corev1.PodPhase]events.Type{
+	corev1.PodPending:   events.PodPending,
+	corev1.PodRunning:   events.PodRunning,
+	corev1.PodSucceeded: events.PodSucceeded,
+	corev1.PodFailed:    events.PodFailed,
+}
+
+// emitPodPhase records an event when the pod transitions to a new phase
+func emitPodPhase(recorder *events.Recorder, pod *corev1.Pod, last *corev1.PodPhase) {
+	if pod.Status.Phase == *last {
+		return
+	}
+	*last = pod.Status.Phase
+	if eventType, ok := podPhaseEvents[pod.Status.Phase]; ok {
+		recorder.Emit(events.Event{Type: eventType, Namespace: pod.Namespace, Pod: pod.Name})
+	}
+}
+
 func processPodEvent(
 	ctx context.Context,
 	podClient kubernetes.PodClient,
@@ -205,7 +252,7 @@ func processPodEvent(
 	if pod.Spec.RestartPolicy == corev1.RestartPolicyAlways {
 		return true, nil
 	}
-	podLogNewFailedContainers(podClient, pod, completed, notifier)
+	podLogNewFailedContainers(ctx, podClient, pod, completed, notifier)
 	podLogDeletion(ctx, podClient, flags, *pod)
 	if podJobIsOK(pod) {
 		logrus.Debugf("Pod %s succeeded after %s", pod.Name, podDuration(pod).Truncate(time.Second))
@@ -474,7 +521,7 @@ func containerNamesInState(pod corev1.Pod, p func(corev1.ContainerStatus) bool)
 	return names
 }
 
-func podLogNewFailedContainers(podClient kubernetes.PodClient, pod *corev1.Pod, completed map[string]time.Time, notifier ContainerNotifier) {
+func podLogNewFailedContainers(ctx context.Context, podClient kubernetes.PodClient, pod *corev1.Pod, completed map[string]time.Time, notifier ContainerNotifier) {
 	var statuses []corev1.ContainerStatus
 	statuses = append(statuses, pod.Status.InitContainerStatuses...)
 	statuses = append(statuses, pod.Status.ContainerStatuses...)
@@ -489,6 +536,8 @@ func podLogNewFailedContainers(podClient kubernetes.PodClient, pod *corev1.Pod,
 		}
 		completed[status.Name] = s.FinishedAt.Time
 		notifier.Notify(pod, status.Name)
+		exitCode := s.ExitCode
+		events.FromContext(ctx).Emit(events.Event{Type: events.ContainerFinished, Namespace: pod.Namespace, Pod: pod.Name, Container: status.Name, ExitCode: &exitCode})
 
 		if s.ExitCode == 0 {
 			logrus.Debugf("Container %s in pod %s completed successfully", status.Name, pod.Name)
@@ -507,6 +556,7 @@ func podLogNewFailedContainers(podClient kubernetes.PodClient, pod *corev1.Pod,
 			}
 			logrus.Infof("Logs for container %s in pod %s:", status.Name, pod.Name)
 			logrus.Info(logs.String())
+			results.FailureLogsFromContext(ctx).Record(pod.Name, logs.String())
 		} else {
 			logrus.WithError(err).Warnf("error: Unable to retrieve logs from failed pod container %s.", status.Name)
 		}
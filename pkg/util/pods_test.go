@@ -1,13 +1,17 @@
 package util
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/testhelper"
 )
 
@@ -235,3 +239,32 @@ func TestCheckPending(t *testing.T) {
 		})
 	}
 }
+
+type eventTypeSink struct {
+	types []events.Type
+}
+
+func (s *eventTypeSink) Write(line []byte) error {
+	event := events.Event{}
+	if err := json.Unmarshal(line, &event); err != nil {
+		return err
+	}
+	s.types = append(s.types, event.Type)
+	return nil
+}
+
+func (s *eventTypeSink) Close() error { return nil }
+
+func TestEmitPodPhase(t *testing.T) {
+	sink := &eventTypeSink{}
+	recorder := events.NewRecorder(nil, events.Execution{}, sink)
+
+	var last corev1.PodPhase
+	for _, phase := range []corev1.PodPhase{corev1.PodPending, corev1.PodPending, corev1.PodRunning, corev1.PodRunning, corev1.PodFailed} {
+		emitPodPhase(recorder, &corev1.Pod{Status: corev1.PodStatus{Phase: phase}}, &last)
+	}
+	expected := []events.Type{events.PodPending, events.PodRunning, events.PodFailed}
+	if diff := cmp.Diff(expected, sink.types); diff != "" {
+		t.Errorf("unexpected events: %s", diff)
+	}
+}
@@ -598,6 +598,7 @@ func Observer(observer api.Observer) []error {
 		errs = append(errs, fmt.Errorf("%s.commands cannot be empty", fieldRoot))
 	}
 	errs = append(errs, validateResourceRequirements(fieldRoot+".resources", observer.Resources)...)
+	errs = append(errs, validateParameterTypes(newContext(fieldPath(fmt.Sprintf("observer %q", observer.Name)), nil, nil, nil), observer.Environment)...)
 	// we're validating unresolved configuration outside of a full test config, so
 	// we cannot know the releases that may or may not be contained in a config using
 	// this observer in the future. This technically disallows users from using `from:`
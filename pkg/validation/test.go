@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -42,6 +43,12 @@ const (
 	// more things from the name
 	maxClaimTestNameLength = 42
 	maxTestNameLength      = 61
+
+	// maxJobTimeout is the longest a job may run
+	maxJobTimeout = 8 * time.Hour
+	// maxRetryAttempts limits how often a step runs, since it holds its
+	// leases and cluster for all attempts
+	maxRetryAttempts = 5
 )
 
 func (v *Validator) commandHasTrap(cmd string) bool {
@@ -72,6 +79,9 @@ type context struct {
 	inputImagesSeen testInputImages
 	// releases is used to validate references to release images .
 	releases sets.Set[string]
+	// timeout is how long the test may run, used to validate that retries
+	// fit into it. The longest job timeout is assumed when unset.
+	timeout time.Duration
 }
 
 // newContext creates a top-level context.
@@ -112,7 +122,15 @@ var trapPattern = regexp.MustCompile(`(^|\W)\s*trap\s*['"]?\w*['"]?\s*\w*`)
 // component, the image references exist in the test configuration, etc.) are
 // not performed.
 func (v *Validator) IsValidReference(step api.LiteralTestStep) []error {
-	return v.validateLiteralTestStep(&context{field: fieldPath(step.As)}, testStageUnknown, step, nil)
+	context := &context{field: fieldPath(step.As)}
+	return append(v.validateLiteralTestStep(context, testStageUnknown, step, nil), validateParallelGroupUnset(context, step)...)
+}
+
+// IsValidChain validates the contents of a registry chain. Its steps are
+// validated where they are defined.
+func (v *Validator) IsValidChain(chain api.RegistryChain) []error {
+	context := &context{field: fieldPath(chain.As)}
+	return validateRetry(context.addField("retry"), chain.Retry)
 }
 
 func (v *Validator) validateTestStepConfiguration(
@@ -210,7 +228,6 @@ func (v *Validator) validateTestStepConfiguration(
 			}
 		}
 
-		maxJobTimeout := time.Hour * 8
 		if test.Timeout != nil && test.Timeout.Duration > maxJobTimeout {
 			validationErrors = append(validationErrors, fmt.Errorf("%s: job timeout is limited to %s", fieldRootN, maxJobTimeout))
 		}
@@ -568,6 +585,9 @@ func (v *Validator) validateTestConfigurationType(
 			validationErrors = append(validationErrors, v.validateClusterProfile(fieldRoot, testConfig.ClusterProfile, metadata)...)
 		}
 		context := newContext(fieldPath(fieldRoot), testConfig.Environment, releases, inputImagesSeen)
+		if test.Timeout != nil {
+			context.timeout = test.Timeout.Duration
+		}
 		validationErrors = append(validationErrors, validateLeases(context.addField("leases"), testConfig.Leases)...)
 		validationErrors = append(validationErrors, v.validateTestSteps(context.addField("pre"), testStagePre, testConfig.Pre, claimRelease)...)
 		validationErrors = append(validationErrors, v.validateTestSteps(context.addField("test"), testStageTest, testConfig.Test, claimRelease)...)
@@ -576,6 +596,9 @@ func (v *Validator) validateTestConfigurationType(
 	if testConfig := test.MultiStageTestConfigurationLiteral; testConfig != nil {
 		typeCount++
 		context := newContext(fieldPath(fieldRoot).addField("steps"), testConfig.Environment, releases, inputImagesSeen)
+		if test.Timeout != nil {
+			context.timeout = test.Timeout.Duration
+		}
 		if testConfig.ClusterProfile != "" {
 			clusterCount++
 			validationErrors = append(validationErrors, v.validateClusterProfile(fieldRoot, testConfig.ClusterProfile, metadata)...)
@@ -590,6 +613,9 @@ func (v *Validator) validateTestConfigurationType(
 		for i, s := range testConfig.Post {
 			validationErrors = append(validationErrors, v.validateLiteralTestStep(context.addField("post").addIndex(i), testStagePost, s, claimRelease)...)
 		}
+		validationErrors = append(validationErrors, validateParallelGroups(context.addField("pre"), testConfig.Pre)...)
+		validationErrors = append(validationErrors, validateParallelGroups(context.addField("test"), testConfig.Test)...)
+		validationErrors = append(validationErrors, validateParallelGroups(context.addField("post"), testConfig.Post)...)
 	}
 	if typeCount == 0 {
 		validationErrors = append(validationErrors, fmt.Errorf("%s has no type, you may want to specify 'container' for a container based test", fieldRoot))
@@ -638,6 +664,10 @@ func validateTestStep(context *context, step api.TestStep) (ret []error) {
 			context.namesSeen.Insert(*step.Reference)
 		}
 	}
+	ret = append(ret, validateChangeConditions(context, step.RunIfChanged, step.SkipIfOnlyChanged)...)
+	if step.LiteralTestStep != nil {
+		ret = append(ret, validateParallelGroupUnset(context, *step.LiteralTestStep)...)
+	}
 	if step.Chain != nil {
 		if len(*step.Chain) == 0 {
 			ret = append(ret, context.addField("chain").errorf("length cannot be 0"))
@@ -677,13 +707,14 @@ func (v *Validator) validateLiteralTestStep(context *context, stage testStage, s
 
 	ret = append(ret, validateResourceRequirements(string(context.field)+".resources", step.Resources)...)
 	ret = append(ret, validateCredentials(string(context.field), step.Credentials)...)
+	ret = append(ret, validateParameterTypes(context, step.Environment)...)
 	if context.env != nil {
-		if err := validateParameters(context, step.Environment); err != nil {
-			ret = append(ret, err)
-		}
+		ret = append(ret, validateParameters(context, step.Environment)...)
 	}
 	ret = append(ret, validateDependencies(string(context.field), step.Dependencies)...)
 	ret = append(ret, validateLeases(context.addField("leases"), step.Leases)...)
+	ret = append(ret, validateRetry(context.addField("retry"), step.Retry)...)
+	ret = append(ret, validateChangeConditions(context, step.RunIfChanged, step.SkipIfOnlyChanged)...)
 	switch stage {
 	case testStagePre, testStageTest:
 		if step.OptionalOnSuccess != nil {
@@ -810,18 +841,94 @@ func ValidateSecretInStep(ns, name string) error {
 	return nil
 }
 
-func validateParameters(context *context, params []api.StepParameter) error {
+func validateParameters(context *context, params []api.StepParameter) (ret []error) {
 	var missing []string
 	for _, param := range params {
-		if param.Default != nil {
+		if value, ok := context.env[param.Name]; ok {
+			if err := StepParameterValue(param, value); err != nil {
+				ret = append(ret, context.errorf("parameter %s: %v", param.Name, err))
+			}
 			continue
 		}
-		if _, ok := context.env[param.Name]; !ok {
+		if param.Default == nil {
 			missing = append(missing, param.Name)
 		}
 	}
 	if missing != nil {
-		return context.errorf("unresolved parameter(s): %s", missing)
+		ret = append(ret, context.errorf("unresolved parameter(s): %s", missing))
+	}
+	return ret
+}
+
+// validateParameterTypes validates the types parameters declare and that their
+// defaults are valid values
+func validateParameterTypes(context *context, params []api.StepParameter) (ret []error) {
+	for i, param := range params {
+		ctx := context.addField("env").addIndex(i)
+		switch param.Type {
+		case "", api.StepParameterTypeBool, api.StepParameterTypeInt, api.StepParameterTypeDuration:
+		case api.StepParameterTypeEnum:
+			if len(param.AllowedValues) == 0 {
+				ret = append(ret, ctx.errorf("`allowed_values` is required for `enum` parameters"))
+			}
+		case api.StepParameterTypeRegex:
+			if param.Pattern == "" {
+				ret = append(ret, ctx.errorf("`pattern` is required for `regex` parameters"))
+			} else if _, err := regexp.Compile(param.Pattern); err != nil {
+				ret = append(ret, ctx.errorf("invalid `pattern`: %v", err))
+				continue
+			}
+		default:
+			ret = append(ret, ctx.errorf("unknown type %q, must be one of bool, int, enum, regex or duration", param.Type))
+			continue
+		}
+		if len(param.AllowedValues) != 0 && param.Type != api.StepParameterTypeEnum {
+			ret = append(ret, ctx.errorf("`allowed_values` can only be set for `enum` parameters"))
+		}
+		if param.Pattern != "" && param.Type != api.StepParameterTypeRegex {
+			ret = append(ret, ctx.errorf("`pattern` can only be set for `regex` parameters"))
+		}
+		if param.Default != nil {
+			if err := StepParameterValue(param, *param.Default); err != nil {
+				ret = append(ret, ctx.errorf("invalid `default`: %v", err))
+			}
+		}
+	}
+	return ret
+}
+
+// StepParameterValue validates a value of a parameter against the type it
+// declares. Parameters without a type accept any value and an empty value,
+// which leaves the parameter unset, is valid for every type.
+func StepParameterValue(param api.StepParameter, value string) error {
+	if value == "" {
+		return nil
+	}
+	switch param.Type {
+	case api.StepParameterTypeBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%q is not a bool, must be `true` or `false`", value)
+		}
+	case api.StepParameterTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%q is not an int", value)
+		}
+	case api.StepParameterTypeEnum:
+		if !util.Contains(param.AllowedValues, value) {
+			return fmt.Errorf("%q is not one of the allowed values: %s", value, strings.Join(param.AllowedValues, ", "))
+		}
+	case api.StepParameterTypeRegex:
+		pattern, err := regexp.Compile("^(?:" + param.Pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", param.Pattern, err)
+		}
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("%q does not match the pattern %q", value, param.Pattern)
+		}
+	case api.StepParameterTypeDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("%q is not a duration: %w", value, err)
+		}
 	}
 	return nil
 }
@@ -857,6 +964,84 @@ func validateDNSConfig(fieldRoot string, dnsConfig []api.StepDNSConfig) (ret []e
 	return errs
 }
 
+// validateChangeConditions validates the regular expressions which make a
+// step depend on the changed files.
+func validateChangeConditions(context *context, runIfChanged, skipIfOnlyChanged string) (ret []error) {
+	if runIfChanged != "" && skipIfOnlyChanged != "" {
+		ret = append(ret, context.errorf("`run_if_changed` and `skip_if_only_changed` are mutually exclusive"))
+	}
+	if _, err := regexp.Compile(runIfChanged); err != nil {
+		ret = append(ret, context.addField("run_if_changed").errorf("invalid regular expression: %v", err))
+	}
+	if _, err := regexp.Compile(skipIfOnlyChanged); err != nil {
+		ret = append(ret, context.addField("skip_if_only_changed").errorf("invalid regular expression: %v", err))
+	}
+	return
+}
+
+// validateParallelGroups validates that the steps of each parallel group are
+// adjacent in a phase, since a group runs as a single unit.
+func validateParallelGroups(context *context, steps []api.LiteralTestStep) (ret []error) {
+	seen := sets.New[string]()
+	for i, step := range steps {
+		group := step.ParallelGroup
+		if group == "" || (i > 0 && steps[i-1].ParallelGroup == group) {
+			continue
+		}
+		if seen.Has(group) {
+			ret = append(ret, context.addIndex(i).addField("parallel_group").errorf("steps of parallel group %q must be adjacent", group))
+		}
+		seen.Insert(group)
+	}
+	return
+}
+
+// validateParallelGroupUnset validates that a step which has not been resolved
+// yet does not name its parallel group, which only the resolver sets.
+func validateParallelGroupUnset(context *context, step api.LiteralTestStep) []error {
+	if step.ParallelGroup == "" {
+		return nil
+	}
+	return []error{context.addField("parallel_group").errorf("cannot be set, use `parallel` to run steps concurrently")}
+}
+
+// validateRetry validates the retry policy of a step or a chain.
+func validateRetry(context *context, retry *api.RetryPolicy) (ret []error) {
+	if retry == nil {
+		return nil
+	}
+	if retry.MaxAttempts < 1 {
+		ret = append(ret, context.errorf("`max_attempts` must be at least 1"))
+	}
+	if retry.MaxAttempts > maxRetryAttempts {
+		ret = append(ret, context.errorf("`max_attempts` cannot be more than %d", maxRetryAttempts))
+	}
+	if retry.Backoff != nil && retry.Backoff.Duration < 0 {
+		ret = append(ret, context.errorf("`backoff` cannot be negative"))
+	}
+	if retry.Backoff != nil && retry.Backoff.Duration > 0 && retry.MaxAttempts > 1 && retry.MaxAttempts <= maxRetryAttempts {
+		timeout := context.timeout
+		if timeout == 0 {
+			timeout = maxJobTimeout
+		}
+		// the backoff doubles for every attempt after the second one
+		if wait := retry.Backoff.Duration * time.Duration(1<<(retry.MaxAttempts-1)-1); wait >= timeout {
+			ret = append(ret, context.errorf("waiting %s between %d attempts with a `backoff` of %s does not fit into the test timeout of %s", wait, retry.MaxAttempts, retry.Backoff.Duration, timeout))
+		}
+	}
+	for i, code := range retry.ExitCodes {
+		if code == 0 {
+			ret = append(ret, context.addField("exit_codes").addIndex(i).errorf("exit code 0 is not a failure"))
+		}
+	}
+	for i, reason := range retry.Reasons {
+		if reason == "" {
+			ret = append(ret, context.addField("reasons").addIndex(i).errorf("reason cannot be empty"))
+		}
+	}
+	return
+}
+
 func validateLeases(context *context, leases []api.StepLease) (ret []error) {
 	for i, l := range leases {
 		if l.ResourceType == "" {
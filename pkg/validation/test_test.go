@@ -758,6 +758,7 @@ func TestValidateTestSteps(t *testing.T) {
 		errs         []error
 		releases     sets.Set[string]
 		clusterClaim api.ClaimRelease
+		timeout      time.Duration
 	}{{
 		name: "valid step",
 		steps: []api.TestStep{{
@@ -1089,6 +1090,120 @@ func TestValidateTestSteps(t *testing.T) {
 		errs: []error{
 			errors.New("test best-effort contains best_effort without timeout"),
 		},
+	}, {
+		name: "step with retry policy",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "retried",
+				From:      "installer",
+				Commands:  "openshift-cluster install",
+				Resources: resources,
+				Retry: &api.RetryPolicy{
+					MaxAttempts: 3,
+					Backoff:     defaultDuration,
+					ExitCodes:   []int32{1, 137},
+					Reasons:     []string{"pod_pending"},
+				},
+			},
+		}},
+	}, {
+		name: "step with invalid change conditions",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:                "conditional",
+				From:              "installer",
+				Commands:          "openshift-cluster install",
+				Resources:         resources,
+				RunIfChanged:      "^pkg/",
+				SkipIfOnlyChanged: "(",
+			},
+		}, {
+			Reference:    &myReference,
+			RunIfChanged: "[",
+		}},
+		errs: []error{
+			errors.New("test[0]: `run_if_changed` and `skip_if_only_changed` are mutually exclusive"),
+			errors.New("test[0].skip_if_only_changed: invalid regular expression: error parsing regexp: missing closing ): `(`"),
+			errors.New("test[1].run_if_changed: invalid regular expression: error parsing regexp: missing closing ]: `[`"),
+		},
+	}, {
+		name: "step with invalid retry policy",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "retried",
+				From:      "installer",
+				Commands:  "openshift-cluster install",
+				Resources: resources,
+				Retry: &api.RetryPolicy{
+					Backoff:   &prowv1.Duration{Duration: -time.Second},
+					ExitCodes: []int32{0},
+					Reasons:   []string{""},
+				},
+			},
+		}},
+		errs: []error{
+			errors.New("test[0].retry: `max_attempts` must be at least 1"),
+			errors.New("test[0].retry: `backoff` cannot be negative"),
+			errors.New("test[0].retry.exit_codes[0]: exit code 0 is not a failure"),
+			errors.New("test[0].retry.reasons[0]: reason cannot be empty"),
+		},
+	}, {
+		name: "step retried too often",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "retried",
+				From:      "installer",
+				Commands:  "openshift-cluster install",
+				Resources: resources,
+				Retry:     &api.RetryPolicy{MaxAttempts: 1000},
+			},
+		}},
+		errs: []error{
+			errors.New("test[0].retry: `max_attempts` cannot be more than 5"),
+		},
+	}, {
+		name: "step retried with a backoff longer than the test timeout",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "retried",
+				From:      "installer",
+				Commands:  "openshift-cluster install",
+				Resources: resources,
+				Retry:     &api.RetryPolicy{MaxAttempts: 3, Backoff: &prowv1.Duration{Duration: 20 * time.Minute}},
+			},
+		}},
+		timeout: time.Hour,
+		errs: []error{
+			errors.New("test[0].retry: waiting 1h0m0s between 3 attempts with a `backoff` of 20m0s does not fit into the test timeout of 1h0m0s"),
+		},
+	}, {
+		name: "step retried with a backoff longer than the longest job timeout",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "retried",
+				From:      "installer",
+				Commands:  "openshift-cluster install",
+				Resources: resources,
+				Retry:     &api.RetryPolicy{MaxAttempts: 5, Backoff: &prowv1.Duration{Duration: time.Hour}},
+			},
+		}},
+		errs: []error{
+			errors.New("test[0].retry: waiting 15h0m0s between 5 attempts with a `backoff` of 1h0m0s does not fit into the test timeout of 8h0m0s"),
+		},
+	}, {
+		name: "step with a parallel group",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:            "grouped",
+				From:          "installer",
+				Commands:      "openshift-cluster install",
+				Resources:     resources,
+				ParallelGroup: "group",
+			},
+		}},
+		errs: []error{
+			errors.New("test[0].parallel_group: cannot be set, use `parallel` to run steps concurrently"),
+		},
 	}, {
 		name: "cluster claim release",
 		steps: []api.TestStep{{
@@ -1105,6 +1220,7 @@ func TestValidateTestSteps(t *testing.T) {
 			if tc.seen != nil {
 				context.namesSeen = tc.seen
 			}
+			context.timeout = tc.timeout
 			v := NewValidator(nil)
 			ret := v.validateTestSteps(context, testStageTest, tc.steps, &tc.clusterClaim)
 			if len(ret) > 0 && len(tc.errs) == 0 {
@@ -1117,6 +1233,44 @@ func TestValidateTestSteps(t *testing.T) {
 	}
 }
 
+func TestIsValidChain(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		chain api.RegistryChain
+		errs  []error
+	}{{
+		name:  "chain without retry policy",
+		chain: api.RegistryChain{As: "chain"},
+	}, {
+		name:  "chain with retry policy",
+		chain: api.RegistryChain{As: "chain", Retry: &api.RetryPolicy{MaxAttempts: 2, ExitCodes: []int32{3}}},
+	}, {
+		name: "chain with invalid retry policy",
+		chain: api.RegistryChain{
+			As: "chain",
+			Retry: &api.RetryPolicy{
+				Backoff:   &prowv1.Duration{Duration: -time.Second},
+				ExitCodes: []int32{0},
+				Reasons:   []string{""},
+			},
+		},
+		errs: []error{
+			errors.New("chain.retry: `max_attempts` must be at least 1"),
+			errors.New("chain.retry: `backoff` cannot be negative"),
+			errors.New("chain.retry.exit_codes[0]: exit code 0 is not a failure"),
+			errors.New("chain.retry.reasons[0]: reason cannot be empty"),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			v := NewValidator(nil)
+			ret := v.IsValidChain(tc.chain)
+			if !errListMessagesEqual(ret, tc.errs) {
+				t.Fatal(diff.ObjectReflectDiff(ret, tc.errs))
+			}
+		})
+	}
+}
+
 func TestValidatePostSteps(t *testing.T) {
 	resources := api.ResourceRequirements{
 		Requests: api.ResourceList{"cpu": "1"},
@@ -1177,6 +1331,19 @@ func TestValidateParameters(t *testing.T) {
 		params: []api.StepParameter{{Name: "TEST0"}, {Name: "TEST1"}},
 		env:    api.TestEnvironment{"TEST0": "test0"},
 		err:    []error{errors.New("test: unresolved parameter(s): [TEST1]")},
+	}, {
+		name:   "typed parameter, valid value provided",
+		params: []api.StepParameter{{Name: "FIPS_ENABLED", Type: api.StepParameterTypeBool}},
+		env:    api.TestEnvironment{"FIPS_ENABLED": "true"},
+	}, {
+		name:   "typed parameter, invalid value provided",
+		params: []api.StepParameter{{Name: "FIPS_ENABLED", Type: api.StepParameterTypeBool}},
+		env:    api.TestEnvironment{"FIPS_ENABLED": "ture"},
+		err:    []error{errors.New("test: parameter FIPS_ENABLED: \"ture\" is not a bool, must be `true` or `false`")},
+	}, {
+		name:   "typed parameter with invalid default",
+		params: []api.StepParameter{{Name: "SIZE", Type: api.StepParameterTypeEnum, AllowedValues: []string{"small", "large"}, Default: &defaultStr}},
+		err:    []error{errors.New("test.env[0]: invalid `default`: \"default\" is not one of the allowed values: small, large")},
 	}} {
 		t.Run(tc.name, func(t *testing.T) {
 			v := NewValidator(nil)
@@ -1197,6 +1364,80 @@ func TestValidateParameters(t *testing.T) {
 	}
 }
 
+func TestValidateParameterTypes(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		params   []api.StepParameter
+		expected []error
+	}{{
+		name: "valid types",
+		params: []api.StepParameter{
+			{Name: "UNTYPED"},
+			{Name: "BOOL", Type: api.StepParameterTypeBool},
+			{Name: "ENUM", Type: api.StepParameterTypeEnum, AllowedValues: []string{"a", "b"}},
+			{Name: "REGEX", Type: api.StepParameterTypeRegex, Pattern: "[a-z]+"},
+		},
+	}, {
+		name:     "unknown type",
+		params:   []api.StepParameter{{Name: "P", Type: "float"}},
+		expected: []error{errors.New(`step.env[0]: unknown type "float", must be one of bool, int, enum, regex or duration`)},
+	}, {
+		name:     "enum without allowed values",
+		params:   []api.StepParameter{{Name: "P", Type: api.StepParameterTypeEnum}},
+		expected: []error{errors.New("step.env[0]: `allowed_values` is required for `enum` parameters")},
+	}, {
+		name:     "regex without pattern",
+		params:   []api.StepParameter{{Name: "P", Type: api.StepParameterTypeRegex}},
+		expected: []error{errors.New("step.env[0]: `pattern` is required for `regex` parameters")},
+	}, {
+		name:     "regex with invalid pattern",
+		params:   []api.StepParameter{{Name: "P", Type: api.StepParameterTypeRegex, Pattern: "("}},
+		expected: []error{errors.New("step.env[0]: invalid `pattern`: error parsing regexp: missing closing ): `(`")},
+	}, {
+		name:   "constraints of other types",
+		params: []api.StepParameter{{Name: "P", Type: api.StepParameterTypeInt, AllowedValues: []string{"1"}, Pattern: "1"}},
+		expected: []error{
+			errors.New("step.env[0]: `allowed_values` can only be set for `enum` parameters"),
+			errors.New("step.env[0]: `pattern` can only be set for `regex` parameters"),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateParameterTypes(newContext("step", nil, nil, nil), tc.params)
+			if diff := cmp.Diff(tc.expected, errs, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected errors: %s", diff)
+			}
+		})
+	}
+}
+
+func TestStepParameterValue(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		param    api.StepParameter
+		value    string
+		expected error
+	}{
+		{name: "untyped", param: api.StepParameter{}, value: "anything"},
+		{name: "empty value of typed parameter", param: api.StepParameter{Type: api.StepParameterTypeBool}},
+		{name: "bool", param: api.StepParameter{Type: api.StepParameterTypeBool}, value: "false"},
+		{name: "invalid bool", param: api.StepParameter{Type: api.StepParameterTypeBool}, value: "ture", expected: errors.New("\"ture\" is not a bool, must be `true` or `false`")},
+		{name: "int", param: api.StepParameter{Type: api.StepParameterTypeInt}, value: "-3"},
+		{name: "invalid int", param: api.StepParameter{Type: api.StepParameterTypeInt}, value: "3.5", expected: errors.New(`"3.5" is not an int`)},
+		{name: "enum", param: api.StepParameter{Type: api.StepParameterTypeEnum, AllowedValues: []string{"ovn", "sdn"}}, value: "ovn"},
+		{name: "invalid enum", param: api.StepParameter{Type: api.StepParameterTypeEnum, AllowedValues: []string{"ovn", "sdn"}}, value: "OVN", expected: errors.New(`"OVN" is not one of the allowed values: ovn, sdn`)},
+		{name: "regex", param: api.StepParameter{Type: api.StepParameterTypeRegex, Pattern: "4\\.[0-9]+"}, value: "4.14"},
+		{name: "regex is anchored", param: api.StepParameter{Type: api.StepParameterTypeRegex, Pattern: "4\\.[0-9]+"}, value: "v4.14", expected: errors.New(`"v4.14" does not match the pattern "4\\.[0-9]+"`)},
+		{name: "duration", param: api.StepParameter{Type: api.StepParameterTypeDuration}, value: "1h30m"},
+		{name: "invalid duration", param: api.StepParameter{Type: api.StepParameterTypeDuration}, value: "90", expected: errors.New(`"90" is not a duration: time: missing unit in duration "90"`)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, StepParameterValue(tc.param, tc.value), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
 func TestValidateCredentials(t *testing.T) {
 	var testCases = []struct {
 		name   string
@@ -1450,6 +1691,44 @@ func TestValidateLeases(t *testing.T) {
 	}
 }
 
+func TestValidateParallelGroups(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		steps    []api.LiteralTestStep
+		expected []error
+	}{{
+		name:  "no groups",
+		steps: []api.LiteralTestStep{{As: "a"}, {As: "b"}},
+	}, {
+		name: "adjacent groups",
+		steps: []api.LiteralTestStep{
+			{As: "a", ParallelGroup: "a"},
+			{As: "b", ParallelGroup: "a"},
+			{As: "c"},
+			{As: "d", ParallelGroup: "d"},
+			{As: "e", ParallelGroup: "d"},
+		},
+	}, {
+		name: "group split by another step",
+		steps: []api.LiteralTestStep{
+			{As: "a", ParallelGroup: "a"},
+			{As: "b"},
+			{As: "c", ParallelGroup: "a"},
+			{As: "d", ParallelGroup: "a"},
+		},
+		expected: []error{
+			errors.New("post[2].parallel_group: steps of parallel group \"a\" must be adjacent"),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := validateParallelGroups(newContext("post", nil, nil, make(testInputImages)), tc.steps)
+			if diff := cmp.Diff(tc.expected, actual, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected errors: %s", diff)
+			}
+		})
+	}
+}
+
 func TestValidateTestConfigurationType(t *testing.T) {
 	for _, tc := range []struct {
 		name     string
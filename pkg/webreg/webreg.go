@@ -111,6 +111,8 @@ const referencePage = `
 {{ syntaxedSource .Reference.Commands }}
 <h3 id="properties"><a href="#properties">Properties</a></h3>
 {{ template "referenceProperties" .Reference }}
+<h3 id="dependents"><a href="/dependents?type=reference&name={{ .Reference.As }}">Jobs using this step</a></h3>
+{{ template "versionHistory" .Versions }}
 <h3 id="github"><p><a href="#github">GitHub Link:</a></h3></p>{{ githubLink .Metadata.Path }}
 {{ ownersBlock .Metadata.Owners }}
 `
@@ -119,6 +121,9 @@ const chainPage = `
 <h2 id="title"><a href="#title">Chain:</a> <nobr style="font-family:monospace">{{ .Chain.As }}</nobr></h2>
 <p id="documentation">{{ .Chain.Documentation }}</p>
 <h3 id="steps" title="Step run by the chain, in runtime order"><a href="#steps">Steps</a></h3>
+{{ if .Chain.Parallel }}
+	<p id="parallel">The steps of this chain run in parallel.</p>
+{{ end }}
 {{ template "stepTable" .Chain.Steps}}
 <h3 id="dependencies" title="Dependencies of steps involved in this chain"><a href="#dependencies">Dependencies</a></h3>
 {{ $depTable := "chain" }}
@@ -127,6 +132,8 @@ const chainPage = `
 {{ template "refEnvironment" .Chain.As }}
 <h3 id="graph" title="Visual representation of steps run by this chain"><a href="#graph">Step Graph</a></h3>
 {{ chainGraph .Chain.As }}
+<h3 id="dependents"><a href="/dependents?type=chain&name={{ .Chain.As }}">Jobs using this chain</a></h3>
+{{ template "versionHistory" .Versions }}
 <h3 id="github"><a href="#github">GitHub Link:</a></h3>{{ githubLink .Metadata.Path }}
 {{ ownersBlock .Metadata.Owners }}
 `
@@ -155,11 +162,42 @@ const workflowJobPage = `
 <h3 id="graph" title="Visual representation of steps run by this {{ toLower $type }}"><a href="#graph">Step Graph</a></h3>
 {{ workflowGraph .Workflow.As .Workflow.Type }}
 {{ if eq $type "Workflow" }}
+<h3 id="dependents"><a href="/dependents?type=workflow&name={{ .Workflow.As }}">Jobs using this workflow</a></h3>
+{{ template "versionHistory" .Versions }}
 <h3 id="github"><a href="#github">GitHub Link:</a></h3>{{ githubLink .Metadata.Path }}
 {{ ownersBlock .Metadata.Owners }}
 {{ end }}
 `
 
+const dependentsPage = `
+<h2 id="title"><a href="#title">Jobs using {{ .Type }}:</a> <nobr style="font-family:monospace">{{ if eq .Type "observer" }}{{ .Name }}{{ else }}<a href="/{{ .Type }}/{{ .Name }}">{{ .Name }}</a>{{ end }}</nobr></h2>
+<p>Tests which run the {{ .Type }}, either directly or through the workflows and chains using it. Tests overriding the steps of the workflow they use may not actually run it.</p>
+<table class="table">
+	<thead>
+		<tr>
+			<th title="GitHub organization that the job is from" class="info">Org</th>
+			<th title="GitHub repo that the job is from" class="info">Repo</th>
+			<th title="GitHub branch that the job is from" class="info">Branch</th>
+			<th title="Variant of the ci-operator config" class="info">Variant</th>
+			<th title="The multistage test" class="info">Test</th>
+			<th title="The registry element the test refers to" class="info">Via</th>
+		</tr>
+	</thead>
+	<tbody>
+		{{ range $index, $dependent := .Dependents }}
+		<tr>
+			<td>{{ $dependent.Org }}</td>
+			<td>{{ $dependent.Repo }}</td>
+			<td>{{ $dependent.Branch }}</td>
+			<td>{{ $dependent.Variant }}</td>
+			<td><nobr><a href="/job?org={{ $dependent.Org }}&repo={{ $dependent.Repo }}&branch={{ $dependent.Branch }}&variant={{ $dependent.Variant }}&test={{ $dependent.Test }}" style="font-family:monospace">{{ $dependent.Test }}</a></nobr></td>
+			<td><nobr style="font-family:monospace">{{ $dependent.Via.Type }} {{ $dependent.Via.Name }}</nobr></td>
+		</tr>
+		{{ end }}
+	</tbody>
+</table>
+`
+
 const jobSearchPage = `
 {{ template "jobTable" . }}
 `
@@ -309,6 +347,9 @@ const templateDefinitions = `
          <td style="font-family:monospace">{{ $name }}</td>
 		 <td>
 		   {{ $env.Documentation }}
+		   {{ if $env.Type }}
+			 (type: <span style="font-family:monospace">{{ $env.Type }}</span>)
+		   {{ end }}
 		   {{ if $env.Default }}
 		   {{ if gt (len $env.Default) 0 }}
 			 (default: <span style="font-family:monospace">{{ $env.Default }}</span>)
@@ -354,6 +395,9 @@ const templateDefinitions = `
      <td>Parameter<sup>[<a href="https://docs.ci.openshift.org/docs/architecture/step-registry/#parameters">?</a>]</sup></td>
      <td>
        {{ $env.Documentation | markdown}}
+       {{ if $env.Type }}
+         (type: <span style="font-family:monospace">{{ parameterType $env }}</span>)
+       {{ end }}
        {{ if $env.Default }}
        {{ if gt (len $env.Default) 0 }}
          (default: <span style="font-family:monospace">{{ $env.Default }}</span>)
@@ -397,9 +441,9 @@ const templateDefinitions = `
 				{{ $nameAndType := testStepNameAndType $step }}
 				{{ $doc := docsForName $nameAndType.Name }}
 				{{ if not $step.LiteralTestStep }}
-					<td>{{ template "nameWithLink" $nameAndType }}</td>
+					<td>{{ template "nameWithLink" $nameAndType }}{{ template "parallelBadge" $step }}</td>
 				{{ else }}
-					<td>{{ $nameAndType.Name }}</td>
+					<td>{{ $nameAndType.Name }}{{ template "parallelBadge" $step }}</td>
 				{{ end }}
 				<td>{{ noescape $doc }}</td>
 			</tr>
@@ -409,6 +453,27 @@ const templateDefinitions = `
 {{ end }}
 {{ end }}
 
+{{ define "versionHistory" }}
+{{ if .Versions }}
+<h3 id="versions" title="Versions of this {{ .Type }}, from the latest to the oldest"><a href="#versions">Version History</a></h3>
+<p>Tests referring to <span style="font-family:monospace">{{ .Name }}</span> run the latest version; tests may pin an older version with <span style="font-family:monospace">{{ .Name }}@vN</span>.</p>
+<ul>
+	{{ range $version := .Versions }}
+	<li><nobr><a href="/{{ $.Type }}/{{ $version }}" style="font-family:monospace">{{ $version }}</a></nobr>
+		{{ if eq $version $.Latest }}<span class="badge badge-success">latest</span>{{ end }}
+		{{ if eq $version $.Current }}<span class="badge badge-secondary">this version</span>{{ end }}
+	</li>
+	{{ end }}
+</ul>
+{{ end }}
+{{ end }}
+
+{{ define "parallelBadge" }}
+{{ if .Parallel }}
+	<span class="badge badge-info" title="Runs at the same time as the adjacent steps marked as parallel">parallel</span>
+{{ end }}
+{{ end }}
+
 {{ define "stepList" }}
 	<ul>
 	{{ range $index, $step := .}}
@@ -679,8 +744,9 @@ func getBaseTemplate() (*template.Template, error) {
 			"doubleInc": func(i int) int {
 				return i + 2
 			},
-			"githubLink":  githubLink,
-			"ownersBlock": ownersBlock,
+			"githubLink":    githubLink,
+			"ownersBlock":   ownersBlock,
+			"parameterType": parameterType,
 		},
 	)
 	return base.Funcs(template.FuncMap{"markdown": markDowner}).Parse(templateDefinitions)
@@ -800,8 +866,21 @@ func setWorkflowGraph(t *template.Template, chains registry.ChainByName, workflo
 
 type environmentLine struct {
 	Documentation string
-	Default       *string
-	Steps         []string
+	// Type describes the values the parameter accepts, see parameterType
+	Type    string
+	Default *string
+	Steps   []string
+}
+
+// parameterType describes the values a typed parameter accepts
+func parameterType(param api.StepParameter) string {
+	switch param.Type {
+	case api.StepParameterTypeEnum:
+		return fmt.Sprintf("%s (%s)", param.Type, strings.Join(param.AllowedValues, ", "))
+	case api.StepParameterTypeRegex:
+		return fmt.Sprintf("%s (%s)", param.Type, param.Pattern)
+	}
+	return string(param.Type)
 }
 
 type environmentData struct {
@@ -812,17 +891,18 @@ type environmentData struct {
 func getEnvironmentDataItems(worklist []api.TestStep, registryRefs registry.ReferenceByName, registryChains registry.ChainByName) map[string]environmentLine {
 	data := map[string]environmentLine{}
 
-	add := func(name, documentation, step string, defaultVal *string) {
-		if _, ok := data[name]; !ok {
-			data[name] = environmentLine{
-				Documentation: documentation,
-				Default:       defaultVal,
+	add := func(param api.StepParameter, step string) {
+		if _, ok := data[param.Name]; !ok {
+			data[param.Name] = environmentLine{
+				Documentation: param.Documentation,
+				Type:          parameterType(param),
+				Default:       param.Default,
 			}
 		}
 
-		line := data[name]
+		line := data[param.Name]
 		line.Steps = append(line.Steps, step)
-		data[name] = line
+		data[param.Name] = line
 	}
 
 	seenChains := sets.New[string]()
@@ -838,7 +918,7 @@ func getEnvironmentDataItems(worklist []api.TestStep, registryRefs registry.Refe
 				continue
 			}
 			for _, env := range ref.Environment {
-				add(env.Name, env.Documentation, ref.As, env.Default)
+				add(env, ref.As)
 			}
 		case step.Chain != nil:
 			chainName := *step.Chain
@@ -852,7 +932,7 @@ func getEnvironmentDataItems(worklist []api.TestStep, registryRefs registry.Refe
 			}
 		case step.LiteralTestStep != nil:
 			for _, env := range step.Environment {
-				add(env.Name, env.Documentation, step.As, env.Default)
+				add(env, step.As)
 			}
 		}
 	}
@@ -1082,6 +1162,8 @@ func WebRegHandler(regAgent agents.RegistryAgent, confAgent agents.ConfigAgent)
 				jobHandler(regAgent, confAgent, w, req)
 			case "ci-operator-reference":
 				ciOpConfigRefHandler(w)
+			case "dependents":
+				dependentsHandler(regAgent, confAgent, w, req)
 			default:
 				writeErrorPage(w, errors.New("Invalid path"), http.StatusNotImplemented)
 			}
@@ -1289,6 +1371,9 @@ func referenceHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *ht
 		return
 	}
 	refs, _, _, docs, metadata := agent.GetRegistryComponents()
+	if redirectToLatestVersion(w, req, "reference", name, refs) {
+		return
+	}
 	if _, ok := refs[name]; !ok {
 		writeErrorPage(w, fmt.Errorf("Could not find reference `%s`. If you reached this page via a link provided in the logs of a failed test, the failed step may be a literal defined step, which does not exist in the step registry. Please look at the job info page for the failed test instead.", name), http.StatusNotFound)
 		return
@@ -1301,6 +1386,7 @@ func referenceHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *ht
 	ref := struct {
 		Reference api.RegistryReference
 		Metadata  api.RegistryInfo
+		Versions  versionHistory
 	}{
 		Reference: api.RegistryReference{
 			LiteralTestStep: api.LiteralTestStep{
@@ -1321,6 +1407,7 @@ func referenceHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *ht
 			Documentation: docs[name],
 		},
 		Metadata: metadata[refMetadataName],
+		Versions: versionsOf("reference", name, refs),
 	}
 	writePage(w, "Registry Step Help Page", page, ref)
 }
@@ -1345,6 +1432,9 @@ func chainHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *http.R
 		writeErrorPage(w, fmt.Errorf("Failed to render page: %w", err), http.StatusInternalServerError)
 		return
 	}
+	if redirectToLatestVersion(w, req, "chain", name, chains) {
+		return
+	}
 	if _, ok := chains[name]; !ok {
 		writeErrorPage(w, fmt.Errorf("Could not find chain %s", name), http.StatusNotFound)
 		return
@@ -1357,13 +1447,16 @@ func chainHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *http.R
 	chain := struct {
 		Chain    api.RegistryChain
 		Metadata api.RegistryInfo
+		Versions versionHistory
 	}{
 		Chain: api.RegistryChain{
 			As:            name,
 			Documentation: docs[name],
 			Steps:         chains[name].Steps,
+			Parallel:      chains[name].Parallel,
 		},
 		Metadata: metadata[chainMetadataName],
+		Versions: versionsOf("chain", name, chains),
 	}
 	writePage(w, "Registry Chain Help Page", page, chain)
 }
@@ -1391,6 +1484,9 @@ func workflowHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *htt
 		writeErrorPage(w, fmt.Errorf("Failed to render page: %w", err), http.StatusInternalServerError)
 		return
 	}
+	if redirectToLatestVersion(w, req, "workflow", name, workflows) {
+		return
+	}
 	if _, ok := workflows[name]; !ok {
 		writeErrorPage(w, fmt.Errorf("Could not find workflow %s", name), http.StatusNotFound)
 		return
@@ -1403,6 +1499,7 @@ func workflowHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *htt
 	workflow := struct {
 		Workflow workflowJob
 		Metadata api.RegistryInfo
+		Versions versionHistory
 	}{
 		Workflow: workflowJob{
 			RegistryWorkflow: api.RegistryWorkflow{
@@ -1412,10 +1509,81 @@ func workflowHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *htt
 			},
 			Type: workflowType},
 		Metadata: metadata[workflowMetadataName],
+		Versions: versionsOf("workflow", name, workflows),
 	}
 	writePage(w, "Registry Workflow Help Page", page, workflow)
 }
 
+// versionHistory lists the versions of a versioned registry element
+type versionHistory struct {
+	Type string
+	// Name is the unversioned name of the element
+	Name string
+	// Current is the version shown on the page
+	Current string
+	Latest  string
+	// Versions are ordered from the latest to the oldest
+	Versions []string
+}
+
+func versionsOf[M ~map[string]T, T any](elementType, name string, elements M) versionHistory {
+	base, _ := registry.SplitVersion(name)
+	history := versionHistory{Type: elementType, Name: base, Current: name}
+	versions := registry.Versions(elements, base)
+	for i := len(versions) - 1; i >= 0; i-- {
+		history.Versions = append(history.Versions, versions[i])
+	}
+	if len(versions) > 0 {
+		history.Latest = versions[len(versions)-1]
+	}
+	return history
+}
+
+// redirectToLatestVersion redirects requests for the unversioned name of a
+// versioned element to the page of its latest version
+func redirectToLatestVersion[M ~map[string]T, T any](w http.ResponseWriter, req *http.Request, elementType, name string, elements M) bool {
+	if _, version := registry.SplitVersion(name); version != "" {
+		return false
+	}
+	if _, ok := elements[name]; ok {
+		return false
+	}
+	latest := versionsOf(elementType, name, elements).Latest
+	if latest == "" {
+		return false
+	}
+	http.Redirect(w, req, fmt.Sprintf("/%s/%s", elementType, latest), http.StatusFound)
+	return true
+}
+
+func dependentsHandler(regAgent agents.RegistryAgent, confAgent agents.ConfigAgent, w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	defer func() { logrus.Infof("rendered in %s", time.Since(start)) }()
+	w.Header().Set("Content-Type", "text/html;charset=UTF-8")
+	element := registryserver.RegistryElement{Type: req.URL.Query().Get(registryserver.TypeQuery), Name: req.URL.Query().Get(registryserver.NameQuery)}
+	nodeType, err := registry.ParseType(element.Type)
+	if err != nil {
+		writeErrorPage(w, err, http.StatusBadRequest)
+		return
+	}
+	node, ok := regAgent.GetGraph().Lookup(nodeType, element.Name)
+	if !ok {
+		writeErrorPage(w, fmt.Errorf("Could not find %s %s", element.Type, element.Name), http.StatusNotFound)
+		return
+	}
+	dependents, err := registryserver.Dependents(node, confAgent)
+	if err != nil {
+		writeErrorPage(w, fmt.Errorf("Failed to find jobs using %s %s: %w", element.Type, element.Name, err), http.StatusInternalServerError)
+		return
+	}
+	page, err := template.New("dependentsPage").Parse(dependentsPage)
+	if err != nil {
+		writeErrorPage(w, fmt.Errorf("Failed to render page: %w", err), http.StatusInternalServerError)
+		return
+	}
+	writePage(w, "Registry Dependents Page", page, registryserver.DependentsResponse{RegistryElement: element, Dependents: dependents})
+}
+
 func findConfigForJob(testName string, config api.ReleaseBuildConfiguration) (api.MultiStageTestConfiguration, error) {
 	for _, test := range config.Tests {
 		if test.As == testName {
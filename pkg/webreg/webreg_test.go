@@ -2,6 +2,8 @@ package webreg
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -316,3 +318,82 @@ func TestGetEnvironmentDataItems(t *testing.T) {
 		})
 	}
 }
+
+func TestVersionsOf(t *testing.T) {
+	refs := registry.ReferenceByName{"install@v1": {}, "install@v2": {}, "rbac": {}}
+	testCases := []struct {
+		description string
+		name        string
+		expected    versionHistory
+	}{
+		{
+			description: "older version of a versioned reference",
+			name:        "install@v1",
+			expected: versionHistory{
+				Type:     "reference",
+				Name:     "install",
+				Current:  "install@v1",
+				Latest:   "install@v2",
+				Versions: []string{"install@v2", "install@v1"},
+			},
+		},
+		{
+			description: "unversioned reference",
+			name:        "rbac",
+			expected:    versionHistory{Type: "reference", Name: "rbac", Current: "rbac"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, versionsOf("reference", tc.name, refs)); diff != "" {
+				t.Errorf("%s: history differs from expected:\n%s", tc.description, diff)
+			}
+		})
+	}
+}
+
+func TestRedirectToLatestVersion(t *testing.T) {
+	refs := registry.ReferenceByName{"install@v1": {}, "install@v2": {}, "rbac": {}}
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "install", expected: "/reference/install@v2"},
+		{name: "install@v1"},
+		{name: "install@v3"},
+		{name: "rbac"},
+		{name: "missing"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			redirected := redirectToLatestVersion(w, httptest.NewRequest(http.MethodGet, "/reference/"+tc.name, nil), "reference", tc.name, refs)
+			if redirected != (tc.expected != "") {
+				t.Fatalf("expected redirect: %t, got %t", tc.expected != "", redirected)
+			}
+			if location := w.Header().Get("Location"); location != tc.expected {
+				t.Errorf("expected redirect to %q, got %q", tc.expected, location)
+			}
+		})
+	}
+}
+
+func TestParameterType(t *testing.T) {
+	testCases := []struct {
+		description string
+		param       api.StepParameter
+		expected    string
+	}{
+		{description: "untyped", param: api.StepParameter{Name: "P"}},
+		{description: "bool", param: api.StepParameter{Name: "P", Type: api.StepParameterTypeBool}, expected: "bool"},
+		{description: "enum", param: api.StepParameter{Name: "P", Type: api.StepParameterTypeEnum, AllowedValues: []string{"ovn", "sdn"}}, expected: "enum (ovn, sdn)"},
+		{description: "regex", param: api.StepParameter{Name: "P", Type: api.StepParameterTypeRegex, Pattern: "4\\.[0-9]+"}, expected: "regex (4\\.[0-9]+)"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if actual := parameterType(tc.param); actual != tc.expected {
+				t.Errorf("%s: expected %q, got %q", tc.description, tc.expected, actual)
+			}
+		})
+	}
+}
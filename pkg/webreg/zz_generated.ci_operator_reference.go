@@ -593,12 +593,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  commands: ' '\n" +
 	"                  # Environment has the values of parameters for the observer.\n" +
 	"                  env:\n" +
-	"                    - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                    - # AllowedValues are the values an `enum` parameter can be set to.\n" +
+	"                      allowed_values:\n" +
+	"                        - \"\"\n" +
+	"                      # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                      default: \"\"\n" +
 	"                      # Documentation is a textual description of the parameter.\n" +
 	"                      documentation: ' '\n" +
 	"                      # Name of the environment variable.\n" +
 	"                      name: ' '\n" +
+	"                      # Pattern is the regular expression values of a `regex` parameter must\n" +
+	"                      # match, anchored at both ends.\n" +
+	"                      pattern: ' '\n" +
+	"                      # Type optionally restricts the values of the parameter. Values are not\n" +
+	"                      # checked when it is not set.\n" +
+	"                      type: ' '\n" +
 	"                  # From is the container image that will be used for this observer.\n" +
 	"                  from: ' '\n" +
 	"                  # FromImage is a literal ImageStreamTag reference to use for this observer.\n" +
@@ -665,12 +674,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        - \"\"\n" +
 	"                  # Environment lists parameters that should be set by the test.\n" +
 	"                  env:\n" +
-	"                    - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                    - # AllowedValues are the values an `enum` parameter can be set to.\n" +
+	"                      allowed_values:\n" +
+	"                        - \"\"\n" +
+	"                      # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                      default: \"\"\n" +
 	"                      # Documentation is a textual description of the parameter.\n" +
 	"                      documentation: ' '\n" +
 	"                      # Name of the environment variable.\n" +
 	"                      name: ' '\n" +
+	"                      # Pattern is the regular expression values of a `regex` parameter must\n" +
+	"                      # match, anchored at both ends.\n" +
+	"                      pattern: ' '\n" +
+	"                      # Type optionally restricts the values of the parameter. Values are not\n" +
+	"                      # checked when it is not set.\n" +
+	"                      type: ' '\n" +
 	"                  # From is the container image that will be used for this step.\n" +
 	"                  from: ' '\n" +
 	"                  # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
@@ -701,6 +719,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # ParallelGroup is the name of the group of adjacent steps this step\n" +
+	"                  # runs concurrently with. It is set by the registry resolver from the\n" +
+	"                  # `parallel` fields of test steps and chains and cannot be configured.\n" +
+	"                  parallel_group: ' '\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -711,9 +733,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # These are directly used in creating the Pods that execute the Job.\n" +
 	"                    requests:\n" +
 	"                        \"\": \"\"\n" +
+	"                  # Retry defines if and how this step should be executed again when it\n" +
+	"                  # fails. Every attempt runs in a separate pod, so the logs and artifacts\n" +
+	"                  # of all attempts are preserved.\n" +
+	"                  retry:\n" +
+	"                    # Backoff is how long to wait before the second attempt. The delay\n" +
+	"                    # doubles for every subsequent attempt.\n" +
+	"                    backoff: 0s\n" +
+	"                    # ExitCodes restricts retries to failures where the test container\n" +
+	"                    # exited with one of the given codes.\n" +
+	"                    exit_codes:\n" +
+	"                        - 0\n" +
+	"                    # MaxAttempts is the maximum number of times the step is executed,\n" +
+	"                    # including the first attempt, and at most 5.\n" +
+	"                    max_attempts: 0\n" +
+	"                    # Reasons restricts retries to failures with one of the given reasons,\n" +
+	"                    # e.g. `pod_pending`.\n" +
+	"                    reasons:\n" +
+	"                        - \"\"\n" +
 	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"                  # in the test container instead of being executed directly via bash\n" +
 	"                  run_as_script: false\n" +
+	"                  # RunIfChanged is a regex that will result in the step only running if\n" +
+	"                  # something that matches it was changed in the pull requests under test.\n" +
+	"                  run_if_changed: ' '\n" +
+	"                  # SkipIfOnlyChanged is a regex that will result in the step being skipped\n" +
+	"                  # if all files changed in the pull requests under test match that regex.\n" +
+	"                  skip_if_only_changed: ' '\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
 	"            # Pre is the array of test steps run to set up the environment for the test.\n" +
@@ -755,12 +801,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        - \"\"\n" +
 	"                  # Environment lists parameters that should be set by the test.\n" +
 	"                  env:\n" +
-	"                    - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                    - # AllowedValues are the values an `enum` parameter can be set to.\n" +
+	"                      allowed_values:\n" +
+	"                        - \"\"\n" +
+	"                      # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                      default: \"\"\n" +
 	"                      # Documentation is a textual description of the parameter.\n" +
 	"                      documentation: ' '\n" +
 	"                      # Name of the environment variable.\n" +
 	"                      name: ' '\n" +
+	"                      # Pattern is the regular expression values of a `regex` parameter must\n" +
+	"                      # match, anchored at both ends.\n" +
+	"                      pattern: ' '\n" +
+	"                      # Type optionally restricts the values of the parameter. Values are not\n" +
+	"                      # checked when it is not set.\n" +
+	"                      type: ' '\n" +
 	"                  # From is the container image that will be used for this step.\n" +
 	"                  from: ' '\n" +
 	"                  # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
@@ -791,6 +846,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # ParallelGroup is the name of the group of adjacent steps this step\n" +
+	"                  # runs concurrently with. It is set by the registry resolver from the\n" +
+	"                  # `parallel` fields of test steps and chains and cannot be configured.\n" +
+	"                  parallel_group: ' '\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -801,9 +860,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # These are directly used in creating the Pods that execute the Job.\n" +
 	"                    requests:\n" +
 	"                        \"\": \"\"\n" +
+	"                  # Retry defines if and how this step should be executed again when it\n" +
+	"                  # fails. Every attempt runs in a separate pod, so the logs and artifacts\n" +
+	"                  # of all attempts are preserved.\n" +
+	"                  retry:\n" +
+	"                    # Backoff is how long to wait before the second attempt. The delay\n" +
+	"                    # doubles for every subsequent attempt.\n" +
+	"                    backoff: 0s\n" +
+	"                    # ExitCodes restricts retries to failures where the test container\n" +
+	"                    # exited with one of the given codes.\n" +
+	"                    exit_codes:\n" +
+	"                        - 0\n" +
+	"                    # MaxAttempts is the maximum number of times the step is executed,\n" +
+	"                    # including the first attempt, and at most 5.\n" +
+	"                    max_attempts: 0\n" +
+	"                    # Reasons restricts retries to failures with one of the given reasons,\n" +
+	"                    # e.g. `pod_pending`.\n" +
+	"                    reasons:\n" +
+	"                        - \"\"\n" +
 	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"                  # in the test container instead of being executed directly via bash\n" +
 	"                  run_as_script: false\n" +
+	"                  # RunIfChanged is a regex that will result in the step only running if\n" +
+	"                  # something that matches it was changed in the pull requests under test.\n" +
+	"                  run_if_changed: ' '\n" +
+	"                  # SkipIfOnlyChanged is a regex that will result in the step being skipped\n" +
+	"                  # if all files changed in the pull requests under test match that regex.\n" +
+	"                  skip_if_only_changed: ' '\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
 	"            # Test is the array of test steps that define the actual test.\n" +
@@ -845,12 +928,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        - \"\"\n" +
 	"                  # Environment lists parameters that should be set by the test.\n" +
 	"                  env:\n" +
-	"                    - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                    - # AllowedValues are the values an `enum` parameter can be set to.\n" +
+	"                      allowed_values:\n" +
+	"                        - \"\"\n" +
+	"                      # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                      default: \"\"\n" +
 	"                      # Documentation is a textual description of the parameter.\n" +
 	"                      documentation: ' '\n" +
 	"                      # Name of the environment variable.\n" +
 	"                      name: ' '\n" +
+	"                      # Pattern is the regular expression values of a `regex` parameter must\n" +
+	"                      # match, anchored at both ends.\n" +
+	"                      pattern: ' '\n" +
+	"                      # Type optionally restricts the values of the parameter. Values are not\n" +
+	"                      # checked when it is not set.\n" +
+	"                      type: ' '\n" +
 	"                  # From is the container image that will be used for this step.\n" +
 	"                  from: ' '\n" +
 	"                  # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
@@ -881,6 +973,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # ParallelGroup is the name of the group of adjacent steps this step\n" +
+	"                  # runs concurrently with. It is set by the registry resolver from the\n" +
+	"                  # `parallel` fields of test steps and chains and cannot be configured.\n" +
+	"                  parallel_group: ' '\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -891,9 +987,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # These are directly used in creating the Pods that execute the Job.\n" +
 	"                    requests:\n" +
 	"                        \"\": \"\"\n" +
+	"                  # Retry defines if and how this step should be executed again when it\n" +
+	"                  # fails. Every attempt runs in a separate pod, so the logs and artifacts\n" +
+	"                  # of all attempts are preserved.\n" +
+	"                  retry:\n" +
+	"                    # Backoff is how long to wait before the second attempt. The delay\n" +
+	"                    # doubles for every subsequent attempt.\n" +
+	"                    backoff: 0s\n" +
+	"                    # ExitCodes restricts retries to failures where the test container\n" +
+	"                    # exited with one of the given codes.\n" +
+	"                    exit_codes:\n" +
+	"                        - 0\n" +
+	"                    # MaxAttempts is the maximum number of times the step is executed,\n" +
+	"                    # including the first attempt, and at most 5.\n" +
+	"                    max_attempts: 0\n" +
+	"                    # Reasons restricts retries to failures with one of the given reasons,\n" +
+	"                    # e.g. `pod_pending`.\n" +
+	"                    reasons:\n" +
+	"                        - \"\"\n" +
 	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"                  # in the test container instead of being executed directly via bash\n" +
 	"                  run_as_script: false\n" +
+	"                  # RunIfChanged is a regex that will result in the step only running if\n" +
+	"                  # something that matches it was changed in the pull requests under test.\n" +
+	"                  run_if_changed: ' '\n" +
+	"                  # SkipIfOnlyChanged is a regex that will result in the step being skipped\n" +
+	"                  # if all files changed in the pull requests under test match that regex.\n" +
+	"                  skip_if_only_changed: ' '\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
 	"            # Override job timeout\n" +
@@ -1041,9 +1161,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        - \"\"\n" +
 	"                  env:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
-	"                    - default: \"\"\n" +
+	"                    - allowed_values:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                      default: \"\"\n" +
 	"                      documentation: ' '\n" +
 	"                      name: ' '\n" +
+	"                      pattern: ' '\n" +
+	"                      type: ' '\n" +
 	"                  from: ' '\n" +
 	"                  from_image:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
@@ -1061,6 +1186,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
+	"                  # Parallel defines that the step runs at the same time as the adjacent\n" +
+	"                  # steps of the same phase which also set it. When set on a chain, its\n" +
+	"                  # steps also run in parallel with each other. Every step in a parallel\n" +
+	"                  # group starts with the same SHARED_DIR; once all of them finish, their\n" +
+	"                  # changes are merged back and it is an error for two steps to change the\n" +
+	"                  # same file differently.\n" +
+	"                  parallel: true\n" +
+	"                  parallel_group: ' '\n" +
 	"                  # Reference is the name of a step reference.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
@@ -1072,7 +1205,27 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    requests:\n" +
 	"                        # LiteralTestStep is a full test step definition.\n" +
 	"                        \"\": \"\"\n" +
+	"                  retry:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    backoff: 0s\n" +
+	"                    exit_codes:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                    max_attempts: 0\n" +
+	"                    reasons:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
 	"                  run_as_script: false\n" +
+	"                  # RunIfChanged is a regex that will result in the step only running if\n" +
+	"                  # something that matches it was changed. It takes precedence over the\n" +
+	"                  # conditions of a referenced step and applies to the steps of a chain\n" +
+	"                  # which do not declare their own.\n" +
+	"                  run_if_changed: ' '\n" +
+	"                  # SkipIfOnlyChanged is a regex that will result in the step being skipped\n" +
+	"                  # if all changed files match that regex. It takes precedence over the\n" +
+	"                  # conditions of a referenced step and applies to the steps of a chain\n" +
+	"                  # which do not declare their own.\n" +
+	"                  skip_if_only_changed: ' '\n" +
 	"                  timeout: 0s\n" +
 	"            # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"            pre:\n" +
@@ -1104,9 +1257,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        - \"\"\n" +
 	"                  env:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
-	"                    - default: \"\"\n" +
+	"                    - allowed_values:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                      default: \"\"\n" +
 	"                      documentation: ' '\n" +
 	"                      name: ' '\n" +
+	"                      pattern: ' '\n" +
+	"                      type: ' '\n" +
 	"                  from: ' '\n" +
 	"                  from_image:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
@@ -1124,6 +1282,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
+	"                  # Parallel defines that the step runs at the same time as the adjacent\n" +
+	"                  # steps of the same phase which also set it. When set on a chain, its\n" +
+	"                  # steps also run in parallel with each other. Every step in a parallel\n" +
+	"                  # group starts with the same SHARED_DIR; once all of them finish, their\n" +
+	"                  # changes are merged back and it is an error for two steps to change the\n" +
+	"                  # same file differently.\n" +
+	"                  parallel: true\n" +
+	"                  parallel_group: ' '\n" +
 	"                  # Reference is the name of a step reference.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
@@ -1135,7 +1301,27 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    requests:\n" +
 	"                        # LiteralTestStep is a full test step definition.\n" +
 	"                        \"\": \"\"\n" +
+	"                  retry:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    backoff: 0s\n" +
+	"                    exit_codes:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                    max_attempts: 0\n" +
+	"                    reasons:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
 	"                  run_as_script: false\n" +
+	"                  # RunIfChanged is a regex that will result in the step only running if\n" +
+	"                  # something that matches it was changed. It takes precedence over the\n" +
+	"                  # conditions of a referenced step and applies to the steps of a chain\n" +
+	"                  # which do not declare their own.\n" +
+	"                  run_if_changed: ' '\n" +
+	"                  # SkipIfOnlyChanged is a regex that will result in the step being skipped\n" +
+	"                  # if all changed files match that regex. It takes precedence over the\n" +
+	"                  # conditions of a referenced step and applies to the steps of a chain\n" +
+	"                  # which do not declare their own.\n" +
+	"                  skip_if_only_changed: ' '\n" +
 	"                  timeout: 0s\n" +
 	"            # Test is the array of test steps that define the actual test.\n" +
 	"            test:\n" +
@@ -1167,9 +1353,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        - \"\"\n" +
 	"                  env:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
-	"                    - default: \"\"\n" +
+	"                    - allowed_values:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                      default: \"\"\n" +
 	"                      documentation: ' '\n" +
 	"                      name: ' '\n" +
+	"                      pattern: ' '\n" +
+	"                      type: ' '\n" +
 	"                  from: ' '\n" +
 	"                  from_image:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
@@ -1187,6 +1378,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
+	"                  # Parallel defines that the step runs at the same time as the adjacent\n" +
+	"                  # steps of the same phase which also set it. When set on a chain, its\n" +
+	"                  # steps also run in parallel with each other. Every step in a parallel\n" +
+	"                  # group starts with the same SHARED_DIR; once all of them finish, their\n" +
+	"                  # changes are merged back and it is an error for two steps to change the\n" +
+	"                  # same file differently.\n" +
+	"                  parallel: true\n" +
+	"                  parallel_group: ' '\n" +
 	"                  # Reference is the name of a step reference.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
@@ -1198,7 +1397,27 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    requests:\n" +
 	"                        # LiteralTestStep is a full test step definition.\n" +
 	"                        \"\": \"\"\n" +
+	"                  retry:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    backoff: 0s\n" +
+	"                    exit_codes:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                    max_attempts: 0\n" +
+	"                    reasons:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
 	"                  run_as_script: false\n" +
+	"                  # RunIfChanged is a regex that will result in the step only running if\n" +
+	"                  # something that matches it was changed. It takes precedence over the\n" +
+	"                  # conditions of a referenced step and applies to the steps of a chain\n" +
+	"                  # which do not declare their own.\n" +
+	"                  run_if_changed: ' '\n" +
+	"                  # SkipIfOnlyChanged is a regex that will result in the step being skipped\n" +
+	"                  # if all changed files match that regex. It takes precedence over the\n" +
+	"                  # conditions of a referenced step and applies to the steps of a chain\n" +
+	"                  # which do not declare their own.\n" +
+	"                  skip_if_only_changed: ' '\n" +
 	"                  timeout: 0s\n" +
 	"            # Workflow is the name of the workflow to be used for this configuration. For fields defined in both\n" +
 	"            # the config and the workflow, the fields from the config will override what is set in Workflow.\n" +
@@ -1409,12 +1628,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              commands: ' '\n" +
 	"              # Environment has the values of parameters for the observer.\n" +
 	"              env:\n" +
-	"                - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                - # AllowedValues are the values an `enum` parameter can be set to.\n" +
+	"                  allowed_values:\n" +
+	"                    - \"\"\n" +
+	"                  # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                  default: \"\"\n" +
 	"                  # Documentation is a textual description of the parameter.\n" +
 	"                  documentation: ' '\n" +
 	"                  # Name of the environment variable.\n" +
 	"                  name: ' '\n" +
+	"                  # Pattern is the regular expression values of a `regex` parameter must\n" +
+	"                  # match, anchored at both ends.\n" +
+	"                  pattern: ' '\n" +
+	"                  # Type optionally restricts the values of the parameter. Values are not\n" +
+	"                  # checked when it is not set.\n" +
+	"                  type: ' '\n" +
 	"              # From is the container image that will be used for this observer.\n" +
 	"              from: ' '\n" +
 	"              # FromImage is a literal ImageStreamTag reference to use for this observer.\n" +
@@ -1481,12 +1709,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    - \"\"\n" +
 	"              # Environment lists parameters that should be set by the test.\n" +
 	"              env:\n" +
-	"                - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                - # AllowedValues are the values an `enum` parameter can be set to.\n" +
+	"                  allowed_values:\n" +
+	"                    - \"\"\n" +
+	"                  # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                  default: \"\"\n" +
 	"                  # Documentation is a textual description of the parameter.\n" +
 	"                  documentation: ' '\n" +
 	"                  # Name of the environment variable.\n" +
 	"                  name: ' '\n" +
+	"                  # Pattern is the regular expression values of a `regex` parameter must\n" +
+	"                  # match, anchored at both ends.\n" +
+	"                  pattern: ' '\n" +
+	"                  # Type optionally restricts the values of the parameter. Values are not\n" +
+	"                  # checked when it is not set.\n" +
+	"                  type: ' '\n" +
 	"              # From is the container image that will be used for this step.\n" +
 	"              from: ' '\n" +
 	"              # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
@@ -1517,6 +1754,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # ParallelGroup is the name of the group of adjacent steps this step\n" +
+	"              # runs concurrently with. It is set by the registry resolver from the\n" +
+	"              # `parallel` fields of test steps and chains and cannot be configured.\n" +
+	"              parallel_group: ' '\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1527,9 +1768,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # These are directly used in creating the Pods that execute the Job.\n" +
 	"                requests:\n" +
 	"                    \"\": \"\"\n" +
+	"              # Retry defines if and how this step should be executed again when it\n" +
+	"              # fails. Every attempt runs in a separate pod, so the logs and artifacts\n" +
+	"              # of all attempts are preserved.\n" +
+	"              retry:\n" +
+	"                # Backoff is how long to wait before the second attempt. The delay\n" +
+	"                # doubles for every subsequent attempt.\n" +
+	"                backoff: 0s\n" +
+	"                # ExitCodes restricts retries to failures where the test container\n" +
+	"                # exited with one of the given codes.\n" +
+	"                exit_codes:\n" +
+	"                    - 0\n" +
+	"                # MaxAttempts is the maximum number of times the step is executed,\n" +
+	"                # including the first attempt, and at most 5.\n" +
+	"                max_attempts: 0\n" +
+	"                # Reasons restricts retries to failures with one of the given reasons,\n" +
+	"                # e.g. `pod_pending`.\n" +
+	"                reasons:\n" +
+	"                    - \"\"\n" +
 	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"              # in the test container instead of being executed directly via bash\n" +
 	"              run_as_script: false\n" +
+	"              # RunIfChanged is a regex that will result in the step only running if\n" +
+	"              # something that matches it was changed in the pull requests under test.\n" +
+	"              run_if_changed: ' '\n" +
+	"              # SkipIfOnlyChanged is a regex that will result in the step being skipped\n" +
+	"              # if all files changed in the pull requests under test match that regex.\n" +
+	"              skip_if_only_changed: ' '\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
 	"        # Pre is the array of test steps run to set up the environment for the test.\n" +
@@ -1571,12 +1836,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    - \"\"\n" +
 	"              # Environment lists parameters that should be set by the test.\n" +
 	"              env:\n" +
-	"                - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                - # AllowedValues are the values an `enum` parameter can be set to.\n" +
+	"                  allowed_values:\n" +
+	"                    - \"\"\n" +
+	"                  # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                  default: \"\"\n" +
 	"                  # Documentation is a textual description of the parameter.\n" +
 	"                  documentation: ' '\n" +
 	"                  # Name of the environment variable.\n" +
 	"                  name: ' '\n" +
+	"                  # Pattern is the regular expression values of a `regex` parameter must\n" +
+	"                  # match, anchored at both ends.\n" +
+	"                  pattern: ' '\n" +
+	"                  # Type optionally restricts the values of the parameter. Values are not\n" +
+	"                  # checked when it is not set.\n" +
+	"                  type: ' '\n" +
 	"              # From is the container image that will be used for this step.\n" +
 	"              from: ' '\n" +
 	"              # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
@@ -1607,6 +1881,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # ParallelGroup is the name of the group of adjacent steps this step\n" +
+	"              # runs concurrently with. It is set by the registry resolver from the\n" +
+	"              # `parallel` fields of test steps and chains and cannot be configured.\n" +
+	"              parallel_group: ' '\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1617,9 +1895,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # These are directly used in creating the Pods that execute the Job.\n" +
 	"                requests:\n" +
 	"                    \"\": \"\"\n" +
+	"              # Retry defines if and how this step should be executed again when it\n" +
+	"              # fails. Every attempt runs in a separate pod, so the logs and artifacts\n" +
+	"              # of all attempts are preserved.\n" +
+	"              retry:\n" +
+	"                # Backoff is how long to wait before the second attempt. The delay\n" +
+	"                # doubles for every subsequent attempt.\n" +
+	"                backoff: 0s\n" +
+	"                # ExitCodes restricts retries to failures where the test container\n" +
+	"                # exited with one of the given codes.\n" +
+	"                exit_codes:\n" +
+	"                    - 0\n" +
+	"                # MaxAttempts is the maximum number of times the step is executed,\n" +
+	"                # including the first attempt, and at most 5.\n" +
+	"                max_attempts: 0\n" +
+	"                # Reasons restricts retries to failures with one of the given reasons,\n" +
+	"                # e.g. `pod_pending`.\n" +
+	"                reasons:\n" +
+	"                    - \"\"\n" +
 	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"              # in the test container instead of being executed directly via bash\n" +
 	"              run_as_script: false\n" +
+	"              # RunIfChanged is a regex that will result in the step only running if\n" +
+	"              # something that matches it was changed in the pull requests under test.\n" +
+	"              run_if_changed: ' '\n" +
+	"              # SkipIfOnlyChanged is a regex that will result in the step being skipped\n" +
+	"              # if all files changed in the pull requests under test match that regex.\n" +
+	"              skip_if_only_changed: ' '\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
 	"        # Test is the array of test steps that define the actual test.\n" +
@@ -1661,12 +1963,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    - \"\"\n" +
 	"              # Environment lists parameters that should be set by the test.\n" +
 	"              env:\n" +
-	"                - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                - # AllowedValues are the values an `enum` parameter can be set to.\n" +
+	"                  allowed_values:\n" +
+	"                    - \"\"\n" +
+	"                  # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                  default: \"\"\n" +
 	"                  # Documentation is a textual description of the parameter.\n" +
 	"                  documentation: ' '\n" +
 	"                  # Name of the environment variable.\n" +
 	"                  name: ' '\n" +
+	"                  # Pattern is the regular expression values of a `regex` parameter must\n" +
+	"                  # match, anchored at both ends.\n" +
+	"                  pattern: ' '\n" +
+	"                  # Type optionally restricts the values of the parameter. Values are not\n" +
+	"                  # checked when it is not set.\n" +
+	"                  type: ' '\n" +
 	"              # From is the container image that will be used for this step.\n" +
 	"              from: ' '\n" +
 	"              # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
@@ -1697,6 +2008,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # ParallelGroup is the name of the group of adjacent steps this step\n" +
+	"              # runs concurrently with. It is set by the registry resolver from the\n" +
+	"              # `parallel` fields of test steps and chains and cannot be configured.\n" +
+	"              parallel_group: ' '\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1707,9 +2022,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # These are directly used in creating the Pods that execute the Job.\n" +
 	"                requests:\n" +
 	"                    \"\": \"\"\n" +
+	"              # Retry defines if and how this step should be executed again when it\n" +
+	"              # fails. Every attempt runs in a separate pod, so the logs and artifacts\n" +
+	"              # of all attempts are preserved.\n" +
+	"              retry:\n" +
+	"                # Backoff is how long to wait before the second attempt. The delay\n" +
+	"                # doubles for every subsequent attempt.\n" +
+	"                backoff: 0s\n" +
+	"                # ExitCodes restricts retries to failures where the test container\n" +
+	"                # exited with one of the given codes.\n" +
+	"                exit_codes:\n" +
+	"                    - 0\n" +
+	"                # MaxAttempts is the maximum number of times the step is executed,\n" +
+	"                # including the first attempt, and at most 5.\n" +
+	"                max_attempts: 0\n" +
+	"                # Reasons restricts retries to failures with one of the given reasons,\n" +
+	"                # e.g. `pod_pending`.\n" +
+	"                reasons:\n" +
+	"                    - \"\"\n" +
 	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"              # in the test container instead of being executed directly via bash\n" +
 	"              run_as_script: false\n" +
+	"              # RunIfChanged is a regex that will result in the step only running if\n" +
+	"              # something that matches it was changed in the pull requests under test.\n" +
+	"              run_if_changed: ' '\n" +
+	"              # SkipIfOnlyChanged is a regex that will result in the step being skipped\n" +
+	"              # if all files changed in the pull requests under test match that regex.\n" +
+	"              skip_if_only_changed: ' '\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
 	"        # Override job timeout\n" +
@@ -1857,9 +2196,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    - \"\"\n" +
 	"              env:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
-	"                - default: \"\"\n" +
+	"                - allowed_values:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  default: \"\"\n" +
 	"                  documentation: ' '\n" +
 	"                  name: ' '\n" +
+	"                  pattern: ' '\n" +
+	"                  type: ' '\n" +
 	"              from: ' '\n" +
 	"              from_image:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
@@ -1877,6 +2221,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
+	"              # Parallel defines that the step runs at the same time as the adjacent\n" +
+	"              # steps of the same phase which also set it. When set on a chain, its\n" +
+	"              # steps also run in parallel with each other. Every step in a parallel\n" +
+	"              # group starts with the same SHARED_DIR; once all of them finish, their\n" +
+	"              # changes are merged back and it is an error for two steps to change the\n" +
+	"              # same file differently.\n" +
+	"              parallel: true\n" +
+	"              parallel_group: ' '\n" +
 	"              # Reference is the name of a step reference.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
@@ -1888,7 +2240,27 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                requests:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    \"\": \"\"\n" +
+	"              retry:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                backoff: 0s\n" +
+	"                exit_codes:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - 0\n" +
+	"                max_attempts: 0\n" +
+	"                reasons:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"              run_as_script: false\n" +
+	"              # RunIfChanged is a regex that will result in the step only running if\n" +
+	"              # something that matches it was changed. It takes precedence over the\n" +
+	"              # conditions of a referenced step and applies to the steps of a chain\n" +
+	"              # which do not declare their own.\n" +
+	"              run_if_changed: ' '\n" +
+	"              # SkipIfOnlyChanged is a regex that will result in the step being skipped\n" +
+	"              # if all changed files match that regex. It takes precedence over the\n" +
+	"              # conditions of a referenced step and applies to the steps of a chain\n" +
+	"              # which do not declare their own.\n" +
+	"              skip_if_only_changed: ' '\n" +
 	"              timeout: 0s\n" +
 	"        # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"        pre:\n" +
@@ -1920,9 +2292,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    - \"\"\n" +
 	"              env:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
-	"                - default: \"\"\n" +
+	"                - allowed_values:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  default: \"\"\n" +
 	"                  documentation: ' '\n" +
 	"                  name: ' '\n" +
+	"                  pattern: ' '\n" +
+	"                  type: ' '\n" +
 	"              from: ' '\n" +
 	"              from_image:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
@@ -1940,6 +2317,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
+	"              # Parallel defines that the step runs at the same time as the adjacent\n" +
+	"              # steps of the same phase which also set it. When set on a chain, its\n" +
+	"              # steps also run in parallel with each other. Every step in a parallel\n" +
+	"              # group starts with the same SHARED_DIR; once all of them finish, their\n" +
+	"              # changes are merged back and it is an error for two steps to change the\n" +
+	"              # same file differently.\n" +
+	"              parallel: true\n" +
+	"              parallel_group: ' '\n" +
 	"              # Reference is the name of a step reference.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
@@ -1951,7 +2336,27 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                requests:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    \"\": \"\"\n" +
+	"              retry:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                backoff: 0s\n" +
+	"                exit_codes:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - 0\n" +
+	"                max_attempts: 0\n" +
+	"                reasons:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"              run_as_script: false\n" +
+	"              # RunIfChanged is a regex that will result in the step only running if\n" +
+	"              # something that matches it was changed. It takes precedence over the\n" +
+	"              # conditions of a referenced step and applies to the steps of a chain\n" +
+	"              # which do not declare their own.\n" +
+	"              run_if_changed: ' '\n" +
+	"              # SkipIfOnlyChanged is a regex that will result in the step being skipped\n" +
+	"              # if all changed files match that regex. It takes precedence over the\n" +
+	"              # conditions of a referenced step and applies to the steps of a chain\n" +
+	"              # which do not declare their own.\n" +
+	"              skip_if_only_changed: ' '\n" +
 	"              timeout: 0s\n" +
 	"        # Test is the array of test steps that define the actual test.\n" +
 	"        test:\n" +
@@ -1983,9 +2388,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    - \"\"\n" +
 	"              env:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
-	"                - default: \"\"\n" +
+	"                - allowed_values:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  default: \"\"\n" +
 	"                  documentation: ' '\n" +
 	"                  name: ' '\n" +
+	"                  pattern: ' '\n" +
+	"                  type: ' '\n" +
 	"              from: ' '\n" +
 	"              from_image:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
@@ -2003,6 +2413,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
+	"              # Parallel defines that the step runs at the same time as the adjacent\n" +
+	"              # steps of the same phase which also set it. When set on a chain, its\n" +
+	"              # steps also run in parallel with each other. Every step in a parallel\n" +
+	"              # group starts with the same SHARED_DIR; once all of them finish, their\n" +
+	"              # changes are merged back and it is an error for two steps to change the\n" +
+	"              # same file differently.\n" +
+	"              parallel: true\n" +
+	"              parallel_group: ' '\n" +
 	"              # Reference is the name of a step reference.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
@@ -2014,7 +2432,27 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                requests:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    \"\": \"\"\n" +
+	"              retry:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                backoff: 0s\n" +
+	"                exit_codes:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - 0\n" +
+	"                max_attempts: 0\n" +
+	"                reasons:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"              run_as_script: false\n" +
+	"              # RunIfChanged is a regex that will result in the step only running if\n" +
+	"              # something that matches it was changed. It takes precedence over the\n" +
+	"              # conditions of a referenced step and applies to the steps of a chain\n" +
+	"              # which do not declare their own.\n" +
+	"              run_if_changed: ' '\n" +
+	"              # SkipIfOnlyChanged is a regex that will result in the step being skipped\n" +
+	"              # if all changed files match that regex. It takes precedence over the\n" +
+	"              # conditions of a referenced step and applies to the steps of a chain\n" +
+	"              # which do not declare their own.\n" +
+	"              skip_if_only_changed: ' '\n" +
 	"              timeout: 0s\n" +
 	"        # Workflow is the name of the workflow to be used for this configuration. For fields defined in both\n" +
 	"        # the config and the workflow, the fields from the config will override what is set in Workflow.\n" +